@@ -2,13 +2,21 @@ package app
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/http/pprof"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -28,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	versionhelper "k8s.io/apimachinery/pkg/version"
 	"k8s.io/apiserver/pkg/server/healthz"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
@@ -38,18 +47,35 @@ import (
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	kubeturbo "github.com/turbonomic/kubeturbo/pkg"
+	"github.com/turbonomic/kubeturbo/pkg/action"
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
 	"github.com/turbonomic/kubeturbo/pkg/action/executor/gitops"
+	"github.com/turbonomic/kubeturbo/pkg/api"
+	"github.com/turbonomic/kubeturbo/pkg/audit"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/prometheus"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/processor"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/shard"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	nodeUtil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
 	agg "github.com/turbonomic/kubeturbo/pkg/discovery/worker/aggregation"
+	"github.com/turbonomic/kubeturbo/pkg/extender"
 	"github.com/turbonomic/kubeturbo/pkg/features"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
 	"github.com/turbonomic/kubeturbo/pkg/resourcemapping"
+	"github.com/turbonomic/kubeturbo/pkg/simulator"
 	"github.com/turbonomic/kubeturbo/pkg/util"
 	"github.com/turbonomic/kubeturbo/test/flag"
 	gitopsv1alpha1 "github.com/turbonomic/turbo-gitops/api/v1alpha1"
@@ -72,6 +98,21 @@ const (
 	DefaultDiscoverySampleIntervalSec = 60
 	DefaultGCIntervalMin              = 10
 	DefaultReadinessRetryThreshold    = 60
+	defaultUtilizationWindow          = 24 * time.Hour
+	DefaultKubeAPIQPS                 = 20.0
+	DefaultKubeAPIBurst               = 30
+	// Below the default termination grace period of 30 seconds of the pods
+	DefaultShutdownTimeout = 25 * time.Second
+	// How long the rediscover endpoint waits for the full discovery requested by the Turbo server, which is queued
+	// behind the discovery in progress if any
+	defaultRediscoverTimeout = 10 * time.Minute
+	// The prefix of the environment variables from which the flags are set
+	flagEnvPrefix = "KUBETURBO_"
+	// The path under which the endpoints of the scheduler extender are served
+	schedulerExtenderPath = "/scheduler-extender"
+	// The roles of kubeturbo in the sharded discovery
+	shardRoleCoordinator = "coordinator"
+	shardRoleWorker      = "worker"
 )
 
 var (
@@ -114,6 +155,13 @@ type VMTServer struct {
 	// hypervisor infrastructure: either use VM UUID or VM IP.
 	// If the underlying infrastructure is VMWare, AWS instances, or Azure instances, VM's UUID is used.
 	UseUUID bool
+	// The stitching type of the nodes: uuid, ip, or auto to select the UUID or the IP stitching for each node
+	// based on its provider ID, e.g., for a cluster with both VMware VMs and bare metal nodes. Takes precedence
+	// over UseUUID if set.
+	StitchingType string
+	// The cloud provider of the nodes, e.g., aws to stitch all the nodes by their EC2 instance IDs, in place of
+	// the provider detected from the provider ID of each node. Only used for the UUID stitching.
+	StitchProvider string
 
 	// VMPriority: priority of VM in supplyChain definition from kubeturbo, should be less than 0;
 	VMPriority int32
@@ -124,6 +172,10 @@ type VMTServer struct {
 	KubeletPort          int
 	EnableKubeletHttps   bool
 	UseNodeProxyEndpoint bool
+	// How the kubelet client authenticates to the kubelet: token, clientcert or anonymous
+	KubeletAuthMode string
+	// The timeout in seconds of a request to the kubelet of a node
+	KubeletTimeoutSec int
 
 	// The cluster processor related config
 	ValidationWorkers int
@@ -189,6 +241,194 @@ type VMTServer struct {
 	CpuFrequencyGetterPullSecret string
 	// Cleanup resources created in the SCC impersonation
 	CleanupSccRelatedResources bool
+
+	// The max random delay before the first registration and discovery, used to spread the
+	// load on the Turbo server when many kubeturbo instances restart at the same time
+	StartupJitter time.Duration
+
+	// The label used to group workload controllers, services and pods into business applications
+	BusinessAppLabel string
+
+	// The cgroup version of the nodes, used to select the memory metrics reported by the kubelet
+	CgroupVersion string
+
+	// Whether to record the result of the most recent action on the target object annotations
+	AnnotateActionResults bool
+	// Whether the description of the refused actions starts with the refusal reason
+	RefusalReasonPrefix bool
+	// Whether to emit a Kubernetes event with the result of each action on the target object
+	RecordActionEvents bool
+
+	// Whether to collect the swap usage of nodes and containers from cAdvisor
+	CollectSwapMetrics bool
+	// Whether to collect the usage of nodes, pods and containers from the Kubernetes metrics API
+	CollectMetricsServerUsage bool
+
+	// The address of the Kubernetes API server (e.g. a read replica) used by discovery to list resources.
+	// Actions always use the primary API server given by --k8s-master.
+	DiscoveryMaster string
+
+	// The number and the max burst of queries per second to the API server of each cluster, shared by the discovery
+	// and the actions
+	KubeAPIQPS   float32
+	KubeAPIBurst int
+
+	// The percentile of the container usage over the utilization window reported as the used value
+	UtilizationPercentile float64
+	// The duration of the window of the container usage samples kept across discovery cycles
+	UtilizationWindow time.Duration
+	// The max number of container usage samples kept per container resource
+	UtilizationMaxSamples int
+	// The max number of container resources with usage samples
+	UtilizationMaxEntities int
+
+	// The SOCKS5 proxy used to reach the kubelet endpoints of nodes behind a private network
+	MetricsSOCKSProxy string
+
+	// How the entity properties with the same namespace and name but different values are resolved
+	PropertyConflictPolicy string
+	// The max number of properties of an entity
+	MaxEntityProperties int
+
+	// Whether to tag each discovery with the schema version of the DTOs
+	EmitSchemaVersion bool
+
+	// Whether to skip the actions while the last discovery is degraded
+	SkipActionsOnDegradedDiscovery bool
+
+	// The max number of entities of a discovery, per entity type and in total
+	MaxEntities string
+
+	// The entity types left out of the supply chain and the discovery
+	DisabledEntityTypes string
+
+	// The resize mode of the ContainerSpecs of the injected sidecars
+	SidecarResizeMode string
+	// How the resizes of the workload controllers resized by a VerticalPodAutoscaler coexist with it
+	VPACoexistenceMode string
+
+	// The kubelet endpoint from which the cpu and memory usage is collected
+	KubeletMetrics string
+
+	// The Prometheus server from which the usage of pods and containers is collected, and its queries
+	PrometheusServerURL   string
+	PrometheusCPUQuery    string
+	PrometheusMemoryQuery string
+	PrometheusQueryStep   time.Duration
+	// The Prometheus server from which the metrics of the services of the Istio service mesh are collected, and
+	// its queries
+	ServiceMeshPrometheusURL     string
+	ServiceMeshTransactionQuery  string
+	ServiceMeshResponseTimeQuery string
+	// The monitoring sources, from the highest priority to the lowest, when more than one collects the same metric
+	MonitoringSourcePriority []string
+
+	// How long to wait for the rollout of a workload controller resize
+	ResizeRolloutTimeout time.Duration
+	// The guardrails of the container resize actions: the minimum CPU and memory, and the maximum change in percent
+	ResizeMinCPU           string
+	ResizeMinMemory        string
+	ResizeMaxChangePercent int
+
+	// The interval of the incremental discoveries which report the pods started or deleted between the full
+	// discoveries, disabled if 0
+	IncrementalDiscoveryIntervalSec int
+
+	// Whether the actions are executed, or only logged with the plan of what would be done
+	ActionMode string
+
+	// Whether to skip verifying the certificate of the Turbo server when no CA bundle is configured
+	InsecureSkipVerify bool
+
+	// How the node suspend actions are executed: by scaling down the machine set, or by cordoning or draining the node
+	NodeSuspendMode string
+	// The maximum number of the nodes drained at the same time
+	MaxConcurrentNodeDrains int
+
+	// How the pods of the ReplicaSets are moved: by cloning the pod, or by scaling up the controller of the pod
+	PodMoveStrategy string
+	// How long a move waits for the new pod to be registered in the endpoints of the services of the pod
+	MoveEndpointsTimeout time.Duration
+	// Who places the cloned pods of the moves: the Turbo server, or the scheduler of the pods
+	MovePlacement string
+
+	// The directory of the kubeconfig files of the clusters managed by this kubeturbo, each discovered as a
+	// separate target
+	KubeConfigDir string
+
+	// The name of the target of the cluster, which overrides the targetName of the TAP config
+	ClusterName string
+
+	// The ConfigMap, as [namespace/]name, holding the policy of the workloads excluded from the actions
+	ActionPolicyConfigMap string
+
+	// The maximum number of the actions of each category executed at once, by category
+	MaxConcurrentActions map[string]int
+	// How long an action waits for the concurrent actions of its category to complete before being refused
+	ActionQueueTimeout time.Duration
+
+	// How long to wait for the actions and the discovery in progress to complete when kubeturbo shuts down
+	ShutdownTimeout time.Duration
+
+	// The directory to which the response of each full discovery is written, none if empty
+	DumpDTOsDir string
+	// The file of the bearer token of the /debug/discovery, /debug/audit and /rediscover endpoints, which are not
+	// served if empty
+	DebugTokenFile string
+
+	// Where the audit log of the actions and the discoveries is kept, file or configmap, disabled if empty
+	AuditLogSink string
+	// The file of the file sink, and the name of the ConfigMap in the namespace of kubeturbo of the configmap sink
+	AuditLogFile      string
+	AuditLogConfigMap string
+	// The number of the latest events of the audit log kept in memory and in the ConfigMap
+	AuditLogMaxEvents int
+	// The audit log shared by the pipelines, nil if disabled
+	auditLog *audit.Log
+
+	// The directory of the files keeping the state of the actions across the restarts, in memory only if empty
+	StateDir string
+
+	// The role of kubeturbo in the sharded discovery, coordinator or worker, not sharded if empty
+	DiscoveryShardRole string
+	// The base URLs of the internal API of the shard workers of the coordinator
+	DiscoveryShardWorkers []string
+
+	// The port of the internal API between the kubeturbo components, which is not served if 0
+	InternalAPIPort int
+	// The PEM encoded certificate, key and CA of the mutual TLS of the internal API
+	InternalAPICertFile string
+	InternalAPIKeyFile  string
+	InternalAPICAFile   string
+
+	// Whether the filter and prioritize endpoints of the scheduler extender are served
+	EnableSchedulerExtender bool
+	// The utilization above which the scheduler extender filters out the nodes
+	SchedulerExtenderMaxUtilization float64
+
+	// Whether discovery reads the pods, nodes, services, endpoints and workload controllers from the local cache of
+	// shared informers instead of listing them from the API server in each discovery
+	InformerCache bool
+
+	// How long the response of a full discovery is sent again for the following full discoveries instead of
+	// discovering the cluster again, disabled if zero
+	DiscoverySnapshotReuseWindow time.Duration
+
+	// The maximum number of the consecutive full discoveries reported to the Turbo server as unchanged, without their
+	// entities, disabled if zero
+	MaxUnchangedDiscoveries int
+
+	// Whether the pods of the Jobs are movable, suspendable and provisionable like the pods of the other workloads
+	IncludeBatchWorkloads bool
+
+	// The infrastructure namespaces, and how their pods are modeled
+	InfrastructureNamespaces string
+	InfrastructurePodsMode   string
+
+	// Whether kubeturbo connects to an in-process simulated Turbo server instead of the server of the TAP config
+	Simulate bool
+	// The simulated Turbo server, nil if not simulating
+	simulator *simulator.MediationServer
 }
 
 // NewVMTServer creates a new VMTServer with default parameters
@@ -206,7 +446,8 @@ func NewVMTServer() *VMTServer {
 func (s *VMTServer) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ClusterKeyInjected, "cluster-key-injected", "", "Injected cluster key to enable pod move across cluster")
 	fs.IntVar(&s.Port, "port", s.Port, "The port that kubeturbo's http service runs on.")
-	fs.StringVar(&s.Address, "ip", s.Address, "the ip address that kubeturbo's http service runs on.")
+	fs.StringVar(&s.Address, "ip", s.Address, "the ip address that kubeturbo's http service runs on, or an IPv4 "+
+		"and an IPv6 address separated by a comma on a dual-stack cluster, e.g. 0.0.0.0,::")
 	// TODO: The flagset that is included by vendoring k8s uses the same names i.e. "master" and "kubeconfig".
 	// This for some reason conflicts with the names introduced by kubeturbo after upgrading the k8s vendored code
 	// to version 1.19.1. Right now we have changed the names of kubeturbo flags as a quick fix. These flags are
@@ -216,11 +457,16 @@ func (s *VMTServer) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.K8sTAPSpec, "turboconfig", s.K8sTAPSpec, "Path to the config file.")
 	fs.StringVar(&s.TestingFlagPath, "testingflag", s.TestingFlagPath, "Path to the testing flag.")
 	fs.StringVar(&s.KubeConfig, "k8s-kubeconfig", s.KubeConfig, "Path to kubeconfig file with authorization and master location information.")
+	fs.StringVar(&s.ClusterName, "cluster-name", "", "The name of the cluster, e.g. prod-east, which names its target as Kubernetes-prod-east and its probe as Kubernetes Probe Kubernetes-prod-east in the Turbonomic UI, so that the clusters of several kubeturbo instances are distinguishable. Overrides the targetName of the TAP config, which defaults to the address of the API server. Cannot be used with --k8s-kubeconfig-dir.")
+	fs.StringVar(&s.KubeConfigDir, "k8s-kubeconfig-dir", s.KubeConfigDir, "Path to a directory of kubeconfig files, one per cluster. Each cluster is discovered as a separate target named after its file without the extension, e.g. Kubernetes-prod-east for prod-east.yaml. The clusters with an invalid kubeconfig are skipped. Cannot be used with --k8s-kubeconfig, --k8s-master or --discovery-master.")
 	fs.BoolVar(&s.EnableProfiling, "profiling", false, "Enable profiling via web interface host:port/debug/pprof/.")
 	fs.BoolVar(&s.UseUUID, "stitch-uuid", true, "Use VirtualMachine's UUID to do stitching, otherwise IP is used.")
+	fs.StringVar(&s.StitchingType, "stitching-type", "", "The property used to stitch the nodes with the VMs of the underlying infrastructure: uuid, ip, or auto. With auto, the UUID is used for the nodes whose provider ID identifies a vSphere VM or an AWS, Azure or GCP instance, and the IP is used for the other nodes, e.g., bare metal nodes. Overrides --stitch-uuid if set.")
+	fs.StringVar(&s.StitchProvider, "stitch-provider", "", "The cloud provider used to build the stitching UUIDs of all the nodes: aws, azure, gcp, vsphere, or default for the system UUID. With aws, the nodes are stitched by the EC2 instance IDs parsed from their provider IDs, in the same form as the VMs discovered by the AWS probe. If not set, the provider is detected from the provider ID of each node.")
 	fs.IntVar(&s.KubeletPort, "kubelet-port", DefaultKubeletPort, "The port of the kubelet runs on.")
 	fs.BoolVar(&s.EnableKubeletHttps, "kubelet-https", DefaultKubeletHttps, "Indicate if Kubelet is running on https server.")
 	fs.BoolVar(&s.UseNodeProxyEndpoint, "use-node-proxy-endpoint", false, "Indicate if Kubelet queries should be routed through APIServer node proxy endpoint.")
+	fs.StringVar(&s.KubeletAuthMode, "kubelet-auth-mode", "", "How kubeturbo authenticates to the kubelet. One of token|clientcert|anonymous. If not set, all the credentials found in the kube config are used.")
 	fs.BoolVar(&s.ForceSelfSignedCerts, "kubelet-force-selfsigned-cert", true, "Indicate if we must use self-signed cert.")
 	fs.BoolVar(&s.FailVolumePodMoves, "fail-volume-pod-moves", true, "Indicate if kubeturbo should fail to move pods which have volumes attached. Default is set to true.")
 	fs.BoolVar(&s.UpdateQuotaToAllowMoves, "update-quota-to-allow-moves", true, "Indicate if kubeturbo should try to update namespace quotas to allow pod moves when quota(s) is/are full. Default is set to true.")
@@ -229,11 +475,11 @@ func (s *VMTServer) AddFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&s.DiscoveryIntervalSec, "discovery-interval-sec", defaultDiscoveryIntervalSec, "The discovery interval in seconds.")
 	fs.IntVar(&s.ValidationWorkers, "validation-workers", DefaultValidationWorkers, "The validation workers")
 	fs.IntVar(&s.ValidationTimeout, "validation-timeout-sec", DefaultValidationTimeout, "The validation timeout in seconds.")
-	fs.IntVar(&s.DiscoveryWorkers, "discovery-workers", DefaultDiscoveryWorkers, "The number of discovery workers.")
+	fs.IntVar(&s.DiscoveryWorkers, "discovery-workers", DefaultDiscoveryWorkers, "The number of discovery workers, i.e., the number of nodes whose kubelets are scraped concurrently in a full discovery. The sampling discoveries use twice as many workers. Increase it to shorten the discovery of large clusters.")
 	fs.IntVar(&s.DiscoveryTimeoutSec, "discovery-timeout-sec", DefaultDiscoveryTimeoutSec, "The discovery timeout in seconds for each discovery worker.")
 	fs.IntVar(&s.DiscoverySamples, "discovery-samples", DefaultDiscoverySamples, "The number of resource usage data samples to be collected from kubelet in each full discovery cycle. This should be no larger than 60.")
 	fs.IntVar(&s.DiscoverySampleIntervalSec, "discovery-sample-interval", DefaultDiscoverySampleIntervalSec, "The discovery interval in seconds to collect additional resource usage data samples from kubelet. This should be no smaller than 10 seconds.")
-	fs.IntVar(&s.GCIntervalMin, "garbage-collection-interval", DefaultGCIntervalMin, "The garbage collection interval in minutes for possible leaked pods from actions failed because of kubeturbo restarts. Default value is 20 mins.")
+	fs.IntVar(&s.GCIntervalMin, "garbage-collection-interval", DefaultGCIntervalMin, "The garbage collection interval in minutes for possible leaked pods, and machines left marked for deletion, from actions failed because of kubeturbo restarts. Default value is 20 mins.")
 	fs.IntVar(&s.ItemsPerListQuery, "items-per-list-query", 0, "Number of workload controller items the list api call should request for.")
 	fs.StringSliceVar(&s.sccSupport, "scc-support", defaultSccSupport, "The SCC list allowed for executing pod actions, e.g., --scc-support=restricted,anyuid or --scc-support=* to allow all. Default allowed scc is [*].")
 	// So far we have noticed cluster api support only in openshift clusters and our implementation works only for openshift
@@ -255,6 +501,132 @@ func (s *VMTServer) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.CpuFrequencyGetterImage, "cpufreqgetter-image", "icr.io/cpopen/turbonomic/cpufreqgetter", "The complete cpufreqgetter image uri used for fallback node cpu frequency getter job.")
 	fs.StringVar(&s.CpuFrequencyGetterPullSecret, "cpufreqgetter-image-pull-secret", "", "The name of the secret that stores the image pull credentials for cpufreqgetter image.")
 	fs.BoolVar(&s.CleanupSccRelatedResources, "cleanup-scc-impersonation-resources", true, "Enable cleanup the resources for scc impersonation.")
+	fs.StringVar(&s.BusinessAppLabel, "business-app-label", "", "The label (e.g. app.kubernetes.io/part-of) whose value groups workload controllers, services and pods in the same namespace into a business application. Disabled if empty.")
+	fs.StringVar(&s.CgroupVersion, "cgroup-version", string(kubelet.CgroupVersionAuto), "The cgroup version of the nodes, one of auto|v1|v2. With auto, the version is detected from the OS image of each node; set v1 or v2 to override the detection.")
+	fs.BoolVar(&s.RefusalReasonPrefix, "action-refusal-reason-prefix", false, "Start the description of the refused actions sent to the Turbo server with the refusal reason, e.g. [POD_ALREADY_ON_HOST].")
+	fs.BoolVar(&s.AnnotateActionResults, "annotate-action-results", false, "Record the most recent action, its time and its result on the target pod or workload controller with the annotations kubeturbo.io/last-action, kubeturbo.io/last-action-time and kubeturbo.io/last-action-result.")
+	fs.BoolVar(&s.RecordActionEvents, "record-action-events", false, "Emit a Kubernetes event with the action type, the action uuid and the outcome of each executed action on the target pod or workload controller, which shows up in kubectl describe. A failed or refused action emits a Warning event with the reason.")
+	fs.BoolVar(&s.CollectSwapMetrics, "collect-swap-metrics", false, "Collect the swap usage of nodes and containers from the kubelet cAdvisor endpoint during full discovery, and report it as the SwapUsedKB entity property. Nodes without swap metrics are skipped.")
+	fs.BoolVar(&s.CollectMetricsServerUsage, "collect-metrics-server-usage", true, "Collect the cpu and memory usage of nodes, pods and containers from the Kubernetes metrics API (metrics.k8s.io) served by the metrics-server, to backfill the usage which cannot be collected from the kubelet, per --monitoring-source-priority. Nothing is collected if the metrics-server is not installed.")
+	fs.Float32Var(&s.KubeAPIQPS, "kube-api-qps", DefaultKubeAPIQPS, "The number of queries per second to the API server of each cluster. The queries of the discovery and of the actions share the same rate limit, so that kubeturbo does not overload the API server of a large cluster. The API server given by --discovery-master has a rate limit of its own.")
+	fs.IntVar(&s.KubeAPIBurst, "kube-api-burst", DefaultKubeAPIBurst, "The max burst of queries to the API server of each cluster above --kube-api-qps.")
+	fs.BoolVar(&s.InformerCache, "informer-cache", true, "Read the pods, nodes, services, endpoints and workload controllers from the local cache of shared informers, which watch them for changes, instead of listing them from the API server in each discovery. It keeps the load of the API server flat regardless of the size of the cluster, at the cost of keeping the resources in memory between the discoveries. A resource is listed from the API server until its cache has synced.")
+	fs.BoolVar(&s.IncludeBatchWorkloads, "include-batch-workloads", false, "Include the pods of the Jobs, including the ones created by CronJobs, in the market like the pods of the other workloads. By default they are neither movable, suspendable nor provisionable, as they complete on their own.")
+	fs.StringVar(&s.InfrastructureNamespaces, "infrastructure-namespaces", configs.DefaultInfrastructureNamespaces, "The infrastructure namespaces, e.g. of the control plane addons, whose pods are modeled per --infrastructure-pods-mode, as a comma separated list of regular expressions matching the whole namespace names, e.g. kube-system,openshift-.*. Also set by infrastructurePods.namespaces in the TAP config, which takes precedence.")
+	fs.StringVar(&s.InfrastructurePodsMode, "infrastructure-pods-mode", configs.InfrastructurePodsWorkload, "How the pods of the --infrastructure-namespaces are modeled: workload (like the pods of the other namespaces, with their actions), overhead (as the overhead of their nodes, discovered with their usage but neither movable, suspendable, provisionable nor resizable, along with their workload controllers) or excluded (left out of the discovery with their namespaces and workloads, their usage and requests only accounted in their nodes). Also set by infrastructurePods.mode in the TAP config, which takes precedence.")
+	fs.BoolVar(&s.Simulate, "simulate", false, "Connect to an in-process simulated Turbo server instead of the server of the Turbo config, to try kubeturbo on a cluster without a Turbonomic instance. The simulated server registers the probe and requests a full discovery every --discovery-interval-sec, logging the number of the discovered entities of each type. No action is sent, and the target is not added through the Turbo API.")
+	fs.StringVar(&s.DiscoveryMaster, "discovery-master", s.DiscoveryMaster, "The address of the Kubernetes API server, e.g. a read replica, used by discovery to list resources. Actions are always executed against the API server given by --k8s-master or kubeconfig. If not set, discovery uses the same API server as actions.")
+	fs.Float64Var(&s.UtilizationPercentile, "utilization-percentile", 0, "The percentile (e.g. 95) of the container CPU and memory usage over the --utilization-window to report as the used value, so that periodic spikes that do not show up in a single discovery interval are accounted for in resize decisions. Disabled if 0.")
+	fs.DurationVar(&s.UtilizationWindow, "utilization-window", defaultUtilizationWindow, "The duration of the rolling window of container usage samples kept across discovery cycles when --utilization-percentile is set. The number of retained samples is capped by --utilization-max-samples and --utilization-max-entities to bound the memory usage.")
+	fs.IntVar(&s.UtilizationMaxSamples, "utilization-max-samples", metrics.DefaultMaxUtilizationSamples, "The max number of usage samples kept per container resource in the --utilization-window. The oldest samples are dropped first. The default is used if 0.")
+	fs.IntVar(&s.UtilizationMaxEntities, "utilization-max-entities", metrics.DefaultMaxUtilizationEntities, "The max number of container resources with usage samples kept in the --utilization-window. The container resources updated least recently are evicted first. The default is used if 0.")
+	fs.StringVar(&s.MetricsSOCKSProxy, "metrics-socks-proxy", "", "The SOCKS5 proxy, in the form of socks5://[user:password@]host:port, used to reach the kubelet metric endpoints of nodes which are only reachable through a bastion or a tunnel, e.g. opened with ssh -D. Nodes are skipped in a discovery if the proxy is unreachable. Disabled if empty.")
+	fs.StringVar(&s.PropertyConflictPolicy, "property-conflict-policy", string(property.ConflictPolicyLastWins), "How the single-valued entity properties set by kubeturbo (e.g. KubernetesNodeName) with the same namespace and name but different values are resolved when the duplicate properties are removed from the discovered entities, one of last|first. The other properties, e.g. the tags, only have their identical duplicates removed. With last, the property added last wins.")
+	fs.IntVar(&s.MaxEntityProperties, "max-entity-properties", 0, "The max number of properties of a discovered entity. The excess tags (e.g. labels) are dropped, last first; the properties used to identify and stitch the entities, and the PersistentVolumeAttached tags, are always kept. No limit if 0.")
+	fs.BoolVar(&s.EmitSchemaVersion, "emit-schema-version", true, "Tag each discovery response with the schema version of the entities built by kubeturbo, so that the server can tell which schema produced a discovery.")
+	fs.BoolVar(&s.SkipActionsOnDegradedDiscovery, "skip-actions-on-degraded-discovery", false, "Refuse to execute actions while the last discovery is degraded, e.g. when the API server dropped the watches during the discovery, to avoid acting on stale or inconsistent data. A degraded discovery is always reported to the server as a warning.")
+	fs.StringVar(&s.DisabledEntityTypes, "disabled-entity-types", "", "The entity types left out of the supply chain and the discovery, as a comma separated list of services, namespaces, volumes and workloadcontrollers, to trade the richness of the topology for the cost of the discovery. The load balancers are left out with the services. The pods are then discovered without the commodities bought from the disabled entities, e.g. the quotas of their namespaces, and no action is generated for the disabled entities, e.g. the resizes of the workload controllers. Also set by disabledEntityTypes in the TAP config, the types disabled by either being left out. Default is empty (all the entity types are discovered).")
+	fs.StringVar(&s.SidecarResizeMode, "sidecar-resize-mode", worker.SidecarResizeRecommend, "The resize mode of the ContainerSpecs of the sidecar containers injected into the pods, e.g. the service mesh proxies, which are detected as the containers missing from the template of the workload controller of their pod, and so cannot be resized through it: recommend (the resizes are recommended only) or disabled (no resize is recommended). The other containers of the pods are resized individually.")
+	fs.StringVar(&s.VPACoexistenceMode, "vpa-coexistence-mode", worker.VPACoexistenceSuppress, "How the resizes of the workload controllers whose pods are resized by a VerticalPodAutoscaler, in any update mode but Off, coexist with it, to prevent the two from resizing the same containers back and forth: suppress (no resize is recommended), recommend (the resizes are recommended only, and never executed) or ignore (the VerticalPodAutoscalers are not discovered).")
+	fs.StringVar(&s.MaxEntities, "max-entities", "", "The max number of entities of a discovery, as a comma separated list of <entity type>=<max>, e.g. total=100000,CONTAINER_POD=50000, where total caps the entities of all types. The entities above the limits are dropped in a stable order, the same in each discovery, and the discovery is reported as degraded. No limit if empty.")
+	fs.StringVar(&s.KubeletMetrics, "kubelet-metrics", string(kubelet.MetricsSourceSummary), "The kubelet endpoint from which the cpu and memory usage of nodes, pods and containers is collected, one of summary|cadvisor. summary uses the kubelet summary API (/stats/summary); cadvisor uses the cAdvisor metrics exposed by the kubelet (/metrics/cadvisor) as a fallback, in which case the cpu usage is only available from the second discovery on.")
+	fs.StringVar(&s.PrometheusServerURL, "prometheus-server-url", "", "The URL of a Prometheus server (e.g. http://prometheus.monitoring:9090) from which the cpu and memory usage of pods and containers is collected, e.g. on clusters where the kubelet stats are restricted. The usage missing from Prometheus is backfilled from the kubelet, per --monitoring-source-priority. The kubelet is still used for the node metrics. Disabled if empty.")
+	fs.StringVar(&s.PrometheusCPUQuery, "prometheus-cpu-query", prometheus.DefaultCPUQuery, "The Prometheus query of the cpu usage of the containers in cores, whose series are labeled with namespace, pod and container.")
+	fs.StringVar(&s.PrometheusMemoryQuery, "prometheus-memory-query", prometheus.DefaultMemoryQuery, "The Prometheus query of the memory usage of the containers in bytes, whose series are labeled with namespace, pod and container.")
+	fs.StringSliceVar(&s.MonitoringSourcePriority, "monitoring-source-priority", monitoringSourceNames(monitoring.DefaultSourcePriority), "The monitoring sources, from the highest priority to the lowest. A metric collected by more than one source is taken from the source with the highest priority, and the metrics missing from a source are backfilled from the sources with a lower priority. The sources not listed come last.")
+	fs.DurationVar(&s.PrometheusQueryStep, "prometheus-query-step", prometheus.DefaultQueryStep, "The resolution of the Prometheus range queries. The latest sample within the last step is used.")
+	fs.StringVar(&s.ServiceMeshPrometheusURL, "service-mesh-prometheus-url", "", "The URL of a Prometheus server scraping the Envoy sidecars of the Istio service mesh, from which the request rate and the response time of the services are collected. The services whose pods have the Istio sidecar then sell the transaction and response time commodities, so that their SLOs can drive the horizontal scaling of the pods. The resolution of the queries is --prometheus-query-step. Disabled if empty.")
+	fs.StringVar(&s.ServiceMeshTransactionQuery, "service-mesh-transaction-query", prometheus.DefaultTransactionQuery, "The Prometheus query of the request rate of the services of the service mesh in requests per second, whose series are labeled with destination_service_namespace and destination_service_name.")
+	fs.StringVar(&s.ServiceMeshResponseTimeQuery, "service-mesh-response-time-query", prometheus.DefaultResponseTimeQuery, "The Prometheus query of the response time of the services of the service mesh in milliseconds, whose series are labeled with destination_service_namespace and destination_service_name.")
+	fs.DurationVar(&s.ResizeRolloutTimeout, "resize-rollout-timeout", 0, "How long to wait for the pods of a deployment, stateful set or daemon set to roll out after its containers are resized (e.g. 10m). The rollout progress is reported with the action, which fails if the rollout does not complete in time or exceeds its progress deadline. Default is 0 (the action completes once the workload controller is updated).")
+	fs.StringVar(&s.ResizeMinCPU, "resize-min-cpu", "", "The minimum CPU request or limit a container resize action may set (e.g. 10m). The resize actions below it are refused with the reason RESIZE_LIMIT_VIOLATION. No minimum if empty.")
+	fs.StringVar(&s.ResizeMinMemory, "resize-min-memory", "", "The minimum memory request or limit a container resize action may set (e.g. 16Mi). The resize actions below it are refused with the reason RESIZE_LIMIT_VIOLATION. No minimum if empty.")
+	fs.IntVar(&s.ResizeMaxChangePercent, "resize-max-change-percent", 0, "The maximum change of a CPU or memory request or limit by a container resize action, in percent of its current value (e.g. 50). The resize actions changing it more are refused with the reason RESIZE_LIMIT_VIOLATION. Default is 0 (no maximum). The resize actions are also refused when the resized pod would exceed the allocatable resources of its node, or of the largest node for a workload controller, or the namespace limit range.")
+	fs.IntVar(&s.IncrementalDiscoveryIntervalSec, "incremental-discovery-interval-sec", 0, "The interval in seconds of the incremental discoveries, which report the pods started or deleted since the last discovery so that the new pods get actions before the next full discovery. The pods of the cluster are watched if set. The minimum interval is 60 seconds. Default is 0 (no incremental discovery).")
+	fs.IntVar(&s.KubeletTimeoutSec, "kubelet-timeout-sec", kubeclient.DefaultKubeletTimeoutSec, "The timeout in seconds of a request to the kubelet of a node to scrape its metrics, directly or through the API server proxy. The scrape of each node is further bounded by --discovery-timeout-sec.")
+	fs.StringVar(&s.ActionMode, "action-mode", action.ActionModeExecute, "Whether the actions accepted from the Turbo server are executed (execute), or only logged with the plan of the changes they would make to the cluster (recommend). In the recommend mode, no action changes the cluster and each action is reported back to the server as refused with the reason RECOMMEND_MODE and the plan.")
+	fs.StringVar(&s.NodeSuspendMode, "node-suspend-mode", executor.NodeSuspendModeMachineSet, "How the node suspend actions are executed: by scaling down the machine set of the node with the cluster API (machine-set), by cordoning the node (cordon), or by cordoning the node and evicting its pods with the eviction API, which respects the pod disruption budgets (drain). The cordoned or drained nodes are left to be removed by the cluster administrator or the cluster autoscaler.")
+	fs.StringVar(&s.PodMoveStrategy, "pod-move-strategy", executor.PodMoveStrategyClone, "How the pods of the ReplicaSets are moved: clone (a clone of the pod is created on the destination before the pod is deleted) or scale-up (the controller is scaled up on the destination, then back down with the pod, which needs Kubernetes 1.22 or later).")
+	fs.StringVar(&s.MovePlacement, "move-placement", executor.MovePlacementServer, "Who places the pods cloned by the moves: server (the destination of the action) or scheduler (the scheduler of the pod).")
+	fs.DurationVar(&s.MoveEndpointsTimeout, "move-endpoints-timeout", executor.DefaultMoveEndpointsTimeout, "How long a pod move waits for the new pod, once ready, to be registered in the endpoints of the services of the moved pod. With --pod-move-strategy=scale-up, the moved pod is only deleted once the new pod is registered, and the move fails otherwise. The cloned pods get the labels selected by the services only once the moved pod is deleted, so the clone moves only log that the new pod is not registered in time. 0 disables the wait.")
+	fs.IntVar(&s.MaxConcurrentNodeDrains, "max-concurrent-node-drains", executor.DefaultMaxConcurrentNodeDrains, "The maximum number of the nodes drained at the same time with --node-suspend-mode=drain, 1 if 0. The node suspend actions beyond it are refused.")
+	fs.StringVar(&s.ActionPolicyConfigMap, "action-policy-configmap", "", "The ConfigMap, as [namespace/]name, holding the action policy under the "+action.ActionPolicyKey+" key. The policy lists the namespaces, the workload controller kinds and the pod label selectors excluded from the move, resize or scale actions, which are refused. The ConfigMap is watched for changes. The namespace of kubeturbo is used if none is given. Default is no action policy.")
+	fs.StringToIntVar(&s.MaxConcurrentActions, "max-concurrent-actions", nil, "The maximum number of the actions of each category executed at once, e.g. node=1,move=5, with the categories "+strings.Join(action.ActionCategories(), ", ")+". The actions beyond the limit of their category wait in a FIFO queue. Default is no limit.")
+	fs.DurationVar(&s.ActionQueueTimeout, "action-queue-timeout", action.DefaultActionQueueTimeout, "How long an action waits in the queue for the concurrent actions of its category to complete with --max-concurrent-actions, before being refused.")
+	fs.DurationVar(&s.ShutdownTimeout, "shutdown-timeout", DefaultShutdownTimeout, "How long to wait for the actions and the discovery in progress to complete when kubeturbo is terminated, before disconnecting from the Turbo server. No new action is accepted meanwhile. Keep it below the termination grace period of the pod.")
+	fs.BoolVar(&s.InsecureSkipVerify, "insecure-skip-verify", true, "Skip verifying the certificate of the Turbo server. If false, or if serverCABundle is set in the Turbo config, the certificate is verified at startup against the CA bundle, or the system CAs if no bundle is set, and kubeturbo does not start if the verification fails.")
+	fs.StringVar(&s.DumpDTOsDir, "dump-dtos-dir", "", "The existing directory to which the response of each full discovery sent to the Turbo server, including the entity DTOs, is written as <target>-discovery.json and <target>-discovery.proto. Default is empty (not written).")
+	fs.DurationVar(&s.DiscoverySnapshotReuseWindow, "discovery-snapshot-reuse-window", 0, "How long the response of a full discovery is sent again, without scraping the kubelets or listing the resources from the API server, for the full discoveries requested by the Turbo server after it, e.g. when plans are run against the cluster. The requests received while a full discovery is in progress are also served its response. It must be shorter than the full discovery interval so that the periodic discoveries still discover the cluster. Default is 0 (always discover the cluster).")
+	fs.IntVar(&s.MaxUnchangedDiscoveries, "max-unchanged-discoveries", 0, "The maximum number of the consecutive full discoveries reported to the Turbo server as unchanged, without their entities, when no entity was added, removed or changed beyond 5% of its used and peak values, and no group or template changed, since the last full discovery sent in full. The server then keeps the entities of the latter. Default is 0 (always send the entities).")
+	fs.StringVar(&s.DebugTokenFile, "debug-token-file", "", "The file of the bearer token of the /debug/discovery, /debug/audit and /rediscover endpoints, see docs/debug-endpoints.md. Default is empty (the endpoints are not served).")
+	fs.StringVar(&s.AuditLogSink, "audit-log-sink", "", "Where the audit log of the actions and the discoveries is kept: file (--audit-log-file) or configmap (--audit-log-configmap), see docs/audit-log.md. Default is empty (no audit log).")
+	fs.StringVar(&s.AuditLogFile, "audit-log-file", "/var/lib/kubeturbo/audit/audit.jsonl", "The file of the audit log with --audit-log-sink=file.")
+	fs.StringVar(&s.AuditLogConfigMap, "audit-log-configmap", "kubeturbo-audit-log", "The ConfigMap in the namespace of kubeturbo of the audit log with --audit-log-sink=configmap, created if missing.")
+	fs.IntVar(&s.AuditLogMaxEvents, "audit-log-max-events", 1000, "The number of the latest events of the audit log kept in memory to be served, and in the ConfigMap with --audit-log-sink=configmap.")
+	fs.StringVar(&s.StateDir, "state-dir", "", "Directory of the persistent action state (bbolt); empty keeps it in memory.")
+	fs.StringVar(&s.DiscoveryShardRole, "discovery-shard-role", "", "The role of kubeturbo in the discovery of a large cluster spread over several kubeturbo pods: coordinator or worker, see docs/discovery-shards.md. Default is empty (not sharded).")
+	fs.StringSliceVar(&s.DiscoveryShardWorkers, "discovery-shard-workers", nil, "The comma separated base URLs of the internal API of the shard workers of the coordinator, e.g. https://kubeturbo-shard-0.kubeturbo-shards:9443, typically the pods of a StatefulSet behind a headless Service.")
+	fs.IntVar(&s.InternalAPIPort, "internal-api-port", 0, "The port of the internal gRPC API between the kubeturbo components, served with mutual TLS, as defined in pkg/api/kubeturbo.proto. The coordinator of a sharded discovery calls the API of its workers to discover the nodes of their shards, and the external tools call the API of kubeturbo to get the summary (Discover) or the entities (ListEntities) of the last discovery. Required by the shard workers. Default is 0 (not served).")
+	fs.StringVar(&s.InternalAPICertFile, "internal-api-cert-file", "", "The file of the PEM encoded certificate presented by kubeturbo as the server and the client of the internal API, e.g. mounted from a Secret issued by cert-manager. Required with --internal-api-port or --discovery-shard-role.")
+	fs.StringVar(&s.InternalAPIKeyFile, "internal-api-key-file", "", "The file of the PEM encoded private key of --internal-api-cert-file. Required with --internal-api-port or --discovery-shard-role.")
+	fs.StringVar(&s.InternalAPICAFile, "internal-api-ca-file", "", "The file of the PEM encoded CA which must have issued the certificates of the peers of the internal API. Required with --internal-api-port or --discovery-shard-role.")
+	fs.BoolVar(&s.EnableSchedulerExtender, "enable-scheduler-extender", false, "Serve the host:port/scheduler-extender/filter and host:port/scheduler-extender/prioritize endpoints of a scheduler extender, which biases the initial placement of the pods toward the nodes which the last full discovery found under-utilized, so that the pods start in good locations rather than being moved later. With several clusters, the endpoints of each cluster are under host:port/scheduler-extender/<target>/. The data of the last full discovery is ignored once older than twice the discovery interval.")
+	fs.Float64Var(&s.SchedulerExtenderMaxUtilization, "scheduler-extender-max-utilization", 0.9, "The utilization of the CPU or memory of a node above which the scheduler extender filters out the node, unless all the nodes are above it.")
+	fs.DurationVar(&s.StartupJitter, "startup-jitter", 0, "The max random delay (e.g. 30s) before the first registration and discovery, to avoid many kubeturbo instances registering with the Turbo server at once. The delay happens right before connecting to the Turbo server, after the http service has started. Default is 0 (no delay).")
+}
+
+// SetFlagsFromEnv sets each flag not set on the command line from the environment variable named after it, e.g.
+// --discovery-interval-sec from KUBETURBO_DISCOVERY_INTERVAL_SEC, so that a Helm chart or an operator can configure
+// kubeturbo without templating the arguments. The flags set on the command line take precedence.
+func SetFlagsFromEnv(fs *pflag.FlagSet) error {
+	var errs []string
+	fs.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			return
+		}
+		name := flagEnvName(flag.Name)
+		value, found := os.LookupEnv(name)
+		if !found {
+			return
+		}
+		if err := fs.Set(flag.Name, value); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid value %q of %s: %v", value, name, err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// flagEnvName returns the name of the environment variable of the given flag.
+func flagEnvName(flagName string) string {
+	return flagEnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flagName))
+}
+
+// monitoringSourceNames returns the names of the given monitoring sources.
+func monitoringSourceNames(sources []types.MonitoringSource) []string {
+	var names []string
+	for _, source := range sources {
+		names = append(names, string(source))
+	}
+	return names
+}
+
+// parseActionPolicyConfigMap parses the [namespace/]name of the action policy ConfigMap, in the namespace of
+// kubeturbo if none is given.
+func parseActionPolicyConfigMap(value string) (string, string, error) {
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name := util.GetKubeturboNamespace(), value
+	if parts := strings.Split(value, "/"); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+	}
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("ActionPolicyConfigMap[%s] should be in the form of [namespace/]name.", value)
+	}
+	return namespace, name, nil
 }
 
 // create an eventRecorder to send events to Kubernetes APIserver
@@ -276,9 +648,6 @@ func (s *VMTServer) createKubeConfigOrDie() *restclient.Config {
 		glog.Errorf("Fatal error: failed to get kubeconfig:  %s", err)
 		os.Exit(1)
 	}
-	// This specifies the number and the max number of query per second to the api server.
-	kubeConfig.QPS = 20.0
-	kubeConfig.Burst = 30
 
 	return kubeConfig
 }
@@ -317,22 +686,83 @@ func (s *VMTServer) ensureBusyboxImageBackwardCompatibility() {
 func (s *VMTServer) CreateKubeletClientOrDie(kubeConfig *restclient.Config, fallbackClient *kubernetes.Clientset,
 	cpuFreqGetterImage, imagePullSecret string, cpufreqJobExcludeNodeLabels map[string]set.Set, useProxyEndpoint bool,
 ) *kubeclient.KubeletClient {
-	kubeletClient, err := kubeclient.NewKubeletConfig(kubeConfig).
+	kubeletClient, err := s.createKubeletClient(kubeConfig, fallbackClient, cpuFreqGetterImage, imagePullSecret,
+		cpufreqJobExcludeNodeLabels, useProxyEndpoint)
+	if err != nil {
+		glog.Errorf("Fatal error: failed to create kubeletClient: %v", err)
+		os.Exit(1)
+	}
+	return kubeletClient
+}
+
+// createKubeletClient creates the client of the kubelets of the cluster of the given kubeconfig.
+func (s *VMTServer) createKubeletClient(kubeConfig *restclient.Config, fallbackClient *kubernetes.Clientset,
+	cpuFreqGetterImage, imagePullSecret string, cpufreqJobExcludeNodeLabels map[string]set.Set, useProxyEndpoint bool,
+) (*kubeclient.KubeletClient, error) {
+	authMode, err := kubeclient.ParseKubeletAuthMode(s.KubeletAuthMode)
+	if err != nil {
+		return nil, err
+	}
+	return kubeclient.NewKubeletConfig(kubeConfig).
 		WithPort(s.KubeletPort).
 		EnableHttps(s.EnableKubeletHttps).
 		ForceSelfSignedCerts(s.ForceSelfSignedCerts).
-		// Timeout(to).
+		WithAuthMode(authMode).
+		WithSOCKSProxy(s.MetricsSOCKSProxy).
+		Timeout(s.KubeletTimeoutSec).
 		Create(fallbackClient, cpuFreqGetterImage, imagePullSecret, cpufreqJobExcludeNodeLabels, useProxyEndpoint)
-	if err != nil {
-		glog.Errorf("Fatal error: failed to create kubeletClient: %v", err)
-		os.Exit(1)
+}
+
+func (s *VMTServer) checkDiscoveryShardFlags() error {
+	switch s.DiscoveryShardRole {
+	case shardRoleCoordinator:
+		if len(s.DiscoveryShardWorkers) == 0 {
+			return fmt.Errorf("DiscoveryShardWorkers should be set with DiscoveryShardRole[%s].", s.DiscoveryShardRole)
+		}
+		for _, workerURL := range s.DiscoveryShardWorkers {
+			if u, err := url.Parse(workerURL); err != nil || u.Scheme != "https" || u.Host == "" {
+				return fmt.Errorf("invalid DiscoveryShardWorkers[%s], must be an absolute https URL", workerURL)
+			}
+		}
+	case shardRoleWorker:
+		if s.InternalAPIPort == 0 {
+			return fmt.Errorf("InternalAPIPort should be set with DiscoveryShardRole[%s].", s.DiscoveryShardRole)
+		}
+	default:
+		return fmt.Errorf("DiscoveryShardRole[%s] should be %s or %s.", s.DiscoveryShardRole, shardRoleCoordinator,
+			shardRoleWorker)
+	}
+	// The shard workers discover the nodes of the cluster they run in
+	if s.KubeConfigDir != "" {
+		return fmt.Errorf("DiscoveryShardRole[%s] cannot be used with --k8s-kubeconfig-dir.", s.DiscoveryShardRole)
 	}
+	return nil
+}
 
-	return kubeletClient
+func (s *VMTServer) checkInternalAPIFlags() error {
+	if s.InternalAPIPort < 0 || s.InternalAPIPort > 65535 {
+		return fmt.Errorf("InternalAPIPort[%d] should be a valid port.", s.InternalAPIPort)
+	}
+	if _, err := s.internalAPITLSFiles().ServerTLSConfig(); err != nil {
+		return fmt.Errorf("the mutual TLS of the internal API is invalid: %v.", err)
+	}
+	return nil
+}
+
+func (s *VMTServer) internalAPITLSFiles() api.TLSFiles {
+	return api.TLSFiles{
+		CertFile: s.InternalAPICertFile,
+		KeyFile:  s.InternalAPIKeyFile,
+		CAFile:   s.InternalAPICAFile,
+	}
 }
 
 func (s *VMTServer) checkFlag() error {
-	if s.KubeConfig == "" && s.Master == "" {
+	if s.KubeConfigDir != "" {
+		if s.KubeConfig != "" || s.Master != "" || s.DiscoveryMaster != "" || s.ClusterName != "" {
+			return fmt.Errorf("--k8s-kubeconfig-dir cannot be used with --k8s-kubeconfig, --k8s-master, --discovery-master or --cluster-name")
+		}
+	} else if s.KubeConfig == "" && s.Master == "" {
 		glog.Warningf("Neither --kubeconfig nor --master was specified.  Using default API client.  This might not work.")
 	}
 
@@ -344,9 +774,8 @@ func (s *VMTServer) checkFlag() error {
 		flag.SetPath(s.TestingFlagPath)
 	}
 
-	ip := net.ParseIP(s.Address)
-	if ip == nil {
-		return fmt.Errorf("wrong ip format:%s", s.Address)
+	if _, err := parseListenIPs(s.Address); err != nil {
+		return fmt.Errorf("invalid --ip: %v", err)
 	}
 
 	if s.Port < 1 {
@@ -357,108 +786,545 @@ func (s *VMTServer) checkFlag() error {
 		return fmt.Errorf("[KubeletPort[%d] should be bigger than 0.", s.KubeletPort)
 	}
 
+	if _, err := kubeclient.ParseKubeletAuthMode(s.KubeletAuthMode); err != nil {
+		return err
+	}
+
+	if _, err := kubelet.ParseCgroupVersion(s.CgroupVersion); err != nil {
+		return err
+	}
+
+	if s.Master != "" {
+		if err := validateAPIServerAddress(s.Master); err != nil {
+			return fmt.Errorf("invalid --k8s-master: %v", err)
+		}
+	}
+
+	if s.ClusterName != "" && strings.TrimSpace(s.ClusterName) != s.ClusterName {
+		return fmt.Errorf("ClusterName[%s] should not have leading or trailing spaces.", s.ClusterName)
+	}
+
+	if s.KubeAPIQPS < 0 {
+		return fmt.Errorf("KubeAPIQPS[%v] should not be negative.", s.KubeAPIQPS)
+	}
+
+	if s.KubeAPIBurst < 0 {
+		return fmt.Errorf("KubeAPIBurst[%d] should not be negative.", s.KubeAPIBurst)
+	}
+
+	if s.DiscoveryMaster != "" {
+		if err := validateAPIServerAddress(s.DiscoveryMaster); err != nil {
+			return fmt.Errorf("invalid --discovery-master: %v", err)
+		}
+	}
+
+	if s.MetricsSOCKSProxy != "" {
+		if err := kubeclient.ValidateSOCKSProxy(s.MetricsSOCKSProxy); err != nil {
+			return err
+		}
+	}
+
+	if _, err := property.ParseConflictPolicy(s.PropertyConflictPolicy); err != nil {
+		return err
+	}
+
+	if s.MaxEntityProperties < 0 {
+		return fmt.Errorf("MaxEntityProperties[%d] should not be negative.", s.MaxEntityProperties)
+	}
+
+	if s.UtilizationPercentile < 0 || s.UtilizationPercentile > 100 {
+		return fmt.Errorf("UtilizationPercentile[%v] should be between 0 and 100.", s.UtilizationPercentile)
+	}
+
+	if s.UtilizationPercentile > 0 && s.UtilizationWindow <= 0 {
+		return fmt.Errorf("UtilizationWindow[%v] should be positive.", s.UtilizationWindow)
+	}
+
+	if s.UtilizationMaxSamples < 0 {
+		return fmt.Errorf("UtilizationMaxSamples[%d] should not be negative.", s.UtilizationMaxSamples)
+	}
+
+	if s.UtilizationMaxEntities < 0 {
+		return fmt.Errorf("UtilizationMaxEntities[%d] should not be negative.", s.UtilizationMaxEntities)
+	}
+
+	if _, err := dtofactory.ParseEntityLimits(s.MaxEntities); err != nil {
+		return fmt.Errorf("invalid MaxEntities[%s]: %v", s.MaxEntities, err)
+	}
+
+	if s.VPACoexistenceMode != "" && s.VPACoexistenceMode != worker.VPACoexistenceSuppress &&
+		s.VPACoexistenceMode != worker.VPACoexistenceRecommend && s.VPACoexistenceMode != worker.VPACoexistenceIgnore {
+		return fmt.Errorf("VPACoexistenceMode[%s] should be one of %s, %s or %s.", s.VPACoexistenceMode,
+			worker.VPACoexistenceSuppress, worker.VPACoexistenceRecommend, worker.VPACoexistenceIgnore)
+	}
+
+	if s.SidecarResizeMode != "" && s.SidecarResizeMode != worker.SidecarResizeRecommend &&
+		s.SidecarResizeMode != worker.SidecarResizeDisabled {
+		return fmt.Errorf("SidecarResizeMode[%s] should be either %s or %s.", s.SidecarResizeMode,
+			worker.SidecarResizeRecommend, worker.SidecarResizeDisabled)
+	}
+
+	if _, err := configs.ParseDisabledEntityTypes(s.DisabledEntityTypes); err != nil {
+		return fmt.Errorf("invalid DisabledEntityTypes[%s]: %v", s.DisabledEntityTypes, err)
+	}
+
+	if _, err := configs.ParseInfrastructurePods(s.InfrastructureNamespaces, s.InfrastructurePodsMode); err != nil {
+		return fmt.Errorf("invalid InfrastructurePods[%s %s]: %v", s.InfrastructurePodsMode,
+			s.InfrastructureNamespaces, err)
+	}
+
+	if _, err := kubelet.ParseMetricsSource(s.KubeletMetrics); err != nil {
+		return err
+	}
+
+	if s.PrometheusServerURL != "" {
+		if u, err := url.Parse(s.PrometheusServerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid PrometheusServerURL[%s], must be an absolute URL", s.PrometheusServerURL)
+		}
+		if s.PrometheusQueryStep <= 0 {
+			return fmt.Errorf("PrometheusQueryStep[%v] should be positive.", s.PrometheusQueryStep)
+		}
+	}
+
+	if s.ServiceMeshPrometheusURL != "" {
+		if u, err := url.Parse(s.ServiceMeshPrometheusURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid ServiceMeshPrometheusURL[%s], must be an absolute URL",
+				s.ServiceMeshPrometheusURL)
+		}
+		if s.PrometheusQueryStep <= 0 {
+			return fmt.Errorf("PrometheusQueryStep[%v] should be positive.", s.PrometheusQueryStep)
+		}
+	}
+
+	if s.StitchingType != "" {
+		if _, err := stitching.ParseStitchingPropertyType(s.StitchingType); err != nil {
+			return err
+		}
+	}
+
+	if s.StitchProvider != "" {
+		if _, err := stitching.NewNodeUUIDGetter(s.StitchProvider); err != nil {
+			return err
+		}
+	}
+
+	if s.ResizeRolloutTimeout < 0 {
+		return fmt.Errorf("ResizeRolloutTimeout[%v] should not be negative.", s.ResizeRolloutTimeout)
+	}
+
+	if _, err := executor.ParseResizeLimits(s.ResizeMinCPU, s.ResizeMinMemory, s.ResizeMaxChangePercent); err != nil {
+		return fmt.Errorf("invalid resize limits: %v", err)
+	}
+
+	if s.KubeletTimeoutSec < 0 {
+		return fmt.Errorf("KubeletTimeoutSec[%d] should not be negative.", s.KubeletTimeoutSec)
+	}
+
+	if s.IncrementalDiscoveryIntervalSec < 0 {
+		return fmt.Errorf("IncrementalDiscoveryIntervalSec[%d] should not be negative.", s.IncrementalDiscoveryIntervalSec)
+	}
+
+	if s.ActionMode != "" && s.ActionMode != action.ActionModeExecute && s.ActionMode != action.ActionModeRecommend {
+		return fmt.Errorf("ActionMode[%s] should be either %s or %s.", s.ActionMode, action.ActionModeExecute,
+			action.ActionModeRecommend)
+	}
+
+	if s.NodeSuspendMode != "" && s.NodeSuspendMode != executor.NodeSuspendModeMachineSet &&
+		s.NodeSuspendMode != executor.NodeSuspendModeCordon && s.NodeSuspendMode != executor.NodeSuspendModeDrain {
+		return fmt.Errorf("NodeSuspendMode[%s] should be one of %s, %s or %s.", s.NodeSuspendMode,
+			executor.NodeSuspendModeMachineSet, executor.NodeSuspendModeCordon, executor.NodeSuspendModeDrain)
+	}
+
+	if s.PodMoveStrategy != "" && s.PodMoveStrategy != executor.PodMoveStrategyClone &&
+		s.PodMoveStrategy != executor.PodMoveStrategyScaleUp {
+		return fmt.Errorf("PodMoveStrategy[%s] should be either %s or %s.", s.PodMoveStrategy,
+			executor.PodMoveStrategyClone, executor.PodMoveStrategyScaleUp)
+	}
+
+	if s.MovePlacement != "" && s.MovePlacement != executor.MovePlacementServer &&
+		s.MovePlacement != executor.MovePlacementScheduler {
+		return fmt.Errorf("MovePlacement[%s] should be either %s or %s.", s.MovePlacement,
+			executor.MovePlacementServer, executor.MovePlacementScheduler)
+	}
+
+	if s.MoveEndpointsTimeout < 0 {
+		return fmt.Errorf("MoveEndpointsTimeout[%v] should not be negative.", s.MoveEndpointsTimeout)
+	}
+
+	if s.MaxConcurrentNodeDrains < 0 {
+		return fmt.Errorf("MaxConcurrentNodeDrains[%d] should not be negative.", s.MaxConcurrentNodeDrains)
+	}
+
+	if s.StartupJitter < 0 {
+		return fmt.Errorf("StartupJitter[%v] should not be negative.", s.StartupJitter)
+	}
+
+	if _, _, err := parseActionPolicyConfigMap(s.ActionPolicyConfigMap); err != nil {
+		return err
+	}
+
+	if err := action.ValidateActionLimits(s.MaxConcurrentActions); err != nil {
+		return fmt.Errorf("MaxConcurrentActions[%v] is invalid: %v.", s.MaxConcurrentActions, err)
+	}
+
+	if _, err := monitoring.ParseSourcePriority(s.MonitoringSourcePriority); err != nil {
+		return fmt.Errorf("MonitoringSourcePriority[%s] is invalid: %v.", strings.Join(s.MonitoringSourcePriority, ","),
+			err)
+	}
+
+	if s.ShutdownTimeout < 0 {
+		return fmt.Errorf("ShutdownTimeout[%v] should not be negative.", s.ShutdownTimeout)
+	}
+
+	if len(s.MaxConcurrentActions) > 0 && s.ActionQueueTimeout <= 0 {
+		return fmt.Errorf("ActionQueueTimeout[%v] should be positive.", s.ActionQueueTimeout)
+	}
+
+	if s.MaxUnchangedDiscoveries < 0 {
+		return fmt.Errorf("MaxUnchangedDiscoveries[%d] should not be negative.", s.MaxUnchangedDiscoveries)
+	}
+	if s.DiscoverySnapshotReuseWindow < 0 {
+		return fmt.Errorf("DiscoverySnapshotReuseWindow[%v] should not be negative.", s.DiscoverySnapshotReuseWindow)
+	}
+	if s.DiscoverySnapshotReuseWindow > 0 && s.DiscoveryIntervalSec > 0 &&
+		s.DiscoverySnapshotReuseWindow >= time.Duration(s.DiscoveryIntervalSec)*time.Second {
+		return fmt.Errorf("DiscoverySnapshotReuseWindow[%v] should be shorter than the discovery interval of %d "+
+			"seconds.", s.DiscoverySnapshotReuseWindow, s.DiscoveryIntervalSec)
+	}
+
+	if s.DumpDTOsDir != "" {
+		if info, err := os.Stat(s.DumpDTOsDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("DumpDTOsDir[%s] should be an existing directory.", s.DumpDTOsDir)
+		}
+	}
+
+	if s.StateDir != "" {
+		if info, err := os.Stat(s.StateDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("StateDir[%s] should be an existing directory.", s.StateDir)
+		}
+	}
+
+	if s.DiscoveryShardRole != "" {
+		if err := s.checkDiscoveryShardFlags(); err != nil {
+			return err
+		}
+	}
+
+	if s.InternalAPIPort != 0 || s.DiscoveryShardRole != "" {
+		if err := s.checkInternalAPIFlags(); err != nil {
+			return err
+		}
+	}
+
+	if s.DebugTokenFile != "" {
+		if _, err := readDebugToken(s.DebugTokenFile); err != nil {
+			return fmt.Errorf("DebugTokenFile[%s] is invalid: %v.", s.DebugTokenFile, err)
+		}
+	}
+
+	if s.AuditLogSink != "" {
+		switch s.AuditLogSink {
+		case audit.SinkFile:
+			if s.AuditLogFile == "" {
+				return fmt.Errorf("AuditLogFile should be set with AuditLogSink[%s].", s.AuditLogSink)
+			}
+		case audit.SinkConfigMap:
+			if s.AuditLogConfigMap == "" {
+				return fmt.Errorf("AuditLogConfigMap should be set with AuditLogSink[%s].", s.AuditLogSink)
+			}
+		default:
+			return fmt.Errorf("AuditLogSink[%s] should be %s or %s.", s.AuditLogSink, audit.SinkFile,
+				audit.SinkConfigMap)
+		}
+		if s.AuditLogMaxEvents <= 0 {
+			return fmt.Errorf("AuditLogMaxEvents[%d] should be positive.", s.AuditLogMaxEvents)
+		}
+	}
+
+	if s.EnableSchedulerExtender &&
+		(s.SchedulerExtenderMaxUtilization <= 0 || s.SchedulerExtenderMaxUtilization > 1) {
+		return fmt.Errorf("SchedulerExtenderMaxUtilization[%v] should be in (0, 1].", s.SchedulerExtenderMaxUtilization)
+	}
+
 	return nil
 }
 
-// Run runs the specified VMTServer.  This should never exit.
-func (s *VMTServer) Run() {
-	if err := s.checkFlag(); err != nil {
-		glog.Fatalf("Check flag failed: %v. Abort.", err.Error())
+// parseListenIPs parses the addresses that kubeturbo's http service runs on: a single IPv4 or IPv6 address, or an IPv4
+// and an IPv6 address separated by a comma on a dual-stack cluster.
+func parseListenIPs(address string) ([]net.IP, error) {
+	var ips []net.IP
+	var hasIPv4, hasIPv6 bool
+	for _, part := range strings.Split(address, ",") {
+		ip := net.ParseIP(strings.TrimSpace(part))
+		if ip == nil {
+			return nil, fmt.Errorf("wrong ip format: %q", part)
+		}
+		isIPv4 := ip.To4() != nil
+		if (isIPv4 && hasIPv4) || (!isIPv4 && hasIPv6) {
+			return nil, fmt.Errorf("%s has more than one address of the same family", address)
+		}
+		hasIPv4, hasIPv6 = hasIPv4 || isIPv4, hasIPv6 || !isIPv4
+		ips = append(ips, ip)
 	}
+	return ips, nil
+}
 
-	kubeConfig := s.createKubeConfigOrDie()
+// listenAndServe serves the handler on the given port of each address that kubeturbo's http service runs on, with
+// TLS if a TLS config is given, until one of the servers fails.
+func (s *VMTServer) listenAndServe(name string, handler http.Handler, port int, tlsConfig *tls.Config) {
+	s.serveListeners(name, port, func(listener net.Listener) error {
+		server := &http.Server{
+			Handler:   handler,
+			TLSConfig: tlsConfig,
+		}
+		if tlsConfig != nil {
+			return server.ServeTLS(listener, "", "")
+		}
+		return server.Serve(listener)
+	})
+}
+
+// serveListeners listens on the given port of each address that kubeturbo's http service runs on, and serves each
+// listener with the given function until one of them fails. Each address is bound to its own family, so that the
+// IPv6 wildcard address does not take the IPv4 wildcard address of a dual-stack service.
+func (s *VMTServer) serveListeners(name string, port int, serve func(listener net.Listener) error) {
+	// The addresses have been validated in checkFlag
+	ips, err := parseListenIPs(s.Address)
+	if err != nil {
+		glog.Fatalf("Failed to serve %s: %v", name, err)
+	}
+	errs := make(chan error, len(ips))
+	for _, ip := range ips {
+		network := "tcp6"
+		if ip.To4() != nil {
+			network = "tcp4"
+		}
+		addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+		listener, err := net.Listen(network, addr)
+		if err != nil {
+			glog.Fatalf("Failed to serve %s on %s: %v", name, addr, err)
+		}
+		glog.V(1).Infof("Serving %s on %s.", name, addr)
+		go func() {
+			errs <- serve(listener)
+		}()
+	}
+	glog.Fatal(<-errs)
+}
+
+// validateAPIServerAddress checks that the API server address has a host, e.g. https://10.0.0.1:6443 or
+// 10.0.0.1:6443. An address without a scheme is left to client-go, which uses https with a TLS config, and http
+// otherwise, e.g. for an insecure port such as 127.0.0.1:8080.
+func validateAPIServerAddress(address string) error {
+	hostURL := address
+	if !strings.Contains(hostURL, "://") {
+		hostURL = "//" + hostURL
+	}
+	u, err := url.Parse(hostURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host, e.g. https://10.0.0.1:6443 or 10.0.0.1:6443", address)
+	}
+	return nil
+}
+
+// setKubeAPIRateLimit sets the number and the max burst of queries per second to the API server. All the clients
+// created from the config share the same rate limiter, so that the discovery and the actions together stay within
+// the limit, whereas each client would otherwise have a rate limit of its own.
+func (s *VMTServer) setKubeAPIRateLimit(kubeConfig *restclient.Config) {
+	kubeConfig.QPS = s.KubeAPIQPS
+	if kubeConfig.QPS == 0 {
+		kubeConfig.QPS = DefaultKubeAPIQPS
+	}
+	kubeConfig.Burst = s.KubeAPIBurst
+	if kubeConfig.Burst == 0 {
+		kubeConfig.Burst = DefaultKubeAPIBurst
+	}
+	kubeConfig.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(kubeConfig.QPS, kubeConfig.Burst)
+	glog.V(2).Infof("Queries to the API server %s are limited to %v per second with a burst of %d.",
+		kubeConfig.Host, kubeConfig.QPS, kubeConfig.Burst)
+}
+
+// createDiscoveryKubeConfig returns the config of the API server used by discovery when it differs from
+// the primary one, i.e. when --discovery-master is set, or nil otherwise. The credentials of the primary
+// config are reused, so the read replica must accept the same credentials.
+func (s *VMTServer) createDiscoveryKubeConfig(kubeConfig *restclient.Config) *restclient.Config {
+	if s.DiscoveryMaster == "" || s.DiscoveryMaster == kubeConfig.Host {
+		return nil
+	}
+	discoveryKubeConfig := restclient.CopyConfig(kubeConfig)
+	discoveryKubeConfig.Host = s.DiscoveryMaster
+	// The read replica has a rate limit of its own
+	discoveryKubeConfig.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(kubeConfig.QPS, kubeConfig.Burst)
+	return discoveryKubeConfig
+}
+
+// clusterPipeline is the discovery and action pipeline of a cluster, registered as a target of the Turbo server.
+type clusterPipeline struct {
+	tapSpec       *kubeturbo.K8sTAPServiceSpec
+	tapService    *kubeturbo.K8sTAPService
+	kubeClient    *kubernetes.Clientset
+	dynamicClient dynamic.Interface
+	controllerGVs util.ControllerGroupVersions
+}
+
+// createClusterPipeline creates the clients of the cluster and its TAP service. The target is named after the
+// given name if not empty, or as configured in the TAP config otherwise, which defaults to the API server address.
+// An error is returned if the clients or the TAP service of the cluster cannot be created from its kubeconfig.
+func (s *VMTServer) createClusterPipeline(kubeConfig *restclient.Config, targetName string) (*clusterPipeline, error) {
+	pipeline, vmtConfig, err := s.createClusterConfig(kubeConfig, targetName)
+	if err != nil {
+		return nil, err
+	}
+	if s.simulator != nil {
+		s.simulateTurboServer(pipeline.tapSpec)
+	} else if !s.InsecureSkipVerify || pipeline.tapSpec.ServerCABundle != "" {
+		if err := kubeturbo.VerifyServerCertificate(pipeline.tapSpec.TurboServer, pipeline.tapSpec.ServerCABundle,
+			pipeline.tapSpec.Proxy); err != nil {
+			return nil, fmt.Errorf("failed to verify the Turbo server: %v", err)
+		}
+	}
+	if s.DiscoveryShardRole == shardRoleCoordinator {
+		tlsConfig, err := s.internalAPITLSFiles().ClientTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the mutual TLS of the internal API: %v", err)
+		}
+		glog.Infof("Discovering the nodes of the cluster with the shard workers %v.", s.DiscoveryShardWorkers)
+		// The nodes of a worker which times out are discovered by the coordinator within the discovery timeout
+		coordinator, err := shard.NewCoordinator(s.DiscoveryShardWorkers, tlsConfig,
+			time.Duration(s.DiscoveryTimeoutSec)*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the clients of the shard workers: %v", err)
+		}
+		vmtConfig.WithShardDiscoverer(coordinator)
+	}
+
+	// The KubeTurbo TAP service
+	k8sTAPService, err := kubeturbo.NewKubernetesTAPService(vmtConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error while creating Kubernetes TAP service: %v", err)
+	}
+	pipeline.tapService = k8sTAPService
+	return pipeline, nil
+}
+
+// createClusterConfig creates the clients of the cluster and the configuration of its TAP service, along with the
+// pipeline of the cluster without the TAP service.
+func (s *VMTServer) createClusterConfig(kubeConfig *restclient.Config, targetName string) (*clusterPipeline,
+	*kubeturbo.Config, error) {
+	s.setKubeAPIRateLimit(kubeConfig)
 	glog.V(3).Infof("kubeConfig: %+v", kubeConfig)
 
-	kubeClient := s.createKubeClientOrDie(kubeConfig)
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubeClient: %v", err)
+	}
 
 	// Create controller runtime client that support custom resources
 	runtimeClient, err := runtimeclient.New(kubeConfig, runtimeclient.Options{Scheme: customScheme})
 	if err != nil {
-		glog.Fatalf("Failed to create controller runtime client: %v.", err)
+		return nil, nil, fmt.Errorf("failed to create controller runtime client: %v", err)
 	}
 
 	// Openshift client for deploymentconfig resize forced rollouts
 	osClient, err := osclient.NewForConfig(kubeConfig)
 	if err != nil {
-		glog.Fatalf("Failed to generate openshift client for kubernetes target: %v", err)
+		return nil, nil, fmt.Errorf("failed to generate openshift client for kubernetes target: %v", err)
 	}
 
 	// TODO: Replace dynamicClient with runtimeClient
 	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
 	if err != nil {
-		glog.Fatalf("Failed to generate dynamic client for kubernetes target: %v", err)
+		return nil, nil, fmt.Errorf("failed to generate dynamic client for kubernetes target: %v", err)
 	}
 
-	util.K8sAPIDeploymentGV, err = discoverk8sAPIResourceGV(kubeClient, util.DeploymentResName)
+	// Discovery lists resources through a separate read client when --discovery-master is set
+	var discoveryKubeClient *kubernetes.Clientset
+	var discoveryDynamicClient dynamic.Interface
+	discoveryKubeConfig := s.createDiscoveryKubeConfig(kubeConfig)
+	if discoveryKubeConfig != nil {
+		glog.V(2).Infof("Discovery uses the API server %s, actions use the API server %s.",
+			discoveryKubeConfig.Host, kubeConfig.Host)
+		discoveryKubeClient, err = kubernetes.NewForConfig(discoveryKubeConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create discovery kubeClient: %v", err)
+		}
+		discoveryDynamicClient, err = dynamic.NewForConfig(discoveryKubeConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate discovery dynamic client for kubernetes target: %v", err)
+		}
+	}
+
+	// The group versions differ between the clusters of different k8s versions, so they are kept per cluster
+	var controllerGVs util.ControllerGroupVersions
+	controllerGVs.Deployment, err = discoverk8sAPIResourceGV(kubeClient, util.DeploymentResName)
 	if err != nil {
 		glog.Warningf("Failure in discovering k8s deployment API group/version: %v", err.Error())
 	}
-	glog.V(2).Infof("Using group version %v for k8s deployments", util.K8sAPIDeploymentGV)
+	glog.V(2).Infof("Using group version %v for k8s deployments", controllerGVs.Deployment)
 
-	util.K8sAPIReplicasetGV, err = discoverk8sAPIResourceGV(kubeClient, util.ReplicaSetResName)
+	controllerGVs.ReplicaSet, err = discoverk8sAPIResourceGV(kubeClient, util.ReplicaSetResName)
 	if err != nil {
 		glog.Warningf("Failure in discovering k8s replicaset API group/version: %v", err.Error())
 	}
-	glog.V(2).Infof("Using group version %v for k8s replicasets", util.K8sAPIReplicasetGV)
+	glog.V(2).Infof("Using group version %v for k8s replicasets", controllerGVs.ReplicaSet)
 
 	glog.V(3).Infof("Turbonomic config path is: %v", s.K8sTAPSpec)
 
 	k8sTAPSpec, err := kubeturbo.ParseK8sTAPServiceSpec(s.K8sTAPSpec, kubeConfig.Host)
 	if err != nil {
-		glog.Fatalf("Failed to generate correct TAP config: %v", err.Error())
+		return nil, nil, fmt.Errorf("failed to generate correct TAP config: %v", err)
 	}
-
-	if k8sTAPSpec.FeatureGates != nil {
-		err = utilfeature.DefaultMutableFeatureGate.SetFromMap(k8sTAPSpec.FeatureGates)
-		if err != nil {
-			glog.Fatalf("Invalid Feature Gates: %v", err)
+	if targetName != "" {
+		// The target is named after --cluster-name, or after the kubeconfig of each cluster as the clusters share the
+		// TAP config
+		k8sTAPSpec.TargetIdentifier = targetName
+		if err := k8sTAPSpec.ValidateK8sTargetConfig(); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate correct TAP config for target %s: %v", targetName, err)
 		}
 	}
-
-	if utilfeature.DefaultFeatureGate.Enabled(features.GoMemLimit) {
-		glog.V(2).Info("Memory Optimisations are enabled.")
-		// AUTOMEMLIMIT_DEBUG environment variable enables debug logging of AUTOMEMLIMIT
-		// GoMemLimit will be set during the start of each discovery, see K8sDiscoveryClient.Discover,
-		// as memory limit may change overtime
-		_ = os.Setenv("AUTOMEMLIMIT_DEBUG", "true")
-		if s.ItemsPerListQuery != 0 {
-			// Perform sanity check on user specified value of itemsPerListQuery
-			if s.ItemsPerListQuery < processor.DefaultItemsPerGiMemory {
-				var errMsg string
-				if s.ItemsPerListQuery < 0 {
-					errMsg = "negative"
-				} else {
-					errMsg = "set too low"
-				}
-				glog.Warningf("Argument --items-per-list-query is %s (%v). Setting it to the default value of %d.",
-					errMsg, s.ItemsPerListQuery, processor.DefaultItemsPerGiMemory)
-				s.ItemsPerListQuery = processor.DefaultItemsPerGiMemory
-			} else {
-				glog.V(2).Infof("Set items per list API call to the user specified value: %v.", s.ItemsPerListQuery)
-			}
-		}
-	} else {
-		glog.V(2).Info("Memory Optimisations are not enabled.")
-	}
-
 	// Collect target and probe info such as master host, server version, probe container image, etc
 	k8sTAPSpec.CollectK8sTargetAndProbeInfo(kubeConfig, kubeClient)
 
 	excludeLabelsMap, err := nodeUtil.LabelMapFromNodeSelectorString(s.CpufreqJobExcludeNodeLabels)
 	if err != nil {
-		glog.Fatalf("Invalid cpu frequency exclude node label selectors: %v. The selectors "+
+		return nil, nil, fmt.Errorf("invalid cpu frequency exclude node label selectors: %v. The selectors "+
 			"should be a comma saperated list of key=value node label pairs", err)
 	}
 
 	s.ensureBusyboxImageBackwardCompatibility()
-	kubeletClient := s.CreateKubeletClientOrDie(kubeConfig, kubeClient, s.CpuFrequencyGetterImage,
+	kubeletClient, err := s.createKubeletClient(kubeConfig, kubeClient, s.CpuFrequencyGetterImage,
 		s.CpuFrequencyGetterPullSecret, excludeLabelsMap, s.UseNodeProxyEndpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create kubeletClient: %v", err)
+	}
 	caClient, err := clusterclient.NewForConfig(kubeConfig)
 	if err != nil {
 		glog.Errorf("Failed to generate correct TAP config: %v", err.Error())
 		caClient = nil
 	}
 
+	// The cgroup version has been validated in checkFlag
+	cgroupVersion, _ := kubelet.ParseCgroupVersion(s.CgroupVersion)
+	// The kubelet metrics source has been validated in checkFlag
+	kubeletMetricsSource, _ := kubelet.ParseMetricsSource(s.KubeletMetrics)
+	// The monitoring source priority has been validated in checkFlag
+	monitoringSourcePriority, _ := monitoring.ParseSourcePriority(s.MonitoringSourcePriority)
+	// The property conflict policy has been validated in checkFlag
+	propertyConflictPolicy, _ := property.ParseConflictPolicy(s.PropertyConflictPolicy)
+	// The entity limits have been validated in checkFlag
+	entityLimits, _ := dtofactory.ParseEntityLimits(s.MaxEntities)
+	resizeLimits, _ := executor.ParseResizeLimits(s.ResizeMinCPU, s.ResizeMinMemory, s.ResizeMaxChangePercent)
+	disabledEntityTypes, _ := configs.ParseDisabledEntityTypes(s.DisabledEntityTypes)
+	infrastructurePods, _ := configs.ParseInfrastructurePods(s.InfrastructureNamespaces, s.InfrastructurePodsMode)
+	// The stitching type has been validated in checkFlag
+	var stitchingType stitching.StitchingPropertyType
+	if s.StitchingType != "" {
+		stitchingType, _ = stitching.ParseStitchingPropertyType(s.StitchingType)
+	}
+
 	// Interface to discover turbonomic ORM mappings (legacy and v2) for resize actions
 	ormClientManager := resourcemapping.NewORMClientManager(dynamicClient, kubeConfig)
 
@@ -468,6 +1334,8 @@ func (s *VMTServer) Run() {
 		WithKubeClient(kubeClient).
 		WithKubeConfig(kubeConfig).
 		WithDynamicClient(dynamicClient).
+		WithDiscoveryClients(discoveryKubeConfig, discoveryKubeClient, discoveryDynamicClient).
+		WithControllerGroupVersions(controllerGVs).
 		WithControllerRuntimeClient(runtimeClient).
 		WithORMClientManager(ormClientManager).
 		WithKubeletClient(kubeletClient).
@@ -476,6 +1344,8 @@ func (s *VMTServer) Run() {
 		WithVMPriority(s.VMPriority).
 		WithVMIsBase(s.VMIsBase).
 		UsingUUIDStitch(s.UseUUID).
+		WithStitchingPropertyType(stitchingType).
+		WithStitchingProvider(s.StitchProvider).
 		WithDiscoveryInterval(s.DiscoveryIntervalSec).
 		WithValidationTimeout(s.ValidationTimeout).
 		WithValidationWorkers(s.ValidationWorkers).
@@ -491,7 +1361,49 @@ func (s *VMTServer) Run() {
 		WithQuotaUpdateConfig(s.UpdateQuotaToAllowMoves).
 		WithReadinessRetryThreshold(s.readinessRetryThreshold).
 		WithClusterKeyInjected(s.ClusterKeyInjected).
-		WithItemsPerListQuery(s.ItemsPerListQuery)
+		WithItemsPerListQuery(s.ItemsPerListQuery).
+		WithBusinessAppLabel(s.BusinessAppLabel).
+		WithCgroupVersion(cgroupVersion).
+		WithAnnotateActionResults(s.AnnotateActionResults).
+		WithRefusalReasonPrefix(s.RefusalReasonPrefix).
+		WithCollectSwapMetrics(s.CollectSwapMetrics).
+		WithCollectMetricsServerUsage(s.CollectMetricsServerUsage).
+		WithUtilizationPercentile(s.UtilizationPercentile, s.UtilizationWindow).
+		WithUtilizationLimits(s.UtilizationMaxSamples, s.UtilizationMaxEntities).
+		WithPropertyNormalization(propertyConflictPolicy, s.MaxEntityProperties).
+		WithSchemaVersion(s.EmitSchemaVersion).
+		WithSkipActionsOnDegradedDiscovery(s.SkipActionsOnDegradedDiscovery).
+		WithEntityLimits(entityLimits).
+		WithDisabledEntityTypes(disabledEntityTypes).
+		WithSidecarResizeMode(s.SidecarResizeMode).
+		WithVPACoexistenceMode(s.VPACoexistenceMode).
+		WithKubeletMetricsSource(kubeletMetricsSource).
+		WithPrometheusMetrics(s.PrometheusServerURL, s.PrometheusCPUQuery, s.PrometheusMemoryQuery, s.PrometheusQueryStep).
+		WithServiceMeshMetrics(s.ServiceMeshPrometheusURL, s.ServiceMeshTransactionQuery, s.ServiceMeshResponseTimeQuery).
+		WithMonitoringSourcePriority(monitoringSourcePriority).
+		WithResizeRolloutTimeout(s.ResizeRolloutTimeout).
+		WithResizeLimits(resizeLimits).
+		WithIncrementalDiscoveryInterval(s.IncrementalDiscoveryIntervalSec).
+		WithActionMode(s.ActionMode).
+		WithNodeSuspendMode(s.NodeSuspendMode, s.MaxConcurrentNodeDrains).
+		WithActionLimits(s.MaxConcurrentActions, s.ActionQueueTimeout).
+		WithDumpDTOsDir(s.DumpDTOsDir).
+		WithInformerCache(s.InformerCache).
+		WithDiscoverySnapshotReuseWindow(s.DiscoverySnapshotReuseWindow).
+		WithMaxUnchangedDiscoveries(s.MaxUnchangedDiscoveries).
+		WithIncludeBatchWorkloads(s.IncludeBatchWorkloads).
+		WithInfrastructurePods(infrastructurePods).
+		WithPodMoveStrategy(s.PodMoveStrategy, s.MoveEndpointsTimeout).
+		WithMovePlacement(s.MovePlacement).
+		WithAuditLog(s.auditLog).
+		WithStateDir(s.StateDir)
+	if s.ActionPolicyConfigMap != "" {
+		namespace, name, _ := parseActionPolicyConfigMap(s.ActionPolicyConfigMap)
+		vmtConfig.WithActionPolicyConfigMap(namespace, name)
+	}
+	if s.RecordActionEvents {
+		vmtConfig.WithActionEventRecorder(createRecorder(kubeClient))
+	}
 
 	if utilfeature.DefaultFeatureGate.Enabled(features.GitopsApps) {
 		vmtConfig.WithGitConfig(s.gitConfig)
@@ -507,55 +1419,293 @@ func (s *VMTServer) Run() {
 	}
 	glog.V(3).Infof("Finished creating turbo configuration: %+v", vmtConfig)
 
-	// The KubeTurbo TAP service
-	k8sTAPService, err := kubeturbo.NewKubernetesTAPService(vmtConfig)
+	return &clusterPipeline{
+		tapSpec:       k8sTAPSpec,
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		controllerGVs: controllerGVs,
+	}, vmtConfig, nil
+}
+
+// applyFeatureGates applies the feature gates of the TAP config, which are process-wide and so shared by the
+// pipelines of all the clusters.
+func (s *VMTServer) applyFeatureGates() error {
+	featureGates, err := kubeturbo.ReadFeatureGates(s.K8sTAPSpec)
 	if err != nil {
-		glog.Fatalf("Unexpected error while creating Kubernetes TAP service: %s", err)
+		return err
+	}
+	if featureGates != nil {
+		if err := utilfeature.DefaultMutableFeatureGate.SetFromMap(featureGates); err != nil {
+			return err
+		}
+	}
+
+	if utilfeature.DefaultFeatureGate.Enabled(features.GoMemLimit) {
+		glog.V(2).Info("Memory Optimisations are enabled.")
+		// AUTOMEMLIMIT_DEBUG environment variable enables debug logging of AUTOMEMLIMIT
+		// GoMemLimit will be set during the start of each discovery, see K8sDiscoveryClient.Discover,
+		// as memory limit may change overtime
+		_ = os.Setenv("AUTOMEMLIMIT_DEBUG", "true")
+		if s.ItemsPerListQuery != 0 {
+			// Perform sanity check on user specified value of itemsPerListQuery
+			if s.ItemsPerListQuery < processor.DefaultItemsPerGiMemory {
+				var errMsg string
+				if s.ItemsPerListQuery < 0 {
+					errMsg = "negative"
+				} else {
+					errMsg = "set too low"
+				}
+				glog.Warningf("Argument --items-per-list-query is %s (%v). Setting it to the default value of %d.",
+					errMsg, s.ItemsPerListQuery, processor.DefaultItemsPerGiMemory)
+				s.ItemsPerListQuery = processor.DefaultItemsPerGiMemory
+			} else {
+				glog.V(2).Infof("Set items per list API call to the user specified value: %v.", s.ItemsPerListQuery)
+			}
+		}
+	} else {
+		glog.V(2).Info("Memory Optimisations are not enabled.")
+	}
+	return nil
+}
+
+// Run runs the specified VMTServer.  This should never exit.
+func (s *VMTServer) Run() {
+	if err := s.checkFlag(); err != nil {
+		glog.Fatalf("Check flag failed: %v. Abort.", err.Error())
+	}
+
+	// The shard workers only discover the nodes of their shards for the coordinator
+	if s.DiscoveryShardRole == shardRoleWorker {
+		s.runDiscoveryShardWorker()
+		return
+	}
+
+	if s.EmitSchemaVersion {
+		glog.Infof("Discovery schema version: %s", dtofactory.DiscoverySchemaVersion)
+	} else {
+		glog.Infof("Discovery schema version %s is not emitted.", dtofactory.DiscoverySchemaVersion)
+	}
+
+	if s.Simulate {
+		simulatedServer, err := simulator.NewMediationServer("127.0.0.1:0")
+		if err != nil {
+			glog.Fatalf("Failed to start the simulated Turbo server: %v", err)
+		}
+		glog.Warningf("Connecting to the simulated Turbo server at %s instead of the Turbo server of the TAP config.",
+			simulatedServer.URL())
+		s.simulator = simulatedServer
+	}
+
+	// The audit log is shared by the pipelines, each recording its events with its target
+	if s.AuditLogSink != "" {
+		s.auditLog = s.createAuditLog()
+		go s.auditLog.Run(wait.NeverStop)
+	}
+
+	// The feature gates are applied once, before the pipelines of the clusters sharing the TAP config are created
+	if err := s.applyFeatureGates(); err != nil {
+		glog.Fatalf("Invalid Feature Gates: %v", err)
+	}
+
+	// One pipeline per cluster, each registered as a separate target from this process
+	var pipelines []*clusterPipeline
+	if s.KubeConfigDir != "" {
+		kubeConfigs, err := loadKubeConfigs(s.KubeConfigDir)
+		if err != nil {
+			glog.Fatalf("Failed to load the kubeconfigs: %v", err)
+		}
+		for _, kubeConfig := range kubeConfigs {
+			glog.V(2).Infof("Creating the pipeline of cluster %s from %s.", kubeConfig.name, s.KubeConfigDir)
+			pipeline, err := s.createClusterPipeline(kubeConfig.config, kubeConfig.name)
+			if err != nil {
+				// The other clusters are still discovered
+				glog.Errorf("Skipping cluster %s: %v", kubeConfig.name, err)
+				continue
+			}
+			pipelines = append(pipelines, pipeline)
+		}
+		if len(pipelines) == 0 {
+			glog.Fatalf("Failed to create the pipeline of any cluster from %s.", s.KubeConfigDir)
+		}
+	} else {
+		pipeline, err := s.createClusterPipeline(s.createKubeConfigOrDie(), s.ClusterName)
+		if err != nil {
+			glog.Fatalf("Failed to create the pipeline of the cluster: %v", err)
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+
+	// Restart gracefully to reconnect with the new credentials when the mounted Secret is updated. The
+	// credentials are shared by all the pipelines.
+	if err := kubeturbo.WatchCredentials(pipelines[0].tapSpec, restart); err != nil {
+		glog.V(2).Infof("Not watching the server credentials: %v", err)
+	}
+	// Likewise when the TAP config is edited, e.g. the address of the Turbo server
+	if err := kubeturbo.WatchTAPSpec(s.K8sTAPSpec, restart); err != nil {
+		glog.V(2).Infof("Not watching the TAP config: %v", err)
 	}
 
 	// Its a must to include the namespace env var in the kubeturbo pod spec.
 	ns := util.GetKubeturboNamespace()
 	// Update scc resources in parallel.
-	go ManageSCCs(ns, dynamicClient, kubeClient)
+	for _, pipeline := range pipelines {
+		go ManageSCCs(ns, pipeline.dynamicClient, pipeline.kubeClient)
+	}
 
-	// The client for healthz, debug, and prometheus
-	go s.startHttp()
+	// The client for healthz, readyz, livez, debug, and prometheus
+	go s.startHttp(pipelines)
 
-	cleanupWG := &sync.WaitGroup{}
-	cleanupSCCFn := func() {
-		ns := util.GetKubeturboNamespace()
-		CleanUpSCCMgmtResources(ns, dynamicClient, kubeClient)
-	}
-	disconnectFn := func() {
-		// Disconnect from Turbo server when Kubeturbo is shutdown
-		// Close the mediation container including the endpoints. It avoids the
-		// invalid endpoints remaining in the server side. See OM-28801.
-		k8sTAPService.DisconnectFromTurbo()
+	if s.InternalAPIPort != 0 {
+		apiServer := api.NewServer()
+		for _, pipeline := range pipelines {
+			apiServer.WithTarget(pipeline.tapSpec.TargetIdentifier, pipeline.tapService)
+		}
+		go s.startInternalAPI(apiServer)
 	}
+
+	cleanupWG := &sync.WaitGroup{}
 	var cleanupFuns []cleanUp
-	if s.CleanupSccRelatedResources {
-		cleanupFuns = append(cleanupFuns, cleanupSCCFn)
+	gCChan := make(chan bool)
+	defer close(gCChan)
+	for _, pipeline := range pipelines {
+		pipeline := pipeline
+		cleanupSCCFn := func() {
+			ns := util.GetKubeturboNamespace()
+			CleanUpSCCMgmtResources(ns, pipeline.dynamicClient, pipeline.kubeClient)
+		}
+		disconnectFn := func() {
+			// Drain the actions and the discovery in progress, and disconnect from Turbo server when Kubeturbo is
+			// shutdown
+			pipeline.tapService.Shutdown(s.ShutdownTimeout)
+		}
+		if s.CleanupSccRelatedResources {
+			cleanupFuns = append(cleanupFuns, cleanupSCCFn)
+		}
+		cleanupFuns = append(cleanupFuns, disconnectFn)
+		worker.NewGarbageCollector(pipeline.kubeClient, pipeline.dynamicClient, gCChan, s.GCIntervalMin*60,
+			time.Minute*30).WithControllerGroupVersions(pipeline.controllerGVs).StartCleanup()
 	}
-	cleanupFuns = append(cleanupFuns, disconnectFn)
 	handleExit(cleanupWG, cleanupFuns...)
 
-	gCChan := make(chan bool)
-	defer close(gCChan)
-	worker.NewGarbageCollector(kubeClient, dynamicClient, gCChan, s.GCIntervalMin*60, time.Minute*30).StartCleanup()
+	if delay := startupDelay(s.StartupJitter); delay > 0 {
+		glog.V(2).Infof("Delaying connection to the Turbo server by %v (max startup jitter %v).", delay, s.StartupJitter)
+		time.Sleep(delay)
+	}
 
 	glog.V(1).Infof("********** Start running Kubeturbo Service **********")
-	k8sTAPService.ConnectToTurbo()
+	connectWG := &sync.WaitGroup{}
+	for _, pipeline := range pipelines {
+		connectWG.Add(1)
+		go func(tapService *kubeturbo.K8sTAPService) {
+			defer connectWG.Done()
+			tapService.ConnectToTurbo()
+		}(pipeline.tapService)
+	}
+	if s.simulator != nil {
+		// The simulated server requests the discoveries in place of the Turbo server
+		for _, pipeline := range pipelines {
+			go s.simulator.RunDiscoveries(pipeline.tapSpec.TargetType, pipeline.tapService.AccountValues(),
+				time.Duration(s.DiscoveryIntervalSec)*time.Second, wait.NeverStop)
+		}
+	}
+	connectWG.Wait()
 	glog.V(1).Info("Kubeturbo service is stopped.")
 
 	cleanupWG.Wait()
+	// Write the events of the actions drained above
+	if s.auditLog != nil {
+		if err := s.auditLog.Flush(); err != nil {
+			glog.Errorf("Failed to write the audit log: %v", err)
+		}
+	}
 	glog.V(1).Info("Cleanup completed. Exiting gracefully.")
 }
 
-func (s *VMTServer) startHttp() {
+// simulateTurboServer points the given TAP config to the simulated Turbo server, without the credentials of the Turbo
+// server so that the target is not added through the Turbo API and no OAuth token is requested.
+func (s *VMTServer) simulateTurboServer(tapSpec *kubeturbo.K8sTAPServiceSpec) {
+	tapSpec.TurboServer = s.simulator.URL()
+	tapSpec.Proxy = ""
+	tapSpec.OpsManagerUsername, tapSpec.OpsManagerPassword = "", ""
+	tapSpec.ClientId, tapSpec.ClientSecret = "", ""
+	tapSpec.TurboAPIToken = ""
+}
+
+// createAuditLog creates the audit log with the sink given on the command line, loaded with the events of the sink.
+func (s *VMTServer) createAuditLog() *audit.Log {
+	var sink audit.Sink
+	if s.AuditLogSink == audit.SinkConfigMap {
+		// The ConfigMap is in the namespace of kubeturbo, in the cluster kubeturbo runs in
+		namespace := util.GetKubeturboNamespace()
+		glog.V(2).Infof("Keeping the audit log in ConfigMap %s/%s.", namespace, s.AuditLogConfigMap)
+		sink = audit.NewConfigMapSink(s.createKubeClientOrDie(s.createKubeConfigOrDie()), namespace,
+			s.AuditLogConfigMap, s.AuditLogMaxEvents)
+	} else {
+		glog.V(2).Infof("Keeping the audit log in file %s.", s.AuditLogFile)
+		sink = audit.NewFileSink(s.AuditLogFile, audit.DefaultMaxFileBytes)
+	}
+	return audit.NewLog(sink, s.AuditLogMaxEvents)
+}
+
+// runDiscoveryShardWorker serves the internal API discovering the nodes of the shards assigned by the coordinator,
+// along with the health and metrics endpoints. The worker builds the DTOs of the nodes of its shards and their pods
+// with the same TAP config and feature gates as the coordinator, which merges them into the discovery of the cluster.
+func (s *VMTServer) runDiscoveryShardWorker() {
+	if err := s.applyFeatureGates(); err != nil {
+		glog.Fatalf("Invalid Feature Gates: %v", err)
+	}
+	_, vmtConfig, err := s.createClusterConfig(s.createKubeConfigOrDie(), s.ClusterName)
+	if err != nil {
+		glog.Fatalf("Failed to create the configuration of the cluster: %v", err)
+	}
+	shardWorker, err := kubeturbo.NewDiscoveryShardWorker(vmtConfig)
+	if err != nil {
+		glog.Fatalf("Failed to create the discovery of the shard worker: %v", err)
+	}
+	go s.startInternalAPI(api.NewServer().WithShardWorker(shardWorker))
+
+	mux := http.NewServeMux()
+	healthz.InstallHandler(mux)
+	mux.Handle("/metrics", promhttp.Handler())
+	glog.V(1).Infof("********** Start running Kubeturbo discovery shard worker on %s **********", s.Address)
+	s.listenAndServe("the discovery shard worker", mux, s.Port, nil)
+}
+
+// startInternalAPI serves the given internal API on the internal API port, with mutual TLS.
+func (s *VMTServer) startInternalAPI(apiServer *api.Server) {
+	// The TLS files have been validated in checkFlag
+	tlsConfig, err := s.internalAPITLSFiles().ServerTLSConfig()
+	if err != nil {
+		glog.Fatalf("Failed to load the mutual TLS of the internal API: %v", err)
+	}
+	s.serveListeners("the internal API", s.InternalAPIPort, apiServer.NewGRPCServer(tlsConfig).Serve)
+}
+
+// restart terminates kubeturbo gracefully through the exit handlers, so that it is restarted by its deployment.
+func restart() {
+	glog.V(1).Infof("Restarting kubeturbo.")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		glog.Fatalf("Failed to restart kubeturbo: %v", err)
+	}
+}
+
+// startupDelay returns a random delay in the range of [0, maxJitter).
+// A zero or negative maxJitter means no delay.
+func startupDelay(maxJitter time.Duration) time.Duration {
+	if maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxJitter)))
+}
+
+func (s *VMTServer) startHttp(pipelines []*clusterPipeline) {
 	mux := http.NewServeMux()
 
 	// healthz
 	healthz.InstallHandler(mux)
+	// readyz and livez
+	healthz.InstallReadyzHandler(mux, healthChecks(pipelines, (*kubeturbo.K8sTAPService).ReadinessChecks)...)
+	healthz.InstallLivezHandler(mux, healthChecks(pipelines, (*kubeturbo.K8sTAPService).LivenessChecks)...)
 
 	// debug
 	if s.EnableProfiling {
@@ -563,16 +1713,231 @@ func (s *VMTServer) startHttp() {
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	if s.DebugTokenFile != "" {
+		token, err := readDebugToken(s.DebugTokenFile)
+		if err != nil {
+			glog.Fatalf("Failed to read the debug token from %s: %v", s.DebugTokenFile, err)
+		}
+		mux.Handle("/debug/discovery", discoverySnapshotHandler(pipelines, token))
+		mux.Handle("/rediscover", rediscoverHandler(pipelines, token, defaultRediscoverTimeout,
+			rediscoverTarget))
+		if s.auditLog != nil {
+			mux.Handle("/debug/audit", bearerTokenHandler(token, s.auditLog.Handler()))
+		}
+	}
+
+	// scheduler extender
+	if s.EnableSchedulerExtender {
+		s.installSchedulerExtender(mux, pipelines)
+	}
+
+	// prometheus.metrics, including the metrics of the discovery and action pipelines
+	mux.Handle("/metrics", promhttp.Handler())
+
+	s.listenAndServe("the http service", mux, s.Port, nil)
+}
+
+// readDebugToken reads the bearer token of the debug endpoints from the given file, e.g. a mounted Secret.
+func readDebugToken(tokenFile string) (string, error) {
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("the token is empty")
+	}
+	return token, nil
+}
+
+// discoverySnapshotHandler serves the snapshot of the last full discovery of the pipeline of the target given by the
+// target query parameter, which may be omitted with a single pipeline. The requests must bear the given token.
+func discoverySnapshotHandler(pipelines []*clusterPipeline, token string) http.Handler {
+	return bearerTokenHandler(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		pipeline := pipelineOf(pipelines, target)
+		if pipeline == nil {
+			http.Error(w, fmt.Sprintf("unknown target %q", target), http.StatusNotFound)
+			return
+		}
+		pipeline.tapService.DiscoverySnapshot().ServeHTTP(w, r)
+	}))
+}
+
+// rediscoverResult is the rediscovery of a target requested on demand.
+type rediscoverResult struct {
+	Target string `json:"target"`
+	// The UUIDs of the target in the Turbo server
+	UUIDs []string `json:"uuids"`
+	// When the full discovery requested by the server completed
+	DiscoveryTime time.Time `json:"discoveryTime"`
+	// The number of the entities of the full discovery by entity type
+	Entities map[string]int `json:"entities"`
+}
 
-		// prometheus.metrics
-		mux.Handle("/metrics", promhttp.Handler())
+// rediscoverHandler asks the Turbo server to rediscover the target given by the target query parameter through the
+// Turbo API on the POST requests, e.g. after large deployments or changes of the node pools. The server then requests
+// the full discovery from the probe as for the scheduled ones, so the discovery is sent to the server. The handler
+// responds with the number of the discovered entities by entity type once that discovery completes, or with 504 if it
+// does not complete within the given timeout, which the timeout query parameter may shorten for that request only. The
+// requests must bear the given token.
+func rediscoverHandler(pipelines []*clusterPipeline, token string, timeout time.Duration,
+	rediscover rediscoverFunc) http.Handler {
+	return bearerTokenHandler(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		target := r.URL.Query().Get("target")
+		pipeline := pipelineOf(pipelines, target)
+		if pipeline == nil {
+			http.Error(w, fmt.Sprintf("unknown target %q", target), http.StatusNotFound)
+			return
+		}
+		reqTimeout := timeout
+		if value := r.URL.Query().Get("timeout"); value != "" {
+			requested, err := time.ParseDuration(value)
+			if err != nil || requested <= 0 {
+				http.Error(w, fmt.Sprintf("invalid timeout %q", value), http.StatusBadRequest)
+				return
+			}
+			if requested < reqTimeout {
+				reqTimeout = requested
+			}
+		}
+		glog.V(2).Infof("Requesting the rediscovery of target %s on demand.", pipeline.tapSpec.TargetIdentifier)
+		rediscovery, err := rediscover(pipeline, reqTimeout)
+		if errors.Is(err, kubeturbo.ErrRediscoveryUnavailable) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if errors.Is(err, kubeturbo.ErrRediscoveryTimeout) {
+			http.Error(w, fmt.Sprintf("target %s (%s): %v within %v", pipeline.tapSpec.TargetIdentifier,
+				strings.Join(rediscovery.UUIDs, ", "), err, reqTimeout), http.StatusGatewayTimeout)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to rediscover target %s: %v", pipeline.tapSpec.TargetIdentifier, err),
+				http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		result := rediscoverResult{
+			Target:        pipeline.tapSpec.TargetIdentifier,
+			UUIDs:         rediscovery.UUIDs,
+			DiscoveryTime: rediscovery.DiscoveryTime,
+			Entities:      rediscovery.Entities,
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			glog.Errorf("Failed to write the result of the rediscovery: %v", err)
+		}
+	}))
+}
+
+// rediscoverFunc rediscovers the target of the pipeline and waits for at most the timeout for its full discovery.
+type rediscoverFunc func(pipeline *clusterPipeline, timeout time.Duration) (*kubeturbo.Rediscovery, error)
+
+// rediscoverTarget rediscovers the target of the pipeline through its TAP service.
+func rediscoverTarget(pipeline *clusterPipeline, timeout time.Duration) (*kubeturbo.Rediscovery, error) {
+	return pipeline.tapService.Rediscover(timeout)
+}
+
+// pipelineOf returns the pipeline of the given target, which may be empty with a single pipeline, nil if unknown.
+func pipelineOf(pipelines []*clusterPipeline, target string) *clusterPipeline {
+	for _, pipeline := range pipelines {
+		if (target == "" && len(pipelines) == 1) || pipeline.tapSpec.TargetIdentifier == target {
+			return pipeline
+		}
 	}
+	return nil
+}
 
-	server := &http.Server{
-		Addr:    net.JoinHostPort(s.Address, strconv.Itoa(s.Port)),
-		Handler: mux,
+// bearerTokenHandler passes on to the given handler the requests bearing the given token, and rejects the others.
+func bearerTokenHandler(token string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// installSchedulerExtender serves the endpoints of the scheduler extender of each pipeline, under the target of the
+// pipeline with several pipelines.
+func (s *VMTServer) installSchedulerExtender(mux *http.ServeMux, pipelines []*clusterPipeline) {
+	maxAge := 2 * time.Duration(s.DiscoveryIntervalSec) * time.Second
+	for _, pipeline := range pipelines {
+		prefix := schedulerExtenderPath
+		if len(pipelines) > 1 {
+			prefix += "/" + pipeline.tapSpec.TargetIdentifier
+		}
+		schedulerExtender := extender.NewSchedulerExtender(pipeline.tapService.DiscoverySnapshot(),
+			s.SchedulerExtenderMaxUtilization, maxAge)
+		for _, verb := range []string{extender.FilterVerb, extender.PrioritizeVerb} {
+			mux.Handle(prefix+"/"+verb, schedulerExtender.Handler(verb))
+		}
+		glog.V(2).Infof("Serving the scheduler extender at %s.", prefix)
+	}
+}
+
+// healthChecks returns the health checks of all the pipelines. With several pipelines, the checks are prefixed
+// with their target so that kubeturbo is ready or alive only when the pipelines of all the clusters are.
+func healthChecks(pipelines []*clusterPipeline,
+	checksOf func(*kubeturbo.K8sTAPService) []healthz.HealthChecker) []healthz.HealthChecker {
+	if len(pipelines) == 1 {
+		return checksOf(pipelines[0].tapService)
+	}
+	var checks []healthz.HealthChecker
+	for _, pipeline := range pipelines {
+		for _, check := range checksOf(pipeline.tapService) {
+			checks = append(checks,
+				healthz.NamedCheck(pipeline.tapSpec.TargetIdentifier+"-"+check.Name(), check.Check))
+		}
+	}
+	return checks
+}
+
+type namedKubeConfig struct {
+	name   string
+	config *restclient.Config
+}
+
+// loadKubeConfigs loads the kubeconfig files in the given directory, sorted by file name, and names each after
+// its file without the extension. The hidden files, such as the data directory of a mounted Secret, are skipped.
+func loadKubeConfigs(dir string) ([]namedKubeConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var kubeConfigs []namedKubeConfig
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		// The files of a mounted Secret are symbolic links, so the type of the target is checked
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		kubeConfig, err := clientcmd.BuildConfigFromFlags("", path)
+		if err != nil {
+			// A broken kubeconfig does not stop the discovery of the other clusters
+			glog.Errorf("Skipping the cluster of the kubeconfig %s: %v", path, err)
+			continue
+		}
+		kubeConfigs = append(kubeConfigs, namedKubeConfig{
+			name:   strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())),
+			config: kubeConfig,
+		})
+	}
+	if len(kubeConfigs) == 0 {
+		return nil, fmt.Errorf("no kubeconfig found in %s", dir)
 	}
-	glog.Fatal(server.ListenAndServe())
+	return kubeConfigs, nil
 }
 
 // handleExit disconnects the tap service from Turbo service when Kubeturbo is shotdown
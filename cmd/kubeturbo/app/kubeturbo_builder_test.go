@@ -1,14 +1,30 @@
 package app
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	kubeturbo "github.com/turbonomic/kubeturbo/pkg"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/simulator"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+	restclient "k8s.io/client-go/rest"
 )
 
 type helper struct {
@@ -71,3 +87,771 @@ func TestOptionsSet(t *testing.T) {
 	}
 	s.AddFlags(pflag.CommandLine)
 }
+
+func TestStartupDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), startupDelay(0))
+	assert.Equal(t, time.Duration(0), startupDelay(-time.Second))
+
+	maxJitter := 50 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		delay := startupDelay(maxJitter)
+		assert.True(t, delay >= 0, "delay %v should not be negative", delay)
+		assert.True(t, delay < maxJitter, "delay %v should be less than %v", delay, maxJitter)
+	}
+}
+
+func TestCheckFlagStartupJitter(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	assert.NoError(t, s.checkFlag())
+
+	s.StartupJitter = 30 * time.Second
+	assert.NoError(t, s.checkFlag())
+
+	s.StartupJitter = -time.Second
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagAddress(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	for _, address := range []string{"0.0.0.0", "::", "0.0.0.0,::", "fd00::1, 10.0.0.1"} {
+		s.Address = address
+		assert.NoError(t, s.checkFlag(), address)
+	}
+	for _, address := range []string{"", "localhost", "0.0.0.0,", "10.0.0.1,10.0.0.2", "::,::1"} {
+		s.Address = address
+		assert.Error(t, s.checkFlag(), address)
+	}
+}
+
+func TestCheckFlagDiscoveryMaster(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.Master = "https://primary:6443"
+	s.DiscoveryMaster = "https://read-replica:6443"
+	assert.NoError(t, s.checkFlag())
+
+	// The addresses without scheme are left to client-go
+	s.Master = "127.0.0.1:8080"
+	s.DiscoveryMaster = "read-replica:6443"
+	assert.NoError(t, s.checkFlag())
+	assert.Equal(t, "127.0.0.1:8080", s.Master)
+	assert.Equal(t, "read-replica:6443", s.DiscoveryMaster)
+
+	s.DiscoveryMaster = "https://"
+	assert.Error(t, s.checkFlag())
+
+	s.DiscoveryMaster = "https://read-replica:6443"
+	s.Master = "http://primary:bad-port"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagInfrastructurePods(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.InfrastructureNamespaces = "kube-system,openshift-.*"
+	s.InfrastructurePodsMode = "overhead"
+	assert.NoError(t, s.checkFlag())
+
+	s.InfrastructurePodsMode = "ignored"
+	assert.Error(t, s.checkFlag())
+
+	s.InfrastructurePodsMode = "excluded"
+	s.InfrastructureNamespaces = "kube-(system"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestSimulateTurboServer(t *testing.T) {
+	server, err := simulator.NewMediationServer("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+	s := NewVMTServer()
+	s.simulator = server
+	tapSpec := &kubeturbo.K8sTAPServiceSpec{
+		TurboCommunicationConfig: &service.TurboCommunicationConfig{},
+		TurboAPIToken:            "token",
+	}
+	tapSpec.TurboServer = "https://turbo.example.com"
+	tapSpec.OpsManagerUsername, tapSpec.OpsManagerPassword = "administrator", "password"
+	tapSpec.ClientId, tapSpec.ClientSecret = "client", "secret"
+
+	s.simulateTurboServer(tapSpec)
+	assert.Equal(t, server.URL(), tapSpec.TurboServer)
+	assert.False(t, tapSpec.TurboAPICredentialsProvided())
+	assert.False(t, tapSpec.SecureModeCredentialsProvided())
+	assert.Empty(t, tapSpec.TurboAPIToken)
+}
+
+func TestCreateDiscoveryKubeConfig(t *testing.T) {
+	s := NewVMTServer()
+	kubeConfig := &restclient.Config{Host: "https://primary:6443", BearerToken: "token", QPS: 20}
+	assert.Nil(t, s.createDiscoveryKubeConfig(kubeConfig))
+
+	s.DiscoveryMaster = kubeConfig.Host
+	assert.Nil(t, s.createDiscoveryKubeConfig(kubeConfig))
+
+	s.DiscoveryMaster = "https://read-replica:6443"
+	discoveryKubeConfig := s.createDiscoveryKubeConfig(kubeConfig)
+	assert.Equal(t, "https://read-replica:6443", discoveryKubeConfig.Host)
+	assert.Equal(t, "token", discoveryKubeConfig.BearerToken)
+	assert.Equal(t, kubeConfig.QPS, discoveryKubeConfig.QPS)
+	assert.Equal(t, "https://primary:6443", kubeConfig.Host)
+	// The read replica does not share the rate limiter of the primary API server
+	assert.NotNil(t, discoveryKubeConfig.RateLimiter)
+	assert.NotSame(t, kubeConfig.RateLimiter, discoveryKubeConfig.RateLimiter)
+}
+
+func TestCheckFlagKubeAPIRateLimit(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.KubeAPIQPS = 50
+	s.KubeAPIBurst = 100
+	assert.NoError(t, s.checkFlag())
+
+	s.KubeAPIQPS = -1
+	assert.Error(t, s.checkFlag())
+
+	s.KubeAPIQPS = 50
+	s.KubeAPIBurst = -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestSetKubeAPIRateLimit(t *testing.T) {
+	s := NewVMTServer()
+	kubeConfig := &restclient.Config{Host: "https://primary:6443"}
+	s.setKubeAPIRateLimit(kubeConfig)
+	assert.Equal(t, float32(DefaultKubeAPIQPS), kubeConfig.QPS)
+	assert.Equal(t, DefaultKubeAPIBurst, kubeConfig.Burst)
+	assert.Equal(t, float32(DefaultKubeAPIQPS), kubeConfig.RateLimiter.QPS())
+
+	s.KubeAPIQPS = 50
+	s.KubeAPIBurst = 100
+	s.setKubeAPIRateLimit(kubeConfig)
+	assert.Equal(t, float32(50), kubeConfig.QPS)
+	assert.Equal(t, 100, kubeConfig.Burst)
+	assert.Equal(t, float32(50), kubeConfig.RateLimiter.QPS())
+}
+
+func TestCheckFlagUtilizationPercentile(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.UtilizationPercentile = 95
+	s.UtilizationWindow = time.Hour
+	assert.NoError(t, s.checkFlag())
+
+	s.UtilizationWindow = 0
+	assert.Error(t, s.checkFlag())
+
+	s.UtilizationWindow = time.Hour
+	s.UtilizationPercentile = 101
+	assert.Error(t, s.checkFlag())
+
+	s.UtilizationPercentile = 95
+	s.UtilizationMaxEntities = -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagMetricsSOCKSProxy(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.MetricsSOCKSProxy = "socks5://127.0.0.1:1080"
+	assert.NoError(t, s.checkFlag())
+
+	s.MetricsSOCKSProxy = "127.0.0.1:1080"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagPropertyNormalization(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.PropertyConflictPolicy = "first"
+	s.MaxEntityProperties = 100
+	assert.NoError(t, s.checkFlag())
+
+	s.PropertyConflictPolicy = "random"
+	assert.Error(t, s.checkFlag())
+
+	s.PropertyConflictPolicy = "last"
+	s.MaxEntityProperties = -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagMaxEntities(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.MaxEntities = "total=100000,CONTAINER_POD=50000"
+	assert.NoError(t, s.checkFlag())
+
+	s.MaxEntities = "POD=50000"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagKubeletMetrics(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.KubeletMetrics = "cadvisor"
+	assert.NoError(t, s.checkFlag())
+
+	s.KubeletMetrics = "heapster"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagPrometheusServer(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.PrometheusServerURL = "http://prometheus.monitoring:9090"
+	s.PrometheusQueryStep = time.Minute
+	assert.NoError(t, s.checkFlag())
+
+	s.PrometheusQueryStep = 0
+	assert.Error(t, s.checkFlag())
+
+	s.PrometheusQueryStep = time.Minute
+	s.PrometheusServerURL = "prometheus.monitoring"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagServiceMeshPrometheus(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.ServiceMeshPrometheusURL = "http://prometheus.istio-system:9090"
+	s.PrometheusQueryStep = time.Minute
+	assert.NoError(t, s.checkFlag())
+
+	s.PrometheusQueryStep = 0
+	assert.Error(t, s.checkFlag())
+
+	s.PrometheusQueryStep = time.Minute
+	s.ServiceMeshPrometheusURL = "prometheus.istio-system"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagResizeRolloutTimeout(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.ResizeRolloutTimeout = 10 * time.Minute
+	assert.NoError(t, s.checkFlag())
+
+	s.ResizeRolloutTimeout = -time.Minute
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagStitchingType(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.StitchingType = "auto"
+	assert.NoError(t, s.checkFlag())
+
+	s.StitchingType = "vmware"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagStitchProvider(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.StitchProvider = "aws"
+	assert.NoError(t, s.checkFlag())
+
+	s.StitchProvider = "openstack"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagIncrementalDiscoveryInterval(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.IncrementalDiscoveryIntervalSec = 120
+	assert.NoError(t, s.checkFlag())
+
+	s.IncrementalDiscoveryIntervalSec = -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagKubeletTimeout(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.KubeletTimeoutSec = 5
+	assert.NoError(t, s.checkFlag())
+
+	s.KubeletTimeoutSec = -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagActionMode(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	assert.NoError(t, s.checkFlag())
+
+	s.ActionMode = "recommend"
+	assert.NoError(t, s.checkFlag())
+
+	s.ActionMode = "dry-run"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagNodeSuspendMode(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	assert.NoError(t, s.checkFlag())
+
+	s.NodeSuspendMode = "drain"
+	s.MaxConcurrentNodeDrains = 2
+	assert.NoError(t, s.checkFlag())
+
+	s.MaxConcurrentNodeDrains = -1
+	assert.Error(t, s.checkFlag())
+
+	s.MaxConcurrentNodeDrains = 1
+	s.NodeSuspendMode = "delete"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagPodMoveStrategy(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.PodMoveStrategy = "scale-up"
+	assert.NoError(t, s.checkFlag())
+
+	s.PodMoveStrategy = "evict"
+	assert.Error(t, s.checkFlag())
+
+	s.PodMoveStrategy = "clone"
+	s.MoveEndpointsTimeout = -time.Second
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagDisabledEntityTypes(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.DisabledEntityTypes = "services,volumes"
+	assert.NoError(t, s.checkFlag())
+
+	s.DisabledEntityTypes = "services,nodes"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagSidecarResizeMode(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.SidecarResizeMode = "disabled"
+	assert.NoError(t, s.checkFlag())
+
+	s.SidecarResizeMode = "automatic"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagVPACoexistenceMode(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	for _, mode := range []string{"suppress", "recommend", "ignore"} {
+		s.VPACoexistenceMode = mode
+		assert.NoError(t, s.checkFlag())
+	}
+
+	s.VPACoexistenceMode = "translate"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagAuditLog(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.AuditLogMaxEvents = 1000
+	s.AuditLogSink = "file"
+	s.AuditLogFile = "/var/lib/kubeturbo/audit/audit.jsonl"
+	assert.NoError(t, s.checkFlag())
+	s.AuditLogFile = ""
+	assert.Error(t, s.checkFlag())
+
+	s.AuditLogSink = "configmap"
+	s.AuditLogConfigMap = "kubeturbo-audit-log"
+	assert.NoError(t, s.checkFlag())
+	s.AuditLogMaxEvents = 0
+	assert.Error(t, s.checkFlag())
+
+	s.AuditLogMaxEvents = 1000
+	s.AuditLogSink = "syslog"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagStateDir(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.StateDir = t.TempDir()
+	assert.NoError(t, s.checkFlag())
+
+	s.StateDir = filepath.Join(s.StateDir, "missing")
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagDiscoveryShard(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.DiscoveryShardRole = "worker"
+	s.InternalAPIPort = 9443
+	assert.Error(t, s.checkFlag())
+
+	s.InternalAPICertFile, s.InternalAPIKeyFile = writeTestCertificate(t)
+	s.InternalAPICAFile = s.InternalAPICertFile
+	assert.NoError(t, s.checkFlag())
+	s.InternalAPIPort = 0
+	assert.Error(t, s.checkFlag())
+
+	s.DiscoveryShardRole = "coordinator"
+	assert.Error(t, s.checkFlag())
+	s.DiscoveryShardWorkers = []string{"https://kubeturbo-shard-0.kubeturbo-shards:9443"}
+	assert.NoError(t, s.checkFlag())
+	s.DiscoveryShardWorkers = []string{"http://kubeturbo-shard-0.kubeturbo-shards:9443"}
+	assert.Error(t, s.checkFlag())
+	s.DiscoveryShardWorkers = []string{"kubeturbo-shard-0"}
+	assert.Error(t, s.checkFlag())
+
+	s.DiscoveryShardRole = "leader"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagInternalAPI(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.InternalAPIPort = 9443
+	assert.Error(t, s.checkFlag())
+
+	s.InternalAPICertFile, s.InternalAPIKeyFile = writeTestCertificate(t)
+	assert.Error(t, s.checkFlag())
+	s.InternalAPICAFile = s.InternalAPICertFile
+	assert.NoError(t, s.checkFlag())
+
+	s.InternalAPIPort = 70000
+	assert.Error(t, s.checkFlag())
+}
+
+// writeTestCertificate writes a self-signed certificate, which is also its own CA, and its key.
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubeturbo"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certFile, keyFile := filepath.Join(t.TempDir(), "tls.crt"), filepath.Join(t.TempDir(), "tls.key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestCheckFlagSchedulerExtender(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.EnableSchedulerExtender = true
+	s.SchedulerExtenderMaxUtilization = 0.8
+	assert.NoError(t, s.checkFlag())
+
+	s.SchedulerExtenderMaxUtilization = 1.5
+	assert.Error(t, s.checkFlag())
+
+	s.EnableSchedulerExtender = false
+	assert.NoError(t, s.checkFlag())
+}
+
+func TestCheckFlagMovePlacement(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.MovePlacement = "scheduler"
+	assert.NoError(t, s.checkFlag())
+
+	s.MovePlacement = "node"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagDiscoverySnapshotReuseWindow(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.DiscoveryIntervalSec = 600
+	s.DiscoverySnapshotReuseWindow = 5 * time.Minute
+	assert.NoError(t, s.checkFlag())
+
+	s.DiscoverySnapshotReuseWindow = 10 * time.Minute
+	assert.Error(t, s.checkFlag())
+
+	s.DiscoverySnapshotReuseWindow = -time.Second
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagMaxUnchangedDiscoveries(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.MaxUnchangedDiscoveries = 5
+	assert.NoError(t, s.checkFlag())
+
+	s.MaxUnchangedDiscoveries = -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagKubeConfigDir(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.KubeConfigDir = "/etc/kubeconfigs"
+	assert.NoError(t, s.checkFlag())
+
+	s.KubeConfig = "/etc/kubeconfig"
+	assert.Error(t, s.checkFlag())
+
+	s.KubeConfig = ""
+	s.DiscoveryMaster = "https://read-replica:6443"
+	assert.Error(t, s.checkFlag())
+}
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: cluster
+  cluster:
+    server: %s
+contexts:
+- name: context
+  context:
+    cluster: cluster
+    user: user
+current-context: context
+users:
+- name: user
+  user:
+    token: token
+`
+
+func TestLoadKubeConfigs(t *testing.T) {
+	dir := t.TempDir()
+	_, err := loadKubeConfigs(dir)
+	assert.Error(t, err)
+
+	for name, server := range map[string]string{
+		"prod-east.yaml": "https://prod-east:6443",
+		"dev":            "https://dev:6443",
+		".hidden":        "https://hidden:6443",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(fmt.Sprintf(testKubeConfig, server)), 0600))
+	}
+	// The data directory of a mounted Secret
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0700))
+
+	kubeConfigs, err := loadKubeConfigs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, kubeConfigs, 2)
+	assert.Equal(t, "dev", kubeConfigs[0].name)
+	assert.Equal(t, "https://dev:6443", kubeConfigs[0].config.Host)
+	assert.Equal(t, "prod-east", kubeConfigs[1].name)
+	assert.Equal(t, "https://prod-east:6443", kubeConfigs[1].config.Host)
+	assert.Equal(t, "token", kubeConfigs[1].config.BearerToken)
+
+	// A broken kubeconfig is skipped
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("not a kubeconfig"), 0600))
+	kubeConfigs, err = loadKubeConfigs(dir)
+	assert.NoError(t, err)
+	assert.Len(t, kubeConfigs, 2)
+}
+
+func TestCheckFlagActionPolicyConfigMap(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.ActionPolicyConfigMap = "action-policy"
+	assert.NoError(t, s.checkFlag())
+
+	s.ActionPolicyConfigMap = "turbo/action-policy"
+	assert.NoError(t, s.checkFlag())
+	namespace, name, err := parseActionPolicyConfigMap(s.ActionPolicyConfigMap)
+	assert.NoError(t, err)
+	assert.Equal(t, "turbo", namespace)
+	assert.Equal(t, "action-policy", name)
+
+	for _, invalid := range []string{"/action-policy", "turbo/", "turbo/action/policy"} {
+		s.ActionPolicyConfigMap = invalid
+		assert.Error(t, s.checkFlag())
+	}
+}
+
+func TestCheckFlagMaxConcurrentActions(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.MaxConcurrentActions = map[string]int{"node": 1, "move": 5}
+	s.ActionQueueTimeout = time.Minute
+	assert.NoError(t, s.checkFlag())
+
+	s.ActionQueueTimeout = 0
+	assert.Error(t, s.checkFlag())
+
+	s.ActionQueueTimeout = time.Minute
+	s.MaxConcurrentActions = map[string]int{"drain": 1}
+	assert.Error(t, s.checkFlag())
+
+	s.MaxConcurrentActions = map[string]int{"move": -1}
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagMonitoringSourcePriority(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.MonitoringSourcePriority = []string{"Kubelet", "Prometheus"}
+	assert.NoError(t, s.checkFlag())
+
+	s.MonitoringSourcePriority = []string{"Kubelet", "Graphite"}
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagResizeLimits(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.ResizeMinCPU, s.ResizeMinMemory, s.ResizeMaxChangePercent = "10m", "16Mi", 50
+	assert.NoError(t, s.checkFlag())
+
+	s.ResizeMinMemory = "16 MiB"
+	assert.Error(t, s.checkFlag())
+
+	s.ResizeMinMemory, s.ResizeMaxChangePercent = "", -1
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagDumpDTOsDir(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.DumpDTOsDir = t.TempDir()
+	assert.NoError(t, s.checkFlag())
+
+	s.DumpDTOsDir = filepath.Join(s.DumpDTOsDir, "missing")
+	assert.Error(t, s.checkFlag())
+}
+
+func TestCheckFlagDebugTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.DebugTokenFile = filepath.Join(dir, "token")
+	assert.NoError(t, os.WriteFile(s.DebugTokenFile, []byte("secret\n"), 0600))
+	assert.NoError(t, s.checkFlag())
+	token, err := readDebugToken(s.DebugTokenFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", token)
+
+	assert.NoError(t, os.WriteFile(s.DebugTokenFile, []byte(" \n"), 0600))
+	assert.Error(t, s.checkFlag())
+
+	s.DebugTokenFile = filepath.Join(dir, "missing")
+	assert.Error(t, s.checkFlag())
+}
+
+func TestDiscoverySnapshotHandlerUnauthorized(t *testing.T) {
+	handler := discoverySnapshotHandler(nil, "secret")
+	for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+		request := httptest.NewRequest(http.MethodGet, "/debug/discovery", nil)
+		request.Header.Set("Authorization", authorization)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code, authorization)
+	}
+
+	// An authorized request of an unknown target
+	request := httptest.NewRequest(http.MethodGet, "/debug/discovery?target=unknown", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestRediscoverHandler(t *testing.T) {
+	handler := rediscoverHandler(nil, "secret", time.Minute, rediscoverTarget)
+	request := httptest.NewRequest(http.MethodPost, "/rediscover", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// The discovery is only triggered by the POST requests
+	request = httptest.NewRequest(http.MethodGet, "/rediscover", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodPost, "/rediscover?target=unknown", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// The rediscovery goes through the Turbo API, which is not configured
+	pipelines := []*clusterPipeline{{
+		tapSpec:    &kubeturbo.K8sTAPServiceSpec{K8sTargetConfig: &configs.K8sTargetConfig{TargetIdentifier: "prod"}},
+		tapService: &kubeturbo.K8sTAPService{},
+	}}
+	request = httptest.NewRequest(http.MethodPost, "/rediscover", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	rediscoverHandler(pipelines, "secret", time.Minute, rediscoverTarget).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotImplemented, recorder.Code)
+
+	request = httptest.NewRequest(http.MethodPost, "/rediscover?timeout=soon", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	recorder = httptest.NewRecorder()
+	rediscoverHandler(pipelines, "secret", time.Minute, rediscoverTarget).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestRediscoverHandlerTimeout(t *testing.T) {
+	pipelines := []*clusterPipeline{{
+		tapSpec: &kubeturbo.K8sTAPServiceSpec{K8sTargetConfig: &configs.K8sTargetConfig{TargetIdentifier: "prod"}},
+	}}
+	var timeouts []time.Duration
+	handler := rediscoverHandler(pipelines, "secret", time.Minute,
+		func(pipeline *clusterPipeline, timeout time.Duration) (*kubeturbo.Rediscovery, error) {
+			timeouts = append(timeouts, timeout)
+			return &kubeturbo.Rediscovery{UUIDs: []string{"uuid"}}, kubeturbo.ErrRediscoveryTimeout
+		})
+
+	// The timeout of a request only applies to that request
+	for _, query := range []string{"?timeout=10s", "", "?timeout=2m"} {
+		request := httptest.NewRequest(http.MethodPost, "/rediscover"+query, nil)
+		request.Header.Set("Authorization", "Bearer secret")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	}
+	assert.Equal(t, []time.Duration{10 * time.Second, time.Minute, time.Minute}, timeouts)
+}
+
+func TestCheckFlagClusterName(t *testing.T) {
+	s := NewVMTServer()
+	s.KubeletPort = DefaultKubeletPort
+	s.ClusterName = "prod-east"
+	assert.NoError(t, s.checkFlag())
+
+	s.ClusterName = " prod-east"
+	assert.Error(t, s.checkFlag())
+
+	// Each cluster of the kubeconfig directory is named after its kubeconfig
+	s.ClusterName = "prod-east"
+	s.KubeConfigDir = "/etc/kubeconfigs"
+	assert.Error(t, s.checkFlag())
+}
+
+func TestSetFlagsFromEnv(t *testing.T) {
+	s := NewVMTServer()
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	s.AddFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"--kube-api-burst=50"}))
+
+	t.Setenv("KUBETURBO_DISCOVERY_INTERVAL_SEC", "300")
+	t.Setenv("KUBETURBO_KUBE_API_BURST", "40")
+	assert.NoError(t, SetFlagsFromEnv(fs))
+	assert.Equal(t, 300, s.DiscoveryIntervalSec)
+	// The command line takes precedence
+	assert.Equal(t, 50, s.KubeAPIBurst)
+
+	t.Setenv("KUBETURBO_KUBE_API_QPS", "fast")
+	assert.Error(t, SetFlagsFromEnv(fs))
+}
@@ -39,6 +39,7 @@ import (
 //   - goflag FlagSet will be parsed first
 //   - pflag FlagSet will be parsed next
 //
+// - Set the flags not set on the command line from the KUBETURBO_ environment variables
 // - Sync those glog flags that also appear in klog flags
 //
 // Return log flush frequency
@@ -74,6 +75,10 @@ func initLogs(s *app.VMTServer) *time.Duration {
 	// We have all the defined flags, now parse it
 	pflag.CommandLine.SetNormalizeFunc(wordSepNormalizeFunc)
 	pflag.Parse()
+	// Set the flags not set on the command line from the environment
+	if err := app.SetFlagsFromEnv(pflag.CommandLine); err != nil {
+		glog.Fatalf("Failed to set the flags from the environment: %v", err)
+	}
 
 	// Sync the glog and klog flags
 	pflag.CommandLine.VisitAll(func(glogFlag *pflag.Flag) {
@@ -0,0 +1,73 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/audit"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+)
+
+// The results of the actions recorded in the audit log
+const (
+	auditResultExecute   = "execute"
+	auditResultRecommend = "recommend"
+	auditResultSucceeded = "succeeded"
+	auditResultRefused   = "refused"
+	auditResultFailed    = "failed"
+)
+
+// auditAction records the event of the given kind of the action in the audit log, if enabled.
+func (h *ActionHandler) auditAction(kind string, actionItem *proto.ActionItemDTO, result, message string) {
+	if h.config.auditRecorder == nil {
+		return
+	}
+	targetSE := actionItem.GetTargetSE()
+	namespace, _ := property.GetWorkloadNamespaceFromProperty(targetSE.GetEntityProperties())
+	h.config.auditRecorder.Record(audit.Event{
+		Kind:       kind,
+		ActionID:   actionItem.GetUuid(),
+		ActionType: actionItem.GetActionType().String(),
+		Entity:     fmt.Sprintf("%v %s/%s", targetSE.GetEntityType(), namespace, targetSE.GetDisplayName()),
+		Result:     result,
+		Message:    message,
+	})
+}
+
+// auditOutcome records the outcome of the action, with the description of the succeeded action or the error of the
+// refused or failed action.
+func (h *ActionHandler) auditOutcome(actionItem *proto.ActionItemDTO, description string, err error) {
+	switch {
+	case err == nil:
+		h.auditAction(audit.KindActionOutcome, actionItem, auditResultSucceeded, description)
+	case isRefusal(err):
+		h.auditAction(audit.KindActionOutcome, actionItem, auditResultRefused, err.Error())
+	default:
+		h.auditAction(audit.KindActionOutcome, actionItem, auditResultFailed, err.Error())
+	}
+}
+
+func isRefusal(err error) bool {
+	_, refused := util.GetRefusalReason(err)
+	return refused
+}
+
+// auditProgress records the steps of the action reported by the executor in the audit log, before passing them on.
+type auditProgress struct {
+	executor.ProgressReporter
+	handler    *ActionHandler
+	actionItem *proto.ActionItemDTO
+}
+
+func (p *auditProgress) ReportProgress(description string) {
+	p.handler.auditAction(audit.KindActionStep, p.actionItem, "", description)
+	p.ProgressReporter.ReportProgress(description)
+}
+
+func (p *auditProgress) ReportStep(percent int32, description string) {
+	p.handler.auditAction(audit.KindActionStep, p.actionItem, "", fmt.Sprintf("%d%%: %s", percent, description))
+	p.ProgressReporter.ReportStep(percent, description)
+}
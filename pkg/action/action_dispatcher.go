@@ -0,0 +1,164 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+const (
+	// The category of the node provision and suspend actions, in addition to the move, resize and scale categories of
+	// the workload actions
+	ActionCategoryNode = "node"
+
+	DefaultActionQueueTimeout = 5 * time.Minute
+)
+
+// ActionCategories returns the categories of the actions which can be limited by the action dispatcher.
+func ActionCategories() []string {
+	categories := []string{ActionCategoryNode}
+	for category := range policyActionTypes {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// actionCategory returns the category of the action type, or an empty string if it has none.
+func actionCategory(actionType turboActionType) string {
+	if actionType == turboActionMachineProvision || actionType == turboActionMachineSuspend {
+		return ActionCategoryNode
+	}
+	for category, actionTypes := range policyActionTypes {
+		for _, categoryType := range actionTypes {
+			if categoryType == actionType {
+				return category
+			}
+		}
+	}
+	return ""
+}
+
+// actionDispatcher bounds the number of the actions of each category executed at once, so that a storm of actions
+// does not destabilize the cluster. The actions beyond the limit of their category wait in a FIFO queue, and are
+// refused if they wait longer than the queue timeout.
+type actionDispatcher struct {
+	limiters     map[string]*actionLimiter
+	queueTimeout time.Duration
+}
+
+// newActionDispatcher creates a dispatcher with the given limits by category. The categories without a positive
+// limit are not limited.
+func newActionDispatcher(limits map[string]int, queueTimeout time.Duration) *actionDispatcher {
+	limiters := make(map[string]*actionLimiter)
+	for category, limit := range limits {
+		if limit > 0 {
+			limiters[category] = &actionLimiter{limit: limit}
+		}
+	}
+	return &actionDispatcher{limiters: limiters, queueTimeout: queueTimeout}
+}
+
+// ValidateActionLimits checks that the action limits are set for known categories and are not negative.
+func ValidateActionLimits(limits map[string]int) error {
+	categories := ActionCategories()
+	for category, limit := range limits {
+		if i := sort.SearchStrings(categories, category); i == len(categories) || categories[i] != category {
+			return fmt.Errorf("unknown action category %q, should be one of %s", category,
+				strings.Join(categories, ", "))
+		}
+		if limit < 0 {
+			return fmt.Errorf("the limit %d of the %s actions should not be negative", limit, category)
+		}
+	}
+	return nil
+}
+
+// acquire waits for a slot to execute an action of the given type, and returns the function releasing it. The
+// action is refused if no slot is available within the queue timeout.
+func (d *actionDispatcher) acquire(actionType turboActionType, progress *actionProgress) (func(), error) {
+	category := actionCategory(actionType)
+	limiter, found := d.limiters[category]
+	if !found {
+		return func() {}, nil
+	}
+	if !limiter.tryAcquire() {
+		glog.V(3).Infof("Queueing the %s action: %d %s actions are running.", category, limiter.limit, category)
+		progress.ReportProgress(fmt.Sprintf("queued behind %d running %s actions", limiter.limit, category))
+		if !limiter.acquire(d.queueTimeout) {
+			return nil, util.NewActionRefusalError(util.ReasonActionQueueTimeout,
+				"no %s action slot became available within %v, with at most %d %s actions at once",
+				category, d.queueTimeout, limiter.limit, category)
+		}
+		progress.ReportProgress("in progress")
+	}
+	return limiter.release, nil
+}
+
+// actionLimiter is a semaphore whose waiters are served in FIFO order.
+type actionLimiter struct {
+	lock    sync.Mutex
+	limit   int
+	running int
+	// The waiters, each notified by closing its channel when a slot is handed over to it
+	queue []chan struct{}
+}
+
+func (l *actionLimiter) tryAcquire() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.running < l.limit && len(l.queue) == 0 {
+		l.running++
+		return true
+	}
+	return false
+}
+
+// acquire waits in the queue for a slot for at most the timeout, and returns whether it got one.
+func (l *actionLimiter) acquire(timeout time.Duration) bool {
+	l.lock.Lock()
+	if l.running < l.limit && len(l.queue) == 0 {
+		l.running++
+		l.lock.Unlock()
+		return true
+	}
+	ready := make(chan struct{})
+	l.queue = append(l.queue, ready)
+	l.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for i, waiter := range l.queue {
+		if waiter == ready {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return false
+		}
+	}
+	// The slot was handed over right after the timeout
+	return true
+}
+
+// release hands the slot over to the first waiter, if any.
+func (l *actionLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.queue) > 0 {
+		close(l.queue[0])
+		l.queue = l.queue[1:]
+		return
+	}
+	l.running--
+}
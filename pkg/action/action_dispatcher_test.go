@@ -0,0 +1,92 @@
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+func TestActionCategory(t *testing.T) {
+	tests := []struct {
+		actionType turboActionType
+		category   string
+	}{
+		{turboActionPodMove, PolicyActionMove},
+		{turboActionContainerResize, PolicyActionResize},
+		{turboActionControllerScale, PolicyActionScale},
+		{turboActionMachineProvision, ActionCategoryNode},
+		{turboActionMachineSuspend, ActionCategoryNode},
+	}
+	for _, tt := range tests {
+		if category := actionCategory(tt.actionType); category != tt.category {
+			t.Errorf("actionCategory(%v) = %q, want %q", tt.actionType, category, tt.category)
+		}
+	}
+}
+
+func TestActionLimiterFIFO(t *testing.T) {
+	limiter := &actionLimiter{limit: 1}
+	if !limiter.tryAcquire() {
+		t.Fatalf("Expect the first action to get a slot")
+	}
+	if limiter.tryAcquire() {
+		t.Fatalf("Expect no slot beyond the limit")
+	}
+
+	order := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			if limiter.acquire(time.Minute) {
+				order <- i
+			}
+		}(i)
+		// Queue the waiters in order
+		for queued := false; !queued; {
+			limiter.lock.Lock()
+			queued = len(limiter.queue) == i+1
+			limiter.lock.Unlock()
+		}
+	}
+	limiter.release()
+	if first := <-order; first != 0 {
+		t.Errorf("Expect the first queued action to get the slot first, got %d", first)
+	}
+	limiter.release()
+	if second := <-order; second != 1 {
+		t.Errorf("Expect the second queued action to get the slot next, got %d", second)
+	}
+	limiter.release()
+	if limiter.running != 0 || len(limiter.queue) != 0 {
+		t.Errorf("Expect all the slots to be released, got %d running and %d queued", limiter.running,
+			len(limiter.queue))
+	}
+}
+
+func TestActionDispatcherQueueTimeout(t *testing.T) {
+	dispatcher := newActionDispatcher(map[string]int{ActionCategoryNode: 1, PolicyActionMove: 0}, time.Millisecond)
+	progress := newActionProgress()
+	release, err := dispatcher.acquire(turboActionMachineSuspend, progress)
+	if err != nil {
+		t.Fatalf("Expect the first node action to get a slot, got %v", err)
+	}
+	_, err = dispatcher.acquire(turboActionMachineProvision, progress)
+	if reason, refused := util.GetRefusalReason(err); !refused || reason != util.ReasonActionQueueTimeout {
+		t.Errorf("Expect the action to be refused with %v, got %v", util.ReasonActionQueueTimeout, err)
+	}
+	if len(dispatcher.limiters[ActionCategoryNode].queue) != 0 {
+		t.Errorf("Expect the timed out action to leave the queue")
+	}
+
+	// The moves are not limited
+	for i := 0; i < 3; i++ {
+		if _, err := dispatcher.acquire(turboActionPodMove, progress); err != nil {
+			t.Errorf("Expect the moves not to be limited, got %v", err)
+		}
+	}
+
+	release()
+	if _, err := dispatcher.acquire(turboActionMachineProvision, progress); err != nil {
+		t.Errorf("Expect the node action to get the released slot, got %v", err)
+	}
+}
@@ -2,17 +2,23 @@ package action
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/executor"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/audit"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/probemetrics"
 	"github.com/turbonomic/kubeturbo/pkg/resourcemapping"
 	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 
 	sdkprobe "github.com/turbonomic/turbo-go-sdk/pkg/probe"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
@@ -25,6 +31,13 @@ import (
 const (
 	defaultActionCacheTTL  = time.Second * 100
 	defaultPodNameCacheTTL = 10 * time.Minute
+	// How long the machine sets and the node groups stay locked once kept across a restart, long enough for the
+	// scaling interrupted by the restart to complete
+	defaultScalingCacheTTL = 30 * time.Minute
+
+	// The action modes: the actions are either executed, or only logged with the plan of what would be done
+	ActionModeExecute   = "execute"
+	ActionModeRecommend = "recommend"
 )
 
 type turboActionType struct {
@@ -56,6 +69,45 @@ type ActionHandlerConfig struct {
 	readinessRetryThreshold int
 	gitConfig               gitops.GitConfig
 	k8sClusterId            string
+	// Whether to record the result of the most recent action on the annotations of the target object
+	annotateActionResults bool
+	// Emits the Kubernetes events with the action results on the target objects, nil if disabled
+	eventRecorder record.EventRecorder
+	// Tracks whether the last discovery is degraded, and whether to skip the actions while it is
+	discoveryStatus                *discoveryutil.DiscoveryStatus
+	skipActionsOnDegradedDiscovery bool
+	// How long to wait for the rollout of a workload controller resize, no wait if not positive
+	resizeRolloutTimeout time.Duration
+	// The guardrails of the container resize actions
+	resizeLimits executor.ResizeLimits
+	// Whether the actions are executed or only recommended, executed if empty
+	actionMode string
+	// How the node suspend actions are executed, by scaling down the machine set if empty
+	nodeSuspendMode         string
+	maxConcurrentNodeDrains int
+	// How the pods of the ReplicaSets are moved, by cloning the pod if empty, and how long a move waits for the new
+	// pod to be registered in the endpoints of the pod
+	podMoveStrategy      string
+	moveEndpointsTimeout time.Duration
+	// Who places the cloned pods of the moves, the Turbo server if empty
+	movePlacement string
+	// The ConfigMap holding the action policy, no action is excluded if the name is empty
+	actionPolicyNamespace string
+	actionPolicyName      string
+	// The maximum number of the actions of each category executed at once, and how long an action waits for a slot
+	actionLimits       map[string]int
+	actionQueueTimeout time.Duration
+	// Whether the description of the refused actions sent to the server starts with the refusal reason, e.g.
+	// [POD_ALREADY_ON_HOST]
+	refusalReasonPrefix bool
+	// Records the actions received, the decisions, the execution steps and the outcomes, nil if disabled
+	auditRecorder *audit.Recorder
+	// The directory of the files keeping the state of the actions across the restarts, in memory only if empty, and
+	// the prefix of the files of the target
+	stateDir    string
+	statePrefix string
+	// The target of the actions, which labels the action metrics
+	target string
 }
 
 func NewActionHandlerConfig(cApiNamespace string, kubeletClient *kubeletclient.KubeletClient,
@@ -85,6 +137,91 @@ func NewActionHandlerConfig(cApiNamespace string, kubeletClient *kubeletclient.K
 	return config
 }
 
+func (c *ActionHandlerConfig) WithAnnotateActionResults(annotateActionResults bool) *ActionHandlerConfig {
+	c.annotateActionResults = annotateActionResults
+	return c
+}
+
+func (c *ActionHandlerConfig) WithEventRecorder(eventRecorder record.EventRecorder) *ActionHandlerConfig {
+	c.eventRecorder = eventRecorder
+	return c
+}
+
+func (c *ActionHandlerConfig) WithSkipActionsOnDegradedDiscovery(discoveryStatus *discoveryutil.DiscoveryStatus,
+	skipActionsOnDegradedDiscovery bool) *ActionHandlerConfig {
+	c.discoveryStatus = discoveryStatus
+	c.skipActionsOnDegradedDiscovery = skipActionsOnDegradedDiscovery
+	return c
+}
+
+func (c *ActionHandlerConfig) WithResizeRolloutTimeout(resizeRolloutTimeout time.Duration) *ActionHandlerConfig {
+	c.resizeRolloutTimeout = resizeRolloutTimeout
+	return c
+}
+
+func (c *ActionHandlerConfig) WithResizeLimits(resizeLimits executor.ResizeLimits) *ActionHandlerConfig {
+	c.resizeLimits = resizeLimits
+	return c
+}
+
+func (c *ActionHandlerConfig) WithActionMode(actionMode string) *ActionHandlerConfig {
+	c.actionMode = actionMode
+	return c
+}
+
+func (c *ActionHandlerConfig) WithNodeSuspendMode(nodeSuspendMode string,
+	maxConcurrentNodeDrains int) *ActionHandlerConfig {
+	c.nodeSuspendMode = nodeSuspendMode
+	c.maxConcurrentNodeDrains = maxConcurrentNodeDrains
+	return c
+}
+
+func (c *ActionHandlerConfig) WithPodMoveStrategy(podMoveStrategy string,
+	moveEndpointsTimeout time.Duration) *ActionHandlerConfig {
+	c.podMoveStrategy = podMoveStrategy
+	c.moveEndpointsTimeout = moveEndpointsTimeout
+	return c
+}
+
+func (c *ActionHandlerConfig) WithMovePlacement(movePlacement string) *ActionHandlerConfig {
+	c.movePlacement = movePlacement
+	return c
+}
+
+func (c *ActionHandlerConfig) WithActionPolicyConfigMap(namespace, name string) *ActionHandlerConfig {
+	c.actionPolicyNamespace = namespace
+	c.actionPolicyName = name
+	return c
+}
+
+func (c *ActionHandlerConfig) WithActionLimits(actionLimits map[string]int,
+	actionQueueTimeout time.Duration) *ActionHandlerConfig {
+	c.actionLimits = actionLimits
+	c.actionQueueTimeout = actionQueueTimeout
+	return c
+}
+
+func (c *ActionHandlerConfig) WithStateDir(stateDir, statePrefix string) *ActionHandlerConfig {
+	c.stateDir = stateDir
+	c.statePrefix = statePrefix
+	return c
+}
+
+func (c *ActionHandlerConfig) WithTarget(target string) *ActionHandlerConfig {
+	c.target = target
+	return c
+}
+
+func (c *ActionHandlerConfig) WithRefusalReasonPrefix(refusalReasonPrefix bool) *ActionHandlerConfig {
+	c.refusalReasonPrefix = refusalReasonPrefix
+	return c
+}
+
+func (c *ActionHandlerConfig) WithAuditRecorder(auditRecorder *audit.Recorder) *ActionHandlerConfig {
+	c.auditRecorder = auditRecorder
+	return c
+}
+
 type ActionHandler struct {
 	config *ActionHandlerConfig
 
@@ -106,28 +243,100 @@ type ActionHandler struct {
 	lockMap *util.ExpirationMap
 
 	podManager util.IPodManager
+
+	// Records the action results on the target objects, nil if disabled
+	resultAnnotator *executor.ActionResultAnnotator
+	// Emits the events with the action results on the target objects, nil if disabled
+	eventRecorder *executor.ActionEventRecorder
+	// Keeps the policy of the workloads excluded from the actions, nil if disabled
+	policyWatcher *ActionPolicyWatcher
+	// Bounds the number of the concurrent actions of each category, nil if unlimited
+	dispatcher *actionDispatcher
+	// Keeps the actions in progress across the restarts, nil if disabled
+	journal *actionJournal
+	// Keeps the state of the actions across the restarts, nil if disabled
+	stateStore *turbostore.BoltStore
+
+	// Tracks the actions in progress, so that they complete before kubeturbo shuts down
+	shutdownLock sync.Mutex
+	shuttingDown bool
+	inFlight     sync.WaitGroup
 }
 
 // Build new ActionHandler and start it.
 func NewActionHandler(config *ActionHandlerConfig) *ActionHandler {
 	lmap := util.NewExpirationMap(defaultActionCacheTTL)
 	podsGetter := config.clusterScraper.Clientset.CoreV1()
-	podCachedManager := util.NewPodCachedManager(turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache, podsGetter)
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	var journal *actionJournal
+	var store *turbostore.BoltStore
+	if config.stateDir != "" {
+		// The pods renamed by the recent actions, the actions in progress and the move locks survive the restarts
+		var err error
+		if store, err = turbostore.OpenBoltStore(config.statePath("state.db")); err != nil {
+			glog.Errorf("Failed to open the state of the actions, kept in memory only: %v", err)
+		}
+	}
+	if store != nil {
+		if cache, err := store.Cache("pod-names", defaultPodNameCacheTTL); err != nil {
+			glog.Errorf("Failed to load the renamed pods, kept in memory only: %v", err)
+		} else {
+			podCache = cache
+		}
+		if cache, err := store.Cache("actions", 0); err != nil {
+			glog.Errorf("Failed to load the actions in progress, not kept: %v", err)
+		} else {
+			journal = newActionJournal(cache)
+		}
+		if cache, err := store.Cache("locks", defaultActionCacheTTL); err != nil {
+			glog.Errorf("Failed to load the move locks, kept in memory only: %v", err)
+		} else {
+			lmap.WithStore(cache)
+		}
+	}
+	podCachedManager := util.NewPodCachedManager(podCache, podsGetter)
 
 	handler := &ActionHandler{
 		config:          config,
 		actionExecutors: make(map[turboActionType]executor.TurboActionExecutor),
 		podManager:      podCachedManager,
+		journal:         journal,
+		stateStore:      store,
 	}
+	handler.journal.reportInterrupted(config.auditRecorder)
 
 	go lmap.Run(config.StopEverything)
 	handler.lockMap = lmap
 	handler.registerActionExecutors()
 	handler.lockStore = newActionLockStore(lmap, handler.getRelatedPod)
+	if config.annotateActionResults {
+		handler.resultAnnotator = executor.NewActionResultAnnotator(config.clusterScraper.DynamicClient).
+			WithControllerGroupVersions(config.clusterScraper.ControllerGroupVersions())
+	}
+	if config.eventRecorder != nil {
+		handler.eventRecorder = executor.NewActionEventRecorder(config.clusterScraper.DynamicClient, config.eventRecorder).
+			WithControllerGroupVersions(config.clusterScraper.ControllerGroupVersions())
+	}
+	if config.actionPolicyName != "" {
+		handler.policyWatcher = NewActionPolicyWatcher(config.clusterScraper.Clientset, config.actionPolicyNamespace,
+			config.actionPolicyName)
+		handler.policyWatcher.Run(config.StopEverything)
+	}
+	if len(config.actionLimits) > 0 {
+		handler.dispatcher = newActionDispatcher(config.actionLimits, config.actionQueueTimeout)
+	}
 
 	return handler
 }
 
+// statePath returns the path of the given file of the state of the actions of the target.
+func (c *ActionHandlerConfig) statePath(name string) string {
+	if c.statePrefix != "" {
+		name = strings.ReplaceAll(c.statePrefix, string(filepath.Separator), "_") + "-" + name
+	}
+	return filepath.Join(c.stateDir, name)
+}
+
 // Register supported action executor.
 // As action executor is stateless, they can be safely reused.
 func (h *ActionHandler) registerActionExecutors() {
@@ -136,7 +345,9 @@ func (h *ActionHandler) registerActionExecutors() {
 		h.config.ormClient, c.gitConfig, c.k8sClusterId)
 
 	reScheduler := executor.NewReScheduler(ae, c.sccAllowedSet, c.failVolumePodMoves,
-		c.updateQuotaToAllowMoves, h.lockMap, c.readinessRetryThreshold)
+		c.updateQuotaToAllowMoves, h.lockMap, c.readinessRetryThreshold).
+		WithMoveStrategy(c.podMoveStrategy, c.moveEndpointsTimeout).
+		WithMovePlacement(c.movePlacement)
 
 	h.actionExecutors[turboActionPodMove] = reScheduler
 
@@ -145,17 +356,39 @@ func (h *ActionHandler) registerActionExecutors() {
 	h.actionExecutors[turboActionPodSuspend] = horizontalScaler
 	h.actionExecutors[turboActionControllerScale] = horizontalScaler
 
-	containerResizer := executor.NewContainerResizer(ae, c.kubeletClient, c.sccAllowedSet)
+	containerResizer := executor.NewContainerResizer(ae, c.kubeletClient, c.sccAllowedSet).
+		WithResizeLimits(c.resizeLimits)
 	h.actionExecutors[turboActionContainerResize] = containerResizer
 
-	controllerResizer := executor.NewWorkloadControllerResizer(ae, c.kubeletClient, c.sccAllowedSet, h.lockMap)
+	controllerResizer := executor.NewWorkloadControllerResizer(ae, c.kubeletClient, c.sccAllowedSet, h.lockMap).
+		WithRolloutTimeout(c.resizeRolloutTimeout).
+		WithResizeLimits(c.resizeLimits)
 	h.actionExecutors[turboActionControllerResize] = controllerResizer
 
 	// Register machine scaler anyway as machine API may be enabled or disabled at runtime, but the registration
 	// only happens at kubeturbo start up time. During actual action execution, machine API will be checked again.
-	machineScaler := executor.NewMachineActionExecutor(c.cAPINamespace, ae)
+	machineScaler := executor.NewMachineActionExecutor(c.cAPINamespace, ae).
+		WithInProgressCaches(h.inProgressCache("machine-sets"), h.inProgressCache("node-groups"))
 	h.actionExecutors[turboActionMachineProvision] = machineScaler
 	h.actionExecutors[turboActionMachineSuspend] = machineScaler
+	// The nodes may be cordoned or drained instead, to be removed outside of kubeturbo
+	if c.nodeSuspendMode == executor.NodeSuspendModeCordon || c.nodeSuspendMode == executor.NodeSuspendModeDrain {
+		h.actionExecutors[turboActionMachineSuspend] = executor.NewNodeMaintainer(c.clusterScraper.Clientset,
+			c.nodeSuspendMode, c.maxConcurrentNodeDrains)
+	}
+}
+
+// inProgressCache returns the cache of the actions in progress kept in the given bucket of the state store, or in
+// memory if the state is not kept.
+func (h *ActionHandler) inProgressCache(bucket string) executor.InProgressCache {
+	if h.stateStore != nil {
+		cache, err := h.stateStore.Cache(bucket, defaultScalingCacheTTL)
+		if err == nil {
+			return cache
+		}
+		glog.Errorf("Failed to load the %s in progress, kept in memory only: %v", bucket, err)
+	}
+	return turbostore.NewCache()
 }
 
 // Implement ActionExecutorClient interface defined in Go SDK.
@@ -168,23 +401,154 @@ func (h *ActionHandler) ExecuteAction(actionExecutionDTO *proto.ActionExecutionD
 	// Check if the action execution DTO is valid, including if the action is supported or not
 	if err := h.checkActionExecutionDTO(actionExecutionDTO); err != nil {
 		glog.Errorf("Invalid action %v: %v", actionExecutionDTO, err)
-		return h.failedResult(err.Error()), err
+		if len(actionExecutionDTO.GetActionItem()) > 0 {
+			h.auditOutcome(actionExecutionDTO.GetActionItem()[0], "", err)
+		}
+		return h.failedResult(h.errorDescription(err)), err
+	}
+	actionItem := actionExecutionDTO.GetActionItem()[0]
+	actionType := actionItem.GetActionType().String()
+	h.auditAction(audit.KindActionReceived, actionItem, "", describeActionPlan(actionExecutionDTO.GetActionItem()))
+	if err := h.beginAction(); err != nil {
+		glog.Warningf("Skip action %s: %v", actionItem.GetUuid(), err)
+		h.auditOutcome(actionItem, "", err)
+		return h.failedResult(h.errorDescription(err)), err
+	}
+	defer h.inFlight.Done()
+	h.journal.begin(actionExecutionDTO.GetActionItem())
+	defer h.journal.end(actionItem)
+	start := time.Now()
+	description, err := h.executeAction(actionExecutionDTO, progressTracker)
+	probemetrics.ObserveAction(h.config.target, actionType, actionMetricResult(err), time.Since(start))
+	h.auditOutcome(actionItem, description, err)
+	if err != nil {
+		return h.failedResult(h.errorDescription(err)), err
+	}
+	return h.goodResult(description), nil
+}
+
+// executeAction executes the action, and returns the description of its outcome reported to the server, if any.
+func (h *ActionHandler) executeAction(actionExecutionDTO *proto.ActionExecutionDTO,
+	progressTracker sdkprobe.ActionProgressTracker) (string, error) {
+	// Only log what the action would do in the recommend mode, without any change to the cluster
+	if h.config.actionMode == ActionModeRecommend {
+		err := h.recommend(actionExecutionDTO.GetActionItem())
+		h.auditAction(audit.KindActionDecision, actionExecutionDTO.GetActionItem()[0], auditResultRecommend,
+			err.Error())
+		return "", err
+	}
+	// Skip the action if the last discovery, which the action may be based on, is degraded
+	if err := h.checkDiscoveryStatus(); err != nil {
+		glog.Warningf("Skip action %s: %v", actionExecutionDTO.GetActionItem()[0].GetUuid(), err)
+		h.auditAction(audit.KindActionDecision, actionExecutionDTO.GetActionItem()[0], auditResultRefused,
+			err.Error())
+		return "", err
 	}
 
-	// 2. keep sending fake progress to prevent timeout
+	// 2. keep sending progress to prevent timeout
 	stop := make(chan struct{})
 	defer close(stop)
-	go keepAlive(progressTracker, stop)
-
-	// 3. execute the action
-	glog.V(3).Infof("Now wait for action result")
-	err := h.execute(actionExecutionDTO.GetActionItem())
+	progress := newActionProgress()
+	go keepAlive(progressTracker, progress, stop)
+
+	// 3. wait for the concurrent actions of the same category to complete
+	var description string
+	release, err := h.acquireActionSlot(actionExecutionDTO.GetActionItem()[0], progress)
+	if err == nil {
+		defer release()
+		// 4. execute the action
+		glog.V(3).Infof("Now wait for action result")
+		description, err = h.execute(actionExecutionDTO.GetActionItem(), progress)
+	}
 	if err != nil {
+		if reason, refused := util.GetRefusalReason(err); refused {
+			glog.V(2).Infof("Action %s is refused with reason %s: %s", actionExecutionDTO.GetActionItem()[0].GetUuid(),
+				reason, reason.Description())
+		}
 		glog.Errorf("action execution error: %++v", err)
-		return h.failedResult(err.Error()), err
+		return "", err
 	}
+	return description, nil
+}
+
+// beginAction counts the action as in progress, or refuses it if kubeturbo is shutting down.
+func (h *ActionHandler) beginAction() error {
+	h.shutdownLock.Lock()
+	defer h.shutdownLock.Unlock()
+	if h.shuttingDown {
+		return util.NewActionRefusalError(util.ReasonShuttingDown, "kubeturbo is shutting down")
+	}
+	h.inFlight.Add(1)
+	return nil
+}
+
+// Shutdown stops accepting new actions, and waits for the actions in progress to complete for at most the timeout,
+// then closes the state store. It returns false if some actions are still in progress after the timeout, leaving the
+// store open for them.
+func (h *ActionHandler) Shutdown(timeout time.Duration) bool {
+	h.shutdownLock.Lock()
+	h.shuttingDown = true
+	h.shutdownLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		if h.stateStore != nil {
+			if err := h.stateStore.Close(); err != nil {
+				glog.Errorf("Failed to close the state of the actions: %v", err)
+			}
+		}
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// acquireActionSlot waits until the action can be executed within the concurrency limit of its category, and
+// returns the function releasing its slot once executed.
+func (h *ActionHandler) acquireActionSlot(actionItem *proto.ActionItemDTO,
+	progress *actionProgress) (func(), error) {
+	if h.dispatcher == nil {
+		return func() {}, nil
+	}
+	return h.dispatcher.acquire(getTurboActionType(actionItem), progress)
+}
+
+// actionMetricResult returns the result of an action execution with the given error reported in the metrics.
+func actionMetricResult(err error) string {
+	if err == nil {
+		return probemetrics.ActionSucceeded
+	}
+	if _, refused := util.GetRefusalReason(err); refused {
+		return probemetrics.ActionRefused
+	}
+	return probemetrics.ActionFailed
+}
 
-	return h.goodResult(), nil
+// Logs the plan of the action in the recommend mode, and refuses the action so that the server does not consider
+// it as executed.
+func (h *ActionHandler) recommend(actionItems []*proto.ActionItemDTO) error {
+	plan := describeActionPlan(actionItems)
+	glog.Infof("Recommend mode: action %s is not executed, it would %s", actionItems[0].GetUuid(), plan)
+	return util.NewActionRefusalError(util.ReasonRecommendMode, "kubeturbo would %s", plan)
+}
+
+// Checks if the last discovery is degraded when the actions are skipped on degraded discoveries.
+func (h *ActionHandler) checkDiscoveryStatus() error {
+	if !h.config.skipActionsOnDegradedDiscovery || h.config.discoveryStatus == nil {
+		return nil
+	}
+	if degraded, reasons := h.config.discoveryStatus.IsDegraded(); degraded {
+		return util.NewActionRefusalError(util.ReasonDegradedDiscovery,
+			"the last discovery is degraded: %s", strings.Join(reasons, "; "))
+	}
+	return nil
 }
 
 func isPodRelevantAction(actionItem *proto.ActionItemDTO) bool {
@@ -193,7 +557,7 @@ func isPodRelevantAction(actionItem *proto.ActionItemDTO) bool {
 		entityType == proto.EntityDTO_CONTAINER
 }
 
-func (h *ActionHandler) execute(actionItems []*proto.ActionItemDTO) error {
+func (h *ActionHandler) execute(actionItems []*proto.ActionItemDTO, progress executor.ProgressReporter) (string, error) {
 	// Only acquire lock for pod actions so they can be sequentialized
 	// We sequentialize pod actions because there could be different types of actions
 	// generated for the same pod at the same time, e.g., resize and provision
@@ -209,7 +573,7 @@ func (h *ActionHandler) execute(actionItems []*proto.ActionItemDTO) error {
 		// getLock() returns error if it times out (default timeout value is set in lockStore
 		lock, err := h.lockStore.getLock(actionItem)
 		if err != nil {
-			return err
+			return "", err
 		}
 		// Unlock the entity after the action execution is finished
 		// defer is applied to the function scope
@@ -220,29 +584,43 @@ func (h *ActionHandler) execute(actionItems []*proto.ActionItemDTO) error {
 		// and created a new one. In such case, the action should be applied to the new pod.
 		pod, err = h.getRelatedPod(actionItem)
 		if err != nil {
-			return fmt.Errorf("cannot find the related pod for action item %s: %v",
+			return "", fmt.Errorf("cannot find the related pod for action item %s: %v",
 				actionItem.GetUuid(), err)
 		}
 	}
 
-	input := &executor.TurboActionExecutorInput{
-		ActionItems: actionItems,
-		Pod:         pod,
+	if err := h.checkWorkload(actionItem, pod); err != nil {
+		glog.Warningf("Skip action %s: %v", actionItem.GetUuid(), err)
+		h.auditAction(audit.KindActionDecision, actionItem, auditResultRefused, err.Error())
+		return "", err
 	}
 
 	actionType := getTurboActionType(actionItem)
 	worker := h.actionExecutors[actionType]
+	h.auditAction(audit.KindActionDecision, actionItem, auditResultExecute, "")
+	if h.config.auditRecorder != nil {
+		progress = &auditProgress{ProgressReporter: progress, handler: h, actionItem: actionItem}
+	}
+	input := &executor.TurboActionExecutorInput{
+		ActionItems: actionItems,
+		Pod:         pod,
+		Progress:    progress,
+	}
 	namespace, _ := property.GetWorkloadNamespaceFromProperty(actionItem.GetTargetSE().GetEntityProperties())
 	output, err := worker.Execute(input)
+	h.annotateResult(actionItem, pod, output, err)
 	if err != nil {
 		glog.Errorf("Failed to execute action %v on %v [%v/%v]: %v",
 			actionType.actionType, actionItem.GetTargetSE().GetEntityType(),
 			namespace, actionItem.GetTargetSE().GetDisplayName(), err)
-		return err
+		return "", err
 	}
 	// Process the action execution output, including caching the pod name change.
 	h.processOutput(output)
-	return nil
+	if output == nil {
+		return "", nil
+	}
+	return output.Description, nil
 }
 
 // Finds the pod associated to the action item DTO. The pod, if any, will be used to lock the associated actions.
@@ -272,6 +650,21 @@ func (h *ActionHandler) getRelatedPod(actionItem *proto.ActionItemDTO) (*api.Pod
 	return h.podManager.GetPodFromDisplayNameOrUUID(podEntity.GetDisplayName(), podEntity.GetId())
 }
 
+// Records the action result on the annotations of the target object and as an event on it, if enabled.
+// For successful pod actions that recreate the pod, the result is recorded on the new pod.
+func (h *ActionHandler) annotateResult(actionItem *proto.ActionItemDTO, pod *api.Pod,
+	output *executor.TurboActionExecutorOutput, err error) {
+	if err == nil && output != nil && output.NewPod != nil {
+		pod = output.NewPod
+	}
+	if h.resultAnnotator != nil {
+		h.resultAnnotator.Annotate(actionItem, pod, err)
+	}
+	if h.eventRecorder != nil {
+		h.eventRecorder.Record(actionItem, pod, err)
+	}
+}
+
 // Processes the output of the action execution generated by the executor.
 // The pod change made by the executor, if any, will be cached in the pod manager for
 // further actions on the same pod.
@@ -289,11 +682,14 @@ func getTurboActionType(ai *proto.ActionItemDTO) turboActionType {
 	return turboActionType{ai.GetActionType(), ai.GetTargetSE().GetEntityType()}
 }
 
-func (h *ActionHandler) goodResult() *proto.ActionResult {
+func (h *ActionHandler) goodResult(description string) *proto.ActionResult {
 
 	state := proto.ActionResponseState_SUCCEEDED
 	progress := int32(100)
 	msg := "Success"
+	if description != "" {
+		msg += ", " + description
+	}
 
 	res := &proto.ActionResponse{
 		ActionResponseState: &state,
@@ -306,6 +702,15 @@ func (h *ActionHandler) goodResult() *proto.ActionResult {
 	}
 }
 
+// errorDescription returns the description of the failed action sent to the server, starting with the refusal
+// reason of a refused action if enabled.
+func (h *ActionHandler) errorDescription(err error) string {
+	if reason, refused := util.GetRefusalReason(err); refused && h.config.refusalReasonPrefix {
+		return fmt.Sprintf("[%s] %v", reason, err)
+	}
+	return err.Error()
+}
+
 func (h *ActionHandler) failedResult(msg string) *proto.ActionResult {
 
 	state := proto.ActionResponseState_FAILED
@@ -323,7 +728,57 @@ func (h *ActionHandler) failedResult(msg string) *proto.ActionResult {
 	}
 }
 
-func keepAlive(tracker sdkprobe.ActionProgressTracker, stop chan struct{}) {
+// actionProgress holds the description of the progress of the action in progress, and the percentage of the action
+// completed if reported, as reported by the executor. It is sent to the server by keepAlive.
+type actionProgress struct {
+	lock        sync.Mutex
+	description string
+	percent     int32
+}
+
+func newActionProgress() *actionProgress {
+	return &actionProgress{description: "in progress"}
+}
+
+func (p *actionProgress) ReportProgress(description string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.description = description
+}
+
+func (p *actionProgress) ReportStep(percent int32, description string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.description = description
+	p.percent = percent
+}
+
+func (p *actionProgress) getDescription() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.description
+}
+
+// nextPercent returns the percentage of the action completed to send after the last one sent: the one reported by
+// the executor, or the last one increased by 1 for the executors which do not report it. The percentage never
+// decreases, and stays below 100 until the action completes.
+func (p *actionProgress) nextPercent(last int32) int32 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	next := last + 1
+	if p.percent > 0 {
+		next = p.percent
+	}
+	if next < last {
+		next = last
+	}
+	if next > 99 {
+		next = 99
+	}
+	return next
+}
+
+func keepAlive(tracker sdkprobe.ActionProgressTracker, actionProgress *actionProgress, stop chan struct{}) {
 
 	// TODO: add timeout
 	go func() {
@@ -331,12 +786,9 @@ func keepAlive(tracker sdkprobe.ActionProgressTracker, stop chan struct{}) {
 		state := proto.ActionResponseState_IN_PROGRESS
 
 		for {
-			progress = progress + 1
-			if progress > 99 {
-				progress = 99
-			}
+			progress = actionProgress.nextPercent(progress)
 
-			tracker.UpdateProgress(state, "in progress", progress)
+			tracker.UpdateProgress(state, actionProgress.getDescription(), progress)
 
 			t := time.NewTimer(time.Second * 3)
 			select {
@@ -2,7 +2,11 @@ package action
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,9 +17,11 @@ import (
 
 	"github.com/turbonomic/kubeturbo/pkg/action/executor"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/audit"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+	"github.com/turbonomic/kubeturbo/pkg/probemetrics"
 	"github.com/turbonomic/kubeturbo/pkg/turbostore"
 )
 
@@ -91,6 +97,251 @@ func TestActionHandler_ExecuteAction_Unsupported_Action(t *testing.T) {
 	}
 }
 
+func TestActionHandler_ExecuteAction_Degraded_Discovery(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	discoveryStatus := discoveryutil.NewDiscoveryStatus()
+	h.config.WithSkipActionsOnDegradedDiscovery(discoveryStatus, true)
+	mockProgressTrack := &mockProgressTrack{}
+
+	// A watch error during the discovery degrades the cycle
+	discoveryStatus.Begin()
+	discoveryStatus.RecordWatchError("namespaces", fmt.Errorf("watch closed"))
+	if degraded, _ := discoveryStatus.Complete(); !degraded {
+		t.Errorf("Expect the discovery to be degraded")
+	}
+	result, err := h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil, mockProgressTrack)
+	if reason, refused := util.GetRefusalReason(err); !refused || reason != util.ReasonDegradedDiscovery {
+		t.Errorf("Expect the action to be refused with %v, got %v", util.ReasonDegradedDiscovery, err)
+	}
+	if *result.Response.ActionResponseState != proto.ActionResponseState_FAILED {
+		t.Errorf("ActionHandler.ExecuteAction(): action response (%v) is not %v",
+			result.Response.ActionResponseState, proto.ActionResponseState_FAILED)
+	}
+
+	// The actions are executed again after a consistent discovery
+	discoveryStatus.Begin()
+	discoveryStatus.Complete()
+	result, err = h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil, mockProgressTrack)
+	if err != nil {
+		t.Errorf("ActionHandler.ExecuteAction(): error = %v", err)
+	}
+	if *result.Response.ActionResponseState != proto.ActionResponseState_SUCCEEDED {
+		t.Errorf("ActionHandler.ExecuteAction(): action response (%v) is not %v",
+			result.Response.ActionResponseState, proto.ActionResponseState_SUCCEEDED)
+	}
+}
+
+func TestActionHandler_ExecuteAction_Recommend_Mode(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	h.config.WithActionMode(ActionModeRecommend)
+	actionExecutionDTO := newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE())
+	currentNode, newNode := "node-1", "node-2"
+	actionExecutionDTO.ActionItem[0].CurrentSE = &proto.EntityDTO{DisplayName: &currentNode}
+	actionExecutionDTO.ActionItem[0].NewSE = &proto.EntityDTO{DisplayName: &newNode}
+	mockProgressTrack := &mockProgressTrack{}
+
+	result, err := h.ExecuteAction(actionExecutionDTO, nil, mockProgressTrack)
+	if reason, refused := util.GetRefusalReason(err); !refused || reason != util.ReasonRecommendMode {
+		t.Errorf("Expect the action to be refused with %v, got %v", util.ReasonRecommendMode, err)
+	}
+	if *result.Response.ActionResponseState != proto.ActionResponseState_FAILED {
+		t.Errorf("ActionHandler.ExecuteAction(): action response (%v) is not %v",
+			result.Response.ActionResponseState, proto.ActionResponseState_FAILED)
+	}
+	expected := "move CONTAINER_POD " + mockPodDispName + " from node-1 to node-2"
+	if !strings.Contains(result.Response.GetResponseDescription(), expected) {
+		t.Errorf("Expect the action response to describe the plan %q, got %q", expected,
+			result.Response.GetResponseDescription())
+	}
+	// The pod is not moved
+	if _, ok := podCache.Get(mockPodId); ok {
+		t.Errorf("The pod change is cached in the recommend mode")
+	}
+
+	// The description starts with the refusal reason if enabled
+	h.config.WithRefusalReasonPrefix(true)
+	result, _ = h.ExecuteAction(actionExecutionDTO, nil, mockProgressTrack)
+	if expected := "[" + string(util.ReasonRecommendMode) + "] "; !strings.Contains(
+		result.Response.GetResponseDescription(), expected) {
+		t.Errorf("Expect the action response to start with %q, got %q", expected,
+			result.Response.GetResponseDescription())
+	}
+}
+
+func TestActionHandler_ExecuteAction_Audit(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	auditLog := audit.NewLog(audit.NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"), 0), 100)
+	h.config.WithAuditRecorder(auditLog.Recorder("prod"))
+	mockProgressTrack := &mockProgressTrack{}
+
+	if _, err := h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil,
+		mockProgressTrack); err != nil {
+		t.Errorf("ActionHandler.ExecuteAction(): error = %v", err)
+	}
+	h.config.WithActionMode(ActionModeRecommend)
+	h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil, mockProgressTrack)
+
+	var kinds, results []string
+	for _, event := range auditLog.Query(audit.Query{Target: "prod"}) {
+		kinds = append(kinds, event.Kind)
+		results = append(results, event.Result)
+	}
+	expectedKinds := []string{audit.KindActionReceived, audit.KindActionDecision, audit.KindActionOutcome,
+		audit.KindActionReceived, audit.KindActionDecision, audit.KindActionOutcome}
+	expectedResults := []string{"", auditResultExecute, auditResultSucceeded,
+		"", auditResultRecommend, auditResultRefused}
+	if strings.Join(kinds, ",") != strings.Join(expectedKinds, ",") ||
+		strings.Join(results, ",") != strings.Join(expectedResults, ",") {
+		t.Errorf("Expect the audit events %v with the results %v, got %v with %v", expectedKinds, expectedResults,
+			kinds, results)
+	}
+}
+
+func TestActionHandler_Interrupted_Actions(t *testing.T) {
+	dir := t.TempDir()
+	store, err := turbostore.OpenBoltStore(filepath.Join(dir, "prod-state.db"))
+	if err != nil {
+		t.Fatalf("Failed to create the state store: %v", err)
+	}
+	previous, err := store.Cache("actions", 0)
+	if err != nil {
+		t.Fatalf("Failed to create the action journal: %v", err)
+	}
+	previous.Set("action-1", "move CONTAINER_POD "+mockPodDispName, -1)
+	previous, err = store.Cache("locks", 0)
+	if err != nil {
+		t.Fatalf("Failed to create the move locks: %v", err)
+	}
+	previous.Set("pod-lock", "pod-lock", time.Minute)
+	store.Close()
+
+	auditLog := audit.NewLog(audit.NewFileSink(filepath.Join(dir, "audit.jsonl"), 0), 100)
+	config := newActionHandlerConfig().WithStateDir(dir, "prod").WithAuditRecorder(auditLog.Recorder("prod"))
+	h := NewActionHandler(config)
+	events := auditLog.Query(audit.Query{ActionID: "action-1"})
+	if len(events) != 1 || events[0].Result != auditResultInterrupted {
+		t.Errorf("Expect the interrupted action to be recorded, got %+v", events)
+	}
+	if items := h.journal.cache.Items(); len(items) != 0 {
+		t.Errorf("Expect the interrupted actions to be forgotten, got %v", items)
+	}
+	if _, ok := h.lockMap.Add("pod-lock", "pod-lock", func(obj interface{}) {}); ok {
+		t.Errorf("Expect the move lock held before the restart to be held again")
+	}
+	if !h.Shutdown(time.Second) {
+		t.Errorf("Expect the shutdown to complete")
+	}
+}
+
+func TestActionHandler_Shutdown(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	mockProgressTrack := &mockProgressTrack{}
+
+	// An action in progress holds the shutdown until the timeout
+	if err := h.beginAction(); err != nil {
+		t.Fatalf("Expect the action to begin, got %v", err)
+	}
+	if h.Shutdown(time.Millisecond) {
+		t.Errorf("Expect the shutdown to time out with an action in progress")
+	}
+	h.inFlight.Done()
+	if !h.Shutdown(time.Second) {
+		t.Errorf("Expect the shutdown to complete without any action in progress")
+	}
+
+	// No new action is accepted
+	result, err := h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil,
+		mockProgressTrack)
+	if reason, refused := util.GetRefusalReason(err); !refused || reason != util.ReasonShuttingDown {
+		t.Errorf("Expect the action to be refused with %v, got %v", util.ReasonShuttingDown, err)
+	}
+	if *result.Response.ActionResponseState != proto.ActionResponseState_FAILED {
+		t.Errorf("ActionHandler.ExecuteAction(): action response (%v) is not %v",
+			result.Response.ActionResponseState, proto.ActionResponseState_FAILED)
+	}
+}
+
+func TestActionMetricResult(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, probemetrics.ActionSucceeded},
+		{fmt.Errorf("failed"), probemetrics.ActionFailed},
+		{util.NewActionRefusalError(util.ReasonRecommendMode, "recommend only"), probemetrics.ActionRefused},
+	}
+	for _, tt := range tests {
+		if got := actionMetricResult(tt.err); got != tt.want {
+			t.Errorf("actionMetricResult(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestActionProgressNextPercent(t *testing.T) {
+	progress := newActionProgress()
+	// The percentage increases by 1 until the executor reports one
+	if got := progress.nextPercent(0); got != 1 {
+		t.Errorf("nextPercent(0) = %v, want 1", got)
+	}
+	progress.ReportStep(50, "halfway")
+	if got := progress.nextPercent(1); got != 50 {
+		t.Errorf("nextPercent(1) = %v, want 50", got)
+	}
+	if got := progress.nextPercent(50); got != 50 {
+		t.Errorf("nextPercent(50) = %v, want 50", got)
+	}
+	if got := progress.getDescription(); got != "halfway" {
+		t.Errorf("getDescription() = %v, want halfway", got)
+	}
+	// The percentage never decreases
+	progress.ReportStep(10, "started")
+	if got := progress.nextPercent(50); got != 50 {
+		t.Errorf("nextPercent(50) = %v, want 50", got)
+	}
+	progress.ReportStep(100, "done")
+	if got := progress.nextPercent(50); got != 99 {
+		t.Errorf("nextPercent(50) = %v, want 99", got)
+	}
+}
+
+func TestDescribeActionPlan(t *testing.T) {
+	containerType := proto.EntityDTO_CONTAINER
+	containerName := "ns/pod-1/app"
+	vcpu := proto.CommodityDTO_VCPU
+	currentCapacity, newCapacity := 100.0, 200.0
+	resizeType := proto.ActionItemDTO_RIGHT_SIZE
+	resize := &proto.ActionItemDTO{
+		ActionType:  &resizeType,
+		TargetSE:    &proto.EntityDTO{EntityType: &containerType, DisplayName: &containerName},
+		CurrentComm: &proto.CommodityDTO{CommodityType: &vcpu, Capacity: &currentCapacity},
+		NewComm:     &proto.CommodityDTO{CommodityType: &vcpu, Capacity: &newCapacity},
+	}
+	provisionType := proto.ActionItemDTO_PROVISION
+	provision := &proto.ActionItemDTO{ActionType: &provisionType, TargetSE: newTargetSE()}
+
+	tests := []struct {
+		name        string
+		actionItems []*proto.ActionItemDTO
+		want        string
+	}{
+		{"resize", []*proto.ActionItemDTO{resize}, "right_size VCPU of CONTAINER ns/pod-1/app from 100 to 200"},
+		{"provision", []*proto.ActionItemDTO{provision}, "provision CONTAINER_POD " + mockPodDispName},
+		{"multiple items", []*proto.ActionItemDTO{resize, resize},
+			"right_size VCPU of CONTAINER ns/pod-1/app from 100 to 200; right_size VCPU of CONTAINER ns/pod-1/app from 100 to 200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeActionPlan(tt.actionItems); got != tt.want {
+				t.Errorf("describeActionPlan() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func newActionHandler(cache turbostore.ITurboCache) *ActionHandler {
 	config := newActionHandlerConfig()
 	actionExecutors := make(map[turboActionType]executor.TurboActionExecutor)
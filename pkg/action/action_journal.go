@@ -0,0 +1,60 @@
+package action
+
+import (
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/audit"
+	"github.com/turbonomic/kubeturbo/pkg/turbostore"
+)
+
+const (
+	// The result of the actions interrupted by a restart of kubeturbo recorded in the audit log
+	auditResultInterrupted = "interrupted"
+)
+
+// actionJournal keeps the actions in progress in the state store, so that the actions interrupted by a restart of kubeturbo,
+// whose outcome is unknown, are reported at the next start. A nil journal keeps nothing.
+type actionJournal struct {
+	cache *turbostore.BoltCache
+}
+
+func newActionJournal(cache *turbostore.BoltCache) *actionJournal {
+	return &actionJournal{cache: cache}
+}
+
+// begin records the action as in progress, with the description of its plan.
+func (j *actionJournal) begin(actionItems []*proto.ActionItemDTO) {
+	if j == nil {
+		return
+	}
+	j.cache.Set(actionItems[0].GetUuid(), describeActionPlan(actionItems), -1)
+}
+
+// end records the action as completed.
+func (j *actionJournal) end(actionItem *proto.ActionItemDTO) {
+	if j == nil {
+		return
+	}
+	j.cache.Delete(actionItem.GetUuid())
+}
+
+// reportInterrupted logs the actions left in progress by the previous run of kubeturbo, records them in the audit
+// log, and forgets them.
+func (j *actionJournal) reportInterrupted(auditRecorder *audit.Recorder) {
+	if j == nil {
+		return
+	}
+	for id, plan := range j.cache.Items() {
+		description, _ := plan.(string)
+		glog.Warningf("Action %s was in progress when kubeturbo stopped, its outcome is unknown: it would %s",
+			id, description)
+		auditRecorder.Record(audit.Event{
+			Kind:     audit.KindActionOutcome,
+			ActionID: id,
+			Result:   auditResultInterrupted,
+			Message:  description,
+		})
+		j.cache.Delete(id)
+	}
+}
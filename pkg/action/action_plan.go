@@ -0,0 +1,35 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// describeActionPlan returns a description of the changes the given action items would make to the cluster, e.g.,
+// "move CONTAINER_POD ns/pod-1 from node-1 to node-2" or
+// "right_size VCPU of CONTAINER ns/pod-1/app from 100 to 200".
+func describeActionPlan(actionItems []*proto.ActionItemDTO) string {
+	var steps []string
+	for _, actionItem := range actionItems {
+		steps = append(steps, describeActionItem(actionItem))
+	}
+	return strings.Join(steps, "; ")
+}
+
+func describeActionItem(actionItem *proto.ActionItemDTO) string {
+	targetSE := actionItem.GetTargetSE()
+	target := fmt.Sprintf("%v %s", targetSE.GetEntityType(), targetSE.GetDisplayName())
+	step := strings.ToLower(actionItem.GetActionType().String())
+	if currentComm, newComm := actionItem.GetCurrentComm(), actionItem.GetNewComm(); currentComm != nil && newComm != nil {
+		step = fmt.Sprintf("%s %v of %s from %v to %v", step, currentComm.GetCommodityType(), target,
+			currentComm.GetCapacity(), newComm.GetCapacity())
+	} else {
+		step = fmt.Sprintf("%s %s", step, target)
+	}
+	if currentSE, newSE := actionItem.GetCurrentSE(), actionItem.GetNewSE(); currentSE != nil && newSE != nil {
+		step = fmt.Sprintf("%s from %s to %s", step, currentSE.GetDisplayName(), newSE.GetDisplayName())
+	}
+	return step
+}
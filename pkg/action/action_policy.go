@@ -0,0 +1,214 @@
+package action
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// The key of the action policy in the data of the ConfigMap
+	ActionPolicyKey = "policy.yaml"
+
+	// The action types which can be excluded by the action policy
+	PolicyActionMove   = "move"
+	PolicyActionResize = "resize"
+	PolicyActionScale  = "scale"
+)
+
+// policyActionTypes maps the action types of the action policy to the actions they apply to.
+var policyActionTypes = map[string][]turboActionType{
+	PolicyActionMove:   {turboActionPodMove},
+	PolicyActionResize: {turboActionContainerResize, turboActionControllerResize},
+	PolicyActionScale:  {turboActionPodProvision, turboActionPodSuspend, turboActionControllerScale},
+}
+
+// ActionPolicy lists the workloads excluded from the actions executed by kubeturbo, e.g.
+//
+//	exclusions:
+//	- actions: [move, resize]
+//	  namespaces: [kube-system]
+//	- actions: [scale]
+//	  kinds: [StatefulSet]
+//	  labelSelector: app=database
+type ActionPolicy struct {
+	Exclusions []*ActionExclusion `json:"exclusions"`
+}
+
+// ActionExclusion excludes the workloads from the given action types. A workload is excluded if it matches all the
+// conditions set: it is in one of the namespaces, its controller is of one of the kinds, and the labels of its pods
+// match the label selector.
+type ActionExclusion struct {
+	Actions       []string `json:"actions"`
+	Namespaces    []string `json:"namespaces,omitempty"`
+	Kinds         []string `json:"kinds,omitempty"`
+	LabelSelector string   `json:"labelSelector,omitempty"`
+
+	selector labels.Selector
+}
+
+// ParseActionPolicy parses the action policy from its YAML or JSON form.
+func ParseActionPolicy(data string) (*ActionPolicy, error) {
+	policy := &ActionPolicy{}
+	if err := yaml.UnmarshalStrict([]byte(data), policy); err != nil {
+		return nil, err
+	}
+	for i, exclusion := range policy.Exclusions {
+		if len(exclusion.Actions) == 0 {
+			return nil, fmt.Errorf("exclusion %d lists no action", i)
+		}
+		for _, action := range exclusion.Actions {
+			if _, found := policyActionTypes[action]; !found {
+				return nil, fmt.Errorf("exclusion %d has invalid action %q, should be one of %s, %s or %s",
+					i, action, PolicyActionMove, PolicyActionResize, PolicyActionScale)
+			}
+		}
+		if len(exclusion.Namespaces) == 0 && len(exclusion.Kinds) == 0 && exclusion.LabelSelector == "" {
+			return nil, fmt.Errorf("exclusion %d has no namespace, kind or label selector", i)
+		}
+		selector, err := labels.Parse(exclusion.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("exclusion %d has invalid label selector %q: %v", i, exclusion.LabelSelector, err)
+		}
+		exclusion.selector = selector
+	}
+	return policy, nil
+}
+
+// appliesTo returns true if any exclusion of the policy applies to the action type.
+func (p *ActionPolicy) appliesTo(actionType turboActionType) bool {
+	if p == nil {
+		return false
+	}
+	for _, exclusion := range p.Exclusions {
+		if exclusion.appliesTo(actionType) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludes returns the exclusion of the policy which excludes the action on the target, or nil if it is allowed.
+func (p *ActionPolicy) excludes(actionType turboActionType, target *actionTarget) *ActionExclusion {
+	if p == nil {
+		return nil
+	}
+	for _, exclusion := range p.Exclusions {
+		if exclusion.appliesTo(actionType) && exclusion.matches(target) {
+			return exclusion
+		}
+	}
+	return nil
+}
+
+func (e *ActionExclusion) appliesTo(actionType turboActionType) bool {
+	for _, action := range e.Actions {
+		for _, excludedType := range policyActionTypes[action] {
+			if excludedType == actionType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *ActionExclusion) matches(target *actionTarget) bool {
+	if len(e.Namespaces) > 0 && !contains(e.Namespaces, target.namespace) {
+		return false
+	}
+	if len(e.Kinds) > 0 && !contains(e.Kinds, target.kind) {
+		return false
+	}
+	return e.selector == nil || e.selector.Matches(labels.Set(target.labels))
+}
+
+func (e *ActionExclusion) String() string {
+	return fmt.Sprintf("actions %v, namespaces %v, kinds %v, label selector %q", e.Actions, e.Namespaces,
+		e.Kinds, e.LabelSelector)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ActionPolicyWatcher keeps the action policy up to date with the ConfigMap holding it, so that the operators can
+// change the scope of the actions without restarting kubeturbo. There is no exclusion while the ConfigMap does not
+// exist, and the previous policy is kept while the ConfigMap holds an invalid one.
+type ActionPolicyWatcher struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+
+	lock   sync.RWMutex
+	policy *ActionPolicy
+}
+
+func NewActionPolicyWatcher(client kubernetes.Interface, namespace, name string) *ActionPolicyWatcher {
+	return &ActionPolicyWatcher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Run watches the ConfigMap until stopCh is closed.
+func (w *ActionPolicyWatcher) Run(stopCh <-chan struct{}) {
+	factory := informers.NewSharedInformerFactoryWithOptions(w.client, 0, informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", w.name).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.update(obj.(*api.ConfigMap))
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			w.update(obj.(*api.ConfigMap))
+		},
+		DeleteFunc: func(_ interface{}) {
+			glog.V(2).Infof("Action policy ConfigMap %s/%s is deleted, no action is excluded.", w.namespace, w.name)
+			w.setPolicy(nil)
+		},
+	})
+	glog.V(2).Infof("Watching the action policy ConfigMap %s/%s.", w.namespace, w.name)
+	factory.Start(stopCh)
+}
+
+func (w *ActionPolicyWatcher) update(configMap *api.ConfigMap) {
+	policy, err := ParseActionPolicy(configMap.Data[ActionPolicyKey])
+	if err != nil {
+		glog.Errorf("Invalid action policy in ConfigMap %s/%s, keeping the previous policy: %v",
+			w.namespace, w.name, err)
+		return
+	}
+	glog.V(2).Infof("Action policy is updated from ConfigMap %s/%s with %d exclusions.", w.namespace, w.name,
+		len(policy.Exclusions))
+	w.setPolicy(policy)
+}
+
+func (w *ActionPolicyWatcher) setPolicy(policy *ActionPolicy) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.policy = policy
+}
+
+// Policy returns the current action policy, nil if there is none.
+func (w *ActionPolicyWatcher) Policy() *ActionPolicy {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.policy
+}
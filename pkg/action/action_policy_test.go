@@ -0,0 +1,136 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/turbostore"
+)
+
+const testActionPolicy = `
+exclusions:
+- actions: [move, resize]
+  namespaces: [kube-system]
+- actions: [scale]
+  kinds: [StatefulSet]
+  labelSelector: app=database
+`
+
+func TestParseActionPolicy(t *testing.T) {
+	policy, err := ParseActionPolicy(testActionPolicy)
+	if err != nil {
+		t.Fatalf("ParseActionPolicy() error = %v", err)
+	}
+	if len(policy.Exclusions) != 2 {
+		t.Errorf("Expect 2 exclusions, got %d", len(policy.Exclusions))
+	}
+	if _, err := ParseActionPolicy(""); err != nil {
+		t.Errorf("Expect an empty policy to be valid, got %v", err)
+	}
+
+	for _, invalid := range []string{
+		`exclusions: [{actions: [delete], namespaces: [default]}]`,
+		`exclusions: [{namespaces: [default]}]`,
+		`exclusions: [{actions: [move]}]`,
+		`exclusions: [{actions: [move], labelSelector: "app in (a"}]`,
+		`exclusions: [{actions: [move], namespace: default}]`,
+	} {
+		if _, err := ParseActionPolicy(invalid); err == nil {
+			t.Errorf("Expect the action policy %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestActionPolicyExcludes(t *testing.T) {
+	policy, err := ParseActionPolicy(testActionPolicy)
+	if err != nil {
+		t.Fatalf("ParseActionPolicy() error = %v", err)
+	}
+	database := map[string]string{"app": "database"}
+	tests := []struct {
+		name       string
+		actionType turboActionType
+		target     *actionTarget
+		excluded   bool
+	}{
+		{"move in excluded namespace", turboActionPodMove,
+			&actionTarget{namespace: "kube-system", kind: "Deployment"}, true},
+		{"resize in excluded namespace", turboActionControllerResize,
+			&actionTarget{namespace: "kube-system", kind: "DaemonSet"}, true},
+		{"scale in excluded namespace", turboActionControllerScale,
+			&actionTarget{namespace: "kube-system", kind: "Deployment"}, false},
+		{"move in other namespace", turboActionPodMove,
+			&actionTarget{namespace: "default", kind: "StatefulSet", labels: database}, false},
+		{"scale of excluded kind and labels", turboActionPodProvision,
+			&actionTarget{namespace: "default", kind: "StatefulSet", labels: database}, true},
+		{"scale of excluded kind with other labels", turboActionControllerScale,
+			&actionTarget{namespace: "default", kind: "StatefulSet", labels: map[string]string{"app": "web"}}, false},
+		{"scale of other kind", turboActionControllerScale,
+			&actionTarget{namespace: "default", kind: "Deployment", labels: database}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if excluded := policy.excludes(tt.actionType, tt.target) != nil; excluded != tt.excluded {
+				t.Errorf("excludes() = %v, want %v", excluded, tt.excluded)
+			}
+		})
+	}
+
+	var noPolicy *ActionPolicy
+	if noPolicy.appliesTo(turboActionPodMove) || noPolicy.excludes(turboActionPodMove, &actionTarget{}) != nil {
+		t.Errorf("Expect no exclusion without an action policy")
+	}
+}
+
+func TestActionPolicyWatcherUpdate(t *testing.T) {
+	watcher := NewActionPolicyWatcher(nil, "turbo", "action-policy")
+	configMap := &api.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "turbo", Name: "action-policy"},
+		Data:       map[string]string{ActionPolicyKey: testActionPolicy},
+	}
+	watcher.update(configMap)
+	policy := watcher.Policy()
+	if policy == nil || len(policy.Exclusions) != 2 {
+		t.Fatalf("Expect the action policy to be updated, got %v", policy)
+	}
+
+	// The previous policy is kept when the ConfigMap is invalid
+	configMap.Data[ActionPolicyKey] = "exclusions: [{actions: [delete]}]"
+	watcher.update(configMap)
+	if watcher.Policy() != policy {
+		t.Errorf("Expect the previous action policy to be kept")
+	}
+}
+
+func TestActionHandler_ExecuteAction_Excluded_By_Policy(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	h.policyWatcher = NewActionPolicyWatcher(nil, "turbo", "action-policy")
+	mockProgressTrack := &mockProgressTrack{}
+
+	// No exclusion applies to the moves
+	h.policyWatcher.update(&api.ConfigMap{Data: map[string]string{
+		ActionPolicyKey: `exclusions: [{actions: [resize], namespaces: [` + mockPodNamespace + `]}]`,
+	}})
+	if _, err := h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil,
+		mockProgressTrack); err != nil {
+		t.Errorf("ActionHandler.ExecuteAction(): error = %v", err)
+	}
+
+	h.policyWatcher.update(&api.ConfigMap{Data: map[string]string{
+		ActionPolicyKey: `exclusions: [{actions: [move], namespaces: [` + mockPodNamespace + `]}]`,
+	}})
+	result, err := h.ExecuteAction(newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()), nil,
+		mockProgressTrack)
+	if reason, refused := util.GetRefusalReason(err); !refused || reason != util.ReasonExcludedByPolicy {
+		t.Errorf("Expect the action to be refused with %v, got %v", util.ReasonExcludedByPolicy, err)
+	}
+	if *result.Response.ActionResponseState != proto.ActionResponseState_FAILED {
+		t.Errorf("ActionHandler.ExecuteAction(): action response (%v) is not %v",
+			result.Response.ActionResponseState, proto.ActionResponseState_FAILED)
+	}
+}
@@ -0,0 +1,141 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// actionTarget describes the workload an action is executed on.
+type actionTarget struct {
+	namespace string
+	// The kind of the workload controller, empty for a bare pod
+	kind string
+	// The labels of the pods of the workload
+	labels map[string]string
+	// Whether neither the pod nor its workload controller opted out of the actions with their annotations
+	controllable bool
+}
+
+// isWorkloadAction returns true if the action is executed on a pod, a container or a workload controller.
+func isWorkloadAction(actionType turboActionType) bool {
+	switch actionType {
+	case turboActionPodMove, turboActionPodProvision, turboActionPodSuspend, turboActionContainerResize,
+		turboActionControllerScale, turboActionControllerResize:
+		return true
+	}
+	return false
+}
+
+// checkWorkload refuses the action if its workload opted out of the actions with the kubeturbo.io/controllable or
+// kubeturbo.io/monitored annotation, or if it is excluded from the action by the action policy. The opt-out of the
+// workload controllers is reported by the discovery, so the workload is only looked up if the action policy applies
+// to the action, and the action is not refused if the lookup fails.
+func (h *ActionHandler) checkWorkload(actionItem *proto.ActionItemDTO, pod *api.Pod) error {
+	actionType := getTurboActionType(actionItem)
+	if !isWorkloadAction(actionType) {
+		return nil
+	}
+	targetSE := actionItem.GetTargetSE()
+	if pod != nil && !discoveryutil.IsControllableFromAnnotation(pod.Annotations) {
+		return newOptedOutError(targetSE, pod.Namespace)
+	}
+	if h.policyWatcher == nil {
+		return nil
+	}
+	policy := h.policyWatcher.Policy()
+	// Skip the lookup of the workload if no exclusion applies to the action
+	if !policy.appliesTo(actionType) {
+		return nil
+	}
+	target, err := h.getActionTarget(actionItem, pod)
+	if err != nil {
+		glog.Warningf("Not checking the action policy for %s %s: failed to get the workload of the action: %v",
+			targetSE.GetEntityType(), targetSE.GetDisplayName(), err)
+		return nil
+	}
+	if !target.controllable {
+		return newOptedOutError(targetSE, target.namespace)
+	}
+	if exclusion := policy.excludes(actionType, target); exclusion != nil {
+		return util.NewActionRefusalError(util.ReasonExcludedByPolicy,
+			"%s %s in namespace %s is excluded by the action policy exclusion with %s",
+			targetSE.GetEntityType(), targetSE.GetDisplayName(), target.namespace, exclusion)
+	}
+	return nil
+}
+
+func newOptedOutError(targetSE *proto.EntityDTO, namespace string) error {
+	return util.NewActionRefusalError(util.ReasonOptedOut,
+		"%s %s in namespace %s or its controller opted out of the actions with the %s or %s annotation",
+		targetSE.GetEntityType(), targetSE.GetDisplayName(), namespace,
+		discoveryutil.TurboControllableAnnotation, discoveryutil.TurboMonitorAnnotation)
+}
+
+// getActionTarget returns the workload of the pod of a pod action, or the workload controller of a workload
+// controller action, with the labels of its pods.
+func (h *ActionHandler) getActionTarget(actionItem *proto.ActionItemDTO, pod *api.Pod) (*actionTarget, error) {
+	if pod != nil {
+		ownerInfo, _, _, err := h.config.clusterScraper.GetPodControllerInfo(pod, true)
+		if err != nil {
+			return nil, err
+		}
+		target := &actionTarget{
+			namespace:    pod.Namespace,
+			kind:         ownerInfo.Kind,
+			labels:       pod.Labels,
+			controllable: discoveryutil.IsControllableFromAnnotation(pod.Annotations),
+		}
+		if target.controllable && !discoveryutil.IsOwnerInfoEmpty(ownerInfo) {
+			controller, err := h.getController(ownerInfo.Kind, pod.Namespace, ownerInfo.Name)
+			if err != nil {
+				// The pods of the custom controllers are still handled by their own annotations
+				glog.V(3).Infof("Not checking the annotations of the controller of pod %s/%s: %v",
+					pod.Namespace, pod.Name, err)
+			} else {
+				target.controllable = discoveryutil.IsControllableFromAnnotation(controller.GetAnnotations())
+			}
+		}
+		return target, nil
+	}
+	namespace, name, kind, err := executor.GetWorkloadControllerInfo(actionItem.GetTargetSE())
+	if err != nil {
+		return nil, err
+	}
+	controller, err := h.getController(kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	podLabels, _, err := unstructured.NestedStringMap(controller.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pod labels of %s %s/%s: %v", kind, namespace, name, err)
+	}
+	return &actionTarget{
+		namespace:    namespace,
+		kind:         kind,
+		labels:       podLabels,
+		controllable: discoveryutil.IsControllableFromAnnotation(controller.GetAnnotations()),
+	}, nil
+}
+
+func (h *ActionHandler) getController(kind, namespace, name string) (*unstructured.Unstructured, error) {
+	res, err := executor.GetSupportedResUsingKind(h.config.clusterScraper.ControllerGroupVersions(), kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	controller, err := h.config.clusterScraper.DynamicClient.Resource(res).Namespace(namespace).
+		Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s/%s: %v", kind, namespace, name, err)
+	}
+	return controller, nil
+}
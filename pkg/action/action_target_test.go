@@ -0,0 +1,42 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/turbostore"
+)
+
+func TestActionHandler_checkWorkload(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	actionItem := newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE()).GetActionItem()[0]
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: mockPodNamespace, Name: mockPodName}}
+	// The workload is not looked up without an action policy
+	h.config.clusterScraper = nil
+
+	if err := h.checkWorkload(actionItem, pod); err != nil {
+		t.Errorf("checkWorkload() error = %v", err)
+	}
+
+	for _, annotation := range []string{discoveryutil.TurboControllableAnnotation, discoveryutil.TurboMonitorAnnotation} {
+		pod.Annotations = map[string]string{annotation: "false"}
+		err := h.checkWorkload(actionItem, pod)
+		if reason, refused := util.GetRefusalReason(err); !refused || reason != util.ReasonOptedOut {
+			t.Errorf("Expect the action to be refused with %v for %s, got %v", util.ReasonOptedOut, annotation, err)
+		}
+	}
+
+	// The node actions are not checked
+	pod.Annotations = map[string]string{discoveryutil.TurboControllableAnnotation: "false"}
+	vmType := proto.EntityDTO_VIRTUAL_MACHINE
+	actionItem.TargetSE.EntityType = &vmType
+	if err := h.checkWorkload(actionItem, nil); err != nil {
+		t.Errorf("checkWorkload() error = %v", err)
+	}
+}
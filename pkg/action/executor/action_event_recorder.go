@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	// The reasons of the Kubernetes events emitted for the executed actions
+	ActionSucceededReason = "TurboActionSucceeded"
+	ActionFailedReason    = "TurboActionFailed"
+	ActionRefusedReason   = "TurboActionRefused"
+
+	// The max length of the failure message kept in an action event
+	maxActionEventMessageLength = 1024
+)
+
+// ActionEventRecorder emits a Kubernetes event with the outcome of an action on its target object, so that the
+// actions executed against a pod or a workload controller show up in kubectl describe.
+type ActionEventRecorder struct {
+	dynamicClient dynamic.Interface
+	controllerGVs commonutil.ControllerGroupVersions
+	recorder      record.EventRecorder
+}
+
+func NewActionEventRecorder(dynamicClient dynamic.Interface, recorder record.EventRecorder) *ActionEventRecorder {
+	return &ActionEventRecorder{
+		dynamicClient: dynamicClient,
+		controllerGVs: commonutil.DefaultControllerGroupVersions(),
+		recorder:      recorder,
+	}
+}
+
+// WithControllerGroupVersions sets the group versions of the deployments and the replicasets of the cluster.
+func (r *ActionEventRecorder) WithControllerGroupVersions(
+	controllerGVs commonutil.ControllerGroupVersions) *ActionEventRecorder {
+	r.controllerGVs = controllerGVs
+	return r
+}
+
+// Record emits the event with the outcome of the action on its target object. The target is the workload controller
+// for workload controller actions, and the given pod otherwise.
+func (r *ActionEventRecorder) Record(actionItem *proto.ActionItemDTO, pod *api.Pod, actionErr error) {
+	target, err := r.getTarget(actionItem, pod)
+	if err != nil {
+		glog.Warningf("Skip recording the event of action %s: %v", actionItem.GetUuid(), err)
+		return
+	}
+	eventType, reason, message := actionEvent(actionItem, actionErr)
+	r.recorder.Event(target, eventType, reason, message)
+}
+
+// getTarget returns the target object of the action. The workload controller is fetched so that the event refers
+// to its uid, which kubectl describe matches the events by; a reference without the uid is used if it is not found.
+func (r *ActionEventRecorder) getTarget(actionItem *proto.ActionItemDTO, pod *api.Pod) (runtime.Object, error) {
+	if actionItem.GetTargetSE().GetEntityType() != proto.EntityDTO_WORKLOAD_CONTROLLER {
+		if pod == nil {
+			return nil, fmt.Errorf("no target object found")
+		}
+		return pod, nil
+	}
+	namespace, name, kind, err := GetWorkloadControllerInfo(actionItem.GetTargetSE())
+	if err != nil {
+		return nil, err
+	}
+	res, err := GetSupportedResUsingKind(r.controllerGVs, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := r.dynamicClient.Resource(res).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		glog.Warningf("Failed to get %s %s/%s to record the event of action %s: %v",
+			kind, namespace, name, actionItem.GetUuid(), err)
+		return &api.ObjectReference{
+			Kind:       kind,
+			APIVersion: res.GroupVersion().String(),
+			Namespace:  namespace,
+			Name:       name,
+		}, nil
+	}
+	return obj, nil
+}
+
+// actionEvent returns the type, the reason and the message of the event reporting the outcome of the action.
+func actionEvent(actionItem *proto.ActionItemDTO, actionErr error) (string, string, string) {
+	action := fmt.Sprintf("%s action %s", actionItem.GetActionType(), actionItem.GetUuid())
+	if actionErr == nil {
+		return api.EventTypeNormal, ActionSucceededReason, fmt.Sprintf("%s succeeded", action)
+	}
+	msg := actionErr.Error()
+	if len(msg) > maxActionEventMessageLength {
+		msg = msg[:maxActionEventMessageLength] + "..."
+	}
+	if reason, refused := util.GetRefusalReason(actionErr); refused {
+		return api.EventTypeWarning, ActionRefusedReason, fmt.Sprintf("%s refused: [%s] %s", action, reason, msg)
+	}
+	return api.EventTypeWarning, ActionFailedReason, fmt.Sprintf("%s failed: %s", action, msg)
+}
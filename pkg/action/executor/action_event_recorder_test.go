@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+func newTestEventRecorder(t *testing.T, server *httptest.Server) (*ActionEventRecorder, *record.FakeRecorder) {
+	dynamicClient, err := dynamic.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.Nil(t, err)
+	fakeRecorder := record.NewFakeRecorder(10)
+	return NewActionEventRecorder(dynamicClient, fakeRecorder), fakeRecorder
+}
+
+func newControllerActionItem(actionType proto.ActionItemDTO_ActionType, uuid string) *proto.ActionItemDTO {
+	controllerType := proto.EntityDTO_WORKLOAD_CONTROLLER
+	name := "deploy-1"
+	namespaceProp := "KubernetesNamespace"
+	namespace := "ns"
+	propNamespace := "DEFAULT"
+	return &proto.ActionItemDTO{ActionType: &actionType, Uuid: &uuid, TargetSE: &proto.EntityDTO{
+		EntityType:  &controllerType,
+		DisplayName: &name,
+		EntityProperties: []*proto.EntityDTO_EntityProperty{
+			{Namespace: &propNamespace, Name: &namespaceProp, Value: &namespace},
+		},
+		EntityData: &proto.EntityDTO_WorkloadControllerData_{WorkloadControllerData: &proto.EntityDTO_WorkloadControllerData{
+			ControllerType: &proto.EntityDTO_WorkloadControllerData_DeploymentData{
+				DeploymentData: &proto.EntityDTO_DeploymentData{},
+			},
+		}},
+	}}
+}
+
+func TestRecordPodActionSucceeded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	actionType := proto.ActionItemDTO_MOVE
+	uuid := "action-1"
+	podType := proto.EntityDTO_CONTAINER_POD
+	actionItem := &proto.ActionItemDTO{ActionType: &actionType, Uuid: &uuid, TargetSE: &proto.EntityDTO{EntityType: &podType}}
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"}}
+
+	recorder, fakeRecorder := newTestEventRecorder(t, server)
+	recorder.Record(actionItem, pod, nil)
+	assert.Equal(t, "Normal TurboActionSucceeded MOVE action action-1 succeeded", <-fakeRecorder.Events)
+}
+
+func TestRecordControllerActionFailed(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"deploy-1","namespace":"ns","uid":"uid-1"}}`)
+	}))
+	defer server.Close()
+
+	recorder, fakeRecorder := newTestEventRecorder(t, server)
+	recorder.Record(newControllerActionItem(proto.ActionItemDTO_RIGHT_SIZE, "action-2"), nil,
+		fmt.Errorf("limitrange violation"))
+	assert.Equal(t, []string{"/apis/apps/v1/namespaces/ns/deployments/deploy-1"}, paths)
+	assert.Equal(t, "Warning TurboActionFailed RIGHT_SIZE action action-2 failed: limitrange violation",
+		<-fakeRecorder.Events)
+
+	recorder.Record(newControllerActionItem(proto.ActionItemDTO_HORIZONTAL_SCALE, "action-3"), nil,
+		util.NewActionRefusalError(util.ReasonReplicasMaxSize, "max replicas reached"))
+	assert.Equal(t, "Warning TurboActionRefused HORIZONTAL_SCALE action action-3 refused: [REPLICAS_MAX_SIZE] max replicas reached",
+		<-fakeRecorder.Events)
+}
+
+func TestRecordControllerActionWithoutController(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	// The event is still recorded with a reference to the controller
+	recorder, fakeRecorder := newTestEventRecorder(t, server)
+	recorder.Record(newControllerActionItem(proto.ActionItemDTO_RIGHT_SIZE, "action-4"), nil, nil)
+	assert.Equal(t, "Normal TurboActionSucceeded RIGHT_SIZE action action-4 succeeded", <-fakeRecorder.Events)
+}
+
+func TestRecordWithoutTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	actionType := proto.ActionItemDTO_MOVE
+	podType := proto.EntityDTO_CONTAINER_POD
+	actionItem := &proto.ActionItemDTO{ActionType: &actionType, TargetSE: &proto.EntityDTO{EntityType: &podType}}
+
+	recorder, fakeRecorder := newTestEventRecorder(t, server)
+	recorder.Record(actionItem, nil, nil)
+	assert.Empty(t, fakeRecorder.Events)
+}
@@ -1,6 +1,8 @@
 package executor
 
 import (
+	"fmt"
+
 	api "k8s.io/api/core/v1"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
@@ -14,12 +16,58 @@ import (
 type TurboActionExecutorInput struct {
 	ActionItems []*proto.ActionItemDTO
 	Pod         *api.Pod
+	// Reports the progress of the action to the server, may be nil
+	Progress ProgressReporter
+}
+
+// The percentages of completion reported at the steps of the long-running actions: once the action has started,
+// halfway, e.g. once a workload controller is updated and its pods roll out, and when the action is finishing.
+const (
+	progressStarted   int32 = 10
+	progressHalfway   int32 = 50
+	progressFinishing int32 = 90
+)
+
+// ProgressReporter reports the progress of an action in progress, which is shown with the action in the server.
+type ProgressReporter interface {
+	ReportProgress(description string)
+	// ReportStep reports the step of the action with the percentage of the action completed
+	ReportStep(percent int32, description string)
+}
+
+// reportProgress reports the progress of an action with the given reporter, if any.
+func reportProgress(progress ProgressReporter, format string, args ...interface{}) {
+	if progress != nil {
+		progress.ReportProgress(fmt.Sprintf(format, args...))
+	}
+}
+
+// reportStep reports the step of an action, and the percentage of the action completed, with the given reporter,
+// if any.
+func reportStep(progress ProgressReporter, percent int32, format string, args ...interface{}) {
+	if progress != nil {
+		progress.ReportStep(percent, fmt.Sprintf(format, args...))
+	}
+}
+
+// stepPercent returns the percentage of completion of an action between the from and to percentages, when done of
+// total items, e.g. pods, have been processed.
+func stepPercent(from, to int32, done, total int64) int32 {
+	if total <= 0 || done >= total {
+		return to
+	}
+	if done <= 0 {
+		return from
+	}
+	return from + int32(int64(to-from)*done/total)
 }
 
 type TurboActionExecutorOutput struct {
 	Succeeded bool
 	OldPod    *api.Pod
 	NewPod    *api.Pod
+	// The outcome of the action reported to the server along with its success, if any
+	Description string
 }
 
 type TurboActionExecutor interface {
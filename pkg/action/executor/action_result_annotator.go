@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/turbonomic/kubeturbo/pkg/util"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	// The annotations recording the most recent action executed against a pod or a workload controller.
+	// Only the metadata of the target object is updated, so that the owning controllers do not see a
+	// change of the desired state and do not reconcile because of it.
+	LastActionAnnotation       = "kubeturbo.io/last-action"
+	LastActionTimeAnnotation   = "kubeturbo.io/last-action-time"
+	LastActionResultAnnotation = "kubeturbo.io/last-action-result"
+
+	LastActionResultSucceeded = "Succeeded"
+	LastActionResultFailed    = "Failed"
+
+	// The max length of the failure message kept in the last action result annotation
+	maxLastActionMessageLength = 256
+)
+
+// ActionResultAnnotator writes the outcome of an action onto the annotations of the target object.
+type ActionResultAnnotator struct {
+	dynamicClient dynamic.Interface
+	controllerGVs util.ControllerGroupVersions
+	now           func() time.Time
+}
+
+func NewActionResultAnnotator(dynamicClient dynamic.Interface) *ActionResultAnnotator {
+	return &ActionResultAnnotator{
+		dynamicClient: dynamicClient,
+		controllerGVs: util.DefaultControllerGroupVersions(),
+		now:           time.Now,
+	}
+}
+
+// WithControllerGroupVersions sets the group versions of the deployments and the replicasets of the cluster.
+func (a *ActionResultAnnotator) WithControllerGroupVersions(
+	controllerGVs util.ControllerGroupVersions) *ActionResultAnnotator {
+	a.controllerGVs = controllerGVs
+	return a
+}
+
+// Annotate records the outcome of the action on its target object. The target is the workload controller
+// for workload controller actions, and the given pod otherwise. Failing to annotate does not fail the action.
+func (a *ActionResultAnnotator) Annotate(actionItem *proto.ActionItemDTO, pod *api.Pod, actionErr error) {
+	res, namespace, name, err := a.getTarget(actionItem, pod)
+	if err != nil {
+		glog.Warningf("Skip annotating the result of action %s: %v", actionItem.GetUuid(), err)
+		return
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": a.lastActionAnnotations(actionItem, actionErr),
+		},
+	})
+	if err != nil {
+		glog.Warningf("Failed to build the last action annotations for %s/%s: %v", namespace, name, err)
+		return
+	}
+	_, err = a.dynamicClient.Resource(res).Namespace(namespace).Patch(context.TODO(), name,
+		types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		glog.Warningf("Failed to annotate %s %s/%s with the result of action %s: %v",
+			res.Resource, namespace, name, actionItem.GetUuid(), err)
+		return
+	}
+	glog.V(3).Infof("Annotated %s %s/%s with the result of action %s.", res.Resource, namespace, name, actionItem.GetUuid())
+}
+
+func (a *ActionResultAnnotator) getTarget(actionItem *proto.ActionItemDTO, pod *api.Pod) (schema.GroupVersionResource, string, string, error) {
+	if actionItem.GetTargetSE().GetEntityType() == proto.EntityDTO_WORKLOAD_CONTROLLER {
+		namespace, name, kind, err := GetWorkloadControllerInfo(actionItem.GetTargetSE())
+		if err != nil {
+			return schema.GroupVersionResource{}, "", "", err
+		}
+		res, err := GetSupportedResUsingKind(a.controllerGVs, kind, namespace, name)
+		return res, namespace, name, err
+	}
+	if pod == nil {
+		return schema.GroupVersionResource{}, "", "", fmt.Errorf("no target object found")
+	}
+	res := schema.GroupVersionResource{Version: "v1", Resource: util.PodResName}
+	return res, pod.Namespace, pod.Name, nil
+}
+
+func (a *ActionResultAnnotator) lastActionAnnotations(actionItem *proto.ActionItemDTO, actionErr error) map[string]string {
+	result := LastActionResultSucceeded
+	if actionErr != nil {
+		msg := actionErr.Error()
+		if len(msg) > maxLastActionMessageLength {
+			msg = msg[:maxLastActionMessageLength] + "..."
+		}
+		result = fmt.Sprintf("%s: %s", LastActionResultFailed, msg)
+	}
+	return map[string]string{
+		LastActionAnnotation:       fmt.Sprintf("%s %s", actionItem.GetActionType(), actionItem.GetUuid()),
+		LastActionTimeAnnotation:   a.now().UTC().Format(time.RFC3339),
+		LastActionResultAnnotation: result,
+	}
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+)
+
+type recordedPatch struct {
+	path        string
+	annotations map[string]string
+}
+
+// newPatchRecorder starts a fake API server which records the merge patches it receives.
+func newPatchRecorder(t *testing.T) (*httptest.Server, *[]recordedPatch) {
+	patches := &[]recordedPatch{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "application/merge-patch+json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		var patch struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		assert.Nil(t, json.Unmarshal(body, &patch))
+		*patches = append(*patches, recordedPatch{path: r.URL.Path, annotations: patch.Metadata.Annotations})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"foo"}}`)
+	}))
+	return server, patches
+}
+
+func newTestAnnotator(t *testing.T, server *httptest.Server) *ActionResultAnnotator {
+	dynamicClient, err := dynamic.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.Nil(t, err)
+	annotator := NewActionResultAnnotator(dynamicClient)
+	annotator.now = func() time.Time { return time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC) }
+	return annotator
+}
+
+func TestAnnotatePodActionSucceeded(t *testing.T) {
+	server, patches := newPatchRecorder(t)
+	defer server.Close()
+
+	actionType := proto.ActionItemDTO_MOVE
+	uuid := "action-1"
+	podType := proto.EntityDTO_CONTAINER_POD
+	actionItem := &proto.ActionItemDTO{ActionType: &actionType, Uuid: &uuid, TargetSE: &proto.EntityDTO{EntityType: &podType}}
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"}}
+
+	newTestAnnotator(t, server).Annotate(actionItem, pod, nil)
+
+	assert.Equal(t, 1, len(*patches))
+	assert.Equal(t, "/api/v1/namespaces/ns/pods/pod-1", (*patches)[0].path)
+	assert.Equal(t, map[string]string{
+		LastActionAnnotation:       "MOVE action-1",
+		LastActionTimeAnnotation:   "2023-05-01T10:00:00Z",
+		LastActionResultAnnotation: LastActionResultSucceeded,
+	}, (*patches)[0].annotations)
+}
+
+func TestAnnotateControllerActionFailed(t *testing.T) {
+	server, patches := newPatchRecorder(t)
+	defer server.Close()
+
+	actionType := proto.ActionItemDTO_RIGHT_SIZE
+	uuid := "action-2"
+	controllerType := proto.EntityDTO_WORKLOAD_CONTROLLER
+	name := "deploy-1"
+	namespaceProp := "KubernetesNamespace"
+	namespace := "ns"
+	propNamespace := "DEFAULT"
+	actionItem := &proto.ActionItemDTO{ActionType: &actionType, Uuid: &uuid, TargetSE: &proto.EntityDTO{
+		EntityType:  &controllerType,
+		DisplayName: &name,
+		EntityProperties: []*proto.EntityDTO_EntityProperty{
+			{Namespace: &propNamespace, Name: &namespaceProp, Value: &namespace},
+		},
+		EntityData: &proto.EntityDTO_WorkloadControllerData_{WorkloadControllerData: &proto.EntityDTO_WorkloadControllerData{
+			ControllerType: &proto.EntityDTO_WorkloadControllerData_DeploymentData{
+				DeploymentData: &proto.EntityDTO_DeploymentData{},
+			},
+		}},
+	}}
+
+	newTestAnnotator(t, server).Annotate(actionItem, nil, fmt.Errorf("limitrange violation"))
+
+	assert.Equal(t, 1, len(*patches))
+	assert.Equal(t, "/apis/apps/v1/namespaces/ns/deployments/deploy-1", (*patches)[0].path)
+	assert.Equal(t, "RIGHT_SIZE action-2", (*patches)[0].annotations[LastActionAnnotation])
+	assert.Equal(t, "Failed: limitrange violation", (*patches)[0].annotations[LastActionResultAnnotation])
+}
+
+func TestAnnotateWithoutTarget(t *testing.T) {
+	server, patches := newPatchRecorder(t)
+	defer server.Close()
+
+	actionType := proto.ActionItemDTO_MOVE
+	podType := proto.EntityDTO_CONTAINER_POD
+	actionItem := &proto.ActionItemDTO{ActionType: &actionType, TargetSE: &proto.EntityDTO{EntityType: &podType}}
+
+	newTestAnnotator(t, server).Annotate(actionItem, nil, nil)
+	assert.Equal(t, 0, len(*patches))
+}
@@ -29,6 +29,10 @@ const (
 	TurboGCLabelKey            string = "kubeturbo.io"
 	TurboGCLabelVal            string = "gc"
 
+	// these annotations of a workload controller bound its replicas for the horizontal scale actions
+	MinReplicasAnnotation string = "kubeturbo.io/min-replicas"
+	MaxReplicasAnnotation string = "kubeturbo.io/max-replicas"
+
 	DummyScheduler   string = "turbo-scheduler"
 	DefaultScheduler string = "default-scheduler"
 )
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kclient "k8s.io/client-go/kubernetes"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+// How long the eviction of the original pod of a move waits for its disruption budgets to allow it before the move
+// is refused
+const defaultDisruptionBudgetWaitTimeout = time.Minute
+
+// evictMovedPod removes the original pod of a move through the eviction API, which checks the disruption budgets of
+// the pod and removes it atomically, so that the move does not violate their minAvailable or maxUnavailable. The
+// eviction is retried while a budget refuses it, and the move is refused with the blocking budgets after the timeout.
+func evictMovedPod(client kclient.Interface, pod *api.Pod, timeout time.Duration) error {
+	var lastErr error
+	err := wait.PollImmediate(DefaultRetrySleepInterval, timeout, func() (bool, error) {
+		lastErr = evict(client, pod)
+		if lastErr == nil {
+			return true, nil
+		}
+		if apierrors.IsTooManyRequests(lastErr) {
+			glog.V(3).Infof("Eviction of pod %s/%s is blocked by a disruption budget, will retry.",
+				pod.Namespace, pod.Name)
+			return false, nil
+		}
+		return false, lastErr
+	})
+	if err == wait.ErrWaitTimeout {
+		return disruptionBudgetRefusal(client, pod, lastErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	return nil
+}
+
+// evict evicts the pod through the eviction API, which answers 429 Too Many Requests when a disruption budget of the
+// pod does not allow it. A pod which no longer exists is evicted already.
+func evict(client kclient.Interface, pod *api.Pod) error {
+	eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+	err := client.CoreV1().Pods(pod.Namespace).EvictV1(context.TODO(), eviction)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// disruptionBudgetRefusal refuses the move of the pod whose eviction is refused with the given error, reporting the
+// disruption budgets which do not allow it if they can be listed.
+func disruptionBudgetRefusal(client kclient.Interface, pod *api.Pod, evictionErr error) error {
+	pdbs, err := getPodDisruptionBudgets(client, pod)
+	if err == nil {
+		if refusal := checkDisruptionBudgets(pod, pdbs); refusal != nil {
+			return refusal
+		}
+	}
+	return util.NewActionRefusalError(util.ReasonDisruptionBudget,
+		"move pod failed: pod %s/%s cannot be evicted without violating its disruption budgets: %v",
+		pod.Namespace, pod.Name, evictionErr)
+}
+
+// getPodDisruptionBudgets returns the disruption budgets whose selector matches the pod.
+func getPodDisruptionBudgets(client kclient.Interface, pod *api.Pod) ([]*policyv1.PodDisruptionBudget, error) {
+	pdbList, err := client.PolicyV1().PodDisruptionBudgets(pod.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the disruption budgets in namespace %s: %v", pod.Namespace, err)
+	}
+	var pdbs []*policyv1.PodDisruptionBudget
+	for i := range pdbList.Items {
+		pdb := &pdbList.Items[i]
+		// A nil selector matches no pod, an empty one all the pods of the namespace
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			glog.Warningf("Invalid selector of disruption budget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			pdbs = append(pdbs, pdb)
+		}
+	}
+	return pdbs, nil
+}
+
+// checkDisruptionBudgets refuses the move of the pod if any of the given disruption budgets does not allow a
+// disruption.
+func checkDisruptionBudgets(pod *api.Pod, pdbs []*policyv1.PodDisruptionBudget) error {
+	var violations []string
+	for _, pdb := range pdbs {
+		if pdb.Status.DisruptionsAllowed > 0 {
+			continue
+		}
+		violations = append(violations, fmt.Sprintf("%s (%s, %d of %d pods healthy)", pdb.Name,
+			describeDisruptionBudget(pdb), pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods))
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return util.NewActionRefusalError(util.ReasonDisruptionBudget,
+		"move pod failed: pod %s/%s cannot be disrupted without violating disruption budget %s",
+		pod.Namespace, pod.Name, strings.Join(violations, ", "))
+}
+
+func describeDisruptionBudget(pdb *policyv1.PodDisruptionBudget) string {
+	if pdb.Spec.MinAvailable != nil {
+		return "minAvailable " + pdb.Spec.MinAvailable.String()
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		return "maxUnavailable " + pdb.Spec.MaxUnavailable.String()
+	}
+	return "no disruption allowed"
+}
@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+// DefaultMoveEndpointsTimeout is how long a move waits by default for the new pod to be registered in the endpoints
+// of the services of the moved pod.
+const DefaultMoveEndpointsTimeout = time.Minute * 2
+
+// podEndpoints returns the names of the endpoints of which the given pod is a ready address, i.e., of the services
+// which the pod serves.
+func podEndpoints(client kclient.Interface, pod *api.Pod) ([]string, error) {
+	endpointsList, err := client.CoreV1().Endpoints(pod.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the endpoints in namespace %s: %v", pod.Namespace, err)
+	}
+	var names []string
+	for i := range endpointsList.Items {
+		if hasReadyAddress(&endpointsList.Items[i], pod.Name) {
+			names = append(names, endpointsList.Items[i].Name)
+		}
+	}
+	return names, nil
+}
+
+// waitForEndpoints waits for the given pod to be a ready address of each of the given endpoints in the namespace.
+func waitForEndpoints(client kclient.Interface, namespace string, endpointsNames []string, podName string,
+	timeout time.Duration) error {
+	for _, name := range endpointsNames {
+		err := wait.PollImmediate(DefaultRetrySleepInterval, timeout, func() (bool, error) {
+			endpoints, err := client.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				glog.V(4).Infof("Failed to get endpoints %s/%s: %v", namespace, name, err)
+				return false, nil
+			}
+			return hasReadyAddress(endpoints, podName), nil
+		})
+		if err != nil {
+			return fmt.Errorf("pod %s/%s was not registered in endpoints %s in %v", namespace, podName, name,
+				timeout)
+		}
+		glog.V(4).Infof("Pod %s/%s is registered in endpoints %s.", namespace, podName, name)
+	}
+	return nil
+}
+
+func hasReadyAddress(endpoints *api.Endpoints, podName string) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" && address.TargetRef.Name == podName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func newEndpoints(name string, podNames ...string) api.Endpoints {
+	var addresses []api.EndpointAddress
+	for _, podName := range podNames {
+		addresses = append(addresses, api.EndpointAddress{
+			TargetRef: &api.ObjectReference{Kind: "Pod", Name: podName},
+		})
+	}
+	return api.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Subsets:    []api.EndpointSubset{{Addresses: addresses}},
+	}
+}
+
+func TestWaitForEndpoints(t *testing.T) {
+	endpoints := map[string]api.Endpoints{
+		"svc":       newEndpoints("svc", "pod-1", "pod-2"),
+		"other-svc": newEndpoints("other-svc", "pod-3"),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/namespaces/ns/endpoints":
+			list := api.EndpointsList{}
+			for _, e := range endpoints {
+				list.Items = append(list.Items, e)
+			}
+			json.NewEncoder(w).Encode(list)
+		case "/api/v1/namespaces/ns/endpoints/svc":
+			json.NewEncoder(w).Encode(endpoints["svc"])
+		default:
+			// Only the endpoints of the moved pod are waited for
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.Nil(t, err)
+
+	pod := newMovePod("node-1", nil)
+	names, err := podEndpoints(client, pod)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"svc"}, names)
+	assert.Error(t, waitForEndpoints(client, "ns", names, "pod-4", 10*time.Millisecond))
+
+	endpoints["svc"] = newEndpoints("svc", "pod-1", "pod-2", "pod-4")
+	assert.Nil(t, waitForEndpoints(client, "ns", names, "pod-4", 10*time.Millisecond))
+
+	// The pod not registered in any endpoints
+	pod.Name = "pod-5"
+	names, err = podEndpoints(client, pod)
+	assert.Nil(t, err)
+	assert.Empty(t, names)
+	assert.Nil(t, waitForEndpoints(client, "ns", names, "pod-6", 10*time.Millisecond))
+}
@@ -40,7 +40,7 @@ func (h *HorizontalScaler) Execute(input *TurboActionExecutorInput) (*TurboActio
 		controllerUpdater, updaterErr = newK8sControllerUpdaterViaPod(h.clusterScraper,
 			pod, h.ormClient, h.gitConfig, h.k8sClusterId, proto.ActionItemDTO_HORIZONTAL_SCALE)
 	} else {
-		namespace, controllerName, kind, err := getWorkloadControllerInfo(actionItem.GetTargetSE())
+		namespace, controllerName, kind, err := GetWorkloadControllerInfo(actionItem.GetTargetSE())
 		if err != nil {
 			glog.Errorf("Failed to get controller information: %v", err)
 			return &TurboActionExecutorOutput{}, err
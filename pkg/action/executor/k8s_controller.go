@@ -46,11 +46,13 @@ type k8sController interface {
 // k8sControllerSpec defines a set of objects that we want to update:
 // - replicas: The replicas of a controller to update for horizontal scale
 // - podSpec: The pod template of a controller to update for consistent resize
+// - annotations: The annotations of a controller, which may bound its replicas
 // Note: Use pointer for in-place update
 type k8sControllerSpec struct {
 	replicas       *int32
 	podSpec        *apicorev1.PodSpec
 	controllerName string
+	annotations    map[string]string
 }
 
 type kubeClients struct {
@@ -137,6 +139,7 @@ func (pc *parentController) get(name string) (*k8sControllerSpec, error) {
 		replicas:       &int32Replicas,
 		podSpec:        &podSpec,
 		controllerName: fmt.Sprintf("%s-%s", kind, objName),
+		annotations:    obj.GetAnnotations(),
 	}, nil
 }
 
@@ -300,7 +303,8 @@ func (pc *parentController) shouldRolloutDeploymentConfig() (bool, *osv1.Deploym
 
 	if shouldRollout && spec.Paused {
 		glog.Errorf("%s %s needs manual rollout, but is paused. Aborting rollout.", objKind, objName)
-		return false, nil, fmt.Errorf("rollout aborted as %s %s needs manual rollout, but is paused", objKind, objName)
+		return false, nil, actionutil.NewActionRefusalError(actionutil.ReasonRolloutPaused,
+			"rollout aborted as %s %s needs manual rollout, but is paused", objKind, objName)
 	}
 
 	return shouldRollout, &typedDC, nil
@@ -15,6 +15,7 @@ import (
 	kclient "k8s.io/client-go/kubernetes"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/executor/gitops"
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
@@ -67,7 +68,7 @@ func newK8sControllerUpdater(clusterScraper *cluster.ClusterScraper,
 	ormClient *resourcemapping.ORMClientManager, kind, controllerName, podName, namespace, clusterId string,
 	managerApp *repository.K8sApp, gitConfig gitops.GitConfig,
 	actionType proto.ActionItemDTO_ActionType) (*k8sControllerUpdater, error) {
-	res, err := GetSupportedResUsingKind(kind, namespace, controllerName)
+	res, err := GetSupportedResUsingKind(clusterScraper.ControllerGroupVersions(), kind, namespace, controllerName)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +97,10 @@ func newK8sControllerUpdater(clusterScraper *cluster.ClusterScraper,
 	}, nil
 }
 
-func GetSupportedResUsingKind(kind, namespace, name string) (schema.GroupVersionResource, error) {
+// GetSupportedResUsingKind returns the resource of the workload controllers of the given kind, with the group
+// versions of the deployments and the replicasets of their cluster.
+func GetSupportedResUsingKind(controllerGVs util.ControllerGroupVersions, kind, namespace,
+	name string) (schema.GroupVersionResource, error) {
 	res := schema.GroupVersionResource{}
 	var err error
 	switch kind {
@@ -106,15 +110,9 @@ func GetSupportedResUsingKind(kind, namespace, name string) (schema.GroupVersion
 			Version:  util.K8sAPIReplicationControllerGV.Version,
 			Resource: util.ReplicationControllerResName}
 	case util.KindReplicaSet:
-		res = schema.GroupVersionResource{
-			Group:    util.K8sAPIReplicasetGV.Group,
-			Version:  util.K8sAPIReplicasetGV.Version,
-			Resource: util.ReplicaSetResName}
+		res = controllerGVs.ReplicaSetResource()
 	case util.KindDeployment:
-		res = schema.GroupVersionResource{
-			Group:    util.K8sAPIDeploymentGV.Group,
-			Version:  util.K8sAPIDeploymentGV.Version,
-			Resource: util.DeploymentResName}
+		res = controllerGVs.DeploymentResource()
 	case util.KindDeploymentConfig:
 		res = schema.GroupVersionResource{
 			Group:    util.OpenShiftAPIDeploymentConfigGV.Group,
@@ -141,9 +139,19 @@ func (c *k8sControllerUpdater) updateWithRetry(ctlrSpec *controllerSpec) error {
 	retryNum := DefaultExecutionRetry
 	interval := defaultUpdateReplicaSleep
 	timeout := time.Duration(retryNum+1) * interval
+	var refusalErr error
 	err := util.RetryDuring(retryNum, timeout, interval, func() error {
-		return c.update(ctlrSpec)
+		err := c.update(ctlrSpec)
+		if _, refused := actionutil.GetRefusalReason(err); refused {
+			// Do not retry a refused action, and return the refusal as is
+			refusalErr = err
+			return util.NewSkipRetryError(err.Error())
+		}
+		return err
 	})
+	if refusalErr != nil {
+		return refusalErr
+	}
 	return err
 }
 
@@ -183,7 +191,7 @@ func (c *k8sControllerUpdater) reconcile(current *k8sControllerSpec, desired *co
 	if desired.replicasDiff != 0 {
 		// This is a horizontal scale
 		// We want to suspend the target pod or provision a new pod first
-		num, err := c.suspendOrProvision(*current.replicas, desired.replicasDiff)
+		num, err := c.suspendOrProvision(*current.replicas, desired.replicasDiff, current.annotations)
 		if err != nil {
 			return false, err
 		}
@@ -216,13 +224,16 @@ func (c *k8sControllerUpdater) reconcile(current *k8sControllerSpec, desired *co
 // returns the desired replica number after suspension or provision
 // Note: For now we only suspend the target pod
 // TODO: Provision a new pod on the target host
-func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32) (int32, error) {
+func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32, annotations map[string]string) (int32, error) {
 	//1. validate replica number
 	result := current + diff
 	glog.V(4).Infof("Current replica %d, diff %d, result %d.", current, diff, result)
 	if result < 1 {
 		return 0, fmt.Errorf("resulting replica is less than 1 after suspension")
 	}
+	if err := checkReplicaBounds(result, diff, annotations); err != nil {
+		return 0, err
+	}
 	//2. suspend the target
 	if diff < 0 {
 		if err := c.suspendPod(); err != nil {
@@ -232,6 +243,43 @@ func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32) (int32, e
 	return result, nil
 }
 
+// checkReplicaBounds refuses to scale a workload controller below the minimum replicas or above the maximum
+// replicas set by its annotations. A scale in the direction of the bounds is allowed, so that a controller
+// which is already out of its bounds can be brought back within them.
+func checkReplicaBounds(result, diff int32, annotations map[string]string) error {
+	minReplicas, err := getReplicasAnnotation(annotations, MinReplicasAnnotation)
+	if err != nil {
+		return err
+	}
+	if diff < 0 && minReplicas != nil && result < *minReplicas {
+		return actionutil.NewActionRefusalError(actionutil.ReasonReplicasMinSize,
+			"replicas can't be brought down to %d below the minimum replicas of %d", result, *minReplicas)
+	}
+	maxReplicas, err := getReplicasAnnotation(annotations, MaxReplicasAnnotation)
+	if err != nil {
+		return err
+	}
+	if diff > 0 && maxReplicas != nil && result > *maxReplicas {
+		return actionutil.NewActionRefusalError(actionutil.ReasonReplicasMaxSize,
+			"replicas can't be brought up to %d above the maximum replicas of %d", result, *maxReplicas)
+	}
+	return nil
+}
+
+// getReplicasAnnotation returns the replicas set by the given annotation, or nil if it is not set.
+func getReplicasAnnotation(annotations map[string]string, key string) (*int32, error) {
+	value, found := annotations[key]
+	if !found {
+		return nil, nil
+	}
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || replicas < 0 {
+		return nil, fmt.Errorf("invalid value %q of annotation %s", value, key)
+	}
+	result := int32(replicas)
+	return &result, nil
+}
+
 // suspendPod takes the name of a pod and deletes it
 // The call does not block
 func (c *k8sControllerUpdater) suspendPod() error {
@@ -4,11 +4,15 @@ import (
 	"reflect"
 	"testing"
 
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
 	"github.com/turbonomic/kubeturbo/pkg/util"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestGetSupportedResUsingKind(t *testing.T) {
+	// The deployments of an older cluster under the extensions group
+	controllerGVs := util.DefaultControllerGroupVersions()
+	controllerGVs.Deployment = schema.GroupVersion{Group: util.K8sExtensionsGroupName, Version: "v1beta1"}
 	testCases := []struct {
 		testName  string
 		kind      string
@@ -34,8 +38,8 @@ func TestGetSupportedResUsingKind(t *testing.T) {
 			namespace: "namesapce",
 			name:      "name",
 			res: schema.GroupVersionResource{
-				Group:    util.K8sAPIReplicasetGV.Group,
-				Version:  util.K8sAPIReplicasetGV.Version,
+				Group:    util.K8sAPIDeploymentReplicasetDefaultGV.Group,
+				Version:  util.K8sAPIDeploymentReplicasetDefaultGV.Version,
 				Resource: util.ReplicaSetResName},
 			wantErr: false,
 		},
@@ -45,8 +49,8 @@ func TestGetSupportedResUsingKind(t *testing.T) {
 			namespace: "namesapce",
 			name:      "name",
 			res: schema.GroupVersionResource{
-				Group:    util.K8sAPIDeploymentGV.Group,
-				Version:  util.K8sAPIDeploymentGV.Version,
+				Group:    util.K8sExtensionsGroupName,
+				Version:  "v1beta1",
 				Resource: util.DeploymentResName},
 			wantErr: false,
 		},
@@ -95,7 +99,7 @@ func TestGetSupportedResUsingKind(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.testName, func(t *testing.T) {
-			res, err := GetSupportedResUsingKind(testCase.kind, testCase.namespace, testCase.name)
+			res, err := GetSupportedResUsingKind(controllerGVs, testCase.kind, testCase.namespace, testCase.name)
 			if (err != nil) != testCase.wantErr {
 				t.Errorf("GetSupportedResUsingKind() error = %v, wantError %v", err, testCase.wantErr)
 				return
@@ -106,3 +110,83 @@ func TestGetSupportedResUsingKind(t *testing.T) {
 		})
 	}
 }
+
+func TestSuspendOrProvisionReplicaBounds(t *testing.T) {
+	bounds := map[string]string{MinReplicasAnnotation: "2", MaxReplicasAnnotation: "4"}
+	testCases := []struct {
+		testName    string
+		current     int32
+		diff        int32
+		annotations map[string]string
+		want        int32
+		wantErr     bool
+		wantReason  actionutil.RefusalReason
+	}{
+		{
+			testName: "test provision without bounds",
+			current:  4,
+			diff:     1,
+			want:     5,
+		},
+		{
+			testName:    "test provision within bounds",
+			current:     3,
+			diff:        1,
+			annotations: bounds,
+			want:        4,
+		},
+		{
+			testName:    "test provision above max replicas",
+			current:     4,
+			diff:        1,
+			annotations: bounds,
+			wantErr:     true,
+			wantReason:  actionutil.ReasonReplicasMaxSize,
+		},
+		{
+			testName:    "test suspend below min replicas",
+			current:     2,
+			diff:        -1,
+			annotations: bounds,
+			wantErr:     true,
+			wantReason:  actionutil.ReasonReplicasMinSize,
+		},
+		{
+			testName:    "test provision towards min replicas",
+			current:     1,
+			diff:        1,
+			annotations: map[string]string{MinReplicasAnnotation: "3"},
+			want:        2,
+		},
+		{
+			testName:    "test suspend towards max replicas",
+			current:     6,
+			diff:        -1,
+			annotations: bounds,
+			want:        5,
+		},
+		{
+			testName:    "test invalid max replicas",
+			current:     3,
+			diff:        1,
+			annotations: map[string]string{MaxReplicasAnnotation: "ten"},
+			wantErr:     true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testName, func(t *testing.T) {
+			got, err := (&k8sControllerUpdater{}).suspendOrProvision(testCase.current, testCase.diff, testCase.annotations)
+			if (err != nil) != testCase.wantErr {
+				t.Errorf("suspendOrProvision() error = %v, wantError %v", err, testCase.wantErr)
+				return
+			}
+			if reason, _ := actionutil.GetRefusalReason(err); reason != testCase.wantReason {
+				t.Errorf("suspendOrProvision() refusal reason = %v, want %v", reason, testCase.wantReason)
+			}
+			if got != testCase.want {
+				t.Errorf("suspendOrProvision() got = %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
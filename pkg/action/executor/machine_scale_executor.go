@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
 	"github.com/turbonomic/kubeturbo/pkg/turbostore"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
@@ -15,20 +16,38 @@ const (
 	MachineScalerTypeCP   MachineScalerType = "TypeCP"   // Cloud provider based scaler
 )
 
+// InProgressCache keeps the keys of the actions in progress, in memory with turbostore.Cache, or across the restarts
+// of kubeturbo with turbostore.BoltCache.
+type InProgressCache interface {
+	Add(key string, obj interface{})
+	Get(key string) (interface{}, bool)
+	Delete(key string) error
+}
+
 type MachineActionExecutor struct {
 	executor      TurboK8sActionExecutor
-	cache         *turbostore.Cache
+	cache         InProgressCache
 	cAPINamespace string
+	// Scales up the cloud node groups when the machine API is not available
+	nodeGroupScaler *NodeGroupScaler
 }
 
 func NewMachineActionExecutor(namespace string, ae TurboK8sActionExecutor) *MachineActionExecutor {
 	return &MachineActionExecutor{
-		executor:      ae,
-		cache:         turbostore.NewCache(),
-		cAPINamespace: namespace,
+		executor:        ae,
+		cache:           turbostore.NewCache(),
+		cAPINamespace:   namespace,
+		nodeGroupScaler: NewNodeGroupScaler(ae.clusterScraper.Clientset),
 	}
 }
 
+// WithInProgressCaches keeps the machine sets and the cloud node groups being scaled in the given caches.
+func (s *MachineActionExecutor) WithInProgressCaches(machineSets, nodeGroups InProgressCache) *MachineActionExecutor {
+	s.cache = machineSets
+	s.nodeGroupScaler.cache = nodeGroups
+	return s
+}
+
 func (s *MachineActionExecutor) unlock(key string) {
 	err := s.cache.Delete(key)
 	if err != nil {
@@ -36,6 +55,15 @@ func (s *MachineActionExecutor) unlock(key string) {
 	}
 }
 
+// isUpstreamClusterAPINode returns true if the node is managed by the upstream Cluster API.
+func (s *MachineActionExecutor) isUpstreamClusterAPINode(nodeName string) bool {
+	_, _, found, err := getUpstreamMachineRef(s.executor.clusterScraper.Clientset, nodeName)
+	if err != nil {
+		glog.Warningf("Failed to check whether node %s is managed by the Cluster API: %v", nodeName, err)
+	}
+	return found
+}
+
 // Execute : executes the scale action.
 func (s *MachineActionExecutor) Execute(vmDTO *TurboActionExecutorInput) (*TurboActionExecutorOutput, error) {
 	nodeName := vmDTO.ActionItems[0].GetTargetSE().GetDisplayName()
@@ -43,6 +71,10 @@ func (s *MachineActionExecutor) Execute(vmDTO *TurboActionExecutorInput) (*Turbo
 	var diff int32
 	switch vmDTO.ActionItems[0].GetActionType() {
 	case proto.ActionItemDTO_PROVISION:
+		if !s.executor.clusterScraper.IsClusterAPIEnabled() && !s.isUpstreamClusterAPINode(nodeName) {
+			glog.V(2).Infof("Cluster API is not available, scaling up the cloud node group of node %s.", nodeName)
+			return s.nodeGroupScaler.Execute(vmDTO)
+		}
 		actionType = ProvisionAction
 		diff = 1
 	case proto.ActionItemDTO_SUSPEND:
@@ -68,7 +100,8 @@ func (s *MachineActionExecutor) Execute(vmDTO *TurboActionExecutorInput) (*Turbo
 	// See if we already have this.
 	_, ok := s.cache.Get(*key)
 	if ok {
-		return nil, fmt.Errorf("the action against the %s is already running", *key)
+		return nil, util.NewActionRefusalError(util.ReasonActionInProgress,
+			"the action against the %s is already running", *key)
 	}
 	s.cache.Add(*key, key)
 	defer s.unlock(*key)
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
@@ -15,6 +16,7 @@ import (
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 )
@@ -25,6 +27,7 @@ type ActionType string
 // These are the valid Action types.
 const (
 	DeleteNodeAnnotation                  = "machine.openshift.io/cluster-api-delete-machine"
+	MachineAnnotation                     = "machine.openshift.io/machine"
 	ProvisionAction            ActionType = "Provision"
 	SuspendAction              ActionType = "Suspend"
 	operationMaxWaits                     = 60
@@ -50,7 +53,7 @@ type k8sClusterApi struct {
 // An error is returned if the Machine is not found or the node does not exist.
 func (client *k8sClusterApi) identifyManagingMachine(nodeName string) (*machinev1beta1.Machine, error) {
 	// Check if a node with the passed name exists.
-	_, err := client.k8sClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	node, err := client.k8sClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		machineName := nodeName
 		// We get the machine name as is in the stitched env.
@@ -65,6 +68,17 @@ func (client *k8sClusterApi) identifyManagingMachine(nodeName string) (*machinev
 		return nil, fmt.Errorf("error retrieving node %s: %v", nodeName, err)
 	}
 
+	// The machine controller annotates the node with the namespace/name of its machine.
+	if machineRef, found := node.Annotations[MachineAnnotation]; found {
+		if parts := strings.SplitN(machineRef, "/", 2); len(parts) == 2 {
+			machine, err := client.machine.Get(context.TODO(), parts[1], metav1.GetOptions{})
+			if err == nil && machine.Namespace == parts[0] {
+				return machine, nil
+			}
+			glog.Warningf("Failed to get machine %s of node %s: %v", machineRef, nodeName, err)
+		}
+	}
+
 	// List all machines and match the node.
 	machineList, err := client.machine.List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
@@ -122,7 +136,7 @@ func (controller *machineSetController) checkPreconditions() error {
 		return err
 	}
 	if !ok {
-		return fmt.Errorf("machine set is not in the coherent state")
+		return actionutil.NewActionRefusalError(actionutil.ReasonNodePoolIncoherent, "machine set is not in the coherent state")
 	}
 
 	currentReplicas := int(*controller.machineSet.Spec.Replicas)
@@ -134,14 +148,16 @@ func (controller *machineSetController) checkPreconditions() error {
 	minNodes := cluster.GetNodePoolSizeConfigValue(cluster.MinNodesConfigKey, viper.GetString, cluster.DefaultMinNodePoolSize)
 	glog.V(4).Infof("%s: %d\n", cluster.MinNodesConfigKey, minNodes)
 	if resultingReplicas < minNodes {
-		return fmt.Errorf("machine set replicas can't be brought down below the minimum nodes of %d", minNodes)
+		return actionutil.NewActionRefusalError(actionutil.ReasonNodePoolMinSize,
+			"machine set replicas can't be brought down below the minimum nodes of %d", minNodes)
 	}
 
 	// Ensure that the resulting replicas do not exceed the maxNodes.
 	maxNodes := cluster.GetNodePoolSizeConfigValue(cluster.MaxNodesConfigKey, viper.GetString, cluster.DefaultMaxNodePoolSize)
 	glog.V(4).Infof("%s: %d\n", cluster.MaxNodesConfigKey, minNodes)
 	if resultingReplicas > maxNodes {
-		return fmt.Errorf("machine set replicas can't exceed the maximum nodes of %d", maxNodes)
+		return actionutil.NewActionRefusalError(actionutil.ReasonNodePoolMaxSize,
+			"machine set replicas can't exceed the maximum nodes of %d", maxNodes)
 	}
 	return nil
 }
@@ -165,8 +181,9 @@ func (controller *machineSetController) executeAction() error {
 		if machine.ObjectMeta.Annotations == nil {
 			machine.ObjectMeta.Annotations = make(map[string]string)
 		}
-		// MachineSet controller does not care what is the value of the string.
-		machine.ObjectMeta.Annotations[DeleteNodeAnnotation] = "delete"
+		// MachineSet controller does not care what is the value of the string. The time the machine is marked
+		// lets the garbage collector unmark it if the action is interrupted before the machine set is scaled down.
+		machine.ObjectMeta.Annotations[DeleteNodeAnnotation] = time.Now().UTC().Format(time.RFC3339)
 		_, err = client.machine.Update(context.TODO(), machine, metav1.UpdateOptions{})
 		if err != nil {
 			return err
@@ -356,6 +373,19 @@ func (controller *machineSetController) waitForState(stateDesc string, f stateCh
 func newController(namespace string, nodeName string, diff int32, actionType ActionType,
 	clusterScraper *cluster.ClusterScraper,
 ) (Controller, *string, error) {
+	// The nodes of the upstream Cluster API are scaled through their machine sets or machine deployments.
+	machineNamespace, machineName, found, err := getUpstreamMachineRef(clusterScraper.Clientset, nodeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if found {
+		controller, key, err := newUpstreamMachineSetController(clusterScraper.DynamicClient, machineNamespace,
+			machineName, nodeName, diff, actionType)
+		if err != nil {
+			return nil, nil, err
+		}
+		return controller, key, nil
+	}
 	// Check whether Cluster API is enabled.
 	if !clusterScraper.IsClusterAPIEnabled() {
 		return nil, nil, fmt.Errorf("no Cluster API available")
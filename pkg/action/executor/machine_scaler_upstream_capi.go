@@ -0,0 +1,255 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+)
+
+// The annotations and the resources of the upstream Cluster API (cluster.x-k8s.io), as opposed to the machine API
+// of OpenShift (machine.openshift.io)
+const (
+	// The annotations set on the nodes by the machine controller
+	UpstreamMachineAnnotation          = "cluster.x-k8s.io/machine"
+	UpstreamClusterNamespaceAnnotation = "cluster.x-k8s.io/cluster-namespace"
+	// Marks the machine to be removed first when its machine set is scaled down
+	UpstreamDeleteMachineAnnotation = "cluster.x-k8s.io/delete-machine"
+	// The node group size bounds of the cluster autoscaler, set on the machine sets and the machine deployments
+	upstreamMinSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	upstreamMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+
+	upstreamClusterAPIGroup   = "cluster.x-k8s.io"
+	upstreamClusterAPIVersion = "v1beta1"
+)
+
+var (
+	upstreamMachineGVR           = upstreamClusterAPIResource("machines")
+	upstreamMachineSetGVR        = upstreamClusterAPIResource("machinesets")
+	upstreamMachineDeploymentGVR = upstreamClusterAPIResource("machinedeployments")
+)
+
+func upstreamClusterAPIResource(resource string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: upstreamClusterAPIGroup, Version: upstreamClusterAPIVersion,
+		Resource: resource}
+}
+
+// upstreamMachineSetController scales the machine set of the machine of a node of the upstream Cluster API. The
+// machine deployment which owns the machine set, if any, is scaled instead, as it would revert the replicas of its
+// machine set.
+type upstreamMachineSetController struct {
+	client     dynamic.Interface
+	nodeName   string
+	diff       int64
+	actionType ActionType
+	machine    *unstructured.Unstructured
+	// The machine set or the machine deployment scaled
+	scalable    *unstructured.Unstructured
+	scalableGVR schema.GroupVersionResource
+	// The replicas of the scalable resource before the action
+	replicas     int64
+	pollInterval time.Duration
+	maxWaits     int
+}
+
+// getUpstreamMachineRef returns the namespace and the name of the machine of the node from the annotations set by
+// the machine controller of the upstream Cluster API, or false if the node is not managed by it.
+func getUpstreamMachineRef(kubeClient kubernetes.Interface, nodeName string) (string, string, bool, error) {
+	node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", false, fmt.Errorf("error retrieving node %s: %v", nodeName, err)
+	}
+	machineName, found := node.Annotations[UpstreamMachineAnnotation]
+	if !found || machineName == "" {
+		return "", "", false, nil
+	}
+	return node.Annotations[UpstreamClusterNamespaceAnnotation], machineName, true, nil
+}
+
+func newUpstreamMachineSetController(client dynamic.Interface, namespace, machineName, nodeName string, diff int32,
+	actionType ActionType) (*upstreamMachineSetController, *string, error) {
+	machine, err := client.Resource(upstreamMachineGVR).Namespace(namespace).
+		Get(context.TODO(), machineName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get machine %s/%s of node %s: %v", namespace, machineName, nodeName, err)
+	}
+	machineSet, err := getUpstreamOwner(client, machine, "MachineSet", upstreamMachineSetGVR)
+	if err != nil {
+		return nil, nil, err
+	}
+	if machineSet == nil {
+		return nil, nil, fmt.Errorf("machine %s/%s of node %s is not managed by a machine set",
+			namespace, machineName, nodeName)
+	}
+	scalable, scalableGVR := machineSet, upstreamMachineSetGVR
+	machineDeployment, err := getUpstreamOwner(client, machineSet, "MachineDeployment", upstreamMachineDeploymentGVR)
+	if err != nil {
+		return nil, nil, err
+	}
+	if machineDeployment != nil {
+		scalable, scalableGVR = machineDeployment, upstreamMachineDeploymentGVR
+	}
+	glog.V(3).Infof("Identified %s %s/%s managing machine %s of node %s", scalable.GetKind(), namespace,
+		scalable.GetName(), machineName, nodeName)
+	key := fmt.Sprintf("%s/%s", namespace, scalable.GetName())
+	return &upstreamMachineSetController{
+		client:       client,
+		nodeName:     nodeName,
+		diff:         int64(diff),
+		actionType:   actionType,
+		machine:      machine,
+		scalable:     scalable,
+		scalableGVR:  scalableGVR,
+		pollInterval: operationWaitSleepInterval,
+		maxWaits:     operationMaxWaits,
+	}, &key, nil
+}
+
+// getUpstreamOwner returns the owner of the given kind of the object, or nil if it has none.
+func getUpstreamOwner(client dynamic.Interface, obj *unstructured.Unstructured, kind string,
+	gvr schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.Kind != kind || !strings.HasPrefix(ownerRef.APIVersion, upstreamClusterAPIGroup+"/") {
+			continue
+		}
+		owner, err := client.Resource(gvr).Namespace(obj.GetNamespace()).
+			Get(context.TODO(), ownerRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("cannot get %s %s/%s owning %s %s: %v", kind, obj.GetNamespace(),
+				ownerRef.Name, obj.GetKind(), obj.GetName(), err)
+		}
+		return owner, nil
+	}
+	return nil, nil
+}
+
+// checkPreconditions checks that the scalable resource has all its replicas, and that the resulting replicas are
+// within the node pool size configured in kubeturbo and the node group size of the cluster autoscaler, if any.
+func (controller *upstreamMachineSetController) checkPreconditions() error {
+	kind, name := controller.scalable.GetKind(), controller.scalable.GetName()
+	replicas, found, err := unstructured.NestedInt64(controller.scalable.Object, "spec", "replicas")
+	if err != nil || !found {
+		return fmt.Errorf("%s %s has no replicas", kind, name)
+	}
+	statusReplicas, _, _ := unstructured.NestedInt64(controller.scalable.Object, "status", "replicas")
+	if statusReplicas != replicas {
+		return actionutil.NewActionRefusalError(actionutil.ReasonNodePoolIncoherent,
+			"%s %s has %d of its %d replicas", kind, name, statusReplicas, replicas)
+	}
+	controller.replicas = replicas
+	resultingReplicas := int(replicas + controller.diff)
+
+	minNodes := cluster.GetNodePoolSizeConfigValue(cluster.MinNodesConfigKey, viper.GetString, cluster.DefaultMinNodePoolSize)
+	if minSize, found := controller.sizeAnnotation(upstreamMinSizeAnnotation); found && minSize > minNodes {
+		minNodes = minSize
+	}
+	if resultingReplicas < minNodes {
+		return actionutil.NewActionRefusalError(actionutil.ReasonNodePoolMinSize,
+			"%s %s replicas can't be brought down below the minimum nodes of %d", kind, name, minNodes)
+	}
+	maxNodes := cluster.GetNodePoolSizeConfigValue(cluster.MaxNodesConfigKey, viper.GetString, cluster.DefaultMaxNodePoolSize)
+	if maxSize, found := controller.sizeAnnotation(upstreamMaxSizeAnnotation); found && maxSize < maxNodes {
+		maxNodes = maxSize
+	}
+	if resultingReplicas > maxNodes {
+		return actionutil.NewActionRefusalError(actionutil.ReasonNodePoolMaxSize,
+			"%s %s replicas can't exceed the maximum nodes of %d", kind, name, maxNodes)
+	}
+	return nil
+}
+
+func (controller *upstreamMachineSetController) sizeAnnotation(annotation string) (int, bool) {
+	value, found := controller.scalable.GetAnnotations()[annotation]
+	if !found {
+		return 0, false
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		glog.Warningf("Invalid %s annotation %q of %s %s", annotation, value, controller.scalable.GetKind(),
+			controller.scalable.GetName())
+		return 0, false
+	}
+	return size, true
+}
+
+// executeAction marks the machine of the node to be deleted first on suspend, and updates the replicas.
+func (controller *upstreamMachineSetController) executeAction() error {
+	if controller.diff < 0 {
+		annotations := controller.machine.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		// The time the machine is marked lets the garbage collector unmark it if the action is interrupted
+		annotations[UpstreamDeleteMachineAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		controller.machine.SetAnnotations(annotations)
+		machine, err := controller.client.Resource(upstreamMachineGVR).Namespace(controller.machine.GetNamespace()).
+			Update(context.TODO(), controller.machine, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to mark machine %s for deletion: %v", controller.machine.GetName(), err)
+		}
+		controller.machine = machine
+	}
+	if err := unstructured.SetNestedField(controller.scalable.Object, controller.replicas+controller.diff,
+		"spec", "replicas"); err != nil {
+		return err
+	}
+	scalable, err := controller.client.Resource(controller.scalableGVR).Namespace(controller.scalable.GetNamespace()).
+		Update(context.TODO(), controller.scalable, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to scale %s %s: %v", controller.scalable.GetKind(), controller.scalable.GetName(),
+			err)
+	}
+	controller.scalable = scalable
+	return nil
+}
+
+// checkSuccess waits until the new machine is ready on provision, or until the machine of the node is deleted on
+// suspend.
+func (controller *upstreamMachineSetController) checkSuccess() error {
+	kind, name := controller.scalable.GetKind(), controller.scalable.GetName()
+	desired := controller.replicas + controller.diff
+	for i := 0; i < controller.maxWaits; i++ {
+		done, err := controller.isActionComplete(desired)
+		if err != nil {
+			return err
+		}
+		if done {
+			glog.V(2).Infof("%s %s scaled to %d replicas.", kind, name, desired)
+			return nil
+		}
+		time.Sleep(controller.pollInterval)
+	}
+	return fmt.Errorf("%s %s did not reach %d replicas: timed out after %v", kind, name, desired,
+		time.Duration(controller.maxWaits)*controller.pollInterval)
+}
+
+func (controller *upstreamMachineSetController) isActionComplete(desired int64) (bool, error) {
+	if controller.actionType == SuspendAction {
+		_, err := controller.client.Resource(upstreamMachineGVR).Namespace(controller.machine.GetNamespace()).
+			Get(context.TODO(), controller.machine.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	scalable, err := controller.client.Resource(controller.scalableGVR).Namespace(controller.scalable.GetNamespace()).
+		Get(context.TODO(), controller.scalable.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	readyReplicas, _, _ := unstructured.NestedInt64(scalable.Object, "status", "readyReplicas")
+	return readyReplicas >= desired, nil
+}
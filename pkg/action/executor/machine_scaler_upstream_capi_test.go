@@ -0,0 +1,156 @@
+package executor
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+const upstreamAPIPath = "/apis/cluster.x-k8s.io/v1beta1/namespaces/capi/"
+
+// fakeClusterAPIServer serves the objects of the upstream Cluster API by path, and marks the machine set ready on
+// update to emulate its controller.
+type fakeClusterAPIServer struct {
+	lock    sync.Mutex
+	objects map[string]map[string]interface{}
+}
+
+func (s *fakeClusterAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	path := strings.TrimPrefix(r.URL.Path, upstreamAPIPath)
+	switch r.Method {
+	case http.MethodGet:
+		if obj, found := s.objects[path]; found {
+			json.NewEncoder(w).Encode(obj)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1",
+			"status": "Failure", "reason": "NotFound", "code": http.StatusNotFound})
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		obj := map[string]interface{}{}
+		json.Unmarshal(body, &obj)
+		if strings.HasPrefix(path, "machinesets/") {
+			replicas := obj["spec"].(map[string]interface{})["replicas"]
+			obj["status"] = map[string]interface{}{"replicas": replicas, "readyReplicas": replicas}
+		}
+		s.objects[path] = obj
+		json.NewEncoder(w).Encode(obj)
+	}
+}
+
+func newUpstreamObject(kind, name string, owner string, replicas int64,
+	annotations map[string]interface{}) map[string]interface{} {
+	obj := map[string]interface{}{
+		"apiVersion": "cluster.x-k8s.io/v1beta1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": "capi", "annotations": annotations},
+	}
+	if owner != "" {
+		obj["metadata"].(map[string]interface{})["ownerReferences"] = []interface{}{map[string]interface{}{
+			"apiVersion": "cluster.x-k8s.io/v1beta1", "kind": owner, "name": "workers", "uid": "1"}}
+	}
+	if kind != "Machine" {
+		obj["spec"] = map[string]interface{}{"replicas": replicas}
+		obj["status"] = map[string]interface{}{"replicas": replicas, "readyReplicas": replicas}
+	}
+	return obj
+}
+
+func newFakeClusterAPI(t *testing.T, machineSetAnnotations map[string]interface{}) (*fakeClusterAPIServer,
+	dynamic.Interface, func()) {
+	fakeServer := &fakeClusterAPIServer{objects: map[string]map[string]interface{}{
+		"machines/machine-1":  newUpstreamObject("Machine", "machine-1", "MachineSet", 0, nil),
+		"machinesets/workers": newUpstreamObject("MachineSet", "workers", "", 2, machineSetAnnotations),
+	}}
+	server := httptest.NewServer(fakeServer)
+	client, err := dynamic.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NoError(t, err)
+	return fakeServer, client, server.Close
+}
+
+func TestUpstreamMachineSetControllerProvision(t *testing.T) {
+	fakeServer, client, closeServer := newFakeClusterAPI(t, nil)
+	defer closeServer()
+
+	controller, key, err := newUpstreamMachineSetController(client, "capi", "machine-1", "node-1", 1, ProvisionAction)
+	assert.NoError(t, err)
+	assert.Equal(t, "capi/workers", *key)
+	controller.maxWaits, controller.pollInterval = 1, 0
+	assert.NoError(t, controller.checkPreconditions())
+	assert.NoError(t, controller.executeAction())
+	assert.NoError(t, controller.checkSuccess())
+	assert.EqualValues(t, 3, fakeServer.objects["machinesets/workers"]["spec"].(map[string]interface{})["replicas"])
+}
+
+func TestUpstreamMachineSetControllerSuspend(t *testing.T) {
+	fakeServer, client, closeServer := newFakeClusterAPI(t, nil)
+	defer closeServer()
+
+	controller, _, err := newUpstreamMachineSetController(client, "capi", "machine-1", "node-1", -1, SuspendAction)
+	assert.NoError(t, err)
+	controller.maxWaits, controller.pollInterval = 1, 0
+	assert.NoError(t, controller.checkPreconditions())
+	assert.NoError(t, controller.executeAction())
+	machine := fakeServer.objects["machines/machine-1"]
+	assert.Contains(t, machine["metadata"].(map[string]interface{})["annotations"], UpstreamDeleteMachineAnnotation)
+	assert.EqualValues(t, 1, fakeServer.objects["machinesets/workers"]["spec"].(map[string]interface{})["replicas"])
+	// The machine is not deleted yet
+	assert.Error(t, controller.checkSuccess())
+	delete(fakeServer.objects, "machines/machine-1")
+	assert.NoError(t, controller.checkSuccess())
+}
+
+func TestUpstreamMachineSetControllerPreconditions(t *testing.T) {
+	_, client, closeServer := newFakeClusterAPI(t, map[string]interface{}{
+		upstreamMinSizeAnnotation: "2",
+		upstreamMaxSizeAnnotation: "2",
+	})
+	defer closeServer()
+
+	for _, test := range []struct {
+		diff       int32
+		actionType ActionType
+		reason     actionutil.RefusalReason
+	}{
+		{1, ProvisionAction, actionutil.ReasonNodePoolMaxSize},
+		{-1, SuspendAction, actionutil.ReasonNodePoolMinSize},
+	} {
+		controller, _, err := newUpstreamMachineSetController(client, "capi", "machine-1", "node-1", test.diff,
+			test.actionType)
+		assert.NoError(t, err)
+		reason, refused := actionutil.GetRefusalReason(controller.checkPreconditions())
+		assert.True(t, refused)
+		assert.Equal(t, test.reason, reason)
+	}
+}
+
+func TestUpstreamMachineSetControllerMachineDeployment(t *testing.T) {
+	fakeServer, client, closeServer := newFakeClusterAPI(t, nil)
+	defer closeServer()
+	fakeServer.objects["machinesets/workers"] = newUpstreamObject("MachineSet", "workers", "MachineDeployment", 2, nil)
+	fakeServer.objects["machinedeployments/workers"] = newUpstreamObject("MachineDeployment", "workers", "", 2, nil)
+
+	controller, _, err := newUpstreamMachineSetController(client, "capi", "machine-1", "node-1", 1, ProvisionAction)
+	assert.NoError(t, err)
+	assert.Equal(t, "MachineDeployment", controller.scalable.GetKind())
+	assert.Equal(t, upstreamMachineDeploymentGVR, controller.scalableGVR)
+
+	// The machine is not managed by a machine set
+	fakeServer.objects["machines/machine-1"] = newUpstreamObject("Machine", "machine-1", "", 0, nil)
+	_, _, err = newUpstreamMachineSetController(client, "capi", "machine-1", "node-1", 1, ProvisionAction)
+	assert.Error(t, err)
+}
@@ -128,7 +128,7 @@ func genNewPodName(oldPod *api.Pod) string {
 // If a pod HAS a persistent volume attached
 //
 //	{
-//	 step 4: delete the original pod
+//	 step 4: evict the original pod
 //	}
 //
 //	step 5: wait until the cloned pod is ready
@@ -136,21 +136,34 @@ func genNewPodName(oldPod *api.Pod) string {
 // If the pod does NOT have persistent volume attached
 //
 //	{
-//		 step 4: delete the original pod
+//		 step 4: evict the original pod
 //	}
 //
 //	step 6: add the labels to the cloned pod
 //	step 7: change the scheduler of parent back to to default-scheduler
 //	step 8: if the parent has parent, unpause the rollout
+//	step 9: wait until the cloned pod is registered in the endpoints of the original pod, unless endpointsTimeout
+//	        is not positive
+//
+// The original pod is evicted rather than deleted, so that the disruption budgets of the pod are enforced by the API
+// server; the move is refused if they do not allow the eviction in time.
+//
+// If schedulerPlacement is true, the clone pod is created unscheduled with node nodeName as its preferred node, and
+// placed by its scheduler, possibly on another node than nodeName, but not on the node of the original pod.
+//
+// The cloned pod gets the labels selected by the services only once the original pod is deleted, so the move does
+// not fail when the cloned pod is not registered in the endpoints in time, which is only logged.
 //
 // TODO: add support for operator controlled parent or parent's parent.
 func movePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, parentKind, parentName string,
-	retryNum int, failVolumePodMoves, updateQuotaToAllowMoves bool, lockMap *util.ExpirationMap) (*api.Pod, error) {
+	retryNum int, endpointsTimeout time.Duration, schedulerPlacement, failVolumePodMoves, updateQuotaToAllowMoves bool,
+	lockMap *util.ExpirationMap) (*api.Pod, error) {
 	podQualifiedName := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
 	podUsingVolume := isPodUsingVolume(pod)
 	if podUsingVolume && failVolumePodMoves {
-		return nil, fmt.Errorf("move pod failed: Pod %s uses a persistent volume. "+
-			"Set kubeturbo flag fail-volume-pod-moves to false to enable such moves.", podQualifiedName)
+		return nil, util.NewActionRefusalError(util.ReasonVolumePodMove,
+			"move pod failed: Pod %s uses a persistent volume. "+
+				"Set kubeturbo flag fail-volume-pod-moves to false to enable such moves.", podQualifiedName)
 	}
 
 	// We still support replicaset and replication controllers as parents,
@@ -164,35 +177,24 @@ func movePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, par
 	//NOTE: do deep-copy if the original pod may be modified outside this function
 	labels := pod.Labels
 
+	var endpointsNames []string
+	if endpointsTimeout > 0 {
+		if endpointsNames, err = podEndpoints(clusterScraper.Clientset, pod); err != nil {
+			glog.Warningf("Move pod warning: %v", err)
+		}
+	}
+
 	parentForPodSpec := grandParent
 	if parentForPodSpec == nil {
 		parentForPodSpec = parent
 	}
 
 	if updateQuotaToAllowMoves {
-		quotaAccessor := NewQuotaAccessor(clusterScraper.Clientset, pod.Namespace)
-		// The accessor should get the quotas again within the lock to avoid
-		// possible race conditions.
-		quotas, err := quotaAccessor.Get()
+		release, err := updateQuotasForMove(clusterScraper, pod, lockMap)
 		if err != nil {
 			return nil, err
 		}
-		hasQuotas := len(quotas) > 0
-		if hasQuotas {
-			// If this namespace has quota we force the move actions to
-			// become sequential.
-			lockHelper, err := lockForQuota(pod.Namespace, lockMap)
-			if err != nil {
-				return nil, err
-			}
-			defer lockHelper.ReleaseLock()
-
-			err = checkQuotas(quotaAccessor, pod, lockMap, 1)
-			if err != nil {
-				return nil, err
-			}
-			defer quotaAccessor.Revert()
-		}
+		defer release()
 	}
 
 	// Use an impersonation client in case SCC users are updated
@@ -201,7 +203,7 @@ func movePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, par
 		client = clusterScraper.Clientset
 	}
 	//step 1. create a clone pod--podC of the original pod--podA
-	npod, err := createClonePod(client, pod, parentForPodSpec, updateQuotaToAllowMoves, nodeName)
+	npod, err := createClonePod(client, pod, parentForPodSpec, updateQuotaToAllowMoves, nodeName, schedulerPlacement)
 	if err != nil {
 		glog.Errorf("Move pod failed: failed to create a clone pod: %v", err)
 		return nil, err
@@ -286,36 +288,24 @@ func movePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, par
 		// TODO: This is not an ideal way of doing things, and users should be
 		// encouraged to rather disable move actions on pods which use volumes
 		// via a config either in kubeturbo or driven from server UI.
-		glog.V(4).Infof("Pod using volume. Deleting original pod %s/%s right away", pod.Namespace, pod.Name)
-		if err := podClient.Delete(context.TODO(), pod.Name, metav1.DeleteOptions{}); err != nil {
-			glog.Errorf("Move pod warning: failed to delete original pod: %v", err)
+		glog.V(4).Infof("Pod using volume. Evicting original pod %s/%s right away", pod.Namespace, pod.Name)
+		if err := evictMovedPod(client, pod, defaultDisruptionBudgetWaitTimeout); err != nil {
+			glog.Errorf("Move pod warning: failed to evict original pod: %v", err)
 			return nil, err
 		}
 	}
-	retryInterval := defaultPodCreateSleep
-	failureThreshold := int32(retryNum)
-	initDelay := int32(0)
-	if parentForPodSpec != nil {
-		unstructuredContainers, found, err := unstructured.NestedSlice(parentForPodSpec.Object, "spec", "template", "spec", "containers")
-		if err != nil || !found {
-			return nil, fmt.Errorf("error retrieving containers for %s/%s because: %v", pod.Namespace, pod.Name, err)
-		}
-		for _, unstructuredContainer := range unstructuredContainers {
-			var container api.Container
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredContainer.(map[string]interface{}), &container); err != nil {
-				return nil, fmt.Errorf("error converting unstructured containers to typed containers for %s/%s because : %v", pod.Namespace, pod.Name, err)
-			}
-			retryInterval, failureThreshold, initDelay = calculateReadinessThreshold(container, retryInterval, failureThreshold, initDelay)
-		}
-	} else {
-		containers := pod.Spec.Containers
-		for _, container := range containers {
-			retryInterval, failureThreshold, initDelay = calculateReadinessThreshold(container, retryInterval, failureThreshold, initDelay)
-		}
+	retryInterval, failureThreshold, initDelay, err := podReadinessThreshold(pod, parentForPodSpec, retryNum)
+	if err != nil {
+		return nil, err
 	}
 	//step 5: wait until podC gets ready
 	glog.V(4).Infof("Now wait for new pod to be ready %s/%s", pod.Namespace, pod.Name)
-	err = podutil.WaitForPodReady(clusterScraper.Clientset, npod.Namespace, npod.Name, nodeName, time.Second*time.Duration(initDelay),
+	readyNodeName := nodeName
+	if schedulerPlacement {
+		// Any node picked by the scheduler
+		readyNodeName = ""
+	}
+	err = podutil.WaitForPodReady(clusterScraper.Clientset, npod.Namespace, npod.Name, readyNodeName, time.Second*time.Duration(initDelay),
 		failureThreshold, retryInterval)
 	if err != nil {
 		glog.Errorf("Wait for cloned Pod ready timeout: %v", err)
@@ -324,9 +314,9 @@ func movePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, par
 
 	if !podUsingVolume {
 		// step 4: delete the original pod--podA
-		glog.V(4).Infof("New pod ready. Deleting original pod %s/%s", pod.Namespace, pod.Name)
-		if err := podClient.Delete(context.TODO(), pod.Name, metav1.DeleteOptions{}); err != nil {
-			glog.Errorf("Move pod warning: failed to delete original pod: %v", err)
+		glog.V(4).Infof("New pod ready. Evicting original pod %s/%s", pod.Namespace, pod.Name)
+		if err := evictMovedPod(client, pod, defaultDisruptionBudgetWaitTimeout); err != nil {
+			glog.Errorf("Move pod warning: failed to evict original pod: %v", err)
 			return nil, err
 		}
 	}
@@ -356,9 +346,75 @@ func movePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, par
 	}
 
 	flag = true
+
+	// step 9:
+	err = waitForEndpoints(clusterScraper.Clientset, xpod.Namespace, endpointsNames, xpod.Name, endpointsTimeout)
+	if err != nil {
+		glog.Warningf("Move pod warning: %v", err)
+	}
 	return xpod, nil
 }
 
+// updateQuotasForMove makes room in the quotas of the namespace of the pod for the new pod of the move, if the
+// namespace has quotas, in which case the moves in the namespace are serialized. The returned function reverts the
+// quotas and releases the lock once the move completes.
+func updateQuotasForMove(clusterScraper *cluster.ClusterScraper, pod *api.Pod,
+	lockMap *util.ExpirationMap) (func(), error) {
+	quotaAccessor := NewQuotaAccessor(clusterScraper.Clientset, pod.Namespace)
+	// The accessor should get the quotas again within the lock to avoid
+	// possible race conditions.
+	quotas, err := quotaAccessor.Get()
+	if err != nil {
+		return nil, err
+	}
+	if len(quotas) == 0 {
+		return func() {}, nil
+	}
+	// If this namespace has quota we force the move actions to
+	// become sequential.
+	lockHelper, err := lockForQuota(pod.Namespace, lockMap)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQuotas(quotaAccessor, pod, lockMap, 1); err != nil {
+		lockHelper.ReleaseLock()
+		return nil, err
+	}
+	return func() {
+		quotaAccessor.Revert()
+		lockHelper.ReleaseLock()
+	}, nil
+}
+
+// podReadinessThreshold returns how often and how many times the readiness of the new pod of a move is checked, and
+// the initial delay in seconds, from the readiness probes of the containers of the pod template of the given
+// controller, or of the pod itself if it has no controller.
+func podReadinessThreshold(pod *api.Pod, parentForPodSpec *unstructured.Unstructured,
+	retryNum int) (time.Duration, int32, int32, error) {
+	retryInterval := defaultPodCreateSleep
+	failureThreshold := int32(retryNum)
+	initDelay := int32(0)
+	if parentForPodSpec != nil {
+		unstructuredContainers, found, err := unstructured.NestedSlice(parentForPodSpec.Object, "spec", "template", "spec", "containers")
+		if err != nil || !found {
+			return 0, 0, 0, fmt.Errorf("error retrieving containers for %s/%s because: %v", pod.Namespace, pod.Name, err)
+		}
+		for _, unstructuredContainer := range unstructuredContainers {
+			var container api.Container
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredContainer.(map[string]interface{}), &container); err != nil {
+				return 0, 0, 0, fmt.Errorf("error converting unstructured containers to typed containers for %s/%s because : %v", pod.Namespace, pod.Name, err)
+			}
+			retryInterval, failureThreshold, initDelay = calculateReadinessThreshold(container, retryInterval, failureThreshold, initDelay)
+		}
+	} else {
+		containers := pod.Spec.Containers
+		for _, container := range containers {
+			retryInterval, failureThreshold, initDelay = calculateReadinessThreshold(container, retryInterval, failureThreshold, initDelay)
+		}
+	}
+	return retryInterval, failureThreshold, initDelay, nil
+}
+
 func calculateReadinessThreshold(container api.Container, retryInterval time.Duration, failureThreshold int32, initDelay int32) (time.Duration, int32, int32) {
 	readinessFailureThreshold, readinessInitialDelaySec, periodSec := getContainerReadinessProbeDetails(container)
 	duration := time.Second * time.Duration(periodSec)
@@ -536,6 +592,78 @@ func isPodUsingVolume(pod *api.Pod) bool {
 	return false
 }
 
+// getPodPersistentVolumes gets the persistent volumes bound to the persistent volume claims of the pod.
+func getPodPersistentVolumes(client kclient.Interface, pod *api.Pod) ([]*api.PersistentVolume, error) {
+	var pvs []*api.PersistentVolume
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := vol.PersistentVolumeClaim.ClaimName
+		pvc, err := client.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(context.TODO(), claimName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume claim %s/%s: %v", pod.Namespace, claimName, err)
+		}
+		if pvc.Spec.VolumeName == "" {
+			// The claim is not bound, there is no volume to attach
+			continue
+		}
+		pv, err := client.CoreV1().PersistentVolumes().Get(context.TODO(), pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume %s of claim %s/%s: %v",
+				pvc.Spec.VolumeName, pod.Namespace, claimName, err)
+		}
+		pvs = append(pvs, pv)
+	}
+	return pvs, nil
+}
+
+// isReadWriteOnce checks if the persistent volume can only be attached to a single node at a time.
+func isReadWriteOnce(pv *api.PersistentVolume) bool {
+	singleNode := false
+	for _, mode := range pv.Spec.AccessModes {
+		switch mode {
+		case api.ReadWriteOnce, api.ReadWriteOncePod:
+			singleNode = true
+		default:
+			// The volume can be attached to multiple nodes
+			return false
+		}
+	}
+	return singleNode
+}
+
+// checkVolumesAttachable checks that the ReadWriteOnce persistent volumes of the pod can be attached to the
+// destination node, i.e., the node satisfies the node affinity of the volumes, and is in the same zone as the
+// zonal volumes labeled with their zone. The volumes which can be attached to multiple nodes are not checked.
+func checkVolumesAttachable(pod *api.Pod, node *api.Node, pvs []*api.PersistentVolume) error {
+	for _, pv := range pvs {
+		if !isReadWriteOnce(pv) {
+			continue
+		}
+		if !commonutil.PvNodeAffinityMatches(pv, node) {
+			return util.NewActionRefusalError(util.ReasonVolumeNotAttachable,
+				"move pod failed: ReadWriteOnce persistent volume %s of pod %s/%s cannot be attached to node %s "+
+					"because the node does not satisfy the node affinity of the volume", pv.Name, pod.Namespace,
+				pod.Name, node.Name)
+		}
+		for _, zoneLabel := range []string{api.LabelTopologyZone, api.LabelFailureDomainBetaZone} {
+			pvZone, found := pv.Labels[zoneLabel]
+			if !found {
+				continue
+			}
+			// The zone label of a multi-zone volume is a list of zones separated by "__"
+			if nodeZone := node.Labels[zoneLabel]; !keyInKeys(nodeZone, strings.Split(pvZone, "__")) {
+				return util.NewActionRefusalError(util.ReasonVolumeNotAttachable,
+					"move pod failed: ReadWriteOnce persistent volume %s of pod %s/%s in zone %s cannot be "+
+						"attached to node %s in zone %q", pv.Name, pod.Namespace, pod.Name, pvZone, node.Name, nodeZone)
+			}
+			break
+		}
+	}
+	return nil
+}
+
 // getPodOwnersInfo gets the pods owner objects (deployment, replicaset, et al)
 // and the client interfaces to make updates to the objects.
 // TODO: this piece of code can be cleaned up and can be made generic to return
@@ -554,10 +682,7 @@ func getPodOwnersInfo(clusterScraper *cluster.ClusterScraper, pod *api.Pod,
 		var res schema.GroupVersionResource
 		switch gpOwnerInfo.Kind {
 		case commonutil.KindDeployment:
-			res = schema.GroupVersionResource{
-				Group:    commonutil.K8sAPIDeploymentGV.Group,
-				Version:  commonutil.K8sAPIDeploymentGV.Version,
-				Resource: commonutil.DeploymentResName}
+			res = clusterScraper.ControllerGroupVersions().DeploymentResource()
 		case commonutil.KindDeploymentConfig:
 			res = schema.GroupVersionResource{
 				Group:    commonutil.OpenShiftAPIDeploymentConfigGV.Group,
@@ -707,7 +832,8 @@ func removeGCLabel(obj *unstructured.Unstructured) {
 }
 
 func createClonePod(client *kclient.Clientset, pod *api.Pod,
-	parent *unstructured.Unstructured, updateQuotaToAllowMoves bool, nodeName string) (*api.Pod, error) {
+	parent *unstructured.Unstructured, updateQuotaToAllowMoves bool, nodeName string,
+	schedulerPlacement bool) (*api.Pod, error) {
 	npod := &api.Pod{}
 
 	// This can be made configurable if need be in future
@@ -754,6 +880,9 @@ func createClonePod(client *kclient.Clientset, pod *api.Pod,
 	// Set podSpec retrieved from parent. This saves from landing into
 	// problems of sidecar containers and injection systems.
 	npod.Spec.NodeName = nodeName
+	if schedulerPlacement {
+		delegatePlacement(&npod.Spec, nodeName, pod.Spec.NodeName)
+	}
 	npod.Name = genNewPodName(pod)
 	// this annotation can be used for future garbage collection if action is interrupted
 	util.AddAnnotation(npod, TurboActionAnnotationKey, TurboMoveAnnotationValue)
@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/executor/nodegroup"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/turbostore"
+)
+
+const (
+	// The status of cluster-autoscaler, which lists the node groups it scales
+	autoscalerStatusNamespace = "kube-system"
+	autoscalerStatusConfigMap = "cluster-autoscaler-status"
+)
+
+// NodeGroupScaler executes the node provision actions of the clusters without the machine API, by scaling up the
+// cloud node group of the node, e.g. its AWS auto scaling group or GCE managed instance group. The cloud and thus
+// the node group provider are found from the provider ID of the node. The action succeeds once the cloud accepts
+// the new size; the new node joins the cluster asynchronously.
+//
+// The autoscaler of an autoscaled node group would scale the group back, so the minimum size of the group is raised to
+// the new size along with it, e.g. the minimum size of an auto scaling group discovered by cluster-autoscaler, or the
+// minimum number of replicas of the GCE autoscaler of a managed instance group. The autoscaler then keeps the new node
+// until the minimum size is lowered again. The node groups of cluster-autoscaler configured with its --nodes flag
+// take their minimum size from the flag, which kubeturbo cannot change, so the actions on them are refused.
+type NodeGroupScaler struct {
+	client kubernetes.Interface
+	// The node groups being scaled
+	cache InProgressCache
+	// Returns the node group provider of a node, replaced in tests
+	providerForNode func(nodeName string) (nodegroup.Provider, *nodegroup.NodeGroup, error)
+	// Returns the status of cluster-autoscaler, empty if it does not run, replaced in tests
+	autoscalerStatus func() string
+}
+
+func NewNodeGroupScaler(client kubernetes.Interface) *NodeGroupScaler {
+	scaler := &NodeGroupScaler{
+		client: client,
+		cache:  turbostore.NewCache(),
+	}
+	scaler.providerForNode = scaler.getNodeGroup
+	scaler.autoscalerStatus = scaler.getAutoscalerStatus
+	return scaler
+}
+
+// Execute scales up the node group of the node of the provision action by one node.
+func (s *NodeGroupScaler) Execute(input *TurboActionExecutorInput) (*TurboActionExecutorOutput, error) {
+	nodeName := input.ActionItems[0].GetTargetSE().GetDisplayName()
+	provider, group, err := s.providerForNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s/%s", group.Location, group.Name)
+	if _, found := s.cache.Get(key); found {
+		return nil, util.NewActionRefusalError(util.ReasonActionInProgress,
+			"the action against the node group %s is already running", group.Name)
+	}
+	s.cache.Add(key, key)
+	defer func() {
+		if err := s.cache.Delete(key); err != nil {
+			glog.Errorf("Error unlocking action %v", err)
+		}
+	}()
+	if !group.Autoscaled && autoscalerStatusLists(s.autoscalerStatus(), group.Name) {
+		return nil, util.NewActionRefusalError(util.ReasonNodePoolAutoscaled,
+			"the %s node group %s is scaled by cluster-autoscaler within the sizes of its --nodes flag",
+			provider.Name(), group.Name)
+	}
+	if group.MaxSize > 0 && group.TargetSize >= group.MaxSize {
+		return nil, util.NewActionRefusalError(util.ReasonNodePoolMaxSize,
+			"the %s node group %s is already at its maximum size %d", provider.Name(), group.Name, group.MaxSize)
+	}
+	newSize := group.TargetSize + 1
+	if group.Autoscaled && group.MinSize < newSize {
+		reportStep(input.Progress, progressStarted, "Raising the minimum size of the autoscaled %s node group %s "+
+			"to %d nodes", provider.Name(), group.Name, newSize)
+		if err := provider.SetMinSize(group, newSize); err != nil {
+			return nil, fmt.Errorf("failed to raise the minimum size of the %s node group %s: %v", provider.Name(),
+				group.Name, err)
+		}
+		glog.V(2).Infof("Raised the minimum size of the autoscaled %s node group %s from %d to %d nodes.",
+			provider.Name(), group.Name, group.MinSize, newSize)
+	}
+	reportStep(input.Progress, progressStarted, "Scaling up the %s node group %s to %d nodes", provider.Name(), group.Name,
+		newSize)
+	if err := provider.SetTargetSize(group, newSize); err != nil {
+		return nil, fmt.Errorf("failed to scale up the %s node group %s: %v", provider.Name(), group.Name, err)
+	}
+	glog.V(2).Infof("Scaled up the %s node group %s of node %s from %d to %d nodes.", provider.Name(), group.Name,
+		nodeName, group.TargetSize, newSize)
+	return &TurboActionExecutorOutput{Succeeded: true}, nil
+}
+
+func (s *NodeGroupScaler) getNodeGroup(nodeName string) (nodegroup.Provider, *nodegroup.NodeGroup, error) {
+	node, err := s.client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+	provider, err := nodegroup.ProviderForNode(node)
+	if err != nil {
+		return nil, nil, err
+	}
+	group, err := provider.GetNodeGroup(node)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the %s node group of node %s: %v", provider.Name(), nodeName, err)
+	}
+	return provider, group, nil
+}
+
+func (s *NodeGroupScaler) getAutoscalerStatus() string {
+	configMap, err := s.client.CoreV1().ConfigMaps(autoscalerStatusNamespace).Get(context.TODO(),
+		autoscalerStatusConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			glog.Warningf("Failed to get the status of cluster-autoscaler: %v", err)
+		}
+		return ""
+	}
+	return configMap.Data["status"]
+}
+
+// autoscalerStatusLists returns whether the status of cluster-autoscaler lists the node group, e.g. "Name: workers"
+// in the text status of the versions before 1.30 or "- name: workers" in the YAML status since. The name of a GCE
+// managed instance group is its URL there.
+func autoscalerStatusLists(status, groupName string) bool {
+	for _, line := range strings.Split(status, "\n") {
+		key, value, found := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "- "), ":")
+		if !found || !strings.EqualFold(key, "name") {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value == groupName || path.Base(value) == groupName {
+			return true
+		}
+	}
+	return false
+}
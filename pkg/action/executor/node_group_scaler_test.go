@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/executor/nodegroup"
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+type fakeNodeGroupProvider struct {
+	sizes    map[string]int
+	minSizes map[string]int
+}
+
+func (p *fakeNodeGroupProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeNodeGroupProvider) GetNodeGroup(node *api.Node) (*nodegroup.NodeGroup, error) {
+	return nil, nil
+}
+
+func (p *fakeNodeGroupProvider) SetTargetSize(group *nodegroup.NodeGroup, size int) error {
+	p.sizes[group.Name] = size
+	return nil
+}
+
+func (p *fakeNodeGroupProvider) SetMinSize(group *nodegroup.NodeGroup, size int) error {
+	p.minSizes[group.Name] = size
+	return nil
+}
+
+func newNodeProvisionInput() *TurboActionExecutorInput {
+	actionType := proto.ActionItemDTO_PROVISION
+	entityType := proto.EntityDTO_VIRTUAL_MACHINE
+	nodeName := "node-1"
+	return &TurboActionExecutorInput{ActionItems: []*proto.ActionItemDTO{{
+		ActionType: &actionType,
+		TargetSE:   &proto.EntityDTO{EntityType: &entityType, DisplayName: &nodeName},
+	}}}
+}
+
+func TestNodeGroupScaler(t *testing.T) {
+	provider := &fakeNodeGroupProvider{sizes: make(map[string]int), minSizes: make(map[string]int)}
+	group := &nodegroup.NodeGroup{Name: "workers", Location: "us-west-2", TargetSize: 3, MaxSize: 4}
+	scaler := NewNodeGroupScaler(nil)
+	scaler.providerForNode = func(nodeName string) (nodegroup.Provider, *nodegroup.NodeGroup, error) {
+		assert.Equal(t, "node-1", nodeName)
+		return provider, group, nil
+	}
+	autoscalerStatus := ""
+	scaler.autoscalerStatus = func() string {
+		return autoscalerStatus
+	}
+
+	output, err := scaler.Execute(newNodeProvisionInput())
+	assert.NoError(t, err)
+	assert.True(t, output.Succeeded)
+	assert.Equal(t, 4, provider.sizes["workers"])
+	assert.Empty(t, provider.minSizes)
+
+	// The node group is at its maximum size
+	group.TargetSize = 4
+	_, err = scaler.Execute(newNodeProvisionInput())
+	reason, refused := actionutil.GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, actionutil.ReasonNodePoolMaxSize, reason)
+
+	// The minimum size of the node group scaled by its autoscaler is raised along with its size, even when the
+	// autoscaler lists it in its status
+	group.TargetSize = 2
+	group.MinSize = 1
+	group.Autoscaled = true
+	autoscalerStatus = "Cluster-autoscaler status at 2023-07-01 10:00:00 +0000 UTC:\nNodeGroups:\n  Name:        workers\n"
+	output, err = scaler.Execute(newNodeProvisionInput())
+	assert.NoError(t, err)
+	assert.True(t, output.Succeeded)
+	assert.Equal(t, 3, provider.minSizes["workers"])
+	assert.Equal(t, 3, provider.sizes["workers"])
+
+	// The node group is listed by the status of cluster-autoscaler, which takes its sizes from its flags
+	group.TargetSize = 3
+	group.Autoscaled = false
+	_, err = scaler.Execute(newNodeProvisionInput())
+	reason, refused = actionutil.GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, actionutil.ReasonNodePoolAutoscaled, reason)
+	assert.Equal(t, 3, provider.sizes["workers"])
+
+	// The node group is already being scaled
+	autoscalerStatus = ""
+	scaler.cache.Add("us-west-2/workers", "us-west-2/workers")
+	_, err = scaler.Execute(newNodeProvisionInput())
+	reason, refused = actionutil.GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, actionutil.ReasonActionInProgress, reason)
+}
+
+func TestAutoscalerStatusLists(t *testing.T) {
+	textStatus := "NodeGroups:\n  Name:        workers\n  Health:      Healthy (ready=3)\n"
+	yamlStatus := "nodeGroups:\n- name: https://www.googleapis.com/compute/v1/projects/p/zones/z/instanceGroups/pool-1\n" +
+		"  health:\n    status: Healthy\n"
+	assert.True(t, autoscalerStatusLists(textStatus, "workers"))
+	assert.False(t, autoscalerStatusLists(textStatus, "work"))
+	assert.True(t, autoscalerStatusLists(yamlStatus, "pool-1"))
+	assert.False(t, autoscalerStatusLists(yamlStatus, "pool"))
+	assert.False(t, autoscalerStatusLists("", "workers"))
+}
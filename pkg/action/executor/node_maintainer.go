@@ -0,0 +1,206 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+const (
+	// The node suspend modes: the node is removed by scaling down its machine set, or only cordoned, or cordoned and
+	// drained so that it can be removed by the cluster administrator or the cluster autoscaler
+	NodeSuspendModeMachineSet = "machine-set"
+	NodeSuspendModeCordon     = "cordon"
+	NodeSuspendModeDrain      = "drain"
+
+	DefaultMaxConcurrentNodeDrains = 1
+
+	defaultNodeDrainTimeout      = 10 * time.Minute
+	defaultNodeDrainPollInterval = 5 * time.Second
+)
+
+// NodeMaintainer executes the node suspend actions by cordoning the node and, in the drain mode, evicting its pods.
+// The pods are evicted with the eviction API, so that the pod disruption budgets are respected; an eviction refused
+// by a disruption budget is retried until the drain times out.
+type NodeMaintainer struct {
+	client kubernetes.Interface
+	drain  bool
+	// Bounds the number of the node drains in progress
+	drains       chan struct{}
+	timeout      time.Duration
+	pollInterval time.Duration
+}
+
+func NewNodeMaintainer(client kubernetes.Interface, mode string, maxConcurrentDrains int) *NodeMaintainer {
+	if maxConcurrentDrains <= 0 {
+		maxConcurrentDrains = DefaultMaxConcurrentNodeDrains
+	}
+	return &NodeMaintainer{
+		client:       client,
+		drain:        mode == NodeSuspendModeDrain,
+		drains:       make(chan struct{}, maxConcurrentDrains),
+		timeout:      defaultNodeDrainTimeout,
+		pollInterval: defaultNodeDrainPollInterval,
+	}
+}
+
+// Execute cordons the node of the suspend action, and drains it in the drain mode.
+func (m *NodeMaintainer) Execute(input *TurboActionExecutorInput) (*TurboActionExecutorOutput, error) {
+	nodeName := input.ActionItems[0].GetTargetSE().GetDisplayName()
+	if m.drain {
+		select {
+		case m.drains <- struct{}{}:
+			defer func() { <-m.drains }()
+		default:
+			return nil, actionutil.NewActionRefusalError(actionutil.ReasonTooManyNodeDrains,
+				"%d node drains are already in progress", cap(m.drains))
+		}
+		// Like kubectl without --force, the pods with no controller are not evicted as they would not be recreated
+		if err := m.checkUnmanagedPods(nodeName); err != nil {
+			return nil, err
+		}
+	}
+	node, err := m.client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+	cordoned := !node.Spec.Unschedulable
+	if cordoned {
+		if err := m.setUnschedulable(nodeName, true); err != nil {
+			return nil, fmt.Errorf("failed to cordon node %s: %v", nodeName, err)
+		}
+		glog.V(2).Infof("Cordoned node %s.", nodeName)
+	}
+	if !m.drain {
+		return &TurboActionExecutorOutput{Succeeded: true}, nil
+	}
+	reportStep(input.Progress, progressStarted, "Draining node %s", nodeName)
+	if err := m.drainNode(nodeName, input.Progress); err != nil {
+		// Leave the node as it was, the pods already evicted may be scheduled back on it
+		if cordoned {
+			if uncordonErr := m.setUnschedulable(nodeName, false); uncordonErr != nil {
+				glog.Errorf("Failed to uncordon node %s after the failed drain: %v", nodeName, uncordonErr)
+			}
+		}
+		return nil, err
+	}
+	glog.V(2).Infof("Drained node %s.", nodeName)
+	return &TurboActionExecutorOutput{Succeeded: true}, nil
+}
+
+func (m *NodeMaintainer) setUnschedulable(nodeName string, unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err := m.client.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.StrategicMergePatchType,
+		[]byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// drainNode evicts the pods of the node, and waits until they are gone.
+func (m *NodeMaintainer) drainNode(nodeName string, progress ProgressReporter) error {
+	var remaining []*api.Pod
+	var lastErr error
+	// The number of the pods to evict when the drain started
+	var total int
+	err := wait.PollImmediate(m.pollInterval, m.timeout, func() (bool, error) {
+		pods, err := m.podsToEvict(nodeName)
+		if err != nil {
+			glog.Warningf("Failed to list the pods of node %s: %v", nodeName, err)
+			return false, nil
+		}
+		remaining = pods
+		if len(pods) == 0 {
+			return true, nil
+		}
+		if total == 0 {
+			total = len(pods)
+		}
+		reportStep(progress, stepPercent(progressStarted, progressFinishing, int64(total-len(pods)), int64(total)),
+			"Draining node %s: %d pods remaining", nodeName, len(pods))
+		for _, pod := range pods {
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			if err := evict(m.client, pod); err != nil {
+				lastErr = err
+				if errors.IsTooManyRequests(err) {
+					glog.V(3).Infof("Eviction of pod %s/%s is blocked by a disruption budget, will retry.",
+						pod.Namespace, pod.Name)
+				} else {
+					glog.Warningf("Failed to evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				}
+			}
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		if lastErr != nil && errors.IsTooManyRequests(lastErr) {
+			return actionutil.NewActionRefusalError(actionutil.ReasonDisruptionBudget,
+				"node %s is not drained within %v, %d pods remaining: %v", nodeName, m.timeout, len(remaining), lastErr)
+		}
+		return fmt.Errorf("node %s is not drained within %v, %d pods remaining: %v",
+			nodeName, m.timeout, len(remaining), lastErr)
+	}
+	return err
+}
+
+// checkUnmanagedPods refuses the drain of the node if a pod to evict has no controller.
+func (m *NodeMaintainer) checkUnmanagedPods(nodeName string) error {
+	pods, err := m.podsToEvict(nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to list the pods of node %s: %v", nodeName, err)
+	}
+	for _, pod := range pods {
+		if metav1.GetControllerOf(pod) == nil {
+			return actionutil.NewActionRefusalError(actionutil.ReasonUnsupportedOwner,
+				"pod %s/%s on node %s has no controller and would not be recreated after the drain",
+				pod.Namespace, pod.Name, nodeName)
+		}
+	}
+	return nil
+}
+
+// podsToEvict returns the pods of the node which are evicted by a drain.
+func (m *NodeMaintainer) podsToEvict(nodeName string) ([]*api.Pod, error) {
+	podList, err := m.client.CoreV1().Pods(api.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pods []*api.Pod
+	for i := range podList.Items {
+		if pod := &podList.Items[i]; !skipDrain(pod) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// skipDrain returns whether the pod is left on the node by a drain, like kubectl does: the daemon set pods are
+// recreated on the node anyway, the mirror pods cannot be evicted, and the finished pods do not run any more.
+func skipDrain(pod *api.Pod) bool {
+	if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+		return true
+	}
+	if _, found := pod.Annotations[api.MirrorPodAnnotationKey]; found {
+		return true
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller != nil && *owner.Controller && owner.Kind == util.KindDaemonSet {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+// fakeNodeServer is a fake API server with a node and the pods running on it. The evictions delete the pods, unless
+// they are blocked by a disruption budget.
+type fakeNodeServer struct {
+	lock          sync.Mutex
+	unschedulable bool
+	pods          []api.Pod
+	blockEviction bool
+	evictions     []string
+}
+
+func (f *fakeNodeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/api/v1/nodes/node-1" && r.Method == http.MethodGet:
+		node := api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Spec: api.NodeSpec{Unschedulable: f.unschedulable}}
+		json.NewEncoder(w).Encode(node)
+	case r.URL.Path == "/api/v1/nodes/node-1" && r.Method == http.MethodPatch:
+		body, _ := io.ReadAll(r.Body)
+		f.unschedulable = strings.Contains(string(body), `"unschedulable":true`)
+		json.NewEncoder(w).Encode(api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	case r.URL.Path == "/api/v1/pods" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(api.PodList{Items: f.pods})
+	case strings.HasSuffix(r.URL.Path, "/eviction") && r.Method == http.MethodPost:
+		name := strings.Split(r.URL.Path, "/")[6]
+		f.evictions = append(f.evictions, name)
+		if f.blockEviction {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"TooManyRequests","code":429}`)
+			return
+		}
+		for i, pod := range f.pods {
+			if pod.Name == name {
+				f.pods = append(f.pods[:i], f.pods[i+1:]...)
+				break
+			}
+		}
+		fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Success"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestNodeMaintainer(t *testing.T, fake *fakeNodeServer, mode string) (*NodeMaintainer, func()) {
+	server := httptest.NewServer(fake)
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NoError(t, err)
+	maintainer := NewNodeMaintainer(client, mode, 1)
+	maintainer.timeout = time.Second
+	maintainer.pollInterval = 10 * time.Millisecond
+	return maintainer, server.Close
+}
+
+func newNodeSuspendInput() *TurboActionExecutorInput {
+	actionType := proto.ActionItemDTO_SUSPEND
+	entityType := proto.EntityDTO_VIRTUAL_MACHINE
+	nodeName := "node-1"
+	return &TurboActionExecutorInput{ActionItems: []*proto.ActionItemDTO{{
+		ActionType: &actionType,
+		TargetSE:   &proto.EntityDTO{EntityType: &entityType, DisplayName: &nodeName},
+	}}}
+}
+
+func newNodePod(name string) api.Pod {
+	isController := true
+	return api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", OwnerReferences: []metav1.OwnerReference{{
+			Kind: "ReplicaSet", Name: "replica-set", Controller: &isController,
+		}}},
+		Spec:   api.PodSpec{NodeName: "node-1"},
+		Status: api.PodStatus{Phase: api.PodRunning},
+	}
+}
+
+func TestNodeMaintainerCordon(t *testing.T) {
+	fake := &fakeNodeServer{pods: []api.Pod{newNodePod("pod-1")}}
+	maintainer, stop := newTestNodeMaintainer(t, fake, NodeSuspendModeCordon)
+	defer stop()
+
+	output, err := maintainer.Execute(newNodeSuspendInput())
+	assert.NoError(t, err)
+	assert.True(t, output.Succeeded)
+	assert.True(t, fake.unschedulable)
+	// The pods are left on the cordoned node
+	assert.Empty(t, fake.evictions)
+}
+
+func TestNodeMaintainerDrain(t *testing.T) {
+	daemonPod := newNodePod("daemon-1")
+	isController := true
+	daemonPod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "daemon", Controller: &isController}}
+	fake := &fakeNodeServer{pods: []api.Pod{newNodePod("pod-1"), newNodePod("pod-2"), daemonPod}}
+	maintainer, stop := newTestNodeMaintainer(t, fake, NodeSuspendModeDrain)
+	defer stop()
+
+	output, err := maintainer.Execute(newNodeSuspendInput())
+	assert.NoError(t, err)
+	assert.True(t, output.Succeeded)
+	assert.True(t, fake.unschedulable)
+	assert.ElementsMatch(t, []string{"pod-1", "pod-2"}, fake.evictions)
+}
+
+func TestNodeMaintainerDrainBlockedByDisruptionBudget(t *testing.T) {
+	fake := &fakeNodeServer{pods: []api.Pod{newNodePod("pod-1")}, blockEviction: true}
+	maintainer, stop := newTestNodeMaintainer(t, fake, NodeSuspendModeDrain)
+	defer stop()
+
+	_, err := maintainer.Execute(newNodeSuspendInput())
+	reason, refused := actionutil.GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, actionutil.ReasonDisruptionBudget, reason)
+	// The node cordoned by the failed drain is uncordoned
+	assert.False(t, fake.unschedulable)
+}
+
+func TestNodeMaintainerDrainUnmanagedPod(t *testing.T) {
+	barePod := newNodePod("bare-1")
+	barePod.OwnerReferences = nil
+	fake := &fakeNodeServer{pods: []api.Pod{newNodePod("pod-1"), barePod}}
+	maintainer, stop := newTestNodeMaintainer(t, fake, NodeSuspendModeDrain)
+	defer stop()
+
+	_, err := maintainer.Execute(newNodeSuspendInput())
+	reason, refused := actionutil.GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, actionutil.ReasonUnsupportedOwner, reason)
+	// The node is neither cordoned nor drained
+	assert.False(t, fake.unschedulable)
+	assert.Empty(t, fake.evictions)
+
+	// The bare pod is left on the cordoned node
+	maintainer, stop = newTestNodeMaintainer(t, fake, NodeSuspendModeCordon)
+	defer stop()
+	_, err = maintainer.Execute(newNodeSuspendInput())
+	assert.NoError(t, err)
+}
+
+func TestNodeMaintainerMaxConcurrentDrains(t *testing.T) {
+	fake := &fakeNodeServer{}
+	maintainer, stop := newTestNodeMaintainer(t, fake, NodeSuspendModeDrain)
+	defer stop()
+
+	// Another drain is in progress
+	maintainer.drains <- struct{}{}
+	_, err := maintainer.Execute(newNodeSuspendInput())
+	reason, refused := actionutil.GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, actionutil.ReasonTooManyNodeDrains, reason)
+	assert.False(t, fake.unschedulable)
+
+	<-maintainer.drains
+	_, err = maintainer.Execute(newNodeSuspendInput())
+	assert.NoError(t, err)
+}
+
+func TestSkipDrain(t *testing.T) {
+	isController := true
+	tests := []struct {
+		name string
+		pod  api.Pod
+		want bool
+	}{
+		{"running", newNodePod("pod-1"), false},
+		{"succeeded", api.Pod{Status: api.PodStatus{Phase: api.PodSucceeded}}, true},
+		{"mirror", api.Pod{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{api.MirrorPodAnnotationKey: "hash"}}}, true},
+		{"daemon set", api.Pod{ObjectMeta: metav1.ObjectMeta{
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Controller: &isController}}}}, true},
+		{"replica set", api.Pod{ObjectMeta: metav1.ObjectMeta{
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Controller: &isController}}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, skipDrain(&tt.pod))
+		})
+	}
+}
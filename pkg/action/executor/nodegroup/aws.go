@@ -0,0 +1,159 @@
+package nodegroup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+)
+
+const (
+	awsScheme = "aws"
+	// The tag of the auto scaling groups discovered by cluster-autoscaler with --node-group-auto-discovery
+	awsAutoscalerEnabledTag = "k8s.io/cluster-autoscaler/enabled"
+)
+
+func init() {
+	RegisterProvider(awsScheme, func() (Provider, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newAWSProvider(cfg), nil
+	})
+}
+
+// awsProvider scales the auto scaling groups of the nodes on AWS. The credentials are resolved by the default chain
+// of the AWS SDK, i.e. the environment, the shared configuration files, the web identity of the service account
+// (IRSA) and the role of the EC2 instance.
+type awsProvider struct {
+	config aws.Config
+	// The options of the auto scaling clients, e.g. the endpoint in tests
+	options []func(*autoscaling.Options)
+}
+
+func newAWSProvider(cfg aws.Config, options ...func(*autoscaling.Options)) *awsProvider {
+	return &awsProvider{
+		config:  cfg,
+		options: options,
+	}
+}
+
+func (p *awsProvider) Name() string {
+	return "AWS"
+}
+
+// GetNodeGroup returns the auto scaling group named by the node group annotation, or the one of the EC2 instance of
+// the node otherwise, e.g. i-0123 for the provider ID aws:///us-west-2a/i-0123, in the region of the node.
+func (p *awsProvider) GetNodeGroup(node *api.Node) (*NodeGroup, error) {
+	parts := providerIDParts(node)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("unexpected provider ID %q of node %s", node.Spec.ProviderID, node.Name)
+	}
+	instanceID := parts[len(parts)-1]
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	region, err := p.region(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	client := p.client(region)
+	groupName := node.Annotations[NodeGroupAnnotation]
+	if groupName == "" {
+		instances, err := client.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: []string{instanceID},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(instances.AutoScalingInstances) == 0 {
+			return nil, fmt.Errorf("EC2 instance %s of node %s is not in an auto scaling group", instanceID, node.Name)
+		}
+		groupName = aws.ToString(instances.AutoScalingInstances[0].AutoScalingGroupName)
+	}
+	groups, err := client.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{groupName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups.AutoScalingGroups) == 0 {
+		return nil, fmt.Errorf("auto scaling group %s of node %s not found in region %s", groupName, node.Name, region)
+	}
+	group := groups.AutoScalingGroups[0]
+	nodeGroup := &NodeGroup{
+		Name:       groupName,
+		Location:   region,
+		TargetSize: int(aws.ToInt32(group.DesiredCapacity)),
+		MinSize:    int(aws.ToInt32(group.MinSize)),
+		MaxSize:    int(aws.ToInt32(group.MaxSize)),
+	}
+	for _, tag := range group.Tags {
+		if aws.ToString(tag.Key) == awsAutoscalerEnabledTag {
+			nodeGroup.Autoscaled = true
+		}
+	}
+	return nodeGroup, nil
+}
+
+// SetTargetSize sets the desired capacity of the auto scaling group.
+func (p *awsProvider) SetTargetSize(group *NodeGroup, size int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := p.client(group.Location).SetDesiredCapacity(ctx, &autoscaling.SetDesiredCapacityInput{
+		AutoScalingGroupName: aws.String(group.Name),
+		DesiredCapacity:      aws.Int32(int32(size)),
+		HonorCooldown:        aws.Bool(false),
+	})
+	if err != nil {
+		return err
+	}
+	glog.V(4).Infof("Set the desired capacity of the AWS auto scaling group %s in %s to %d.", group.Name,
+		group.Location, size)
+	return nil
+}
+
+// SetMinSize sets the minimum size of the auto scaling group, which cluster-autoscaler does not scale below.
+func (p *awsProvider) SetMinSize(group *NodeGroup, size int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := p.client(group.Location).UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(group.Name),
+		MinSize:              aws.Int32(int32(size)),
+	})
+	if err != nil {
+		return err
+	}
+	glog.V(4).Infof("Set the minimum size of the AWS auto scaling group %s in %s to %d.", group.Name,
+		group.Location, size)
+	return nil
+}
+
+// region returns the region label of the node, or the region of the AWS configuration, e.g. AWS_REGION, or the
+// region of the EC2 instance of kubeturbo otherwise.
+func (p *awsProvider) region(ctx context.Context, node *api.Node) (string, error) {
+	if region := node.Labels[api.LabelTopologyRegion]; region != "" {
+		return region, nil
+	}
+	if p.config.Region != "" {
+		return p.config.Region, nil
+	}
+	output, err := imds.NewFromConfig(p.config).GetRegion(ctx, &imds.GetRegionInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the AWS region of node %s: %v", node.Name, err)
+	}
+	return output.Region, nil
+}
+
+func (p *awsProvider) client(region string) *autoscaling.Client {
+	return autoscaling.NewFromConfig(p.config, append([]func(*autoscaling.Options){func(o *autoscaling.Options) {
+		o.Region = region
+	}}, p.options...)...)
+}
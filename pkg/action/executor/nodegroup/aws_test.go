@@ -0,0 +1,106 @@
+package nodegroup
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newAWSNode(providerID string, labels map[string]string, annotations map[string]string) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: labels, Annotations: annotations},
+		Spec:       api.NodeSpec{ProviderID: providerID},
+	}
+}
+
+func TestAWSProvider(t *testing.T) {
+	var actions []url.Values
+	var regions []string
+	tags := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		params, _ := url.ParseQuery(string(body))
+		actions = append(actions, params)
+		// e.g. AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20230701/us-west-2/autoscaling/aws4_request, ...
+		authorization := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+		regions = append(regions, strings.Split(authorization, "/")[2])
+		switch params.Get("Action") {
+		case "DescribeAutoScalingInstances":
+			fmt.Fprintf(w, `<DescribeAutoScalingInstancesResponse><DescribeAutoScalingInstancesResult>
+<AutoScalingInstances><member><InstanceId>%s</InstanceId><AutoScalingGroupName>workers</AutoScalingGroupName>
+</member></AutoScalingInstances></DescribeAutoScalingInstancesResult></DescribeAutoScalingInstancesResponse>`,
+				params.Get("InstanceIds.member.1"))
+		case "DescribeAutoScalingGroups":
+			fmt.Fprintf(w, `<DescribeAutoScalingGroupsResponse><DescribeAutoScalingGroupsResult><AutoScalingGroups>
+<member><AutoScalingGroupName>%s</AutoScalingGroupName><DesiredCapacity>3</DesiredCapacity><MinSize>1</MinSize><MaxSize>5</MaxSize>
+<Tags>%s</Tags></member></AutoScalingGroups></DescribeAutoScalingGroupsResult></DescribeAutoScalingGroupsResponse>`,
+				params.Get("AutoScalingGroupNames.member.1"), tags)
+		case "SetDesiredCapacity":
+			fmt.Fprint(w, `<SetDesiredCapacityResponse></SetDesiredCapacityResponse>`)
+		case "UpdateAutoScalingGroup":
+			fmt.Fprint(w, `<UpdateAutoScalingGroupResponse></UpdateAutoScalingGroupResponse>`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidAction</Code><Message>unknown</Message></Error></ErrorResponse>`)
+		}
+	}))
+	defer server.Close()
+	provider := newAWSProvider(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		HTTPClient:  server.Client(),
+	}, func(o *autoscaling.Options) {
+		o.EndpointResolver = autoscaling.EndpointResolverFromURL(server.URL)
+	})
+
+	// The region is the region label of the node
+	group, err := provider.GetNodeGroup(newAWSNode("aws:///us-west-2-lax-1a/i-0123",
+		map[string]string{api.LabelTopologyRegion: "us-west-2"}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, &NodeGroup{Name: "workers", Location: "us-west-2", TargetSize: 3, MinSize: 1, MaxSize: 5}, group)
+	assert.Equal(t, []string{"us-west-2", "us-west-2"}, regions)
+	assert.Equal(t, "i-0123", actions[0].Get("InstanceIds.member.1"))
+
+	actions = nil
+	assert.NoError(t, provider.SetTargetSize(group, 4))
+	assert.Equal(t, "workers", actions[0].Get("AutoScalingGroupName"))
+	assert.Equal(t, "4", actions[0].Get("DesiredCapacity"))
+
+	actions = nil
+	assert.NoError(t, provider.SetMinSize(group, 4))
+	assert.Equal(t, "UpdateAutoScalingGroup", actions[0].Get("Action"))
+	assert.Equal(t, "workers", actions[0].Get("AutoScalingGroupName"))
+	assert.Equal(t, "4", actions[0].Get("MinSize"))
+
+	// Without a region label, the region of the configuration is used; the group is discovered by cluster-autoscaler
+	regions = nil
+	tags = `<member><Key>k8s.io/cluster-autoscaler/enabled</Key><Value>true</Value></member>`
+	group, err = provider.GetNodeGroup(newAWSNode("aws:///us-east-1a/i-0123", nil, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"us-east-1", "us-east-1"}, regions)
+	assert.True(t, group.Autoscaled)
+
+	// The group named by the annotation is not looked up from the instance
+	actions = nil
+	group, err = provider.GetNodeGroup(newAWSNode("aws:///us-east-1a/i-0123", nil,
+		map[string]string{NodeGroupAnnotation: "gpu-workers"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "gpu-workers", group.Name)
+	assert.Len(t, actions, 1)
+	assert.Equal(t, "DescribeAutoScalingGroups", actions[0].Get("Action"))
+
+	_, err = provider.GetNodeGroup(newAWSNode("aws://", nil, nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package nodegroup
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/golang/glog"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+	api "k8s.io/api/core/v1"
+)
+
+const (
+	gceScheme = "gce"
+	// The metadata of an instance created by a managed instance group, with the path of the group
+	gceCreatedByKey = "created-by"
+)
+
+func init() {
+	RegisterProvider(gceScheme, func() (Provider, error) {
+		return newGCEProvider()
+	})
+}
+
+// gceProvider scales the managed instance groups of the nodes on GCE. The compute API is called with the application
+// default credentials, e.g. GOOGLE_APPLICATION_CREDENTIALS, the workload identity of the service account or the
+// service account of the instance.
+type gceProvider struct {
+	service *compute.Service
+}
+
+func newGCEProvider(options ...option.ClientOption) (*gceProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	service, err := compute.NewService(ctx, append([]option.ClientOption{
+		option.WithScopes(compute.ComputeScope)}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &gceProvider{service: service}, nil
+}
+
+func (p *gceProvider) Name() string {
+	return "GCE"
+}
+
+// GetNodeGroup returns the managed instance group named by the node group annotation in the zone of the node, or the
+// one which created the instance of the node otherwise, e.g. instance-1 in zone us-central1-a of project my-project
+// for the provider ID gce://my-project/us-central1-a/instance-1. The location of the group is its project followed
+// by its zone or region, e.g. projects/my-project/zones/us-central1-a.
+func (p *gceProvider) GetNodeGroup(node *api.Node) (*NodeGroup, error) {
+	parts := providerIDParts(node)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unexpected provider ID %q of node %s", node.Spec.ProviderID, node.Name)
+	}
+	project, zone, instanceName := parts[0], parts[1], parts[2]
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	group := &NodeGroup{}
+	if name := node.Annotations[NodeGroupAnnotation]; name != "" {
+		group.Name = name
+		group.Location = fmt.Sprintf("projects/%s/zones/%s", project, zone)
+	} else if err := p.findNodeGroup(ctx, project, zone, instanceName, group); err != nil {
+		return nil, err
+	}
+	if group.Name == "" || group.Location == "" {
+		return nil, fmt.Errorf("instance %s of node %s is not in a managed instance group", instanceName, node.Name)
+	}
+	var manager *compute.InstanceGroupManager
+	var err error
+	_, regional, zoneOrRegion := parseGCELocation(group.Location)
+	if regional {
+		manager, err = p.service.RegionInstanceGroupManagers.Get(project, zoneOrRegion, group.Name).Context(ctx).Do()
+	} else {
+		manager, err = p.service.InstanceGroupManagers.Get(project, zoneOrRegion, group.Name).Context(ctx).Do()
+	}
+	if err != nil {
+		return nil, err
+	}
+	group.TargetSize = int(manager.TargetSize)
+	if manager.Status == nil || manager.Status.Autoscaler == "" {
+		return group, nil
+	}
+	// The GCE autoscaler keeps the size of the group between its minimum and maximum number of replicas
+	group.Autoscaled = true
+	group.autoscaler = path.Base(manager.Status.Autoscaler)
+	var autoscaler *compute.Autoscaler
+	if regional {
+		autoscaler, err = p.service.RegionAutoscalers.Get(project, zoneOrRegion, group.autoscaler).Context(ctx).Do()
+	} else {
+		autoscaler, err = p.service.Autoscalers.Get(project, zoneOrRegion, group.autoscaler).Context(ctx).Do()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the autoscaler %s of the managed instance group %s: %v",
+			group.autoscaler, group.Name, err)
+	}
+	if policy := autoscaler.AutoscalingPolicy; policy != nil {
+		group.MinSize = int(policy.MinNumReplicas)
+		group.MaxSize = int(policy.MaxNumReplicas)
+	}
+	return group, nil
+}
+
+// findNodeGroup sets the name and the location of the managed instance group which created the instance.
+func (p *gceProvider) findNodeGroup(ctx context.Context, project, zone, instanceName string, group *NodeGroup) error {
+	instance, err := p.service.Instances.Get(project, zone, instanceName).Context(ctx).Do()
+	if err != nil {
+		return err
+	}
+	if instance.Metadata != nil {
+		for _, item := range instance.Metadata.Items {
+			if item.Key != gceCreatedByKey || item.Value == nil {
+				continue
+			}
+			// e.g. projects/123456/zones/us-central1-a/instanceGroupManagers/group-1, or regions/us-central1 for
+			// a regional group
+			location, name, found := strings.Cut(*item.Value, "/instanceGroupManagers/")
+			if !found {
+				break
+			}
+			if _, zoneOrRegion, found := strings.Cut(location, "/zones/"); found {
+				group.Location = fmt.Sprintf("projects/%s/zones/%s", project, zoneOrRegion)
+			} else if _, zoneOrRegion, found := strings.Cut(location, "/regions/"); found {
+				group.Location = fmt.Sprintf("projects/%s/regions/%s", project, zoneOrRegion)
+			}
+			group.Name = name
+		}
+	}
+	return nil
+}
+
+// SetTargetSize resizes the managed instance group.
+func (p *gceProvider) SetTargetSize(group *NodeGroup, size int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	var err error
+	if project, regional, zoneOrRegion := parseGCELocation(group.Location); regional {
+		_, err = p.service.RegionInstanceGroupManagers.Resize(project, zoneOrRegion, group.Name, int64(size)).
+			Context(ctx).Do()
+	} else {
+		_, err = p.service.InstanceGroupManagers.Resize(project, zoneOrRegion, group.Name, int64(size)).
+			Context(ctx).Do()
+	}
+	if err != nil {
+		return err
+	}
+	glog.V(4).Infof("Resized the GCE managed instance group %s in %s to %d.", group.Name, group.Location, size)
+	return nil
+}
+
+// SetMinSize sets the minimum number of replicas of the GCE autoscaler of the managed instance group.
+func (p *gceProvider) SetMinSize(group *NodeGroup, size int) error {
+	if group.autoscaler == "" {
+		return fmt.Errorf("the managed instance group %s has no autoscaler", group.Name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	patch := &compute.Autoscaler{AutoscalingPolicy: &compute.AutoscalingPolicy{MinNumReplicas: int64(size)}}
+	var err error
+	if project, regional, zoneOrRegion := parseGCELocation(group.Location); regional {
+		_, err = p.service.RegionAutoscalers.Patch(project, zoneOrRegion, patch).Autoscaler(group.autoscaler).
+			Context(ctx).Do()
+	} else {
+		_, err = p.service.Autoscalers.Patch(project, zoneOrRegion, patch).Autoscaler(group.autoscaler).
+			Context(ctx).Do()
+	}
+	if err != nil {
+		return err
+	}
+	glog.V(4).Infof("Set the minimum number of replicas of the autoscaler %s of the GCE managed instance group %s "+
+		"in %s to %d.", group.autoscaler, group.Name, group.Location, size)
+	return nil
+}
+
+// parseGCELocation returns the project of the location of a managed instance group, whether the location is a
+// region, and the zone or region, e.g. my-project, false and us-central1-a for projects/my-project/zones/us-central1-a.
+func parseGCELocation(location string) (string, bool, string) {
+	parts := strings.Split(location, "/")
+	return parts[1], parts[2] == "regions", parts[3]
+}
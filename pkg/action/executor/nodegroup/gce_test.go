@@ -0,0 +1,120 @@
+package nodegroup
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCEProvider(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/compute/v1/projects/my-project/zones/us-central1-a/instances/instance-1":
+			fmt.Fprint(w, `{"name":"instance-1","metadata":{"items":[{"key":"created-by",
+"value":"projects/123456/regions/us-central1/instanceGroupManagers/workers"}]}}`)
+		case "/compute/v1/projects/my-project/zones/us-central1-a/instances/instance-2":
+			fmt.Fprint(w, `{"name":"instance-2","metadata":{"items":[{"key":"created-by",
+"value":"projects/123456/zones/us-central1-a/instanceGroupManagers/gpu-workers"}]}}`)
+		case "/compute/v1/projects/my-project/zones/us-central1-a/instances/instance-3":
+			fmt.Fprint(w, `{"name":"instance-3","metadata":{"items":[]}}`)
+		case "/compute/v1/projects/my-project/regions/us-central1/instanceGroupManagers/workers":
+			fmt.Fprint(w, `{"targetSize":3}`)
+		case "/compute/v1/projects/my-project/zones/us-central1-a/instanceGroupManagers/gpu-workers":
+			fmt.Fprint(w, `{"targetSize":2,"status":{"autoscaler":"projects/my-project/zones/us-central1-a/autoscalers/gpu"}}`)
+		case "/compute/v1/projects/my-project/zones/us-central1-a/autoscalers/gpu":
+			fmt.Fprint(w, `{"name":"gpu","autoscalingPolicy":{"minNumReplicas":1,"maxNumReplicas":5}}`)
+		case "/compute/v1/projects/my-project/zones/us-central1-a/autoscalers":
+			assert.Equal(t, http.MethodPatch, r.Method)
+			assert.Equal(t, "gpu", r.URL.Query().Get("autoscaler"))
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"autoscalingPolicy":{"minNumReplicas":3}}`, string(body))
+			fmt.Fprint(w, `{"status":"DONE"}`)
+		case "/compute/v1/projects/my-project/regions/us-central1/instanceGroupManagers/workers/resize":
+			assert.Equal(t, "4", r.URL.Query().Get("size"))
+			fmt.Fprint(w, `{"status":"DONE"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":404,"message":"not found"}}`)
+		}
+	}))
+	defer server.Close()
+	provider, err := newGCEProvider(option.WithEndpoint(server.URL+"/compute/v1/"),
+		option.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+
+	node := &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       api.NodeSpec{ProviderID: "gce://my-project/us-central1-a/instance-1"},
+	}
+	// The instance was created by a regional managed instance group
+	group, err := provider.GetNodeGroup(node)
+	assert.NoError(t, err)
+	assert.Equal(t, &NodeGroup{Name: "workers", Location: "projects/my-project/regions/us-central1", TargetSize: 3},
+		group)
+	requests = nil
+	assert.NoError(t, provider.SetTargetSize(group, 4))
+	assert.Equal(t, []string{
+		"POST /compute/v1/projects/my-project/regions/us-central1/instanceGroupManagers/workers/resize",
+	}, requests)
+
+	// The zonal managed instance group has a GCE autoscaler
+	node.Spec.ProviderID = "gce://my-project/us-central1-a/instance-2"
+	group, err = provider.GetNodeGroup(node)
+	assert.NoError(t, err)
+	assert.Equal(t, &NodeGroup{Name: "gpu-workers", Location: "projects/my-project/zones/us-central1-a", TargetSize: 2,
+		MinSize: 1, MaxSize: 5, Autoscaled: true, autoscaler: "gpu"}, group)
+	assert.NoError(t, provider.SetMinSize(group, 3))
+
+	// The group named by the annotation is in the zone of the node, the instance is not looked up
+	requests = nil
+	node.Annotations = map[string]string{NodeGroupAnnotation: "gpu-workers"}
+	node.Spec.ProviderID = "gce://my-project/us-central1-a/instance-3"
+	group, err = provider.GetNodeGroup(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "gpu-workers", group.Name)
+	assert.NotContains(t, requests, "GET /compute/v1/projects/my-project/zones/us-central1-a/instances/instance-3")
+	node.Annotations = nil
+
+	// The instance is not in a managed instance group
+	node.Spec.ProviderID = "gce://my-project/us-central1-a/instance-3"
+	_, err = provider.GetNodeGroup(node)
+	assert.Error(t, err)
+
+	node.Spec.ProviderID = "gce://my-project/instance-1"
+	_, err = provider.GetNodeGroup(node)
+	assert.Error(t, err)
+}
+
+func TestProviderForNode(t *testing.T) {
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	_, err := ProviderForNode(node)
+	assert.Error(t, err)
+
+	node.Spec.ProviderID = "azure:///subscriptions/sub/resourceGroups/rg/providers/vm-1"
+	_, err = ProviderForNode(node)
+	assert.Error(t, err)
+
+	created := 0
+	RegisterProvider("fake", func() (Provider, error) {
+		created++
+		return &gceProvider{}, nil
+	})
+	node.Spec.ProviderID = "fake://node-1"
+	provider, err := ProviderForNode(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "GCE", provider.Name())
+	// The provider is created once
+	same, err := ProviderForNode(node)
+	assert.NoError(t, err)
+	assert.Same(t, provider, same)
+	assert.Equal(t, 1, created)
+}
@@ -0,0 +1,106 @@
+package nodegroup
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	api "k8s.io/api/core/v1"
+)
+
+// NodeGroupAnnotation names the node group of a node explicitly, e.g. the auto scaling group or the managed
+// instance group of the node. Without it, the node group is looked up from the cloud instance of the node.
+const NodeGroupAnnotation = "kubeturbo.io/node-group"
+
+// The timeout of the requests to the cloud APIs
+const requestTimeout = 30 * time.Second
+
+// NodeGroup is a group of identical nodes scaled by the cloud, e.g. an AWS auto scaling group or a GCE managed
+// instance group.
+type NodeGroup struct {
+	Name string
+	// The location of the node group in the cloud, e.g. the region of an auto scaling group or the zone of a managed
+	// instance group
+	Location string
+	// The number of nodes the cloud maintains in the node group
+	TargetSize int
+	// The minimum number of nodes of the node group kept by its autoscaler, if any
+	MinSize int
+	// The maximum number of nodes of the node group, 0 if unbounded
+	MaxSize int
+	// Whether an autoscaler of the cloud owns the size of the node group, e.g. the auto scaling group is tagged for
+	// the auto-discovery of cluster-autoscaler, or a GCE autoscaler is attached to the managed instance group. The
+	// autoscaler takes the minimum and maximum sizes from the node group.
+	Autoscaled bool
+	// The name of the GCE autoscaler of the managed instance group
+	autoscaler string
+}
+
+// Provider scales the node groups of a cloud.
+type Provider interface {
+	// Name returns the name of the cloud.
+	Name() string
+	// GetNodeGroup returns the node group of the node.
+	GetNodeGroup(node *api.Node) (*NodeGroup, error)
+	// SetTargetSize sets the number of nodes the cloud maintains in the node group.
+	SetTargetSize(group *NodeGroup, size int) error
+	// SetMinSize sets the minimum number of nodes of the autoscaled node group, so that its autoscaler does not scale
+	// the node group back below it.
+	SetMinSize(group *NodeGroup, size int) error
+}
+
+// ProviderFactory creates the provider of a cloud.
+type ProviderFactory func() (Provider, error)
+
+var (
+	providersLock sync.Mutex
+	// The provider factories by the scheme of the provider ID of the nodes, e.g. aws for aws:///us-west-2a/i-0123
+	providerFactories = make(map[string]ProviderFactory)
+	// The providers created so far, by scheme
+	providers = make(map[string]Provider)
+)
+
+// RegisterProvider registers the factory of the provider of the nodes whose provider ID has the given scheme.
+func RegisterProvider(scheme string, factory ProviderFactory) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	providerFactories[scheme] = factory
+	delete(providers, scheme)
+}
+
+// ProviderForNode returns the provider of the cloud of the node, from the scheme of its provider ID.
+func ProviderForNode(node *api.Node) (Provider, error) {
+	scheme, _, found := strings.Cut(node.Spec.ProviderID, "://")
+	if !found {
+		return nil, fmt.Errorf("node %s has no cloud provider ID", node.Name)
+	}
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	if provider, found := providers[scheme]; found {
+		return provider, nil
+	}
+	factory, found := providerFactories[scheme]
+	if !found {
+		return nil, fmt.Errorf("no node group provider for the %s cloud of node %s", scheme, node.Name)
+	}
+	provider, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the %s node group provider: %v", scheme, err)
+	}
+	providers[scheme] = provider
+	return provider, nil
+}
+
+// providerIDParts splits the provider ID of the node into the parts after the scheme, e.g. [us-west-2a i-0123] for
+// aws:///us-west-2a/i-0123. The empty parts are dropped.
+func providerIDParts(node *api.Node) []string {
+	_, path, _ := strings.Cut(node.Spec.ProviderID, "://")
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
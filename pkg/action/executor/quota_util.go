@@ -3,9 +3,11 @@ package executor
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,6 +21,10 @@ import (
 
 const QuotaAnnotationKey = "kubeturbo.io/last-good-config"
 
+// QuotaUpdateTimeAnnotationKey is the annotation with the time a quota was increased for an action, so that the
+// garbage collector can revert the quotas left increased by the actions which failed to revert them.
+const QuotaUpdateTimeAnnotationKey = "kubeturbo.io/quota-update-time"
+
 type QuotaAccessor interface {
 	Get() ([]*corev1.ResourceQuota, error)
 	Evaluate(quotas []*corev1.ResourceQuota, pod *corev1.Pod, replicas int64) error
@@ -143,6 +149,7 @@ func (q *QuotaAccessorImpl) Evaluate(quotas []*corev1.ResourceQuota, pod *corev1
 				// would still fail.
 				return fmt.Errorf("Error updating resource quota annotations: %v", err)
 			}
+			newQuota.Annotations[QuotaUpdateTimeAnnotationKey] = time.Now().UTC().Format(time.RFC3339)
 			addGCLabelOnQuota(newQuota)
 			quotasToUpdate = append(quotasToUpdate, newQuota)
 		}
@@ -182,7 +189,12 @@ func (q *QuotaAccessorImpl) Revert() {
 		}
 
 		RemoveGCLabelFromQuota(revertedQuota)
-		_, err = q.client.CoreV1().ResourceQuotas(q.namespace).Update(context.TODO(), revertedQuota, metav1.UpdateOptions{})
+		// Retry as the quota is otherwise left increased until the garbage collector reverts it
+		err = commonutil.RetryDuring(DefaultExecutionRetry, 0, DefaultRetrySleepInterval, func() error {
+			_, err := q.client.CoreV1().ResourceQuotas(q.namespace).Update(context.TODO(), revertedQuota,
+				metav1.UpdateOptions{})
+			return err
+		})
 		if err != nil {
 			glog.Warningf("Error reverting the updated quota: %s/%s: %v", quota.Namespace, quota.Name, err)
 			continue
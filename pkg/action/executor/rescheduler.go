@@ -2,6 +2,7 @@ package executor
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 
@@ -9,6 +10,7 @@ import (
 	api "k8s.io/api/core/v1"
 
 	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
 
@@ -19,6 +21,12 @@ type ReScheduler struct {
 	updateQuotaToAllowMoves bool
 	lockMap                 *util.ExpirationMap
 	readinessRetryThreshold int
+	// How the pods of the ReplicaSets are moved, by cloning the pod if empty, and how long to wait for the new pod
+	// to be registered in the endpoints of the pod
+	moveStrategy     string
+	endpointsTimeout time.Duration
+	// Who places the cloned pods of the moves, the Turbo server if empty
+	movePlacement string
 }
 
 func NewReScheduler(ae TurboK8sActionExecutor, sccAllowedSet map[string]struct{},
@@ -33,6 +41,19 @@ func NewReScheduler(ae TurboK8sActionExecutor, sccAllowedSet map[string]struct{}
 	}
 }
 
+func (r *ReScheduler) WithMoveStrategy(moveStrategy string, endpointsTimeout time.Duration) *ReScheduler {
+	r.moveStrategy = moveStrategy
+	r.endpointsTimeout = endpointsTimeout
+	return r
+}
+
+// WithMovePlacement sets who places the cloned pods of the moves: the Turbo server, on the destination of the action,
+// or the scheduler of the pod, with the destination as the preferred node.
+func (r *ReScheduler) WithMovePlacement(movePlacement string) *ReScheduler {
+	r.movePlacement = movePlacement
+	return r
+}
+
 // Execute executes the move action. The error message will be shown in UI.
 func (r *ReScheduler) Execute(input *TurboActionExecutorInput) (*TurboActionExecutorOutput, error) {
 	actionItem := input.ActionItems[0]
@@ -46,17 +67,25 @@ func (r *ReScheduler) Execute(input *TurboActionExecutorInput) (*TurboActionExec
 	}
 
 	//2. move pod to the node and check move status
+	reportStep(input.Progress, progressStarted, "Moving pod %s/%s to node %s", pod.Namespace, pod.Name, node.Name)
 	npod, err := r.reSchedule(pod, node)
 	if err != nil {
 		glog.Errorf("Failed to execute pod move: %v.", err)
 		return &TurboActionExecutorOutput{}, err
 	}
 
-	return &TurboActionExecutorOutput{
+	output := &TurboActionExecutorOutput{
 		Succeeded: true,
 		OldPod:    pod,
 		NewPod:    npod,
-	}, nil
+	}
+	//3. report the node picked by the scheduler instead of the destination, if any
+	if npod != nil && npod.Spec.NodeName != node.Name {
+		output.Description = fmt.Sprintf("pod %s/%s was placed on node %s by the scheduler instead of node %s",
+			npod.Namespace, npod.Name, npod.Spec.NodeName, node.Name)
+		glog.V(2).Infof("Move of pod %s/%s: %s.", pod.Namespace, pod.Name, output.Description)
+	}
+	return output, nil
 }
 
 // get k8s.node of the new hosting node
@@ -152,8 +181,8 @@ func (r *ReScheduler) preActionCheck(pod *api.Pod, node *api.Node) error {
 		if cond.Type == api.NodeReady {
 			// If the destination node is NOT in a Ready state, return an error to fail the action
 			if cond.Status != api.ConditionTrue {
-				return fmt.Errorf("Move action: pod[%v]'s new host (%v) is NotReady: %v",
-					fullName, node.Name, cond.Message)
+				return util.NewActionRefusalError(util.ReasonDestinationNotReady,
+					"Move action: pod[%v]'s new host (%v) is NotReady: %v", fullName, node.Name, cond.Message)
 			}
 		} else if cond.Status == api.ConditionTrue {
 			glog.Warningf("Move action: pod[%v]'s new host(%v) in bad condition: %v", fullName, node.Name, cond.Message)
@@ -173,7 +202,13 @@ func (r *ReScheduler) reSchedule(pod *api.Pod, node *api.Node) (*api.Pod, error)
 	fullName := util.BuildIdentifier(pod.Namespace, pod.Name)
 	// if the pod is already on the target node, then simply return success.
 	if pod.Spec.NodeName == nodeName {
-		return nil, fmt.Errorf("pod [%v] is already on host [%v]", fullName, nodeName)
+		return nil, util.NewActionRefusalError(util.ReasonPodAlreadyOnHost,
+			"pod [%v] is already on host [%v]", fullName, nodeName)
+	}
+
+	// The mirror pods of the static pods are only copies of the pods run by the kubelets, which cannot be moved
+	if podutil.IsMirrorPod(pod) {
+		return nil, util.NewActionRefusalError(util.ReasonStaticPod, "pod [%v] is a static pod", fullName)
 	}
 
 	ownerInfo, err := podutil.GetPodParentInfo(pod)
@@ -182,11 +217,41 @@ func (r *ReScheduler) reSchedule(pod *api.Pod, node *api.Node) (*api.Pod, error)
 	}
 
 	if !util.SupportedParent(ownerInfo, false) {
-		return nil, fmt.Errorf("the object kind [%v] of [%s] is not supported", ownerInfo.Kind, ownerInfo.Name)
+		return nil, util.NewActionRefusalError(util.ReasonUnsupportedOwner,
+			"the object kind [%v] of [%s] is not supported", ownerInfo.Kind, ownerInfo.Name)
+	}
+	//2. check that the destination runs the operating system of the pod, even with the scheduler placement
+	if err := checkNodeOS(r.clusterScraper.Clientset, pod, node); err != nil {
+		return nil, err
+	}
+	//3. check that the volumes of the pod can be attached to the destination
+	if !r.failVolumePodMoves && isPodUsingVolume(pod) {
+		pvs, err := getPodPersistentVolumes(r.clusterScraper.Clientset, pod)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkVolumesAttachable(pod, node, pvs); err != nil {
+			return nil, err
+		}
+	}
+	//4. check that the pod can be scheduled on the destination, unless the scheduler places the cloned pod
+	scaleUp := r.moveStrategy == PodMoveStrategyScaleUp && ownerInfo.Kind == commonutil.KindReplicaSet &&
+		!isPodUsingVolume(pod)
+	schedulerPlacement := r.movePlacement == MovePlacementScheduler && !scaleUp
+	if err := checkSchedulingConstraints(r.clusterScraper.Clientset, pod, node); err != nil {
+		if !schedulerPlacement {
+			return nil, err
+		}
+		glog.V(2).Infof("Leaving the placement of pod %s to the scheduler: %v", fullName, err)
+	}
+	//5. move, evicting the original pod within its disruption budgets
+	// The pods using volumes are cloned as the volumes may not be attached to a new pod while the pod runs
+	if scaleUp {
+		return scaleUpMovePod(r.clusterScraper, pod, nodeName, ownerInfo.Kind, r.readinessRetryThreshold,
+			r.endpointsTimeout, r.updateQuotaToAllowMoves, r.lockMap)
 	}
-	//2. move
-	return movePod(r.clusterScraper, pod, nodeName, ownerInfo.Kind,
-		ownerInfo.Name, r.readinessRetryThreshold, r.failVolumePodMoves, r.updateQuotaToAllowMoves, r.lockMap)
+	return movePod(r.clusterScraper, pod, nodeName, ownerInfo.Kind, ownerInfo.Name, r.readinessRetryThreshold,
+		r.endpointsTimeout, schedulerPlacement, r.failVolumePodMoves, r.updateQuotaToAllowMoves, r.lockMap)
 }
 
 func getVMIps(entity *proto.EntityDTO) []string {
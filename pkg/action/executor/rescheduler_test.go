@@ -0,0 +1,301 @@
+package executor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+func newMovePod(nodeName string, owner *metav1.OwnerReference) *api.Pod {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-1",
+			Namespace: "ns",
+		},
+		Spec: api.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+	if owner != nil {
+		pod.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return pod
+}
+
+func newMoveNode(name string, ready api.ConditionStatus) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: api.NodeStatus{
+			Conditions: []api.NodeCondition{{Type: api.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func assertRefusalReason(t *testing.T, expected util.RefusalReason, err error) {
+	reason, refused := util.GetRefusalReason(err)
+	assert.True(t, refused, "expected refusal error, got %v", err)
+	assert.Equal(t, expected, reason)
+}
+
+func TestReSchedulerPodAlreadyOnHost(t *testing.T) {
+	_, err := (&ReScheduler{}).reSchedule(newMovePod("node-1", nil), newMoveNode("node-1", api.ConditionTrue))
+	assertRefusalReason(t, util.ReasonPodAlreadyOnHost, err)
+}
+
+func TestReSchedulerUnsupportedOwner(t *testing.T) {
+	isController := true
+	owner := &metav1.OwnerReference{Kind: "StatefulSet", Name: "sts", UID: "sts-uid", Controller: &isController}
+	_, err := (&ReScheduler{}).reSchedule(newMovePod("node-1", owner), newMoveNode("node-2", api.ConditionTrue))
+	assertRefusalReason(t, util.ReasonUnsupportedOwner, err)
+}
+
+func TestReSchedulerStaticPod(t *testing.T) {
+	pod := newMovePod("node-1", nil)
+	pod.Annotations = map[string]string{api.MirrorPodAnnotationKey: "hash"}
+	_, err := (&ReScheduler{}).reSchedule(pod, newMoveNode("node-2", api.ConditionTrue))
+	assertRefusalReason(t, util.ReasonStaticPod, err)
+
+	isController := true
+	pod = newMovePod("node-1", &metav1.OwnerReference{Kind: "Node", Name: "node-1", UID: "node-uid",
+		Controller: &isController})
+	_, err = (&ReScheduler{}).reSchedule(pod, newMoveNode("node-2", api.ConditionTrue))
+	assertRefusalReason(t, util.ReasonStaticPod, err)
+}
+
+func TestReSchedulerDestinationNotReady(t *testing.T) {
+	r := &ReScheduler{}
+	err := r.preActionCheck(newMovePod("node-1", nil), newMoveNode("node-2", api.ConditionFalse))
+	assertRefusalReason(t, util.ReasonDestinationNotReady, err)
+	assert.Nil(t, r.preActionCheck(newMovePod("node-1", nil), newMoveNode("node-2", api.ConditionTrue)))
+}
+
+func TestMovePodWithVolumeRefused(t *testing.T) {
+	pod := newMovePod("node-1", nil)
+	pod.Spec.Volumes = []api.Volume{{
+		Name: "data",
+		VolumeSource: api.VolumeSource{
+			PersistentVolumeClaim: &api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+		},
+	}}
+	_, err := movePod(nil, pod, "node-2", "ReplicaSet", "rs", 1, 0, false, true, false, nil)
+	assertRefusalReason(t, util.ReasonVolumePodMove, err)
+}
+
+func newVolume(name, zone, nodeAffinityZone string, accessModes ...api.PersistentVolumeAccessMode) *api.PersistentVolume {
+	pv := &api.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Spec:       api.PersistentVolumeSpec{AccessModes: accessModes},
+	}
+	if zone != "" {
+		pv.Labels[api.LabelTopologyZone] = zone
+	}
+	if nodeAffinityZone != "" {
+		pv.Spec.NodeAffinity = &api.VolumeNodeAffinity{
+			Required: &api.NodeSelector{
+				NodeSelectorTerms: []api.NodeSelectorTerm{{
+					MatchExpressions: []api.NodeSelectorRequirement{{
+						Key:      api.LabelTopologyZone,
+						Operator: api.NodeSelectorOpIn,
+						Values:   []string{nodeAffinityZone},
+					}},
+				}},
+			},
+		}
+	}
+	return pv
+}
+
+func TestCheckVolumesAttachable(t *testing.T) {
+	pod := newMovePod("node-1", nil)
+	node := newMoveNode("node-2", api.ConditionTrue)
+	node.Labels = map[string]string{api.LabelTopologyZone: "us-east-1a"}
+
+	for name, pv := range map[string]*api.PersistentVolume{
+		"no constraint":        newVolume("pv", "", "", api.ReadWriteOnce),
+		"same zone":            newVolume("pv", "us-east-1a", "", api.ReadWriteOnce),
+		"multi-zone":           newVolume("pv", "us-east-1b__us-east-1a", "", api.ReadWriteOnce),
+		"node affinity":        newVolume("pv", "", "us-east-1a", api.ReadWriteOncePod),
+		"read write many":      newVolume("pv", "us-east-1b", "us-east-1b", api.ReadWriteMany),
+		"read write once many": newVolume("pv", "us-east-1b", "", api.ReadWriteOnce, api.ReadOnlyMany),
+	} {
+		assert.Nil(t, checkVolumesAttachable(pod, node, []*api.PersistentVolume{pv}), name)
+	}
+	for _, pv := range []*api.PersistentVolume{
+		newVolume("pv", "us-east-1b", "", api.ReadWriteOnce),
+		newVolume("pv", "", "us-east-1b", api.ReadWriteOnce),
+	} {
+		assertRefusalReason(t, util.ReasonVolumeNotAttachable,
+			checkVolumesAttachable(pod, node, []*api.PersistentVolume{newVolume("pv-ok", "", "", api.ReadWriteOnce), pv}))
+	}
+}
+
+func newDisruptionBudget(name string, matchLabels map[string]string, disruptionsAllowed int32) *policyv1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt(2)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     &metav1.LabelSelector{MatchLabels: matchLabels},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+			CurrentHealthy:     2,
+			ExpectedPods:       2,
+		},
+	}
+}
+
+func TestCheckDisruptionBudgets(t *testing.T) {
+	pod := newMovePod("node-1", nil)
+	assert.Nil(t, checkDisruptionBudgets(pod, nil))
+	assert.Nil(t, checkDisruptionBudgets(pod, []*policyv1.PodDisruptionBudget{
+		newDisruptionBudget("pdb-1", nil, 1)}))
+
+	err := checkDisruptionBudgets(pod, []*policyv1.PodDisruptionBudget{
+		newDisruptionBudget("pdb-1", nil, 1), newDisruptionBudget("pdb-2", nil, 0)})
+	assertRefusalReason(t, util.ReasonDisruptionBudget, err)
+	assert.Contains(t, err.Error(), "pdb-2 (minAvailable 2, 2 of 2 pods healthy)")
+	assert.NotContains(t, err.Error(), "pdb-1")
+}
+
+func TestEvictMovedPod(t *testing.T) {
+	pdbs := []policyv1.PodDisruptionBudget{
+		*newDisruptionBudget("blocking", map[string]string{"app": "foo"}, 0),
+		*newDisruptionBudget("other-app", map[string]string{"app": "bar"}, 0),
+		{ObjectMeta: metav1.ObjectMeta{Name: "no-selector", Namespace: "ns"}},
+	}
+	// The status of the evictions, as answered by the API server
+	evictionStatus := http.StatusTooManyRequests
+	var evictions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/eviction") && r.Method == http.MethodPost:
+			evictions = append(evictions, r.URL.Path)
+			status := metav1.Status{Status: metav1.StatusSuccess, Code: int32(evictionStatus)}
+			if evictionStatus >= http.StatusBadRequest {
+				status.Status = metav1.StatusFailure
+				status.Reason = metav1.StatusReasonUnknown
+			}
+			if evictionStatus == http.StatusTooManyRequests {
+				status.Reason = metav1.StatusReasonTooManyRequests
+			}
+			w.WriteHeader(evictionStatus)
+			json.NewEncoder(w).Encode(status)
+		case r.URL.Path == "/apis/policy/v1/namespaces/ns/poddisruptionbudgets":
+			json.NewEncoder(w).Encode(policyv1.PodDisruptionBudgetList{Items: pdbs})
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.Nil(t, err)
+
+	// The eviction is refused by the budget, which is reported
+	pod := newMovePod("node-1", nil)
+	pod.Labels = map[string]string{"app": "foo"}
+	err = evictMovedPod(client, pod, 10*time.Millisecond)
+	assertRefusalReason(t, util.ReasonDisruptionBudget, err)
+	assert.Contains(t, err.Error(), "blocking")
+	assert.NotContains(t, err.Error(), "other-app")
+	assert.NotEmpty(t, evictions)
+
+	// The eviction is refused, and the budgets cannot be listed
+	pod.Namespace = "forbidden"
+	err = evictMovedPod(client, pod, 10*time.Millisecond)
+	assertRefusalReason(t, util.ReasonDisruptionBudget, err)
+
+	evictionStatus = http.StatusCreated
+	assert.Nil(t, evictMovedPod(client, pod, 10*time.Millisecond))
+
+	// The pod is already gone
+	evictionStatus = http.StatusNotFound
+	assert.Nil(t, evictMovedPod(client, pod, 10*time.Millisecond))
+
+	evictionStatus = http.StatusInternalServerError
+	err = evictMovedPod(client, pod, 10*time.Millisecond)
+	assert.NotNil(t, err)
+	_, refused := util.GetRefusalReason(err)
+	assert.False(t, refused)
+}
+
+func TestDelegatePlacement(t *testing.T) {
+	spec := &api.PodSpec{NodeName: "node-2"}
+	delegatePlacement(spec, "node-2", "node-1")
+	assert.Empty(t, spec.NodeName)
+	nodeAffinity := spec.Affinity.NodeAffinity
+	assert.Equal(t, []api.PreferredSchedulingTerm{{
+		Weight: preferredNodeWeight,
+		Preference: api.NodeSelectorTerm{
+			MatchFields: []api.NodeSelectorRequirement{nodeNameRequirement(api.NodeSelectorOpIn, "node-2")},
+		},
+	}}, nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+	assert.Equal(t, []api.NodeSelectorTerm{{
+		MatchFields: []api.NodeSelectorRequirement{nodeNameRequirement(api.NodeSelectorOpNotIn, "node-1")},
+	}}, nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+
+	// The node of the moved pod is excluded from each of the required terms of the pod
+	zoneTerm := api.NodeSelectorTerm{MatchExpressions: []api.NodeSelectorRequirement{{
+		Key: "zone", Operator: api.NodeSelectorOpIn, Values: []string{"zone-a"},
+	}}}
+	spec = &api.PodSpec{Affinity: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &api.NodeSelector{
+			NodeSelectorTerms: []api.NodeSelectorTerm{zoneTerm, zoneTerm},
+		},
+	}}}
+	delegatePlacement(spec, "node-2", "node-1")
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		assert.Len(t, term.MatchExpressions, 1)
+		assert.Equal(t, []api.NodeSelectorRequirement{nodeNameRequirement(api.NodeSelectorOpNotIn, "node-1")},
+			term.MatchFields)
+	}
+}
+
+func TestCheckNodeOS(t *testing.T) {
+	windowsNode := newMoveNode("node-2", api.ConditionTrue)
+	windowsNode.Labels = map[string]string{"kubernetes.io/os": "windows"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/nodes/node-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		node := newMoveNode("node-1", api.ConditionTrue)
+		node.Labels = map[string]string{"beta.kubernetes.io/os": "linux"}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(node)
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.Nil(t, err)
+
+	// The operating system of the pod is the one of its spec, of its node selector, or of its node
+	pod := newMovePod("node-1", nil)
+	pod.Spec.OS = &api.PodOS{Name: api.Windows}
+	assert.Nil(t, checkNodeOS(nil, pod, windowsNode))
+	pod.Spec.OS = nil
+	pod.Spec.NodeSelector = map[string]string{"kubernetes.io/os": "linux"}
+	assertRefusalReason(t, util.ReasonOSMismatch, checkNodeOS(nil, pod, windowsNode))
+	pod.Spec.NodeSelector = nil
+	err = checkNodeOS(client, pod, windowsNode)
+	assertRefusalReason(t, util.ReasonOSMismatch, err)
+	assert.Contains(t, err.Error(), "linux")
+
+	// The move is not refused if the operating system of the pod or of the destination is unknown
+	pod.Spec.NodeName = "node-3"
+	assert.Nil(t, checkNodeOS(client, pod, windowsNode))
+	assert.Nil(t, checkNodeOS(nil, pod, newMoveNode("node-2", api.ConditionTrue)))
+}
@@ -49,6 +49,7 @@ type ContainerResizer struct {
 	enableNonDisruptiveSupport bool
 	sccAllowedSet              map[string]struct{}
 	spec                       *containerResizeSpec
+	resizeLimits               ResizeLimits
 }
 
 func NewContainerResizeSpec(idx int) *containerResizeSpec {
@@ -68,6 +69,11 @@ func NewContainerResizer(ae TurboK8sActionExecutor, kubeletClient *kubeclient.Ku
 	}
 }
 
+func (r *ContainerResizer) WithResizeLimits(resizeLimits ResizeLimits) *ContainerResizer {
+	r.resizeLimits = resizeLimits
+	return r
+}
+
 func (r *ContainerResizer) buildResourceList(cType proto.CommodityDTO_CommodityType,
 	amount float64, result k8sapi.ResourceList) error {
 	switch cType {
@@ -218,6 +224,23 @@ func (r *ContainerResizer) Execute(input *TurboActionExecutorInput) (*TurboActio
 		return &TurboActionExecutorOutput{}, err
 	}
 
+	// check the new resources against the resize limits, the namespace limit range and the node allocatable
+	if err := checkResizeLimits(r.resizeLimits, &pod.Spec, []*containerResizeSpec{spec}); err != nil {
+		glog.Errorf("Failed to execute resize action on pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return &TurboActionExecutorOutput{}, err
+	}
+	desiredPod := buildDesiredPod4QuotaEvaluation(pod.Namespace, []*containerResizeSpec{spec}, *pod.Spec.DeepCopy())
+	if err := CheckLimitrangeViolationOnPod(r.clusterScraper.Clientset, pod.Namespace, desiredPod); err != nil {
+		glog.Errorf("Failed to execute resize action on pod %s/%s due to limitrange violation: %v",
+			pod.Namespace, pod.Name, err)
+		return &TurboActionExecutorOutput{}, util.NewActionRefusalError(util.ReasonLimitRangeViolation,
+			"limitrange violation:%v", err)
+	}
+	if err := checkNodeAllocatable(r.clusterScraper.Clientset, pod.Spec.NodeName, desiredPod); err != nil {
+		glog.Errorf("Failed to execute resize action on pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return &TurboActionExecutorOutput{}, err
+	}
+
 	// execute the Action
 	npod, err := resizeContainer(
 		r.clusterScraper,
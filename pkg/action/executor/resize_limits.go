@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	k8sapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kclient "k8s.io/client-go/kubernetes"
+
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+// ResizeLimits are the guardrails of the container resize actions. A resize is refused when a new request or limit of
+// a container falls below the floor of its resource, or changes by more than the maximum percentage of its current
+// value.
+type ResizeLimits struct {
+	// The minimum CPU and memory of the requests and the limits, no floor if zero
+	MinCPU    resource.Quantity
+	MinMemory resource.Quantity
+	// The maximum change of a request or a limit in percent of its current value, no cap if not positive
+	MaxChangePercent int
+}
+
+// ParseResizeLimits parses the resize limits given on the command line, e.g. "10m" for the minimum CPU and "16Mi" for
+// the minimum memory. An empty quantity is no floor.
+func ParseResizeLimits(minCPU, minMemory string, maxChangePercent int) (ResizeLimits, error) {
+	limits := ResizeLimits{MaxChangePercent: maxChangePercent}
+	for _, floor := range []struct {
+		value    string
+		name     string
+		quantity *resource.Quantity
+	}{{minCPU, "CPU", &limits.MinCPU}, {minMemory, "memory", &limits.MinMemory}} {
+		if floor.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(floor.value)
+		if err != nil {
+			return ResizeLimits{}, fmt.Errorf("invalid minimum %s %q: %v", floor.name, floor.value, err)
+		}
+		if quantity.Sign() < 0 {
+			return ResizeLimits{}, fmt.Errorf("invalid minimum %s %q: must not be negative", floor.name, floor.value)
+		}
+		*floor.quantity = quantity
+	}
+	if maxChangePercent < 0 {
+		return ResizeLimits{}, fmt.Errorf("invalid maximum change %d%%: must not be negative", maxChangePercent)
+	}
+	return limits, nil
+}
+
+func (l ResizeLimits) floor(name k8sapi.ResourceName) resource.Quantity {
+	switch name {
+	case k8sapi.ResourceCPU:
+		return l.MinCPU
+	case k8sapi.ResourceMemory:
+		return l.MinMemory
+	}
+	return resource.Quantity{}
+}
+
+// checkResizeLimits returns a refusal error if the new resources of the given resize specs violate the resize limits,
+// against the current resources of the containers of the pod spec.
+func checkResizeLimits(limits ResizeLimits, podSpec *k8sapi.PodSpec, specs []*containerResizeSpec) error {
+	for _, spec := range specs {
+		if spec == nil || spec.Index < 0 || spec.Index >= len(podSpec.Containers) {
+			continue
+		}
+		container := podSpec.Containers[spec.Index]
+		if err := checkResourceListLimits(limits, container.Name, "limit", container.Resources.Limits,
+			spec.NewCapacity); err != nil {
+			return err
+		}
+		if err := checkResourceListLimits(limits, container.Name, "request", container.Resources.Requests,
+			spec.NewRequest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkResourceListLimits(limits ResizeLimits, containerName, kind string,
+	current, desired k8sapi.ResourceList) error {
+	for name, quantity := range desired {
+		// A zero request stands for the request left unspecified
+		if quantity.IsZero() {
+			continue
+		}
+		if floor := limits.floor(name); !floor.IsZero() && quantity.Cmp(floor) < 0 {
+			return actionutil.NewActionRefusalError(actionutil.ReasonResizeLimitViolation,
+				"the new %s %s %s of container %s is below the minimum %s", name, kind, quantity.String(),
+				containerName, floor.String())
+		}
+		currentQuantity, found := current[name]
+		if limits.MaxChangePercent <= 0 || !found || currentQuantity.IsZero() {
+			continue
+		}
+		change := math.Abs(float64(quantity.MilliValue()-currentQuantity.MilliValue())) /
+			float64(currentQuantity.MilliValue()) * 100
+		if change > float64(limits.MaxChangePercent) {
+			return actionutil.NewActionRefusalError(actionutil.ReasonResizeLimitViolation,
+				"the %s %s of container %s would change by %.0f%% from %s to %s, more than the maximum %d%%",
+				name, kind, containerName, change, currentQuantity.String(), quantity.String(), limits.MaxChangePercent)
+		}
+	}
+	return nil
+}
+
+// checkNodeAllocatable returns a refusal error if the requests of the desired pod, or the limits of one of its
+// containers, exceed the allocatable resources of the given node, or of the largest node if no node is given, as the
+// resized pod could then never run.
+func checkNodeAllocatable(client kclient.Interface, nodeName string, pod *k8sapi.Pod) error {
+	var nodes []k8sapi.Node
+	nodeDesc := "the largest node"
+	if nodeName != "" {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get node %s: %v", nodeName, err)
+		}
+		nodes, nodeDesc = []k8sapi.Node{*node}, "node "+nodeName
+	} else {
+		nodeList, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %v", err)
+		}
+		nodes = nodeList.Items
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	for _, name := range []k8sapi.ResourceName{k8sapi.ResourceCPU, k8sapi.ResourceMemory} {
+		var allocatable resource.Quantity
+		for _, node := range nodes {
+			if quantity, found := node.Status.Allocatable[name]; found && quantity.Cmp(allocatable) > 0 {
+				allocatable = quantity
+			}
+		}
+		if allocatable.IsZero() {
+			continue
+		}
+		var requests resource.Quantity
+		for _, container := range pod.Spec.Containers {
+			if request, found := container.Resources.Requests[name]; found {
+				requests.Add(request)
+			}
+			if limit, found := container.Resources.Limits[name]; found && limit.Cmp(allocatable) > 0 {
+				return actionutil.NewActionRefusalError(actionutil.ReasonNodeAllocatableExceeded,
+					"the %s limit %s of container %s exceeds the allocatable %s of %s", name, limit.String(),
+					container.Name, allocatable.String(), nodeDesc)
+			}
+		}
+		if requests.Cmp(allocatable) > 0 {
+			return actionutil.NewActionRefusalError(actionutil.ReasonNodeAllocatableExceeded,
+				"the %s requests %s of the pod exceed the allocatable %s of %s", name, requests.String(),
+				allocatable.String(), nodeDesc)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8sapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	actionutil "github.com/turbonomic/kubeturbo/pkg/action/util"
+)
+
+func TestParseResizeLimits(t *testing.T) {
+	limits, err := ParseResizeLimits("10m", "16Mi", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), limits.MinCPU.MilliValue())
+	assert.Equal(t, int64(16*1024*1024), limits.MinMemory.Value())
+	assert.Equal(t, 50, limits.MaxChangePercent)
+
+	limits, err = ParseResizeLimits("", "", 0)
+	assert.NoError(t, err)
+	assert.True(t, limits.MinCPU.IsZero())
+
+	for _, invalid := range [][]string{{"ten", ""}, {"", "-1Mi"}} {
+		_, err = ParseResizeLimits(invalid[0], invalid[1], 0)
+		assert.Error(t, err, invalid)
+	}
+	_, err = ParseResizeLimits("", "", -1)
+	assert.Error(t, err)
+}
+
+func TestCheckResizeLimits(t *testing.T) {
+	podSpec := &k8sapi.PodSpec{Containers: []k8sapi.Container{{
+		Name: "app",
+		Resources: k8sapi.ResourceRequirements{
+			Limits: k8sapi.ResourceList{
+				k8sapi.ResourceCPU:    resource.MustParse("200m"),
+				k8sapi.ResourceMemory: resource.MustParse("256Mi"),
+			},
+		},
+	}}}
+	newSpec := func(cpuLimit, memoryLimit, cpuRequest string) *containerResizeSpec {
+		spec := NewContainerResizeSpec(0)
+		if cpuLimit != "" {
+			spec.NewCapacity[k8sapi.ResourceCPU] = resource.MustParse(cpuLimit)
+		}
+		if memoryLimit != "" {
+			spec.NewCapacity[k8sapi.ResourceMemory] = resource.MustParse(memoryLimit)
+		}
+		if cpuRequest != "" {
+			spec.NewRequest[k8sapi.ResourceCPU] = resource.MustParse(cpuRequest)
+		}
+		return spec
+	}
+	limits, _ := ParseResizeLimits("100m", "64Mi", 50)
+
+	// Within the limits, with the unspecified request set to zero
+	assert.NoError(t, checkResizeLimits(limits, podSpec, []*containerResizeSpec{newSpec("300m", "128Mi", "0")}))
+	// No limit
+	assert.NoError(t, checkResizeLimits(ResizeLimits{}, podSpec, []*containerResizeSpec{newSpec("1m", "1Mi", "")}))
+	// A new request has no current value to change from
+	assert.NoError(t, checkResizeLimits(limits, podSpec, []*containerResizeSpec{newSpec("", "", "2")}))
+
+	for _, spec := range []*containerResizeSpec{
+		// Below the floors
+		newSpec("", "", "50m"),
+		newSpec("", "32Mi", ""),
+		// More than the maximum change, up and down
+		newSpec("301m", "", ""),
+		newSpec("", "100Mi", ""),
+	} {
+		assertRefusalReason(t, actionutil.ReasonResizeLimitViolation,
+			checkResizeLimits(limits, podSpec, []*containerResizeSpec{spec}))
+	}
+}
+
+func TestCheckNodeAllocatable(t *testing.T) {
+	newNode := func(name, cpu, memory string) *k8sapi.Node {
+		return &k8sapi.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: k8sapi.NodeStatus{Allocatable: k8sapi.ResourceList{
+				k8sapi.ResourceCPU:    resource.MustParse(cpu),
+				k8sapi.ResourceMemory: resource.MustParse(memory),
+			}},
+		}
+	}
+	small, large := newNode("small", "2", "4Gi"), newNode("large", "8", "32Gi")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			json.NewEncoder(w).Encode(&k8sapi.NodeList{Items: []k8sapi.Node{*small, *large}})
+		case "/api/v1/nodes/small":
+			json.NewEncoder(w).Encode(small)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NoError(t, err)
+	newPod := func(cpuRequest, memoryLimit string) *k8sapi.Pod {
+		container := k8sapi.Container{Name: "app", Resources: k8sapi.ResourceRequirements{
+			Requests: k8sapi.ResourceList{k8sapi.ResourceCPU: resource.MustParse(cpuRequest)},
+			Limits:   k8sapi.ResourceList{k8sapi.ResourceMemory: resource.MustParse(memoryLimit)},
+		}}
+		return &k8sapi.Pod{Spec: k8sapi.PodSpec{Containers: []k8sapi.Container{container, container}}}
+	}
+
+	// The pod fits in the largest node only
+	assert.NoError(t, checkNodeAllocatable(client, "", newPod("3", "16Gi")))
+	assertRefusalReason(t, actionutil.ReasonNodeAllocatableExceeded, checkNodeAllocatable(client, "small", newPod("3", "1Gi")))
+	assertRefusalReason(t, actionutil.ReasonNodeAllocatableExceeded, checkNodeAllocatable(client, "small", newPod("500m", "8Gi")))
+	assertRefusalReason(t, actionutil.ReasonNodeAllocatableExceeded, checkNodeAllocatable(client, "", newPod("5", "1Gi")))
+
+	assert.Error(t, checkNodeAllocatable(client, "missing", newPod("1", "1Gi")))
+}
@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+const (
+	defaultRolloutPollInterval = 5 * time.Second
+
+	rollingUpdateStrategy    = "RollingUpdate"
+	progressDeadlineReason   = "ProgressDeadlineExceeded"
+	progressingConditionType = "Progressing"
+)
+
+// waitForRollout waits until the rollout of the pods of the given workload controller completes after its pod
+// template has been updated, and reports the progress of the rollout. Only the rollouts of the deployments, the
+// stateful sets and the daemon sets are tracked; the other kinds do not roll out the pods on their own.
+func waitForRollout(client dynamic.Interface, controllerGVs util.ControllerGroupVersions, kind, namespace,
+	name string, timeout time.Duration, progress ProgressReporter) error {
+	if kind != util.KindDeployment && kind != util.KindStatefulSet && kind != util.KindDaemonSet {
+		glog.V(3).Infof("Skip waiting for the rollout of %s %s/%s.", kind, namespace, name)
+		return nil
+	}
+	res, err := GetSupportedResUsingKind(controllerGVs, kind, namespace, name)
+	if err != nil {
+		return err
+	}
+	var lastStatus string
+	err = wait.PollImmediate(defaultRolloutPollInterval, timeout, func() (bool, error) {
+		obj, err := client.Resource(res).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			glog.Warningf("Failed to get %s %s/%s while waiting for its rollout: %v", kind, namespace, name, err)
+			return false, nil
+		}
+		done, status, err := rolloutStatus(obj)
+		if err != nil {
+			return false, err
+		}
+		if status != lastStatus {
+			lastStatus = status
+			glog.V(3).Infof("Rollout of %s %s/%s: %s", kind, namespace, name, status)
+			updated, total := rolloutCounts(obj)
+			reportStep(progress, stepPercent(progressHalfway, progressFinishing, updated, total),
+				"Waiting for the rollout of %s %s/%s: %s", kind, namespace, name, status)
+		}
+		return done, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("the rollout of %s %s/%s did not complete within %v: %s",
+			kind, namespace, name, timeout, lastStatus)
+	}
+	if err != nil {
+		return fmt.Errorf("the rollout of %s %s/%s failed: %v", kind, namespace, name, err)
+	}
+	return nil
+}
+
+// rolloutCounts returns the number of the pods of the given deployment, stateful set or daemon set updated by its
+// rollout, and the number of the pods to update.
+func rolloutCounts(obj *unstructured.Unstructured) (int64, int64) {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	switch obj.GetKind() {
+	case util.KindDeployment:
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		return updated, replicas
+	case util.KindStatefulSet:
+		partition, _, _ := unstructured.NestedInt64(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		return updated, replicas - partition
+	case util.KindDaemonSet:
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedNumberScheduled")
+		return updated, desired
+	default:
+		return 0, 0
+	}
+}
+
+// rolloutStatus returns whether the rollout of the pods of the given deployment, stateful set or daemon set is
+// complete, with a description of its progress, in the same way as kubectl rollout status. An error is returned
+// if the rollout has failed, i.e., the deployment has exceeded its progress deadline.
+func rolloutStatus(obj *unstructured.Unstructured) (bool, string, error) {
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observedGeneration < obj.GetGeneration() {
+		return false, "waiting for the update to be observed", nil
+	}
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	switch obj.GetKind() {
+	case util.KindDeployment:
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if ok && condition["type"] == progressingConditionType && condition["reason"] == progressDeadlineReason {
+				return false, "", fmt.Errorf("deployment exceeded its progress deadline")
+			}
+		}
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		current, _, _ := unstructured.NestedInt64(obj.Object, "status", "replicas")
+		available, _, _ := unstructured.NestedInt64(obj.Object, "status", "availableReplicas")
+		if updated < replicas {
+			return false, fmt.Sprintf("%d of %d new replicas have been updated", updated, replicas), nil
+		}
+		if current > updated {
+			return false, fmt.Sprintf("%d old replicas are pending termination", current-updated), nil
+		}
+		if available < updated {
+			return false, fmt.Sprintf("%d of %d updated replicas are available", available, updated), nil
+		}
+		return true, fmt.Sprintf("%d of %d updated replicas are available", available, updated), nil
+	case util.KindStatefulSet:
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+		if strategy != "" && strategy != rollingUpdateStrategy {
+			return true, fmt.Sprintf("the update strategy %s does not roll out the pods", strategy), nil
+		}
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		if ready < replicas {
+			return false, fmt.Sprintf("%d of %d replicas are ready", ready, replicas), nil
+		}
+		partition, _, _ := unstructured.NestedInt64(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		if updated < replicas-partition {
+			return false, fmt.Sprintf("%d of %d replicas have been updated", updated, replicas-partition), nil
+		}
+		return true, fmt.Sprintf("%d of %d replicas have been updated", updated, replicas-partition), nil
+	case util.KindDaemonSet:
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+		if strategy != "" && strategy != rollingUpdateStrategy {
+			return true, fmt.Sprintf("the update strategy %s does not roll out the pods", strategy), nil
+		}
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedNumberScheduled")
+		available, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberAvailable")
+		if updated < desired {
+			return false, fmt.Sprintf("%d of %d updated pods have been scheduled", updated, desired), nil
+		}
+		if available < desired {
+			return false, fmt.Sprintf("%d of %d updated pods are available", available, desired), nil
+		}
+		return true, fmt.Sprintf("%d of %d updated pods are available", available, desired), nil
+	default:
+		return true, "", nil
+	}
+}
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+func newRolloutObject(kind string, generation int64, spec, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   spec,
+		"status": status,
+	}}
+	obj.SetKind(kind)
+	obj.SetName("app")
+	obj.SetNamespace("ns")
+	obj.SetGeneration(generation)
+	return obj
+}
+
+func TestRolloutStatusDeployment(t *testing.T) {
+	spec := map[string]interface{}{"replicas": int64(3)}
+
+	// The resize has not been observed by the deployment controller yet
+	done, _, err := rolloutStatus(newRolloutObject(util.KindDeployment, 2, spec,
+		map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(3),
+			"updatedReplicas": int64(3), "availableReplicas": int64(3)}))
+	assert.Nil(t, err)
+	assert.False(t, done)
+
+	done, status, err := rolloutStatus(newRolloutObject(util.KindDeployment, 2, spec,
+		map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(4),
+			"updatedReplicas": int64(1), "availableReplicas": int64(3)}))
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, "1 of 3 new replicas have been updated", status)
+
+	done, status, err = rolloutStatus(newRolloutObject(util.KindDeployment, 2, spec,
+		map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(4),
+			"updatedReplicas": int64(3), "availableReplicas": int64(3)}))
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, "1 old replicas are pending termination", status)
+
+	done, _, err = rolloutStatus(newRolloutObject(util.KindDeployment, 2, spec,
+		map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(3),
+			"updatedReplicas": int64(3), "availableReplicas": int64(3)}))
+	assert.Nil(t, err)
+	assert.True(t, done)
+
+	_, _, err = rolloutStatus(newRolloutObject(util.KindDeployment, 2, spec,
+		map[string]interface{}{"observedGeneration": int64(2), "replicas": int64(4),
+			"updatedReplicas": int64(1), "availableReplicas": int64(3),
+			"conditions": []interface{}{map[string]interface{}{
+				"type": progressingConditionType, "status": "False", "reason": progressDeadlineReason}}}))
+	assert.NotNil(t, err)
+}
+
+func TestRolloutStatusStatefulSet(t *testing.T) {
+	spec := map[string]interface{}{"replicas": int64(3)}
+	done, status, err := rolloutStatus(newRolloutObject(util.KindStatefulSet, 1, spec,
+		map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(3),
+			"updatedReplicas": int64(2)}))
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, "2 of 3 replicas have been updated", status)
+
+	// Only the pods above the partition are updated
+	spec["updateStrategy"] = map[string]interface{}{
+		"type": rollingUpdateStrategy, "rollingUpdate": map[string]interface{}{"partition": int64(1)}}
+	done, _, err = rolloutStatus(newRolloutObject(util.KindStatefulSet, 1, spec,
+		map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(3),
+			"updatedReplicas": int64(2)}))
+	assert.Nil(t, err)
+	assert.True(t, done)
+
+	// The pods are not rolled out with the OnDelete strategy
+	spec["updateStrategy"] = map[string]interface{}{"type": "OnDelete"}
+	done, _, err = rolloutStatus(newRolloutObject(util.KindStatefulSet, 1, spec,
+		map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(3)}))
+	assert.Nil(t, err)
+	assert.True(t, done)
+}
+
+func TestRolloutStatusDaemonSet(t *testing.T) {
+	done, status, err := rolloutStatus(newRolloutObject(util.KindDaemonSet, 1, map[string]interface{}{},
+		map[string]interface{}{"observedGeneration": int64(1), "desiredNumberScheduled": int64(2),
+			"updatedNumberScheduled": int64(2), "numberAvailable": int64(1)}))
+	assert.Nil(t, err)
+	assert.False(t, done)
+	assert.Equal(t, "1 of 2 updated pods are available", status)
+
+	done, _, err = rolloutStatus(newRolloutObject(util.KindDaemonSet, 1, map[string]interface{}{},
+		map[string]interface{}{"observedGeneration": int64(1), "desiredNumberScheduled": int64(2),
+			"updatedNumberScheduled": int64(2), "numberAvailable": int64(2)}))
+	assert.Nil(t, err)
+	assert.True(t, done)
+}
+
+func TestRolloutCounts(t *testing.T) {
+	updated, total := rolloutCounts(newRolloutObject(util.KindDeployment, 1,
+		map[string]interface{}{"replicas": int64(4)}, map[string]interface{}{"updatedReplicas": int64(1)}))
+	assert.Equal(t, int64(1), updated)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, int32(60), stepPercent(progressHalfway, progressFinishing, updated, total))
+
+	// Only the pods above the partition are updated
+	updated, total = rolloutCounts(newRolloutObject(util.KindStatefulSet, 1,
+		map[string]interface{}{"replicas": int64(3), "updateStrategy": map[string]interface{}{
+			"rollingUpdate": map[string]interface{}{"partition": int64(1)}}},
+		map[string]interface{}{"updatedReplicas": int64(2)}))
+	assert.Equal(t, int64(2), updated)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, progressFinishing, stepPercent(progressHalfway, progressFinishing, updated, total))
+
+	updated, total = rolloutCounts(newRolloutObject(util.KindDaemonSet, 1, map[string]interface{}{},
+		map[string]interface{}{"desiredNumberScheduled": int64(2), "updatedNumberScheduled": int64(0)}))
+	assert.Equal(t, int64(0), updated)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, progressHalfway, stepPercent(progressHalfway, progressFinishing, updated, total))
+}
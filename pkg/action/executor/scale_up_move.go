@@ -0,0 +1,399 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+const (
+	// The strategies of the pod moves: by creating a clone of the pod on the destination before deleting the pod
+	// (clone), or, for the pods of the ReplicaSets, by scaling up the controller of the pod with the new pod
+	// required on the destination before scaling it back down (scale-up)
+	PodMoveStrategyClone   = "clone"
+	PodMoveStrategyScaleUp = "scale-up"
+
+	// The ReplicaSets delete their pods of the lowest cost first when scaled down
+	podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+	minPodDeletionCost        = "-2147483648"
+)
+
+// scaleUpMovePod moves the pod of a ReplicaSet, possibly of a Deployment, to node nodeName with the controllers of the
+// pod, so that the new pod is owned by the ReplicaSet from the start and serves before the pod is deleted:
+//
+//	step 1: if the ReplicaSet has a Deployment, pause the rollout
+//	step 2: require the node in the pod template of the ReplicaSet
+//	step 3: lower the deletion cost of the pod below the other pods of the ReplicaSet
+//	step 4: scale up the Deployment, or the ReplicaSet, by one
+//	step 5: wait until the new pod is scheduled on the node, then restore the pod template of the ReplicaSet so that
+//	        the pods it creates later are not required on the node
+//	step 6: wait until the new pod is ready, and registered in the endpoints of the pod unless endpointsTimeout is
+//	        not positive
+//	step 7: scale the Deployment, or the ReplicaSet, back down, which deletes the pod of the lowest cost
+//	step 8: if the ReplicaSet has a Deployment, unpause the rollout
+//
+// The moves of the pods of the same controller are serialized, since each one scales the controller from the replicas
+// it reads and rewrites the pod template of the ReplicaSet. A Deployment paused by the user is not moved, since the
+// move would unpause it.
+//
+// On failure the deletion cost of the pod is restored before scaling back down, so that the new pod is deleted.
+func scaleUpMovePod(clusterScraper *cluster.ClusterScraper, pod *api.Pod, nodeName, parentKind string,
+	retryNum int, endpointsTimeout time.Duration, updateQuotaToAllowMoves bool,
+	lockMap *util.ExpirationMap) (*api.Pod, error) {
+	podQualifiedName := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+	parent, grandParent, pClient, gPClient, gPKind, err := getPodOwnersInfo(clusterScraper, pod, parentKind)
+	if err != nil {
+		return nil, err
+	}
+	if grandParent != nil && gPKind != commonutil.KindDeployment {
+		return nil, util.NewActionRefusalError(util.ReasonUnsupportedOwner,
+			"the scale-up move of pod %s owned by %s %s is not supported", podQualifiedName, gPKind,
+			grandParent.GetName())
+	}
+	scaled, scaledClient := parent, pClient
+	if grandParent != nil {
+		scaled, scaledClient = grandParent, gPClient
+	}
+
+	lockHelper, err := lockForController(scaled, lockMap)
+	if err != nil {
+		return nil, err
+	}
+	defer lockHelper.ReleaseLock()
+	// Read the controllers again under the lock, as a former move of their pods may have changed them
+	parentName := parent.GetName()
+	if parent, err = pClient.Get(context.TODO(), parentName, metav1.GetOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %v", parentKind, parentName, err)
+	}
+	scaled = parent
+	if grandParent != nil {
+		grandParentName := grandParent.GetName()
+		if grandParent, err = gPClient.Get(context.TODO(), grandParentName, metav1.GetOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %v", gPKind, grandParentName, err)
+		}
+		scaled = grandParent
+		paused, _, err := unstructured.NestedBool(grandParent.Object, "spec", "paused")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the rollout state of %s %s: %v", gPKind, grandParent.GetName(), err)
+		}
+		if paused {
+			return nil, util.NewActionRefusalError(util.ReasonRolloutPaused,
+				"the scale-up move of pod %s is not supported while the rollout of %s %s is paused",
+				podQualifiedName, gPKind, grandParent.GetName())
+		}
+	}
+	replicas, found, err := unstructured.NestedInt64(scaled.Object, "spec", "replicas")
+	if err != nil || !found {
+		return nil, fmt.Errorf("failed to get the replicas of %s %s: %v", scaled.GetKind(), scaled.GetName(), err)
+	}
+
+	if updateQuotaToAllowMoves {
+		release, err := updateQuotasForMove(clusterScraper, pod, lockMap)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	podClient := clusterScraper.Clientset.CoreV1().Pods(pod.Namespace)
+	podList, err := parentsPods(parent, podClient)
+	if err != nil {
+		return nil, err
+	}
+	var endpointsNames []string
+	if endpointsTimeout > 0 {
+		if endpointsNames, err = podEndpoints(clusterScraper.Clientset, pod); err != nil {
+			return nil, err
+		}
+	}
+
+	if grandParent != nil {
+		// step 8: (via defer)
+		defer func() {
+			glog.V(3).Infof("Unpausing pods controller: %s for pod: %s", gPKind, podQualifiedName)
+			err := commonutil.RetryDuring(DefaultExecutionRetry, DefaultRetryShortTimeout,
+				DefaultRetrySleepInterval, func() error {
+					return ResourceRollout(gPClient, grandParent, false)
+				})
+			if err != nil {
+				glog.Errorf("Move pod warning: %v", err)
+			}
+		}()
+		// step 1:
+		glog.V(3).Infof("Pausing pods controller: %s for pod: %s", gPKind, podQualifiedName)
+		if err := ResourceRollout(gPClient, grandParent, true); err != nil {
+			return nil, err
+		}
+	}
+
+	// The whole affinity of the pod template is restored, so that the template of the ReplicaSet matches the template
+	// of its Deployment again, and unpausing the Deployment does not roll it out
+	affinity, hasAffinity, err := unstructured.NestedFieldCopy(parent.Object, "spec", "template", "spec",
+		"affinity")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the affinity of %s %s: %v", parentKind, parent.GetName(), err)
+	}
+	templateRestored := false
+	restoreTemplate := func() {
+		if templateRestored {
+			return
+		}
+		glog.V(3).Infof("Restoring the affinity of %s for pod %s.", parentKind, podQualifiedName)
+		err := commonutil.RetryDuring(DefaultExecutionRetry, DefaultRetryShortTimeout,
+			DefaultRetrySleepInterval, func() error {
+				return restoreAffinity(pClient, parent.GetName(), affinity, hasAffinity)
+			})
+		if err != nil {
+			glog.Errorf("Move pod warning: %v", err)
+			return
+		}
+		templateRestored = true
+	}
+	// step 5: (via defer, on failure before the new pod is scheduled)
+	defer restoreTemplate()
+	// step 2:
+	glog.V(3).Infof("Requiring node %s in the pod template of %s for pod %s.", nodeName, parentKind,
+		podQualifiedName)
+	if err := patchRequiredNodeAffinity(pClient, parent.GetName(), nodeSelectorForNode(nodeName)); err != nil {
+		return nil, err
+	}
+
+	// step 3:
+	deletionCost, hasDeletionCost := pod.Annotations[podDeletionCostAnnotation]
+	if err := patchPodDeletionCost(podClient, pod.Name, minPodDeletionCost); err != nil {
+		return nil, err
+	}
+	succeeded := false
+	defer func() {
+		if succeeded {
+			return
+		}
+		// Restore the deletion cost first, so that the new pod is the one deleted when scaling back down
+		var cost interface{}
+		if hasDeletionCost {
+			cost = deletionCost
+		}
+		if err := patchPodDeletionCost(podClient, pod.Name, cost); err != nil && !apierrors.IsNotFound(err) {
+			glog.Errorf("Move pod warning: failed to restore the deletion cost of pod %s: %v", podQualifiedName, err)
+		}
+		glog.Errorf("Move pod failed, scaling %s %s back to %d replicas.", scaled.GetKind(), scaled.GetName(),
+			replicas)
+		if err := patchReplicas(scaledClient, scaled.GetName(), replicas); err != nil {
+			glog.Errorf("Move pod warning: %v", err)
+		}
+	}()
+
+	// step 4:
+	glog.V(3).Infof("Scaling %s %s up to %d replicas for pod %s.", scaled.GetKind(), scaled.GetName(),
+		replicas+1, podQualifiedName)
+	if err := patchReplicas(scaledClient, scaled.GetName(), replicas+1); err != nil {
+		return nil, err
+	}
+
+	// step 5:
+	retryInterval, failureThreshold, initDelay, err := podReadinessThreshold(pod, parent, retryNum)
+	if err != nil {
+		return nil, err
+	}
+	newPod, err := waitForNewPod(parent, podClient, podList, nodeName, DefaultRetryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	// The node affinity of the new pod itself cannot be changed, but is only enforced when scheduling
+	restoreTemplate()
+
+	// step 6:
+	glog.V(4).Infof("Now wait for new pod %s/%s to be ready", newPod.Namespace, newPod.Name)
+	err = podutil.WaitForPodReady(clusterScraper.Clientset, newPod.Namespace, newPod.Name, nodeName,
+		time.Second*time.Duration(initDelay), failureThreshold, retryInterval)
+	if err != nil {
+		return nil, err
+	}
+	err = waitForEndpoints(clusterScraper.Clientset, pod.Namespace, endpointsNames, newPod.Name, endpointsTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// step 7:
+	glog.V(3).Infof("Scaling %s %s back to %d replicas to delete pod %s.", scaled.GetKind(), scaled.GetName(),
+		replicas, podQualifiedName)
+	if err := patchReplicas(scaledClient, scaled.GetName(), replicas); err != nil {
+		return nil, err
+	}
+	succeeded = true
+	if err := waitForPodDeletion(podClient, pod, DefaultRetryTimeout); err != nil {
+		return nil, err
+	}
+	return newPod, nil
+}
+
+// lockForController serializes the scale-up moves of the pods of the given controller.
+func lockForController(controller *unstructured.Unstructured, lockMap *util.ExpirationMap) (*util.LockHelper, error) {
+	controllerLockKey := fmt.Sprintf("controller-lock-%s-%s/%s", controller.GetKind(), controller.GetNamespace(),
+		controller.GetName())
+	lockHelper, err := util.NewLockHelper(controllerLockKey, lockMap)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockHelper.Trylock(defaultWaitLockTimeOut, defaultWaitLockSleep)
+	if err != nil {
+		glog.Errorf("Failed to acquire lock with key(%v): %v", controllerLockKey, err)
+		return nil, err
+	}
+	lockHelper.KeepRenewLock()
+
+	return lockHelper, nil
+}
+
+// nodeSelectorForNode returns the required node affinity, in unstructured form, which selects the given node.
+func nodeSelectorForNode(nodeName string) map[string]interface{} {
+	return map[string]interface{}{
+		"nodeSelectorTerms": []interface{}{
+			map[string]interface{}{
+				"matchFields": []interface{}{
+					map[string]interface{}{
+						"key":      "metadata.name",
+						"operator": string(api.NodeSelectorOpIn),
+						"values":   []interface{}{nodeName},
+					},
+				},
+			},
+		},
+	}
+}
+
+// patchRequiredNodeAffinity sets the required node affinity of the pod template of the given controller.
+func patchRequiredNodeAffinity(client dynamic.ResourceInterface, name string, nodeSelector interface{}) error {
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"affinity": map[string]interface{}{
+						"nodeAffinity": map[string]interface{}{
+							"requiredDuringSchedulingIgnoredDuringExecution": nodeSelector,
+						},
+					},
+				},
+			},
+		},
+	}
+	return mergePatch(client, name, patch)
+}
+
+// restoreAffinity puts back the given affinity of the pod template of the given controller as it was, or removes the
+// affinity if the template had none. A merge patch would only remove the required node affinity, and leave its empty
+// parents in the template.
+func restoreAffinity(client dynamic.ResourceInterface, name string, affinity interface{}, found bool) error {
+	op := map[string]interface{}{"op": "remove", "path": "/spec/template/spec/affinity"}
+	if found {
+		op = map[string]interface{}{"op": "replace", "path": "/spec/template/spec/affinity", "value": affinity}
+	}
+	data, err := json.Marshal([]interface{}{op})
+	if err != nil {
+		return err
+	}
+	if _, err := client.Patch(context.TODO(), name, types.JSONPatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to restore the affinity of %s: %v", name, err)
+	}
+	return nil
+}
+
+// patchReplicas sets the replicas of the given controller.
+func patchReplicas(client dynamic.ResourceInterface, name string, replicas int64) error {
+	return mergePatch(client, name, map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": replicas},
+	})
+}
+
+func mergePatch(client dynamic.ResourceInterface, name string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Patch(context.TODO(), name, types.MergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch %s: %v", name, err)
+	}
+	return nil
+}
+
+// patchPodDeletionCost sets the deletion cost annotation of the given pod, or removes it if nil.
+func patchPodDeletionCost(podClient v1.PodInterface, name string, cost interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{podDeletionCostAnnotation: cost},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = podClient.Patch(context.TODO(), name, types.MergePatchType, data, metav1.PatchOptions{})
+	return err
+}
+
+// waitForNewPod waits for the pod of the given controller, not in the given list of its former pods, to be scheduled
+// on the given node.
+func waitForNewPod(parent *unstructured.Unstructured, podClient v1.PodInterface, oldPods *api.PodList,
+	nodeName string, timeout time.Duration) (*api.Pod, error) {
+	oldPodNames := sets.NewString()
+	for _, pod := range oldPods.Items {
+		oldPodNames.Insert(pod.Name)
+	}
+	var newPod *api.Pod
+	err := wait.PollImmediate(DefaultRetrySleepInterval, timeout, func() (bool, error) {
+		podList, err := parentsPods(parent, podClient)
+		if err != nil {
+			glog.V(4).Infof("Failed to list the pods of %s %s: %v", parent.GetKind(), parent.GetName(), err)
+			return false, nil
+		}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if !oldPodNames.Has(pod.Name) && pod.Spec.NodeName == nodeName && pod.DeletionTimestamp == nil {
+				newPod = pod
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("no new pod of %s %s was scheduled on node %s in %v", parent.GetKind(),
+			parent.GetName(), nodeName, timeout)
+	}
+	return newPod, nil
+}
+
+// waitForPodDeletion waits for the given pod to be deleted, or terminating.
+func waitForPodDeletion(podClient v1.PodInterface, pod *api.Pod, timeout time.Duration) error {
+	err := wait.PollImmediate(DefaultRetrySleepInterval, timeout, func() (bool, error) {
+		current, err := podClient.Get(context.TODO(), pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, nil
+		}
+		return current.UID != pod.UID || current.DeletionTimestamp != nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("pod %s/%s was not deleted in %v after scaling its controller back down", pod.Namespace,
+			pod.Name, timeout)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package executor
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+)
+
+func TestNodeSelectorForNode(t *testing.T) {
+	var nodeSelector api.NodeSelector
+	assert.Nil(t, runtime.DefaultUnstructuredConverter.FromUnstructured(nodeSelectorForNode("node-2"), &nodeSelector))
+	assert.Equal(t, api.NodeSelector{
+		NodeSelectorTerms: []api.NodeSelectorTerm{{
+			MatchFields: []api.NodeSelectorRequirement{{
+				Key:      "metadata.name",
+				Operator: api.NodeSelectorOpIn,
+				Values:   []string{"node-2"},
+			}},
+		}},
+	}, nodeSelector)
+}
+
+const (
+	scaleUpMoveAppsPath = "/apis/apps/v1/namespaces/ns/"
+	scaleUpMoveCorePath = "/api/v1/namespaces/ns/"
+)
+
+// fakeScaleUpMoveServer serves the objects of a Deployment, its ReplicaSet and their pods by path, and emulates their
+// controllers: scaling the Deployment up creates a ready pod on the node required by the template of the ReplicaSet,
+// and scaling it down deletes the pod of the lowest deletion cost.
+type fakeScaleUpMoveServer struct {
+	lock    sync.Mutex
+	objects map[string]map[string]interface{}
+	// Whether the new pods are registered in the endpoints of the service
+	registerEndpoints bool
+	// The affinity of the template of the ReplicaSet, and whether the Deployment was paused, when scaled up
+	scaledUpAffinity interface{}
+	scaledUpPaused   bool
+}
+
+// toObject returns the given object as decoded from JSON by the server.
+func toObject(obj interface{}) map[string]interface{} {
+	data, _ := json.Marshal(obj)
+	object := map[string]interface{}{}
+	json.Unmarshal(data, &object)
+	return object
+}
+
+func newScaleUpMovePod(name, nodeName string) map[string]interface{} {
+	return toObject(&api.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "-uid"),
+			Labels: map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-rs",
+				UID: "rs-uid", Controller: boolPtr(true)}}},
+		Spec: api.PodSpec{NodeName: nodeName, Containers: []api.Container{{Name: "web", Image: "web"}}},
+		Status: api.PodStatus{Phase: api.PodRunning,
+			Conditions: []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}},
+	})
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func newScaleUpMoveTemplate(affinity map[string]interface{}) map[string]interface{} {
+	spec := map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "web", "image": "web"}},
+	}
+	if affinity != nil {
+		spec["affinity"] = affinity
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+		"spec":     spec,
+	}
+}
+
+func newFakeScaleUpMoveServer(affinity map[string]interface{}) *fakeScaleUpMoveServer {
+	selector := map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}
+	return &fakeScaleUpMoveServer{objects: map[string]map[string]interface{}{
+		scaleUpMoveAppsPath + "deployments/web": {
+			"apiVersion": "apps/v1", "kind": "Deployment",
+			"metadata": map[string]interface{}{"name": "web", "namespace": "ns", "uid": "deploy-uid"},
+			"spec": map[string]interface{}{"replicas": int64(1), "selector": selector,
+				"template": newScaleUpMoveTemplate(affinity)},
+		},
+		scaleUpMoveAppsPath + "replicasets/web-rs": {
+			"apiVersion": "apps/v1", "kind": "ReplicaSet",
+			"metadata": map[string]interface{}{"name": "web-rs", "namespace": "ns", "uid": "rs-uid",
+				"ownerReferences": []interface{}{map[string]interface{}{"apiVersion": "apps/v1",
+					"kind": "Deployment", "name": "web", "uid": "deploy-uid", "controller": true}}},
+			"spec": map[string]interface{}{"replicas": int64(1), "selector": selector,
+				"template": newScaleUpMoveTemplate(affinity)},
+		},
+		scaleUpMoveCorePath + "pods/web-1": newScaleUpMovePod("web-1", "node-1"),
+		scaleUpMoveCorePath + "endpoints/web": toObject(&api.Endpoints{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns"},
+			Subsets: []api.EndpointSubset{{Addresses: []api.EndpointAddress{
+				{TargetRef: &api.ObjectReference{Kind: "Pod", Name: "web-1"}}}}},
+		}),
+	}}
+}
+
+func (s *fakeScaleUpMoveServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	path := r.URL.Path
+	switch r.Method {
+	case http.MethodGet:
+		if obj, found := s.objects[path]; found {
+			json.NewEncoder(w).Encode(obj)
+			return
+		}
+		for collection, kind := range map[string]string{"pods": "PodList", "endpoints": "EndpointsList",
+			"events": "EventList"} {
+			if path == scaleUpMoveCorePath+collection {
+				json.NewEncoder(w).Encode(s.list(path, kind, r.URL.Query().Get("labelSelector")))
+				return
+			}
+		}
+		s.notFound(w)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		obj := map[string]interface{}{}
+		json.Unmarshal(body, &obj)
+		s.objects[path] = obj
+		json.NewEncoder(w).Encode(obj)
+	case http.MethodPatch:
+		original, found := s.objects[path]
+		if !found {
+			s.notFound(w)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		originalData, _ := json.Marshal(original)
+		var patched []byte
+		var err error
+		if r.Header.Get("Content-Type") == "application/json-patch+json" {
+			var patch jsonpatch.Patch
+			if patch, err = jsonpatch.DecodePatch(body); err == nil {
+				patched, err = patch.Apply(originalData)
+			}
+		} else {
+			patched, err = jsonpatch.MergePatch(originalData, body)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1",
+				"status": "Failure", "message": err.Error(), "code": http.StatusUnprocessableEntity})
+			return
+		}
+		obj := map[string]interface{}{}
+		json.Unmarshal(patched, &obj)
+		s.objects[path] = obj
+		if path == scaleUpMoveAppsPath+"deployments/web" {
+			s.reconcile()
+		}
+		json.NewEncoder(w).Encode(obj)
+	}
+}
+
+func (s *fakeScaleUpMoveServer) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1",
+		"status": "Failure", "reason": "NotFound", "code": http.StatusNotFound})
+}
+
+// list lists the objects under the given collection path matching the given label selector.
+func (s *fakeScaleUpMoveServer) list(path, kind, labelSelector string) map[string]interface{} {
+	selector, _ := labels.Parse(labelSelector)
+	items := []interface{}{}
+	for _, key := range s.keys(path + "/") {
+		obj := &unstructured.Unstructured{Object: s.objects[key]}
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			items = append(items, s.objects[key])
+		}
+	}
+	return map[string]interface{}{"apiVersion": "v1", "kind": kind, "metadata": map[string]interface{}{},
+		"items": items}
+}
+
+func (s *fakeScaleUpMoveServer) keys(prefix string) []string {
+	var keys []string
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// replicasOf returns the replicas of the given controller, decoded from JSON as a float.
+func replicasOf(obj map[string]interface{}) int64 {
+	replicas, _, _ := unstructured.NestedFieldNoCopy(obj, "spec", "replicas")
+	if f, ok := replicas.(float64); ok {
+		return int64(f)
+	}
+	return replicas.(int64)
+}
+
+// reconcile creates or deletes the pods of the ReplicaSet to match the replicas of the Deployment.
+func (s *fakeScaleUpMoveServer) reconcile() {
+	deployment := &unstructured.Unstructured{Object: s.objects[scaleUpMoveAppsPath+"deployments/web"]}
+	replicas := replicasOf(deployment.Object)
+	pods := s.keys(scaleUpMoveCorePath + "pods/")
+	if int64(len(pods)) < replicas {
+		replicaSet := s.objects[scaleUpMoveAppsPath+"replicasets/web-rs"]
+		s.scaledUpAffinity, _, _ = unstructured.NestedFieldCopy(replicaSet, "spec", "template", "spec", "affinity")
+		s.scaledUpPaused, _, _ = unstructured.NestedBool(deployment.Object, "spec", "paused")
+		// The pod is scheduled on the node required by the template
+		nodeName := ""
+		terms, _, _ := unstructured.NestedSlice(replicaSet, "spec", "template", "spec", "affinity",
+			"nodeAffinity", "requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+		if len(terms) > 0 {
+			fields := terms[0].(map[string]interface{})["matchFields"].([]interface{})
+			nodeName = fields[0].(map[string]interface{})["values"].([]interface{})[0].(string)
+		}
+		s.objects[scaleUpMoveCorePath+"pods/web-new"] = newScaleUpMovePod("web-new", nodeName)
+		if s.registerEndpoints {
+			endpoints := s.objects[scaleUpMoveCorePath+"endpoints/web"]
+			subset := endpoints["subsets"].([]interface{})[0].(map[string]interface{})
+			subset["addresses"] = append(subset["addresses"].([]interface{}), map[string]interface{}{
+				"targetRef": map[string]interface{}{"kind": "Pod", "name": "web-new"}})
+		}
+		return
+	}
+	if int64(len(pods)) > replicas {
+		// The pod of the lowest deletion cost, or the newest pod
+		deleted := scaleUpMoveCorePath + "pods/web-new"
+		for _, key := range pods {
+			pod := &unstructured.Unstructured{Object: s.objects[key]}
+			if pod.GetAnnotations()[podDeletionCostAnnotation] == minPodDeletionCost {
+				deleted = key
+			}
+		}
+		delete(s.objects, deleted)
+	}
+}
+
+func newScaleUpMoveScraper(t *testing.T, s *fakeScaleUpMoveServer) (*cluster.ClusterScraper, func()) {
+	server := httptest.NewServer(s)
+	config := &restclient.Config{Host: server.URL}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	assert.NoError(t, err)
+	dynamicClient, err := dynamic.NewForConfig(config)
+	assert.NoError(t, err)
+	return cluster.NewClusterScraper(config, kubeClient, dynamicClient, nil, nil, nil, ""), server.Close
+}
+
+func getScaleUpMovePod(t *testing.T, s *fakeScaleUpMoveServer, name string) *api.Pod {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	obj, found := s.objects[scaleUpMoveCorePath+"pods/"+name]
+	if !found {
+		return nil
+	}
+	pod := &api.Pod{}
+	assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj, pod))
+	return pod
+}
+
+func assertScaleUpMoveRestored(t *testing.T, s *fakeScaleUpMoveServer, affinity map[string]interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	deployment := s.objects[scaleUpMoveAppsPath+"deployments/web"]
+	assert.EqualValues(t, 1, replicasOf(deployment))
+	paused, _, _ := unstructured.NestedBool(deployment, "spec", "paused")
+	assert.False(t, paused)
+	// The template of the ReplicaSet matches the template of the Deployment again
+	template, _, _ := unstructured.NestedMap(s.objects[scaleUpMoveAppsPath+"replicasets/web-rs"], "spec", "template")
+	assert.Equal(t, toObject(newScaleUpMoveTemplate(affinity)), template)
+	assert.True(t, s.scaledUpPaused)
+	assert.Equal(t, map[string]interface{}{"requiredDuringSchedulingIgnoredDuringExecution": toObject(
+		nodeSelectorForNode("node-2"))}, s.scaledUpAffinity.(map[string]interface{})["nodeAffinity"])
+}
+
+func TestScaleUpMovePod(t *testing.T) {
+	podAntiAffinity := map[string]interface{}{"podAntiAffinity": map[string]interface{}{
+		"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{map[string]interface{}{
+			"weight": int64(1), "podAffinityTerm": map[string]interface{}{"topologyKey": "kubernetes.io/hostname"},
+		}},
+	}}
+	for name, affinity := range map[string]map[string]interface{}{
+		"no affinity":       nil,
+		"pod anti-affinity": podAntiAffinity,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newFakeScaleUpMoveServer(affinity)
+			s.registerEndpoints = true
+			clusterScraper, closeServer := newScaleUpMoveScraper(t, s)
+			defer closeServer()
+			pod := getScaleUpMovePod(t, s, "web-1")
+
+			newPod, err := scaleUpMovePod(clusterScraper, pod, "node-2", "ReplicaSet", 1, time.Second, false,
+				util.NewExpirationMap(time.Minute))
+			assert.NoError(t, err)
+			if assert.NotNil(t, newPod) {
+				assert.Equal(t, "web-new", newPod.Name)
+				assert.Equal(t, "node-2", newPod.Spec.NodeName)
+			}
+			// The original pod is deleted by scaling back down
+			assert.Nil(t, getScaleUpMovePod(t, s, "web-1"))
+			assertScaleUpMoveRestored(t, s, affinity)
+		})
+	}
+}
+
+func TestScaleUpMovePodRollback(t *testing.T) {
+	s := newFakeScaleUpMoveServer(nil)
+	// The new pod is never registered in the endpoints of the original pod
+	clusterScraper, closeServer := newScaleUpMoveScraper(t, s)
+	defer closeServer()
+	pod := getScaleUpMovePod(t, s, "web-1")
+
+	_, err := scaleUpMovePod(clusterScraper, pod, "node-2", "ReplicaSet", 1, 10*time.Millisecond, false,
+		util.NewExpirationMap(time.Minute))
+	assert.Error(t, err)
+	// The deletion cost of the original pod is restored, so that the new pod is deleted by scaling back down
+	original := getScaleUpMovePod(t, s, "web-1")
+	if assert.NotNil(t, original) {
+		assert.NotContains(t, original.Annotations, podDeletionCostAnnotation)
+	}
+	assert.Nil(t, getScaleUpMovePod(t, s, "web-new"))
+	assertScaleUpMoveRestored(t, s, nil)
+}
@@ -0,0 +1,60 @@
+package executor
+
+import (
+	api "k8s.io/api/core/v1"
+)
+
+const (
+	// Who places the new pod of a move: the destination chosen by the Turbo server (server), or the scheduler of
+	// the pod, with the destination as the preferred node (scheduler)
+	MovePlacementServer    = "server"
+	MovePlacementScheduler = "scheduler"
+
+	// The weight of the preference of the new pod of a move for the destination, the highest of the preferred
+	// node affinity, so that the scheduler only picks another node if the destination does not fit the pod
+	preferredNodeWeight int32 = 100
+)
+
+// delegatePlacement leaves the placement of the new pod of a move to the scheduler of the pod: the pod is created
+// unscheduled, prefers the preferred node, and is required not to run on the node of the moved pod, by adding the
+// node name to each term of the required node affinity of the pod, which are ORed.
+func delegatePlacement(spec *api.PodSpec, preferredNode, currentNode string) {
+	spec.NodeName = ""
+	if spec.Affinity == nil {
+		spec.Affinity = &api.Affinity{}
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &api.NodeAffinity{}
+	}
+	nodeAffinity := spec.Affinity.NodeAffinity
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, api.PreferredSchedulingTerm{
+			Weight: preferredNodeWeight,
+			Preference: api.NodeSelectorTerm{
+				MatchFields: []api.NodeSelectorRequirement{nodeNameRequirement(api.NodeSelectorOpIn, preferredNode)},
+			},
+		})
+	if currentNode == "" {
+		return
+	}
+	notCurrentNode := nodeNameRequirement(api.NodeSelectorOpNotIn, currentNode)
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &api.NodeSelector{
+			NodeSelectorTerms: []api.NodeSelectorTerm{{MatchFields: []api.NodeSelectorRequirement{notCurrentNode}}},
+		}
+		return
+	}
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchFields = append(required.NodeSelectorTerms[i].MatchFields, notCurrentNode)
+	}
+}
+
+// nodeNameRequirement returns the node selector requirement on the name of the node.
+func nodeNameRequirement(operator api.NodeSelectorOperator, nodeName string) api.NodeSelectorRequirement {
+	return api.NodeSelectorRequirement{
+		Key:      "metadata.name",
+		Operator: operator,
+		Values:   []string{nodeName},
+	}
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kclient "k8s.io/client-go/kubernetes"
+
+	"github.com/golang/glog"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	nodeutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/compliance"
+)
+
+// checkSchedulingConstraints refuses the move of the pod to the node if the node does not satisfy the scheduling
+// constraints of the pod, so that the moved pod is not left Pending: the node selector, the node affinity, the
+// taints and tolerations, and the pod affinity and anti-affinity and the topology spread constraints against the pods
+// running in the cluster.
+func checkSchedulingConstraints(client kclient.Interface, pod *api.Pod, node *api.Node) error {
+	allPodsNodesMap, err := getRunningPodsNodes(client, pod)
+	if err != nil {
+		return err
+	}
+	violations := compliance.SchedulingConstraintViolations(pod, node, allPodsNodesMap)
+	if len(violations) > 0 {
+		return util.NewActionRefusalError(util.ReasonNotSchedulable,
+			"move pod failed: pod %s/%s cannot be scheduled on node %s: %s", pod.Namespace, pod.Name, node.Name,
+			strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// checkNodeOS refuses the move of the pod to a node running another operating system, e.g. of a Linux pod to a
+// Windows node, where the images of the pod cannot run. The operating system of the pod is the one of its spec or of
+// its node selector, or else the one of the node it runs on. The move is not refused if either is unknown.
+func checkNodeOS(client kclient.Interface, pod *api.Pod, node *api.Node) error {
+	nodeOS := nodeutil.GetNodeOS(node)
+	if nodeOS == "" {
+		return nil
+	}
+	podOS := podOperatingSystem(pod)
+	if podOS == "" {
+		source, err := client.CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+		if err != nil {
+			glog.Warningf("Failed to get node %s of pod %s/%s to check its operating system: %v",
+				pod.Spec.NodeName, pod.Namespace, pod.Name, err)
+			return nil
+		}
+		podOS = nodeutil.GetNodeOS(source)
+	}
+	if podOS == "" || podOS == nodeOS {
+		return nil
+	}
+	return util.NewActionRefusalError(util.ReasonOSMismatch,
+		"move pod failed: pod %s/%s runs on %s but node %s runs on %s", pod.Namespace, pod.Name, podOS, node.Name,
+		nodeOS)
+}
+
+// podOperatingSystem returns the operating system set in the spec or the node selector of the pod, empty if none.
+func podOperatingSystem(pod *api.Pod) string {
+	if pod.Spec.OS != nil {
+		return string(pod.Spec.OS.Name)
+	}
+	if os, found := pod.Spec.NodeSelector[nodeutil.NodeLabelOS]; found {
+		return os
+	}
+	return pod.Spec.NodeSelector[nodeutil.NodeLabelOSBeta]
+}
+
+// getRunningPodsNodes returns the pods running in the cluster, except the given one, with the nodes they run on.
+func getRunningPodsNodes(client kclient.Interface, pod *api.Pod) (map[*api.Pod]*api.Node, error) {
+	nodeList, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the nodes: %v", err)
+	}
+	nodes := make(map[string]*api.Node, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes[nodeList.Items[i].Name] = &nodeList.Items[i]
+	}
+	podList, err := client.CoreV1().Pods(api.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %v", err)
+	}
+	allPodsNodesMap := make(map[*api.Pod]*api.Node)
+	for i := range podList.Items {
+		existingPod := &podList.Items[i]
+		if existingPod.UID == pod.UID || existingPod.Status.Phase == api.PodSucceeded ||
+			existingPod.Status.Phase == api.PodFailed {
+			continue
+		}
+		if node, found := nodes[existingPod.Spec.NodeName]; found {
+			allPodsNodesMap[existingPod] = node
+		}
+	}
+	return allPodsNodesMap, nil
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 	k8sapi "k8s.io/api/core/v1"
@@ -32,6 +33,9 @@ type WorkloadControllerResizer struct {
 	kubeletClient *kubeclient.KubeletClient
 	sccAllowedSet map[string]struct{}
 	lockMap       *actionutil.ExpirationMap
+	// How long to wait for the rollout of the resized pods, no wait if not positive
+	rolloutTimeout time.Duration
+	resizeLimits   ResizeLimits
 }
 
 func NewWorkloadControllerResizer(ae TurboK8sActionExecutor, kubeletClient *kubeclient.KubeletClient,
@@ -44,6 +48,16 @@ func NewWorkloadControllerResizer(ae TurboK8sActionExecutor, kubeletClient *kube
 	}
 }
 
+func (r *WorkloadControllerResizer) WithRolloutTimeout(rolloutTimeout time.Duration) *WorkloadControllerResizer {
+	r.rolloutTimeout = rolloutTimeout
+	return r
+}
+
+func (r *WorkloadControllerResizer) WithResizeLimits(resizeLimits ResizeLimits) *WorkloadControllerResizer {
+	r.resizeLimits = resizeLimits
+	return r
+}
+
 // Execute executes the workload controller resize action
 // The error info will be shown in UI
 func (r *WorkloadControllerResizer) Execute(input *TurboActionExecutorInput) (*TurboActionExecutorOutput, error) {
@@ -74,12 +88,25 @@ func (r *WorkloadControllerResizer) Execute(input *TurboActionExecutorInput) (*T
 		resizeSpecs = append(resizeSpecs, spec)
 	}
 
+	// Verify if the desired resources violate the resize limits, against the current podSpec
+	if err := checkResizeLimits(r.resizeLimits, podSpec, resizeSpecs); err != nil {
+		glog.Errorf("Failed to execute action on the workload controller %v/%v: %v", namespace, controllerName, err)
+		return &TurboActionExecutorOutput{}, err
+	}
+
 	// Verify if the desired podSpec viloates the limitrange
 	desiredPod := buildDesiredPod4QuotaEvaluation(namespace, resizeSpecs, *podSpec)
 	limitrangeViolateErr := CheckLimitrangeViolationOnPod(r.clusterScraper.Clientset, namespace, desiredPod)
 	if limitrangeViolateErr != nil {
 		glog.Errorf("Failed to execute action on the workload controller %v/%v due to limitrange violation: %v", namespace, controllerName, limitrangeViolateErr)
-		return &TurboActionExecutorOutput{}, fmt.Errorf("limitrange violation:%v", limitrangeViolateErr)
+		return &TurboActionExecutorOutput{}, actionutil.NewActionRefusalError(actionutil.ReasonLimitRangeViolation,
+			"limitrange violation:%v", limitrangeViolateErr)
+	}
+
+	// Verify if the desired pod fits in a node
+	if err := checkNodeAllocatable(r.clusterScraper.Clientset, "", desiredPod); err != nil {
+		glog.Errorf("Failed to execute action on the workload controller %v/%v: %v", namespace, controllerName, err)
+		return &TurboActionExecutorOutput{}, err
 	}
 
 	// Temporally increase the NS quota if needed && not Gitops && not orm case
@@ -129,12 +156,26 @@ func (r *WorkloadControllerResizer) Execute(input *TurboActionExecutorInput) (*T
 	}
 	glog.V(2).Infof("Successfully execute resize action on the workload controller %s/%s.", namespace, controllerName)
 
+	// The changes of the workload controllers managed by gitops or operators are applied asynchronously
+	if r.rolloutTimeout > 0 && managerApp == nil && !isOwnerSet {
+		reportStep(input.Progress, progressHalfway, "Resized %s %s/%s, waiting for the rollout", kind, namespace,
+			controllerName)
+		if err := waitForRollout(r.clusterScraper.DynamicClient, r.clusterScraper.ControllerGroupVersions(), kind,
+			namespace, controllerName, r.rolloutTimeout, input.Progress); err != nil {
+			glog.Errorf("Failed to roll out the resize action on the workload controller %s/%s: %v",
+				namespace, controllerName, err)
+			return &TurboActionExecutorOutput{}, err
+		}
+		glog.V(2).Infof("The resize action on the workload controller %s/%s has been rolled out.",
+			namespace, controllerName)
+	}
+
 	return &TurboActionExecutorOutput{
 		Succeeded: true,
 	}, nil
 }
 
-// getControllerInfo retrieves information about a workload controller based on the provided target entity.
+// GetWorkloadControllerInfo retrieves information about a workload controller based on the provided target entity.
 //
 // Parameters:
 //
@@ -146,7 +187,7 @@ func (r *WorkloadControllerResizer) Execute(input *TurboActionExecutorInput) (*T
 //	controllerName - The name of the workload controller.
 //	kind - The type of the workload controller.
 //	error - An error if any occurred during the retrieval process.
-func getWorkloadControllerInfo(targetSE *proto.EntityDTO) (string, string, string, error) {
+func GetWorkloadControllerInfo(targetSE *proto.EntityDTO) (string, string, string, error) {
 	if targetSE == nil {
 		return "", "", "", fmt.Errorf("workload controller action item does not have a valid target entity")
 	}
@@ -209,7 +250,7 @@ func getWorkloadControllerInfo(targetSE *proto.EntityDTO) (string, string, strin
 func (r *WorkloadControllerResizer) getWorkloadControllerDetails(actionItem *proto.ActionItemDTO) (string,
 	string, string, *k8sapi.PodSpec, *repository.K8sApp, int64, bool, error) {
 	targetSE := actionItem.GetTargetSE()
-	namespace, controllerName, kind, err := getWorkloadControllerInfo(targetSE)
+	namespace, controllerName, kind, err := GetWorkloadControllerInfo(targetSE)
 	if err != nil {
 		return "", "", "", nil, nil, 0, false, err
 	}
@@ -222,7 +263,7 @@ func (r *WorkloadControllerResizer) getWorkloadControllerDetails(actionItem *pro
 }
 
 func (r *WorkloadControllerResizer) getWorkloadControllerSpec(parentKind, namespace, name string) (*k8sapi.PodSpec, int64, bool, error) {
-	res, err := GetSupportedResUsingKind(parentKind, namespace, name)
+	res, err := GetSupportedResUsingKind(r.clusterScraper.ControllerGroupVersions(), parentKind, namespace, name)
 	if err != nil {
 		return nil, 0, false, err
 	}
@@ -3,7 +3,8 @@ package util
 import (
 	"sync"
 	"time"
-	//"github.com/golang/glog"
+
+	"github.com/golang/glog"
 )
 
 type expireCallBack func(obj interface{})
@@ -21,6 +22,15 @@ type ExpirationMap struct {
 	generation int64
 
 	ttl time.Duration
+	// Keeps the keys across the restarts of kubeturbo, nil if kept in memory only
+	store ExpirationStore
+}
+
+// ExpirationStore keeps the keys of an ExpirationMap with their expiration, e.g. a turbostore.BoltCache.
+type ExpirationStore interface {
+	Set(k string, x interface{}, d time.Duration)
+	Delete(k string) error
+	Items() map[string]interface{}
 }
 
 func (n *node) isExpire() bool {
@@ -48,6 +58,26 @@ func NewExpirationMap(ttl time.Duration) *ExpirationMap {
 	}
 }
 
+// WithStore keeps the keys of the map in the given store, and adds back the unexpired keys of the store, e.g. the
+// move locks held when kubeturbo stopped, for a full ttl. The objects of the keys added back are the keys themselves,
+// and nothing is called when they expire.
+func (s *ExpirationMap) WithStore(store ExpirationStore) *ExpirationMap {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.store = store
+	for key := range store.Items() {
+		s.generation += 1
+		s.items[key] = &node{
+			obj:      key,
+			version:  s.generation,
+			expire:   time.Now().Add(s.ttl),
+			callBack: func(obj interface{}) {},
+		}
+		store.Set(key, key, s.ttl)
+	}
+	return s
+}
+
 func (s *ExpirationMap) Add(key string, obj interface{}, fun expireCallBack) (int64, bool) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -64,6 +94,9 @@ func (s *ExpirationMap) Add(key string, obj interface{}, fun expireCallBack) (in
 		callBack: fun,
 	}
 	s.items[key] = item
+	if s.store != nil {
+		s.store.Set(key, key, s.ttl)
+	}
 
 	return item.version, true
 }
@@ -82,17 +115,33 @@ func (s *ExpirationMap) Del(key string, version int64) bool {
 		return false
 	}
 
-	delete(s.items, key)
+	s.deleteItem(key)
 	return true
 }
 
+// deleteItem deletes the item of the key from the map and the store, with the lock held.
+func (s *ExpirationMap) deleteItem(key string) {
+	delete(s.items, key)
+	if s.store != nil {
+		if err := s.store.Delete(key); err != nil {
+			glog.Errorf("Failed to delete %s from the store: %v", key, err)
+		}
+	}
+}
+
 // touch: update the timestamp to prevent expire
 func (s *ExpirationMap) Touch(key string, version int64) bool {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	if item, ok := s.items[key]; ok && item.version == version {
-		return item.touch(s.ttl)
+		if !item.touch(s.ttl) {
+			return false
+		}
+		if s.store != nil {
+			s.store.Set(key, key, s.ttl)
+		}
+		return true
 	}
 
 	return false
@@ -121,7 +170,7 @@ func (s *ExpirationMap) expireItem(key string) bool {
 	if item, ok := s.items[key]; ok {
 		if item.isExpire() {
 			item.callBack(item.obj)
-			delete(s.items, key)
+			s.deleteItem(key)
 			return true
 		}
 	}
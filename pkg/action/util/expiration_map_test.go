@@ -158,3 +158,38 @@ func TestExpirationMap_DelTouch(t *testing.T) {
 		t.Errorf("Touch failed.")
 	}
 }
+
+type mapExpirationStore map[string]interface{}
+
+func (m mapExpirationStore) Set(k string, x interface{}, d time.Duration) { m[k] = x }
+
+func (m mapExpirationStore) Delete(k string) error {
+	delete(m, k)
+	return nil
+}
+
+func (m mapExpirationStore) Items() map[string]interface{} { return m }
+
+func TestExpirationMap_WithStore(t *testing.T) {
+	backing := mapExpirationStore{"held": "held"}
+	store := NewExpirationMap(defaultTTL).WithStore(backing)
+
+	// The keys of the store are held again
+	if _, ok := store.Add("held", "held", printKey); ok {
+		t.Errorf("Expect the key of the store to be held.")
+	}
+
+	v, ok := store.Add("hello", "hello", printKey)
+	if !ok {
+		t.Errorf("Add failed.")
+	}
+	if _, found := backing["hello"]; !found {
+		t.Errorf("Expect the key to be stored, got %v", backing)
+	}
+	if !store.Del("hello", v) {
+		t.Errorf("Delete failed.")
+	}
+	if _, found := backing["hello"]; found {
+		t.Errorf("Expect the key to be deleted from the store, got %v", backing)
+	}
+}
@@ -0,0 +1,156 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RefusalReason is a machine-readable code describing why kubeturbo refuses to execute an action.
+// The code is carried by ActionRefusalError next to the message, and is only added to the action failure
+// description sent to the server, in the form of "[<code>] <message>", with --action-refusal-reason-prefix.
+type RefusalReason string
+
+const (
+	// ReasonVolumePodMove means that the pod uses a persistent volume and such moves are disabled.
+	ReasonVolumePodMove RefusalReason = "VOLUME_POD_MOVE_DISABLED"
+	// ReasonPodAlreadyOnHost means that the pod is already running on the move destination.
+	ReasonPodAlreadyOnHost RefusalReason = "POD_ALREADY_ON_HOST"
+	// ReasonUnsupportedOwner means that the kind of the pod owner is not supported by the action.
+	ReasonUnsupportedOwner RefusalReason = "UNSUPPORTED_OWNER_KIND"
+	// ReasonUnsupportedSCC means that the pod runs with an SCC that is not allowed by --scc-support.
+	ReasonUnsupportedSCC RefusalReason = "UNSUPPORTED_SCC"
+	// ReasonDestinationNotReady means that the move destination node is not ready.
+	ReasonDestinationNotReady RefusalReason = "DESTINATION_NOT_READY"
+	// ReasonRolloutPaused means that the workload controller needs a manual rollout but is paused.
+	ReasonRolloutPaused RefusalReason = "ROLLOUT_PAUSED"
+	// ReasonLimitRangeViolation means that the resulting resources violate the namespace limit range.
+	ReasonLimitRangeViolation RefusalReason = "LIMIT_RANGE_VIOLATION"
+	// ReasonActionInProgress means that another action is already running against the same target.
+	ReasonActionInProgress RefusalReason = "ACTION_IN_PROGRESS"
+	// ReasonNodePoolIncoherent means that the machine set is not in a coherent state.
+	ReasonNodePoolIncoherent RefusalReason = "NODE_POOL_INCOHERENT"
+	// ReasonNodePoolMinSize means that the node pool cannot be scaled below its minimum size.
+	ReasonNodePoolMinSize RefusalReason = "NODE_POOL_MIN_SIZE"
+	// ReasonNodePoolMaxSize means that the node pool cannot be scaled above its maximum size.
+	ReasonNodePoolMaxSize RefusalReason = "NODE_POOL_MAX_SIZE"
+	// ReasonNodePoolAutoscaled means that the size of the cloud node group is owned by an autoscaler whose minimum
+	// size kubeturbo cannot raise, e.g. cluster-autoscaler with the --nodes flag, which would undo the new size.
+	ReasonNodePoolAutoscaled RefusalReason = "NODE_POOL_AUTOSCALED"
+	// ReasonDegradedDiscovery means that the last discovery is degraded and the actions are skipped until
+	// a consistent discovery completes.
+	ReasonDegradedDiscovery RefusalReason = "DEGRADED_DISCOVERY"
+	// ReasonVolumeNotAttachable means that a ReadWriteOnce persistent volume of the pod cannot be attached
+	// to the move destination node.
+	ReasonVolumeNotAttachable RefusalReason = "VOLUME_NOT_ATTACHABLE"
+	// ReasonReplicasMinSize means that the workload controller cannot be scaled below its minimum replicas.
+	ReasonReplicasMinSize RefusalReason = "REPLICAS_MIN_SIZE"
+	// ReasonReplicasMaxSize means that the workload controller cannot be scaled above its maximum replicas.
+	ReasonReplicasMaxSize RefusalReason = "REPLICAS_MAX_SIZE"
+	// ReasonRecommendMode means that kubeturbo runs with --action-mode=recommend and makes no change to the cluster.
+	ReasonRecommendMode RefusalReason = "RECOMMEND_MODE"
+	// ReasonDisruptionBudget means that the pods of a node cannot be evicted without violating their disruption budgets.
+	ReasonDisruptionBudget RefusalReason = "DISRUPTION_BUDGET_VIOLATION"
+	// ReasonTooManyNodeDrains means that the maximum number of concurrent node drains is reached.
+	ReasonTooManyNodeDrains RefusalReason = "TOO_MANY_NODE_DRAINS"
+	// ReasonNotSchedulable means that the move destination does not satisfy the node selector, the node or pod
+	// affinity, or the tolerations of the pod.
+	ReasonNotSchedulable RefusalReason = "SCHEDULING_CONSTRAINT_VIOLATION"
+	// ReasonExcludedByPolicy means that the workload is excluded from the action by the action policy ConfigMap.
+	ReasonExcludedByPolicy RefusalReason = "EXCLUDED_BY_POLICY"
+	// ReasonOptedOut means that the pod or its workload controller opted out of the actions with the
+	// kubeturbo.io/controllable=false or kubeturbo.io/monitored=false annotation.
+	ReasonOptedOut RefusalReason = "WORKLOAD_OPTED_OUT"
+	// ReasonActionQueueTimeout means that the action waited too long for the other actions of its type to complete.
+	ReasonActionQueueTimeout RefusalReason = "ACTION_QUEUE_TIMEOUT"
+	// ReasonShuttingDown means that kubeturbo is shutting down and no longer accepts new actions.
+	ReasonShuttingDown RefusalReason = "PROBE_SHUTTING_DOWN"
+	// ReasonStaticPod means that the pod is the mirror of a static pod managed by the kubelet of its node, which the
+	// API server cannot move.
+	ReasonStaticPod RefusalReason = "STATIC_POD"
+	// ReasonOSMismatch means that the move destination node runs another operating system than the pod, e.g. a
+	// Windows node for a Linux pod.
+	ReasonOSMismatch RefusalReason = "OS_MISMATCH"
+	// ReasonResizeLimitViolation means that the new resources of a container fall below the configured minimum, or
+	// change by more than the configured maximum percentage.
+	ReasonResizeLimitViolation RefusalReason = "RESIZE_LIMIT_VIOLATION"
+	// ReasonNodeAllocatableExceeded means that the resized pod would not fit in the allocatable resources of a node.
+	ReasonNodeAllocatableExceeded RefusalReason = "NODE_ALLOCATABLE_EXCEEDED"
+)
+
+// refusalReasonCatalog maps each refusal reason to a short human-readable description.
+var refusalReasonCatalog = map[RefusalReason]string{
+	ReasonVolumePodMove:           "Moving pods with persistent volumes is disabled",
+	ReasonPodAlreadyOnHost:        "Pod is already on the destination host",
+	ReasonUnsupportedOwner:        "Pod owner kind is not supported",
+	ReasonUnsupportedSCC:          "Pod security context constraint is not allowed",
+	ReasonDestinationNotReady:     "Destination node is not ready",
+	ReasonRolloutPaused:           "Workload controller rollout is paused",
+	ReasonLimitRangeViolation:     "Namespace limit range would be violated",
+	ReasonActionInProgress:        "Another action is in progress on the same target",
+	ReasonNodePoolIncoherent:      "Node pool is not in a coherent state",
+	ReasonNodePoolMinSize:         "Node pool minimum size would be violated",
+	ReasonNodePoolMaxSize:         "Node pool maximum size would be exceeded",
+	ReasonNodePoolAutoscaled:      "Node pool is scaled by its autoscaler",
+	ReasonDegradedDiscovery:       "Last discovery is degraded",
+	ReasonVolumeNotAttachable:     "Persistent volume cannot be attached to the destination node",
+	ReasonReplicasMinSize:         "Workload controller minimum replicas would be violated",
+	ReasonReplicasMaxSize:         "Workload controller maximum replicas would be exceeded",
+	ReasonRecommendMode:           "Actions are only recommended, not executed",
+	ReasonDisruptionBudget:        "Pod disruption budget would be violated",
+	ReasonTooManyNodeDrains:       "Maximum number of concurrent node drains reached",
+	ReasonNotSchedulable:          "Destination node does not satisfy the scheduling constraints of the pod",
+	ReasonExcludedByPolicy:        "Workload is excluded from the action by the action policy",
+	ReasonOptedOut:                "Workload opted out of the actions with its annotations",
+	ReasonActionQueueTimeout:      "Timed out waiting for the concurrent actions of the same type to complete",
+	ReasonShuttingDown:            "Kubeturbo is shutting down",
+	ReasonStaticPod:               "Static pods cannot be moved",
+	ReasonOSMismatch:              "Destination node runs another operating system than the pod",
+	ReasonResizeLimitViolation:    "Resize would violate the configured resize limits",
+	ReasonNodeAllocatableExceeded: "Resized pod would not fit in the allocatable resources of a node",
+}
+
+// Description returns the human-readable description of the refusal reason.
+func (r RefusalReason) Description() string {
+	if desc, found := refusalReasonCatalog[r]; found {
+		return desc
+	}
+	return string(r)
+}
+
+// RefusalReasons returns all the known refusal reasons.
+func RefusalReasons() []RefusalReason {
+	reasons := make([]RefusalReason, 0, len(refusalReasonCatalog))
+	for reason := range refusalReasonCatalog {
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// ActionRefusalError is returned by the action executor guards when an action is refused.
+type ActionRefusalError struct {
+	Reason  RefusalReason
+	Message string
+}
+
+// NewActionRefusalError creates an ActionRefusalError with the given reason and formatted message.
+func NewActionRefusalError(reason RefusalReason, format string, args ...interface{}) *ActionRefusalError {
+	return &ActionRefusalError{
+		Reason:  reason,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error returns the message of the refusal, which is the same as the message of the error returned before the
+// refusal reasons, so the reason is only available from the Reason field, e.g. with GetRefusalReason.
+func (e *ActionRefusalError) Error() string {
+	return e.Message
+}
+
+// GetRefusalReason returns the refusal reason if the error, or any error it wraps, is an ActionRefusalError.
+func GetRefusalReason(err error) (RefusalReason, bool) {
+	var refusalErr *ActionRefusalError
+	if errors.As(err, &refusalErr) {
+		return refusalErr.Reason, true
+	}
+	return "", false
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefusalReasonDescription(t *testing.T) {
+	for _, reason := range RefusalReasons() {
+		assert.NotEqual(t, string(reason), reason.Description(), "missing description for %s", reason)
+	}
+	assert.Equal(t, "UNKNOWN", RefusalReason("UNKNOWN").Description())
+}
+
+func TestActionRefusalError(t *testing.T) {
+	err := NewActionRefusalError(ReasonPodAlreadyOnHost, "pod [%v] is already on host [%v]", "ns/pod", "node-1")
+	assert.Equal(t, "pod [ns/pod] is already on host [node-1]", err.Error())
+
+	reason, refused := GetRefusalReason(fmt.Errorf("failed to move: %w", err))
+	assert.True(t, refused)
+	assert.Equal(t, ReasonPodAlreadyOnHost, reason)
+
+	_, refused = GetRefusalReason(fmt.Errorf("some other error"))
+	assert.False(t, refused)
+	_, refused = GetRefusalReason(nil)
+	assert.False(t, refused)
+}
+
+func TestSupportPrivilegePodRefusalReason(t *testing.T) {
+	_, err := SupportPrivilegePod(podWithSccAnnotations("pod-1", "anyuid"), map[string]struct{}{"restricted": {}})
+	reason, refused := GetRefusalReason(err)
+	assert.True(t, refused)
+	assert.Equal(t, ReasonUnsupportedSCC, reason)
+}
@@ -170,7 +170,7 @@ func SupportPrivilegePod(pod *api.Pod, sccAllowedSet map[string]struct{}) (bool,
 
 	podScc := pod.Annotations[osSccAnnotation]
 	if _, ok := sccAllowedSet[podScc]; !ok {
-		return false, fmt.Errorf("pod %s/%s has unsupported SCC %s. Please add the SCC level to "+
+		return false, NewActionRefusalError(ReasonUnsupportedSCC, "pod %s/%s has unsupported SCC %s. Please add the SCC level to "+
 			"--scc-support cmd line argument in kubeturbo deployment yaml to allow execution", pod.Namespace, pod.Name, podScc)
 	}
 
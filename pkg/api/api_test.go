@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubeturbo "github.com/turbonomic/kubeturbo/pkg"
+	"github.com/turbonomic/kubeturbo/pkg/discovery"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+type fakeTarget struct {
+	snapshot      *discovery.DiscoverySnapshot
+	rediscovery   *kubeturbo.Rediscovery
+	rediscoverErr error
+	timeouts      []time.Duration
+}
+
+func (t *fakeTarget) DiscoverySnapshot() *discovery.DiscoverySnapshot {
+	return t.snapshot
+}
+
+func (t *fakeTarget) Rediscover(timeout time.Duration) (*kubeturbo.Rediscovery, error) {
+	t.timeouts = append(t.timeouts, timeout)
+	return t.rediscovery, t.rediscoverErr
+}
+
+type fakeShardWorker struct{}
+
+func (w fakeShardWorker) DiscoverShard(nodeNames []string) (*worker.DiscoveryResult, error) {
+	if nodeNames[0] == "down" {
+		return nil, fmt.Errorf("failed to process cluster")
+	}
+	podClusterID := "cluster-1/default/pod-1"
+	return &worker.DiscoveryResult{
+		EntityDTOs: []*proto.EntityDTO{
+			{Id: strPtr(nodeNames[0]), EntityType: proto.EntityDTO_VIRTUAL_MACHINE.Enum()},
+		},
+		ContainerSpecMetrics: []*repository.ContainerSpecMetrics{{
+			ContainerSpecId: "spec-1",
+			ContainerMetrics: map[metrics.ResourceType]*repository.ContainerMetrics{
+				metrics.CPU: {Capacity: []float64{2}, Used: []metrics.Point{{Value: 1, Timestamp: 10}}},
+			},
+		}},
+		PodEntitiesMap: map[string]*repository.KubePod{
+			podClusterID: {
+				KubeEntity:   repository.NewKubeEntity(metrics.PodType, "cluster-1", "default", "pod-1", "uid-1"),
+				Pod:          &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default"}},
+				PodClusterId: podClusterID,
+				ContainerApps: map[string]*proto.EntityDTO{
+					"container-1": {Id: strPtr("app-1"), EntityType: proto.EntityDTO_APPLICATION_COMPONENT.Enum()},
+				},
+			},
+		},
+		NotReadyNodes: []string{nodeNames[0]},
+		SuccessCount:  1,
+		ErrorCount:    1,
+	}, nil
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// testCA issues the certificates of the mutual TLS of the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubeturbo-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	ca := &testCA{cert: cert, key: key, dir: t.TempDir()}
+	assert.NoError(t, os.WriteFile(filepath.Join(ca.dir, "ca.crt"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return ca
+}
+
+// issue issues the certificate of the given name for both the servers and the clients.
+func (ca *testCA) issue(t *testing.T, name string) TLSFiles {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	files := TLSFiles{
+		CertFile: filepath.Join(ca.dir, name+".crt"),
+		KeyFile:  filepath.Join(ca.dir, name+".key"),
+		CAFile:   filepath.Join(ca.dir, "ca.crt"),
+	}
+	assert.NoError(t, os.WriteFile(files.CertFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(files.KeyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return files
+}
+
+// startServer serves the given server over gRPC with mutual TLS, and returns the client of the given certificate.
+func startServer(t *testing.T, server *Server, ca *testCA, clientFiles TLSFiles) *Client {
+	serverTLS, err := ca.issue(t, "server").ServerTLSConfig()
+	assert.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	grpcServer := server.NewGRPCServer(serverTLS)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+	clientTLS, err := clientFiles.ClientTLSConfig()
+	assert.NoError(t, err)
+	client, err := NewClient("https://"+listener.Addr().String()+"/", clientTLS, 10*time.Second)
+	assert.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newDiscoveryResponse() *proto.DiscoveryResponse {
+	node, pod := proto.EntityDTO_VIRTUAL_MACHINE, proto.EntityDTO_CONTAINER_POD
+	description := "the kubelet of node-2 is down"
+	return &proto.DiscoveryResponse{
+		EntityDTO: []*proto.EntityDTO{
+			{EntityType: &node, Id: newString("node-1")},
+			{EntityType: &pod, Id: newString("pod-1")},
+			{EntityType: &pod, Id: newString("pod-2")},
+		},
+		DiscoveredGroup: []*proto.GroupDTO{{}},
+		ErrorDTO:        []*proto.ErrorDTO{{Description: &description}},
+	}
+}
+
+func newString(s string) *string {
+	return &s
+}
+
+func TestServerDiscovery(t *testing.T) {
+	ca := newTestCA(t)
+	target := &fakeTarget{snapshot: discovery.NewDiscoverySnapshot("")}
+	client := startServer(t, NewServer().WithTarget("cluster-1", target), ca, ca.issue(t, "client"))
+
+	// No discovery yet
+	_, err := client.ListEntities(context.TODO(), &ListEntitiesRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetDiscoverySnapshot(context.TODO(), &GetDiscoverySnapshotRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	target.snapshot.Record("cluster-1", time.Now(), newDiscoveryResponse())
+	discovered, err := client.GetDiscoverySnapshot(context.TODO(), &GetDiscoverySnapshotRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster-1", discovered.Target)
+	assert.Equal(t, int32(3), discovered.Entities)
+	assert.Equal(t, int32(1), discovered.Groups)
+	assert.Equal(t, []string{"the kubelet of node-2 is down"}, discovered.Errors)
+
+	entities, err := client.ListEntities(context.TODO(), &ListEntitiesRequest{Target: "cluster-1"})
+	assert.NoError(t, err)
+	assert.Len(t, entities.Entities, 3)
+	entities, err = client.ListEntities(context.TODO(), &ListEntitiesRequest{EntityType: "CONTAINER_POD"})
+	assert.NoError(t, err)
+	if assert.Len(t, entities.Entities, 2) {
+		assert.Equal(t, "pod-1", entities.Entities[0].GetId())
+		assert.Equal(t, proto.EntityDTO_CONTAINER_POD, entities.Entities[1].GetEntityType())
+	}
+
+	_, err = client.ListEntities(context.TODO(), &ListEntitiesRequest{EntityType: "POD"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.GetDiscoverySnapshot(context.TODO(), &GetDiscoverySnapshotRequest{Target: "cluster-2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.DiscoverShard(context.TODO(), &DiscoverShardRequest{Nodes: []string{"node-1"}})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServerRediscover(t *testing.T) {
+	ca := newTestCA(t)
+	discoveryTime := time.Unix(1700000000, 0)
+	target := &fakeTarget{rediscovery: &kubeturbo.Rediscovery{
+		UUIDs:         []string{"uuid-1"},
+		DiscoveryTime: discoveryTime,
+		Entities:      map[string]int{"VIRTUAL_MACHINE": 2, "CONTAINER_POD": 5},
+	}}
+	client := startServer(t, NewServer().WithTarget("cluster-1", target), ca, ca.issue(t, "client"))
+
+	rediscovered, err := client.Rediscover(context.TODO(), &RediscoverRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "cluster-1", rediscovered.Target)
+	assert.Equal(t, []string{"uuid-1"}, rediscovered.Uuids)
+	assert.Equal(t, discoveryTime.Unix(), rediscovered.DiscoveryTimeSeconds)
+	if assert.Len(t, rediscovered.Entities, 2) {
+		assert.Equal(t, "CONTAINER_POD", rediscovered.Entities[0].EntityType)
+		assert.Equal(t, int32(5), rediscovered.Entities[0].Count)
+		assert.Equal(t, "VIRTUAL_MACHINE", rediscovered.Entities[1].EntityType)
+		assert.Equal(t, int32(2), rediscovered.Entities[1].Count)
+	}
+
+	// The full discovery is waited for within the deadline of the call, which defaults to the timeout of the client
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+	defer cancel()
+	target.rediscovery.Entities, target.rediscoverErr = nil, kubeturbo.ErrRediscoveryTimeout
+	_, err = client.Rediscover(ctx, &RediscoverRequest{Target: "cluster-1"})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Contains(t, err.Error(), "uuid-1")
+	if assert.Len(t, target.timeouts, 2) {
+		assert.True(t, target.timeouts[0] > 0 && target.timeouts[0] <= 10*time.Second, target.timeouts[0])
+		assert.True(t, target.timeouts[1] > 10*time.Second && target.timeouts[1] <= time.Minute, target.timeouts[1])
+	}
+
+	target.rediscovery, target.rediscoverErr = nil, kubeturbo.ErrRediscoveryUnavailable
+	_, err = client.Rediscover(context.TODO(), &RediscoverRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	target.rediscoverErr = fmt.Errorf("the Turbo API is down")
+	_, err = client.Rediscover(context.TODO(), &RediscoverRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = client.Rediscover(context.TODO(), &RediscoverRequest{Target: "cluster-2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServerDiscoverShard(t *testing.T) {
+	ca := newTestCA(t)
+	server := NewServer().WithShardWorker(fakeShardWorker{})
+	client := startServer(t, server, ca, ca.issue(t, "coordinator"))
+
+	response, err := client.DiscoverShard(context.TODO(), &DiscoverShardRequest{Nodes: []string{"node-1"}})
+	assert.NoError(t, err)
+	result, err := DecodeDiscoverShardResponse(response)
+	assert.NoError(t, err)
+	if assert.Len(t, result.EntityDTOs, 1) {
+		assert.Equal(t, "node-1", result.EntityDTOs[0].GetId())
+	}
+	if assert.Len(t, result.ContainerSpecMetrics, 1) {
+		cpu := result.ContainerSpecMetrics[0].ContainerMetrics[metrics.CPU]
+		assert.Equal(t, []float64{2}, cpu.Capacity)
+		assert.Equal(t, []metrics.Point{{Value: 1, Timestamp: 10}}, cpu.Used)
+	}
+	pod := result.PodEntitiesMap["cluster-1/default/pod-1"]
+	if assert.NotNil(t, pod) {
+		assert.Equal(t, "uid-1", pod.UID)
+		assert.Equal(t, "pod-1", pod.Pod.Name)
+		assert.Equal(t, "app-1", pod.ContainerApps["container-1"].GetId())
+	}
+	assert.Equal(t, []string{"node-1"}, result.NotReadyNodes)
+	assert.Equal(t, 1, result.SuccessCount)
+	assert.Equal(t, 1, result.ErrorCount)
+
+	_, err = client.DiscoverShard(context.TODO(), &DiscoverShardRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.DiscoverShard(context.TODO(), &DiscoverShardRequest{Nodes: []string{"down"}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, err.Error(), "failed to process cluster")
+	_, err = client.GetDiscoverySnapshot(context.TODO(), &GetDiscoverySnapshotRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	// The client certificate issued by another CA is rejected by the server
+	otherCA := newTestCA(t)
+	otherFiles := otherCA.issue(t, "client")
+	otherFiles.CAFile = filepath.Join(ca.dir, "ca.crt")
+	client := startServer(t, NewServer(), ca, otherFiles)
+	_, err := client.GetDiscoverySnapshot(context.TODO(), &GetDiscoverySnapshotRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = TLSFiles{CertFile: "missing.crt", KeyFile: "missing.key", CAFile: "ca.crt"}.ServerTLSConfig()
+	assert.Error(t, err)
+}
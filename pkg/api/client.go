@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Client calls the API served at a base URL, e.g. https://kubeturbo-shard-0.kubeturbo-shards:9443.
+type Client struct {
+	KubeturboClient
+	baseURL string
+	conn    *grpc.ClientConn
+}
+
+// NewClient creates the client of the API served at the given base URL, with the given mutual TLS configuration.
+// The calls without a deadline are bounded by the given timeout. The connection is established on the first call.
+func NewClient(baseURL string, tlsConfig *tls.Config, timeout time.Duration) (*Client, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	address := strings.TrimPrefix(baseURL, "https://")
+	if strings.Contains(address, "/") {
+		return nil, fmt.Errorf("invalid base URL %q of the internal API, expected https://<host>:<port>", baseURL)
+	}
+	conn, err := grpc.Dial(address,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name), grpc.MaxCallRecvMsgSize(maxMessageBytes)),
+		grpc.WithUnaryInterceptor(timeoutInterceptor(timeout)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the client of the internal API at %s: %v", baseURL, err)
+	}
+	return &Client{
+		KubeturboClient: NewKubeturboClient(conn),
+		baseURL:         baseURL,
+		conn:            conn,
+	}, nil
+}
+
+// BaseURL returns the base URL of the API called by the client.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// timeoutInterceptor bounds the calls without a deadline by the given timeout.
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, request, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, found := ctx.Deadline(); !found && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, request, reply, cc, opts...)
+	}
+}
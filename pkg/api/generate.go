@@ -0,0 +1,5 @@
+package api
+
+// The messages and the service of the API are generated from kubeturbo.proto, with the directory of CommonDTO.proto
+// of turbo-go-sdk in TURBO_GO_SDK_PROTO.
+//go:generate protoc -I . -I ${TURBO_GO_SDK_PROTO} --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative kubeturbo.proto
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	// The compressor of the large responses of the shard workers
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+
+	kubeturbo "github.com/turbonomic/kubeturbo/pkg"
+	"github.com/turbonomic/kubeturbo/pkg/discovery"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+const (
+	// The max age of the discovery of the requests without max age
+	anyAge = 100 * 365 * 24 * time.Hour
+	// The max size of a message, large enough for the entities of a large cluster
+	maxMessageBytes = 256 * 1024 * 1024
+	// The max time to wait for the full discovery of a rediscovery, shortened by the deadline of the call
+	maxRediscoverTimeout = 10 * time.Minute
+)
+
+// Target is a cluster discovered by kubeturbo, e.g. the K8sTAPService of the cluster.
+type Target interface {
+	// DiscoverySnapshot returns the snapshot of the last full discovery of the cluster.
+	DiscoverySnapshot() *discovery.DiscoverySnapshot
+	// Rediscover asks the Turbo server to rediscover the cluster and waits for at most the timeout for the full
+	// discovery the server then requests.
+	Rediscover(timeout time.Duration) (*kubeturbo.Rediscovery, error)
+}
+
+// ShardWorker discovers the nodes of the shards assigned by the coordinator, e.g. the K8sDiscoveryClient of a shard
+// worker.
+type ShardWorker interface {
+	DiscoverShard(nodeNames []string) (*worker.DiscoveryResult, error)
+}
+
+// Server serves the API for the clusters and the shard worker it is given. The methods of the API without them are
+// unimplemented, e.g. the discovery of a cluster on a shard worker.
+type Server struct {
+	UnimplementedKubeturboServer
+	targets     map[string]Target
+	shardWorker ShardWorker
+}
+
+func NewServer() *Server {
+	return &Server{
+		targets: make(map[string]Target),
+	}
+}
+
+// WithTarget serves the discovery of the cluster of the given target identifier.
+func (s *Server) WithTarget(targetID string, target Target) *Server {
+	s.targets[targetID] = target
+	return s
+}
+
+// WithShardWorker serves the discovery of the shards of nodes with the given shard worker.
+func (s *Server) WithShardWorker(shardWorker ShardWorker) *Server {
+	s.shardWorker = shardWorker
+	return s
+}
+
+// NewGRPCServer returns the gRPC server of the API with the given TLS configuration, e.g. the mutual TLS of
+// TLSFiles.ServerTLSConfig.
+func (s *Server) NewGRPCServer(tlsConfig *tls.Config) *grpc.Server {
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.MaxRecvMsgSize(maxMessageBytes), grpc.MaxSendMsgSize(maxMessageBytes))
+	RegisterKubeturboServer(grpcServer, s)
+	return grpcServer
+}
+
+// target returns the cluster of the given target identifier, which may be empty with a single cluster.
+func (s *Server) target(targetID string) (string, Target, error) {
+	if len(s.targets) == 0 {
+		return "", nil, status.Errorf(codes.Unimplemented, "no cluster is discovered by this kubeturbo")
+	}
+	if targetID == "" && len(s.targets) == 1 {
+		for id, target := range s.targets {
+			return id, target, nil
+		}
+	}
+	target, found := s.targets[targetID]
+	if !found {
+		return "", nil, status.Errorf(codes.NotFound, "unknown target %q", targetID)
+	}
+	return targetID, target, nil
+}
+
+// snapshot returns a copy of the last full discovery of the given target if it is younger than the given max age in
+// seconds, any age if not positive.
+func (s *Server) snapshot(targetID string, maxAgeSeconds int64) (string, *proto.DiscoveryResponse,
+	time.Duration, error) {
+	targetID, target, err := s.target(targetID)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	maxAge := anyAge
+	if maxAgeSeconds > 0 {
+		maxAge = time.Duration(maxAgeSeconds) * time.Second
+	}
+	snapshot, age := target.DiscoverySnapshot().Clone(targetID, maxAge)
+	if snapshot == nil {
+		return "", nil, 0, status.Errorf(codes.NotFound, "no full discovery of target %s within the max age",
+			targetID)
+	}
+	return targetID, snapshot, age, nil
+}
+
+func (s *Server) GetDiscoverySnapshot(ctx context.Context,
+	request *GetDiscoverySnapshotRequest) (*GetDiscoverySnapshotResponse, error) {
+	targetID, snapshot, age, err := s.snapshot(request.Target, 0)
+	if err != nil {
+		return nil, err
+	}
+	response := &GetDiscoverySnapshotResponse{
+		Target:     targetID,
+		Entities:   int32(len(snapshot.GetEntityDTO())),
+		Groups:     int32(len(snapshot.GetDiscoveredGroup())),
+		AgeSeconds: int64(age.Seconds()),
+	}
+	for _, errorDTO := range snapshot.GetErrorDTO() {
+		response.Errors = append(response.Errors, errorDTO.GetDescription())
+	}
+	return response, nil
+}
+
+func (s *Server) Rediscover(ctx context.Context, request *RediscoverRequest) (*RediscoverResponse, error) {
+	targetID, target, err := s.target(request.Target)
+	if err != nil {
+		return nil, err
+	}
+	timeout := maxRediscoverTimeout
+	if deadline, found := ctx.Deadline(); found && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	glog.V(2).Infof("Requesting the rediscovery of target %s through the internal API.", targetID)
+	rediscovery, err := target.Rediscover(timeout)
+	if errors.Is(err, kubeturbo.ErrRediscoveryUnavailable) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if errors.Is(err, kubeturbo.ErrRediscoveryTimeout) {
+		return nil, status.Errorf(codes.DeadlineExceeded, "target %s (%v): %v within %v", targetID,
+			rediscovery.UUIDs, err, timeout)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to rediscover target %s: %v", targetID, err)
+	}
+	response := &RediscoverResponse{
+		Target:               targetID,
+		Uuids:                rediscovery.UUIDs,
+		DiscoveryTimeSeconds: rediscovery.DiscoveryTime.Unix(),
+	}
+	for entityType, count := range rediscovery.Entities {
+		response.Entities = append(response.Entities, &EntityCount{EntityType: entityType, Count: int32(count)})
+	}
+	sort.Slice(response.Entities, func(i, j int) bool {
+		return response.Entities[i].EntityType < response.Entities[j].EntityType
+	})
+	return response, nil
+}
+
+func (s *Server) ListEntities(ctx context.Context, request *ListEntitiesRequest) (*ListEntitiesResponse, error) {
+	var entityType proto.EntityDTO_EntityType
+	if request.EntityType != "" {
+		value, found := proto.EntityDTO_EntityType_value[request.EntityType]
+		if !found {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown entity type %q", request.EntityType)
+		}
+		entityType = proto.EntityDTO_EntityType(value)
+	}
+	targetID, snapshot, age, err := s.snapshot(request.Target, request.MaxAgeSeconds)
+	if err != nil {
+		return nil, err
+	}
+	response := &ListEntitiesResponse{
+		Target:     targetID,
+		AgeSeconds: int64(age.Seconds()),
+	}
+	for _, entity := range snapshot.GetEntityDTO() {
+		if request.EntityType == "" || entity.GetEntityType() == entityType {
+			response.Entities = append(response.Entities, entity)
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) DiscoverShard(ctx context.Context, request *DiscoverShardRequest) (*DiscoverShardResponse, error) {
+	if s.shardWorker == nil {
+		return nil, status.Errorf(codes.Unimplemented, "the shards are not discovered by this kubeturbo")
+	}
+	if len(request.Nodes) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "the nodes of the shard are required")
+	}
+	result, err := s.shardWorker.DiscoverShard(request.Nodes)
+	if err != nil {
+		glog.Errorf("Failed to discover the shard of %d nodes for the coordinator: %v", len(request.Nodes), err)
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+	response, err := EncodeDiscoverShardResponse(result)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return response, nil
+}
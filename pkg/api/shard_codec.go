@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	protobuf "google.golang.org/protobuf/proto"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+// ShardMetrics are the results of the discovery of a shard other than the entities, which the coordinator merges
+// into the discovery of the cluster, sent as JSON in DiscoverShardResponse.
+type ShardMetrics struct {
+	NamespaceMetrics      []*repository.NamespaceMetrics
+	EntityGroups          []*repository.EntityGroup
+	KubeControllers       []*repository.KubeController
+	ContainerSpecMetrics  []*repository.ContainerSpecMetrics
+	PodVolumeMetrics      []*repository.PodVolumeMetrics
+	Pods                  map[string]*shardPod
+	SidecarContainerSpecs []string
+	PodsWithVolumes       []string
+	NotReadyNodes         []string
+	MirrorPodUids         []string
+}
+
+// shardPod is a KubePod, whose embedded entity and pod have conflicting fields for JSON, and whose application DTOs
+// are encoded in the protobuf wire format.
+type shardPod struct {
+	Entity           *repository.KubeEntity
+	Pod              *v1.Pod
+	NodeCpuFrequency float64
+	ServiceId        string
+	PodClusterId     string
+	ContainerApps    map[string][]byte
+}
+
+// EncodeDiscoverShardResponse encodes the result of the discovery of a shard for the coordinator.
+func EncodeDiscoverShardResponse(result *worker.DiscoveryResult) (*DiscoverShardResponse, error) {
+	shardMetrics := &ShardMetrics{
+		NamespaceMetrics:      result.NamespaceMetrics,
+		EntityGroups:          result.EntityGroups,
+		KubeControllers:       result.KubeControllers,
+		ContainerSpecMetrics:  result.ContainerSpecMetrics,
+		PodVolumeMetrics:      result.PodVolumeMetrics,
+		Pods:                  make(map[string]*shardPod, len(result.PodEntitiesMap)),
+		SidecarContainerSpecs: result.SidecarContainerSpecs,
+		PodsWithVolumes:       result.PodsWithVolumes,
+		NotReadyNodes:         result.NotReadyNodes,
+		MirrorPodUids:         result.MirrorPodUids,
+	}
+	for podClusterID, kubePod := range result.PodEntitiesMap {
+		pod := &shardPod{
+			Entity:           kubePod.KubeEntity,
+			Pod:              kubePod.Pod,
+			NodeCpuFrequency: kubePod.NodeCpuFrequency,
+			ServiceId:        kubePod.ServiceId,
+			PodClusterId:     kubePod.PodClusterId,
+			ContainerApps:    make(map[string][]byte, len(kubePod.ContainerApps)),
+		}
+		for containerID, app := range kubePod.ContainerApps {
+			data, err := protobuf.Marshal(app)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode the application of container %s: %v", containerID, err)
+			}
+			pod.ContainerApps[containerID] = data
+		}
+		shardMetrics.Pods[podClusterID] = pod
+	}
+	data, err := json.Marshal(shardMetrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the metrics of the shard: %v", err)
+	}
+	return &DiscoverShardResponse{
+		Entities:       result.EntityDTOs,
+		Metrics:        data,
+		SucceededTasks: int32(result.SuccessCount),
+		FailedTasks:    int32(result.ErrorCount),
+	}, nil
+}
+
+// DecodeDiscoverShardResponse decodes the result of the discovery of a shard sent by a shard worker.
+func DecodeDiscoverShardResponse(response *DiscoverShardResponse) (*worker.DiscoveryResult, error) {
+	shardMetrics := &ShardMetrics{}
+	if err := json.Unmarshal(response.Metrics, shardMetrics); err != nil {
+		return nil, fmt.Errorf("failed to decode the metrics of the shard: %v", err)
+	}
+	result := &worker.DiscoveryResult{
+		EntityDTOs:            response.Entities,
+		NamespaceMetrics:      shardMetrics.NamespaceMetrics,
+		EntityGroups:          shardMetrics.EntityGroups,
+		KubeControllers:       shardMetrics.KubeControllers,
+		ContainerSpecMetrics:  shardMetrics.ContainerSpecMetrics,
+		PodVolumeMetrics:      shardMetrics.PodVolumeMetrics,
+		PodEntitiesMap:        make(map[string]*repository.KubePod, len(shardMetrics.Pods)),
+		SidecarContainerSpecs: shardMetrics.SidecarContainerSpecs,
+		PodsWithVolumes:       shardMetrics.PodsWithVolumes,
+		NotReadyNodes:         shardMetrics.NotReadyNodes,
+		MirrorPodUids:         shardMetrics.MirrorPodUids,
+		SuccessCount:          int(response.SucceededTasks),
+		ErrorCount:            int(response.FailedTasks),
+	}
+	for podClusterID, pod := range shardMetrics.Pods {
+		kubePod := &repository.KubePod{
+			KubeEntity:       pod.Entity,
+			Pod:              pod.Pod,
+			NodeCpuFrequency: pod.NodeCpuFrequency,
+			ServiceId:        pod.ServiceId,
+			PodClusterId:     pod.PodClusterId,
+			ContainerApps:    make(map[string]*proto.EntityDTO, len(pod.ContainerApps)),
+		}
+		for containerID, data := range pod.ContainerApps {
+			app := &proto.EntityDTO{}
+			if err := protobuf.Unmarshal(data, app); err != nil {
+				return nil, fmt.Errorf("failed to decode the application of container %s: %v", containerID, err)
+			}
+			kubePod.ContainerApps[containerID] = app
+		}
+		result.PodEntitiesMap[podClusterID] = kubePod
+	}
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSFiles are the PEM encoded files of the mutual TLS between the kubeturbo components, e.g. mounted from a Secret
+// issued by cert-manager. The certificate is presented by both the servers and the clients of the API, and the
+// certificate of the peer must be issued by the CA.
+type TLSFiles struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// ServerTLSConfig returns the configuration of the servers of the API, which require the clients to present a
+// certificate issued by the CA.
+func (f TLSFiles) ServerTLSConfig() (*tls.Config, error) {
+	cert, pool, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// ClientTLSConfig returns the configuration of the clients of the API, which verify the certificate of the servers
+// against the CA.
+func (f TLSFiles) ClientTLSConfig() (*tls.Config, error) {
+	cert, pool, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}, nil
+}
+
+func (f TLSFiles) load() (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+	if err != nil {
+		return cert, nil, fmt.Errorf("failed to load the certificate %s and the key %s: %v", f.CertFile, f.KeyFile, err)
+	}
+	pem, err := os.ReadFile(f.CAFile)
+	if err != nil {
+		return cert, nil, fmt.Errorf("failed to read the CA %s: %v", f.CAFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return cert, nil, fmt.Errorf("no valid PEM encoded certificate found in the CA %s", f.CAFile)
+	}
+	return cert, pool, nil
+}
@@ -0,0 +1,249 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// The kinds of the events of the audit log
+const (
+	// An action received from the server
+	KindActionReceived = "ActionReceived"
+	// The decision of kubeturbo not to execute an action, e.g. in the recommend mode or on a degraded discovery
+	KindActionDecision = "ActionDecision"
+	// A step of the execution of an action, as reported to the server
+	KindActionStep = "ActionStep"
+	// The outcome of an action, succeeded, refused or failed
+	KindActionOutcome = "ActionOutcome"
+	// The summary of a full discovery
+	KindDiscovery = "Discovery"
+)
+
+const (
+	// The sinks of the audit log
+	SinkFile      = "file"
+	SinkConfigMap = "configmap"
+
+	// How often the events recorded since the last flush are written to the sink
+	defaultFlushInterval = 10 * time.Second
+)
+
+// Event is an event of the audit log, written as a line of JSON.
+type Event struct {
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// The target identifier of the cluster
+	Target     string `json:"target,omitempty"`
+	ActionID   string `json:"actionId,omitempty"`
+	ActionType string `json:"actionType,omitempty"`
+	// The entity the action applies to, as <entity type> <namespace>/<display name>
+	Entity string `json:"entity,omitempty"`
+	// The result of an action or a discovery, e.g. succeeded, refused or failed
+	Result  string `json:"result,omitempty"`
+	Message string `json:"message,omitempty"`
+	// The figures of a discovery, e.g. the number of the entities
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Sink persists the events of the audit log across the restarts of kubeturbo.
+type Sink interface {
+	// Write appends the given events to the sink.
+	Write(events []Event) error
+	// Load returns the events of the sink, from the oldest to the latest.
+	Load() ([]Event, error)
+}
+
+// Log is the audit log of the actions and the discoveries. It keeps the latest events in memory to be queried,
+// and writes them to its sink in the background. It is safe for concurrent use. A nil Log records nothing.
+type Log struct {
+	sink      Sink
+	maxEvents int
+
+	lock sync.Mutex
+	// The latest events, from the oldest to the latest
+	events []Event
+	// The events recorded since the last flush
+	pending []Event
+}
+
+// NewLog creates the audit log keeping the given number of the latest events in memory, loaded from the sink.
+func NewLog(sink Sink, maxEvents int) *Log {
+	l := &Log{
+		sink:      sink,
+		maxEvents: maxEvents,
+	}
+	events, err := sink.Load()
+	if err != nil {
+		glog.Warningf("Failed to load the audit log: %v", err)
+	}
+	l.events = latest(events, maxEvents)
+	glog.V(2).Infof("Loaded %d events of the audit log.", len(l.events))
+	return l
+}
+
+// Record records the event, at the current time if the event has no time.
+func (l *Log) Record(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = latest(append(l.events, event), l.maxEvents)
+	l.pending = latest(append(l.pending, event), l.maxEvents)
+}
+
+// Run writes the recorded events to the sink periodically until the stop channel is closed, and once more then.
+func (l *Log) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			l.flush()
+			return
+		}
+		l.flush()
+	}
+}
+
+// Flush writes the events recorded since the last flush to the sink, and keeps them for the next flush on failure.
+func (l *Log) Flush() error {
+	l.lock.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.lock.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := l.sink.Write(pending); err != nil {
+		l.lock.Lock()
+		l.pending = latest(append(pending, l.pending...), l.maxEvents)
+		l.lock.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (l *Log) flush() {
+	if err := l.Flush(); err != nil {
+		glog.Errorf("Failed to write the audit log: %v", err)
+	}
+}
+
+// Query selects the events of the audit log.
+type Query struct {
+	// The kind, the target and the action of the events, any if empty
+	Kind     string
+	Target   string
+	ActionID string
+	// The events since the given time, any if zero
+	Since time.Time
+	// The max number of the latest events, all if not positive
+	Limit int
+}
+
+// Query returns the events selected by the query, from the oldest to the latest.
+func (l *Log) Query(q Query) []Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var events []Event
+	for _, event := range l.events {
+		if (q.Kind != "" && event.Kind != q.Kind) || (q.Target != "" && event.Target != q.Target) ||
+			(q.ActionID != "" && event.ActionID != q.ActionID) || event.Time.Before(q.Since) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return latest(events, q.Limit)
+}
+
+// Handler serves the events of the audit log as lines of JSON, selected by the kind, target, action, since
+// (RFC 3339 time or duration back from now) and limit query parameters.
+func (l *Log) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q, err := parseQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		for _, event := range l.Query(q) {
+			if err := encoder.Encode(event); err != nil {
+				glog.Errorf("Failed to write the audit log: %v", err)
+				return
+			}
+		}
+	})
+}
+
+func parseQuery(r *http.Request) (Query, error) {
+	params := r.URL.Query()
+	q := Query{
+		Kind:     params.Get("kind"),
+		Target:   params.Get("target"),
+		ActionID: params.Get("action"),
+	}
+	if since := params.Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			q.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil {
+			q.Since = time.Now().Add(-d)
+		} else {
+			return q, fmt.Errorf("invalid since %q, should be an RFC 3339 time or a duration", since)
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return q, fmt.Errorf("invalid limit %q", limit)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// latest returns the given number of the latest events, all if not positive.
+func latest(events []Event, n int) []Event {
+	if n > 0 && len(events) > n {
+		return append([]Event(nil), events[len(events)-n:]...)
+	}
+	return events
+}
+
+// Recorder records the events of a target in the audit log. A nil Recorder records nothing.
+type Recorder struct {
+	log    *Log
+	target string
+}
+
+// Recorder returns the recorder of the events of the given target, nil if the log is nil.
+func (l *Log) Recorder(target string) *Recorder {
+	if l == nil {
+		return nil
+	}
+	return &Recorder{log: l, target: target}
+}
+
+// Record records the event of the target.
+func (r *Recorder) Record(event Event) {
+	if r == nil {
+		return
+	}
+	event.Target = r.target
+	r.log.Record(event)
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	sink := NewFileSink(path, 200)
+	events, err := sink.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, sink.Write([]Event{{Kind: KindActionReceived, ActionID: fmt.Sprint(i)}}))
+	}
+	// The file is rotated once larger than the max size, and both files are loaded
+	_, err = os.Stat(path + ".1")
+	assert.NoError(t, err)
+	events, err = sink.Load()
+	assert.NoError(t, err)
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.ActionID)
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3"}, ids)
+
+	// A truncated line is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"kind":"Action`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	events, err = sink.Load()
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+}
+
+func TestLog(t *testing.T) {
+	sink := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"), DefaultMaxFileBytes)
+	assert.NoError(t, sink.Write([]Event{{Kind: KindDiscovery, Target: "prod", Result: "succeeded"}}))
+
+	log := NewLog(sink, 3)
+	recorder := log.Recorder("prod")
+	recorder.Record(Event{Kind: KindActionReceived, ActionID: "1"})
+	recorder.Record(Event{Kind: KindActionOutcome, ActionID: "1", Result: "succeeded"})
+	log.Recorder("dev").Record(Event{Kind: KindActionReceived, ActionID: "2"})
+
+	// Only the latest events are kept in memory
+	events := log.Query(Query{})
+	assert.Len(t, events, 3)
+	assert.Equal(t, "1", events[0].ActionID)
+	assert.False(t, events[0].Time.IsZero())
+
+	assert.Len(t, log.Query(Query{Target: "prod"}), 2)
+	assert.Len(t, log.Query(Query{ActionID: "1", Kind: KindActionOutcome}), 1)
+	assert.Len(t, log.Query(Query{Limit: 1}), 1)
+	assert.Empty(t, log.Query(Query{Since: time.Now().Add(time.Hour)}))
+
+	// The recorded events are loaded from the sink once flushed
+	assert.NoError(t, log.Flush())
+	events, err := sink.Load()
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+	assert.Len(t, NewLog(sink, 10).Query(Query{}), 4)
+
+	// The nil log and recorder record nothing
+	var nilLog *Log
+	nilLog.Record(Event{Kind: KindDiscovery})
+	nilLog.Recorder("prod").Record(Event{Kind: KindDiscovery})
+}
+
+func TestLogHandler(t *testing.T) {
+	log := NewLog(NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"), DefaultMaxFileBytes), 10)
+	log.Record(Event{Kind: KindActionReceived, ActionID: "1", Time: time.Now().Add(-time.Hour)})
+	log.Record(Event{Kind: KindActionOutcome, ActionID: "1", Result: "failed"})
+	handler := log.Handler()
+
+	request := httptest.NewRequest(http.MethodGet, "/debug/audit?since=10m", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var events []Event
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		var event Event
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	assert.Len(t, events, 1)
+	assert.Equal(t, "failed", events[0].Result)
+
+	for _, query := range []string{"since=yesterday", "limit=all"} {
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/audit?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+	}
+}
+
+func TestConfigMapSinkEncode(t *testing.T) {
+	sink := NewConfigMapSink(nil, "turbo", "kubeturbo-audit-log", 2)
+	events := []Event{{ActionID: "1"}, {ActionID: "2"}, {ActionID: "3"}}
+	decoded := decodeEvents(sink.encode(events))
+	assert.Len(t, decoded, 2)
+	assert.Equal(t, "2", decoded[0].ActionID)
+}
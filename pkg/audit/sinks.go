@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The key of the events in the ConfigMap sink
+	ConfigMapKey = "audit.jsonl"
+	// The size above which the file of the file sink is rotated
+	DefaultMaxFileBytes = 10 * 1024 * 1024
+	// The max size of the events in the ConfigMap sink, below the 1MiB limit of a ConfigMap
+	maxConfigMapBytes = 900 * 1024
+)
+
+// FileSink appends the events as lines of JSON to a file, e.g. on a persistent volume. The file is rotated to
+// <path>.1 once larger than the max size, replacing the previous rotated file.
+type FileSink struct {
+	path     string
+	maxBytes int64
+}
+
+func NewFileSink(path string, maxBytes int64) *FileSink {
+	return &FileSink{
+		path:     path,
+		maxBytes: maxBytes,
+	}
+}
+
+func (s *FileSink) Write(events []Event) error {
+	if info, err := os.Stat(s.path); err == nil && s.maxBytes > 0 && info.Size() >= s.maxBytes {
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(encodeEvents(events))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *FileSink) Load() ([]Event, error) {
+	var events []Event
+	for _, path := range []string{s.path + ".1", s.path} {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, decodeEvents(data)...)
+	}
+	return events, nil
+}
+
+// ConfigMapSink keeps the latest events as lines of JSON under the ConfigMapKey key of a ConfigMap, as a ring
+// buffer of at most the given number of events, which is created if missing.
+type ConfigMapSink struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	maxEvents int
+}
+
+func NewConfigMapSink(client kubernetes.Interface, namespace, name string, maxEvents int) *ConfigMapSink {
+	return &ConfigMapSink{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		maxEvents: maxEvents,
+	}
+}
+
+func (s *ConfigMapSink) Write(events []Event) error {
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := configMaps.Get(context.TODO(), s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		configMap = &api.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: s.namespace, Name: s.name}}
+		configMap.Data = map[string]string{ConfigMapKey: string(s.encode(events))}
+		_, err = configMaps.Create(context.TODO(), configMap, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	all := append(decodeEvents([]byte(configMap.Data[ConfigMapKey])), events...)
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[ConfigMapKey] = string(s.encode(all))
+	_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *ConfigMapSink) Load() ([]Event, error) {
+	configMap, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeEvents([]byte(configMap.Data[ConfigMapKey])), nil
+}
+
+// encode encodes the latest events which fit in the ConfigMap.
+func (s *ConfigMapSink) encode(events []Event) []byte {
+	events = latest(events, s.maxEvents)
+	data := encodeEvents(events)
+	for len(data) > maxConfigMapBytes && len(events) > 0 {
+		events = events[len(events)/10+1:]
+		data = encodeEvents(events)
+	}
+	return data
+}
+
+func encodeEvents(events []Event) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			glog.Errorf("Failed to encode the audit event %+v: %v", event, err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// decodeEvents decodes the lines of JSON, skipping the malformed ones, e.g. a line truncated by a crash.
+func decodeEvents(data []byte) []Event {
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			glog.V(3).Infof("Skip the malformed audit event %q: %v", line, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
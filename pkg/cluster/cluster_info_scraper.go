@@ -66,6 +66,7 @@ type ClusterScraperInterface interface {
 	GetAllNodes() ([]*api.Node, error)
 	GetNamespaces() ([]*api.Namespace, error)
 	GetNamespaceQuotas() (map[string][]*api.ResourceQuota, error)
+	GetNamespaceLimitRanges() (map[string][]*api.LimitRange, error)
 	GetAllPods() ([]*api.Pod, error)
 	GetAllEndpoints() ([]*api.Endpoints, error)
 	GetAllServices() ([]*api.Service, error)
@@ -74,12 +75,14 @@ type ClusterScraperInterface interface {
 	GetAllPVCs() ([]*api.PersistentVolumeClaim, error)
 	GetResources(resource schema.GroupVersionResource) ([]unstructured.Unstructured, error)
 	GetResourcesPaginated(resource schema.GroupVersionResource, itemsPerPage int) ([]unstructured.Unstructured, error)
+	GetOwnerReferences(namespace string, owner metav1.OwnerReference) ([]metav1.OwnerReference, error)
 	GetMachineSetToNodesMap(nodes []*api.Node) map[string][]*api.Node
 	GetAllTurboSLOScalings() ([]policyv1alpha1.SLOHorizontalScale, error)
 	GetAllTurboCVSScalings() ([]policyv1alpha1.ContainerVerticalScale, error)
 	GetAllTurboPolicyBindings() ([]policyv1alpha1.PolicyBinding, error)
 	GetAllGitOpsConfigurations() ([]gitopsv1alpha1.GitOps, error)
 	UpdateGitOpsConfigCache()
+	ControllerGroupVersions() commonutil.ControllerGroupVersions
 }
 
 type ClusterScraper struct {
@@ -93,6 +96,13 @@ type ClusterScraper struct {
 	cache                   turbostore.ITurboCache
 	GitOpsConfigCache       map[string][]*gitopsv1alpha1.Configuration
 	GitOpsConfigCacheLock   sync.Mutex
+	// The scraper which also receives the GitOps configurations discovered by this scraper, e.g. the scraper
+	// used by actions when discovery lists resources from a different API server.
+	gitOpsConfigCacheMirror *ClusterScraper
+	// The local cache of the resources listed by discovery, nil if the resources are listed from the API server
+	informerCache *InformerCache
+	// The group versions of the deployments and the replicasets of the cluster
+	controllerGVs commonutil.ControllerGroupVersions
 }
 
 func NewClusterScraper(restConfig *restclient.Config, kclient *client.Clientset, dynamicClient dynamic.Interface,
@@ -110,9 +120,35 @@ func NewClusterScraper(restConfig *restclient.Config, kclient *client.Clientset,
 		// defaultCacheTTL.
 		cache:             turbostore.NewTurboCache(defaultCacheTTL).Cache,
 		GitOpsConfigCache: make(map[string][]*gitopsv1alpha1.Configuration),
+		controllerGVs:     commonutil.DefaultControllerGroupVersions(),
 	}
 }
 
+// WithControllerGroupVersions sets the group versions of the deployments and the replicasets discovered from the
+// cluster.
+func (s *ClusterScraper) WithControllerGroupVersions(controllerGVs commonutil.ControllerGroupVersions) *ClusterScraper {
+	s.controllerGVs = controllerGVs
+	return s
+}
+
+// ControllerGroupVersions returns the group versions of the deployments and the replicasets of the cluster.
+func (s *ClusterScraper) ControllerGroupVersions() commonutil.ControllerGroupVersions {
+	return s.controllerGVs
+}
+
+// StartInformerCache watches the pods, nodes, services and endpoints, and the resources listed through the dynamic
+// client, in the background until the given channel is closed, so that they are read from the local cache. The
+// watch errors are reported to watchErrorHandler.
+func (s *ClusterScraper) StartInformerCache(stopCh <-chan struct{}, watchErrorHandler func(resource string, err error)) {
+	s.informerCache = NewInformerCache(s.Clientset, s.DynamicClient, watchErrorHandler)
+	s.informerCache.Start(stopCh)
+}
+
+// InformerCache returns the local cache of the resources listed by discovery, nil if none.
+func (s *ClusterScraper) InformerCache() *InformerCache {
+	return s.informerCache
+}
+
 func (s *ClusterScraper) GetNamespaces() ([]*api.Namespace, error) {
 	namespaceList, err := s.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
@@ -157,7 +193,26 @@ func (s *ClusterScraper) GetNamespaceQuotas() (map[string][]*api.ResourceQuota,
 	return quotaMap, nil
 }
 
+// GetNamespaceLimitRanges returns a map of the namespaces to the limit ranges defined in them.
+func (s *ClusterScraper) GetNamespaceLimitRanges() (map[string][]*api.LimitRange, error) {
+	limitRangeList, err := s.CoreV1().LimitRanges(api.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	limitRangeMap := make(map[string][]*api.LimitRange)
+	for i := range limitRangeList.Items {
+		limitRange := &limitRangeList.Items[i]
+		limitRangeMap[limitRange.Namespace] = append(limitRangeMap[limitRange.Namespace], limitRange)
+	}
+	return limitRangeMap, nil
+}
+
 func (s *ClusterScraper) GetAllNodes() ([]*api.Node, error) {
+	if s.informerCache != nil {
+		if nodes, synced := s.informerCache.Nodes(); synced {
+			return nodes, nil
+		}
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 		FieldSelector: fieldSelectEverything,
@@ -251,6 +306,11 @@ func (s *ClusterScraper) GetNodes(opts metav1.ListOptions) ([]*api.Node, error)
 }
 
 func (s *ClusterScraper) GetAllPods() ([]*api.Pod, error) {
+	if s.informerCache != nil {
+		if pods, synced := s.informerCache.Pods(); synced {
+			return pods, nil
+		}
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 		FieldSelector: fieldSelectEverything,
@@ -272,6 +332,11 @@ func (s *ClusterScraper) GetPods(namespaces string, opts metav1.ListOptions) ([]
 }
 
 func (s *ClusterScraper) GetAllServices() ([]*api.Service, error) {
+	if s.informerCache != nil {
+		if services, synced := s.informerCache.Services(); synced {
+			return services, nil
+		}
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 	}
@@ -306,6 +371,11 @@ func (s *ClusterScraper) GetEndpoints(namespaces string, opts metav1.ListOptions
 }
 
 func (s *ClusterScraper) GetAllEndpoints() ([]*api.Endpoints, error) {
+	if s.informerCache != nil {
+		if endpoints, synced := s.informerCache.Endpoints(); synced {
+			return endpoints, nil
+		}
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 	}
@@ -313,15 +383,28 @@ func (s *ClusterScraper) GetAllEndpoints() ([]*api.Endpoints, error) {
 }
 
 func (s *ClusterScraper) GetResources(resource schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	if s.informerCache != nil {
+		if items, synced := s.informerCache.Resources(resource); synced {
+			return items, nil
+		}
+	}
 	list, err := s.DynamicClient.Resource(resource).Namespace(api.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
 	if err != nil || list == nil {
 		return nil, err
 	}
+	if s.informerCache != nil {
+		s.informerCache.WatchResource(resource)
+	}
 	return list.Items, err
 }
 
 func (s *ClusterScraper) GetResourcesPaginated(
 	resource schema.GroupVersionResource, itemsPerPage int) ([]unstructured.Unstructured, error) {
+	if s.informerCache != nil {
+		if items, synced := s.informerCache.Resources(resource); synced {
+			return items, nil
+		}
+	}
 	var items []unstructured.Unstructured
 	continueList := ""
 	// TODO: Is there a possibility of this loop never exiting?
@@ -338,6 +421,9 @@ func (s *ClusterScraper) GetResourcesPaginated(
 		}
 		continueList = listItems.GetContinue()
 	}
+	if s.informerCache != nil {
+		s.informerCache.WatchResource(resource)
+	}
 	return items, nil
 }
 
@@ -511,10 +597,7 @@ func (s *ClusterScraper) GetPodControllerInfo(
 			Resource: commonutil.ReplicationControllerResName}
 		canHaveGrandParent = true
 	case commonutil.KindReplicaSet:
-		res = schema.GroupVersionResource{
-			Group:    commonutil.K8sAPIReplicasetGV.Group,
-			Version:  commonutil.K8sAPIReplicasetGV.Version,
-			Resource: commonutil.ReplicaSetResName}
+		res = s.controllerGVs.ReplicaSetResource()
 		canHaveGrandParent = true
 	case commonutil.KindStatefulSet:
 		res = schema.GroupVersionResource{
@@ -619,11 +702,24 @@ func (s *ClusterScraper) UpdateGitOpsConfigCache() {
 		}
 	}
 	// Lock the "cache" to prevent access while it gets overwritten
+	s.setGitOpsConfigCache(gitOpsConfigCache)
+	if s.gitOpsConfigCacheMirror != nil {
+		s.gitOpsConfigCacheMirror.setGitOpsConfigCache(gitOpsConfigCache)
+	}
+}
+
+func (s *ClusterScraper) setGitOpsConfigCache(gitOpsConfigCache map[string][]*gitopsv1alpha1.Configuration) {
 	s.GitOpsConfigCacheLock.Lock()
 	defer s.GitOpsConfigCacheLock.Unlock()
 	s.GitOpsConfigCache = gitOpsConfigCache
 }
 
+// MirrorGitOpsConfigCacheTo makes the given scraper receive the GitOps configurations discovered by this scraper.
+// The cache is only read after being updated, so both scrapers share the same map.
+func (s *ClusterScraper) MirrorGitOpsConfigCacheTo(mirror *ClusterScraper) {
+	s.gitOpsConfigCacheMirror = mirror
+}
+
 // IsClusterAPIEnabled checks whether the machine API is enabled for this cluster.
 // This API can be installed or unsintalled anytime, so this function may return true or false accordingly at runtime.
 func (s *ClusterScraper) IsClusterAPIEnabled() bool {
@@ -0,0 +1,151 @@
+package cluster
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerCache keeps the pods, nodes, services and endpoints of the cluster, and the resources listed through the
+// dynamic client such as the workload controllers, in the local cache of shared informers. The discoveries read them
+// from the cache instead of listing them from the API server, so that the load of the API server does not grow with
+// the size of the cluster. A resource is read from the API server until its informer has synced.
+type InformerCache struct {
+	factory        informers.SharedInformerFactory
+	dynamicFactory dynamicinformer.DynamicSharedInformerFactory
+	pods           cache.SharedIndexInformer
+	nodes          cache.SharedIndexInformer
+	services       cache.SharedIndexInformer
+	endpoints      cache.SharedIndexInformer
+	stopCh         <-chan struct{}
+	// Reports the watch errors, as the cache may be stale until the informer relists the resource, nil if none
+	watchErrorHandler func(resource string, err error)
+	lock              sync.Mutex
+	// The informers of the resources listed through the dynamic client, watched once they are listed successfully
+	resources map[schema.GroupVersionResource]cache.SharedIndexInformer
+}
+
+func NewInformerCache(clientset client.Interface, dynamicClient dynamic.Interface,
+	watchErrorHandler func(resource string, err error)) *InformerCache {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	c := &InformerCache{
+		factory:           factory,
+		dynamicFactory:    dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0),
+		pods:              factory.Core().V1().Pods().Informer(),
+		nodes:             factory.Core().V1().Nodes().Informer(),
+		services:          factory.Core().V1().Services().Informer(),
+		endpoints:         factory.Core().V1().Endpoints().Informer(),
+		watchErrorHandler: watchErrorHandler,
+		resources:         make(map[schema.GroupVersionResource]cache.SharedIndexInformer),
+	}
+	c.setWatchErrorHandler(c.pods, "pods")
+	c.setWatchErrorHandler(c.nodes, "nodes")
+	c.setWatchErrorHandler(c.services, "services")
+	c.setWatchErrorHandler(c.endpoints, "endpoints")
+	return c
+}
+
+// Start watches the pods, nodes, services and endpoints in the background until the given channel is closed.
+func (c *InformerCache) Start(stopCh <-chan struct{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stopCh = stopCh
+	c.factory.Start(stopCh)
+}
+
+// PodInformer returns the informer of the pods, so that the pod watchers share the same watch.
+func (c *InformerCache) PodInformer() cache.SharedIndexInformer {
+	return c.pods
+}
+
+// Pods returns a copy of the cached pods, and false if the cache has not synced yet.
+func (c *InformerCache) Pods() ([]*api.Pod, bool) {
+	return listCached(c.pods, (*api.Pod).DeepCopy)
+}
+
+// Nodes returns a copy of the cached nodes, and false if the cache has not synced yet.
+func (c *InformerCache) Nodes() ([]*api.Node, bool) {
+	return listCached(c.nodes, (*api.Node).DeepCopy)
+}
+
+// Services returns a copy of the cached services, and false if the cache has not synced yet.
+func (c *InformerCache) Services() ([]*api.Service, bool) {
+	return listCached(c.services, (*api.Service).DeepCopy)
+}
+
+// Endpoints returns a copy of the cached endpoints, and false if the cache has not synced yet.
+func (c *InformerCache) Endpoints() ([]*api.Endpoints, bool) {
+	return listCached(c.endpoints, (*api.Endpoints).DeepCopy)
+}
+
+// Resources returns a copy of the cached resources of the given type, and false if the resource is not watched or
+// its cache has not synced yet.
+func (c *InformerCache) Resources(resource schema.GroupVersionResource) ([]unstructured.Unstructured, bool) {
+	c.lock.Lock()
+	informer, found := c.resources[resource]
+	c.lock.Unlock()
+	if !found {
+		return nil, false
+	}
+	objects, synced := listCached(informer, (*unstructured.Unstructured).DeepCopy)
+	if !synced {
+		return nil, false
+	}
+	items := make([]unstructured.Unstructured, len(objects))
+	for i, object := range objects {
+		items[i] = *object
+	}
+	return items, true
+}
+
+// WatchResource watches the resources of the given type in the background, if not watched yet. It is only called
+// once the resource has been listed successfully, so that the resources not served by the cluster, e.g. the
+// DeploymentConfigs outside of OpenShift, are not watched.
+func (c *InformerCache) WatchResource(resource schema.GroupVersionResource) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, found := c.resources[resource]; found || c.stopCh == nil {
+		return
+	}
+	glog.V(2).Infof("Watching %v in the informer cache.", resource)
+	informer := c.dynamicFactory.ForResource(resource).Informer()
+	c.setWatchErrorHandler(informer, resource.Resource)
+	c.resources[resource] = informer
+	c.dynamicFactory.Start(c.stopCh)
+}
+
+func (c *InformerCache) setWatchErrorHandler(informer cache.SharedIndexInformer, resource string) {
+	if c.watchErrorHandler == nil {
+		return
+	}
+	if err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(r, err)
+		c.watchErrorHandler(resource, err)
+	}); err != nil {
+		glog.Warningf("Failed to set the watch error handler of %s: %v", resource, err)
+	}
+}
+
+// listCached returns a copy of the objects in the cache of the given informer, and false if it has not synced yet.
+// The objects are copied as the callers may modify them, whereas the cached objects are shared.
+func listCached[T any](informer cache.SharedIndexInformer, deepCopy func(T) T) ([]T, bool) {
+	if !informer.HasSynced() {
+		return nil, false
+	}
+	cached := informer.GetStore().List()
+	objects := make([]T, 0, len(cached))
+	for _, object := range cached {
+		if typed, ok := object.(T); ok {
+			objects = append(objects, deepCopy(typed))
+		}
+	}
+	return objects, true
+}
@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestPodInformer(pods ...api.Pod) cache.SharedIndexInformer {
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &api.PodList{Items: pods}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}
+	return cache.NewSharedIndexInformer(listWatch, &api.Pod{}, 0, cache.Indexers{})
+}
+
+func TestListCached(t *testing.T) {
+	informer := newTestPodInformer(
+		api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"}},
+		api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-2", Namespace: "ns"}},
+	)
+	// The cache has not synced yet
+	_, synced := listCached(informer, (*api.Pod).DeepCopy)
+	assert.False(t, synced)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	assert.True(t, cache.WaitForCacheSync(stopCh, informer.HasSynced))
+
+	pods, synced := listCached(informer, (*api.Pod).DeepCopy)
+	assert.True(t, synced)
+	assert.Len(t, pods, 2)
+
+	// The cached pods are not modified through the copies
+	pods[0].Labels = map[string]string{"modified": "true"}
+	cached, _ := listCached(informer, (*api.Pod).DeepCopy)
+	for _, pod := range cached {
+		assert.Empty(t, pod.Labels)
+	}
+}
+
+func TestInformerCacheResourcesNotWatched(t *testing.T) {
+	c := &InformerCache{resources: make(map[schema.GroupVersionResource]cache.SharedIndexInformer)}
+	_, synced := c.Resources(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"})
+	assert.False(t, synced)
+
+	// Not watched before the cache is started
+	c.WatchResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"})
+	assert.Empty(t, c.resources)
+}
@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const apiResourcesKeyPrefix = "api-resources-"
+
+// GetOwnerReferences returns the owner references of the given owner of an object in the given namespace, e.g. of the
+// custom resource of an operator owning a Deployment. The owner may be of any kind served by the cluster, including
+// the custom resources, whose resource is looked up through the discovery API.
+func (s *ClusterScraper) GetOwnerReferences(namespace string, owner metav1.OwnerReference) ([]metav1.OwnerReference,
+	error) {
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api version of owner %s %s: %v", owner.Kind, owner.Name, err)
+	}
+	resource, err := s.apiResourceForKind(owner.APIVersion, owner.Kind)
+	if err != nil {
+		return nil, err
+	}
+	var client dynamic.ResourceInterface = s.DynamicClient.Resource(gv.WithResource(resource.Name))
+	if resource.Namespaced {
+		client = s.DynamicClient.Resource(gv.WithResource(resource.Name)).Namespace(namespace)
+	}
+	obj, err := client.Get(context.TODO(), owner.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owner %s %s/%s: %v", owner.Kind, namespace, owner.Name, err)
+	}
+	if obj.GetUID() != owner.UID {
+		return nil, fmt.Errorf("owner %s %s/%s has been replaced", owner.Kind, namespace, owner.Name)
+	}
+	return obj.GetOwnerReferences(), nil
+}
+
+// apiResourceForKind returns the resource of the given kind served in the given api version. The resources of each
+// api version are cached, and looked up again when the kind is not found, e.g. for the custom resource definitions
+// installed since.
+func (s *ClusterScraper) apiResourceForKind(apiVersion, kind string) (*metav1.APIResource, error) {
+	key := apiResourcesKeyPrefix + apiVersion
+	if cached, found := s.cache.Get(key); found {
+		if resource := findAPIResource(cached.([]metav1.APIResource), kind); resource != nil {
+			return resource, nil
+		}
+	}
+	resourceList, err := s.Clientset.Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the resources of %s: %v", apiVersion, err)
+	}
+	s.cache.Set(key, resourceList.APIResources, 0)
+	if resource := findAPIResource(resourceList.APIResources, kind); resource != nil {
+		return resource, nil
+	}
+	return nil, fmt.Errorf("kind %s is not served in %s", kind, apiVersion)
+}
+
+func findAPIResource(resources []metav1.APIResource, kind string) *metav1.APIResource {
+	for i := range resources {
+		// Skip the subresources, e.g. deployments/scale
+		if resources[i].Kind == kind && !strings.Contains(resources[i].Name, "/") {
+			return &resources[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func TestGetOwnerReferences(t *testing.T) {
+	discoveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body interface{}
+		switch r.URL.Path {
+		case "/apis/example.com/v1":
+			discoveries++
+			body = metav1.APIResourceList{
+				GroupVersion: "example.com/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "apps/status", Kind: "App", Namespaced: true},
+					{Name: "apps", Kind: "App", Namespaced: true},
+					{Name: "platforms", Kind: "Platform", Namespaced: false},
+				},
+			}
+		case "/apis/example.com/v1/namespaces/ns/apps/app":
+			body = map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "App",
+				"metadata": map[string]interface{}{
+					"name": "app", "namespace": "ns", "uid": "app-uid",
+					"ownerReferences": []interface{}{map[string]interface{}{
+						"apiVersion": "example.com/v1", "kind": "Platform", "name": "platform", "uid": "platform-uid",
+					}},
+				},
+			}
+		case "/apis/example.com/v1/platforms/platform":
+			body = map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Platform",
+				"metadata":   map[string]interface{}{"name": "platform", "uid": "platform-uid"},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+	restConfig := &restclient.Config{Host: server.URL}
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	assert.NoError(t, err)
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	assert.NoError(t, err)
+	scraper := NewClusterScraper(restConfig, kubeClient, dynamicClient, nil, nil, nil, "")
+
+	ownerRefs, err := scraper.GetOwnerReferences("ns",
+		metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "App", Name: "app", UID: "app-uid"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ownerRefs))
+	assert.Equal(t, "platform", ownerRefs[0].Name)
+
+	// The cluster scoped owner is looked up without the namespace, with the cached api resources
+	ownerRefs, err = scraper.GetOwnerReferences("ns",
+		metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Platform", Name: "platform", UID: "platform-uid"})
+	assert.NoError(t, err)
+	assert.Empty(t, ownerRefs)
+	assert.Equal(t, 1, discoveries)
+
+	// The replaced owner
+	_, err = scraper.GetOwnerReferences("ns",
+		metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "App", Name: "app", UID: "old-app-uid"})
+	assert.Error(t, err)
+
+	// The kind not served is looked up again
+	_, err = scraper.GetOwnerReferences("ns",
+		metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Unknown", Name: "unknown", UID: "unknown-uid"})
+	assert.Error(t, err)
+	assert.Equal(t, 2, discoveries)
+}
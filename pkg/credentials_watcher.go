@@ -0,0 +1,86 @@
+package kubeturbo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// The environment variables from which the Turbo server api credentials are read, e.g., set from a Secret with
+	// secretKeyRef. The credentials mounted at credentialsDirPath take precedence.
+	usernameEnv = "TURBO_USERNAME"
+	passwordEnv = "TURBO_PASSWORD"
+)
+
+// serverCredentials are the credentials kubeturbo uses to connect to the Turbo server.
+type serverCredentials struct {
+	username     string
+	password     string
+	clientId     string
+	clientSecret string
+	apiToken     string
+}
+
+func loadOpsMgrCredentialsFromEnv(tapSpec *K8sTAPServiceSpec) {
+	username, password := os.Getenv(usernameEnv), os.Getenv(passwordEnv)
+	if username == "" || password == "" {
+		glog.V(2).Infof("server api credentials from environment variables %s and %s unavailable.",
+			usernameEnv, passwordEnv)
+		return
+	}
+	tapSpec.OpsManagerUsername = strings.TrimSpace(username)
+	tapSpec.OpsManagerPassword = strings.TrimSpace(password)
+}
+
+// credentials returns the Turbo server credentials in the spec.
+func (tapSpec *K8sTAPServiceSpec) credentials() serverCredentials {
+	return serverCredentials{
+		username:     tapSpec.OpsManagerUsername,
+		password:     tapSpec.OpsManagerPassword,
+		clientId:     tapSpec.ClientId,
+		clientSecret: tapSpec.ClientSecret,
+		apiToken:     tapSpec.TurboAPIToken,
+	}
+}
+
+// WatchCredentials watches the Turbo server credentials mounted from a Secret, and calls onChange once the mounted
+// credentials differ from the ones in the given spec, e.g., when the Secret is updated. The credentials are only
+// applied when kubeturbo connects to the server, so the caller is expected to restart kubeturbo.
+func WatchCredentials(tapSpec *K8sTAPServiceSpec, onChange func()) error {
+	return watchCredentials(credentialsDirPath, tapSpec.credentials(), onChange)
+}
+
+func watchCredentials(dir string, current serverCredentials, onChange func()) error {
+	return watchDir(dir, func() bool {
+		return mountedCredentialsChanged(dir, current)
+	}, onChange)
+}
+
+// mountedCredentialsChanged returns whether the username and password, the client id and secret, or the API token,
+// mounted in the given directory differ from the current ones. The credentials that are not mounted, or only partly,
+// are ignored.
+func mountedCredentialsChanged(dir string, current serverCredentials) bool {
+	username, password := readCredentialFile(dir, usernameFilePath), readCredentialFile(dir, passwordFilePath)
+	if username != "" && password != "" && (username != current.username || password != current.password) {
+		return true
+	}
+	clientId, clientSecret := readCredentialFile(dir, clientIdFilePath), readCredentialFile(dir, clientSecretFilePath)
+	if clientId != "" && clientSecret != "" && (clientId != current.clientId || clientSecret != current.clientSecret) {
+		return true
+	}
+	apiToken := readCredentialFile(dir, apiTokenFilePath)
+	return apiToken != "" && apiToken != current.apiToken
+}
+
+// readCredentialFile reads the credential file in the given directory with the same name as the given path, or
+// returns an empty string if it cannot be read.
+func readCredentialFile(dir, path string) string {
+	content, err := os.ReadFile(filepath.Join(dir, filepath.Base(path)))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
@@ -0,0 +1,78 @@
+package kubeturbo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseK8sTAPServiceSpecWithEnvCredentials(t *testing.T) {
+	t.Setenv(usernameEnv, "env-user")
+	t.Setenv(passwordEnv, "env-password")
+
+	config, err := ParseK8sTAPServiceSpec("../test/config/turbo-config", "target-foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "env-user", config.OpsManagerUsername)
+	assert.Equal(t, "env-password", config.OpsManagerPassword)
+
+	// Both the username and the password must be set
+	t.Setenv(passwordEnv, "")
+	config, err = ParseK8sTAPServiceSpec("../test/config/turbo-config", "target-foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", config.OpsManagerUsername)
+	assert.Equal(t, "bar", config.OpsManagerPassword)
+}
+
+func writeCredentials(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0600))
+	}
+}
+
+func TestMountedCredentialsChanged(t *testing.T) {
+	dir := t.TempDir()
+	current := serverCredentials{username: "user", password: "password"}
+	assert.False(t, mountedCredentialsChanged(dir, current))
+
+	writeCredentials(t, dir, map[string]string{"username": "user", "password": "password"})
+	assert.False(t, mountedCredentialsChanged(dir, current))
+
+	// The partly mounted credentials are ignored
+	writeCredentials(t, dir, map[string]string{"clientid": "id"})
+	assert.False(t, mountedCredentialsChanged(dir, current))
+
+	writeCredentials(t, dir, map[string]string{"clientsecret": "secret"})
+	assert.True(t, mountedCredentialsChanged(dir, current))
+	current.clientId, current.clientSecret = "id", "secret"
+	assert.False(t, mountedCredentialsChanged(dir, current))
+
+	writeCredentials(t, dir, map[string]string{"apitoken": "token"})
+	assert.True(t, mountedCredentialsChanged(dir, current))
+	current.apiToken = "token"
+	assert.False(t, mountedCredentialsChanged(dir, current))
+
+	writeCredentials(t, dir, map[string]string{"password": "new-password"})
+	assert.True(t, mountedCredentialsChanged(dir, current))
+}
+
+func TestWatchCredentials(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentials(t, dir, map[string]string{"username": "user", "password": "password"})
+	changed := make(chan struct{}, 1)
+	err := watchCredentials(dir, serverCredentials{username: "user", password: "password"}, func() {
+		changed <- struct{}{}
+	})
+	assert.NoError(t, err)
+
+	writeCredentials(t, dir, map[string]string{"password": "new-password"})
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Errorf("The change of the mounted credentials is not detected")
+	}
+
+	assert.Error(t, watchCredentials(filepath.Join(dir, "missing"), serverCredentials{}, func() {}))
+}
@@ -0,0 +1,43 @@
+package kubeturbo
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+// watchDir watches the given directory, e.g. a mounted ConfigMap or Secret, and calls onChange once changed returns
+// true after an event in the directory, then stops watching. The files of a mounted ConfigMap or Secret are symbolic
+// links replaced at once on update, so the directory is watched rather than the files.
+func watchDir(dir string, changed func() bool, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+	glog.V(2).Infof("Start watching %s.", dir)
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if changed() {
+					glog.V(1).Infof("The content of %s has changed.", dir)
+					onChange()
+					return
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				glog.Warningf("Error watching %s: %v", dir, err)
+			}
+		}
+	}()
+	return nil
+}
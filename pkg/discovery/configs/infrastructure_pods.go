@@ -0,0 +1,222 @@
+package configs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// The modes of the pods of the infrastructure namespaces, e.g. the control plane addons of kube-system
+const (
+	// The pods are modeled like the pods of the other namespaces, with their actions
+	InfrastructurePodsWorkload = "workload"
+	// The pods are modeled as the overhead of their nodes: discovered with their usage, but pinned to their nodes and
+	// with no action
+	InfrastructurePodsOverhead = "overhead"
+	// The pods are left out of the discovery with their namespaces and workloads, their usage and requests only
+	// accounted in the usage of their nodes
+	InfrastructurePodsExcluded = "excluded"
+
+	DefaultInfrastructureNamespaces = "kube-system"
+)
+
+// InfrastructurePods is how the pods of the infrastructure namespaces are modeled, set on the command line and
+// overridden by the TAP config, e.g.
+//
+//	infrastructurePods:
+//	  namespaces: [kube-system, "openshift-.*"]
+//	  mode: overhead
+type InfrastructurePods struct {
+	// The regular expressions matching the whole names of the infrastructure namespaces
+	Namespaces []string `json:"namespaces,omitempty"`
+	// One of workload, overhead or excluded
+	Mode string `json:"mode,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseInfrastructurePods parses the infrastructure namespaces, given as a comma separated list of regular
+// expressions, and the mode of their pods, workload if empty.
+func ParseInfrastructurePods(namespaces, mode string) (*InfrastructurePods, error) {
+	infrastructurePods := &InfrastructurePods{Mode: mode}
+	for _, namespace := range strings.Split(namespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			infrastructurePods.Namespaces = append(infrastructurePods.Namespaces, namespace)
+		}
+	}
+	if err := infrastructurePods.validate(); err != nil {
+		return nil, err
+	}
+	return infrastructurePods, nil
+}
+
+// Override returns the infrastructure pods with the namespaces and the mode set in the given override, e.g. the TAP
+// config, in place of their own.
+func (p *InfrastructurePods) Override(override *InfrastructurePods) (*InfrastructurePods, error) {
+	overridden := &InfrastructurePods{}
+	if p != nil {
+		overridden.Namespaces, overridden.Mode = p.Namespaces, p.Mode
+	}
+	if override != nil {
+		if len(override.Namespaces) > 0 {
+			overridden.Namespaces = override.Namespaces
+		}
+		if override.Mode != "" {
+			overridden.Mode = override.Mode
+		}
+	}
+	if err := overridden.validate(); err != nil {
+		return nil, err
+	}
+	return overridden, nil
+}
+
+func (p *InfrastructurePods) validate() error {
+	switch p.Mode {
+	case "":
+		p.Mode = InfrastructurePodsWorkload
+	case InfrastructurePodsWorkload, InfrastructurePodsOverhead, InfrastructurePodsExcluded:
+	default:
+		return fmt.Errorf("unknown mode %q, should be one of %s, %s or %s", p.Mode,
+			InfrastructurePodsWorkload, InfrastructurePodsOverhead, InfrastructurePodsExcluded)
+	}
+	for _, namespace := range p.Namespaces {
+		if _, err := regexp.Compile(namespace); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %v", namespace, err)
+		}
+	}
+	p.pattern = nil
+	if len(p.Namespaces) > 0 {
+		p.pattern = regexp.MustCompile("^(" + strings.Join(p.Namespaces, "|") + ")$")
+	}
+	return nil
+}
+
+// String returns the mode and the namespaces of the infrastructure pods.
+func (p *InfrastructurePods) String() string {
+	if p == nil {
+		return InfrastructurePodsWorkload
+	}
+	return fmt.Sprintf("%s %v", p.Mode, p.Namespaces)
+}
+
+// IsOverhead returns whether the pods of the given namespace are modeled as the overhead of their nodes.
+func (p *InfrastructurePods) IsOverhead(namespace string) bool {
+	return p != nil && p.Mode == InfrastructurePodsOverhead && p.matches(namespace)
+}
+
+// IsExcluded returns whether the given namespace is left out of the discovery.
+func (p *InfrastructurePods) IsExcluded(namespace string) bool {
+	return p != nil && p.Mode == InfrastructurePodsExcluded && p.matches(namespace)
+}
+
+func (p *InfrastructurePods) matches(namespace string) bool {
+	return p.pattern != nil && p.pattern.MatchString(namespace)
+}
+
+// The types of the entities which are removed with their providers and their owners, all in the same namespace
+var namespacedEntityTypes = map[proto.EntityDTO_EntityType]bool{
+	proto.EntityDTO_WORKLOAD_CONTROLLER:   true,
+	proto.EntityDTO_CONTAINER_SPEC:        true,
+	proto.EntityDTO_CONTAINER_POD:         true,
+	proto.EntityDTO_CONTAINER:             true,
+	proto.EntityDTO_APPLICATION_COMPONENT: true,
+	proto.EntityDTO_SERVICE:               true,
+}
+
+// RemoveEntities removes the entities of the given IDs, e.g. the namespaces, workload controllers and pods of the
+// excluded namespaces, from the given entity DTOs, along with the namespaced entities which buy from the removed
+// entities or are owned by them, e.g. the containers of the pods, their applications and services, and the
+// ContainerSpecs of the workload controllers. The commodities bought from the removed entities and the connections to
+// them are dropped from the entities kept, e.g. the business applications. It returns the entities kept with the IDs
+// of all the removed entities, e.g. to remove them from the groups with RemoveGroupMembers.
+func RemoveEntities(entityDTOs []*proto.EntityDTO, removedIDs map[string]bool) ([]*proto.EntityDTO, map[string]bool) {
+	if len(removedIDs) == 0 {
+		return entityDTOs, nil
+	}
+	removed := make(map[string]bool, len(removedIDs))
+	for id := range removedIDs {
+		removed[id] = true
+	}
+	owners := make(map[string][]string)
+	for _, entityDTO := range entityDTOs {
+		for _, connected := range entityDTO.GetConnectedEntities() {
+			if connected.GetConnectionType() == proto.ConnectedEntity_OWNS_CONNECTION {
+				owners[connected.GetConnectedEntityId()] = append(owners[connected.GetConnectedEntityId()],
+					entityDTO.GetId())
+			}
+		}
+	}
+	// The namespaced entities are removed until no more entity is removed, as the consumers are not ordered after
+	// their providers
+	for changed := true; changed; {
+		changed = false
+		for _, entityDTO := range entityDTOs {
+			if removed[entityDTO.GetId()] || !namespacedEntityTypes[entityDTO.GetEntityType()] {
+				continue
+			}
+			providers := owners[entityDTO.GetId()]
+			for _, bought := range entityDTO.GetCommoditiesBought() {
+				providers = append(providers, bought.GetProviderId())
+			}
+			for _, provider := range providers {
+				if removed[provider] {
+					removed[entityDTO.GetId()] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return removeEntitiesByID(entityDTOs, removed), removed
+}
+
+// removeEntitiesByID removes the entities of the given IDs from the given entity DTOs, along with the commodities
+// bought from them and the connections to them.
+func removeEntitiesByID(entityDTOs []*proto.EntityDTO, removedIDs map[string]bool) []*proto.EntityDTO {
+	var kept []*proto.EntityDTO
+	for _, entityDTO := range entityDTOs {
+		if removedIDs[entityDTO.GetId()] {
+			continue
+		}
+		var commoditiesBought []*proto.EntityDTO_CommodityBought
+		for _, bought := range entityDTO.CommoditiesBought {
+			if !removedIDs[bought.GetProviderId()] {
+				commoditiesBought = append(commoditiesBought, bought)
+			}
+		}
+		entityDTO.CommoditiesBought = commoditiesBought
+		var connectedEntities []*proto.ConnectedEntity
+		for _, connected := range entityDTO.ConnectedEntities {
+			if !removedIDs[connected.GetConnectedEntityId()] {
+				connectedEntities = append(connectedEntities, connected)
+			}
+		}
+		entityDTO.ConnectedEntities = connectedEntities
+		kept = append(kept, entityDTO)
+	}
+	return kept
+}
+
+// RemoveGroupMembers removes the entities of the given IDs from the static member lists of the given group DTOs, e.g.
+// the entities removed by RemoveEntities, so that the groups have no member which is not discovered.
+func RemoveGroupMembers(groupDTOs []*proto.GroupDTO, removedIDs map[string]bool) {
+	if len(removedIDs) == 0 {
+		return
+	}
+	for _, groupDTO := range groupDTOs {
+		memberList := groupDTO.GetMemberList()
+		if memberList == nil {
+			continue
+		}
+		var members []string
+		for _, member := range memberList.Member {
+			if !removedIDs[member] {
+				members = append(members, member)
+			}
+		}
+		memberList.Member = members
+	}
+}
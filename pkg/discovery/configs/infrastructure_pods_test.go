@@ -0,0 +1,120 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestParseInfrastructurePods(t *testing.T) {
+	infrastructurePods, err := ParseInfrastructurePods(" kube-system, openshift-.*,", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kube-system", "openshift-.*"}, infrastructurePods.Namespaces)
+	assert.Equal(t, InfrastructurePodsWorkload, infrastructurePods.Mode)
+	// Pods of the infrastructure namespaces are workloads by default
+	assert.False(t, infrastructurePods.IsOverhead("kube-system"))
+	assert.False(t, infrastructurePods.IsExcluded("kube-system"))
+
+	_, err = ParseInfrastructurePods("kube-system", "ignored")
+	assert.Error(t, err)
+	_, err = ParseInfrastructurePods("kube-(system", InfrastructurePodsOverhead)
+	assert.Error(t, err)
+}
+
+func TestInfrastructurePodsOverride(t *testing.T) {
+	infrastructurePods, err := ParseInfrastructurePods(DefaultInfrastructureNamespaces, InfrastructurePodsOverhead)
+	assert.NoError(t, err)
+	assert.True(t, infrastructurePods.IsOverhead("kube-system"))
+	// The whole name of the namespace is matched
+	assert.False(t, infrastructurePods.IsOverhead("kube-system-addons"))
+	assert.False(t, infrastructurePods.IsOverhead("default"))
+	assert.False(t, infrastructurePods.IsExcluded("kube-system"))
+
+	// The mode of the override wins, with the namespaces kept
+	overridden, err := infrastructurePods.Override(&InfrastructurePods{Mode: InfrastructurePodsExcluded})
+	assert.NoError(t, err)
+	assert.True(t, overridden.IsExcluded("kube-system"))
+	assert.False(t, overridden.IsOverhead("kube-system"))
+	// The original is left unchanged
+	assert.True(t, infrastructurePods.IsOverhead("kube-system"))
+
+	// The namespaces of the override win, with the mode kept
+	overridden, err = infrastructurePods.Override(&InfrastructurePods{Namespaces: []string{"openshift-.*"}})
+	assert.NoError(t, err)
+	assert.True(t, overridden.IsOverhead("openshift-monitoring"))
+	assert.False(t, overridden.IsOverhead("kube-system"))
+
+	// No override
+	overridden, err = infrastructurePods.Override(nil)
+	assert.NoError(t, err)
+	assert.True(t, overridden.IsOverhead("kube-system"))
+
+	_, err = infrastructurePods.Override(&InfrastructurePods{Mode: "ignored"})
+	assert.Error(t, err)
+
+	var none *InfrastructurePods
+	assert.False(t, none.IsOverhead("kube-system"))
+	assert.False(t, none.IsExcluded("kube-system"))
+	overridden, err = none.Override(&InfrastructurePods{Namespaces: []string{"kube-system"}, Mode: "excluded"})
+	assert.NoError(t, err)
+	assert.True(t, overridden.IsExcluded("kube-system"))
+}
+
+func TestRemoveInfrastructureEntities(t *testing.T) {
+	newEntity := func(entityType proto.EntityDTO_EntityType, id string, providers ...string) *proto.EntityDTO {
+		entityBuilder := sdkbuilder.NewEntityDTOBuilder(entityType, id)
+		for _, provider := range providers {
+			entityBuilder.Provider(sdkbuilder.CreateProvider(proto.EntityDTO_CONTAINER_POD, provider)).
+				BuysCommodities([]*proto.CommodityDTO{{}})
+		}
+		entityDTO, err := entityBuilder.Create()
+		assert.NoError(t, err)
+		return entityDTO
+	}
+	controller := newEntity(proto.EntityDTO_WORKLOAD_CONTROLLER, "controller", "namespace")
+	controller.ConnectedEntities = []*proto.ConnectedEntity{{
+		ConnectedEntityId: &[]string{"spec"}[0],
+		ConnectionType:    proto.ConnectedEntity_OWNS_CONNECTION.Enum(),
+	}}
+	entityDTOs := []*proto.EntityDTO{
+		// The consumers come before their providers
+		newEntity(proto.EntityDTO_BUSINESS_APPLICATION, "business-app", "service", "other-service"),
+		newEntity(proto.EntityDTO_SERVICE, "service", "app"),
+		newEntity(proto.EntityDTO_APPLICATION_COMPONENT, "app", "container"),
+		newEntity(proto.EntityDTO_CONTAINER, "container", "pod", "spec"),
+		newEntity(proto.EntityDTO_CONTAINER_POD, "pod", "node", "controller"),
+		newEntity(proto.EntityDTO_CONTAINER_SPEC, "spec"),
+		controller,
+		newEntity(proto.EntityDTO_NAMESPACE, "namespace"),
+		newEntity(proto.EntityDTO_VIRTUAL_MACHINE, "node"),
+		newEntity(proto.EntityDTO_SERVICE, "other-service"),
+	}
+
+	kept, removedIDs := RemoveEntities(entityDTOs, map[string]bool{"namespace": true, "controller": true, "pod": true})
+	var keptIDs []string
+	for _, entityDTO := range kept {
+		keptIDs = append(keptIDs, entityDTO.GetId())
+	}
+	assert.Equal(t, []string{"business-app", "node", "other-service"}, keptIDs)
+	// The business application keeps the commodities bought from the other service
+	assert.Len(t, kept[0].GetCommoditiesBought(), 1)
+	assert.Equal(t, "other-service", kept[0].GetCommoditiesBought()[0].GetProviderId())
+	// The removed IDs include the entities removed with the given ones
+	assert.Len(t, removedIDs, 7)
+	assert.True(t, removedIDs["spec"])
+	assert.True(t, removedIDs["service"])
+
+	// The removed entities are removed from the groups
+	groupDTO := &proto.GroupDTO{
+		Members: &proto.GroupDTO_MemberList{MemberList: &proto.GroupDTO_MembersList{Member: []string{"pod", "node"}}},
+	}
+	RemoveGroupMembers([]*proto.GroupDTO{groupDTO}, removedIDs)
+	assert.Equal(t, []string{"node"}, groupDTO.GetMemberList().GetMember())
+
+	// Nothing is removed without removed IDs
+	kept, removedIDs = RemoveEntities(entityDTOs, nil)
+	assert.Len(t, kept, len(entityDTOs))
+	assert.Empty(t, removedIDs)
+}
@@ -3,6 +3,7 @@ package configs
 import (
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
 )
@@ -10,12 +11,23 @@ import (
 type ProbeConfig struct {
 	// A correct stitching property type is the prerequisite for stitching process.
 	StitchingPropertyType stitching.StitchingPropertyType
+	// The cloud provider used to build the stitching UUIDs of all the nodes, detected per node if empty
+	StitchingProvider string
 
 	// Config for one or more monitoring clients
 	MonitoringConfigs []monitoring.MonitorWorkerConfig
+	// The priority of the monitoring sources when more than one collects the same metric, the default if empty
+	MonitoringSourcePriority []types.MonitoringSource
 
 	// ClusterScraper contains rest client (ClientSet) and dynamic client (DynamicClient) for the kubernetes server API
 	ClusterScraper *cluster.ClusterScraper
+	// ActionClusterScraper contains the clients of the primary kubernetes server API used to execute actions.
+	// It is the same as ClusterScraper unless discovery uses a separate API server, e.g. a read replica.
+	ActionClusterScraper *cluster.ClusterScraper
 	// Rest Client for the kubelet module in each node
 	NodeClient *kubeclient.KubeletClient
+	// Whether the pods of the Jobs are movable, suspendable and provisionable like the pods of the other workloads
+	IncludeBatchWorkloads bool
+	// How the pods of the infrastructure namespaces are modeled, like the pods of the other namespaces if nil
+	InfrastructurePods *InfrastructurePods
 }
@@ -0,0 +1,127 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// The entity types of the supply chain which can be disabled, by the names given on the command line and in the TAP
+// config, each with the types of the entities left out of the supply chain and the discovery when disabled. The load
+// balancers are discovered with the services of type LoadBalancer.
+var optionalEntityTypes = map[string][]proto.EntityDTO_EntityType{
+	"services":            {proto.EntityDTO_SERVICE, proto.EntityDTO_LOAD_BALANCER},
+	"namespaces":          {proto.EntityDTO_NAMESPACE},
+	"volumes":             {proto.EntityDTO_VIRTUAL_VOLUME},
+	"workloadcontrollers": {proto.EntityDTO_WORKLOAD_CONTROLLER},
+}
+
+// DisabledEntityTypes is the set of the entity types left out of the supply chain and the discovery, to trade the
+// richness of the topology for the cost of the discovery. The entities which buy from the disabled entities are kept
+// without the commodities bought from them. A nil set disables no entity type.
+type DisabledEntityTypes map[proto.EntityDTO_EntityType]bool
+
+// ParseDisabledEntityTypes parses the optional entity types to disable, given as a comma separated list of services,
+// namespaces, volumes and workloadcontrollers.
+func ParseDisabledEntityTypes(names string) (DisabledEntityTypes, error) {
+	disabled := make(DisabledEntityTypes)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		entityTypes, found := optionalEntityTypes[name]
+		if !found {
+			return nil, fmt.Errorf("unknown entity type %q, should be one of %s", name,
+				strings.Join(OptionalEntityTypeNames(), ", "))
+		}
+		for _, entityType := range entityTypes {
+			disabled[entityType] = true
+		}
+	}
+	return disabled, nil
+}
+
+// OptionalEntityTypeNames returns the sorted names of the entity types which can be disabled.
+func OptionalEntityTypeNames() []string {
+	var names []string
+	for name := range optionalEntityTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Union returns the entity types disabled in either set.
+func (d DisabledEntityTypes) Union(other DisabledEntityTypes) DisabledEntityTypes {
+	union := make(DisabledEntityTypes, len(d)+len(other))
+	for entityType := range d {
+		union[entityType] = true
+	}
+	for entityType := range other {
+		union[entityType] = true
+	}
+	return union
+}
+
+// String returns the sorted names of the disabled entity types.
+func (d DisabledEntityTypes) String() string {
+	var names []string
+	for entityType := range d {
+		names = append(names, entityType.String())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// Enabled returns true if the entities of the given type are discovered.
+func (d DisabledEntityTypes) Enabled(entityType proto.EntityDTO_EntityType) bool {
+	return !d[entityType]
+}
+
+// RemoveTemplates removes the templates of the disabled entity types from the supply chain, along with the providers
+// of the disabled types of the other templates.
+func (d DisabledEntityTypes) RemoveTemplates(templates []*proto.TemplateDTO) []*proto.TemplateDTO {
+	if len(d) == 0 {
+		return templates
+	}
+	var kept []*proto.TemplateDTO
+	for _, template := range templates {
+		if d[template.GetTemplateClass()] {
+			continue
+		}
+		var commoditiesBought []*proto.TemplateDTO_CommBoughtProviderProp
+		for _, bought := range template.CommodityBought {
+			if !d[bought.GetKey().GetTemplateClass()] {
+				commoditiesBought = append(commoditiesBought, bought)
+			}
+		}
+		template.CommodityBought = commoditiesBought
+		kept = append(kept, template)
+	}
+	return kept
+}
+
+// RemoveEntities removes the entities of the disabled types from the given entity DTOs, along with the commodities
+// bought from the entities of the disabled types and the connections to them, e.g. the pods aggregated by their
+// workload controllers.
+func (d DisabledEntityTypes) RemoveEntities(entityDTOs []*proto.EntityDTO) []*proto.EntityDTO {
+	if len(d) == 0 {
+		return entityDTOs
+	}
+	// The IDs of the entities of the disabled types, built or only referenced as providers
+	removedIDs := make(map[string]bool)
+	for _, entityDTO := range entityDTOs {
+		if d[entityDTO.GetEntityType()] {
+			removedIDs[entityDTO.GetId()] = true
+		}
+		for _, bought := range entityDTO.GetCommoditiesBought() {
+			if d[bought.GetProviderType()] {
+				removedIDs[bought.GetProviderId()] = true
+			}
+		}
+	}
+	return removeEntitiesByID(entityDTOs, removedIDs)
+}
@@ -0,0 +1,58 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestParseDisabledEntityTypes(t *testing.T) {
+	disabled, err := ParseDisabledEntityTypes(" Services, volumes,")
+	assert.NoError(t, err)
+	assert.Equal(t, "LOAD_BALANCER,SERVICE,VIRTUAL_VOLUME", disabled.String())
+	assert.False(t, disabled.Enabled(proto.EntityDTO_SERVICE))
+	assert.True(t, disabled.Enabled(proto.EntityDTO_NAMESPACE))
+
+	disabled, err = ParseDisabledEntityTypes("")
+	assert.NoError(t, err)
+	assert.Empty(t, disabled)
+
+	_, err = ParseDisabledEntityTypes("pods")
+	assert.Error(t, err)
+
+	var none DisabledEntityTypes
+	assert.True(t, none.Enabled(proto.EntityDTO_SERVICE))
+	assert.Equal(t, "NAMESPACE", none.Union(DisabledEntityTypes{proto.EntityDTO_NAMESPACE: true}).String())
+}
+
+func TestRemoveEntities(t *testing.T) {
+	newPod := func(id string) *proto.EntityDTO {
+		entityDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, id).
+			Provider(sdkbuilder.CreateProvider(proto.EntityDTO_VIRTUAL_MACHINE, "node")).
+			BuysCommodities([]*proto.CommodityDTO{{}}).
+			Provider(sdkbuilder.CreateProvider(proto.EntityDTO_WORKLOAD_CONTROLLER, "controller")).
+			BuysCommodities([]*proto.CommodityDTO{{}}).
+			AggregatedBy("controller").
+			Create()
+		assert.NoError(t, err)
+		return entityDTO
+	}
+	controller, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_WORKLOAD_CONTROLLER, "controller").Create()
+	assert.NoError(t, err)
+
+	disabled := DisabledEntityTypes{proto.EntityDTO_WORKLOAD_CONTROLLER: true}
+	entityDTOs := disabled.RemoveEntities([]*proto.EntityDTO{newPod("pod"), controller})
+	assert.Len(t, entityDTOs, 1)
+	pod := entityDTOs[0]
+	assert.Equal(t, "pod", pod.GetId())
+	assert.Len(t, pod.GetCommoditiesBought(), 1)
+	assert.Equal(t, "node", pod.GetCommoditiesBought()[0].GetProviderId())
+	assert.Empty(t, pod.GetConnectedEntities())
+
+	// Nothing is removed without disabled entity types
+	entityDTOs = DisabledEntityTypes(nil).RemoveEntities([]*proto.EntityDTO{newPod("pod"), controller})
+	assert.Len(t, entityDTOs, 2)
+	assert.Len(t, entityDTOs[0].GetCommoditiesBought(), 2)
+}
@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	discoveryHealthEvent    = "Kubeturbo discovery health"
+	discoveryHealthCategory = "Discovery"
+)
+
+// discoveryHealth reports the health of the probe to the server with each full discovery, so that the server can
+// display the health of the target instead of only marking it stale when the connection drops. The keep-alive
+// messages of the mediation protocol carry no data and are only sent by the SDK while a discovery is in progress,
+// so the health is sent as a notification of the discovery response instead.
+type discoveryHealth struct {
+	// The number of the full discoveries which failed since the last successful one
+	failedDiscoveries int
+}
+
+// recordFailure records a failed full discovery, reported with the next successful one.
+func (h *discoveryHealth) recordFailure() {
+	h.failedDiscoveries++
+}
+
+// notification creates the notification of the health of a successful full discovery, which discovered the given
+// number of entities in the given duration, degraded for the given reasons if any. The failures are reset.
+func (h *discoveryHealth) notification(entityCount int, duration time.Duration,
+	degradedReasons []string) *proto.NotificationDTO {
+	severity := proto.NotificationDTO_NORMAL
+	description := fmt.Sprintf("Discovered %d entities in %.3f seconds", entityCount, duration.Seconds())
+	if h.failedDiscoveries > 0 {
+		severity = proto.NotificationDTO_MINOR
+		description += fmt.Sprintf("; %d discoveries failed since the last successful one", h.failedDiscoveries)
+	}
+	if len(degradedReasons) > 0 {
+		severity = proto.NotificationDTO_MINOR
+		description += fmt.Sprintf("; degraded: %s", strings.Join(degradedReasons, "; "))
+	}
+	h.failedDiscoveries = 0
+	event, category := discoveryHealthEvent, discoveryHealthCategory
+	return &proto.NotificationDTO{
+		Event:       &event,
+		Category:    &category,
+		Description: &description,
+		Severity:    &severity,
+	}
+}
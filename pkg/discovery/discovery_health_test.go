@@ -0,0 +1,27 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestDiscoveryHealthNotification(t *testing.T) {
+	health := discoveryHealth{}
+	notification := health.notification(10, 2*time.Second, nil)
+	assert.Equal(t, proto.NotificationDTO_NORMAL, notification.GetSeverity())
+	assert.Equal(t, "Discovered 10 entities in 2.000 seconds", notification.GetDescription())
+
+	health.recordFailure()
+	health.recordFailure()
+	notification = health.notification(10, 2*time.Second, []string{"watch of pods failed"})
+	assert.Equal(t, proto.NotificationDTO_MINOR, notification.GetSeverity())
+	assert.Equal(t, "Discovered 10 entities in 2.000 seconds; 2 discoveries failed since the last successful one; "+
+		"degraded: watch of pods failed", notification.GetDescription())
+
+	// The failures are reported once
+	notification = health.notification(10, 2*time.Second, nil)
+	assert.Equal(t, proto.NotificationDTO_NORMAL, notification.GetSeverity())
+}
@@ -0,0 +1,16 @@
+package discovery
+
+import (
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+// ShardDiscoverer spreads the discovery of the nodes of the cluster over the discovery shard workers.
+type ShardDiscoverer interface {
+	// Assign assigns the given nodes to the shard workers. It returns the nodes of each worker, and the nodes which
+	// the coordinator discovers itself.
+	Assign(nodes []*api.Node) (map[string][]*api.Node, []*api.Node)
+	// DiscoverShard discovers the nodes of the given names on the given shard worker.
+	DiscoverShard(shardWorker string, nodeNames []string) (*worker.DiscoveryResult, error)
+}
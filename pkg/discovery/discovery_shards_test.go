@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+// fakeShardDiscoverer assigns the nodes to the shard workers by the first letter of their names, and the others to
+// the coordinator.
+type fakeShardDiscoverer struct {
+	failedWorkers map[string]bool
+}
+
+func (d *fakeShardDiscoverer) Assign(nodes []*api.Node) (map[string][]*api.Node, []*api.Node) {
+	shards := make(map[string][]*api.Node)
+	var selfNodes []*api.Node
+	for _, node := range nodes {
+		if node.Name[0] == 's' {
+			selfNodes = append(selfNodes, node)
+			continue
+		}
+		shards[node.Name[:1]] = append(shards[node.Name[:1]], node)
+	}
+	return shards, selfNodes
+}
+
+func (d *fakeShardDiscoverer) DiscoverShard(shardWorker string, nodeNames []string) (*worker.DiscoveryResult, error) {
+	if d.failedWorkers[shardWorker] {
+		return nil, fmt.Errorf("shard worker %s is unavailable", shardWorker)
+	}
+	return newNodesResult("worker-"+shardWorker, nodeNames), nil
+}
+
+func newNodesResult(discoveredBy string, nodeNames []string) *worker.DiscoveryResult {
+	result := &worker.DiscoveryResult{PodEntitiesMap: make(map[string]*repository.KubePod)}
+	for _, nodeName := range nodeNames {
+		id := discoveredBy + "/" + nodeName
+		result.EntityDTOs = append(result.EntityDTOs,
+			&proto.EntityDTO{Id: &id, EntityType: proto.EntityDTO_VIRTUAL_MACHINE.Enum()})
+		result.PodEntitiesMap[nodeName+"/pod"] = &repository.KubePod{PodClusterId: nodeName + "/pod"}
+		result.SuccessCount++
+	}
+	return result
+}
+
+func TestDiscoverShards(t *testing.T) {
+	var nodes []*api.Node
+	for _, name := range []string{"self-1", "a-1", "a-2", "b-1", "self-2"} {
+		nodes = append(nodes, &api.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	var dispatches [][]string
+	dispatchNodes := func(nodes []*api.Node) *worker.DiscoveryResult {
+		var nodeNames []string
+		for _, node := range nodes {
+			nodeNames = append(nodeNames, node.Name)
+		}
+		dispatches = append(dispatches, nodeNames)
+		return newNodesResult("self", nodeNames)
+	}
+	entityIDs := func(result *worker.DiscoveryResult) []string {
+		var ids []string
+		for _, entity := range result.EntityDTOs {
+			ids = append(ids, entity.GetId())
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	// Without shards, all the nodes are dispatched by the coordinator
+	dc := &K8sDiscoveryClient{Config: &DiscoveryClientConfig{}}
+	result, dispatchedNodes := dc.discoverShards(nodes, dispatchNodes)
+	assert.Equal(t, nodes, dispatchedNodes)
+	assert.Len(t, result.EntityDTOs, len(nodes))
+
+	dispatches = nil
+	dc.Config.ShardDiscoverer = &fakeShardDiscoverer{}
+	result, dispatchedNodes = dc.discoverShards(nodes, dispatchNodes)
+	assert.Equal(t, []string{"self/self-1", "self/self-2", "worker-a/a-1", "worker-a/a-2", "worker-b/b-1"},
+		entityIDs(result))
+	assert.Len(t, result.PodEntitiesMap, len(nodes))
+	assert.Equal(t, len(nodes), result.SuccessCount)
+	assert.Equal(t, [][]string{{"self-1", "self-2"}}, dispatches)
+	assert.Len(t, dispatchedNodes, 2)
+
+	// The nodes of the failed workers are dispatched by the coordinator
+	dispatches = nil
+	dc.Config.ShardDiscoverer = &fakeShardDiscoverer{failedWorkers: map[string]bool{"a": true}}
+	result, dispatchedNodes = dc.discoverShards(nodes, dispatchNodes)
+	assert.Equal(t, []string{"self/a-1", "self/a-2", "self/self-1", "self/self-2", "worker-b/b-1"},
+		entityIDs(result))
+	assert.Equal(t, [][]string{{"self-1", "self-2"}, {"a-1", "a-2"}}, dispatches)
+	assert.Len(t, dispatchedNodes, 4)
+}
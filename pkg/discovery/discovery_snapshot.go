@@ -0,0 +1,214 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// The formats of a discovery snapshot
+const (
+	SnapshotFormatJSON  = "json"
+	SnapshotFormatProto = "proto"
+)
+
+// DiscoverySnapshot keeps the response of the last full discovery sent to the server, so that support engineers can
+// inspect exactly what was sent. The snapshot is served over http, and written to the dump directory if any.
+type DiscoverySnapshot struct {
+	lock      sync.RWMutex
+	targetID  string
+	timestamp time.Time
+	// When the discovery of the snapshot started
+	startTime time.Time
+	response  *proto.DiscoveryResponse
+	// Closed when the next snapshot is recorded, nil if nobody waits for it
+	recorded chan struct{}
+	// The utilization of each node of the snapshot by node name
+	nodeUtilizations map[string]float64
+	// The directory to which each snapshot is written, none if empty
+	dumpDir string
+}
+
+func NewDiscoverySnapshot(dumpDir string) *DiscoverySnapshot {
+	return &DiscoverySnapshot{
+		dumpDir: dumpDir,
+	}
+}
+
+// Record keeps the given response of the discovery of the given target started at the given time as the last
+// snapshot, and writes it as json and proto files to the dump directory if any.
+func (s *DiscoverySnapshot) Record(targetID string, startTime time.Time, response *proto.DiscoveryResponse) {
+	s.lock.Lock()
+	s.targetID = targetID
+	s.timestamp = time.Now()
+	s.startTime = startTime
+	s.response = response
+	s.nodeUtilizations = nodeUtilizations(response)
+	if s.recorded != nil {
+		close(s.recorded)
+		s.recorded = nil
+	}
+	s.lock.Unlock()
+
+	if s.dumpDir == "" {
+		return
+	}
+	for _, format := range []string{SnapshotFormatJSON, SnapshotFormatProto} {
+		if err := s.dump(targetID, response, format); err != nil {
+			glog.Errorf("Failed to dump the discovery of %s as %s to %s: %v", targetID, format, s.dumpDir, err)
+		}
+	}
+}
+
+// Clone returns a copy of the last snapshot of the given target if it was recorded less than the given max age ago,
+// along with its age, or nil otherwise. The copy can be sent to the server and modified without altering the snapshot.
+func (s *DiscoverySnapshot) Clone(targetID string, maxAge time.Duration) (*proto.DiscoveryResponse, time.Duration) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.response == nil || s.targetID != targetID {
+		return nil, 0
+	}
+	age := time.Since(s.timestamp)
+	if age >= maxAge {
+		return nil, 0
+	}
+	return protobuf.Clone(s.response).(*proto.DiscoveryResponse), age
+}
+
+// WaitForDiscovery waits for at most the timeout until the snapshot of a discovery of the given target started after
+// the given time is recorded, and returns it along with the time it was recorded, or nil if none is recorded in time.
+// The returned response must not be modified.
+func (s *DiscoverySnapshot) WaitForDiscovery(targetID string, startedAfter time.Time,
+	timeout time.Duration) (*proto.DiscoveryResponse, time.Time) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.lock.Lock()
+		if s.response != nil && s.targetID == targetID && s.startTime.After(startedAfter) {
+			response, timestamp := s.response, s.timestamp
+			s.lock.Unlock()
+			return response, timestamp
+		}
+		if s.recorded == nil {
+			s.recorded = make(chan struct{})
+		}
+		recorded := s.recorded
+		s.lock.Unlock()
+		select {
+		case <-recorded:
+		case <-timer.C:
+			return nil, time.Time{}
+		}
+	}
+}
+
+// NodeUtilizations returns the utilization of each node of the last snapshot by node name if it was recorded less
+// than the given max age ago, or nil otherwise. The returned map must not be modified.
+func (s *DiscoverySnapshot) NodeUtilizations(maxAge time.Duration) map[string]float64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.response == nil || time.Since(s.timestamp) >= maxAge {
+		return nil
+	}
+	return s.nodeUtilizations
+}
+
+// nodeUtilizations returns the utilization of each node of the discovery response by node name, which is the highest
+// of the utilizations of the VCPU and VMem commodities sold by the node.
+func nodeUtilizations(response *proto.DiscoveryResponse) map[string]float64 {
+	utilizations := make(map[string]float64)
+	for _, entityDTO := range response.GetEntityDTO() {
+		if entityDTO.GetEntityType() != proto.EntityDTO_VIRTUAL_MACHINE {
+			continue
+		}
+		utilization := 0.0
+		for _, comm := range entityDTO.GetCommoditiesSold() {
+			if comm.GetCommodityType() != proto.CommodityDTO_VCPU && comm.GetCommodityType() != proto.CommodityDTO_VMEM {
+				continue
+			}
+			if comm.GetCapacity() > 0 && comm.GetUsed()/comm.GetCapacity() > utilization {
+				utilization = comm.GetUsed() / comm.GetCapacity()
+			}
+		}
+		utilizations[entityDTO.GetDisplayName()] = utilization
+	}
+	return utilizations
+}
+
+// dump writes the discovery response to the dump directory. The file is renamed once written, so that a reader
+// never sees a partial snapshot.
+func (s *DiscoverySnapshot) dump(targetID string, response *proto.DiscoveryResponse, format string) error {
+	data, err := encodeDiscoveryResponse(response, format)
+	if err != nil {
+		return err
+	}
+	// The target id is a cluster name, which may contain the path separator
+	fileName := strings.ReplaceAll(targetID, string(filepath.Separator), "_") + "-discovery." + format
+	tmpFile, err := os.CreateTemp(s.dumpDir, "."+fileName)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), filepath.Join(s.dumpDir, fileName))
+}
+
+// ServeHTTP writes the last snapshot in the format of the format query parameter, json by default. The snapshot
+// target and time are set in the X-Discovery-Target and X-Discovery-Time headers.
+func (s *DiscoverySnapshot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = SnapshotFormatJSON
+	}
+	s.lock.RLock()
+	targetID, timestamp, response := s.targetID, s.timestamp, s.response
+	s.lock.RUnlock()
+	if response == nil {
+		http.Error(w, "no full discovery has completed yet", http.StatusNotFound)
+		return
+	}
+	data, err := encodeDiscoveryResponse(response, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == SnapshotFormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	w.Header().Set("X-Discovery-Target", targetID)
+	w.Header().Set("X-Discovery-Time", timestamp.UTC().Format(time.RFC3339))
+	if _, err := w.Write(data); err != nil {
+		glog.Errorf("Failed to write the discovery snapshot of %s: %v", targetID, err)
+	}
+}
+
+// encodeDiscoveryResponse encodes the discovery response in the given format: the json of the DTOs, whose enums are
+// encoded as numbers, or the protobuf wire format, as sent to the server.
+func encodeDiscoveryResponse(response *proto.DiscoveryResponse, format string) ([]byte, error) {
+	switch format {
+	case SnapshotFormatJSON:
+		return json.MarshalIndent(response, "", "  ")
+	case SnapshotFormatProto:
+		return protobuf.Marshal(response)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, should be either %s or %s", format, SnapshotFormatJSON,
+			SnapshotFormatProto)
+	}
+}
@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func newSnapshotResponse() *proto.DiscoveryResponse {
+	return &proto.DiscoveryResponse{
+		EntityDTO: []*proto.EntityDTO{newDiffedEntityDTO("pod-1", "node-1", 100)},
+	}
+}
+
+func serveSnapshot(snapshot *DiscoverySnapshot, url string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	snapshot.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	return recorder
+}
+
+func TestDiscoverySnapshotServeHTTP(t *testing.T) {
+	snapshot := NewDiscoverySnapshot("")
+	assert.Equal(t, http.StatusNotFound, serveSnapshot(snapshot, "/debug/discovery").Code)
+
+	snapshot.Record("cluster", time.Now(), newSnapshotResponse())
+	recorder := serveSnapshot(snapshot, "/debug/discovery")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "cluster", recorder.Header().Get("X-Discovery-Target"))
+	response := &proto.DiscoveryResponse{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), response))
+	assert.Equal(t, "pod-1", response.GetEntityDTO()[0].GetId())
+
+	recorder = serveSnapshot(snapshot, "/debug/discovery?format=proto")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	response = &proto.DiscoveryResponse{}
+	assert.NoError(t, protobuf.Unmarshal(recorder.Body.Bytes(), response))
+	assert.Equal(t, "pod-1", response.GetEntityDTO()[0].GetId())
+
+	assert.Equal(t, http.StatusBadRequest, serveSnapshot(snapshot, "/debug/discovery?format=yaml").Code)
+}
+
+func TestDiscoverySnapshotDump(t *testing.T) {
+	dir := t.TempDir()
+	NewDiscoverySnapshot(dir).Record("cluster/east", time.Now(), newSnapshotResponse())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	data, err := os.ReadFile(filepath.Join(dir, "cluster_east-discovery.proto"))
+	assert.NoError(t, err)
+	response := &proto.DiscoveryResponse{}
+	assert.NoError(t, protobuf.Unmarshal(data, response))
+	assert.Equal(t, "pod-1", response.GetEntityDTO()[0].GetId())
+	_, err = os.Stat(filepath.Join(dir, "cluster_east-discovery.json"))
+	assert.NoError(t, err)
+}
+
+func TestDiscoverySnapshotClone(t *testing.T) {
+	snapshot := NewDiscoverySnapshot("")
+	cached, _ := snapshot.Clone("cluster", time.Minute)
+	assert.Nil(t, cached)
+
+	snapshot.Record("cluster", time.Now(), newSnapshotResponse())
+	cached, age := snapshot.Clone("cluster", time.Minute)
+	assert.NotNil(t, cached)
+	assert.True(t, age < time.Minute)
+	assert.Equal(t, "pod-1", cached.GetEntityDTO()[0].GetId())
+
+	// The clone is a copy of the snapshot
+	cached.EntityDTO = nil
+	cached, _ = snapshot.Clone("cluster", time.Minute)
+	assert.Len(t, cached.GetEntityDTO(), 1)
+
+	// The snapshot of another target, or older than the max age, is not reused
+	cached, _ = snapshot.Clone("other-cluster", time.Minute)
+	assert.Nil(t, cached)
+	cached, _ = snapshot.Clone("cluster", 0)
+	assert.Nil(t, cached)
+}
+
+func newSnapshotNodeDTO(name string, cpuUsed, memUsed float64) *proto.EntityDTO {
+	entityType := proto.EntityDTO_VIRTUAL_MACHINE
+	cpuType, memType := proto.CommodityDTO_VCPU, proto.CommodityDTO_VMEM
+	capacity := 100.0
+	return &proto.EntityDTO{
+		EntityType:  &entityType,
+		Id:          &name,
+		DisplayName: &name,
+		CommoditiesSold: []*proto.CommodityDTO{
+			{CommodityType: &cpuType, Used: &cpuUsed, Capacity: &capacity},
+			{CommodityType: &memType, Used: &memUsed, Capacity: &capacity},
+		},
+	}
+}
+
+func TestDiscoverySnapshotNodeUtilizations(t *testing.T) {
+	snapshot := NewDiscoverySnapshot("")
+	assert.Nil(t, snapshot.NodeUtilizations(time.Minute))
+
+	response := newSnapshotResponse()
+	response.EntityDTO = append(response.EntityDTO, newSnapshotNodeDTO("node-1", 80, 20),
+		newSnapshotNodeDTO("node-2", 10, 30))
+	snapshot.Record("cluster", time.Now(), response)
+	assert.Equal(t, map[string]float64{"node-1": 0.8, "node-2": 0.3}, snapshot.NodeUtilizations(time.Minute))
+	assert.Nil(t, snapshot.NodeUtilizations(0))
+}
+
+func TestDiscoverySnapshotWaitForDiscovery(t *testing.T) {
+	snapshot := NewDiscoverySnapshot("")
+	requested := time.Now()
+	// The discovery in progress when the rediscovery was requested does not count
+	snapshot.Record("cluster", requested.Add(-time.Second), newSnapshotResponse())
+	response, _ := snapshot.WaitForDiscovery("cluster", requested, 10*time.Millisecond)
+	assert.Nil(t, response)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		snapshot.Record("cluster", requested.Add(time.Millisecond), newSnapshotResponse())
+	}()
+	response, recordedAt := snapshot.WaitForDiscovery("cluster", requested, time.Minute)
+	assert.NotNil(t, response)
+	assert.True(t, recordedAt.After(requested))
+
+	response, _ = snapshot.WaitForDiscovery("other-cluster", requested, 10*time.Millisecond)
+	assert.Nil(t, response)
+}
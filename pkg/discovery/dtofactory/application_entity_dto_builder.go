@@ -94,7 +94,7 @@ func (builder *applicationEntityDTOBuilder) BuildEntityDTO(pod *api.Pod) ([]*pro
 		// controllability of applications should not be dictated by mirror pods modeled as daemon pods
 		// because they cannot be controlled through the API server
 		controllable := util.Controllable(pod, false)
-		monitored := true
+		monitored := util.IsMonitoredFromAnnotation(pod.GetAnnotations())
 		powerState := proto.EntityDTO_POWERED_ON
 		if !util.PodIsReady(pod) {
 			controllable = false
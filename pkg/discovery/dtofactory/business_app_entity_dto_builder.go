@@ -31,8 +31,7 @@ func (builder *businessAppEntityDTOBuilder) BuildEntityDTOs() []*proto.EntityDTO
 		entityDTOBuilder.DisplayName(displayName)
 
 		for _, entity := range entities {
-			key := fmt.Sprintf("%s-%s/%s-%s/%s", "App", app.Namespace, app.Name,
-				entity.EntityType.String(), entity.Name)
+			key := app.ComponentKey(entity)
 			commodityBought, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_APPLICATION).
 				Key(key).
 				Capacity(accessCommodityDefaultCapacity).
@@ -108,7 +108,7 @@ func (builder *clusterDTOBuilder) BuildEntity(entityDTOs []*proto.EntityDTO, nam
 
 	entityDTOBuilder.WithPowerState(proto.EntityDTO_POWERED_ON)
 	// The added property indicates that this cluster now uses millicore as unit for vcpu commodities
-	properties := property.BuildClusterProperty()
+	properties := property.BuildClusterProperty(builder.getClusterId(), displayName)
 
 	// build entityDTO.
 	entityDto, err := entityDTOBuilder.
@@ -123,6 +123,18 @@ func (builder *clusterDTOBuilder) BuildEntity(entityDTOs []*proto.EntityDTO, nam
 	return entityDto, nil
 }
 
+// getClusterId returns the id of the cluster shared by all the tools managing it, i.e., the UID of the kube-system
+// namespace, which lives as long as the cluster. The cluster entity keeps the UID of the kubernetes service as its
+// id, which is also the key of the cluster commodities, so that the existing entities are not replaced.
+func (builder *clusterDTOBuilder) getClusterId() string {
+	if clusterId, exists := builder.cluster.NamespaceUIDMap[kubeSystemPrefix]; exists {
+		return clusterId
+	}
+	glog.Warningf("Failed to retrieve UID for 'kube-system' namespace, use %s as the cluster id.",
+		builder.cluster.Name)
+	return builder.cluster.Name
+}
+
 func (builder *clusterDTOBuilder) getCommoditiesSold(entityDTOs []*proto.EntityDTO) ([]*proto.CommodityDTO,
 	map[proto.CommodityDTO_CommodityType]float64, error) {
 	// Cluster access commodity
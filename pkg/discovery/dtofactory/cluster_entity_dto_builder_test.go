@@ -190,6 +190,35 @@ func TestBuildClusterDto(t *testing.T) {
 	assert.False(t, clusterDTO.GetProviderPolicy().GetAvailableForPlacement())
 }
 
+func TestBuildClusterDtoProperties(t *testing.T) {
+	getProperties := func(clusterDTO *proto.EntityDTO) map[string]string {
+		properties := make(map[string]string)
+		for _, property := range clusterDTO.GetEntityProperties() {
+			properties[property.GetName()] = property.GetValue()
+		}
+		return properties
+	}
+	kubeCluster := repository.KubeCluster{Name: clusterId}
+	clusterSummary := repository.ClusterSummary{
+		KubeCluster:     &kubeCluster,
+		NamespaceUIDMap: map[string]string{"kube-system": "kube-system-uid"},
+	}
+	clusterDTO, err := NewClusterDTOBuilder(&clusterSummary, targetId).BuildEntity(nil, nil)
+	assert.Nil(t, err)
+	// The entity keeps the kubernetes service UID as its id
+	assert.Equal(t, clusterId, clusterDTO.GetId())
+	properties := getProperties(clusterDTO)
+	assert.Equal(t, "kube-system-uid", properties["KubernetesClusterId"])
+	assert.Equal(t, targetId, properties["KubernetesClusterName"])
+	assert.Equal(t, "Millicore", properties["VCPUUnit"])
+
+	// The kube-system namespace is not discovered
+	clusterSummary.NamespaceUIDMap = map[string]string{}
+	clusterDTO, err = NewClusterDTOBuilder(&clusterSummary, targetId).BuildEntity(nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, clusterId, getProperties(clusterDTO)["KubernetesClusterId"])
+}
+
 func Test_clusterDTOBuilder_createClusterData(t *testing.T) {
 	kubeCluster := repository.KubeCluster{Name: clusterId}
 	clusterSummary := repository.ClusterSummary{KubeCluster: &kubeCluster}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
@@ -75,6 +76,10 @@ var (
 
 type containerDTOBuilder struct {
 	generalBuilder
+	// Cluster Summary needed to bound the resources of the containers with the limit ranges of their namespace
+	clusterSummary *repository.ClusterSummary
+	// Whether the pods of a namespace are modeled as the overhead of their nodes, with no action
+	isOverheadNamespace func(namespace string) bool
 }
 
 func NewContainerDTOBuilder(sink *metrics.EntityMetricSink) *containerDTOBuilder {
@@ -83,6 +88,18 @@ func NewContainerDTOBuilder(sink *metrics.EntityMetricSink) *containerDTOBuilder
 	}
 }
 
+func (builder *containerDTOBuilder) WithClusterSummary(clusterSummary *repository.ClusterSummary) *containerDTOBuilder {
+	builder.clusterSummary = clusterSummary
+	return builder
+}
+
+// WithOverheadNamespaces sets the namespaces whose pods are modeled as the overhead of their nodes, whose containers
+// are not controllable.
+func (builder *containerDTOBuilder) WithOverheadNamespaces(isOverheadNamespace func(namespace string) bool) *containerDTOBuilder {
+	builder.isOverheadNamespace = isOverheadNamespace
+	return builder
+}
+
 func (builder *containerDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.EntityDTO, []string) {
 	var result []*proto.EntityDTO
 	var err error
@@ -143,6 +160,9 @@ func (builder *containerDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.E
 				glog.Warningf("Failed to create commoditiesSold for container[%s]: %v", name, err)
 				continue
 			}
+			if builder.clusterSummary != nil {
+				setLimitRangeBounds(builder.clusterSummary.NamespaceMap[pod.Namespace], commoditiesSold)
+			}
 			ebuilder.SellsCommodities(commoditiesSold)
 
 			//2. commodities bought
@@ -157,6 +177,12 @@ func (builder *containerDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.E
 
 			//3. set properties
 			properties := builder.getContainerProperties(pod, i)
+			if swapUsedProperty := builder.getSwapUsedProperty(metrics.ContainerType, containerMId); swapUsedProperty != nil {
+				properties = append(properties, swapUsedProperty)
+			}
+			if status := containerStatus(pod, container.Name); status != nil {
+				properties = append(properties, builder.getContainerRestartProperties(containerMId, status)...)
+			}
 			ebuilder.WithProperties(properties)
 
 			//ebuilder.Monitored(util.Monitored(pod))
@@ -166,8 +192,9 @@ func (builder *containerDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.E
 
 			// controllability of applications should not be dictated by mirror pods modeled as daemon pods
 			// because they cannot be controlled through the API server
-			controllable := util.Controllable(pod, false)
-			monitored := true
+			controllable := util.Controllable(pod, false) &&
+				(builder.isOverheadNamespace == nil || !builder.isOverheadNamespace(pod.Namespace))
+			monitored := util.IsMonitoredFromAnnotation(pod.GetAnnotations())
 			powerState := proto.EntityDTO_POWERED_ON
 			if !util.PodIsReady(pod) {
 				controllable = false
@@ -451,3 +478,13 @@ func getContainerStitchingProperty(pod *api.Pod, index int) string {
 	}
 	return ""
 }
+
+// containerStatus returns the status of the container with the given name, or nil if it is not reported yet.
+func containerStatus(pod *api.Pod, name string) *api.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == name {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
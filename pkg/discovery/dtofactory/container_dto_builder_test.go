@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -182,6 +183,64 @@ func Test_containerDTOBuilder_BuildDTOs_containerData(t *testing.T) {
 	assert.False(t, containerDTOs[1].GetContainerData().GetHasMemLimit())
 }
 
+func Test_containerDTOBuilder_BuildDTOs_restarts(t *testing.T) {
+	pod := testPod.DeepCopy()
+	pod.Spec.Containers = []api.Container{
+		mockContainer(containerNameFoo),
+		mockContainer(containerNameBar),
+	}
+	pod.Status.ContainerStatuses = []api.ContainerStatus{{Name: containerNameFoo, RestartCount: 7}}
+	sink := mockMetricsSink()
+	containerFooMId := util.ContainerMetricId(util.PodMetricIdAPI(pod), containerNameFoo)
+	sink.AddNewMetricEntries(
+		metrics.NewEntityStateMetric(metrics.ContainerType, containerFooMId, metrics.Restarts, 2.0),
+		metrics.NewEntityStateMetric(metrics.ContainerType, containerFooMId, metrics.OOMKilled, true))
+
+	containerDTOs, _ := NewContainerDTOBuilder(sink).BuildEntityDTOs([]*api.Pod{pod})
+	assert.Equal(t, 2, len(containerDTOs))
+	properties := make(map[string]string)
+	for _, p := range containerDTOs[0].GetEntityProperties() {
+		properties[p.GetName()] = p.GetValue()
+	}
+	assert.Equal(t, "7", properties["RestartCount"])
+	assert.Equal(t, "2", properties["RestartsSinceLastDiscovery"])
+	assert.Equal(t, "true", properties["OOMKilledSinceLastDiscovery"])
+	// No status of the bar container, no restart properties
+	for _, p := range containerDTOs[1].GetEntityProperties() {
+		assert.NotEqual(t, "RestartCount", p.GetName())
+	}
+}
+
+func Test_containerDTOBuilder_BuildDTOs_limitRangeBounds(t *testing.T) {
+	pod := testPod.DeepCopy()
+	pod.Spec.Containers = []api.Container{mockContainer(containerNameFoo)}
+	kubeNamespace := repository.CreateDefaultKubeNamespace("cluster", namespace, "namespace-uid")
+	kubeNamespace.LimitRanges = []*api.LimitRange{{Spec: api.LimitRangeSpec{Limits: []api.LimitRangeItem{{
+		Type: api.LimitTypeContainer,
+		Min:  api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+		Max:  api.ResourceList{api.ResourceMemory: resource.MustParse("1Gi")},
+	}}}}}
+	clusterSummary := repository.CreateClusterSummary(repository.NewKubeCluster("cluster", nil))
+	clusterSummary.NamespaceMap[namespace] = kubeNamespace
+
+	containerDTOs, _ := NewContainerDTOBuilder(mockMetricsSink()).WithClusterSummary(clusterSummary).
+		BuildEntityDTOs([]*api.Pod{pod})
+	assert.Equal(t, 1, len(containerDTOs))
+	for _, commodity := range containerDTOs[0].GetCommoditiesSold() {
+		switch commodity.GetCommodityType() {
+		case proto.CommodityDTO_VCPU:
+			assert.Equal(t, 100.0, commodity.GetMinAmountForConsumer())
+			assert.Nil(t, commodity.MaxAmountForConsumer)
+		case proto.CommodityDTO_VMEM:
+			assert.Nil(t, commodity.MinAmountForConsumer)
+			assert.Equal(t, 1024.0*1024, commodity.GetMaxAmountForConsumer())
+		default:
+			assert.Nil(t, commodity.MinAmountForConsumer)
+			assert.Nil(t, commodity.MaxAmountForConsumer)
+		}
+	}
+}
+
 func mockOwnerReference() (r metav1.OwnerReference) {
 	isController := true
 	return metav1.OwnerReference{
@@ -57,6 +57,9 @@ func (builder *containerSpecDTOBuilder) BuildDTOs() ([]*proto.EntityDTO, error)
 			glog.Errorf("Error creating commodities sold by ContainerSpec %s, %v", containerSpecId, err)
 			continue
 		}
+		if builder.clusterSummary != nil {
+			setLimitRangeBounds(builder.clusterSummary.NamespaceMap[containerSpec.Namespace], commoditiesSold)
+		}
 		entityDTOBuilder.SellsCommodities(commoditiesSold)
 		// ContainerSpec entity is not monitored and will not be sent to Market analysis engine in turbo server
 		entityDTOBuilder.Monitored(false)
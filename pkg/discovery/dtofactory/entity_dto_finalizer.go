@@ -0,0 +1,120 @@
+package dtofactory
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+const (
+	// DiscoverySchemaVersion is the version of the schema of the DTOs built by kubeturbo. It must be bumped
+	// whenever a change of the DTO building changes how the server should interpret a discovery, e.g. a new
+	// entity type, commodity or property, so that the server can tell which schema produced a discovery.
+	DiscoverySchemaVersion = "1"
+	// SchemaVersionContextKey is the key of the schema version in the context of the discovery response.
+	SchemaVersionContextKey = "kubeturboSchemaVersion"
+)
+
+// EntityDTOFinalizer runs the final normalization pass over all the entity DTOs of a discovery,
+// after all the builders and processors have added their commodities and properties.
+type EntityDTOFinalizer struct {
+	propertyConflictPolicy property.ConflictPolicy
+	// The max number of properties of an entity, no limit if not positive
+	maxEntityProperties int
+	// Whether to tag the discovery response with the DiscoverySchemaVersion
+	emitSchemaVersion bool
+	// The max number of entities of a discovery, nil if not limited
+	entityLimits *EntityLimits
+	// Tracks whether the discovery is degraded, nil if not tracked
+	discoveryStatus *util.DiscoveryStatus
+}
+
+func NewEntityDTOFinalizer() *EntityDTOFinalizer {
+	return &EntityDTOFinalizer{
+		propertyConflictPolicy: property.ConflictPolicyLastWins,
+		emitSchemaVersion:      true,
+	}
+}
+
+func (f *EntityDTOFinalizer) WithPropertyConflictPolicy(policy property.ConflictPolicy) *EntityDTOFinalizer {
+	f.propertyConflictPolicy = policy
+	return f
+}
+
+func (f *EntityDTOFinalizer) WithMaxEntityProperties(maxEntityProperties int) *EntityDTOFinalizer {
+	f.maxEntityProperties = maxEntityProperties
+	return f
+}
+
+func (f *EntityDTOFinalizer) WithSchemaVersion(emitSchemaVersion bool) *EntityDTOFinalizer {
+	f.emitSchemaVersion = emitSchemaVersion
+	return f
+}
+
+func (f *EntityDTOFinalizer) WithEntityLimits(entityLimits *EntityLimits) *EntityDTOFinalizer {
+	f.entityLimits = entityLimits
+	return f
+}
+
+func (f *EntityDTOFinalizer) WithDiscoveryStatus(discoveryStatus *util.DiscoveryStatus) *EntityDTOFinalizer {
+	f.discoveryStatus = discoveryStatus
+	return f
+}
+
+// TruncateEntities drops the entities above the entity limits along with the entities which depend on them, and
+// removes them from the members of the given groups. It returns the remaining entities with the number of the removed
+// entities of each type, which is empty if no entity is removed. A truncated discovery is marked as degraded.
+func (f *EntityDTOFinalizer) TruncateEntities(entityDTOs []*proto.EntityDTO,
+	groupDTOs []*proto.GroupDTO) ([]*proto.EntityDTO, map[proto.EntityDTO_EntityType]int) {
+	truncated, removedIDs, removed := f.entityLimits.Truncate(entityDTOs)
+	if len(removed) > 0 {
+		configs.RemoveGroupMembers(groupDTOs, removedIDs)
+		glog.Warningf("The discovery exceeds the max entities %v, %d of %d entities are removed: %v. "+
+			"The discovered topology is incomplete.", f.entityLimits, len(entityDTOs)-len(truncated),
+			len(entityDTOs), removed)
+		if f.discoveryStatus != nil {
+			f.discoveryStatus.MarkDegraded(fmt.Sprintf("max entities %v exceeded, removed entities %v",
+				f.entityLimits, removed))
+		}
+	}
+	return truncated, removed
+}
+
+// DiscoveryContext returns the context of the discovery response, which carries the DiscoverySchemaVersion.
+// It is nil if the schema version is not emitted.
+func (f *EntityDTOFinalizer) DiscoveryContext() *proto.DiscoveryContextDTO {
+	if !f.emitSchemaVersion {
+		return nil
+	}
+	return &proto.DiscoveryContextDTO{
+		ContextEntry: map[string]string{
+			SchemaVersionContextKey: DiscoverySchemaVersion,
+		},
+	}
+}
+
+// Finalize normalizes the properties of the given entity DTOs in place.
+func (f *EntityDTOFinalizer) Finalize(entityDTOs []*proto.EntityDTO) {
+	droppedTotal := 0
+	for _, entityDTO := range entityDTOs {
+		if entityDTO == nil || len(entityDTO.EntityProperties) == 0 {
+			continue
+		}
+		properties, dropped := property.NormalizeProperties(entityDTO.EntityProperties,
+			f.propertyConflictPolicy, f.maxEntityProperties)
+		if dropped > 0 {
+			glog.V(4).Infof("Dropped %d duplicate or excess properties of %s %s.",
+				dropped, entityDTO.GetEntityType(), entityDTO.GetDisplayName())
+			entityDTO.EntityProperties = properties
+			droppedTotal += dropped
+		}
+	}
+	if droppedTotal > 0 {
+		glog.V(2).Infof("Dropped %d duplicate or excess entity properties in total.", droppedTotal)
+	}
+}
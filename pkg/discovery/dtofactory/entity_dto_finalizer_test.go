@@ -0,0 +1,75 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+func TestEntityDTOFinalizerFinalize(t *testing.T) {
+	podDTO := &proto.EntityDTO{
+		EntityProperties: []*proto.EntityDTO_EntityProperty{
+			property.BuildTagProperty("DEFAULT", "KubernetesNodeName", "node-1"),
+			property.BuildTagProperty(property.VCTagsPropertyNamespace, "[k8s label] app", "web"),
+			property.BuildTagProperty("DEFAULT", "KubernetesNodeName", "node-2"),
+			property.BuildTagProperty(property.VCTagsPropertyNamespace, "[k8s label] tier", "front"),
+		},
+	}
+	nodeDTO := &proto.EntityDTO{}
+	entityDTOs := []*proto.EntityDTO{podDTO, nodeDTO, nil}
+
+	NewEntityDTOFinalizer().WithPropertyConflictPolicy(property.ConflictPolicyFirstWins).
+		WithMaxEntityProperties(2).Finalize(entityDTOs)
+
+	assert.Len(t, podDTO.EntityProperties, 2)
+	assert.Equal(t, "node-1", podDTO.EntityProperties[0].GetValue())
+	assert.Equal(t, "[k8s label] app", podDTO.EntityProperties[1].GetName())
+	assert.Empty(t, nodeDTO.EntityProperties)
+}
+
+func TestEntityDTOFinalizerDiscoveryContext(t *testing.T) {
+	discoveryContext := NewEntityDTOFinalizer().DiscoveryContext()
+	assert.NotNil(t, discoveryContext)
+	assert.Equal(t, DiscoverySchemaVersion, discoveryContext.GetContextEntry()[SchemaVersionContextKey])
+
+	response := &proto.DiscoveryResponse{DiscoveryContext: discoveryContext}
+	assert.Equal(t, DiscoverySchemaVersion, response.GetDiscoveryContext().GetContextEntry()[SchemaVersionContextKey])
+
+	assert.Nil(t, NewEntityDTOFinalizer().WithSchemaVersion(false).DiscoveryContext())
+}
+
+func TestEntityDTOFinalizerTruncateEntities(t *testing.T) {
+	discoveryStatus := util.NewDiscoveryStatus()
+	limits, err := ParseEntityLimits("total=10")
+	assert.NoError(t, err)
+	finalizer := NewEntityDTOFinalizer().WithEntityLimits(limits).WithDiscoveryStatus(discoveryStatus)
+
+	discoveryStatus.Begin()
+	truncated, dropped := finalizer.TruncateEntities(newTestEntityDTOs(proto.EntityDTO_CONTAINER_POD, 10), nil)
+	assert.Len(t, truncated, 10)
+	assert.Empty(t, dropped)
+	degraded, _ := discoveryStatus.Complete()
+	assert.False(t, degraded)
+
+	discoveryStatus.Begin()
+	entityDTOs := newTestEntityDTOs(proto.EntityDTO_CONTAINER_POD, 15)
+	groupDTOs := []*proto.GroupDTO{{Members: &proto.GroupDTO_MemberList{MemberList: &proto.GroupDTO_MembersList{
+		Member: []string{entityDTOs[0].GetId(), entityDTOs[14].GetId()}}}}}
+	truncated, dropped = finalizer.TruncateEntities(entityDTOs, groupDTOs)
+	assert.Len(t, truncated, 10)
+	assert.Equal(t, map[proto.EntityDTO_EntityType]int{proto.EntityDTO_CONTAINER_POD: 5}, dropped)
+	// The dropped entities are removed from the groups
+	assert.Equal(t, []string{entityDTOs[0].GetId()}, groupDTOs[0].GetMemberList().GetMember())
+	degraded, reasons := discoveryStatus.Complete()
+	assert.True(t, degraded)
+	assert.Contains(t, reasons[0], "max entities total=10 exceeded")
+
+	// No limit
+	truncated, dropped = NewEntityDTOFinalizer().TruncateEntities(newTestEntityDTOs(proto.EntityDTO_CONTAINER_POD, 15), nil)
+	assert.Len(t, truncated, 15)
+	assert.Empty(t, dropped)
+}
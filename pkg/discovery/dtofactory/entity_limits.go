@@ -0,0 +1,192 @@
+package dtofactory
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+)
+
+const entityLimitTotalKey = "total"
+
+// EntityLimits caps the number of entities sent in a discovery, as a safety valve on runaway clusters.
+// The limits which are not positive are not enforced.
+type EntityLimits struct {
+	// The max number of entities of all types
+	Total int
+	// The max number of entities of each type
+	PerType map[proto.EntityDTO_EntityType]int
+}
+
+// ParseEntityLimits parses the entity limits given on the command line, as a comma separated list of
+// <entity type>=<max> pairs, e.g. "total=100000,CONTAINER_POD=50000". The entity types are the names of
+// the entity types of the DTOs, and "total" caps the entities of all types.
+func ParseEntityLimits(limits string) (*EntityLimits, error) {
+	entityLimits := &EntityLimits{
+		PerType: make(map[proto.EntityDTO_EntityType]int),
+	}
+	if strings.TrimSpace(limits) == "" {
+		return entityLimits, nil
+	}
+	for _, limit := range strings.Split(limits, ",") {
+		pair := strings.SplitN(limit, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid entity limit %q, must be in the form of <entity type>=<max>", limit)
+		}
+		key := strings.TrimSpace(pair[0])
+		max, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		if err != nil || max <= 0 {
+			return nil, fmt.Errorf("invalid entity limit %q, the max must be a positive integer", limit)
+		}
+		if strings.EqualFold(key, entityLimitTotalKey) {
+			entityLimits.Total = max
+			continue
+		}
+		entityType, found := proto.EntityDTO_EntityType_value[strings.ToUpper(key)]
+		if !found {
+			return nil, fmt.Errorf("invalid entity limit %q, unknown entity type %s", limit, key)
+		}
+		entityLimits.PerType[proto.EntityDTO_EntityType(entityType)] = max
+	}
+	return entityLimits, nil
+}
+
+// IsEmpty returns true if no limit is enforced.
+func (l *EntityLimits) IsEmpty() bool {
+	return l == nil || (l.Total <= 0 && len(l.PerType) == 0)
+}
+
+// Truncate drops the entities above the limits, and returns the remaining entities with the IDs of the removed
+// entities and the number of the removed entities of each type. The truncation is deterministic: the entities of
+// each type are sorted by ID and the last ones are dropped, so that the same entities are dropped in each discovery.
+// The total limit is enforced by capping the types with the most entities first, so that the types with a few
+// entities such as the nodes and the namespaces are kept as long as possible. The entities which depend on the
+// dropped entities are removed with them by configs.RemoveEntities, e.g. the pods of the dropped nodes and the
+// containers of the dropped pods, so the removed entities may exceed the limits. The order of the remaining entities
+// is kept.
+func (l *EntityLimits) Truncate(entityDTOs []*proto.EntityDTO) ([]*proto.EntityDTO, map[string]bool,
+	map[proto.EntityDTO_EntityType]int) {
+	if l.IsEmpty() {
+		return entityDTOs, nil, nil
+	}
+	// The IDs of the entities of each type, sorted
+	idsByType := make(map[proto.EntityDTO_EntityType][]string)
+	for _, entityDTO := range entityDTOs {
+		if entityDTO == nil {
+			continue
+		}
+		idsByType[entityDTO.GetEntityType()] = append(idsByType[entityDTO.GetEntityType()], entityDTO.GetId())
+	}
+	// The number of entities kept for each type
+	keptByType := make(map[proto.EntityDTO_EntityType]int, len(idsByType))
+	total := 0
+	for entityType, ids := range idsByType {
+		sort.Strings(ids)
+		kept := len(ids)
+		if max, found := l.PerType[entityType]; found && max > 0 && kept > max {
+			kept = max
+		}
+		keptByType[entityType] = kept
+		total += kept
+	}
+	if l.Total > 0 && total > l.Total {
+		capAllTypes(keptByType, l.Total)
+	}
+
+	droppedIDs := make(map[string]bool)
+	for entityType, ids := range idsByType {
+		for _, id := range ids[keptByType[entityType]:] {
+			droppedIDs[id] = true
+		}
+	}
+	if len(droppedIDs) == 0 {
+		return entityDTOs, nil, nil
+	}
+	var nonNil []*proto.EntityDTO
+	for _, entityDTO := range entityDTOs {
+		if entityDTO != nil {
+			nonNil = append(nonNil, entityDTO)
+		}
+	}
+	truncated, removedIDs := configs.RemoveEntities(nonNil, droppedIDs)
+	removed := make(map[proto.EntityDTO_EntityType]int)
+	for _, entityDTO := range nonNil {
+		if removedIDs[entityDTO.GetId()] {
+			removed[entityDTO.GetEntityType()]++
+		}
+	}
+	return truncated, removedIDs, removed
+}
+
+// capAllTypes lowers the number of entities kept for the types with the most entities, so that the total
+// is at most the given limit. All the types are capped by the highest common cap which fits in the limit,
+// and the remaining room, if any, is given to the types in the order of their names.
+func capAllTypes(keptByType map[proto.EntityDTO_EntityType]int, limit int) {
+	entityTypes := make([]proto.EntityDTO_EntityType, 0, len(keptByType))
+	for entityType := range keptByType {
+		entityTypes = append(entityTypes, entityType)
+	}
+	sort.Slice(entityTypes, func(i, j int) bool {
+		return entityTypes[i].String() < entityTypes[j].String()
+	})
+	// Find the highest common cap with a binary search
+	low, high := 0, 0
+	for _, kept := range keptByType {
+		if kept > high {
+			high = kept
+		}
+	}
+	sumCapped := func(c int) int {
+		sum := 0
+		for _, kept := range keptByType {
+			if kept < c {
+				sum += kept
+			} else {
+				sum += c
+			}
+		}
+		return sum
+	}
+	for low < high {
+		mid := (low + high + 1) / 2
+		if sumCapped(mid) <= limit {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	room := limit - sumCapped(low)
+	for _, entityType := range entityTypes {
+		kept := keptByType[entityType]
+		if kept <= low {
+			continue
+		}
+		keptByType[entityType] = low
+		if room > 0 {
+			// The types above the cap have at least one more entity
+			keptByType[entityType]++
+			room--
+		}
+	}
+}
+
+// String returns the limits in the same format as parsed by ParseEntityLimits.
+func (l *EntityLimits) String() string {
+	if l == nil {
+		return ""
+	}
+	var limits []string
+	if l.Total > 0 {
+		limits = append(limits, fmt.Sprintf("%s=%d", entityLimitTotalKey, l.Total))
+	}
+	var perType []string
+	for entityType, max := range l.PerType {
+		perType = append(perType, fmt.Sprintf("%s=%d", entityType, max))
+	}
+	sort.Strings(perType)
+	return strings.Join(append(limits, perType...), ",")
+}
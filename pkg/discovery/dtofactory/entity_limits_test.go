@@ -0,0 +1,136 @@
+package dtofactory
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newTestEntityDTOs(entityType proto.EntityDTO_EntityType, count int) []*proto.EntityDTO {
+	var entityDTOs []*proto.EntityDTO
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("%s-%03d", entityType, i)
+		entityDTOs = append(entityDTOs, &proto.EntityDTO{EntityType: &entityType, Id: &id})
+	}
+	return entityDTOs
+}
+
+func countByType(entityDTOs []*proto.EntityDTO) map[proto.EntityDTO_EntityType]int {
+	counts := make(map[proto.EntityDTO_EntityType]int)
+	for _, entityDTO := range entityDTOs {
+		counts[entityDTO.GetEntityType()]++
+	}
+	return counts
+}
+
+func getIDs(entityDTOs []*proto.EntityDTO) []string {
+	var ids []string
+	for _, entityDTO := range entityDTOs {
+		ids = append(ids, entityDTO.GetId())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestParseEntityLimits(t *testing.T) {
+	limits, err := ParseEntityLimits("")
+	assert.NoError(t, err)
+	assert.True(t, limits.IsEmpty())
+
+	limits, err = ParseEntityLimits("total=100, container_pod=50,CONTAINER=80")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, limits.Total)
+	assert.Equal(t, map[proto.EntityDTO_EntityType]int{
+		proto.EntityDTO_CONTAINER_POD: 50,
+		proto.EntityDTO_CONTAINER:     80,
+	}, limits.PerType)
+	assert.Equal(t, "total=100,CONTAINER=80,CONTAINER_POD=50", limits.String())
+
+	for _, invalid := range []string{"total", "total=0", "CONTAINER_POD=-1", "POD=10", "total=abc"} {
+		_, err = ParseEntityLimits(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEntityLimitsTruncate(t *testing.T) {
+	var entityDTOs []*proto.EntityDTO
+	entityDTOs = append(entityDTOs, newTestEntityDTOs(proto.EntityDTO_VIRTUAL_MACHINE, 3)...)
+	entityDTOs = append(entityDTOs, newTestEntityDTOs(proto.EntityDTO_CONTAINER_POD, 20)...)
+	entityDTOs = append(entityDTOs, newTestEntityDTOs(proto.EntityDTO_CONTAINER, 30)...)
+
+	// Per type
+	limits := &EntityLimits{PerType: map[proto.EntityDTO_EntityType]int{proto.EntityDTO_CONTAINER_POD: 10}}
+	truncated, _, dropped := limits.Truncate(entityDTOs)
+	assert.Equal(t, map[proto.EntityDTO_EntityType]int{proto.EntityDTO_CONTAINER_POD: 10}, dropped)
+	assert.Equal(t, map[proto.EntityDTO_EntityType]int{
+		proto.EntityDTO_VIRTUAL_MACHINE: 3,
+		proto.EntityDTO_CONTAINER_POD:   10,
+		proto.EntityDTO_CONTAINER:       30,
+	}, countByType(truncated))
+
+	// Total, the types with the most entities are capped first, and the remaining room is given
+	// to the capped types in the order of their names
+	limits = &EntityLimits{Total: 24}
+	truncated, _, dropped = limits.Truncate(entityDTOs)
+	assert.Len(t, truncated, 24)
+	assert.Equal(t, map[proto.EntityDTO_EntityType]int{
+		proto.EntityDTO_CONTAINER_POD: 10,
+		proto.EntityDTO_CONTAINER:     19,
+	}, dropped)
+	assert.Equal(t, 3, countByType(truncated)[proto.EntityDTO_VIRTUAL_MACHINE])
+
+	// Not exceeded
+	limits = &EntityLimits{Total: 53}
+	truncated, _, dropped = limits.Truncate(entityDTOs)
+	assert.Empty(t, dropped)
+	assert.Len(t, truncated, 53)
+}
+
+func TestEntityLimitsTruncateIsDeterministic(t *testing.T) {
+	entityDTOs := newTestEntityDTOs(proto.EntityDTO_CONTAINER_POD, 50)
+	limits := &EntityLimits{Total: 20, PerType: map[proto.EntityDTO_EntityType]int{proto.EntityDTO_CONTAINER_POD: 30}}
+	expected, _, _ := limits.Truncate(entityDTOs)
+	assert.Equal(t, getIDs(entityDTOs[:20]), getIDs(expected))
+
+	for i := 0; i < 5; i++ {
+		shuffled := append([]*proto.EntityDTO(nil), entityDTOs...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		truncated, _, _ := limits.Truncate(shuffled)
+		assert.Equal(t, getIDs(expected), getIDs(truncated))
+	}
+}
+
+func TestEntityLimitsTruncateRemovesDependents(t *testing.T) {
+	nodeType, podType, containerType := proto.EntityDTO_VIRTUAL_MACHINE, proto.EntityDTO_CONTAINER_POD,
+		proto.EntityDTO_CONTAINER
+	newEntity := func(entityType *proto.EntityDTO_EntityType, id string, providers ...string) *proto.EntityDTO {
+		entityDTO := &proto.EntityDTO{EntityType: entityType, Id: &id}
+		for i := range providers {
+			entityDTO.CommoditiesBought = append(entityDTO.CommoditiesBought,
+				&proto.EntityDTO_CommodityBought{ProviderId: &providers[i]})
+		}
+		return entityDTO
+	}
+	entityDTOs := []*proto.EntityDTO{
+		newEntity(&nodeType, "node-1"),
+		newEntity(&nodeType, "node-2"),
+		newEntity(&podType, "pod-1", "node-1"),
+		newEntity(&podType, "pod-2", "node-2"),
+		newEntity(&podType, "pod-3", "node-1"),
+		newEntity(&containerType, "container-1", "pod-1"),
+		newEntity(&containerType, "container-2", "pod-2"),
+		newEntity(&containerType, "container-3", "pod-3"),
+	}
+
+	// node-2 is dropped with its pod and the container of the pod, pod-3 with its container
+	limits := &EntityLimits{PerType: map[proto.EntityDTO_EntityType]int{nodeType: 1, podType: 2}}
+	truncated, removedIDs, removed := limits.Truncate(entityDTOs)
+	assert.Equal(t, []string{"container-1", "node-1", "pod-1"}, getIDs(truncated))
+	assert.Equal(t, map[string]bool{"node-2": true, "pod-2": true, "pod-3": true, "container-2": true,
+		"container-3": true}, removedIDs)
+	assert.Equal(t, map[proto.EntityDTO_EntityType]int{nodeType: 1, podType: 2, containerType: 2}, removed)
+}
@@ -0,0 +1,119 @@
+package dtofactory
+
+import (
+	"sort"
+	"strings"
+
+	api "k8s.io/api/core/v1"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// isExtendedResource returns true if the resource is an extended resource advertised by a device plugin or the
+// cluster admin, e.g., nvidia.com/gpu, which are integers requested by the containers in their requests or limits.
+// The native resources, i.e., those without a domain or in the kubernetes.io domain, are not.
+func isExtendedResource(name api.ResourceName) bool {
+	resourceName := string(name)
+	if !strings.Contains(resourceName, "/") || strings.Contains(resourceName, api.ResourceDefaultNamespacePrefix) {
+		return false
+	}
+	return !strings.HasPrefix(resourceName, api.DefaultResourceRequestsPrefix)
+}
+
+// getPodExtendedResourceRequests returns the extended resources requested by the pod, the same way as the scheduler
+// accounts them: the sum of the requests of the containers, or the largest request of an init container if it is
+// larger. The limit of an extended resource is its request if the request is not set, as they cannot be overcommitted.
+func getPodExtendedResourceRequests(pod *api.Pod) map[api.ResourceName]float64 {
+	requests := make(map[api.ResourceName]float64)
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range getContainerExtendedResourceRequests(&container) {
+			requests[name] += quantity
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range getContainerExtendedResourceRequests(&container) {
+			if quantity > requests[name] {
+				requests[name] = quantity
+			}
+		}
+	}
+	return requests
+}
+
+func getContainerExtendedResourceRequests(container *api.Container) map[api.ResourceName]float64 {
+	requests := make(map[api.ResourceName]float64)
+	for name, quantity := range container.Resources.Limits {
+		if isExtendedResource(name) {
+			requests[name] = float64(quantity.Value())
+		}
+	}
+	for name, quantity := range container.Resources.Requests {
+		if isExtendedResource(name) {
+			requests[name] = float64(quantity.Value())
+		}
+	}
+	return requests
+}
+
+// getExtendedResourceCommoditiesSold builds the GPU commodities sold by the node for the extended resources it
+// allocates, e.g., nvidia.com/gpu, keyed by the resource name. The used is the sum of the requests of the given pods
+// running on the node.
+func getExtendedResourceCommoditiesSold(node *api.Node, pods []*api.Pod) ([]*proto.CommodityDTO, error) {
+	used := make(map[api.ResourceName]float64)
+	for _, pod := range pods {
+		if pod.Spec.NodeName != node.Name || pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		for name, quantity := range getPodExtendedResourceRequests(pod) {
+			used[name] += quantity
+		}
+	}
+	var names []string
+	for name, quantity := range node.Status.Allocatable {
+		if isExtendedResource(name) && !quantity.IsZero() {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	var commoditiesSold []*proto.CommodityDTO
+	for _, name := range names {
+		quantity := node.Status.Allocatable[api.ResourceName(name)]
+		commSold, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_GPU_SLICE).
+			Key(name).
+			Capacity(float64(quantity.Value())).
+			Used(used[api.ResourceName(name)]).
+			Resizable(false).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesSold = append(commoditiesSold, commSold)
+	}
+	return commoditiesSold, nil
+}
+
+// getExtendedResourceCommoditiesBought builds the GPU commodities bought by the pod from its node for the extended
+// resources it requests, keyed by the resource name, so that the pod is only placed on the nodes allocating them.
+func getExtendedResourceCommoditiesBought(pod *api.Pod) ([]*proto.CommodityDTO, error) {
+	requests := getPodExtendedResourceRequests(pod)
+	var names []string
+	for name, quantity := range requests {
+		if quantity > 0 {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	var commoditiesBought []*proto.CommodityDTO
+	for _, name := range names {
+		commBought, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_GPU_SLICE).
+			Key(name).
+			Used(requests[api.ResourceName(name)]).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, commBought)
+	}
+	return commoditiesBought, nil
+}
@@ -0,0 +1,91 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newGPUPod(name string, phase api.PodPhase, gpuRequests, gpuLimits string) *api.Pod {
+	container := api.Container{Name: "main", Resources: api.ResourceRequirements{
+		Requests: api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+		Limits:   api.ResourceList{},
+	}}
+	if gpuRequests != "" {
+		container.Resources.Requests["nvidia.com/gpu"] = resource.MustParse(gpuRequests)
+	}
+	if gpuLimits != "" {
+		container.Resources.Limits["nvidia.com/gpu"] = resource.MustParse(gpuLimits)
+	}
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       api.PodSpec{NodeName: "node-1", Containers: []api.Container{container}},
+		Status:     api.PodStatus{Phase: phase},
+	}
+}
+
+func TestIsExtendedResource(t *testing.T) {
+	assert.True(t, isExtendedResource("nvidia.com/gpu"))
+	assert.True(t, isExtendedResource("example.com/dongle"))
+	assert.False(t, isExtendedResource(api.ResourceCPU))
+	assert.False(t, isExtendedResource(api.ResourceEphemeralStorage))
+	assert.False(t, isExtendedResource("kubernetes.io/batch-job"))
+	assert.False(t, isExtendedResource("requests.nvidia.com/gpu"))
+}
+
+func TestGetPodExtendedResourceRequests(t *testing.T) {
+	pod := newGPUPod("pod-1", api.PodRunning, "", "2")
+	pod.Spec.Containers = append(pod.Spec.Containers, newGPUPod("pod-2", api.PodRunning, "1", "1").Spec.Containers...)
+	pod.Spec.InitContainers = newGPUPod("init", api.PodRunning, "4", "4").Spec.Containers
+	// The init container requests more than the containers together
+	assert.Equal(t, map[api.ResourceName]float64{"nvidia.com/gpu": 4}, getPodExtendedResourceRequests(pod))
+
+	pod.Spec.InitContainers = nil
+	// The limit is the request of a container without it
+	assert.Equal(t, map[api.ResourceName]float64{"nvidia.com/gpu": 3}, getPodExtendedResourceRequests(pod))
+}
+
+func TestGetExtendedResourceCommoditiesSold(t *testing.T) {
+	node := &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{Allocatable: api.ResourceList{
+			api.ResourceCPU:      resource.MustParse("8"),
+			"nvidia.com/gpu":     resource.MustParse("4"),
+			"example.com/dongle": resource.MustParse("0"),
+		}},
+	}
+	otherNodePod := newGPUPod("pod-3", api.PodRunning, "1", "1")
+	otherNodePod.Spec.NodeName = "node-2"
+	pods := []*api.Pod{
+		newGPUPod("pod-1", api.PodRunning, "2", "2"),
+		newGPUPod("pod-2", api.PodSucceeded, "1", "1"),
+		otherNodePod,
+	}
+
+	commoditiesSold, err := getExtendedResourceCommoditiesSold(node, pods)
+	assert.NoError(t, err)
+	// The extended resources not allocated are not sold
+	assert.Len(t, commoditiesSold, 1)
+	assert.Equal(t, proto.CommodityDTO_GPU_SLICE, commoditiesSold[0].GetCommodityType())
+	assert.Equal(t, "nvidia.com/gpu", commoditiesSold[0].GetKey())
+	assert.EqualValues(t, 4, commoditiesSold[0].GetCapacity())
+	assert.EqualValues(t, 2, commoditiesSold[0].GetUsed())
+	assert.False(t, commoditiesSold[0].GetResizable())
+}
+
+func TestGetExtendedResourceCommoditiesBought(t *testing.T) {
+	commoditiesBought, err := getExtendedResourceCommoditiesBought(newGPUPod("pod-1", api.PodRunning, "", ""))
+	assert.NoError(t, err)
+	assert.Empty(t, commoditiesBought)
+
+	commoditiesBought, err = getExtendedResourceCommoditiesBought(newGPUPod("pod-1", api.PodRunning, "1", "1"))
+	assert.NoError(t, err)
+	assert.Len(t, commoditiesBought, 1)
+	assert.Equal(t, proto.CommodityDTO_GPU_SLICE, commoditiesBought[0].GetCommodityType())
+	assert.Equal(t, "nvidia.com/gpu", commoditiesBought[0].GetKey())
+	assert.EqualValues(t, 1, commoditiesBought[0].GetUsed())
+}
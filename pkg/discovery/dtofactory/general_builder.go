@@ -10,6 +10,7 @@ import (
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 
@@ -34,6 +35,7 @@ var (
 		metrics.VStorage:           proto.CommodityDTO_VSTORAGE,
 		metrics.StorageAmount:      proto.CommodityDTO_STORAGE_AMOUNT,
 		metrics.VCPUThrottling:     proto.CommodityDTO_VCPU_THROTTLING,
+		metrics.NetThroughput:      proto.CommodityDTO_NET_THROUGHPUT,
 	}
 )
 
@@ -126,6 +128,41 @@ func (builder generalBuilder) getNodeCPUFrequencyViaPod(pod *api.Pod) (float64,
 	return cpuFrequency, nil
 }
 
+// getSwapUsedProperty returns the swap usage property of the entity, if the swap usage is collected.
+func (builder generalBuilder) getSwapUsedProperty(eType metrics.DiscoveredEntityType, key string) *proto.EntityDTO_EntityProperty {
+	swapUsedUID := metrics.GenerateEntityStateMetricUID(eType, key, metrics.SwapUsed)
+	swapUsedMetric, err := builder.metricsSink.GetMetric(swapUsedUID)
+	if err != nil {
+		return nil
+	}
+	swapUsedKB, ok := swapUsedMetric.GetValue().(float64)
+	if !ok {
+		return nil
+	}
+	return property.BuildSwapUsedProperty(swapUsedKB)
+}
+
+// getContainerRestartProperties returns the restart properties of the container with the given status, if its
+// restarts since the previous discovery are collected.
+func (builder generalBuilder) getContainerRestartProperties(key string,
+	status *api.ContainerStatus) []*proto.EntityDTO_EntityProperty {
+	restartsMetric, err := builder.metricsSink.GetMetric(
+		metrics.GenerateEntityStateMetricUID(metrics.ContainerType, key, metrics.Restarts))
+	if err != nil {
+		return nil
+	}
+	restarts, ok := restartsMetric.GetValue().(float64)
+	if !ok {
+		return nil
+	}
+	oomKilled := false
+	if oomKilledMetric, err := builder.metricsSink.GetMetric(
+		metrics.GenerateEntityStateMetricUID(metrics.ContainerType, key, metrics.OOMKilled)); err == nil {
+		oomKilled, _ = oomKilledMetric.GetValue().(bool)
+	}
+	return property.BuildContainerRestartProperties(status.RestartCount, restarts, oomKilled)
+}
+
 // Create commodity DTOs for the given list of resources
 // Note: cpuFrequency is the speed of CPU for a node. It is passed in as a parameter to convert
 // the cpu resource metric values from Kubernetes that is specified in number of cores to MHz.
@@ -164,6 +201,16 @@ func (builder generalBuilder) getSoldResourceCommodityWithKey(entityType metrics
 	if err != nil {
 		return nil, err
 	}
+	// Use the percentile over the utilization history window as the used value if it is collected
+	percentileUID := metrics.GenerateEntityResourceMetricUID(entityType, entityID, resourceType, metrics.UsedPercentile)
+	if _, err := builder.metricsSink.GetMetric(percentileUID); err == nil {
+		percentileValue, err := builder.metricValue(entityType, entityID,
+			resourceType, metrics.UsedPercentile, converter)
+		if err == nil {
+			metricValue.Avg = percentileValue.Avg
+			metricValue.Peak = math.Max(metricValue.Peak, percentileValue.Avg)
+		}
+	}
 
 	// Set used value as the average of multiple used metric points
 	commSoldBuilder.Used(metricValue.Avg)
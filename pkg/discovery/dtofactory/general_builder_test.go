@@ -349,3 +349,28 @@ func TestMetricValueWithThrottlingCumulativePoints(t *testing.T) {
 	assert.EqualValues(t, 32, int(metricValue.Avg))
 	assert.EqualValues(t, 50, int(metricValue.Peak))
 }
+
+func TestBuildCommSoldWithUsedPercentile(t *testing.T) {
+	metricsSink = metrics.NewEntityMetricSink()
+	metricsSink.AddNewMetricEntries(
+		metrics.NewEntityResourceMetric(metrics.ContainerType, container1, metrics.CPU, metrics.Used,
+			[]metrics.Point{{Value: 1, Timestamp: 1}, {Value: 3, Timestamp: 2}}),
+		metrics.NewEntityResourceMetric(metrics.ContainerType, container1, metrics.CPU, metrics.Capacity, 8.0))
+	dtoBuilder := &generalBuilder{
+		metricsSink: metricsSink,
+	}
+
+	commSold, err := dtoBuilder.getSoldResourceCommodityWithKey(metrics.ContainerType, container1, metrics.CPU, "", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, commSold.GetUsed())
+	assert.Equal(t, 3.0, commSold.GetPeak())
+
+	// The percentile over the utilization history is used if present
+	metricsSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.ContainerType, container1, metrics.CPU,
+		metrics.UsedPercentile, 5.0))
+	commSold, err = dtoBuilder.getSoldResourceCommodityWithKey(metrics.ContainerType, container1, metrics.CPU, "", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5.0, commSold.GetUsed())
+	assert.Equal(t, 5.0, commSold.GetPeak())
+	assert.Equal(t, 8.0, commSold.GetCapacity())
+}
@@ -0,0 +1,44 @@
+package dtofactory
+
+import (
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+// The resources of the commodities sold by the containers and the container specs which are bounded by the limit
+// ranges of their namespace
+var limitRangeBoundedResources = map[proto.CommodityDTO_CommodityType]metrics.ResourceType{
+	proto.CommodityDTO_VCPU:         metrics.CPU,
+	proto.CommodityDTO_VMEM:         metrics.Memory,
+	proto.CommodityDTO_VCPU_REQUEST: metrics.CPURequest,
+	proto.CommodityDTO_VMEM_REQUEST: metrics.MemoryRequest,
+}
+
+// setLimitRangeBounds sets the minimum and the maximum of the limit ranges of the given namespace as the bounds of the
+// resource commodities sold by a container or a container spec, so that their resizes are not rejected on admission.
+func setLimitRangeBounds(kubeNamespace *repository.KubeNamespace, commodities []*proto.CommodityDTO) {
+	if kubeNamespace == nil || len(kubeNamespace.LimitRanges) == 0 {
+		return
+	}
+	bounds := kubeNamespace.ContainerResourceBounds()
+	for _, commodity := range commodities {
+		resourceType, bounded := limitRangeBoundedResources[commodity.GetCommodityType()]
+		if !bounded {
+			continue
+		}
+		bound, found := bounds[resourceType]
+		if !found {
+			continue
+		}
+		if bound.Min > 0 {
+			minAmount := bound.Min
+			commodity.MinAmountForConsumer = &minAmount
+		}
+		if bound.Max > 0 {
+			maxAmount := bound.Max
+			commodity.MaxAmountForConsumer = &maxAmount
+		}
+	}
+}
@@ -45,10 +45,11 @@ var (
 
 	// List of commodities and a boolean indicating if the commodity should be resized
 	resizableCommodities = map[proto.CommodityDTO_CommodityType]bool{
-		proto.CommodityDTO_VCPU:         false,
-		proto.CommodityDTO_VMEM:         false,
-		proto.CommodityDTO_VCPU_REQUEST: false,
-		proto.CommodityDTO_VMEM_REQUEST: false,
+		proto.CommodityDTO_VCPU:           false,
+		proto.CommodityDTO_VMEM:           false,
+		proto.CommodityDTO_VCPU_REQUEST:   false,
+		proto.CommodityDTO_VMEM_REQUEST:   false,
+		proto.CommodityDTO_NET_THROUGHPUT: false,
 	}
 )
 
@@ -56,6 +57,8 @@ type nodeEntityDTOBuilder struct {
 	generalBuilder
 	stitchingManager   *stitching.StitchingManager
 	clusterKeyInjected string
+	// The pods running on the nodes, which use the extended resources of the nodes
+	pods []*api.Pod
 }
 
 func NewNodeEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *stitching.StitchingManager) *nodeEntityDTOBuilder {
@@ -70,6 +73,11 @@ func (builder *nodeEntityDTOBuilder) WithClusterKeyInjected(clusterKeyInjected s
 	return builder
 }
 
+func (builder *nodeEntityDTOBuilder) WithPods(pods []*api.Pod) *nodeEntityDTOBuilder {
+	builder.pods = pods
+	return builder
+}
+
 // BuildEntityDTOs builds entityDTOs based on the given node list.
 func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node, nodesPods map[string][]string,
 	hostnameSpreadWorkloads sets.String, otherSpreadPods sets.String, podsToControllers map[string]string) ([]*proto.EntityDTO, []string) {
@@ -126,7 +134,7 @@ func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node, nodesPod
 		entityDTOBuilder = entityDTOBuilder.WithProperties(properties)
 
 		// reconciliation meta data
-		metaData, err := builder.stitchingManager.GenerateReconciliationMetaData()
+		metaData, err := builder.stitchingManager.GenerateReconciliationMetaData(node.Name)
 		if err != nil {
 			glog.Errorf("Failed to build reconciling metadata for node %s: %s", displayName, err)
 			nodeActive = false
@@ -177,13 +185,18 @@ func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node, nodesPod
 			}
 		}
 
-		// Get CPU capacity in cores.
-		cpuMetricValue, err := builder.metricValue(metrics.NodeType, nodeKey, metrics.CPU, metrics.Capacity, nil)
-		if err != nil {
-			glog.Errorf("Failed to get number of CPU in cores for VM %s: %v", nodeKey, err)
-			continue
+		// Get CPU capacity in cores, from the total CPU of the node as the sold CPU capacity is only the allocatable
+		// CPU, net of the reserved CPU.
+		cpuMillicore, _ := util.GetCpuAndMemoryValues(node.Status.Capacity)
+		if cpuMillicore <= 0 {
+			cpuMetricValue, err := builder.metricValue(metrics.NodeType, nodeKey, metrics.CPU, metrics.Capacity, nil)
+			if err != nil {
+				glog.Errorf("Failed to get number of CPU in cores for VM %s: %v", nodeKey, err)
+				continue
+			}
+			cpuMillicore = cpuMetricValue.Avg
 		}
-		cpuCores := int32(math.Round(util.MetricMilliToUnit(cpuMetricValue.Avg)))
+		cpuCores := int32(math.Round(util.MetricMilliToUnit(cpuMillicore)))
 		vmdata := &proto.EntityDTO_VirtualMachineData{
 			IpAddress: getNodeIPs(node),
 			// Set numCPUs in cores.
@@ -220,7 +233,7 @@ func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node, nodesPod
 }
 
 // Build the sold commodityDTO by each node. They include:
-// VCPU, VMem, CPURequest, MemRequest;
+// VCPU, VMem, CPURequest, MemRequest, NetThroughput, GPU;
 // VMPMAccessCommodity, ApplicationCommodity, ClusterCommodity.
 func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node, clusterId string) ([]*proto.CommodityDTO, bool, error) {
 	var commoditiesSold []*proto.CommodityDTO
@@ -243,6 +256,12 @@ func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node, clus
 	resourceCommoditiesSold := builder.getResourceCommoditiesSold(metrics.NodeType, key, nodeResourceCommoditiesSold, converter, nil)
 	storageCommoditiesSold, isAvailableForPlacement := builder.getNodeStorageCommoditiesSold(node.Name)
 	resourceCommoditiesSold = append(resourceCommoditiesSold, storageCommoditiesSold...)
+	// Network throughput commodity, once the network usage of the node is collected
+	netThroughputCommSold, err := builder.getSoldResourceCommodityWithKey(metrics.NodeType, key, metrics.NetThroughput,
+		"", nil, nil)
+	if err == nil {
+		resourceCommoditiesSold = append(resourceCommoditiesSold, netThroughputCommSold)
+	}
 
 	// Disable vertical resize of the resource commodities for all nodes
 	for _, commSold := range resourceCommoditiesSold {
@@ -252,6 +271,13 @@ func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node, clus
 	}
 	commoditiesSold = append(commoditiesSold, resourceCommoditiesSold...)
 
+	// GPU commodities for the extended resources, e.g., nvidia.com/gpu
+	extendedResourceCommoditiesSold, err := getExtendedResourceCommoditiesSold(node, builder.pods)
+	if err != nil {
+		return nil, isAvailableForPlacement, err
+	}
+	commoditiesSold = append(commoditiesSold, extendedResourceCommoditiesSold...)
+
 	// Label commodities
 	for key, value := range node.ObjectMeta.Labels {
 		label := key + "=" + value
@@ -493,6 +519,9 @@ func (builder *nodeEntityDTOBuilder) getNodeProperties(node *api.Node) ([]*proto
 
 	// additional node info properties.
 	properties = append(properties, property.BuildNodeProperties(node)...)
+	if swapUsedProperty := builder.getSwapUsedProperty(metrics.NodeType, util.NodeKeyFunc(node)); swapUsedProperty != nil {
+		properties = append(properties, swapUsedProperty)
+	}
 	return properties, nil
 }
 
@@ -2,6 +2,7 @@ package dtofactory
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/golang/glog"
@@ -236,8 +237,19 @@ func Test_NodeEntityDTO(t *testing.T) {
 	nodePods := map[string][]string{node.Name: pods}
 	nodeEntityDTOs, _ := nodeEntityDTOBuilder.BuildEntityDTOs([]*api.Node{node}, nodePods, nil, nil, nil)
 	vmData := nodeEntityDTOs[0].GetVirtualMachineData()
-	// The capacity metric is set in millicores but numcpus is set in cores
-	assert.EqualValues(t, 10, vmData.GetNumCpus())
+	// Numcpus is set in cores from the total CPU of the node, not from the capacity metric of the allocatable CPU
+	assert.EqualValues(t, 2, vmData.GetNumCpus())
+	// The reserved CPU and memory are the difference between the total and the allocatable resources
+	reserved := make(map[string]string)
+	for _, p := range nodeEntityDTOs[0].GetEntityProperties() {
+		if strings.HasPrefix(p.GetName(), "KubernetesNodeReserved") {
+			reserved[p.GetName()] = p.GetValue()
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"KubernetesNodeReservedCPUMillicores": "500",
+		"KubernetesNodeReservedMemoryKB":      "2097152",
+	}, reserved)
 
 	// Confirm entity properties are populated and populated properly
 	matches := 0
@@ -0,0 +1,224 @@
+package dtofactory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+const (
+	// The context keys of the deployment profile of the nodes
+	nodeProfileInstanceTypeKey  = "instanceType"
+	nodeProfileCloudProviderKey = "cloudProvider"
+	nodeProfileRegionKey        = "region"
+)
+
+// The commodities of the templates of the nodes
+var nodeProfileCommodities = []proto.CommodityDTO_CommodityType{
+	proto.CommodityDTO_VCPU,
+	proto.CommodityDTO_VMEM,
+}
+
+// NodeProfileDTOBuilder builds the templates of the nodes, one per instance type present in the cluster, so that
+// the server can recommend provisioning nodes of a specific size.
+type NodeProfileDTOBuilder struct {
+	cluster   *repository.ClusterSummary
+	clusterId string
+}
+
+func NewNodeProfileDTOBuilder(cluster *repository.ClusterSummary, clusterId string) *NodeProfileDTOBuilder {
+	return &NodeProfileDTOBuilder{
+		cluster:   cluster,
+		clusterId: clusterId,
+	}
+}
+
+// Build builds the entity and deployment profiles of the instance types of the nodes, and sets the profile of the
+// given node entity DTOs. The capacities of a profile are the largest capacities of the nodes of its instance type.
+// The nodes without an instance type label have no profile.
+func (builder *NodeProfileDTOBuilder) Build(nodeDTOs []*proto.EntityDTO) ([]*proto.EntityProfileDTO,
+	[]*proto.DeploymentProfileDTO) {
+	nodeDTOsById := make(map[string]*proto.EntityDTO)
+	for _, nodeDTO := range nodeDTOs {
+		if nodeDTO.GetEntityType() == proto.EntityDTO_VIRTUAL_MACHINE {
+			nodeDTOsById[nodeDTO.GetId()] = nodeDTO
+		}
+	}
+	nodesByInstanceType := make(map[string][]*api.Node)
+	for _, node := range builder.cluster.Nodes {
+		if instanceType := getNodeInstanceType(node); instanceType != "" {
+			nodesByInstanceType[instanceType] = append(nodesByInstanceType[instanceType], node)
+		}
+	}
+	instanceTypes := make([]string, 0, len(nodesByInstanceType))
+	for instanceType := range nodesByInstanceType {
+		instanceTypes = append(instanceTypes, instanceType)
+	}
+	sort.Strings(instanceTypes)
+
+	var entityProfiles []*proto.EntityProfileDTO
+	var deploymentProfiles []*proto.DeploymentProfileDTO
+	for _, instanceType := range instanceTypes {
+		var profileNodeDTOs []*proto.EntityDTO
+		for _, node := range nodesByInstanceType[instanceType] {
+			if nodeDTO, found := nodeDTOsById[string(node.UID)]; found {
+				profileNodeDTOs = append(profileNodeDTOs, nodeDTO)
+			}
+		}
+		if len(profileNodeDTOs) == 0 {
+			continue
+		}
+		entityProfile := builder.buildEntityProfile(instanceType, nodesByInstanceType[instanceType], profileNodeDTOs)
+		for _, nodeDTO := range profileNodeDTOs {
+			nodeDTO.ProfileId = entityProfile.Id
+		}
+		entityProfiles = append(entityProfiles, entityProfile)
+		deploymentProfiles = append(deploymentProfiles,
+			builder.buildDeploymentProfile(instanceType, nodesByInstanceType[instanceType], entityProfile))
+		glog.V(4).Infof("Node profile %s: %+v", entityProfile.GetId(), entityProfile)
+	}
+	glog.V(2).Infof("Built %d node profiles for cluster %s.", len(entityProfiles), builder.cluster.Name)
+	return entityProfiles, deploymentProfiles
+}
+
+func (builder *NodeProfileDTOBuilder) buildEntityProfile(instanceType string, nodes []*api.Node,
+	nodeDTOs []*proto.EntityDTO) *proto.EntityProfileDTO {
+	id := fmt.Sprintf("NodeProfile::%s [%s]", instanceType, builder.clusterId)
+	entityType := proto.EntityDTO_VIRTUAL_MACHINE
+	description := fmt.Sprintf("Template of the %s nodes of cluster %s", instanceType, builder.cluster.Name)
+	enableProvisionMatch := true
+
+	var commodityProfiles []*proto.CommodityProfileDTO
+	for _, commodityType := range nodeProfileCommodities {
+		commodityType := commodityType
+		capacity, found := getMaxCommoditySoldCapacity(nodeDTOs, commodityType)
+		if !found {
+			continue
+		}
+		commodityProfiles = append(commodityProfiles, &proto.CommodityProfileDTO{
+			CommodityType: &commodityType,
+			Capacity:      &capacity,
+		})
+	}
+
+	var numVCPUs int32
+	for _, node := range nodes {
+		if cpus := int32(node.Status.Capacity.Cpu().Value()); cpus > numVCPUs {
+			numVCPUs = cpus
+		}
+	}
+
+	properties := []*proto.EntityDTO_EntityProperty{
+		property.BuildTagProperty(property.VCTagsPropertyNamespace, api.LabelInstanceTypeStable, instanceType),
+	}
+	for _, label := range []string{api.LabelTopologyRegion, api.LabelArchStable, api.LabelOSStable} {
+		if value := getCommonNodeLabel(nodes, label); value != "" {
+			properties = append(properties, property.BuildTagProperty(property.VCTagsPropertyNamespace, label, value))
+		}
+	}
+
+	entityProfile := &proto.EntityProfileDTO{
+		Id:                   &id,
+		DisplayName:          &instanceType,
+		EntityType:           &entityType,
+		CommodityProfile:     commodityProfiles,
+		Model:                &instanceType,
+		Description:          &description,
+		EnableProvisionMatch: &enableProvisionMatch,
+		EntityProperties:     properties,
+		EntityTypeSpecificData: &proto.EntityProfileDTO_VmProfileDTO{
+			VmProfileDTO: &proto.EntityProfileDTO_VMProfileDTO{
+				NumVCPUs: &numVCPUs,
+			},
+		},
+	}
+	if cloudProvider := getCommonNodeCloudProvider(nodes); cloudProvider != "" {
+		entityProfile.Vendor = &cloudProvider
+	}
+	return entityProfile
+}
+
+func (builder *NodeProfileDTOBuilder) buildDeploymentProfile(instanceType string, nodes []*api.Node,
+	entityProfile *proto.EntityProfileDTO) *proto.DeploymentProfileDTO {
+	id := fmt.Sprintf("NodeDeploymentProfile::%s [%s]", instanceType, builder.clusterId)
+	contextData := []*proto.ContextData{newContextData(nodeProfileInstanceTypeKey, instanceType)}
+	if cloudProvider := entityProfile.GetVendor(); cloudProvider != "" {
+		contextData = append(contextData, newContextData(nodeProfileCloudProviderKey, cloudProvider))
+	}
+	if region := getCommonNodeLabel(nodes, api.LabelTopologyRegion); region != "" {
+		contextData = append(contextData, newContextData(nodeProfileRegionKey, region))
+	}
+	return &proto.DeploymentProfileDTO{
+		Id:                     &id,
+		ProfileName:            &instanceType,
+		ContextData:            contextData,
+		RelatedEntityProfileId: []string{entityProfile.GetId()},
+		RelatedScopeId:         []string{builder.clusterId},
+	}
+}
+
+func newContextData(key, value string) *proto.ContextData {
+	return &proto.ContextData{
+		ContextKey:   &key,
+		ContextValue: &value,
+	}
+}
+
+// getNodeInstanceType returns the instance type of a node from the stable label, or the deprecated beta label.
+func getNodeInstanceType(node *api.Node) string {
+	if instanceType := node.Labels[api.LabelInstanceTypeStable]; instanceType != "" {
+		return instanceType
+	}
+	return node.Labels[api.LabelInstanceType]
+}
+
+// getCommonNodeLabel returns the value of the label if all the nodes have the same value, or an empty string.
+func getCommonNodeLabel(nodes []*api.Node, label string) string {
+	var common string
+	for i, node := range nodes {
+		value := node.Labels[label]
+		if i > 0 && value != common {
+			return ""
+		}
+		common = value
+	}
+	return common
+}
+
+// getCommonNodeCloudProvider returns the cloud provider of the nodes from the scheme of their provider ID, e.g. aws
+// for aws:///us-west-2a/i-0be85bb9db1707470, if all the nodes have the same one, or an empty string.
+func getCommonNodeCloudProvider(nodes []*api.Node) string {
+	var common string
+	for i, node := range nodes {
+		cloudProvider, _, found := strings.Cut(node.Spec.ProviderID, "://")
+		if !found || (i > 0 && cloudProvider != common) {
+			return ""
+		}
+		common = cloudProvider
+	}
+	return common
+}
+
+func getMaxCommoditySoldCapacity(entityDTOs []*proto.EntityDTO,
+	commodityType proto.CommodityDTO_CommodityType) (float32, bool) {
+	var capacity float64
+	found := false
+	for _, entityDTO := range entityDTOs {
+		for _, commodity := range entityDTO.GetCommoditiesSold() {
+			if commodity.GetCommodityType() == commodityType && commodity.Capacity != nil {
+				if !found || commodity.GetCapacity() > capacity {
+					capacity = commodity.GetCapacity()
+				}
+				found = true
+			}
+		}
+	}
+	return float32(capacity), found
+}
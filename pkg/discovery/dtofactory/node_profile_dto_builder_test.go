@@ -0,0 +1,111 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+func newProfileNode(name, instanceType, zone string) *api.Node {
+	node := &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID(name + "-uid"),
+			Labels: map[string]string{
+				api.LabelTopologyRegion: "us-west-2",
+				api.LabelTopologyZone:   zone,
+			},
+		},
+		Spec:   api.NodeSpec{ProviderID: "aws:///" + zone + "/i-" + name},
+		Status: api.NodeStatus{Capacity: api.ResourceList{api.ResourceCPU: resource.MustParse("4")}},
+	}
+	if instanceType != "" {
+		node.Labels[api.LabelInstanceTypeStable] = instanceType
+	}
+	return node
+}
+
+func newProfileNodeDTO(t *testing.T, node *api.Node, vcpu, vmem float64) *proto.EntityDTO {
+	vcpuComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU).Capacity(vcpu).Create()
+	assert.NoError(t, err)
+	vmemComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMEM).Capacity(vmem).Create()
+	assert.NoError(t, err)
+	nodeDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID)).
+		SellsCommodities([]*proto.CommodityDTO{vcpuComm, vmemComm}).
+		Create()
+	assert.NoError(t, err)
+	return nodeDTO
+}
+
+func TestNodeProfileDTOBuilder(t *testing.T) {
+	nodes := []*api.Node{
+		newProfileNode("node-1", "m5.xlarge", "us-west-2a"),
+		newProfileNode("node-2", "m5.xlarge", "us-west-2b"),
+		newProfileNode("node-3", "", "us-west-2a"),
+	}
+	nodeDTOs := []*proto.EntityDTO{
+		newProfileNodeDTO(t, nodes[0], 8000, 16000000),
+		newProfileNodeDTO(t, nodes[1], 8400, 15000000),
+		newProfileNodeDTO(t, nodes[2], 8000, 16000000),
+	}
+	clusterSummary := &repository.ClusterSummary{
+		KubeCluster: repository.NewKubeCluster("cluster", nodes),
+	}
+
+	entityProfiles, deploymentProfiles := NewNodeProfileDTOBuilder(clusterSummary, "cluster-id").Build(nodeDTOs)
+	// The nodes without an instance type have no profile
+	assert.Len(t, entityProfiles, 1)
+	assert.Len(t, deploymentProfiles, 1)
+
+	entityProfile := entityProfiles[0]
+	assert.Equal(t, "NodeProfile::m5.xlarge [cluster-id]", entityProfile.GetId())
+	assert.Equal(t, "m5.xlarge", entityProfile.GetDisplayName())
+	assert.Equal(t, proto.EntityDTO_VIRTUAL_MACHINE, entityProfile.GetEntityType())
+	assert.Equal(t, "aws", entityProfile.GetVendor())
+	assert.True(t, entityProfile.GetEnableProvisionMatch())
+	assert.EqualValues(t, 4, entityProfile.GetVmProfileDTO().GetNumVCPUs())
+	// The largest capacities of the nodes of the instance type
+	capacities := make(map[proto.CommodityDTO_CommodityType]float32)
+	for _, commodityProfile := range entityProfile.GetCommodityProfile() {
+		capacities[commodityProfile.GetCommodityType()] = commodityProfile.GetCapacity()
+	}
+	assert.Equal(t, map[proto.CommodityDTO_CommodityType]float32{
+		proto.CommodityDTO_VCPU: 8400,
+		proto.CommodityDTO_VMEM: 16000000,
+	}, capacities)
+	// The zone differs between the nodes, so it is not a property of the profile
+	properties := make(map[string]string)
+	for _, entityProperty := range entityProfile.GetEntityProperties() {
+		properties[entityProperty.GetName()] = entityProperty.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		api.LabelInstanceTypeStable: "m5.xlarge",
+		api.LabelTopologyRegion:     "us-west-2",
+	}, properties)
+
+	deploymentProfile := deploymentProfiles[0]
+	assert.Equal(t, []string{entityProfile.GetId()}, deploymentProfile.GetRelatedEntityProfileId())
+	assert.Equal(t, []string{"cluster-id"}, deploymentProfile.GetRelatedScopeId())
+	contextData := make(map[string]string)
+	for _, data := range deploymentProfile.GetContextData() {
+		contextData[data.GetContextKey()] = data.GetContextValue()
+	}
+	assert.Equal(t, map[string]string{
+		nodeProfileInstanceTypeKey:  "m5.xlarge",
+		nodeProfileCloudProviderKey: "aws",
+		nodeProfileRegionKey:        "us-west-2",
+	}, contextData)
+
+	// The nodes refer to the profile of their instance type
+	assert.Equal(t, entityProfile.GetId(), nodeDTOs[0].GetProfileId())
+	assert.Equal(t, entityProfile.GetId(), nodeDTOs[1].GetProfileId())
+	assert.Empty(t, nodeDTOs[2].GetProfileId())
+}
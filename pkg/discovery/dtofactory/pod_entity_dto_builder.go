@@ -81,6 +81,10 @@ type podEntityDTOBuilder struct {
 	hostnameSpreadPods   sets.String
 	otherSpreadPods      sets.String
 	podsToControllers    map[string]string
+	// Whether the pods of the Jobs are movable, suspendable and provisionable
+	includeBatchWorkloads bool
+	// Whether the pods of a namespace are modeled as the overhead of their nodes, pinned and with no action
+	isOverheadNamespace func(namespace string) bool
 }
 
 func NewPodEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *stitching.StitchingManager, clusterScraper *cluster.ClusterScraper) *podEntityDTOBuilder {
@@ -136,6 +140,20 @@ func (builder *podEntityDTOBuilder) WithMirrorPodToDaemonMap(mirrorPodToDaemonMa
 	return builder
 }
 
+// WithBatchWorkloadsIncluded sets whether the pods of the Jobs are movable, suspendable and provisionable like the
+// pods of the other workloads.
+func (builder *podEntityDTOBuilder) WithBatchWorkloadsIncluded(includeBatchWorkloads bool) *podEntityDTOBuilder {
+	builder.includeBatchWorkloads = includeBatchWorkloads
+	return builder
+}
+
+// WithOverheadNamespaces sets the namespaces whose pods are modeled as the overhead of their nodes, neither movable,
+// suspendable, provisionable nor controllable, e.g. the infrastructure namespaces like kube-system.
+func (builder *podEntityDTOBuilder) WithOverheadNamespaces(isOverheadNamespace func(namespace string) bool) *podEntityDTOBuilder {
+	builder.isOverheadNamespace = isOverheadNamespace
+	return builder
+}
+
 func (builder *podEntityDTOBuilder) WithPodsWithAffinities(podsWithAffinities sets.String) *podEntityDTOBuilder {
 	builder.podsWithAffinities = podsWithAffinities
 	return builder
@@ -186,6 +204,15 @@ func (builder *podEntityDTOBuilder) buildDTOs(pods []*api.Pod, resCommTypeSold,
 		if hasKey {
 			mirrorPodUids = append(mirrorPodUids, podID)
 		}
+		// The static pods are run by the kubelets, and their mirror pods cannot be moved, suspended or cloned
+		// through the API server, like the daemon pods, whether or not they are modeled as daemons
+		staticPod := util.IsMirrorPod(pod)
+		// The pods of the Jobs run to completion, so moving or cloning them restarts their work, unless included
+		batchPod := !builder.includeBatchWorkloads && util.IsJobPod(pod)
+		// The pods of the infrastructure namespaces modeled as the overhead of their nodes
+		overheadPod := builder.isOverheadNamespace != nil && builder.isOverheadNamespace(pod.Namespace)
+		// The pods which are neither moved, suspended nor cloned
+		pinned := daemon || staticPod || batchPod || overheadPod
 
 		// display name.
 		displayName := util.GetPodClusterID(pod)
@@ -221,8 +248,8 @@ func (builder *podEntityDTOBuilder) buildDTOs(pods []*api.Pod, resCommTypeSold,
 		provider := sdkbuilder.CreateProvider(proto.EntityDTO_VIRTUAL_MACHINE, providerNodeUID)
 		entityDTOBuilder = entityDTOBuilder.Provider(provider)
 
-		// pods are movable across nodes except for the daemon pods
-		if daemon {
+		// pods are movable across nodes except for the daemon, static and batch pods
+		if pinned {
 			entityDTOBuilder.IsMovable(proto.EntityDTO_VIRTUAL_MACHINE, false)
 		}
 
@@ -269,16 +296,18 @@ func (builder *podEntityDTOBuilder) buildDTOs(pods []*api.Pod, resCommTypeSold,
 		}
 
 		mounts := builder.podToVolumesMap[displayName]
-		controllable := util.Controllable(pod, mirrorPodDaemon)
-		monitored := true
+		// The daemon set pods can neither be moved nor suspended, and are only resized through their daemon set, so
+		// no action is generated for them
+		controllable := util.Controllable(pod, mirrorPodDaemon) && !util.IsDaemonSetPod(pod) && !overheadPod
+		monitored := util.IsMonitoredFromAnnotation(pod.GetAnnotations())
 		suspendable := true
 		provisionable := true
 		powerState := proto.EntityDTO_POWERED_ON
 		if !builder.isContainerMetricsAvailable(pod) {
 			powerState = proto.EntityDTO_POWERSTATE_UNKNOWN
 		}
-		// action eligibility for daemon pods
-		if daemon {
+		// action eligibility for daemon, static and batch pods
+		if pinned {
 			suspendable = false
 			provisionable = false
 		}
@@ -416,7 +445,7 @@ func (builder *podEntityDTOBuilder) getPodQuotaCommoditiesSold(pod *api.Pod) ([]
 }
 
 // Build the CommodityDTOs bought by the pod from the node provider.
-// Commodities bought are vCPU, vMem vmpm access, cluster
+// Commodities bought are vCPU, vMem, NetThroughput, GPU, vmpm access, cluster
 func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod,
 	resCommTypeBoughtFromNode []metrics.ResourceType) ([]*proto.CommodityDTO, error) {
 	var commoditiesBought []*proto.CommodityDTO
@@ -434,6 +463,26 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod,
 	}
 	commoditiesBought = append(commoditiesBought, resourceCommoditiesBought...)
 
+	// Network throughput commodity, once the network usage of the pod is collected
+	netThroughput, err := builder.metricValue(metrics.PodType, podMId, metrics.NetThroughput, metrics.Used, nil)
+	if err == nil {
+		netThroughputComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_NET_THROUGHPUT).
+			Used(netThroughput.Avg).
+			Peak(netThroughput.Peak).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, netThroughputComm)
+	}
+
+	// GPU commodities for the extended resources requested, e.g., nvidia.com/gpu
+	extendedResourceCommoditiesBought, err := getExtendedResourceCommoditiesBought(pod)
+	if err != nil {
+		return nil, err
+	}
+	commoditiesBought = append(commoditiesBought, extendedResourceCommoditiesBought...)
+
 	// Label commodities
 	for key, value := range pod.Spec.NodeSelector {
 		selector := key + "=" + value
@@ -15,6 +15,7 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/test/synthetic"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	"k8s.io/apiserver/pkg/util/feature"
 )
@@ -497,3 +498,175 @@ func Test_getPodCommoditiesBought_SpreadWithPodAntiAffinity(t *testing.T) {
 	assert.Equal(t, 1, len(commoditiesBought))
 	assert.Equal(t, proto.CommodityDTO_SEGMENTATION, *commoditiesBought[0].CommodityType)
 }
+
+func Test_getPodCommoditiesBought_NetThroughput(t *testing.T) {
+	mockPod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pod-1",
+			Namespace: "test-namespace",
+			UID:       "test-pod-1-UID",
+		},
+	}
+	podBuilder := &podEntityDTOBuilder{
+		generalBuilder: newGeneralBuilder(metrics.NewEntityMetricSink()),
+	}
+	podBuilder.metricsSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.PodType,
+		"test-namespace/test-pod-1", metrics.NetThroughput, metrics.Used, float64(100)))
+
+	commoditiesBought, err := podBuilder.getPodCommoditiesBought(mockPod, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(commoditiesBought))
+	assert.Equal(t, proto.CommodityDTO_NET_THROUGHPUT, commoditiesBought[0].GetCommodityType())
+	assert.Equal(t, float64(100), commoditiesBought[0].GetUsed())
+}
+
+func Test_podEntityDTOBuilder_BatchWorkloads(t *testing.T) {
+	cluster, err := synthetic.Generate(synthetic.Spec{Nodes: 1, Pods: 3, Namespaces: 1, PodsPerController: 1, Seed: 1})
+	assert.NoError(t, err)
+	isController := true
+	// The pod of a Job, and the pod of a Job created by a CronJob, which owns the Job but not its pods
+	jobPod, cronJobPod, replicaSetPod := cluster.Pods[0], cluster.Pods[1], cluster.Pods[2]
+	jobPod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "batch/v1",
+		Kind:       util.Kind_Job,
+		Name:       "job",
+		UID:        "job-uid",
+		Controller: &isController,
+	}}
+	cronJobPod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "batch/v1",
+		Kind:       util.Kind_Job,
+		Name:       "cronjob-27766560",
+		UID:        "cronjob-27766560-uid",
+		Controller: &isController,
+	}}
+
+	tests := []struct {
+		name                  string
+		includeBatchWorkloads bool
+		expectedPinned        map[*api.Pod]bool
+	}{
+		{
+			name:                  "batch workloads excluded",
+			includeBatchWorkloads: false,
+			expectedPinned:        map[*api.Pod]bool{jobPod: true, cronJobPod: true, replicaSetPod: false},
+		},
+		{
+			name:                  "batch workloads included",
+			includeBatchWorkloads: true,
+			expectedPinned:        map[*api.Pod]bool{jobPod: false, cronJobPod: false, replicaSetPod: false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podDTOs, _, _, _ := NewPodEntityDTOBuilder(cluster.Sink, newSyntheticStitchingManager(cluster), nil).
+				WithNodeNameUIDMap(cluster.NodeNameUIDMap()).
+				WithNameSpaceUIDMap(cluster.NamespaceUIDMap()).
+				WithRunningPods(cluster.Pods).
+				WithBatchWorkloadsIncluded(tt.includeBatchWorkloads).
+				BuildEntityDTOs()
+			assert.Len(t, podDTOs, len(cluster.Pods))
+			for pod, pinned := range tt.expectedPinned {
+				podDTO := findPodDTO(podDTOs, string(pod.UID))
+				if !assert.NotNil(t, podDTO, pod.Name) {
+					continue
+				}
+				assert.Equal(t, !pinned, isMovableOnNode(podDTO), pod.Name)
+				assert.Equal(t, !pinned, podDTO.GetActionEligibility().GetSuspendable(), pod.Name)
+				assert.Equal(t, !pinned, podDTO.GetActionEligibility().GetCloneable(), pod.Name)
+			}
+		})
+	}
+}
+
+func Test_podEntityDTOBuilder_DaemonSetPods(t *testing.T) {
+	cluster, err := synthetic.Generate(synthetic.Spec{Nodes: 1, Pods: 2, Namespaces: 1, PodsPerController: 1,
+		MaxContainersPerPod: 2, Seed: 1})
+	assert.NoError(t, err)
+	daemonSetPod, replicaSetPod := cluster.Pods[0], cluster.Pods[1]
+	// The containers of the pod before it is owned by a DaemonSet
+	expectedContainerDTOs := map[string]*proto.EntityDTO{}
+	for _, containerDTO := range buildSyntheticContainerDTOs(cluster) {
+		expectedContainerDTOs[containerDTO.GetId()] = containerDTO
+	}
+	isController := true
+	daemonSetPod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       util.Kind_DaemonSet,
+		Name:       "daemonset",
+		UID:        "daemonset-uid",
+		Controller: &isController,
+	}}
+
+	podDTOs := buildSyntheticPodDTOs(cluster, newSyntheticStitchingManager(cluster), cluster.NodeNameUIDMap(),
+		cluster.NamespaceUIDMap())
+	assert.Len(t, podDTOs, len(cluster.Pods))
+	// The DaemonSet pods are neither moved nor suspended, so no action is generated for them
+	podDTO := findPodDTO(podDTOs, string(daemonSetPod.UID))
+	if assert.NotNil(t, podDTO) {
+		assert.False(t, podDTO.GetConsumerPolicy().GetControllable())
+		assert.True(t, podDTO.GetConsumerPolicy().GetDaemon())
+		assert.False(t, isMovableOnNode(podDTO))
+	}
+	podDTO = findPodDTO(podDTOs, string(replicaSetPod.UID))
+	if assert.NotNil(t, podDTO) {
+		assert.True(t, podDTO.GetConsumerPolicy().GetControllable())
+		assert.False(t, podDTO.GetConsumerPolicy().GetDaemon())
+	}
+
+	// The containers of the DaemonSet pods are still resized through their DaemonSet
+	containerDTOs := buildSyntheticContainerDTOs(cluster)
+	assert.Len(t, containerDTOs, len(expectedContainerDTOs))
+	daemonSetContainers := 0
+	for i := range daemonSetPod.Spec.Containers {
+		containerID := util.ContainerIdFunc(string(daemonSetPod.UID), i)
+		containerDTO, expected := findContainerDTO(containerDTOs, containerID), expectedContainerDTOs[containerID]
+		if !assert.NotNil(t, containerDTO, containerID) || !assert.NotNil(t, expected, containerID) {
+			continue
+		}
+		daemonSetContainers++
+		assert.True(t, containerDTO.GetConsumerPolicy().GetControllable(), containerDTO.GetDisplayName())
+		assert.Equal(t, resizableCommoditiesSold(expected), resizableCommoditiesSold(containerDTO), containerID)
+	}
+	assert.Equal(t, len(daemonSetPod.Spec.Containers), daemonSetContainers)
+}
+
+// resizableCommoditiesSold returns whether the commodities sold by the entity of the given DTO are resizable by type.
+func resizableCommoditiesSold(entityDTO *proto.EntityDTO) map[proto.CommodityDTO_CommodityType]bool {
+	resizable := map[proto.CommodityDTO_CommodityType]bool{}
+	for _, commodity := range entityDTO.GetCommoditiesSold() {
+		resizable[commodity.GetCommodityType()] = commodity.GetResizable()
+	}
+	return resizable
+}
+
+func findContainerDTO(containerDTOs []*proto.EntityDTO, containerID string) *proto.EntityDTO {
+	for _, containerDTO := range containerDTOs {
+		if containerDTO.GetId() == containerID {
+			return containerDTO
+		}
+	}
+	return nil
+}
+
+func findPodDTO(podDTOs []*proto.EntityDTO, podUID string) *proto.EntityDTO {
+	for _, podDTO := range podDTOs {
+		if podDTO.GetId() == podUID {
+			return podDTO
+		}
+	}
+	return nil
+}
+
+// isMovableOnNode returns whether the pod of the given DTO is movable across the nodes, which it is unless it is
+// explicitly marked as not movable.
+func isMovableOnNode(podDTO *proto.EntityDTO) bool {
+	for _, commBought := range podDTO.GetCommoditiesBought() {
+		if commBought.GetProviderType() == proto.EntityDTO_VIRTUAL_MACHINE {
+			actionEligibility := commBought.GetActionEligibility()
+			return actionEligibility == nil || actionEligibility.Movable == nil || *actionEligibility.Movable
+		}
+	}
+	return false
+}
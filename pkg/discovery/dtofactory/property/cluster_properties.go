@@ -7,17 +7,28 @@ import (
 const (
 	vcpuUnit          = "VCPUUnit"
 	unitTypeMillicore = "Millicore"
+	k8sClusterId      = "KubernetesClusterId"
+	k8sClusterName    = "KubernetesClusterName"
 )
 
-// Build the cluster property to depict this cluster now uses millicores as units for vcpu & related commodities.
-func BuildClusterProperty() []*proto.EntityDTO_EntityProperty {
+// Build the cluster property to depict this cluster now uses millicores as units for vcpu & related commodities,
+// and the properties identifying the cluster across the targets: its id, i.e., the UID of the kube-system
+// namespace, and its name, i.e., the target id. The empty ones are not built.
+func BuildClusterProperty(clusterId, clusterName string) []*proto.EntityDTO_EntityProperty {
 	var properties []*proto.EntityDTO_EntityProperty
 	propertyNamespace := k8sPropertyNamespace
 	propertyName := vcpuUnit
 	propertyValue := unitTypeMillicore
-	return append(properties, &proto.EntityDTO_EntityProperty{
+	properties = append(properties, &proto.EntityDTO_EntityProperty{
 		Namespace: &propertyNamespace,
 		Name:      &propertyName,
 		Value:     &propertyValue,
 	})
+	if clusterId != "" {
+		properties = append(properties, BuildTagProperty(k8sPropertyNamespace, k8sClusterId, clusterId))
+	}
+	if clusterName != "" {
+		properties = append(properties, BuildTagProperty(k8sPropertyNamespace, k8sClusterName, clusterName))
+	}
+	return properties
 }
@@ -1,16 +1,33 @@
 package property
 
 import (
+	"strconv"
+
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	api "k8s.io/api/core/v1"
 )
 
 const TaintPropertyNamePrefix = "[k8s taint]"
 
-// BuildNodeProperties builds entity properties for a node. It brings over the following 3 things as properties:
+// The properties of the topology of a node, each set from the first of its labels found on the node, so that the
+// placement policies can be defined regardless of the deprecated labels set by the older clusters
+var nodeTopologyProperties = []struct {
+	name   string
+	labels []string
+}{
+	{k8sNodeRegion, []string{api.LabelTopologyRegion, api.LabelFailureDomainBetaRegion}},
+	{k8sNodeZone, []string{api.LabelTopologyZone, api.LabelFailureDomainBetaZone}},
+	{k8sNodeInstanceType, []string{api.LabelInstanceTypeStable, api.LabelInstanceType}},
+}
+
+// BuildNodeProperties builds entity properties for a node. It brings over the following 4 things as properties:
 // 1. The name of the node shown inside Kubernetes cluster; the property name is "KubernetesNodeName".
-// 2. The labels of the node; each label's key-value pair is directly brought over as tags.
-// 3. The taints of the node.
+// 2. The region, zone and instance type of the node, from its topology labels; the property names are
+// "KubernetesNodeRegion", "KubernetesNodeZone" and "KubernetesNodeInstanceType".
+// 3. The labels of the node; each label's key-value pair is directly brought over as tags.
+// 4. The taints of the node.
+// 5. The CPU and memory reserved on the node for the system, the difference between its capacity and its allocatable
+// resources; the property names are "KubernetesNodeReservedCPUMillicores" and "KubernetesNodeReservedMemoryKB".
 func BuildNodeProperties(node *api.Node) []*proto.EntityDTO_EntityProperty {
 	var properties []*proto.EntityDTO_EntityProperty
 	propertyNamespace := k8sPropertyNamespace
@@ -22,6 +39,8 @@ func BuildNodeProperties(node *api.Node) []*proto.EntityDTO_EntityProperty {
 		Value:     &propertyValue,
 	}
 	properties = append(properties, nameProperty)
+	properties = append(properties, buildNodeTopologyProperties(node)...)
+	properties = append(properties, buildNodeReservedProperties(node)...)
 
 	tagsPropertyNamespace := VCTagsPropertyNamespace
 	labels := node.GetLabels()
@@ -55,6 +74,48 @@ func BuildNodeProperties(node *api.Node) []*proto.EntityDTO_EntityProperty {
 	return properties
 }
 
+// buildNodeTopologyProperties builds the region, zone and instance type properties of the node which has the labels.
+func buildNodeTopologyProperties(node *api.Node) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	labels := node.GetLabels()
+	for _, topology := range nodeTopologyProperties {
+		for _, label := range topology.labels {
+			if value, found := labels[label]; found && value != "" {
+				properties = append(properties, BuildTagProperty(k8sPropertyNamespace, topology.name, value))
+				break
+			}
+		}
+	}
+	return properties
+}
+
+// buildNodeReservedProperties builds the reserved CPU and memory properties of the node which reports allocatable
+// resources below its capacity. The reserved resources are the overhead of the node, which is not sold to the pods.
+func buildNodeReservedProperties(node *api.Node) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	for _, reserved := range []struct {
+		name     string
+		resource api.ResourceName
+	}{{k8sNodeReservedCPU, api.ResourceCPU}, {k8sNodeReservedMemory, api.ResourceMemory}} {
+		capacity, hasCapacity := node.Status.Capacity[reserved.resource]
+		allocatable, hasAllocatable := node.Status.Allocatable[reserved.resource]
+		if !hasCapacity || !hasAllocatable {
+			continue
+		}
+		capacity.Sub(allocatable)
+		// The CPU in millicores and the memory in kilobytes, as their commodities
+		amount := capacity.MilliValue()
+		if reserved.resource == api.ResourceMemory {
+			amount = capacity.Value() / 1024
+		}
+		if amount > 0 {
+			properties = append(properties, BuildTagProperty(k8sPropertyNamespace, reserved.name,
+				strconv.FormatInt(amount, 10)))
+		}
+	}
+	return properties
+}
+
 // Get node name from entity property.
 func GetNodeNameFromProperty(properties []*proto.EntityDTO_EntityProperty) (nodeName string) {
 	if properties == nil {
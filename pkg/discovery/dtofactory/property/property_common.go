@@ -2,6 +2,7 @@ package property
 
 import (
 	"regexp"
+	"strconv"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
@@ -15,6 +16,11 @@ const (
 	k8sNamespace                 = "KubernetesNamespace"
 	k8sPodName                   = "KubernetesPodName"
 	k8sNodeName                  = "KubernetesNodeName"
+	k8sNodeRegion                = "KubernetesNodeRegion"
+	k8sNodeZone                  = "KubernetesNodeZone"
+	k8sNodeInstanceType          = "KubernetesNodeInstanceType"
+	k8sNodeReservedCPU           = "KubernetesNodeReservedCPUMillicores"
+	k8sNodeReservedMemory        = "KubernetesNodeReservedMemoryKB"
 	k8sContainerIndex            = "Kubernetes-Container-Index"
 	k8sAppNamespace              = "KubernetesAppNamespace"
 	k8sAppName                   = "KubernetesAppName"
@@ -22,6 +28,13 @@ const (
 	TolerationPropertyNamePrefix = "[k8s toleration]"
 	LabelPropertyNamePrefix      = "[k8s label]"
 	k8sVolumeAttached            = "PersistentVolumeAttached"
+	k8sSwapUsed                  = "SwapUsedKB"
+	k8sRestartCount              = "RestartCount"
+	k8sRecentRestarts            = "RestartsSinceLastDiscovery"
+	k8sRecentOOMKilled           = "OOMKilledSinceLastDiscovery"
+	k8sTopLevelOwnerKind         = "KubernetesTopLevelOwnerKind"
+	k8sTopLevelOwnerName         = "KubernetesTopLevelOwnerName"
+	k8sTopLevelOwnerAPIVersion   = "KubernetesTopLevelOwnerAPIVersion"
 )
 
 func BuildTagProperty(namespace string, name string, value string) *proto.EntityDTO_EntityProperty {
@@ -131,3 +144,19 @@ func GetManagerAppFromProperties(properties []*proto.EntityDTO_EntityProperty) *
 	}
 	return managerApp
 }
+
+// BuildSwapUsedProperty builds the property of the swap usage in KB of a node or a container.
+func BuildSwapUsedProperty(swapUsedKB float64) *proto.EntityDTO_EntityProperty {
+	return BuildTagProperty(k8sPropertyNamespace, k8sSwapUsed, strconv.FormatFloat(swapUsedKB, 'f', -1, 64))
+}
+
+// BuildContainerRestartProperties builds the properties of the total restarts of a container, and of its restarts and
+// whether it was OOMKilled since the previous discovery.
+func BuildContainerRestartProperties(restartCount int32, recentRestarts float64,
+	recentOOMKilled bool) []*proto.EntityDTO_EntityProperty {
+	return []*proto.EntityDTO_EntityProperty{
+		BuildTagProperty(k8sPropertyNamespace, k8sRestartCount, strconv.FormatInt(int64(restartCount), 10)),
+		BuildTagProperty(k8sPropertyNamespace, k8sRecentRestarts, strconv.FormatFloat(recentRestarts, 'f', -1, 64)),
+		BuildTagProperty(k8sPropertyNamespace, k8sRecentOOMKilled, strconv.FormatBool(recentOOMKilled)),
+	}
+}
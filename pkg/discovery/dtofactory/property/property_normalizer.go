@@ -0,0 +1,128 @@
+package property
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// ConflictPolicy decides which value is kept when an entity has multiple properties with the same
+// namespace and name but different values.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyLastWins keeps the value of the property added last.
+	ConflictPolicyLastWins ConflictPolicy = "last"
+	// ConflictPolicyFirstWins keeps the value of the property added first.
+	ConflictPolicyFirstWins ConflictPolicy = "first"
+)
+
+// ParseConflictPolicy validates the property conflict policy given on the command line.
+// An empty string is treated as ConflictPolicyLastWins.
+func ParseConflictPolicy(policy string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(policy)); p {
+	case "", ConflictPolicyLastWins:
+		return ConflictPolicyLastWins, nil
+	case ConflictPolicyFirstWins:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unsupported property conflict policy %q, must be one of %s|%s",
+			policy, ConflictPolicyLastWins, ConflictPolicyFirstWins)
+	}
+}
+
+// singleValuedProperties are the names of the properties in the DEFAULT namespace which kubeturbo sets once per
+// entity, so that an entity with several of them has conflicting values which are resolved with the ConflictPolicy.
+// The other properties, e.g. the stitching properties, may legitimately have multiple values for the same name.
+var singleValuedProperties = map[string]bool{
+	k8sNamespace:               true,
+	k8sPodName:                 true,
+	k8sNodeName:                true,
+	k8sNodeRegion:              true,
+	k8sNodeZone:                true,
+	k8sNodeInstanceType:        true,
+	k8sNodeReservedCPU:         true,
+	k8sNodeReservedMemory:      true,
+	k8sContainerIndex:          true,
+	k8sSwapUsed:                true,
+	k8sRestartCount:            true,
+	k8sRecentRestarts:          true,
+	k8sRecentOOMKilled:         true,
+	k8sTopLevelOwnerKind:       true,
+	k8sTopLevelOwnerName:       true,
+	k8sTopLevelOwnerAPIVersion: true,
+	k8sClusterId:               true,
+	k8sClusterName:             true,
+	vcpuUnit:                   true,
+}
+
+type propertyKey struct {
+	namespace string
+	name      string
+	value     string
+}
+
+// NormalizeProperties de-duplicates the given entity properties, and caps the number of properties to
+// maxProperties if it is positive. The order of the first occurrence of each property is kept, so the result is
+// deterministic.
+//
+// Only the known single-valued properties in the DEFAULT namespace are de-duplicated by namespace and name,
+// resolving the conflicting values with the given policy. The other properties, e.g. the tags in the VCTAGS
+// namespace such as the tolerations of a pod, may have multiple values for the same name, so only identical
+// properties are de-duplicated. The application properties are kept as they are, since an entity of several
+// applications has one namespace, name and type triple per application. When capping, the properties in other
+// namespaces are used by kubeturbo and the server to identify and stitch the entities, so only tags are dropped,
+// last first, except the PersistentVolumeAttached tags which the server uses to place the pods with volumes.
+func NormalizeProperties(properties []*proto.EntityDTO_EntityProperty, policy ConflictPolicy,
+	maxProperties int) (normalized []*proto.EntityDTO_EntityProperty, dropped int) {
+	// The index in the normalized properties of each key
+	index := make(map[propertyKey]int, len(properties))
+	for _, p := range properties {
+		if p == nil {
+			dropped++
+			continue
+		}
+		if isAppProperty(p) {
+			normalized = append(normalized, p)
+			continue
+		}
+		key := propertyKey{namespace: p.GetNamespace(), name: p.GetName()}
+		singleValued := key.namespace == k8sPropertyNamespace && singleValuedProperties[key.name]
+		if !singleValued {
+			key.value = p.GetValue()
+		}
+		if i, found := index[key]; found {
+			if singleValued && policy != ConflictPolicyFirstWins {
+				normalized[i] = p
+			}
+			dropped++
+			continue
+		}
+		index[key] = len(normalized)
+		normalized = append(normalized, p)
+	}
+	if maxProperties <= 0 || len(normalized) <= maxProperties {
+		return normalized, dropped
+	}
+	tagsToDrop := len(normalized) - maxProperties
+	for i := len(normalized) - 1; i >= 0 && tagsToDrop > 0; i-- {
+		if normalized[i].GetNamespace() != VCTagsPropertyNamespace || normalized[i].GetName() == k8sVolumeAttached {
+			continue
+		}
+		normalized = append(normalized[:i], normalized[i+1:]...)
+		tagsToDrop--
+		dropped++
+	}
+	return normalized, dropped
+}
+
+// isAppProperty returns whether the property is part of the namespace, name and type of an application of the
+// entity, as built by BuildBusinessAppRelatedProperties.
+func isAppProperty(p *proto.EntityDTO_EntityProperty) bool {
+	if p.GetNamespace() != k8sPropertyNamespace {
+		return false
+	}
+	name := p.GetName()
+	return name == k8sAppNamespace || name == k8sAppName || name == k8sAppType
+}
@@ -0,0 +1,105 @@
+package property
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+func propertyValues(properties []*proto.EntityDTO_EntityProperty) []string {
+	var values []string
+	for _, p := range properties {
+		values = append(values, p.GetNamespace()+"/"+p.GetName()+"="+p.GetValue())
+	}
+	return values
+}
+
+func TestNormalizePropertiesDuplicates(t *testing.T) {
+	properties := []*proto.EntityDTO_EntityProperty{
+		BuildTagProperty(k8sPropertyNamespace, k8sPodName, "pod-1"),
+		BuildTagProperty(VCTagsPropertyNamespace, "[k8s label] app", "web"),
+		BuildTagProperty(k8sPropertyNamespace, k8sPodName, "pod-1"),
+		nil,
+		BuildTagProperty(VCTagsPropertyNamespace, "[k8s label] app", "web"),
+	}
+	normalized, dropped := NormalizeProperties(properties, ConflictPolicyLastWins, 0)
+	assert.Equal(t, 3, dropped)
+	assert.Equal(t, []string{"DEFAULT/KubernetesPodName=pod-1", "VCTAGS/[k8s label] app=web"}, propertyValues(normalized))
+}
+
+func TestNormalizePropertiesConflicts(t *testing.T) {
+	properties := []*proto.EntityDTO_EntityProperty{
+		BuildTagProperty(k8sPropertyNamespace, k8sNodeName, "node-1"),
+		BuildTagProperty(k8sPropertyNamespace, k8sPodName, "pod-1"),
+		BuildTagProperty(k8sPropertyNamespace, k8sNodeName, "node-2"),
+		// Multi-valued tags are kept
+		BuildTagProperty(VCTagsPropertyNamespace, TolerationPropertyNamePrefix, "key1"),
+		BuildTagProperty(VCTagsPropertyNamespace, TolerationPropertyNamePrefix, "key2"),
+		// Properties which are not known to be single-valued are kept, e.g. the stitching properties
+		BuildTagProperty(k8sPropertyNamespace, "IP", "10.0.0.1"),
+		BuildTagProperty(k8sPropertyNamespace, "IP", "10.0.0.2"),
+	}
+
+	normalized, dropped := NormalizeProperties(properties, ConflictPolicyLastWins, 0)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, []string{"DEFAULT/KubernetesNodeName=node-2", "DEFAULT/KubernetesPodName=pod-1",
+		"VCTAGS/[k8s toleration]=key1", "VCTAGS/[k8s toleration]=key2", "DEFAULT/IP=10.0.0.1",
+		"DEFAULT/IP=10.0.0.2"}, propertyValues(normalized))
+
+	normalized, dropped = NormalizeProperties(properties, ConflictPolicyFirstWins, 0)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, []string{"DEFAULT/KubernetesNodeName=node-1", "DEFAULT/KubernetesPodName=pod-1",
+		"VCTAGS/[k8s toleration]=key1", "VCTAGS/[k8s toleration]=key2", "DEFAULT/IP=10.0.0.1",
+		"DEFAULT/IP=10.0.0.2"}, propertyValues(normalized))
+}
+
+func TestNormalizePropertiesApps(t *testing.T) {
+	var properties []*proto.EntityDTO_EntityProperty
+	for _, app := range []repository.K8sApp{
+		{Namespace: "argocd", Name: "app-1", Type: repository.AppTypeArgoCD},
+		{Namespace: "argocd", Name: "app-2", Type: repository.AppTypeArgoCD},
+	} {
+		properties = append(properties, BuildBusinessAppRelatedProperties(app)...)
+	}
+	properties = append(properties, BuildTagProperty(k8sPropertyNamespace, k8sPodName, "pod-1"))
+
+	// The triples of both applications are kept
+	normalized, dropped := NormalizeProperties(properties, ConflictPolicyLastWins, 0)
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, properties, normalized)
+}
+
+func TestNormalizePropertiesCap(t *testing.T) {
+	properties := []*proto.EntityDTO_EntityProperty{
+		BuildTagProperty(VCTagsPropertyNamespace, "[k8s label] a", "1"),
+		BuildTagProperty(k8sPropertyNamespace, k8sPodName, "pod-1"),
+		BuildTagProperty(VCTagsPropertyNamespace, "[k8s label] b", "2"),
+		BuildTagProperty(k8sPropertyNamespace, k8sNamespace, "ns"),
+		BuildTagProperty(VCTagsPropertyNamespace, "[k8s label] c", "3"),
+	}
+	normalized, dropped := NormalizeProperties(properties, ConflictPolicyLastWins, 3)
+	assert.Equal(t, 2, dropped)
+	assert.Equal(t, []string{"VCTAGS/[k8s label] a=1", "DEFAULT/KubernetesPodName=pod-1",
+		"DEFAULT/KubernetesNamespace=ns"}, propertyValues(normalized))
+
+	// The properties other than tags, and the volume tags, are never dropped
+	properties = AddVolumeProperties(properties)
+	normalized, dropped = NormalizeProperties(properties, ConflictPolicyLastWins, 1)
+	assert.Equal(t, 3, dropped)
+	assert.Equal(t, []string{"DEFAULT/KubernetesPodName=pod-1", "DEFAULT/KubernetesNamespace=ns",
+		"VCTAGS/PersistentVolumeAttached=true"}, propertyValues(normalized))
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	policy, err := ParseConflictPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictPolicyLastWins, policy)
+	policy, err = ParseConflictPolicy("First")
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictPolicyFirstWins, policy)
+	_, err = ParseConflictPolicy("random")
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"testing"
@@ -100,6 +101,67 @@ func TestNodeProperty(t *testing.T) {
 	assert.Equal(t, 5, matches, "there should be 5 matches in the test node properties")
 }
 
+func TestNodeTopologyProperties(t *testing.T) {
+	node := &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-node-1",
+			Labels: map[string]string{
+				api.LabelTopologyRegion:          "us-east-1",
+				api.LabelFailureDomainBetaRegion: "us-west-1",
+				api.LabelFailureDomainBetaZone:   "us-east-1a",
+				api.LabelInstanceType:            "m5.large",
+			},
+		},
+	}
+
+	topology := make(map[string]string)
+	for _, p := range BuildNodeProperties(node) {
+		if p.GetNamespace() == k8sPropertyNamespace && p.GetName() != k8sNodeName {
+			topology[p.GetName()] = p.GetValue()
+		}
+	}
+	// The stable labels are preferred to the deprecated ones, which are used if the stable ones are not set
+	assert.Equal(t, map[string]string{
+		k8sNodeRegion:       "us-east-1",
+		k8sNodeZone:         "us-east-1a",
+		k8sNodeInstanceType: "m5.large",
+	}, topology)
+
+	node.Labels = nil
+	assert.Len(t, BuildNodeProperties(node), 1)
+}
+
+func TestNodeReservedProperties(t *testing.T) {
+	node := &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-node-1"},
+		Status: api.NodeStatus{
+			Capacity: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse("4"),
+				api.ResourceMemory: resource.MustParse("16Gi"),
+			},
+			Allocatable: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse("3800m"),
+				api.ResourceMemory: resource.MustParse("15Gi"),
+			},
+		},
+	}
+
+	reserved := make(map[string]string)
+	for _, p := range BuildNodeProperties(node) {
+		if p.GetNamespace() == k8sPropertyNamespace && p.GetName() != k8sNodeName {
+			reserved[p.GetName()] = p.GetValue()
+		}
+	}
+	assert.Equal(t, map[string]string{
+		k8sNodeReservedCPU:    "200",
+		k8sNodeReservedMemory: "1048576",
+	}, reserved)
+
+	// Nothing is reserved without the allocatable resources
+	node.Status.Allocatable = nil
+	assert.Len(t, BuildNodeProperties(node), 1)
+}
+
 func TestBuildPodProperties(t *testing.T) {
 	labels := make(map[string]string)
 	labels[label1Key] = label1Value
@@ -284,3 +346,20 @@ func TestAddVolumeProperties(t *testing.T) {
 	}
 	assert.Fail(t, "Can't find volume property in the pod's properties")
 }
+
+func TestBuildWorkloadControllerOwnerProperties(t *testing.T) {
+	assert.Nil(t, BuildWorkloadControllerOwnerProperties(nil))
+
+	ps := BuildWorkloadControllerOwnerProperties(&metav1.OwnerReference{
+		APIVersion: "example.com/v1", Kind: "App", Name: "app", UID: "app-uid"})
+	values := make(map[string]string)
+	for _, p := range ps {
+		assert.Equal(t, k8sPropertyNamespace, p.GetNamespace())
+		values[p.GetName()] = p.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		k8sTopLevelOwnerKind:       "App",
+		k8sTopLevelOwnerName:       "app",
+		k8sTopLevelOwnerAPIVersion: "example.com/v1",
+	}, values)
+}
@@ -0,0 +1,69 @@
+package property
+
+import (
+	"sort"
+	"strings"
+
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	k8sLoadBalancerStatus   = "KubernetesLoadBalancerStatus"
+	k8sLoadBalancerIngress  = "KubernetesLoadBalancerIngress"
+	k8sLoadBalancerBackends = "KubernetesLoadBalancerBackends"
+
+	LoadBalancerStatusPending = "Pending"
+	LoadBalancerStatusActive  = "Active"
+)
+
+// GetLoadBalancerIngress returns the IPs and host names of the ingress points of a service of type
+// LoadBalancer, sorted. It is empty if the service is not of type LoadBalancer, or if the external
+// load balancer has not been provisioned yet, i.e., the service is still pending.
+func GetLoadBalancerIngress(service *api.Service) []string {
+	if service.Spec.Type != api.ServiceTypeLoadBalancer {
+		return nil
+	}
+	var ingress []string
+	for _, lbIngress := range service.Status.LoadBalancer.Ingress {
+		if lbIngress.IP != "" {
+			ingress = append(ingress, lbIngress.IP)
+		} else if lbIngress.Hostname != "" {
+			ingress = append(ingress, lbIngress.Hostname)
+		}
+	}
+	sort.Strings(ingress)
+	return ingress
+}
+
+// BuildLoadBalancerServiceProperties builds the properties of a service of type LoadBalancer: the status
+// of the external load balancer, its ingress addresses once assigned, and the backend pool made of the
+// IPs of the pods of the service. Nil is returned for the services of other types.
+func BuildLoadBalancerServiceProperties(service *api.Service, pods []*api.Pod) []*proto.EntityDTO_EntityProperty {
+	if service.Spec.Type != api.ServiceTypeLoadBalancer {
+		return nil
+	}
+	var properties []*proto.EntityDTO_EntityProperty
+	ingress := GetLoadBalancerIngress(service)
+	if len(ingress) == 0 {
+		properties = append(properties,
+			BuildTagProperty(k8sPropertyNamespace, k8sLoadBalancerStatus, LoadBalancerStatusPending))
+	} else {
+		properties = append(properties,
+			BuildTagProperty(k8sPropertyNamespace, k8sLoadBalancerStatus, LoadBalancerStatusActive),
+			BuildTagProperty(k8sPropertyNamespace, k8sLoadBalancerIngress, strings.Join(ingress, ",")))
+	}
+	var backends []string
+	for _, pod := range pods {
+		if pod.Status.PodIP != "" {
+			backends = append(backends, pod.Status.PodIP)
+		}
+	}
+	if len(backends) > 0 {
+		sort.Strings(backends)
+		properties = append(properties,
+			BuildTagProperty(k8sPropertyNamespace, k8sLoadBalancerBackends, strings.Join(backends, ",")))
+	}
+	return properties
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Build entity properties of a pod. The properties are consisted of name and namespace of a pod.
@@ -18,6 +19,19 @@ func BuildWorkloadControllerNSProperty(namespace string) *proto.EntityDTO_Entity
 	}
 }
 
+// BuildWorkloadControllerOwnerProperties builds the properties of the owner at the top of the ownership chain of a
+// workload controller, e.g. the custom resource of an operator, to which the resize actions are routed.
+func BuildWorkloadControllerOwnerProperties(owner *metav1.OwnerReference) []*proto.EntityDTO_EntityProperty {
+	if owner == nil {
+		return nil
+	}
+	return []*proto.EntityDTO_EntityProperty{
+		BuildTagProperty(k8sPropertyNamespace, k8sTopLevelOwnerKind, owner.Kind),
+		BuildTagProperty(k8sPropertyNamespace, k8sTopLevelOwnerName, owner.Name),
+		BuildTagProperty(k8sPropertyNamespace, k8sTopLevelOwnerAPIVersion, owner.APIVersion),
+	}
+}
+
 // Get the namespace and name of a pod from entity property.
 func GetWorkloadNamespaceFromProperty(properties []*proto.EntityDTO_EntityProperty) (string, error) {
 	namespace := ""
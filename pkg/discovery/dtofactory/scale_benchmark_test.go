@@ -0,0 +1,137 @@
+package dtofactory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/test/synthetic"
+)
+
+// The synthetic clusters the DTO builders are benchmarked against, from a small cluster to a large one with a skewed
+// placement of its pods. Run with make bench, or:
+//
+//	go test -run '^$' -bench . -benchmem ./pkg/discovery/dtofactory/
+var scaleBenchmarkSpecs = []synthetic.Spec{
+	{Nodes: 10, Pods: 300, Namespaces: 5, PodsPerController: 3, MaxContainersPerPod: 2, Seed: 1},
+	{Nodes: 100, Pods: 3000, Namespaces: 20, PodsPerController: 3, MaxContainersPerPod: 3, Seed: 1},
+	{Nodes: 500, Pods: 15000, Namespaces: 50, PodsPerController: 5, MaxContainersPerPod: 3, Seed: 1,
+		Placement: synthetic.PlacementSkewed},
+}
+
+func generateBenchmarkCluster(b *testing.B, spec synthetic.Spec) *synthetic.Cluster {
+	cluster, err := synthetic.Generate(spec)
+	if err != nil {
+		b.Fatalf("Failed to generate the synthetic cluster: %v", err)
+	}
+	return cluster
+}
+
+func benchmarkName(spec synthetic.Spec) string {
+	return fmt.Sprintf("nodes=%d/pods=%d", spec.Nodes, spec.Pods)
+}
+
+func newSyntheticStitchingManager(cluster *synthetic.Cluster) *stitching.StitchingManager {
+	stitchingManager := stitching.NewStitchingManager(stitching.UUID)
+	for _, node := range cluster.Nodes {
+		stitchingManager.StoreStitchingValue(node)
+	}
+	return stitchingManager
+}
+
+func buildSyntheticNodeDTOs(cluster *synthetic.Cluster, stitchingManager *stitching.StitchingManager,
+	nodePods map[string][]string) []*proto.EntityDTO {
+	nodeDTOs, _ := NewNodeEntityDTOBuilder(cluster.Sink, stitchingManager).
+		BuildEntityDTOs(cluster.Nodes, nodePods, nil, nil, nil)
+	return nodeDTOs
+}
+
+func buildSyntheticPodDTOs(cluster *synthetic.Cluster, stitchingManager *stitching.StitchingManager,
+	nodeNameUIDMap, namespaceUIDMap map[string]string) []*proto.EntityDTO {
+	podDTOs, _, _, _ := NewPodEntityDTOBuilder(cluster.Sink, stitchingManager, nil).
+		WithNodeNameUIDMap(nodeNameUIDMap).
+		WithNameSpaceUIDMap(namespaceUIDMap).
+		WithRunningPods(cluster.Pods).
+		BuildEntityDTOs()
+	return podDTOs
+}
+
+func buildSyntheticContainerDTOs(cluster *synthetic.Cluster) []*proto.EntityDTO {
+	containerDTOs, _ := NewContainerDTOBuilder(cluster.Sink).BuildEntityDTOs(cluster.Pods)
+	return containerDTOs
+}
+
+func TestSyntheticClusterDTOs(t *testing.T) {
+	for _, spec := range []synthetic.Spec{
+		{Nodes: 5, Pods: 50, Namespaces: 2, PodsPerController: 5, MaxContainersPerPod: 3, Seed: 1},
+		// Bare pods buy their quotas from their namespaces
+		{Nodes: 5, Pods: 50, Namespaces: 2, Placement: synthetic.PlacementSkewed, Seed: 2},
+	} {
+		cluster, err := synthetic.Generate(spec)
+		assert.NoError(t, err)
+		stitchingManager := newSyntheticStitchingManager(cluster)
+
+		// Every entity of the synthetic cluster gets its DTO, so that the benchmarks exercise the whole builders
+		nodeDTOs := buildSyntheticNodeDTOs(cluster, stitchingManager, cluster.NodePods())
+		assert.Len(t, nodeDTOs, spec.Nodes)
+		for _, nodeDTO := range nodeDTOs {
+			assert.True(t, nodeDTO.GetProviderPolicy().GetAvailableForPlacement(), nodeDTO.GetDisplayName())
+		}
+		podDTOs := buildSyntheticPodDTOs(cluster, stitchingManager, cluster.NodeNameUIDMap(),
+			cluster.NamespaceUIDMap())
+		assert.Len(t, podDTOs, spec.Pods)
+		for _, podDTO := range podDTOs {
+			assert.Equal(t, proto.EntityDTO_POWERED_ON, podDTO.GetPowerState())
+			// The pods buy from their nodes and from their controllers or namespaces
+			assert.Len(t, podDTO.GetCommoditiesBought(), 2)
+		}
+		containerDTOs := buildSyntheticContainerDTOs(cluster)
+		assert.Len(t, containerDTOs, cluster.NumContainers())
+	}
+}
+
+func BenchmarkNodeEntityDTOBuilder(b *testing.B) {
+	for _, spec := range scaleBenchmarkSpecs {
+		b.Run(benchmarkName(spec), func(b *testing.B) {
+			cluster := generateBenchmarkCluster(b, spec)
+			stitchingManager := newSyntheticStitchingManager(cluster)
+			nodePods := cluster.NodePods()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buildSyntheticNodeDTOs(cluster, stitchingManager, nodePods)
+			}
+		})
+	}
+}
+
+func BenchmarkPodEntityDTOBuilder(b *testing.B) {
+	for _, spec := range scaleBenchmarkSpecs {
+		b.Run(benchmarkName(spec), func(b *testing.B) {
+			cluster := generateBenchmarkCluster(b, spec)
+			stitchingManager := newSyntheticStitchingManager(cluster)
+			nodeNameUIDMap, namespaceUIDMap := cluster.NodeNameUIDMap(), cluster.NamespaceUIDMap()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buildSyntheticPodDTOs(cluster, stitchingManager, nodeNameUIDMap, namespaceUIDMap)
+			}
+		})
+	}
+}
+
+func BenchmarkContainerDTOBuilder(b *testing.B) {
+	for _, spec := range scaleBenchmarkSpecs {
+		b.Run(benchmarkName(spec), func(b *testing.B) {
+			cluster := generateBenchmarkCluster(b, spec)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buildSyntheticContainerDTOs(cluster)
+			}
+		})
+	}
+}
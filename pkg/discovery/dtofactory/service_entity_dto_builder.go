@@ -2,9 +2,12 @@ package dtofactory
 
 import (
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/features"
 	api "k8s.io/api/core/v1"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
@@ -17,8 +20,9 @@ import (
 )
 
 const (
-	servicePrefix    string = "Service"
-	kubeSystemPrefix string = "kube-system"
+	servicePrefix      string = "Service"
+	loadBalancerPrefix string = "LoadBalancer"
+	kubeSystemPrefix   string = "kube-system"
 )
 
 var (
@@ -32,6 +36,10 @@ type ServiceEntityDTOBuilder struct {
 	ClusterScraper *cluster.ClusterScraper
 	// Pods with app DTOs
 	PodEntitiesMap map[string]*repository.KubePod
+	// The request rate in requests per second and the response time in milliseconds of the services of the Istio
+	// service mesh, by the <namespace>/<name> of the services, nil if not collected
+	meshTransactions  map[string]float64
+	meshResponseTimes map[string]float64
 }
 
 func NewServiceEntityDTOBuilder(clusterSummary *repository.ClusterSummary,
@@ -44,6 +52,16 @@ func NewServiceEntityDTOBuilder(clusterSummary *repository.ClusterSummary,
 	return builder
 }
 
+// WithServiceMeshMetrics sets the request rate and the response time of the services of the service mesh, by the
+// <namespace>/<name> of the services, which the services of the pods with the Istio sidecar sell as the transaction
+// and response time commodities.
+func (builder *ServiceEntityDTOBuilder) WithServiceMeshMetrics(transactions,
+	responseTimes map[string]float64) *ServiceEntityDTOBuilder {
+	builder.meshTransactions = transactions
+	builder.meshResponseTimes = responseTimes
+	return builder
+}
+
 func (builder *ServiceEntityDTOBuilder) BuildDTOs() []*proto.EntityDTO {
 	var result []*proto.EntityDTO
 	svcUID, err := builder.ClusterScraper.GetKubernetesServiceID()
@@ -87,6 +105,9 @@ func (builder *ServiceEntityDTOBuilder) BuildDTOs() []*proto.EntityDTO {
 			glog.Warningf("Failed to create commodity sold for service %s: %v", serviceName, err)
 			continue
 		}
+		if err := builder.createServiceMeshCommoditiesSold(ebuilder, service, pods); err != nil {
+			glog.Warningf("Failed to create service mesh commodities sold for service %s: %v", serviceName, err)
+		}
 
 		// commodities bought
 		if err := builder.createCommodityBought(ebuilder, pods, appEntityDTOsMap); err != nil {
@@ -94,6 +115,17 @@ func (builder *ServiceEntityDTOBuilder) BuildDTOs() []*proto.EntityDTO {
 			continue
 		}
 
+		// external load balancer of the service of type LoadBalancer
+		var lbDTO *proto.EntityDTO
+		if utilfeature.DefaultFeatureGate.Enabled(features.LoadBalancerServices) &&
+			service.Spec.Type == api.ServiceTypeLoadBalancer {
+			ebuilder.WithProperties(property.BuildLoadBalancerServiceProperties(service, pods))
+			lbDTO, err = createLoadBalancer(ebuilder, service, serviceName)
+			if err != nil {
+				glog.Errorf("Failed to create load balancer EntityDTO of service %s: %v", serviceName, err)
+			}
+		}
+
 		// service data.
 		ebuilder.ServiceData(createServiceData(service))
 
@@ -117,6 +149,9 @@ func (builder *ServiceEntityDTOBuilder) BuildDTOs() []*proto.EntityDTO {
 		}
 		glog.V(4).Infof("service DTO: %++v", entityDto)
 		result = append(result, entityDto)
+		if lbDTO != nil {
+			result = append(result, lbDTO)
+		}
 	}
 
 	return result
@@ -205,6 +240,49 @@ func (builder *ServiceEntityDTOBuilder) createCommoditySold(
 	return nil
 }
 
+// Create the transaction and response time commodities sold by the service of the Istio service mesh, from the
+// request rate and the response time of the service reported by the Envoy sidecars of its pods, so that the SLOs
+// of the service can drive the horizontal scaling of its pods. None is created for the services whose pods have no
+// sidecar, or without metrics, e.g. the services which received no request recently.
+func (builder *ServiceEntityDTOBuilder) createServiceMeshCommoditiesSold(ebuilder *sdkbuilder.EntityDTOBuilder,
+	service *api.Service, pods []*api.Pod) error {
+	if builder.meshTransactions == nil && builder.meshResponseTimes == nil {
+		return nil
+	}
+	inMesh := false
+	for _, pod := range pods {
+		if util.HasIstioSidecar(pod) {
+			inMesh = true
+			break
+		}
+	}
+	if !inMesh {
+		return nil
+	}
+	serviceId := service.Namespace + "/" + service.Name
+	var commoditiesSold []*proto.CommodityDTO
+	if transactions, found := builder.meshTransactions[serviceId]; found {
+		commSold, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_TRANSACTION).
+			Used(transactions).
+			Create()
+		if err != nil {
+			return err
+		}
+		commoditiesSold = append(commoditiesSold, commSold)
+	}
+	if responseTime, found := builder.meshResponseTimes[serviceId]; found {
+		commSold, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_RESPONSE_TIME).
+			Used(responseTime).
+			Create()
+		if err != nil {
+			return err
+		}
+		commoditiesSold = append(commoditiesSold, commSold)
+	}
+	ebuilder.SellsCommodities(commoditiesSold)
+	return nil
+}
+
 func (builder *ServiceEntityDTOBuilder) createCommodityBought(ebuilder *sdkbuilder.EntityDTOBuilder,
 	pods []*api.Pod, appDTOs map[string]*proto.EntityDTO) error {
 	foundProvider := false
@@ -239,6 +317,43 @@ func (builder *ServiceEntityDTOBuilder) createCommodityBought(ebuilder *sdkbuild
 	return nil
 }
 
+// Create the EntityDTO of the external load balancer of a service of type LoadBalancer, and make the service
+// buy from it. No load balancer is created when the service is still pending, i.e., no ingress IP or host
+// name has been assigned to it yet.
+func createLoadBalancer(ebuilder *sdkbuilder.EntityDTOBuilder, service *api.Service,
+	serviceName string) (*proto.EntityDTO, error) {
+	ingress := property.GetLoadBalancerIngress(service)
+	if len(ingress) == 0 {
+		glog.V(3).Infof("Load balancer of service %s is pending.", serviceName)
+		return nil, nil
+	}
+	id := fmt.Sprintf("%s-%s", loadBalancerPrefix, service.UID)
+	// The application commodity is keyed by the service, so that only the service can buy from the load balancer
+	key := string(service.UID)
+	commSold, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_APPLICATION).Key(key).Create()
+	if err != nil {
+		return nil, err
+	}
+	lbDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_LOAD_BALANCER, id).
+		DisplayName(fmt.Sprintf("%s-%s", loadBalancerPrefix, serviceName)).
+		SellsCommodity(commSold).
+		WithProperty(getServiceProperty(stitching.ServiceNamespaceStitchingAttr, service.Namespace)).
+		WithProperty(getServiceProperty(stitching.ServiceNameStitchingAttr, service.Name)).
+		WithPowerState(proto.EntityDTO_POWERED_ON).
+		Create()
+	if err != nil {
+		return nil, err
+	}
+
+	commBought, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_APPLICATION).Key(key).Create()
+	if err != nil {
+		return nil, err
+	}
+	ebuilder.Provider(sdkbuilder.CreateProvider(proto.EntityDTO_LOAD_BALANCER, id)).
+		BuysCommodities([]*proto.CommodityDTO{commBought})
+	return lbDTO, nil
+}
+
 func (builder *ServiceEntityDTOBuilder) getCommoditiesBought(appDTO *proto.EntityDTO) ([]*proto.CommodityDTO, error) {
 	commoditiesSoldByApp := appDTO.GetCommoditiesSold()
 	var commoditiesBoughtFromApp []*proto.CommodityDTO
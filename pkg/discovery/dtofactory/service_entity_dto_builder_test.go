@@ -4,8 +4,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 )
 
 var testService = api.Service{
@@ -24,3 +28,105 @@ func TestBuildServiceData(t *testing.T) {
 		proto.EntityDTO_KubernetesServiceData_ServiceType(serviceTypeEnum),
 		serviceData.GetKubernetesServiceData().GetServiceType())
 }
+
+func getPropertyValue(properties []*proto.EntityDTO_EntityProperty, name string) (string, bool) {
+	for _, p := range properties {
+		if p.GetName() == name {
+			return p.GetValue(), true
+		}
+	}
+	return "", false
+}
+
+func TestCreateLoadBalancer(t *testing.T) {
+	service := &api.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "svc-uid"},
+		Spec:       api.ServiceSpec{ClusterIP: "10.10.0.2", Type: api.ServiceTypeLoadBalancer},
+	}
+	pods := []*api.Pod{
+		{Status: api.PodStatus{PodIP: "10.0.0.2"}},
+		{Status: api.PodStatus{PodIP: "10.0.0.1"}},
+	}
+
+	// Pending, no ingress IP assigned yet
+	ebuilder := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_SERVICE, "svc-uid")
+	lbDTO, err := createLoadBalancer(ebuilder, service, "default/web")
+	assert.NoError(t, err)
+	assert.Nil(t, lbDTO)
+	properties := property.BuildLoadBalancerServiceProperties(service, pods)
+	status, _ := getPropertyValue(properties, "KubernetesLoadBalancerStatus")
+	assert.Equal(t, property.LoadBalancerStatusPending, status)
+	_, found := getPropertyValue(properties, "KubernetesLoadBalancerIngress")
+	assert.False(t, found)
+	backends, _ := getPropertyValue(properties, "KubernetesLoadBalancerBackends")
+	assert.Equal(t, "10.0.0.1,10.0.0.2", backends)
+	serviceDTO, err := ebuilder.Create()
+	assert.NoError(t, err)
+	assert.Empty(t, serviceDTO.GetCommoditiesBought())
+
+	// Ingress IP and host name assigned
+	service.Status.LoadBalancer.Ingress = []api.LoadBalancerIngress{
+		{Hostname: "web.example.com"}, {IP: "203.0.113.10"},
+	}
+	ebuilder = sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_SERVICE, "svc-uid")
+	lbDTO, err = createLoadBalancer(ebuilder, service, "default/web")
+	assert.NoError(t, err)
+	assert.NotNil(t, lbDTO)
+	assert.Equal(t, proto.EntityDTO_LOAD_BALANCER, lbDTO.GetEntityType())
+	assert.Equal(t, "LoadBalancer-svc-uid", lbDTO.GetId())
+	assert.Equal(t, "LoadBalancer-default/web", lbDTO.GetDisplayName())
+	assert.Len(t, lbDTO.GetCommoditiesSold(), 1)
+	assert.Equal(t, "svc-uid", lbDTO.GetCommoditiesSold()[0].GetKey())
+	properties = property.BuildLoadBalancerServiceProperties(service, pods)
+	status, _ = getPropertyValue(properties, "KubernetesLoadBalancerStatus")
+	assert.Equal(t, property.LoadBalancerStatusActive, status)
+	ingress, _ := getPropertyValue(properties, "KubernetesLoadBalancerIngress")
+	assert.Equal(t, "203.0.113.10,web.example.com", ingress)
+
+	serviceDTO, err = ebuilder.Create()
+	assert.NoError(t, err)
+	assert.Len(t, serviceDTO.GetCommoditiesBought(), 1)
+	bought := serviceDTO.GetCommoditiesBought()[0]
+	assert.Equal(t, lbDTO.GetId(), bought.GetProviderId())
+	assert.Equal(t, proto.EntityDTO_LOAD_BALANCER, bought.GetProviderType())
+	assert.Equal(t, "svc-uid", bought.GetBought()[0].GetKey())
+
+	// Not a service of type LoadBalancer
+	assert.Nil(t, property.BuildLoadBalancerServiceProperties(&testService, pods))
+}
+
+func TestCreateServiceMeshCommoditiesSold(t *testing.T) {
+	service := &api.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "svc-uid"}}
+	meshPod := &api.Pod{Spec: api.PodSpec{Containers: []api.Container{{Name: "web"}, {Name: "istio-proxy"}}}}
+	plainPod := &api.Pod{Spec: api.PodSpec{Containers: []api.Container{{Name: "web"}}}}
+	builder := (&ServiceEntityDTOBuilder{}).WithServiceMeshMetrics(
+		map[string]float64{"default/web": 25, "default/other": 3},
+		map[string]float64{"default/web": 120.5})
+
+	soldTypes := func(pods ...*api.Pod) map[proto.CommodityDTO_CommodityType]float64 {
+		ebuilder := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_SERVICE, "svc-uid")
+		assert.NoError(t, builder.createServiceMeshCommoditiesSold(ebuilder, service, pods))
+		serviceDTO, err := ebuilder.Create()
+		assert.NoError(t, err)
+		sold := make(map[proto.CommodityDTO_CommodityType]float64)
+		for _, comm := range serviceDTO.GetCommoditiesSold() {
+			sold[comm.GetCommodityType()] = comm.GetUsed()
+		}
+		return sold
+	}
+
+	// The service of the pods in the mesh sells the transaction and response time commodities
+	assert.Equal(t, map[proto.CommodityDTO_CommodityType]float64{
+		proto.CommodityDTO_TRANSACTION:   25,
+		proto.CommodityDTO_RESPONSE_TIME: 120.5,
+	}, soldTypes(plainPod, meshPod))
+	// Not the service of the pods out of the mesh
+	assert.Empty(t, soldTypes(plainPod))
+	// Nor the service without metrics
+	service.Name = "idle"
+	assert.Empty(t, soldTypes(meshPod))
+	// Nor any service if the metrics are not collected
+	service.Name = "web"
+	builder = &ServiceEntityDTOBuilder{}
+	assert.Empty(t, soldTypes(meshPod))
+}
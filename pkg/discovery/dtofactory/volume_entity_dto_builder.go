@@ -8,6 +8,7 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
@@ -88,6 +89,7 @@ func (builder *volumeEntityDTOBuilder) getVolumeCommoditiesSold(vol *api.Persist
 
 	volumeCapacity := float64(0)
 	volumeUsed := float64(0)
+	metricsFound := false
 	for _, podVol := range podVols {
 		// Volume capacity metrics is available as part of volume spec.
 		// However we don't use that if the actual queried volume size
@@ -95,12 +97,6 @@ func (builder *volumeEntityDTOBuilder) getVolumeCommoditiesSold(vol *api.Persist
 		// pod metrics for that volume.
 		capacity, used, found := builder.getVolumeMetrics(vol, podVol.QualifiedPodName, podVol.MountName)
 		if !found {
-			// Not adding the capacity value from the volume spec (vol.spec.capacity) here
-			// currently ensures that there are no dangling volumes shown in supply chain
-			// if the volume is not used by a pod, or if there are no volume metrics discovered
-			// in some environment.
-			// (TODO): We can revisit this in future. A volume can also be discovered with just
-			// the capacity value and not connected to any other entity.
 			continue
 		}
 
@@ -112,11 +108,22 @@ func (builder *volumeEntityDTOBuilder) getVolumeCommoditiesSold(vol *api.Persist
 		// does not allow having multiple sold commodities with the same key (empty here).
 		volumeCapacity = capacity
 		volumeUsed = math.Max(volumeUsed, used)
+		metricsFound = true
+	}
+
+	if !metricsFound {
+		// The volume is not mounted by any running pod, or the kubelet does not report the stats of the
+		// volume, e.g., for the CSI drivers which do not support the volume stats. Use the capacity of the
+		// volume spec, so that the volume is still discovered and stitched to the underlying storage, without
+		// a used value.
+		volumeCapacity = getVolumeSpecCapacity(vol)
 	}
 
 	if volumeCapacity != float64(0) {
 		commBuilder := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_STORAGE_AMOUNT)
-		commBuilder.Used(volumeUsed)
+		if metricsFound {
+			commBuilder.Used(volumeUsed)
+		}
 		commBuilder.Capacity(volumeCapacity)
 		commodity, err := commBuilder.Create()
 		if err != nil {
@@ -130,6 +137,15 @@ func (builder *volumeEntityDTOBuilder) getVolumeCommoditiesSold(vol *api.Persist
 	return commoditiesSold, volumeCapacity
 }
 
+// getVolumeSpecCapacity returns the storage capacity of the volume spec in MB, or 0 if it is not set.
+func getVolumeSpecCapacity(vol *api.PersistentVolume) float64 {
+	quantity, found := vol.Spec.Capacity[api.ResourceStorage]
+	if !found {
+		return 0
+	}
+	return util.Base2BytesToMegabytes(float64(quantity.Value()))
+}
+
 func (builder *volumeEntityDTOBuilder) getVolumeMetrics(vol *api.PersistentVolume, podKey, mountName string) (float64, float64, bool) {
 	for _, metricEntry := range builder.podVolumeMetrics {
 		if metricEntry.Volume.Name == vol.Name &&
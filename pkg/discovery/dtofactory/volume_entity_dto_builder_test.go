@@ -0,0 +1,62 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	api "k8s.io/api/core/v1"
+	k8sres "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestVolume(name, capacity string) *api.PersistentVolume {
+	return &api.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID("uid-" + name),
+		},
+		Spec: api.PersistentVolumeSpec{
+			Capacity: api.ResourceList{
+				api.ResourceStorage: k8sres.MustParse(capacity),
+			},
+			PersistentVolumeSource: api.PersistentVolumeSource{
+				AWSElasticBlockStore: &api.AWSElasticBlockStoreVolumeSource{
+					VolumeID: "aws://us-east-2c/vol-" + name,
+				},
+			},
+		},
+	}
+}
+
+func TestVolumeCommoditiesSold(t *testing.T) {
+	mounted := newTestVolume("mounted", "10Gi")
+	unmounted := newTestVolume("unmounted", "2Gi")
+	builder := NewVolumeEntityDTOBuilder([]*repository.PodVolumeMetrics{{
+		Volume:    mounted,
+		PodVolume: repository.PodVolume{QualifiedPodName: "ns/pod-1", MountName: "data"},
+		Capacity:  9800,
+		Used:      1024,
+	}})
+
+	// The capacity and used values reported by the kubelet are used for the mounted volume
+	commodities, capacity := builder.getVolumeCommoditiesSold(mounted,
+		[]repository.PodVolume{{QualifiedPodName: "ns/pod-1", MountName: "data"}})
+	assert.Equal(t, 9800.0, capacity)
+	assert.Len(t, commodities, 1)
+	assert.Equal(t, 1024.0, commodities[0].GetUsed())
+
+	// The capacity of the spec is used for the volume not mounted by any pod, without a used value
+	commodities, capacity = builder.getVolumeCommoditiesSold(unmounted, nil)
+	assert.Equal(t, 2048.0, capacity)
+	assert.Len(t, commodities, 1)
+	assert.Nil(t, commodities[0].Used)
+
+	dtos, err := builder.BuildEntityDTOs(map[*api.PersistentVolume][]repository.PodVolume{
+		mounted:   {{QualifiedPodName: "ns/pod-1", MountName: "data"}},
+		unmounted: nil,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, dtos, 2)
+}
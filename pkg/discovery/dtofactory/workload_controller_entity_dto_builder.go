@@ -16,6 +16,8 @@ type workloadControllerDTOBuilder struct {
 	clusterSummary     *repository.ClusterSummary
 	kubeControllersMap map[string]*repository.KubeController
 	namespaceUIDMap    map[string]string
+	// Whether the workload controllers of a namespace are modeled as the overhead of the nodes, with no action
+	isOverheadNamespace func(namespace string) bool
 }
 
 func NewWorkloadControllerDTOBuilder(clusterSummary *repository.ClusterSummary, kubeControllersMap map[string]*repository.KubeController,
@@ -27,6 +29,13 @@ func NewWorkloadControllerDTOBuilder(clusterSummary *repository.ClusterSummary,
 	}
 }
 
+// WithOverheadNamespaces sets the namespaces whose pods are modeled as the overhead of their nodes, whose workload
+// controllers are not controllable.
+func (builder *workloadControllerDTOBuilder) WithOverheadNamespaces(isOverheadNamespace func(namespace string) bool) *workloadControllerDTOBuilder {
+	builder.isOverheadNamespace = isOverheadNamespace
+	return builder
+}
+
 // Build entityDTOs based on the given map from controller UID to KubeController entity.
 func (builder *workloadControllerDTOBuilder) BuildDTOs() ([]*proto.EntityDTO, error) {
 	var result []*proto.EntityDTO
@@ -82,6 +91,12 @@ func (builder *workloadControllerDTOBuilder) BuildDTOs() ([]*proto.EntityDTO, er
 			controller, found := builder.clusterSummary.ControllerMap[workloadControllerId]
 			if found {
 				entityDTOBuilder.WithProperties(property.BuildLabelAnnotationProperties(controller.Labels, controller.Annotations, detectors.AWWorkloadController))
+				entityDTOBuilder.WithProperties(property.BuildWorkloadControllerOwnerProperties(controller.TopLevelOwner))
+				// The controllers opt out of the actions or of the analysis with their annotations
+				controllable := discoveryUtil.IsControllableFromAnnotation(controller.Annotations) &&
+					(builder.isOverheadNamespace == nil || !builder.isOverheadNamespace(kubeController.Namespace))
+				entityDTOBuilder.ConsumerPolicy(&proto.EntityDTO_ConsumerPolicy{Controllable: &controllable})
+				entityDTOBuilder.Monitored(discoveryUtil.IsMonitoredFromAnnotation(controller.Annotations))
 				if controller.Replicas != nil {
 					replicas = int32(*controller.Replicas)
 				}
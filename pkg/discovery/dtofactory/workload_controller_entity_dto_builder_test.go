@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	discoveryUtil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	api "k8s.io/api/core/v1"
@@ -80,6 +81,7 @@ func TestBuildDTOs(t *testing.T) {
 			k8sController.WithReplicas(int64(deploymentReplicaCount))
 		} else if controller.UID == testKubeController2.UID {
 			k8sController.WithReplicas(int64(customControllerReplicaCount))
+			k8sController.Annotations = map[string]string{discoveryUtil.TurboControllableAnnotation: "false"}
 		}
 		kubeCluster.ControllerMap[controller.UID] = k8sController
 	}
@@ -91,6 +93,8 @@ func TestBuildDTOs(t *testing.T) {
 			actionEligibility := entityDTO.GetActionEligibility()
 			assert.False(t, actionEligibility.GetCloneable())
 			assert.False(t, actionEligibility.GetSuspendable())
+			assert.True(t, entityDTO.GetConsumerPolicy().GetControllable())
+			assert.True(t, entityDTO.GetMonitored())
 
 			// Test commodity sold DTOs
 			expectedCommoditiesSold := createCommoditiesSold(testKubeController1.UID)
@@ -123,6 +127,9 @@ func TestBuildDTOs(t *testing.T) {
 			actionEligibility := entityDTO.GetActionEligibility()
 			assert.False(t, actionEligibility.GetCloneable())
 			assert.False(t, actionEligibility.GetSuspendable())
+			// The controller opted out of the actions with its annotation
+			assert.False(t, entityDTO.GetConsumerPolicy().GetControllable())
+			assert.True(t, entityDTO.GetMonitored())
 
 			// Test create WorkloadControllerData with CustomControllerData
 			expectedWorkloadControllerData2 := &proto.EntityDTO_WorkloadControllerData{
@@ -0,0 +1,185 @@
+package discovery
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// The relative change of a commodity used or peak value below which an entity is considered unchanged
+const defaultDiffTolerance = 0.05
+
+// The changes of the entities of a full discovery since the previous full discovery
+const (
+	entityAdded     = "added"
+	entityChanged   = "changed"
+	entityUnchanged = "unchanged"
+	entityRemoved   = "removed"
+)
+
+// entityDTODiffer keeps a digest of the entity DTOs of the last full discovery sent to the server in full, and diffs
+// the entities of the next full discovery against it: an entity is unchanged if its topology, i.e., its providers,
+// commodities, capacities and properties, is the same and the used and peak values of its commodities are the same
+// within the tolerance.
+// As the server removes the entities missing from a full discovery, the entities cannot be left out one by one: a full
+// discovery is reported to the server as unchanged, without any entity, only if none of its entities is added, changed
+// or removed and the rest of the response, e.g. the groups, is the same. The server then keeps the entities of the
+// last full discovery. The digest is kept across the discoveries reported as unchanged, so that the values drifting
+// slowly within the tolerance at each discovery are still sent eventually.
+type entityDTODiffer struct {
+	lock      sync.Mutex
+	tolerance float64
+	// The maximum number of the consecutive full discoveries reported as unchanged, none if not positive
+	maxUnchangedDiscoveries int
+	// The number of the consecutive full discoveries reported as unchanged since the last one sent in full
+	unchangedDiscoveries int
+	// key: entity id
+	digests map[string]*entityDigest
+	// The digest of the rest of the response, e.g. the groups and the templates
+	responseDigest uint64
+}
+
+type entityDigest struct {
+	topology uint64
+	// The used and peak values of the commodities, in the order of the commodities
+	values []float64
+}
+
+func newEntityDTODiffer(tolerance float64, maxUnchangedDiscoveries int) *entityDTODiffer {
+	return &entityDTODiffer{
+		tolerance:               tolerance,
+		maxUnchangedDiscoveries: maxUnchangedDiscoveries,
+		digests:                 make(map[string]*entityDigest),
+	}
+}
+
+// Diff counts the entities of the given response of a full discovery by change since the last full discovery sent in
+// full, and returns whether the response can be reported to the server as unchanged. Otherwise the response is sent
+// in full, and its digest is kept for the next full discovery.
+func (d *entityDTODiffer) Diff(response *proto.DiscoveryResponse) (map[string]int, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	entityDTOs := response.GetEntityDTO()
+	changes := map[string]int{entityAdded: 0, entityChanged: 0, entityUnchanged: 0, entityRemoved: 0}
+	digests := make(map[string]*entityDigest, len(entityDTOs))
+	for _, entityDTO := range entityDTOs {
+		digest := newEntityDigest(entityDTO)
+		digests[entityDTO.GetId()] = digest
+		previous, found := d.digests[entityDTO.GetId()]
+		switch {
+		case !found:
+			changes[entityAdded]++
+		case d.unchanged(previous, digest):
+			changes[entityUnchanged]++
+		default:
+			changes[entityChanged]++
+		}
+	}
+	for id := range d.digests {
+		if _, found := digests[id]; !found {
+			changes[entityRemoved]++
+		}
+	}
+	responseDigest := newResponseDigest(response)
+	unchanged := len(d.digests) > 0 && changes[entityAdded] == 0 && changes[entityChanged] == 0 &&
+		changes[entityRemoved] == 0 && responseDigest == d.responseDigest
+	if unchanged && d.unchangedDiscoveries < d.maxUnchangedDiscoveries {
+		d.unchangedDiscoveries++
+		return changes, true
+	}
+	d.unchangedDiscoveries = 0
+	d.digests = digests
+	d.responseDigest = responseDigest
+	return changes, false
+}
+
+func (d *entityDTODiffer) unchanged(previous, digest *entityDigest) bool {
+	if previous.topology != digest.topology || len(previous.values) != len(digest.values) {
+		return false
+	}
+	for i, value := range digest.values {
+		if math.Abs(value-previous.values[i]) > d.tolerance*math.Max(math.Abs(previous.values[i]), math.Abs(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// newEntityDigest hashes the topology of the entity and collects the used and peak values of its commodities.
+func newEntityDigest(entityDTO *proto.EntityDTO) *entityDigest {
+	hash := fnv.New64a()
+	digest := &entityDigest{}
+	write := func(format string, args ...interface{}) {
+		_, _ = fmt.Fprintf(hash, format, args...)
+	}
+	addCommodity := func(commodity *proto.CommodityDTO) {
+		write("commodity:%v/%s/%v|", commodity.GetCommodityType(), commodity.GetKey(), commodity.GetCapacity())
+		digest.values = append(digest.values, commodity.GetUsed(), commodity.GetPeak())
+	}
+	write("entity:%v/%s/%s/%v|", entityDTO.GetEntityType(), entityDTO.GetDisplayName(), entityDTO.GetPowerState(),
+		entityDTO.GetUpdateType())
+	for _, commodity := range entityDTO.GetCommoditiesSold() {
+		addCommodity(commodity)
+	}
+	for _, bought := range entityDTO.GetCommoditiesBought() {
+		write("provider:%v/%s|", bought.GetProviderType(), bought.GetProviderId())
+		for _, commodity := range bought.GetBought() {
+			addCommodity(commodity)
+		}
+	}
+	var properties []string
+	for _, property := range entityDTO.GetEntityProperties() {
+		properties = append(properties,
+			fmt.Sprintf("%s/%s=%s", property.GetNamespace(), property.GetName(), property.GetValue()))
+	}
+	// The properties are built from maps, e.g. the labels, in a random order
+	sort.Strings(properties)
+	for _, property := range properties {
+		write("property:%s|", property)
+	}
+	digest.topology = hash.Sum64()
+	return digest
+}
+
+// newResponseDigest hashes the parts of the response other than the entities which the server keeps from a full
+// discovery: the groups, the action policies, the templates and the discovery context. The digest does not depend on
+// the order of the groups, but may differ for the same groups with their members in another order, in which case the
+// response is only sent in full.
+func newResponseDigest(response *proto.DiscoveryResponse) uint64 {
+	var hashes []uint64
+	addMessage := func(message protobuf.Message) {
+		data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(message)
+		if err != nil {
+			data = []byte(err.Error())
+		}
+		hash := fnv.New64a()
+		_, _ = hash.Write(data)
+		hashes = append(hashes, hash.Sum64())
+	}
+	for _, group := range response.GetDiscoveredGroup() {
+		addMessage(group)
+	}
+	for _, policy := range response.GetActionPolicies() {
+		addMessage(policy)
+	}
+	for _, profile := range response.GetEntityProfile() {
+		addMessage(profile)
+	}
+	for _, profile := range response.GetDeploymentProfile() {
+		addMessage(profile)
+	}
+	if response.GetDiscoveryContext() != nil {
+		addMessage(response.GetDiscoveryContext())
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	hash := fnv.New64a()
+	for _, h := range hashes {
+		_, _ = fmt.Fprintf(hash, "%x|", h)
+	}
+	return hash.Sum64()
+}
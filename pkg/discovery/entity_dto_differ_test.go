@@ -0,0 +1,105 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newDiffedEntityDTO(id, providerId string, used float64, labels ...string) *proto.EntityDTO {
+	entityType := proto.EntityDTO_CONTAINER_POD
+	providerType := proto.EntityDTO_VIRTUAL_MACHINE
+	commodityType := proto.CommodityDTO_VCPU
+	capacity := 1000.0
+	namespace := "DEFAULT"
+	var properties []*proto.EntityDTO_EntityProperty
+	for i := range labels {
+		name, value := labels[i], "value"
+		properties = append(properties, &proto.EntityDTO_EntityProperty{Namespace: &namespace, Name: &name, Value: &value})
+	}
+	return &proto.EntityDTO{
+		EntityType:       &entityType,
+		Id:               &id,
+		DisplayName:      &id,
+		EntityProperties: properties,
+		CommoditiesBought: []*proto.EntityDTO_CommodityBought{{
+			ProviderId:   &providerId,
+			ProviderType: &providerType,
+			Bought: []*proto.CommodityDTO{{
+				CommodityType: &commodityType,
+				Capacity:      &capacity,
+				Used:          &used,
+				Peak:          &used,
+			}},
+		}},
+	}
+}
+
+func newDiffedResponse(groupNames []string, entityDTOs ...*proto.EntityDTO) *proto.DiscoveryResponse {
+	response := &proto.DiscoveryResponse{EntityDTO: entityDTOs}
+	for i := range groupNames {
+		response.DiscoveredGroup = append(response.DiscoveredGroup, &proto.GroupDTO{DisplayName: &groupNames[i]})
+	}
+	return response
+}
+
+func TestEntityDTODiffer(t *testing.T) {
+	differ := newEntityDTODiffer(defaultDiffTolerance, 0)
+	changes, unchanged := differ.Diff(newDiffedResponse(nil,
+		newDiffedEntityDTO("moved", "node-1", 100),
+		newDiffedEntityDTO("busy", "node-1", 100),
+		newDiffedEntityDTO("stable", "node-1", 100, "a", "b"),
+		newDiffedEntityDTO("deleted", "node-1", 100),
+	))
+	assert.Equal(t, map[string]int{entityAdded: 4, entityChanged: 0, entityUnchanged: 0, entityRemoved: 0}, changes)
+	assert.False(t, unchanged)
+
+	changes, unchanged = differ.Diff(newDiffedResponse(nil,
+		newDiffedEntityDTO("moved", "node-2", 100),
+		newDiffedEntityDTO("busy", "node-1", 200),
+		// Within the tolerance and with the properties in another order
+		newDiffedEntityDTO("stable", "node-1", 103, "b", "a"),
+		newDiffedEntityDTO("created", "node-1", 100),
+	))
+	assert.Equal(t, map[string]int{entityAdded: 1, entityChanged: 2, entityUnchanged: 1, entityRemoved: 1}, changes)
+	assert.False(t, unchanged)
+}
+
+func TestEntityDTODifferUnchanged(t *testing.T) {
+	differ := newEntityDTODiffer(defaultDiffTolerance, 2)
+	// The first full discovery is always sent in full
+	_, unchanged := differ.Diff(newDiffedResponse([]string{"a", "b"}, newDiffedEntityDTO("pod", "node-1", 100)))
+	assert.False(t, unchanged)
+
+	// Within the tolerance and with the groups in another order
+	_, unchanged = differ.Diff(newDiffedResponse([]string{"b", "a"}, newDiffedEntityDTO("pod", "node-1", 104)))
+	assert.True(t, unchanged)
+
+	// Still diffed against the discovery sent in full, so that the values drifting within the tolerance are sent
+	changes, unchanged := differ.Diff(newDiffedResponse([]string{"a", "b"}, newDiffedEntityDTO("pod", "node-1", 108)))
+	assert.Equal(t, 1, changes[entityChanged])
+	assert.False(t, unchanged)
+
+	// A group changed
+	_, unchanged = differ.Diff(newDiffedResponse([]string{"a"}, newDiffedEntityDTO("pod", "node-1", 108)))
+	assert.False(t, unchanged)
+
+	// Sent in full again after the max number of the consecutive discoveries reported as unchanged
+	for i := 0; i < 2; i++ {
+		_, unchanged = differ.Diff(newDiffedResponse([]string{"a"}, newDiffedEntityDTO("pod", "node-1", 108)))
+		assert.True(t, unchanged)
+	}
+	_, unchanged = differ.Diff(newDiffedResponse([]string{"a"}, newDiffedEntityDTO("pod", "node-1", 108)))
+	assert.False(t, unchanged)
+}
+
+func TestNewUnchangedDiscoveryResponse(t *testing.T) {
+	response := newDiffedResponse([]string{"a"}, newDiffedEntityDTO("pod", "node-1", 100))
+	response.Notification = []*proto.NotificationDTO{{}}
+	unchangedResponse := newUnchangedDiscoveryResponse(response)
+	assert.NotNil(t, unchangedResponse.GetNoChange())
+	assert.Empty(t, unchangedResponse.GetEntityDTO())
+	assert.Empty(t, unchangedResponse.GetDiscoveredGroup())
+	assert.Len(t, unchangedResponse.GetNotification(), 1)
+}
@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/probemetrics"
+	"github.com/turbonomic/kubeturbo/pkg/registration"
+)
+
+// DiscoverIncremental reports the pods which have started running or have been deleted since the last full or
+// incremental discovery, so that the server can act on the new pods before the next full discovery.
+// This is a part of the interface that gets registered with and is invoked asynchronously by the GO SDK Probe.
+func (dc *K8sDiscoveryClient) DiscoverIncremental(accountValues []*proto.AccountValue) (*proto.DiscoveryResponse, error) {
+	discoveryResponse := &proto.DiscoveryResponse{}
+	if dc.podChangeTracker == nil {
+		return discoveryResponse, nil
+	}
+
+	dc.discoveryLock.Lock()
+	defer dc.discoveryLock.Unlock()
+
+	clusterSummary := dc.lastClusterSummary
+	if clusterSummary == nil {
+		glog.V(2).Infof("Skip the incremental discovery before the first full discovery.")
+		return discoveryResponse, nil
+	}
+
+	start := time.Now()
+	started, deleted := dc.podChangeTracker.Changes()
+	var entityDTOs []*proto.EntityDTO
+	for _, pod := range deleted {
+		entityDTOs = append(entityDTOs, newDeletedPodDTOs(pod)...)
+	}
+
+	// Discover the started pods on the nodes of the last full discovery, the pods on the new nodes are left to the
+	// next full discovery
+	nodes, nodeRunningPods := groupPodsByNode(started, clusterSummary)
+	if len(nodes) > 0 {
+		taskCount := dc.dispatcher.DispatchPods(nodes, nodeRunningPods, clusterSummary)
+		result := dc.resultCollector.Collect(taskCount)
+		for _, entityDTO := range result.EntityDTOs {
+			// The nodes are reported by the full discoveries only
+			if entityDTO.GetEntityType() != proto.EntityDTO_VIRTUAL_MACHINE {
+				entityDTOs = append(entityDTOs, entityDTO)
+			}
+		}
+	}
+	entityDTOs = dc.Config.DisabledEntityTypes.RemoveEntities(entityDTOs)
+	entityDTOs, _ = configs.RemoveEntities(entityDTOs, excludedInfrastructureIDs(dc.Config.probeConfig.InfrastructurePods,
+		clusterSummary, append(started, deleted...)))
+	dc.dtoFinalizer.Finalize(entityDTOs)
+
+	discoveryResponse.EntityDTO = entityDTOs
+	discoveryResponse.DiscoveryContext = dc.dtoFinalizer.DiscoveryContext()
+	probemetrics.ObserveDiscovery(targetIdentifier(accountValues), probemetrics.IncrementalDiscovery, time.Since(start))
+	glog.V(2).Infof("Incremental discovery of %d started and %d deleted pods returned %d entityDTOs in %s.",
+		len(started), len(deleted), len(entityDTOs), time.Since(start))
+	return discoveryResponse, nil
+}
+
+// targetIdentifier returns the identifier of the target in the given account values.
+func targetIdentifier(accountValues []*proto.AccountValue) string {
+	for _, accountValue := range accountValues {
+		if accountValue.GetKey() == registration.TargetIdentifierField {
+			return accountValue.GetStringValue()
+		}
+	}
+	return ""
+}
+
+// ResetIncrementalDiscovery drops the state of the last full discovery, so that no incremental discovery is reported
+// until the next full discovery, e.g., after the server has restarted and lost the entities reported so far.
+func (dc *K8sDiscoveryClient) ResetIncrementalDiscovery() {
+	if dc.podChangeTracker == nil {
+		return
+	}
+	dc.discoveryLock.Lock()
+	defer dc.discoveryLock.Unlock()
+	dc.lastClusterSummary = nil
+	glog.V(2).Infof("Incremental discoveries are paused until the next full discovery.")
+}
+
+// groupPodsByNode groups the given running pods by the nodes they run on, which are known in the given cluster.
+func groupPodsByNode(pods []*api.Pod, clusterSummary *repository.ClusterSummary) ([]*api.Node, map[string][]*api.Pod) {
+	var nodes []*api.Node
+	nodeRunningPods := make(map[string][]*api.Pod)
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		kubeNode, found := clusterSummary.NodeMap[nodeName]
+		if !found {
+			glog.V(3).Infof("Skip pod %s/%s on node %s which is not discovered yet.", pod.Namespace, pod.Name, nodeName)
+			continue
+		}
+		if _, exists := nodeRunningPods[nodeName]; !exists {
+			nodes = append(nodes, kubeNode.Node)
+		}
+		nodeRunningPods[nodeName] = append(nodeRunningPods[nodeName], pod)
+	}
+	return nodes, nodeRunningPods
+}
+
+// newDeletedPodDTOs creates the entityDTOs which remove the given deleted pod, along with its containers and their
+// application components.
+func newDeletedPodDTOs(pod *api.Pod) []*proto.EntityDTO {
+	podId := string(pod.UID)
+	podFullName := discoveryutil.GetPodClusterID(pod)
+	entityDTOs := []*proto.EntityDTO{newDeletedDTO(proto.EntityDTO_CONTAINER_POD, podId, podFullName)}
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		containerId := discoveryutil.ContainerIdFunc(podId, i)
+		entityDTOs = append(entityDTOs,
+			newDeletedDTO(proto.EntityDTO_CONTAINER, containerId, discoveryutil.ContainerNameFunc(pod, container)),
+			newDeletedDTO(proto.EntityDTO_APPLICATION_COMPONENT, discoveryutil.ApplicationIdFunc(containerId),
+				discoveryutil.ApplicationDisplayName(podFullName, container.Name)))
+	}
+	return entityDTOs
+}
+
+func newDeletedDTO(entityType proto.EntityDTO_EntityType, id, displayName string) *proto.EntityDTO {
+	updateType := proto.UpdateType_DELETED
+	return &proto.EntityDTO{
+		EntityType:  &entityType,
+		Id:          &id,
+		DisplayName: &displayName,
+		UpdateType:  &updateType,
+	}
+}
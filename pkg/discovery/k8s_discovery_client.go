@@ -3,6 +3,8 @@ package discovery
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/KimMachineGun/automemlimit/memlimit"
@@ -10,19 +12,26 @@ import (
 	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	sdkprobe "github.com/turbonomic/turbo-go-sdk/pkg/probe"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 
+	"github.com/turbonomic/kubeturbo/pkg/audit"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/prometheus"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/processor"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/compliance"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/compliance/podaffinity"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/k8sappcomponents"
 	"github.com/turbonomic/kubeturbo/pkg/features"
+	"github.com/turbonomic/kubeturbo/pkg/probemetrics"
 	"github.com/turbonomic/kubeturbo/pkg/registration"
 	"github.com/turbonomic/kubeturbo/pkg/resourcemapping"
 	kubeturboversion "github.com/turbonomic/kubeturbo/version"
@@ -57,6 +66,52 @@ type DiscoveryClientConfig struct {
 	itemsPerListQuery int
 	// VCPU Throttling threshold
 	CommodityConfig *dtofactory.CommodityConfig
+	// The label used to group workload controllers, services and pods into business applications
+	BusinessAppLabel string
+	// The percentile of the container usage over the utilization window reported as the used value, 0 if disabled
+	UtilizationPercentile float64
+	// The duration of the window of the container usage samples kept across discovery cycles
+	UtilizationWindow time.Duration
+	// The max number of container usage samples kept per container resource, the default if not positive
+	UtilizationMaxSamples int
+	// The max number of container resources with usage samples, the default if not positive
+	UtilizationMaxEntities int
+	// The policy to resolve the entity properties with the same namespace and name but different values
+	PropertyConflictPolicy property.ConflictPolicy
+	// The max number of properties of an entity, no limit if not positive
+	MaxEntityProperties int
+	// Whether to tag the discovery response with the schema version of the DTOs
+	EmitSchemaVersion bool
+	// Tracks whether the discovery is degraded, shared with the action execution
+	DiscoveryStatus *discoveryutil.DiscoveryStatus
+	// The max number of entities of a discovery, nil if not limited
+	EntityLimits *dtofactory.EntityLimits
+	// Whether to report the pods started or deleted since the last full discovery in the incremental discoveries
+	IncrementalDiscovery bool
+	// The directory to which the response of each full discovery is written, none if empty
+	DumpDTOsDir string
+	// Whether to read the pods, nodes, services, endpoints and workload controllers from the local cache of shared
+	// informers instead of listing them from the API server in each discovery
+	InformerCache bool
+	// How long the response of a full discovery is sent again for the following full discoveries instead of
+	// discovering the cluster, disabled if not positive
+	SnapshotReuseWindow time.Duration
+	// Spreads the discovery of the nodes over the discovery shard workers, nil if the discovery is not sharded
+	ShardDiscoverer ShardDiscoverer
+	// The maximum number of the consecutive full discoveries reported to the server as unchanged, without their
+	// entities, disabled if not positive
+	MaxUnchangedDiscoveries int
+	// The entity types left out of the supply chain and the discovery
+	DisabledEntityTypes configs.DisabledEntityTypes
+	// The resize mode of the ContainerSpecs of the injected sidecars
+	SidecarResizeMode string
+	// How the resizes of the workload controllers resized by a VerticalPodAutoscaler coexist with it
+	VPACoexistenceMode string
+	// The collector of the request rate and the response time of the services of the Istio service mesh, nil if
+	// disabled
+	ServiceMeshCollector *prometheus.ServiceMeshCollector
+	// Records the summaries of the full discoveries in the audit log, nil if disabled
+	AuditRecorder *audit.Recorder
 }
 
 func NewDiscoveryConfig(probeConfig *configs.ProbeConfig,
@@ -95,6 +150,7 @@ func NewDiscoveryConfig(probeConfig *configs.ProbeConfig,
 		DiscoverySamples:                    discoverySamples,
 		DiscoverySampleIntervalSec:          discoverySampleIntervalSec,
 		itemsPerListQuery:                   itemsPerListQuery,
+		EmitSchemaVersion:                   true,
 	}
 }
 
@@ -104,6 +160,125 @@ func (config *DiscoveryClientConfig) WithClusterKeyInjected(clusterKeyInjected s
 	return config
 }
 
+// WithBusinessAppLabel sets the label used to group resources into business applications.
+func (config *DiscoveryClientConfig) WithBusinessAppLabel(businessAppLabel string) *DiscoveryClientConfig {
+	config.BusinessAppLabel = businessAppLabel
+	return config
+}
+
+// WithUtilizationPercentile sets the percentile of the container usage over the given window to be reported
+// as the used value of the container commodities.
+func (config *DiscoveryClientConfig) WithUtilizationPercentile(percentile float64, window time.Duration) *DiscoveryClientConfig {
+	config.UtilizationPercentile = percentile
+	config.UtilizationWindow = window
+	return config
+}
+
+// WithUtilizationLimits sets the max number of container usage samples kept per container resource, and the max
+// number of container resources with usage samples, to bound the memory used by the utilization history.
+func (config *DiscoveryClientConfig) WithUtilizationLimits(maxSamples, maxEntities int) *DiscoveryClientConfig {
+	config.UtilizationMaxSamples = maxSamples
+	config.UtilizationMaxEntities = maxEntities
+	return config
+}
+
+// WithPropertyNormalization sets how the duplicate entity properties are resolved and the max number of properties
+// of an entity.
+func (config *DiscoveryClientConfig) WithPropertyNormalization(policy property.ConflictPolicy,
+	maxEntityProperties int) *DiscoveryClientConfig {
+	config.PropertyConflictPolicy = policy
+	config.MaxEntityProperties = maxEntityProperties
+	return config
+}
+
+// WithSchemaVersion sets whether to tag the discovery response with the schema version of the DTOs.
+func (config *DiscoveryClientConfig) WithSchemaVersion(emitSchemaVersion bool) *DiscoveryClientConfig {
+	config.EmitSchemaVersion = emitSchemaVersion
+	return config
+}
+
+// WithDiscoveryStatus sets the tracker of the degraded discoveries, which is shared with the action execution.
+func (config *DiscoveryClientConfig) WithDiscoveryStatus(discoveryStatus *discoveryutil.DiscoveryStatus) *DiscoveryClientConfig {
+	config.DiscoveryStatus = discoveryStatus
+	return config
+}
+
+// WithEntityLimits sets the max number of entities of a discovery, per entity type and in total.
+func (config *DiscoveryClientConfig) WithEntityLimits(entityLimits *dtofactory.EntityLimits) *DiscoveryClientConfig {
+	config.EntityLimits = entityLimits
+	return config
+}
+
+// WithIncrementalDiscovery sets whether to watch the pods of the cluster and report the pods started or deleted
+// since the last full discovery in the incremental discoveries.
+func (config *DiscoveryClientConfig) WithIncrementalDiscovery(incrementalDiscovery bool) *DiscoveryClientConfig {
+	config.IncrementalDiscovery = incrementalDiscovery
+	return config
+}
+
+// WithInformerCache sets whether the resources listed by discovery are read from the local cache of shared informers.
+func (config *DiscoveryClientConfig) WithInformerCache(informerCache bool) *DiscoveryClientConfig {
+	config.InformerCache = informerCache
+	return config
+}
+
+// WithDumpDTOsDir sets the directory to which the response of each full discovery is written as json and proto files.
+func (config *DiscoveryClientConfig) WithDumpDTOsDir(dumpDTOsDir string) *DiscoveryClientConfig {
+	config.DumpDTOsDir = dumpDTOsDir
+	return config
+}
+
+// WithMaxUnchangedDiscoveries sets the maximum number of the consecutive full discoveries reported to the server as
+// unchanged, without their entities, when none of their entities changed since the last full discovery sent in full.
+func (config *DiscoveryClientConfig) WithMaxUnchangedDiscoveries(maxUnchangedDiscoveries int) *DiscoveryClientConfig {
+	config.MaxUnchangedDiscoveries = maxUnchangedDiscoveries
+	return config
+}
+
+// WithShardDiscoverer spreads the discovery of the nodes over the discovery shard workers with the given discoverer.
+func (config *DiscoveryClientConfig) WithShardDiscoverer(discoverer ShardDiscoverer) *DiscoveryClientConfig {
+	config.ShardDiscoverer = discoverer
+	return config
+}
+
+// WithSnapshotReuseWindow sets how long the response of a full discovery is sent again for the following full
+// discoveries, e.g. triggered by the plans of the server, instead of discovering the cluster again.
+func (config *DiscoveryClientConfig) WithSnapshotReuseWindow(window time.Duration) *DiscoveryClientConfig {
+	config.SnapshotReuseWindow = window
+	return config
+}
+
+// WithDisabledEntityTypes leaves the given entity types out of the discovery.
+func (config *DiscoveryClientConfig) WithDisabledEntityTypes(disabledEntityTypes configs.DisabledEntityTypes) *DiscoveryClientConfig {
+	config.DisabledEntityTypes = disabledEntityTypes
+	return config
+}
+
+// WithSidecarResizeMode sets the resize mode of the ContainerSpecs of the injected sidecars.
+func (config *DiscoveryClientConfig) WithSidecarResizeMode(mode string) *DiscoveryClientConfig {
+	config.SidecarResizeMode = mode
+	return config
+}
+
+// WithVPACoexistenceMode sets how the resizes of the workload controllers resized by a VerticalPodAutoscaler
+// coexist with it.
+func (config *DiscoveryClientConfig) WithVPACoexistenceMode(mode string) *DiscoveryClientConfig {
+	config.VPACoexistenceMode = mode
+	return config
+}
+
+// WithServiceMeshCollector sets the collector of the metrics of the services of the Istio service mesh.
+func (config *DiscoveryClientConfig) WithServiceMeshCollector(collector *prometheus.ServiceMeshCollector) *DiscoveryClientConfig {
+	config.ServiceMeshCollector = collector
+	return config
+}
+
+// WithAuditRecorder sets the recorder of the summaries of the full discoveries in the audit log.
+func (config *DiscoveryClientConfig) WithAuditRecorder(recorder *audit.Recorder) *DiscoveryClientConfig {
+	config.AuditRecorder = recorder
+	return config
+}
+
 // K8sDiscoveryClient defines the go sdk discovery client interface
 type K8sDiscoveryClient struct {
 	Config                 *DiscoveryClientConfig
@@ -113,6 +288,31 @@ type K8sDiscoveryClient struct {
 	samplingDispatcher     *worker.SamplingDispatcher
 	resultCollector        *worker.ResultCollector
 	globalEntityMetricSink *metrics.EntityMetricSink
+	// Usage history of containers across discovery cycles, nil if the utilization percentile is disabled
+	utilizationHistory *metrics.UtilizationHistory
+	// Restarts of containers across discovery cycles
+	restartTracker *metrics.ContainerRestartTracker
+	// Diffs the entity DTOs of each full discovery against the previous one
+	entityDTODiffer *entityDTODiffer
+	// Reports the health of the probe with each full discovery
+	discoveryHealth discoveryHealth
+	// The response of the last full discovery, for debugging
+	discoverySnapshot *DiscoverySnapshot
+	// Whether the next full discovery discovers the cluster rather than reusing the last snapshot
+	freshDiscoveryRequired atomic.Bool
+	// Final normalization pass over the entity DTOs of each discovery
+	dtoFinalizer *dtofactory.EntityDTOFinalizer
+	// Tracks whether the discovery is degraded
+	discoveryStatus *discoveryutil.DiscoveryStatus
+	// Tracks the pods started or deleted since the last discovery, nil if the incremental discovery is disabled
+	podChangeTracker *podChangeTracker
+	// The cluster summary of the last full discovery, used by the incremental discoveries
+	lastClusterSummary *repository.ClusterSummary
+	// The templates of the nodes built by the last full discovery, sent with its entities
+	nodeEntityProfiles     []*proto.EntityProfileDTO
+	nodeDeploymentProfiles []*proto.DeploymentProfileDTO
+	// Serializes the full and the incremental discoveries, which share the discovery workers
+	discoveryLock sync.Mutex
 }
 
 func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
@@ -121,15 +321,31 @@ func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
 	// for discovery tasks
 	clusterProcessor := processor.NewClusterProcessor(k8sClusterScraper, config.probeConfig.NodeClient,
 		config.ValidationWorkers, config.ValidationTimeoutSec, config.itemsPerListQuery)
+	if clusterProcessor != nil {
+		clusterProcessor.WithBusinessAppLabel(config.BusinessAppLabel).
+			WithVPADiscovery(config.VPACoexistenceMode != worker.VPACoexistenceIgnore)
+	}
 
 	globalEntityMetricSink := metrics.NewEntityMetricSink().WithMaxMetricPointsSize(config.DiscoverySamples)
 
 	// make maxWorkerCount of result collector twice the worker count.
 	resultCollector := worker.NewResultCollector(config.DiscoveryWorkers * 2)
 
+	var utilizationHistory *metrics.UtilizationHistory
+	if config.UtilizationPercentile > 0 {
+		glog.Infof("Reporting p%v of the container usage over a window of %v.",
+			config.UtilizationPercentile, config.UtilizationWindow)
+		utilizationHistory = metrics.NewUtilizationHistory(config.UtilizationPercentile, config.UtilizationWindow,
+			config.UtilizationMaxSamples, config.UtilizationMaxEntities)
+	}
+
+	restartTracker := metrics.NewContainerRestartTracker()
+
 	dispatcherConfig := worker.NewDispatcherConfig(k8sClusterScraper, config.probeConfig,
 		config.DiscoveryWorkers, config.DiscoveryTimeoutSec, config.DiscoverySamples, config.DiscoverySampleIntervalSec).
-		WithClusterKeyInjected(config.ClusterKeyInjected)
+		WithClusterKeyInjected(config.ClusterKeyInjected).
+		WithUtilizationHistory(utilizationHistory).
+		WithContainerRestartTracker(restartTracker)
 	dispatcher := worker.NewDispatcher(dispatcherConfig, globalEntityMetricSink)
 	dispatcher.Init(resultCollector)
 
@@ -140,6 +356,11 @@ func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
 	dataSamplingDispatcher := worker.NewSamplingDispatcher(samplingDispatcherConfig, globalEntityMetricSink)
 	dataSamplingDispatcher.InitSamplingDiscoveryWorkers()
 
+	discoveryStatus := config.DiscoveryStatus
+	if discoveryStatus == nil {
+		discoveryStatus = discoveryutil.NewDiscoveryStatus()
+	}
+
 	dc := &K8sDiscoveryClient{
 		Config:                 config,
 		k8sClusterScraper:      k8sClusterScraper,
@@ -148,6 +369,33 @@ func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
 		samplingDispatcher:     dataSamplingDispatcher,
 		resultCollector:        resultCollector,
 		globalEntityMetricSink: globalEntityMetricSink,
+		utilizationHistory:     utilizationHistory,
+		restartTracker:         restartTracker,
+		entityDTODiffer:        newEntityDTODiffer(defaultDiffTolerance, config.MaxUnchangedDiscoveries),
+		discoverySnapshot:      NewDiscoverySnapshot(config.DumpDTOsDir),
+		dtoFinalizer: dtofactory.NewEntityDTOFinalizer().
+			WithPropertyConflictPolicy(config.PropertyConflictPolicy).
+			WithMaxEntityProperties(config.MaxEntityProperties).
+			WithSchemaVersion(config.EmitSchemaVersion).
+			WithEntityLimits(config.EntityLimits).
+			WithDiscoveryStatus(discoveryStatus),
+		discoveryStatus: discoveryStatus,
+	}
+	if config.InformerCache {
+		glog.Infof("Reading the pods, nodes, services, endpoints and workload controllers from the informer cache.")
+		// The resources are watched for the lifetime of the probe
+		k8sClusterScraper.StartInformerCache(make(chan struct{}), discoveryStatus.RecordWatchError)
+	}
+	if config.IncrementalDiscovery {
+		glog.Infof("Watching the pods to report the pods started or deleted between the full discoveries.")
+		dc.podChangeTracker = newPodChangeTracker()
+		if informerCache := k8sClusterScraper.InformerCache(); informerCache != nil {
+			// The pods are already watched by the informer cache
+			dc.podChangeTracker.Watch(informerCache.PodInformer())
+		} else {
+			// The pods are watched for the lifetime of the probe
+			dc.podChangeTracker.Start(k8sClusterScraper.Clientset, make(chan struct{}))
+		}
 	}
 	return dc
 }
@@ -289,25 +537,181 @@ func (dc *K8sDiscoveryClient) Discover(
 		return
 	}
 
+	dc.discoveryLock.Lock()
+	defer dc.discoveryLock.Unlock()
+
+	// The requests which arrive while a discovery is in progress wait for it above, and are served its response
+	freshDiscoveryRequired := dc.freshDiscoveryRequired.Swap(false)
+	if dc.Config.SnapshotReuseWindow > 0 && !freshDiscoveryRequired {
+		if cached, age := dc.discoverySnapshot.Clone(targetID, dc.Config.SnapshotReuseWindow); cached != nil {
+			glog.V(2).Infof("Sending the response of the full discovery of %.3f seconds ago with %d entities "+
+				"instead of discovering kubernetes cluster again.", age.Seconds(), len(cached.GetEntityDTO()))
+			probemetrics.RecordDiscoverySnapshotReuse(targetID)
+			discoveryResponse = cached
+			return
+		}
+	}
+
 	currentTime := time.Now()
+	dc.discoveryStatus.Begin()
 	newDiscoveryResultDTOs, groupDTOs, err := dc.DiscoverWithNewFramework(targetID)
 	if err != nil {
 		glog.Errorf("Failed to discover kubernetes cluster: %v", err)
+		probemetrics.RecordDiscoveryFailure(targetID, probemetrics.FullDiscovery)
+		dc.discoveryHealth.recordFailure()
+		dc.Config.AuditRecorder.Record(audit.Event{Kind: audit.KindDiscovery, Result: "failed", Message: err.Error()})
 		return
 	}
 
+	// Drop the entities above the limits, if any, and report the discovery as degraded
+	newDiscoveryResultDTOs, _ = dc.dtoFinalizer.TruncateEntities(newDiscoveryResultDTOs, groupDTOs)
+
+	// De-duplicate and cap the entity properties added by the different builders and processors
+	dc.dtoFinalizer.Finalize(newDiscoveryResultDTOs)
+
 	discoveryResponse = &proto.DiscoveryResponse{
 		DiscoveredGroup: groupDTOs,
 		EntityDTO:       newDiscoveryResultDTOs,
 		ActionPolicies:  dc.getTargetActionPolicies(),
+		// The templates of the nodes
+		EntityProfile:     dc.nodeEntityProfiles,
+		DeploymentProfile: dc.nodeDeploymentProfiles,
+		// The schema version of the DTOs
+		DiscoveryContext: dc.dtoFinalizer.DiscoveryContext(),
 	}
 
-	newFrameworkDiscTime := time.Now().Sub(currentTime).Seconds()
-	glog.V(2).Infof("Successfully discovered kubernetes cluster in %.3f seconds", newFrameworkDiscTime)
+	degraded, reasons := dc.discoveryStatus.Complete()
+	if degraded {
+		glog.Warningf("Discovery of kubernetes cluster is degraded, the discovered data may be stale or inconsistent: %s",
+			strings.Join(reasons, "; "))
+		discoveryResponse.ErrorDTO = append(discoveryResponse.ErrorDTO, newDegradedDiscoveryErrorDTO(reasons))
+	}
+	discoveryDuration := time.Now().Sub(currentTime)
+	discoveryResponse.Notification = append(discoveryResponse.Notification,
+		dc.discoveryHealth.notification(len(newDiscoveryResultDTOs), discoveryDuration, reasons))
+	dc.discoverySnapshot.Record(targetID, currentTime, discoveryResponse)
 
+	probemetrics.ObserveDiscovery(targetID, probemetrics.FullDiscovery, discoveryDuration)
+	probemetrics.SetDiscoveredEntities(targetID, countEntitiesByType(newDiscoveryResultDTOs))
+	changes, unchanged := dc.entityDTODiffer.Diff(discoveryResponse)
+	probemetrics.SetDiscoveredEntityChanges(targetID, changes)
+	glog.V(2).Infof("Entities since the last full discovery sent in full: %d added, %d changed, %d unchanged, "+
+		"%d removed.", changes[entityAdded], changes[entityChanged], changes[entityUnchanged], changes[entityRemoved])
+	glog.V(2).Infof("Successfully discovered kubernetes cluster in %.3f seconds", discoveryDuration.Seconds())
+	dc.auditDiscovery(discoveryResponse, discoveryDuration, reasons, changes, unchanged)
+
+	if unchanged {
+		// The snapshot above keeps the full response, e.g. for the debug endpoint and the snapshot reuse
+		glog.V(2).Infof("Reporting the full discovery to the server as unchanged, without its %d entities.",
+			len(newDiscoveryResultDTOs))
+		discoveryResponse = newUnchangedDiscoveryResponse(discoveryResponse)
+	}
 	return
 }
 
+// newUnchangedDiscoveryResponse returns the response reporting the given full discovery as unchanged since the last
+// full discovery sent in full, so that the server keeps the entities and the groups of the latter. The errors and the
+// notifications of the discovery are still reported.
+func newUnchangedDiscoveryResponse(response *proto.DiscoveryResponse) *proto.DiscoveryResponse {
+	return &proto.DiscoveryResponse{
+		ErrorDTO:         response.GetErrorDTO(),
+		Notification:     response.GetNotification(),
+		DiscoveryContext: response.GetDiscoveryContext(),
+		NoChange:         &proto.NoChange{},
+	}
+}
+
+// auditDiscovery records the summary of the full discovery in the audit log, if enabled.
+func (dc *K8sDiscoveryClient) auditDiscovery(discoveryResponse *proto.DiscoveryResponse, duration time.Duration,
+	degradedReasons []string, changes map[string]int, unchanged bool) {
+	if dc.Config.AuditRecorder == nil {
+		return
+	}
+	result := "succeeded"
+	if len(degradedReasons) > 0 {
+		result = "degraded"
+	}
+	details := map[string]string{
+		"entities": fmt.Sprint(len(discoveryResponse.GetEntityDTO())),
+		"groups":   fmt.Sprint(len(discoveryResponse.GetDiscoveredGroup())),
+		"duration": duration.Round(time.Millisecond).String(),
+		"sent":     "full",
+	}
+	if unchanged {
+		details["sent"] = "unchanged"
+	}
+	for change, count := range changes {
+		details[change] = fmt.Sprint(count)
+	}
+	dc.Config.AuditRecorder.Record(audit.Event{
+		Kind:    audit.KindDiscovery,
+		Result:  result,
+		Message: strings.Join(degradedReasons, "; "),
+		Details: details,
+	})
+}
+
+// DiscoverySnapshot returns the snapshot of the response of the last full discovery.
+func (dc *K8sDiscoveryClient) DiscoverySnapshot() *DiscoverySnapshot {
+	return dc.discoverySnapshot
+}
+
+// RequireFreshDiscovery makes the next full discovery discover the cluster even within the snapshot reuse window, e.g.
+// for the rediscoveries requested on demand.
+func (dc *K8sDiscoveryClient) RequireFreshDiscovery() {
+	dc.freshDiscoveryRequired.Store(true)
+}
+
+// WaitForFullDiscovery waits for at most the timeout until a full discovery of the given target started after the
+// given time completes, and returns the number of its entities by entity type along with the time it completed. It
+// returns false if no such discovery completes in time, e.g. when the discovery fails.
+func (dc *K8sDiscoveryClient) WaitForFullDiscovery(targetID string, startedAfter time.Time,
+	timeout time.Duration) (map[string]int, time.Time, bool) {
+	response, completedAt := dc.discoverySnapshot.WaitForDiscovery(targetID, startedAfter, timeout)
+	if response == nil {
+		return nil, time.Time{}, false
+	}
+	return countEntitiesByType(response.GetEntityDTO()), completedAt, true
+}
+
+// WaitForDiscovery waits for the full or incremental discovery in progress, if any, to complete for at most the
+// timeout. It returns false if the discovery is still in progress after the timeout.
+func (dc *K8sDiscoveryClient) WaitForDiscovery(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		dc.discoveryLock.Lock()
+		dc.discoveryLock.Unlock()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// countEntitiesByType counts the given entity DTOs by entity type.
+func countEntitiesByType(entityDTOs []*proto.EntityDTO) map[string]int {
+	counts := make(map[string]int)
+	for _, entityDTO := range entityDTOs {
+		counts[entityDTO.GetEntityType().String()]++
+	}
+	return counts
+}
+
+// newDegradedDiscoveryErrorDTO creates the warning which reports a degraded discovery to the server.
+func newDegradedDiscoveryErrorDTO(reasons []string) *proto.ErrorDTO {
+	severity := proto.ErrorDTO_WARNING
+	description := fmt.Sprintf("Discovery is degraded: %s", strings.Join(reasons, "; "))
+	return &proto.ErrorDTO{
+		Severity:    &severity,
+		Description: &description,
+	}
+}
+
 // DiscoverWithNewFramework performs the actual discovery.
 func (dc *K8sDiscoveryClient) DiscoverWithNewFramework(targetID string) ([]*proto.EntityDTO, []*proto.GroupDTO, error) {
 	// CREATE CLUSTER, NODES, NAMESPACES, QUOTAS, SERVICES HERE
@@ -317,45 +721,14 @@ func (dc *K8sDiscoveryClient) DiscoverWithNewFramework(targetID string) ([]*prot
 		return nil, nil, fmt.Errorf("failed to process cluster: %v", err)
 	}
 	glog.V(3).Infof("Discovering cluster resources took %s", time.Since(start))
-
-	// affinity process with new algorithm
-	var nodesPods map[string][]string
-	var podsWithAffinities sets.String
-	var hostnameSpreadWorkloads map[string]sets.String
-	var otherSpreadPods sets.String
-	if !utilfeature.DefaultFeatureGate.Enabled(features.IgnoreAffinities) {
-		if utilfeature.DefaultFeatureGate.Enabled(features.NewAffinityProcessing) {
-			glog.V(2).Infof("Begin to process affinity with new algorithm.")
-			start := time.Now()
-			namespaceLister, err := podaffinity.NewNamespaceLister(dc.k8sClusterScraper.Clientset, clusterSummary)
-			if err != nil {
-				glog.Errorf("Error creating affinity processor: %v", err)
-			} else {
-				affinityProcessor, err := podaffinity.New(clusterSummary,
-					podaffinity.NewNodeInfoLister(clusterSummary), namespaceLister)
-				if err != nil {
-					glog.Errorf("Failure in processing affinity rules: %s", err)
-				} else {
-					nodesPods, podsWithAffinities, hostnameSpreadWorkloads, otherSpreadPods = affinityProcessor.ProcessAffinities(clusterSummary.Pods)
-				}
-				glog.V(2).Infof("Successfully processed affinities.")
-				glog.V(3).Infof("Processing affinities with new algorithm took %s", time.Since(start))
-				if glog.V(3) {
-					nodeCommsTotal := 0
-					for node, pods := range nodesPods {
-						nodeCommsTotal += len(pods)
-						glog.Infof("Node %s will sell %v affinity related commodities.", node, len(pods))
-					}
-					glog.Infof("Total %v affinity related commodities will be sold by all nodes.", nodeCommsTotal)
-				}
-				glog.V(6).Infof("\n\nProcessed affinity result: \n\n %++v \n\n %++v \n\n",
-					nodesPods, podsWithAffinities)
-			}
-		}
-	} else {
-		glog.V(2).Infof("Ignoring affinities.")
+	if dc.podChangeTracker != nil {
+		// The pods started or deleted from now on are reported by the incremental discoveries
+		dc.podChangeTracker.Reset(clusterSummary.Pods)
+		dc.lastClusterSummary = clusterSummary
 	}
 
+	nodesPods, podsWithAffinities, hostnameSpreadWorkloads, otherSpreadPods := dc.processAffinities(clusterSummary)
+
 	// ORM Discovery
 	dc.Config.ORMClientManager.DiscoverORMs()
 
@@ -370,35 +743,44 @@ func (dc *K8sDiscoveryClient) DiscoverWithNewFramework(targetID string) ([]*prot
 	// Discover pods and create DTOs for nodes, namespaces, controllers, pods, containers, application.
 	// Merge collected usage data samples from globalEntityMetricSink into the metric sink of each individual discovery worker.
 	// Collect the kubePod, kubeNamespace metrics, groups and kubeControllers from all the discovery workers.
-	taskCount := dc.dispatcher.Dispatch(nodes, nodesPods, podsWithAffinities, otherSpreadPods, hostnameSpreadWorkloads, clusterSummary)
-	result := dc.resultCollector.Collect(taskCount)
+	dispatchNodes := func(nodes []*api.Node) *worker.DiscoveryResult {
+		taskCount := dc.dispatcher.Dispatch(nodes, nodesPods, podsWithAffinities, otherSpreadPods,
+			hostnameSpreadWorkloads, clusterSummary)
+		return dc.resultCollector.Collect(taskCount)
+	}
+	result, dispatchedNodes := dc.discoverShards(nodes, dispatchNodes)
 	glog.V(3).Infof("Collection and processing of metrics from node kubelets took %s", time.Since(start))
 
-	// Clear globalEntityMetricSink cache after collecting full discovery results
-	dc.globalEntityMetricSink.ClearCache()
-	// Reschedule dispatch sampling discovery tasks for newly discovered nodes
-	dc.samplingDispatcher.ScheduleDispatch(nodes)
+	// The nodes of the shard workers, if any, are sampled by the workers
+	dc.finishNodeDiscovery(dispatchedNodes)
 
 	start = time.Now()
+	disabledEntityTypes := dc.Config.DisabledEntityTypes
 	// Namespace discovery worker to create namespace DTOs
-	stitchType := dc.Config.probeConfig.StitchingPropertyType
-	namespacesDiscoveryWorker := worker.Newk8sNamespaceDiscoveryWorker(clusterSummary, stitchType)
-	namespaceDtos, err := namespacesDiscoveryWorker.Do(result.NamespaceMetrics)
-	if err != nil {
-		glog.Errorf("Failed to discover namespaces from current Kubernetes cluster with the new discovery framework: %s", err)
-	} else {
-		glog.V(2).Infof("There are %d namespace entityDTOs.", len(namespaceDtos))
-		result.EntityDTOs = append(result.EntityDTOs, namespaceDtos...)
+	var namespaceDtos []*proto.EntityDTO
+	if disabledEntityTypes.Enabled(proto.EntityDTO_NAMESPACE) {
+		stitchType := dc.Config.probeConfig.StitchingPropertyType
+		namespacesDiscoveryWorker := worker.Newk8sNamespaceDiscoveryWorker(clusterSummary, stitchType)
+		namespaceDtos, err = namespacesDiscoveryWorker.Do(result.NamespaceMetrics)
+		if err != nil {
+			glog.Errorf("Failed to discover namespaces from current Kubernetes cluster with the new discovery framework: %s", err)
+		} else {
+			glog.V(2).Infof("There are %d namespace entityDTOs.", len(namespaceDtos))
+			result.EntityDTOs = append(result.EntityDTOs, namespaceDtos...)
+		}
 	}
 
 	// K8s workload controller discovery worker to create WorkloadController DTOs
-	controllerDiscoveryWorker := worker.NewK8sControllerDiscoveryWorker(clusterSummary)
-	workloadControllerDtos, err := controllerDiscoveryWorker.Do(clusterSummary, result.KubeControllers)
-	if err != nil {
-		glog.Errorf("Failed to discover workload controllers from current Kubernetes cluster with the new discovery framework: %s", err)
-	} else {
-		glog.V(2).Infof("There are %d WorkloadController entityDTOs.", len(workloadControllerDtos))
-		result.EntityDTOs = append(result.EntityDTOs, workloadControllerDtos...)
+	if disabledEntityTypes.Enabled(proto.EntityDTO_WORKLOAD_CONTROLLER) {
+		controllerDiscoveryWorker := worker.NewK8sControllerDiscoveryWorker(clusterSummary).
+			WithOverheadNamespaces(dc.Config.probeConfig.InfrastructurePods.IsOverhead)
+		workloadControllerDtos, err := controllerDiscoveryWorker.Do(clusterSummary, result.KubeControllers)
+		if err != nil {
+			glog.Errorf("Failed to discover workload controllers from current Kubernetes cluster with the new discovery framework: %s", err)
+		} else {
+			glog.V(2).Infof("There are %d WorkloadController entityDTOs.", len(workloadControllerDtos))
+			result.EntityDTOs = append(result.EntityDTOs, workloadControllerDtos...)
+		}
 	}
 
 	// K8s container spec discovery worker to create ContainerSpec DTOs by aggregating commodities data of container
@@ -415,14 +797,25 @@ func (dc *K8sDiscoveryClient) DiscoverWithNewFramework(targetID string) ([]*prot
 	}
 
 	// Service DTOs
-	glog.V(2).Infof("Begin to generate service EntityDTOs.")
-	serviceDTOs := dtofactory.
-		NewServiceEntityDTOBuilder(clusterSummary, dc.k8sClusterScraper, result.PodEntitiesMap).
-		BuildDTOs()
-	result.EntityDTOs = append(result.EntityDTOs, serviceDTOs...)
-	glog.V(2).Infof("There are %d service entityDTOs.", len(serviceDTOs))
-
-	if utilfeature.DefaultFeatureGate.Enabled(features.PersistentVolumes) {
+	if disabledEntityTypes.Enabled(proto.EntityDTO_SERVICE) {
+		glog.V(2).Infof("Begin to generate service EntityDTOs.")
+		serviceDTOBuilder := dtofactory.
+			NewServiceEntityDTOBuilder(clusterSummary, dc.k8sClusterScraper, result.PodEntitiesMap)
+		if dc.Config.ServiceMeshCollector != nil {
+			// The services are discovered without the service mesh commodities if the metrics are not available
+			if meshMetrics, err := dc.Config.ServiceMeshCollector.Collect(); err != nil {
+				glog.Errorf("Failed to collect the service mesh metrics: %v", err)
+			} else {
+				serviceDTOBuilder.WithServiceMeshMetrics(meshMetrics.Transactions, meshMetrics.ResponseTimes)
+			}
+		}
+		serviceDTOs := serviceDTOBuilder.BuildDTOs()
+		result.EntityDTOs = append(result.EntityDTOs, serviceDTOs...)
+		glog.V(2).Infof("There are %d service entityDTOs.", len(serviceDTOs))
+	}
+
+	if utilfeature.DefaultFeatureGate.Enabled(features.PersistentVolumes) &&
+		disabledEntityTypes.Enabled(proto.EntityDTO_VIRTUAL_VOLUME) {
 		glog.V(2).Infof("Begin to generate persistent volume EntityDTOs.")
 		// Persistent Volume DTOs
 		volumeEntityDTOBuilder := dtofactory.NewVolumeEntityDTOBuilder(result.PodVolumeMetrics)
@@ -471,10 +864,25 @@ func (dc *K8sDiscoveryClient) DiscoverWithNewFramework(targetID string) ([]*prot
 
 	glog.V(2).Infof("Successfully processed taints and tolerations.")
 
+	// Topology spread process to keep the pods within the max skew of their topology spread constraints
+	compliance.NewTopologySpreadProcessor(clusterSummary).Process(result.EntityDTOs)
+
+	// Drop the commodities bought from the disabled entity types, and their entities built by other builders
+	result.EntityDTOs = disabledEntityTypes.RemoveEntities(result.EntityDTOs)
+	// Drop the entities of the excluded infrastructure namespaces
+	excludedIDs := excludedInfrastructureIDs(dc.Config.probeConfig.InfrastructurePods, clusterSummary,
+		clusterSummary.Pods)
+	result.EntityDTOs, excludedIDs = configs.RemoveEntities(result.EntityDTOs, excludedIDs)
+	namespaceDtos, _ = configs.RemoveEntities(namespaceDtos, excludedIDs)
+
 	// Discovery worker for creating Group DTOs
-	entityGroupDiscoveryWorker := worker.Newk8sEntityGroupDiscoveryWorker(clusterSummary, targetID)
+	entityGroupDiscoveryWorker := worker.Newk8sEntityGroupDiscoveryWorker(clusterSummary, targetID).
+		WithSidecarResizeMode(dc.Config.SidecarResizeMode).
+		WithVPACoexistenceMode(dc.Config.VPACoexistenceMode)
 	groupDTOs, _ := entityGroupDiscoveryWorker.Do(result.EntityGroups, result.SidecarContainerSpecs,
 		result.PodsWithVolumes, result.NotReadyNodes, result.MirrorPodUids)
+	// The groups are built from the cluster summary, so the members of the excluded namespaces are removed
+	configs.RemoveGroupMembers(groupDTOs, excludedIDs)
 
 	glog.V(2).Infof("There are totally %d groups DTOs", len(groupDTOs))
 	if glog.V(4) {
@@ -494,9 +902,161 @@ func (dc *K8sDiscoveryClient) DiscoverWithNewFramework(targetID string) ([]*prot
 		result.EntityDTOs = append(result.EntityDTOs, clusterEntityDTO)
 	}
 
+	// Create the templates of the nodes, scoped to the cluster
+	dc.nodeEntityProfiles, dc.nodeDeploymentProfiles = nil, nil
+	if clusterEntityDTO != nil {
+		dc.nodeEntityProfiles, dc.nodeDeploymentProfiles = dtofactory.NewNodeProfileDTOBuilder(clusterSummary,
+			clusterEntityDTO.GetId()).Build(result.EntityDTOs)
+	}
+
 	return result.EntityDTOs, groupDTOs, nil
 }
 
+// processAffinities processes the affinities and the topology spread constraints of the pods of the cluster with the
+// new algorithm, unless the affinities are ignored or processed with the old algorithm after the DTOs are built. It
+// returns the pods for which each node sells the affinity commodities, the pods with affinities, the workloads spread
+// over the hostnames and the other spread pods.
+func (dc *K8sDiscoveryClient) processAffinities(clusterSummary *repository.ClusterSummary) (map[string][]string,
+	sets.String, map[string]sets.String, sets.String) {
+	var nodesPods map[string][]string
+	var podsWithAffinities sets.String
+	var hostnameSpreadWorkloads map[string]sets.String
+	var otherSpreadPods sets.String
+	if !utilfeature.DefaultFeatureGate.Enabled(features.IgnoreAffinities) {
+		if utilfeature.DefaultFeatureGate.Enabled(features.NewAffinityProcessing) {
+			glog.V(2).Infof("Begin to process affinity with new algorithm.")
+			start := time.Now()
+			// Stop the informer of the namespace lister once the affinities are processed
+			stopCh := make(chan struct{})
+			namespaceLister, err := podaffinity.NewNamespaceLister(dc.k8sClusterScraper.Clientset, clusterSummary,
+				stopCh, dc.discoveryStatus.RecordWatchError)
+			if err != nil {
+				glog.Errorf("Error creating affinity processor: %v", err)
+			} else {
+				affinityProcessor, err := podaffinity.New(clusterSummary,
+					podaffinity.NewNodeInfoLister(clusterSummary), namespaceLister)
+				if err != nil {
+					glog.Errorf("Failure in processing affinity rules: %s", err)
+				} else {
+					nodesPods, podsWithAffinities, hostnameSpreadWorkloads, otherSpreadPods = affinityProcessor.ProcessAffinities(clusterSummary.Pods)
+				}
+				glog.V(2).Infof("Successfully processed affinities.")
+				glog.V(3).Infof("Processing affinities with new algorithm took %s", time.Since(start))
+				if glog.V(3) {
+					nodeCommsTotal := 0
+					for node, pods := range nodesPods {
+						nodeCommsTotal += len(pods)
+						glog.Infof("Node %s will sell %v affinity related commodities.", node, len(pods))
+					}
+					glog.Infof("Total %v affinity related commodities will be sold by all nodes.", nodeCommsTotal)
+				}
+				glog.V(6).Infof("\n\nProcessed affinity result: \n\n %++v \n\n %++v \n\n",
+					nodesPods, podsWithAffinities)
+			}
+			close(stopCh)
+		}
+	} else {
+		glog.V(2).Infof("Ignoring affinities.")
+	}
+
+	return nodesPods, podsWithAffinities, hostnameSpreadWorkloads, otherSpreadPods
+}
+
+// discoverShards discovers the given nodes with the given dispatch function, or spreads them over the discovery shard
+// workers if the discovery is sharded and merges the results of the workers with the results of the nodes of the
+// coordinator. The nodes of the workers which fail are dispatched once the others complete. It returns the merged
+// results along with the dispatched nodes.
+func (dc *K8sDiscoveryClient) discoverShards(nodes []*api.Node,
+	dispatchNodes func([]*api.Node) *worker.DiscoveryResult) (*worker.DiscoveryResult, []*api.Node) {
+	if dc.Config.ShardDiscoverer == nil {
+		return dispatchNodes(nodes), nodes
+	}
+	shards, dispatchedNodes := dc.Config.ShardDiscoverer.Assign(nodes)
+	type shardResult struct {
+		shardWorker string
+		result      *worker.DiscoveryResult
+		err         error
+	}
+	shardResults := make(chan shardResult, len(shards))
+	for shardWorker, shardNodes := range shards {
+		go func(shardWorker string, shardNodes []*api.Node) {
+			nodeNames := make([]string, 0, len(shardNodes))
+			for _, node := range shardNodes {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			result, err := dc.Config.ShardDiscoverer.DiscoverShard(shardWorker, nodeNames)
+			shardResults <- shardResult{shardWorker: shardWorker, result: result, err: err}
+		}(shardWorker, shardNodes)
+	}
+	result := dispatchNodes(dispatchedNodes)
+	var failedNodes []*api.Node
+	for range shards {
+		shard := <-shardResults
+		if shard.err != nil {
+			glog.Errorf("Failed to discover the %d nodes of the shard of worker %s, discovering them directly: %v",
+				len(shards[shard.shardWorker]), shard.shardWorker, shard.err)
+			failedNodes = append(failedNodes, shards[shard.shardWorker]...)
+			continue
+		}
+		glog.V(2).Infof("Merging the discovery of the %d nodes of the shard of worker %s with %d entities.",
+			len(shards[shard.shardWorker]), shard.shardWorker, len(shard.result.EntityDTOs))
+		result.Merge(shard.result)
+	}
+	if len(failedNodes) > 0 {
+		result.Merge(dispatchNodes(failedNodes))
+		dispatchedNodes = append(dispatchedNodes, failedNodes...)
+	}
+	return result, dispatchedNodes
+}
+
+// finishNodeDiscovery drops the state of the containers which are gone once the nodes are discovered, and samples the
+// usage of the given dispatched nodes until the next full discovery.
+func (dc *K8sDiscoveryClient) finishNodeDiscovery(dispatchedNodes []*api.Node) {
+	// Clear globalEntityMetricSink cache after collecting full discovery results
+	dc.globalEntityMetricSink.ClearCache()
+	// Drop the usage history of the containers which are gone
+	if dc.utilizationHistory != nil {
+		dc.utilizationHistory.Cleanup()
+	}
+	// Drop the restarts of the containers which are gone
+	dc.restartTracker.Cleanup()
+	// Reschedule dispatch sampling discovery tasks for newly discovered nodes
+	dc.samplingDispatcher.ScheduleDispatch(dispatchedNodes)
+}
+
+// DiscoverShard discovers the nodes of the given names for the coordinator of a sharded discovery, which merges the
+// result into the discovery of the cluster. The usage of the nodes is sampled until the next discovery of the shard,
+// and the state kept across the discoveries, e.g. the utilization history, is kept for the nodes of the shard, as the
+// coordinator keeps assigning the same nodes to the worker.
+func (dc *K8sDiscoveryClient) DiscoverShard(nodeNames []string) (*worker.DiscoveryResult, error) {
+	dc.discoveryLock.Lock()
+	defer dc.discoveryLock.Unlock()
+
+	start := time.Now()
+	clusterSummary, err := dc.clusterProcessor.DiscoverCluster()
+	if err != nil {
+		return nil, fmt.Errorf("failed to process cluster: %v", err)
+	}
+	nodesPods, podsWithAffinities, hostnameSpreadWorkloads, otherSpreadPods := dc.processAffinities(clusterSummary)
+
+	shardNodeNames := sets.NewString(nodeNames...)
+	var nodes []*api.Node
+	for _, node := range clusterSummary.Nodes {
+		if shardNodeNames.Has(node.Name) {
+			nodes = append(nodes, node)
+		}
+	}
+	dc.Config.probeConfig.NodeClient.CleanupCache(nodes)
+	dc.samplingDispatcher.FinishSampling()
+	taskCount := dc.dispatcher.Dispatch(nodes, nodesPods, podsWithAffinities, otherSpreadPods,
+		hostnameSpreadWorkloads, clusterSummary)
+	result := dc.resultCollector.Collect(taskCount)
+	dc.finishNodeDiscovery(nodes)
+	glog.V(2).Infof("Discovered %d of the %d nodes of the shard with %d entities in %s.", len(nodes),
+		len(nodeNames), len(result.EntityDTOs), time.Since(start))
+	return result, nil
+}
+
 func (dc *K8sDiscoveryClient) getTargetActionPolicies() []*proto.ActionPolicyDTO {
 	if dc.k8sClusterScraper.IsClusterAPIEnabled() {
 		// Set target level action policy for virtual machine entity type if cluster API is enabled
@@ -510,3 +1070,30 @@ func (dc *K8sDiscoveryClient) getTargetActionPolicies() []*proto.ActionPolicyDTO
 	glog.V(2).Info("Cluster API is not available. Do not set node action policy for this cluster.")
 	return nil
 }
+
+// excludedInfrastructureIDs returns the IDs of the namespaces, the workload controllers and the given pods of the
+// infrastructure namespaces left out of the discovery, if any.
+func excludedInfrastructureIDs(infrastructurePods *configs.InfrastructurePods, clusterSummary *repository.ClusterSummary,
+	pods []*api.Pod) map[string]bool {
+	excludedIDs := make(map[string]bool)
+	for namespace, namespaceUID := range clusterSummary.NamespaceUIDMap {
+		if infrastructurePods.IsExcluded(namespace) {
+			excludedIDs[namespaceUID] = true
+		}
+	}
+	for uid, controller := range clusterSummary.ControllerMap {
+		if infrastructurePods.IsExcluded(controller.Namespace) {
+			excludedIDs[uid] = true
+		}
+	}
+	for _, pod := range pods {
+		if infrastructurePods.IsExcluded(pod.Namespace) {
+			excludedIDs[string(pod.UID)] = true
+		}
+	}
+	if len(excludedIDs) > 0 {
+		glog.V(2).Infof("Leaving the entities of the infrastructure namespaces %v out of the discovery.",
+			infrastructurePods.Namespaces)
+	}
+	return excludedIDs
+}
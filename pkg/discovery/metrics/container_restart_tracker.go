@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// The reason of the termination of a container killed for exceeding its memory limit
+const oomKilledReason = "OOMKilled"
+
+// ContainerRestartTracker keeps the restart count and the last OOMKilled termination of the containers across
+// discovery cycles, so that the restarts and the OOMKilled terminations since the previous discovery can be reported.
+// It is safe for concurrent use by multiple discovery workers.
+type ContainerRestartTracker struct {
+	lock sync.Mutex
+	// key: container metric id
+	containers map[string]*containerRestarts
+}
+
+type containerRestarts struct {
+	restartCount int32
+	lastOOMKill  time.Time
+	// Whether the container was observed since the last cleanup
+	observed bool
+}
+
+func NewContainerRestartTracker() *ContainerRestartTracker {
+	return &ContainerRestartTracker{
+		containers: make(map[string]*containerRestarts),
+	}
+}
+
+// Observe records the status of the given container, and returns the number of its restarts and whether it was
+// OOMKilled since it was last observed. A container observed for the first time reports all its restarts and its
+// last OOMKilled termination, if any.
+func (t *ContainerRestartTracker) Observe(key string, status *v1.ContainerStatus) (int32, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	previous, found := t.containers[key]
+	if !found {
+		previous = &containerRestarts{}
+	}
+	restarts := status.RestartCount - previous.restartCount
+	if restarts < 0 {
+		// The pod was recreated with the same name, e.g. by a stateful set
+		restarts = status.RestartCount
+	}
+	lastOOMKill := lastOOMKillTime(status)
+	oomKilled := lastOOMKill.After(previous.lastOOMKill)
+	if !oomKilled {
+		lastOOMKill = previous.lastOOMKill
+	}
+	t.containers[key] = &containerRestarts{
+		restartCount: status.RestartCount,
+		lastOOMKill:  lastOOMKill,
+		observed:     true,
+	}
+	return restarts, oomKilled
+}
+
+// Cleanup removes the containers which were not observed since the last cleanup, e.g. deleted containers.
+func (t *ContainerRestartTracker) Cleanup() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for key, container := range t.containers {
+		if !container.observed {
+			delete(t.containers, key)
+			continue
+		}
+		container.observed = false
+	}
+}
+
+// Size returns the number of the tracked containers.
+func (t *ContainerRestartTracker) Size() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.containers)
+}
+
+// lastOOMKillTime returns the time of the latest OOMKilled termination of the container, either the current or the
+// last termination, or the zero time if none.
+func lastOOMKillTime(status *v1.ContainerStatus) time.Time {
+	var lastOOMKill time.Time
+	for _, terminated := range []*v1.ContainerStateTerminated{status.State.Terminated,
+		status.LastTerminationState.Terminated} {
+		if terminated != nil && terminated.Reason == oomKilledReason && terminated.FinishedAt.Time.After(lastOOMKill) {
+			lastOOMKill = terminated.FinishedAt.Time
+		}
+	}
+	return lastOOMKill
+}
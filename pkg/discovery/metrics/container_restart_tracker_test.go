@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func oomKilledStatus(restartCount int32, finishedAt time.Time) *v1.ContainerStatus {
+	return &v1.ContainerStatus{
+		Name:         "app",
+		RestartCount: restartCount,
+		LastTerminationState: v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				Reason:     "OOMKilled",
+				FinishedAt: metav1.NewTime(finishedAt),
+			},
+		},
+	}
+}
+
+func TestContainerRestartTrackerObserve(t *testing.T) {
+	tracker := NewContainerRestartTracker()
+	oomKill := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// All the restarts and the last OOMKilled termination are reported on the first observation
+	restarts, oomKilled := tracker.Observe("ns/pod/app", oomKilledStatus(3, oomKill))
+	assert.Equal(t, int32(3), restarts)
+	assert.True(t, oomKilled)
+
+	// No new restart
+	restarts, oomKilled = tracker.Observe("ns/pod/app", oomKilledStatus(3, oomKill))
+	assert.Equal(t, int32(0), restarts)
+	assert.False(t, oomKilled)
+
+	// A new restart which is not an OOMKilled termination
+	status := oomKilledStatus(4, oomKill)
+	status.LastTerminationState.Terminated.Reason = "Error"
+	restarts, oomKilled = tracker.Observe("ns/pod/app", status)
+	assert.Equal(t, int32(1), restarts)
+	assert.False(t, oomKilled)
+
+	// A new OOMKilled termination
+	restarts, oomKilled = tracker.Observe("ns/pod/app", oomKilledStatus(6, oomKill.Add(time.Minute)))
+	assert.Equal(t, int32(2), restarts)
+	assert.True(t, oomKilled)
+
+	// The pod was recreated with the same name
+	restarts, oomKilled = tracker.Observe("ns/pod/app", &v1.ContainerStatus{Name: "app", RestartCount: 1})
+	assert.Equal(t, int32(1), restarts)
+	assert.False(t, oomKilled)
+}
+
+func TestContainerRestartTrackerOOMKilledContainer(t *testing.T) {
+	tracker := NewContainerRestartTracker()
+	// A container which is not restarted after its OOMKilled termination
+	status := &v1.ContainerStatus{
+		Name: "app",
+		State: v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				Reason:     "OOMKilled",
+				FinishedAt: metav1.NewTime(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+	restarts, oomKilled := tracker.Observe("ns/pod/app", status)
+	assert.Equal(t, int32(0), restarts)
+	assert.True(t, oomKilled)
+}
+
+func TestContainerRestartTrackerCleanup(t *testing.T) {
+	tracker := NewContainerRestartTracker()
+	tracker.Observe("ns/pod-1/app", &v1.ContainerStatus{Name: "app"})
+	tracker.Observe("ns/pod-2/app", &v1.ContainerStatus{Name: "app"})
+	tracker.Cleanup()
+	assert.Equal(t, 2, tracker.Size())
+
+	// pod-2 is gone
+	tracker.Observe("ns/pod-1/app", &v1.ContainerStatus{Name: "app"})
+	tracker.Cleanup()
+	assert.Equal(t, 1, tracker.Size())
+}
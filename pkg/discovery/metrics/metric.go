@@ -37,15 +37,19 @@ const (
 	VStorage           ResourceType = "VStorage"
 	StorageAmount      ResourceType = "StorageAmount"
 	VCPUThrottling     ResourceType = "VCPUThrottling"
+	NetThroughput      ResourceType = "NetThroughput"
 
 	Access              ResourceType = "Access"
 	Cluster             ResourceType = "Cluster"
 	CpuFrequency        ResourceType = "CpuFrequency"
+	SwapUsed            ResourceType = "SwapUsed"
 	Owner               ResourceType = "Owner"
 	OwnerType           ResourceType = "OwnerType"
 	OwnerUID            ResourceType = "OwnerUID"
 	IsInjectedSidecar   ResourceType = "IsInjectedSidecar"
 	MetricsAvailability ResourceType = "MetricsAvailability"
+	Restarts            ResourceType = "Restarts"
+	OOMKilled           ResourceType = "OOMKilled"
 )
 
 var (
@@ -174,6 +178,8 @@ const (
 	Used      MetricProp = "Used"
 	Available MetricProp = "Available"
 	Threshold MetricProp = "Threshold"
+	// The percentile of the used values over the utilization history window
+	UsedPercentile MetricProp = "UsedPercentile"
 )
 
 type Metric interface {
@@ -70,7 +70,7 @@ func (s *EntityMetricSink) MergeSink(anotherSink *EntityMetricSink, filterFunc M
 			continue
 		}
 		if filterFunc != nil && !filterFunc(metric) {
-			glog.Errorf("Failed the filterFunc.")
+			glog.V(5).Infof("Metric %s is filtered out.", key)
 			continue
 		}
 		s.UpdateMetricEntry(metric)
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"container/list"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxUtilizationSamples is the default max number of samples retained per entity resource
+// in the utilization history, which bounds the memory used by the history.
+const DefaultMaxUtilizationSamples = 1440
+
+// DefaultMaxUtilizationEntities is the default max number of entity resources in the utilization history,
+// beyond which the entity resources updated least recently are evicted.
+const DefaultMaxUtilizationEntities = 100000
+
+// UtilizationHistory keeps a rolling window of the used metric points of entity resources across
+// multiple discovery cycles, so that a percentile of the usage over a window longer than a single
+// discovery interval can be reported, e.g. to account for periodic spikes in resize decisions.
+// It is safe for concurrent use by multiple discovery workers.
+type UtilizationHistory struct {
+	lock sync.Mutex
+	// The percentile in (0, 100] of the samples in the window
+	percentile float64
+	// The duration of the rolling window
+	window time.Duration
+	// The max number of samples retained per entity resource
+	maxSamples int
+	// The max number of entity resources retained in the history
+	maxEntities int
+	// key: metric UID; value: element of the history entry in the lru list
+	samples map[string]*list.Element
+	// The history entries in ascending order of their last update, so the front is evicted first
+	lru *list.List
+	now func() time.Time
+}
+
+// historyEntry is the samples of an entity resource in ascending order of timestamp.
+type historyEntry struct {
+	key     string
+	samples []Point
+}
+
+func NewUtilizationHistory(percentile float64, window time.Duration, maxSamples, maxEntities int) *UtilizationHistory {
+	if maxSamples <= 0 {
+		maxSamples = DefaultMaxUtilizationSamples
+	}
+	if maxEntities <= 0 {
+		maxEntities = DefaultMaxUtilizationEntities
+	}
+	return &UtilizationHistory{
+		percentile:  percentile,
+		window:      window,
+		maxSamples:  maxSamples,
+		maxEntities: maxEntities,
+		samples:     make(map[string]*list.Element),
+		lru:         list.New(),
+		now:         time.Now,
+	}
+}
+
+// AddPoints adds the points collected in the current discovery cycle to the history of the given key,
+// and returns the percentile of the samples within the window. Points which are not newer than the
+// latest sample in the history are ignored. If the history is full, the entity resource updated least recently
+// is evicted to make room for a new one.
+func (h *UtilizationHistory) AddPoints(key string, points []Point) (float64, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var samples []Point
+	elem, found := h.samples[key]
+	if found {
+		samples = elem.Value.(*historyEntry).samples
+	}
+	for _, point := range points {
+		if len(samples) > 0 && point.Timestamp <= samples[len(samples)-1].Timestamp {
+			continue
+		}
+		samples = append(samples, point)
+	}
+	samples = h.trim(samples)
+	if len(samples) == 0 {
+		if found {
+			h.remove(elem)
+		}
+		return 0, false
+	}
+	if found {
+		elem.Value.(*historyEntry).samples = samples
+		h.lru.MoveToBack(elem)
+	} else {
+		for h.lru.Len() >= h.maxEntities {
+			h.remove(h.lru.Front())
+		}
+		h.samples[key] = h.lru.PushBack(&historyEntry{key: key, samples: samples})
+	}
+	return percentileOf(samples, h.percentile), true
+}
+
+// Cleanup removes the history of the keys without any sample within the window, e.g. deleted containers.
+func (h *UtilizationHistory) Cleanup() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, elem := range h.samples {
+		entry := elem.Value.(*historyEntry)
+		if samples := h.trim(entry.samples); len(samples) == 0 {
+			h.remove(elem)
+		} else {
+			entry.samples = samples
+		}
+	}
+}
+
+// Size returns the number of keys with samples in the history.
+func (h *UtilizationHistory) Size() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return len(h.samples)
+}
+
+// remove removes the history entry of the given element.
+func (h *UtilizationHistory) remove(elem *list.Element) {
+	delete(h.samples, elem.Value.(*historyEntry).key)
+	h.lru.Remove(elem)
+}
+
+// trim drops the samples which are out of the window, and the oldest samples beyond the max number of samples.
+func (h *UtilizationHistory) trim(samples []Point) []Point {
+	start := 0
+	if h.window > 0 {
+		windowStart := h.now().Add(-h.window).UnixNano() / int64(time.Millisecond)
+		for start < len(samples) && samples[start].Timestamp < windowStart {
+			start++
+		}
+	}
+	if len(samples)-start > h.maxSamples {
+		start = len(samples) - h.maxSamples
+	}
+	if start == 0 {
+		return samples
+	}
+	// Copy the retained samples so that the dropped ones can be garbage collected
+	return append([]Point(nil), samples[start:]...)
+}
+
+// percentileOf returns the given percentile of the values of the points using the nearest-rank method.
+func percentileOf(points []Point, percentile float64) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	values := make([]float64, len(points))
+	for i, point := range points {
+		values[i] = point.Value
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(percentile / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	} else if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1]
+}
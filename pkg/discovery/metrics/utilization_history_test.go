@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentileOf(t *testing.T) {
+	var points []Point
+	// 1, 2, ..., 100 in reverse order
+	for i := 100; i > 0; i-- {
+		points = append(points, Point{Value: float64(i), Timestamp: int64(100 - i)})
+	}
+	assert.Equal(t, 95.0, percentileOf(points, 95))
+	assert.Equal(t, 50.0, percentileOf(points, 50))
+	assert.Equal(t, 100.0, percentileOf(points, 100))
+	assert.Equal(t, 1.0, percentileOf(points, 0.1))
+	assert.Equal(t, 0.0, percentileOf(nil, 95))
+	assert.Equal(t, 7.0, percentileOf([]Point{{Value: 7}}, 95))
+}
+
+func TestUtilizationHistoryPercentileOverWindow(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := NewUtilizationHistory(95, time.Hour, 0, 0)
+	history.now = func() time.Time { return now }
+
+	// A synthetic window of 6 discovery cycles of 10 samples each with a spike in one of the cycles,
+	// which is not visible in the average of any other single cycle
+	start := now.Add(-time.Hour).Add(time.Minute)
+	var percentile float64
+	var ok bool
+	for cycle := 0; cycle < 6; cycle++ {
+		var points []Point
+		for i := 0; i < 10; i++ {
+			value := 10.0
+			if cycle == 2 && i >= 5 {
+				value = 100.0
+			}
+			ts := start.Add(time.Duration(cycle*10+i) * time.Minute)
+			points = append(points, Point{Value: value, Timestamp: ts.UnixNano() / int64(time.Millisecond)})
+		}
+		percentile, ok = history.AddPoints("container", points)
+		assert.True(t, ok)
+	}
+	// 5 of the 60 samples are spikes, so the p95 is the spike
+	assert.Equal(t, 100.0, percentile)
+
+	// Points already in the history are ignored
+	percentile, ok = history.AddPoints("container", []Point{{Value: 1000, Timestamp: start.UnixNano() / int64(time.Millisecond)}})
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, percentile)
+
+	// The spike moves out of the window
+	now = now.Add(40 * time.Minute)
+	percentile, ok = history.AddPoints("container", nil)
+	assert.True(t, ok)
+	assert.Equal(t, 10.0, percentile)
+
+	// All the samples move out of the window
+	now = now.Add(2 * time.Hour)
+	_, ok = history.AddPoints("container", nil)
+	assert.False(t, ok)
+	assert.Equal(t, 0, history.Size())
+}
+
+func TestUtilizationHistoryMaxSamples(t *testing.T) {
+	history := NewUtilizationHistory(100, 0, 3, 0)
+	percentile, ok := history.AddPoints("container", []Point{
+		{Value: 50, Timestamp: 1}, {Value: 1, Timestamp: 2}, {Value: 2, Timestamp: 3}, {Value: 3, Timestamp: 4},
+	})
+	assert.True(t, ok)
+	// The oldest sample is dropped
+	assert.Equal(t, 3.0, percentile)
+	assert.Len(t, history.samples["container"].Value.(*historyEntry).samples, 3)
+}
+
+func TestUtilizationHistoryMaxEntities(t *testing.T) {
+	history := NewUtilizationHistory(100, 0, 0, 2)
+	history.AddPoints("container-1", []Point{{Value: 1, Timestamp: 1}})
+	history.AddPoints("container-2", []Point{{Value: 2, Timestamp: 1}})
+	// container-1 is updated after container-2, so container-2 is evicted first
+	history.AddPoints("container-1", []Point{{Value: 1, Timestamp: 2}})
+	history.AddPoints("container-3", []Point{{Value: 3, Timestamp: 1}})
+	assert.Equal(t, 2, history.Size())
+	_, found := history.samples["container-2"]
+	assert.False(t, found)
+
+	history.AddPoints("container-4", []Point{{Value: 4, Timestamp: 1}})
+	assert.Equal(t, 2, history.Size())
+	_, found = history.samples["container-1"]
+	assert.False(t, found)
+	for _, key := range []string{"container-3", "container-4"} {
+		_, found = history.samples[key]
+		assert.True(t, found)
+	}
+}
+
+func TestUtilizationHistoryCleanup(t *testing.T) {
+	now := time.Now()
+	history := NewUtilizationHistory(95, time.Hour, 0, 0)
+	history.now = func() time.Time { return now }
+	nowMilliSec := now.UnixNano() / int64(time.Millisecond)
+	history.AddPoints("old", []Point{{Value: 1, Timestamp: nowMilliSec}})
+	now = now.Add(30 * time.Minute)
+	history.AddPoints("new", []Point{{Value: 1, Timestamp: now.UnixNano() / int64(time.Millisecond)}})
+	assert.Equal(t, 2, history.Size())
+
+	now = now.Add(45 * time.Minute)
+	history.Cleanup()
+	assert.Equal(t, 1, history.Size())
+	_, found := history.samples["new"]
+	assert.True(t, found)
+}
@@ -0,0 +1,104 @@
+package kubelet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// CgroupVersion is the version of the cgroup hierarchy used on a node.
+type CgroupVersion string
+
+const (
+	// CgroupVersionAuto detects the cgroup version of each node from its node info.
+	CgroupVersionAuto CgroupVersion = "auto"
+	CgroupV1          CgroupVersion = "v1"
+	CgroupV2          CgroupVersion = "v2"
+)
+
+// ParseCgroupVersion validates the cgroup version given on the command line.
+// An empty string is treated as auto detection.
+func ParseCgroupVersion(version string) (CgroupVersion, error) {
+	switch v := CgroupVersion(strings.ToLower(version)); v {
+	case "", CgroupVersionAuto:
+		return CgroupVersionAuto, nil
+	case CgroupV1, CgroupV2:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unsupported cgroup version %q, must be one of %s|%s|%s",
+			version, CgroupVersionAuto, CgroupV1, CgroupV2)
+	}
+}
+
+// cgroupV2OSImages lists the OS images which mount the unified cgroup v2 hierarchy by default,
+// together with the first major version that does so.
+var cgroupV2OSImages = []struct {
+	pattern      *regexp.Regexp
+	minMajorVers int
+}{
+	// e.g. "Red Hat Enterprise Linux 9.2 (Plow)" and "Red Hat Enterprise Linux CoreOS 414.92.202307070025-0 (Plow)"
+	{regexp.MustCompile(`^Red Hat Enterprise Linux (?:CoreOS \d+\.)?(\d)`), 9},
+	// e.g. "Ubuntu 22.04.3 LTS"
+	{regexp.MustCompile(`^Ubuntu (\d+)\.`), 22},
+	// e.g. "Debian GNU/Linux 11 (bullseye)"
+	{regexp.MustCompile(`^Debian GNU/Linux (\d+)`), 11},
+	// e.g. "Fedora Linux 38 (Container Image)"
+	{regexp.MustCompile(`^Fedora (?:Linux )?(\d+)`), 31},
+	// e.g. "Amazon Linux 2023"
+	{regexp.MustCompile(`^Amazon Linux (\d{4})`), 2023},
+}
+
+// detectCgroupVersion returns the configured cgroup version if it is set explicitly, or detects
+// the version from the OS image of the node otherwise. Nodes running an OS image which is not
+// known to default to cgroup v2 are assumed to run cgroup v1; the --cgroup-version flag can be
+// used to override the detection for such nodes. The Windows nodes have no cgroups, and report their working set as
+// on cgroup v1 whatever the configured version.
+func detectCgroupVersion(configured CgroupVersion, node *api.Node) CgroupVersion {
+	if util.IsWindowsNode(node) {
+		return CgroupV1
+	}
+	if configured == CgroupV1 || configured == CgroupV2 {
+		return configured
+	}
+	if node == nil {
+		return CgroupV1
+	}
+	osImage := node.Status.NodeInfo.OSImage
+	for _, image := range cgroupV2OSImages {
+		match := image.pattern.FindStringSubmatch(osImage)
+		if len(match) < 2 {
+			continue
+		}
+		if major, err := strconv.Atoi(match[1]); err == nil && major >= image.minMajorVers {
+			glog.V(4).Infof("Detected cgroup %s on node %s with OS image %s.", CgroupV2, node.Name, osImage)
+			return CgroupV2
+		}
+	}
+	return CgroupV1
+}
+
+// memoryUsedBytes returns the memory used in bytes from the memory stats reported by the kubelet. Unlike the memory,
+// the cpu usage is read from UsageNanoCores on both versions: cAdvisor derives it from cpuacct.usage on cgroup v1
+// and from the usage_usec of cpu.stat on cgroup v2, with the same semantics.
+//
+// On cgroup v1, the working set (usage minus total_inactive_file) is used.
+// On cgroup v2, the inactive file pages are reported as inactive_file, which is not subtracted by
+// older cAdvisor versions, so the reported working set is the same as the usage, including the
+// reclaimable page cache. In that case the RSS (anonymous memory) is used instead.
+func memoryUsedBytes(memory *stats.MemoryStats, version CgroupVersion) (float64, bool) {
+	if memory == nil || memory.WorkingSetBytes == nil {
+		return 0, false
+	}
+	if version == CgroupV2 && memory.UsageBytes != nil && memory.RSSBytes != nil &&
+		*memory.WorkingSetBytes == *memory.UsageBytes && *memory.RSSBytes < *memory.UsageBytes {
+		return float64(*memory.RSSBytes), true
+	}
+	return float64(*memory.WorkingSetBytes), true
+}
@@ -0,0 +1,98 @@
+package kubelet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func newCgroupTestNode(osImage string) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status:     api.NodeStatus{NodeInfo: api.NodeSystemInfo{OSImage: osImage}},
+	}
+}
+
+func TestParseCgroupVersion(t *testing.T) {
+	for in, expected := range map[string]CgroupVersion{"": CgroupVersionAuto, "auto": CgroupVersionAuto,
+		"v1": CgroupV1, "V2": CgroupV2} {
+		version, err := ParseCgroupVersion(in)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, version)
+	}
+	_, err := ParseCgroupVersion("v3")
+	assert.NotNil(t, err)
+}
+
+func TestDetectCgroupVersion(t *testing.T) {
+	tests := map[string]CgroupVersion{
+		"Red Hat Enterprise Linux CoreOS 414.92.202307070025-0 (Plow)":  CgroupV2,
+		"Red Hat Enterprise Linux CoreOS 411.86.202212072103-0 (Ootpa)": CgroupV1,
+		"Red Hat Enterprise Linux 9.2 (Plow)":                           CgroupV2,
+		"Red Hat Enterprise Linux 8.8 (Ootpa)":                          CgroupV1,
+		"Ubuntu 22.04.3 LTS":                                            CgroupV2,
+		"Ubuntu 20.04.6 LTS":                                            CgroupV1,
+		"Debian GNU/Linux 12 (bookworm)":                                CgroupV2,
+		"Amazon Linux 2023":                                             CgroupV2,
+		"Amazon Linux 2":                                                CgroupV1,
+		"Container-Optimized OS from Google":                            CgroupV1,
+	}
+	for osImage, expected := range tests {
+		assert.Equal(t, expected, detectCgroupVersion(CgroupVersionAuto, newCgroupTestNode(osImage)), osImage)
+	}
+	// The configured version overrides the detection
+	assert.Equal(t, CgroupV2, detectCgroupVersion(CgroupV2, newCgroupTestNode("Ubuntu 20.04.6 LTS")))
+	assert.Equal(t, CgroupV1, detectCgroupVersion(CgroupV1, newCgroupTestNode("Ubuntu 22.04.3 LTS")))
+	assert.Equal(t, CgroupV1, detectCgroupVersion(CgroupVersionAuto, nil))
+	// The Windows nodes have no cgroups
+	windowsNode := newCgroupTestNode("Windows Server 2022 Datacenter")
+	windowsNode.Labels = map[string]string{"kubernetes.io/os": "windows"}
+	assert.Equal(t, CgroupV1, detectCgroupVersion(CgroupV2, windowsNode))
+}
+
+func TestMemoryUsedBytes(t *testing.T) {
+	usage, workingSet, rss := uint64(1000), uint64(800), uint64(600)
+	memory := &stats.MemoryStats{UsageBytes: &usage, WorkingSetBytes: &workingSet, RSSBytes: &rss}
+	// The working set is read on both versions when the inactive file pages are subtracted
+	for _, version := range []CgroupVersion{CgroupV1, CgroupV2} {
+		used, found := memoryUsedBytes(memory, version)
+		assert.True(t, found)
+		assert.Equal(t, float64(workingSet), used)
+	}
+
+	// The working set includes the page cache on cgroup v2
+	memory.WorkingSetBytes = &usage
+	used, _ := memoryUsedBytes(memory, CgroupV1)
+	assert.Equal(t, float64(usage), used)
+	used, _ = memoryUsedBytes(memory, CgroupV2)
+	assert.Equal(t, float64(rss), used)
+
+	memory.WorkingSetBytes = nil
+	_, found := memoryUsedBytes(memory, CgroupV2)
+	assert.False(t, found)
+	_, found = memoryUsedBytes(nil, CgroupV1)
+	assert.False(t, found)
+}
+
+func TestParseContainerStatsCgroupVersions(t *testing.T) {
+	usage, rss, cpu := uint64(4096), uint64(1024), uint64(1000000)
+	podStat := &stats.PodStats{
+		PodRef: stats.PodReference{Name: "pod1", Namespace: "ns1", UID: "pod1-uid"},
+		Containers: []stats.ContainerStats{{
+			Name:   "c1",
+			CPU:    &stats.CPUStats{UsageNanoCores: &cpu},
+			Memory: &stats.MemoryStats{UsageBytes: &usage, WorkingSetBytes: &usage, RSSBytes: &rss},
+		}},
+	}
+	for version, expectedKB := range map[CgroupVersion]float64{CgroupV1: 4, CgroupV2: 1} {
+		klet, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil, nil).WithCgroupVersion(version), true)
+		klet.nodeCgroupVersion = detectCgroupVersion(version, nil)
+		cpuUsed, memUsed, _ := klet.parseContainerStats(podStat, timestamp)
+		assert.Equal(t, expectedKB, memUsed, string(version))
+		// The cpu usage is read from the same field on both versions
+		assert.Equal(t, float64(1), cpuUsed, string(version))
+	}
+}
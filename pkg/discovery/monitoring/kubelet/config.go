@@ -11,6 +11,16 @@ type KubeletMonitorConfig struct {
 	// k8s client used to fall back on, in case
 	// some kubelet apis are not available.
 	kubeClient *kubernetes.Clientset
+	// The cgroup version of the nodes, detected per node if set to auto.
+	cgroupVersion CgroupVersion
+	// Whether to collect the swap usage of nodes and containers from cAdvisor
+	collectSwapMetrics bool
+	// The kubelet endpoint from which the cpu and memory usage is collected
+	metricsSource MetricsSource
+	// The last cpu usage reported by cAdvisor, shared by the monitors of all the discoveries
+	cpuUsageCache *cpuUsageCache
+	// The last network usage reported by the kubelet, shared by the monitors of all the discoveries
+	networkUsageCache *networkUsageCache
 }
 
 // Implement MonitoringWorkerConfig interface.
@@ -23,7 +33,26 @@ func (c KubeletMonitorConfig) GetMonitoringSource() types.MonitoringSource {
 
 func NewKubeletMonitorConfig(kubeletClient *kubeclient.KubeletClient, kubeClient *kubernetes.Clientset) *KubeletMonitorConfig {
 	return &KubeletMonitorConfig{
-		kubeletClient: kubeletClient,
-		kubeClient:    kubeClient,
+		kubeletClient:     kubeletClient,
+		kubeClient:        kubeClient,
+		cgroupVersion:     CgroupVersionAuto,
+		metricsSource:     MetricsSourceSummary,
+		cpuUsageCache:     newCPUUsageCache(),
+		networkUsageCache: newNetworkUsageCache(),
 	}
 }
+
+func (c *KubeletMonitorConfig) WithCollectSwapMetrics(collectSwapMetrics bool) *KubeletMonitorConfig {
+	c.collectSwapMetrics = collectSwapMetrics
+	return c
+}
+
+func (c *KubeletMonitorConfig) WithCgroupVersion(cgroupVersion CgroupVersion) *KubeletMonitorConfig {
+	c.cgroupVersion = cgroupVersion
+	return c
+}
+
+func (c *KubeletMonitorConfig) WithMetricsSource(metricsSource MetricsSource) *KubeletMonitorConfig {
+	c.metricsSource = metricsSource
+	return c
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/features"
+	"github.com/turbonomic/kubeturbo/pkg/probemetrics"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/kubernetes"
 
@@ -38,14 +39,33 @@ type KubeletMonitor struct {
 
 	// Whether this kubelet monitor runs during full discovery
 	isFullDiscovery bool
+
+	// The configured cgroup version, and the one in effect for the current node
+	cgroupVersion     CgroupVersion
+	nodeCgroupVersion CgroupVersion
+
+	// Whether to collect the swap usage from cAdvisor
+	collectSwapMetrics bool
+
+	// The kubelet endpoint from which the cpu and memory usage is collected
+	metricsSource MetricsSource
+	cpuUsageCache *cpuUsageCache
+
+	// The last network usage of the nodes and pods, to compute their network throughput
+	networkUsageCache *networkUsageCache
 }
 
 func NewKubeletMonitor(config *KubeletMonitorConfig, isFullDiscovery bool) (*KubeletMonitor, error) {
 	return &KubeletMonitor{
-		kubeletClient:   config.kubeletClient,
-		kubeClient:      config.kubeClient,
-		metricSink:      metrics.NewEntityMetricSink(),
-		isFullDiscovery: isFullDiscovery,
+		kubeletClient:      config.kubeletClient,
+		kubeClient:         config.kubeClient,
+		metricSink:         metrics.NewEntityMetricSink(),
+		isFullDiscovery:    isFullDiscovery,
+		cgroupVersion:      config.cgroupVersion,
+		collectSwapMetrics: config.collectSwapMetrics,
+		metricsSource:      config.metricsSource,
+		cpuUsageCache:      config.cpuUsageCache,
+		networkUsageCache:  config.networkUsageCache,
 	}, nil
 }
 
@@ -60,6 +80,7 @@ func (m *KubeletMonitor) GetMonitoringSource() types.MonitoringSource {
 func (m *KubeletMonitor) ReceiveTask(task *task.Task) {
 	m.reset()
 	m.node = task.Node()
+	m.nodeCgroupVersion = detectCgroupVersion(m.cgroupVersion, m.node)
 }
 
 func (m *KubeletMonitor) Do() (*metrics.EntityMetricSink, error) {
@@ -80,6 +101,7 @@ func (m *KubeletMonitor) Do() (*metrics.EntityMetricSink, error) {
 func (m *KubeletMonitor) RetrieveResourceStat() error {
 	err := m.scrapeKubelet(m.node)
 	if err != nil {
+		probemetrics.RecordKubeletScrapeError()
 		return err
 	}
 	return nil
@@ -104,9 +126,32 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) error {
 		glog.Errorf("Failed to get resource metrics summary from %s: %s", node.Name, err)
 		return err
 	}
+	// Scrape the cAdvisor metrics once for the usage, throttling and swap metrics which are read from them
+	collectSwapMetrics := m.collectSwapMetrics && m.isFullDiscovery
+	throttlingEnabled := utilfeature.DefaultFeatureGate.Enabled(features.ThrottlingMetrics)
+	metricsSource := m.metricsSource
+	// The kubelet of the Windows nodes serves no cAdvisor metrics, as there are no cgroups: the usage is read from the
+	// summary, and there are no throttling and swap metrics
+	windows := util.IsWindowsNode(node)
+	if windows {
+		if throttlingEnabled || collectSwapMetrics {
+			glog.V(3).Infof("Skip the throttling and swap metrics of Windows node %s.", node.Name)
+		}
+		metricsSource, throttlingEnabled, collectSwapMetrics = MetricsSourceSummary, false, false
+	}
+	var cadvisorMetrics map[string]*dto.MetricFamily
+	var cadvisorErr error
+	if metricsSource == MetricsSourceCadvisor || throttlingEnabled || collectSwapMetrics {
+		cadvisorMetrics, cadvisorErr = kc.GetCadvisorMetricFamilies(ip, node.Name)
+	}
 	// get summary information about the given node and the pods running on it.
-	summary, err := kc.GetSummary(ip, node.Name)
+	summary, err := m.getSummary(ip, node.Name, metricsSource, cadvisorMetrics, cadvisorErr)
 	if err != nil {
+		if kubeclient.IsProxyUnreachableError(err) {
+			// The node is skipped in this discovery, the other nodes may still be reachable through the proxy
+			glog.Warningf("Skip scraping node %s: %v", node.Name, err)
+			return err
+		}
 		glog.Errorf("Failed to get resource metrics summary from %s: %s", node.Name, err)
 		return err
 	}
@@ -131,19 +176,31 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) error {
 
 	// TODO Use time stamp attached to the discovered CPUStats/MemoryStats of node and pod from kubelet to be more precise
 	currentMilliSec := time.Now().UnixNano() / int64(time.Millisecond)
-	if utilfeature.DefaultFeatureGate.Enabled(features.ThrottlingMetrics) {
-		metricFamilies, err := kc.GetCPUThrottlingMetrics(ip, node.Name)
-		if err != nil {
-			glog.Warningf("Failed to read kubelet cadvisor metrics for %s, %v.", node.Name, err)
+	if throttlingEnabled {
+		if cadvisorErr != nil {
+			glog.Warningf("Failed to read kubelet cadvisor metrics for %s, %v.", node.Name, cadvisorErr)
 		}
+		metricFamilies := kubeclient.ThrottlingMetricFamilies(cadvisorMetrics)
 		if _, found := metricFamilies[kubeclient.ContainerCPUThrottledTotal]; !found {
 			glog.V(3).Infof("No throttling metrics found for node %s.", node.Name)
 		}
 		m.generateThrottlingMetrics(metricFamilies, currentMilliSec)
 	}
+	// Collect swap usage only in full discovery, it is reported as entity properties
+	if collectSwapMetrics {
+		if cadvisorErr != nil {
+			glog.Warningf("Failed to read kubelet cadvisor swap metrics for %s, %v.", node.Name, cadvisorErr)
+		} else {
+			m.generateSwapMetrics(cadvisorMetrics[kubeclient.ContainerMemorySwap], util.NodeKeyFunc(node))
+		}
+	}
 
+	if windows {
+		fillWindowsCPUUsage(summary, m.cpuUsageCache, time.Now())
+	}
 	m.parseNodeStats(summary.Node, thresholds, currentMilliSec)
 	m.parsePodStats(summary.Pods, currentMilliSec)
+	m.generateNetworkMetrics(summary, time.Now())
 
 	glog.V(4).Infof("Finished scrape node %s.", node.Name)
 	return nil
@@ -181,6 +238,20 @@ type throttlingMetric struct {
 	containerThreads float64
 }
 
+// getSummary gets the summary of the given node and the pods running on it from the given metrics source. The
+// cAdvisor metrics scraped for the node, or the error scraping them, are used with the cAdvisor metrics source.
+func (m *KubeletMonitor) getSummary(ip, nodeName string, metricsSource MetricsSource,
+	cadvisorMetrics map[string]*dto.MetricFamily, cadvisorErr error) (*stats.Summary, error) {
+	if metricsSource != MetricsSourceCadvisor {
+		return m.kubeletClient.GetSummary(ip, nodeName)
+	}
+	if cadvisorErr != nil {
+		return nil, cadvisorErr
+	}
+	return buildSummaryFromCadvisor(nodeName, kubeclient.CadvisorUsageMetricFamilies(cadvisorMetrics),
+		m.cpuUsageCache, time.Now()), nil
+}
+
 // parseMetricFamilies parses the incoming prometheus format metric from four metric families
 // "container_cpu_cfs_throttled_periods_total", "container_cpu_cfs_periods_total", "container_spec_cpu_quota"
 // and "container_spec_cpu_period".
@@ -295,8 +366,8 @@ func (m *KubeletMonitor) parseNodeStats(nodeStats stats.NodeStats, thresholds []
 	if nodeStats.CPU != nil && nodeStats.CPU.UsageNanoCores != nil {
 		cpuUsageMilliCore = util.MetricNanoToMilli(float64(*nodeStats.CPU.UsageNanoCores))
 	}
-	if nodeStats.Memory != nil && nodeStats.Memory.WorkingSetBytes != nil {
-		memoryWorkingSetBytes = float64(*nodeStats.Memory.WorkingSetBytes)
+	if memUsed, found := memoryUsedBytes(nodeStats.Memory, m.nodeCgroupVersion); found {
+		memoryWorkingSetBytes = memUsed
 	}
 	if nodeStats.Memory != nil && nodeStats.Memory.AvailableBytes != nil {
 		memoryAvailableBytes = float64(*nodeStats.Memory.AvailableBytes)
@@ -336,7 +407,23 @@ func (m *KubeletMonitor) parseNodeStats(nodeStats stats.NodeStats, thresholds []
 	glog.V(4).Infof("Memory working set of node %s is %.3f KB", nodeName, memoryWorkingSetKiloBytes)
 	glog.V(4).Infof("Memory capacity for node %s is %.3f Bytes", nodeName, memoryCapacityBytes)
 
-	m.genUsedMetrics(metrics.NodeType, key, cpuUsageMilliCore, memoryWorkingSetKiloBytes, timestamp)
+	// The node sells its allocatable resources, net of the resources reserved for the system, so its used resources
+	// are the ones of its pods, without the usage of the system daemons running in the reserved resources
+	cpuUsedMilliCore, memoryUsedKiloBytes := cpuUsageMilliCore, memoryWorkingSetKiloBytes
+	for _, container := range nodeStats.SystemContainers {
+		if container.Name != stats.SystemContainerPods {
+			continue
+		}
+		if container.CPU != nil && container.CPU.UsageNanoCores != nil {
+			cpuUsedMilliCore = util.MetricNanoToMilli(float64(*container.CPU.UsageNanoCores))
+		}
+		if memUsed, found := memoryUsedBytes(container.Memory, m.nodeCgroupVersion); found {
+			memoryUsedKiloBytes = util.Base2BytesToKilobytes(memUsed)
+		}
+		glog.V(4).Infof("CPU and memory usage of the pods of node %s is %.3f Core and %.3f KB", nodeName,
+			util.MetricMilliToUnit(cpuUsedMilliCore), memoryUsedKiloBytes)
+	}
+	m.genUsedMetrics(metrics.NodeType, key, cpuUsedMilliCore, memoryUsedKiloBytes, timestamp)
 
 	// Collect node fsMetrics only in full discovery not in sampling discovery
 	if m.isFullDiscovery {
@@ -406,7 +493,6 @@ func GetThresholdPercentile(value kubeclient.ThresholdValue, capacity float64) f
 func (m *KubeletMonitor) parsePodStats(podStats []stats.PodStats, timestamp int64) {
 	for i := range podStats {
 		pod := &(podStats[i])
-		cpuUsed, memUsed, isContMetricsMissing := m.parseContainerStats(pod, timestamp)
 		key := util.PodMetricId(&(pod.PodRef))
 
 		ephemeralFsCapacity, ephemeralFsUsed := float64(0), float64(0)
@@ -422,11 +508,13 @@ func (m *KubeletMonitor) parsePodStats(podStats []stats.PodStats, timestamp int6
 			glog.V(4).Infof("Ephemeral fs status is not available for pod %v", key)
 		}
 
-		glog.V(4).Infof("Cpu usage of pod %s is %.3f Millicore", key, cpuUsed)
-		glog.V(4).Infof("Memory usage of pod %s is %.3f Kb", key, memUsed)
 		glog.V(4).Infof("Ephemeral fs capacity for pod %s is %.3f Megabytes", key, ephemeralFsCapacity)
 		glog.V(4).Infof("Ephemeral fs used for pod %s is %.3f Megabytes", key, ephemeralFsUsed)
 
+		cpuUsed, memUsed, isContMetricsMissing := m.parseContainerStats(pod, timestamp)
+		glog.V(4).Infof("Cpu usage of pod %s is %.3f Millicore", key, cpuUsed)
+		glog.V(4).Infof("Memory usage of pod %s is %.3f Kb", key, memUsed)
+
 		m.genUsedMetrics(metrics.PodType, key, cpuUsed, memUsed, timestamp)
 		// We set isAvailable against the metrics "MetricsAvailability"
 		m.genMetricAvailablityMetrics(metrics.PodType, key, !isContMetricsMissing)
@@ -475,7 +563,8 @@ func (m *KubeletMonitor) parseContainerStats(pod *stats.PodStats, timestamp int6
 		cpuUsed := float64(0.0)
 		memUsed := float64(0.0)
 		cpuMetricsMissing := container.CPU == nil || container.CPU.UsageNanoCores == nil
-		memMetricsMissing := container.Memory == nil || container.Memory.WorkingSetBytes == nil
+		memUsedBytes, memFound := memoryUsedBytes(container.Memory, m.nodeCgroupVersion)
+		memMetricsMissing := !memFound
 		if cpuMetricsMissing && memMetricsMissing {
 			continue
 		}
@@ -485,7 +574,7 @@ func (m *KubeletMonitor) parseContainerStats(pod *stats.PodStats, timestamp int6
 			cpuUsed = util.MetricNanoToMilli(float64(*container.CPU.UsageNanoCores))
 		}
 		if !memMetricsMissing {
-			memUsed = util.Base2BytesToKilobytes(float64(*container.Memory.WorkingSetBytes))
+			memUsed = util.Base2BytesToKilobytes(memUsedBytes)
 		}
 
 		totalUsedCPU += cpuUsed
@@ -286,6 +286,33 @@ func TestParseStats(t *testing.T) {
 	}
 }
 
+func TestParseNodeStats(t *testing.T) {
+	klet, err := NewKubeletMonitor(&KubeletMonitorConfig{}, true)
+	assert.NoError(t, err)
+	node := createContainerStat("node", 2e9, 4096*1024)
+	nodeStats := stats.NodeStats{NodeName: "node-1", CPU: node.CPU, Memory: node.Memory}
+	used := func(resource metrics.ResourceType) float64 {
+		metric, err := klet.metricSink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.NodeType,
+			util.NodeStatsKeyFunc(nodeStats), resource, metrics.Used))
+		assert.NoError(t, err)
+		return metric.GetValue().([]metrics.Point)[0].Value
+	}
+
+	// The usage of the whole node without the usage of its pods
+	klet.parseNodeStats(nodeStats, nil, timestamp)
+	assert.EqualValues(t, 2000, used(metrics.CPU))
+	assert.EqualValues(t, 4096, used(metrics.Memory))
+
+	// The usage of the pods, without the system daemons running in the reserved resources
+	nodeStats.SystemContainers = []stats.ContainerStats{
+		createContainerStat(stats.SystemContainerKubelet, 2e8, 512*1024),
+		createContainerStat(stats.SystemContainerPods, 15e8, 3072*1024),
+	}
+	klet.parseNodeStats(nodeStats, nil, timestamp)
+	assert.EqualValues(t, 1500, used(metrics.CPU))
+	assert.EqualValues(t, 3072, used(metrics.Memory))
+}
+
 func TestGenThrottlingMetrics(t *testing.T) {
 	kubeletMonitorConf := &KubeletMonitorConfig{}
 	kubeletMonitor, _ := NewKubeletMonitor(kubeletMonitorConf, true)
@@ -0,0 +1,230 @@
+package kubelet
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+// MetricsSource is the kubelet endpoint from which the cpu and memory usage of nodes, pods and containers
+// is collected.
+type MetricsSource string
+
+const (
+	// MetricsSourceSummary collects the usage from the summary API of the kubelet, /stats/summary.
+	MetricsSourceSummary MetricsSource = "summary"
+	// MetricsSourceCadvisor collects the usage from the cAdvisor metrics exposed by the kubelet,
+	// /metrics/cadvisor. It is a fallback for the nodes on which the summary API is not usable.
+	MetricsSourceCadvisor MetricsSource = "cadvisor"
+)
+
+// ParseMetricsSource validates the kubelet metrics source given on the command line.
+// An empty string is treated as MetricsSourceSummary.
+func ParseMetricsSource(source string) (MetricsSource, error) {
+	switch s := MetricsSource(strings.ToLower(source)); s {
+	case "", MetricsSourceSummary:
+		return MetricsSourceSummary, nil
+	case MetricsSourceCadvisor:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unsupported kubelet metrics source %q, must be one of %s|%s",
+			source, MetricsSourceSummary, MetricsSourceCadvisor)
+	}
+}
+
+type cpuUsageSample struct {
+	usageSeconds float64
+	timestamp    time.Time
+}
+
+// cpuUsageCache keeps the last cumulative cpu usage of each cgroup of each node reported by cAdvisor,
+// to compute the cpu usage rate between two scrapes. It is shared by all the kubelet monitors.
+type cpuUsageCache struct {
+	lock sync.Mutex
+	// The samples of the cgroups of each node, by node name and cgroup id
+	samples map[string]map[string]cpuUsageSample
+}
+
+func newCPUUsageCache() *cpuUsageCache {
+	return &cpuUsageCache{
+		samples: make(map[string]map[string]cpuUsageSample),
+	}
+}
+
+// update replaces the samples of the given node, and returns the cpu usage in nano cores of each cgroup
+// which was also sampled in the previous scrape. The cgroups whose usage went backwards, e.g. a restarted
+// container, have no usage until the next scrape.
+func (c *cpuUsageCache) update(nodeName string, samples map[string]cpuUsageSample) map[string]uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	previous := c.samples[nodeName]
+	c.samples[nodeName] = samples
+	usage := make(map[string]uint64)
+	for id, sample := range samples {
+		prev, found := previous[id]
+		if !found {
+			continue
+		}
+		elapsed := sample.timestamp.Sub(prev.timestamp).Seconds()
+		delta := sample.usageSeconds - prev.usageSeconds
+		if elapsed <= 0 || delta < 0 {
+			continue
+		}
+		usage[id] = uint64(delta / elapsed * 1e9)
+	}
+	return usage
+}
+
+type cadvisorCgroupKey struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// buildSummaryFromCadvisor builds the cpu and memory stats of the node and of its containers in the
+// same form as the summary API, from the metric families reported by cAdvisor. The cpu usage is a
+// cumulative counter in cAdvisor, so it is only available from the second scrape of a node on.
+// Example:
+// in:
+// container_cpu_usage_seconds_total{container="",id="/",image="",name="",namespace="",pod=""} 1200.5 1629775344665
+// container_cpu_usage_seconds_total{container="app",id="/kubepods/pod8266a379/8e1a2ff0",image="app:v1",name="8e1a2ff0",namespace="ns",pod="app-1"} 10.5 1629775344665
+// container_memory_working_set_bytes{container="",id="/",image="",name="",namespace="",pod=""} 2.147483648e+09 1629775344665
+// container_memory_working_set_bytes{container="app",id="/kubepods/pod8266a379/8e1a2ff0",image="app:v1",name="8e1a2ff0",namespace="ns",pod="app-1"} 1.048576e+06 1629775344665
+// machine_memory_bytes 8.589934592e+09
+//
+// out:
+//
+//	a summary of the node with the usage of the root cgroup, and of the pod ns/app-1 with the container app
+func buildSummaryFromCadvisor(nodeName string, metricFamilies map[string]*dto.MetricFamily,
+	cache *cpuUsageCache, now time.Time) *stats.Summary {
+	summary := &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName: nodeName,
+		},
+	}
+	// The cgroup ids of the containers, and the node for the root cgroup
+	containerIds := make(map[cadvisorCgroupKey]string)
+	rootKey := cadvisorCgroupKey{}
+
+	cpuSamples := make(map[string]cpuUsageSample)
+	forEachCgroup(metricFamilies[kubeclient.ContainerCPUTotalUsageSec], dto.MetricType_COUNTER,
+		func(id string, key cadvisorCgroupKey, metric *dto.Metric) {
+			timestamp := now
+			if metric.GetTimestampMs() > 0 {
+				timestamp = time.Unix(0, metric.GetTimestampMs()*int64(time.Millisecond))
+			}
+			cpuSamples[id] = cpuUsageSample{usageSeconds: metric.GetCounter().GetValue(), timestamp: timestamp}
+			containerIds[key] = id
+		})
+	cpuUsage := cache.update(nodeName, cpuSamples)
+
+	workingSet := make(map[string]uint64)
+	forEachCgroup(metricFamilies[kubeclient.ContainerMemoryWorkingSet], dto.MetricType_GAUGE,
+		func(id string, key cadvisorCgroupKey, metric *dto.Metric) {
+			workingSet[id] = uint64(metric.GetGauge().GetValue())
+			containerIds[key] = id
+		})
+
+	metricTime := metav1.NewTime(now)
+	cpuStats := func(id string) *stats.CPUStats {
+		usage, found := cpuUsage[id]
+		if !found {
+			return nil
+		}
+		return &stats.CPUStats{Time: metricTime, UsageNanoCores: &usage}
+	}
+	memoryStats := func(id string) *stats.MemoryStats {
+		bytes, found := workingSet[id]
+		if !found {
+			return nil
+		}
+		return &stats.MemoryStats{Time: metricTime, WorkingSetBytes: &bytes}
+	}
+
+	if id, found := containerIds[rootKey]; found {
+		summary.Node.CPU = cpuStats(id)
+		summary.Node.Memory = memoryStats(id)
+		if summary.Node.Memory != nil && metricFamilies[kubeclient.MachineMemoryBytes] != nil {
+			for _, metric := range metricFamilies[kubeclient.MachineMemoryBytes].GetMetric() {
+				capacity := uint64(metric.GetGauge().GetValue())
+				if capacity >= *summary.Node.Memory.WorkingSetBytes {
+					available := capacity - *summary.Node.Memory.WorkingSetBytes
+					summary.Node.Memory.AvailableBytes = &available
+				}
+				break
+			}
+		}
+	} else {
+		glog.V(3).Infof("No cAdvisor metrics of the root cgroup found for node %s.", nodeName)
+	}
+
+	podIndex := make(map[stats.PodReference]int)
+	for key, id := range containerIds {
+		if key.container == "" || key.container == "POD" {
+			// The node, the pod cgroups and the pause containers
+			continue
+		}
+		podRef := stats.PodReference{Name: key.pod, Namespace: key.namespace}
+		i, found := podIndex[podRef]
+		if !found {
+			i = len(summary.Pods)
+			podIndex[podRef] = i
+			summary.Pods = append(summary.Pods, stats.PodStats{PodRef: podRef})
+		}
+		summary.Pods[i].Containers = append(summary.Pods[i].Containers, stats.ContainerStats{
+			Name:   key.container,
+			CPU:    cpuStats(id),
+			Memory: memoryStats(id),
+		})
+	}
+	return summary
+}
+
+// forEachCgroup calls the given function with the id, the namespace, pod and container names of each
+// cgroup reported in the given metric family, which must be of the expected type.
+func forEachCgroup(metricFamily *dto.MetricFamily, metricType dto.MetricType,
+	f func(id string, key cadvisorCgroupKey, metric *dto.Metric)) {
+	if metricFamily == nil {
+		return
+	}
+	if metricFamily.GetType() != metricType {
+		glog.Warningf("Expected metrics type: %v, but received type: %v while parsing %s.",
+			metricType, metricFamily.GetType(), metricFamily.GetName())
+		return
+	}
+	for _, metric := range metricFamily.GetMetric() {
+		if metric == nil {
+			continue
+		}
+		var id string
+		var key cadvisorCgroupKey
+		for _, l := range metric.GetLabel() {
+			switch l.GetName() {
+			case "id":
+				id = l.GetValue()
+			case "container", "container_name":
+				key.container = l.GetValue()
+			case "namespace":
+				key.namespace = l.GetValue()
+			case "pod", "pod_name":
+				key.pod = l.GetValue()
+			default:
+			}
+		}
+		if id == rootCgroupId {
+			key = cadvisorCgroupKey{}
+		} else if key.pod == "" {
+			// The system cgroups which do not belong to a pod
+			continue
+		}
+		f(id, key, metric)
+	}
+}
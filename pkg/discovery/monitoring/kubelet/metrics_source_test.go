@@ -0,0 +1,118 @@
+package kubelet
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+const cadvisorSample = `
+# HELP container_cpu_usage_seconds_total Cumulative cpu time consumed in seconds.
+# TYPE container_cpu_usage_seconds_total counter
+container_cpu_usage_seconds_total{container="",id="/",image="",name="",namespace="",pod=""} %s 1629775344665
+container_cpu_usage_seconds_total{container="",id="/system.slice/kubelet.service",image="",name="",namespace="",pod=""} 50 1629775344665
+container_cpu_usage_seconds_total{container="",id="/kubepods/burstable/pod278c96f7",image="",name="",namespace="ns",pod="app-1"} 12 1629775344665
+container_cpu_usage_seconds_total{container="POD",id="/kubepods/burstable/pod278c96f7/1b2c3d4e",image="pause",name="1b2c3d4e",namespace="ns",pod="app-1"} 0.1 1629775344665
+container_cpu_usage_seconds_total{container="app",id="/kubepods/burstable/pod278c96f7/6a79a7d4",image="app:v1",name="6a79a7d4",namespace="ns",pod="app-1"} %s 1629775344665
+# HELP container_memory_working_set_bytes Current working set in bytes.
+# TYPE container_memory_working_set_bytes gauge
+container_memory_working_set_bytes{container="",id="/",image="",name="",namespace="",pod=""} 2.147483648e+09 1629775344665
+container_memory_working_set_bytes{container="app",id="/kubepods/burstable/pod278c96f7/6a79a7d4",image="app:v1",name="6a79a7d4",namespace="ns",pod="app-1"} 1.048576e+06 1629775344665
+# HELP machine_memory_bytes Amount of memory installed on the machine.
+# TYPE machine_memory_bytes gauge
+machine_memory_bytes 8.589934592e+09
+`
+
+func TestParseMetricsSource(t *testing.T) {
+	for input, expected := range map[string]MetricsSource{
+		"":         MetricsSourceSummary,
+		"summary":  MetricsSourceSummary,
+		"cAdvisor": MetricsSourceCadvisor,
+	} {
+		source, err := ParseMetricsSource(input)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, source)
+	}
+	_, err := ParseMetricsSource("prometheus")
+	assert.NotNil(t, err)
+}
+
+func TestBuildSummaryFromCadvisor(t *testing.T) {
+	cache := newCPUUsageCache()
+	now := time.Now()
+
+	// The first scrape has no cpu usage yet
+	first, err := kubeclient.TextToCadvisorMetricFamilies([]byte(sprintfSample("1000", "10")))
+	assert.Nil(t, err)
+	summary := buildSummaryFromCadvisor("node-1", first, cache, now)
+	assert.Equal(t, "node-1", summary.Node.NodeName)
+	assert.Nil(t, summary.Node.CPU)
+	assert.Equal(t, uint64(2147483648), *summary.Node.Memory.WorkingSetBytes)
+	assert.Equal(t, uint64(6442450944), *summary.Node.Memory.AvailableBytes)
+	assert.Len(t, summary.Pods, 1)
+	assert.Equal(t, stats.PodReference{Name: "app-1", Namespace: "ns"}, summary.Pods[0].PodRef)
+	assert.Len(t, summary.Pods[0].Containers, 1)
+	container := summary.Pods[0].Containers[0]
+	assert.Equal(t, "app", container.Name)
+	assert.Nil(t, container.CPU)
+	assert.Equal(t, uint64(1048576), *container.Memory.WorkingSetBytes)
+
+	// The second scrape, 10 seconds later, has the cpu usage rate since the first one
+	second, err := kubeclient.TextToCadvisorMetricFamilies([]byte(sprintfSample("1020", "15")))
+	assert.Nil(t, err)
+	for _, metricFamily := range second {
+		for _, metric := range metricFamily.GetMetric() {
+			if metric.TimestampMs != nil {
+				*metric.TimestampMs += 10000
+			}
+		}
+	}
+	summary = buildSummaryFromCadvisor("node-1", second, cache, now.Add(10*time.Second))
+	assert.Equal(t, uint64(2e9), *summary.Node.CPU.UsageNanoCores)
+	assert.Equal(t, uint64(5e8), *summary.Pods[0].Containers[0].CPU.UsageNanoCores)
+
+	// A restarted container has no cpu usage until the next scrape
+	third, err := kubeclient.TextToCadvisorMetricFamilies([]byte(sprintfSample("1040", "1")))
+	assert.Nil(t, err)
+	summary = buildSummaryFromCadvisor("node-1", third, cache, now.Add(20*time.Second))
+	assert.Nil(t, summary.Pods[0].Containers[0].CPU)
+}
+
+func TestScrapeCadvisorSummary(t *testing.T) {
+	cache := newCPUUsageCache()
+	now := time.Now()
+	// The metrics without timestamp are sampled at the time of the scrape
+	first, _ := kubeclient.TextToCadvisorMetricFamilies([]byte(sprintfSample("1000", "10")))
+	second, _ := kubeclient.TextToCadvisorMetricFamilies([]byte(sprintfSample("1020", "15")))
+	for _, metricFamilies := range []map[string]*dto.MetricFamily{first, second} {
+		for _, metricFamily := range metricFamilies {
+			for _, metric := range metricFamily.GetMetric() {
+				metric.TimestampMs = nil
+			}
+		}
+	}
+	buildSummaryFromCadvisor("node-1", first, cache, now)
+	summary := buildSummaryFromCadvisor("node-1", second, cache, now.Add(10*time.Second))
+
+	klet, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil, nil).WithMetricsSource(MetricsSourceCadvisor), false)
+	klet.parsePodStats(summary.Pods, now.UnixNano()/int64(time.Millisecond))
+	cpuUsed, err := klet.metricSink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.ContainerType,
+		"ns/app-1/app", metrics.CPU, metrics.Used))
+	assert.Nil(t, err)
+	assert.Equal(t, float64(500), cpuUsed.GetValue().([]metrics.Point)[0].Value)
+	memUsed, err := klet.metricSink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.ContainerType,
+		"ns/app-1/app", metrics.Memory, metrics.Used))
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1024), memUsed.GetValue().([]metrics.Point)[0].Value)
+}
+
+func sprintfSample(nodeCPU, containerCPU string) string {
+	return fmt.Sprintf(cadvisorSample, nodeCPU, containerCPU)
+}
@@ -0,0 +1,113 @@
+package kubelet
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// The network throughput capacity of a node in Kbit/s. The bandwidth of the network interfaces is not reported by
+// the kubelet, so the nodes are assumed to have a 10 Gbit/s network.
+const nodeNetThroughputCapacityKbps = 10 * 1000 * 1000
+
+type networkUsageSample struct {
+	// The cumulative bytes received and transmitted
+	bytes     float64
+	timestamp time.Time
+}
+
+// networkUsageCache keeps the last cumulative network usage of the node and of each pod of each node reported by
+// the kubelet, so that the network throughput can be computed from two consecutive scrapes.
+type networkUsageCache struct {
+	lock sync.Mutex
+	// The samples of the node and of its pods, by node name and metric id
+	samples map[string]map[string]networkUsageSample
+}
+
+func newNetworkUsageCache() *networkUsageCache {
+	return &networkUsageCache{
+		samples: make(map[string]map[string]networkUsageSample),
+	}
+}
+
+// update replaces the samples of the given node, and returns the network throughput in Kbit/s of the node and of
+// each pod which was also sampled in the previous scrape. The counters which went backwards, e.g. a restarted pod,
+// have no throughput until the next scrape.
+func (c *networkUsageCache) update(nodeName string, samples map[string]networkUsageSample) map[string]float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	previous := c.samples[nodeName]
+	c.samples[nodeName] = samples
+	throughput := make(map[string]float64)
+	for id, sample := range samples {
+		prev, found := previous[id]
+		if !found {
+			continue
+		}
+		elapsed := sample.timestamp.Sub(prev.timestamp).Seconds()
+		delta := sample.bytes - prev.bytes
+		if elapsed <= 0 || delta < 0 {
+			continue
+		}
+		throughput[id] = delta * 8 / 1000 / elapsed
+	}
+	return throughput
+}
+
+// networkUsage returns the bytes received and transmitted on the default interface, which excludes the virtual
+// interfaces of the pods for a node, so that their traffic is not counted twice.
+func networkUsage(networkStats *stats.NetworkStats, now time.Time) (networkUsageSample, bool) {
+	if networkStats == nil || networkStats.RxBytes == nil || networkStats.TxBytes == nil {
+		return networkUsageSample{}, false
+	}
+	timestamp := now
+	if !networkStats.Time.IsZero() {
+		timestamp = networkStats.Time.Time
+	}
+	return networkUsageSample{
+		bytes:     float64(*networkStats.RxBytes) + float64(*networkStats.TxBytes),
+		timestamp: timestamp,
+	}, true
+}
+
+// generateNetworkMetrics generates the network throughput metrics of the node and its pods in the summary. The
+// throughput is only known from the second scrape of a node.
+func (m *KubeletMonitor) generateNetworkMetrics(summary *stats.Summary, now time.Time) {
+	nodeKey := util.NodeStatsKeyFunc(summary.Node)
+	samples := make(map[string]networkUsageSample)
+	if sample, found := networkUsage(summary.Node.Network, now); found {
+		samples[nodeKey] = sample
+	}
+	podKeys := make([]string, 0, len(summary.Pods))
+	for i := range summary.Pods {
+		podKey := util.PodMetricId(&summary.Pods[i].PodRef)
+		if sample, found := networkUsage(summary.Pods[i].Network, now); found {
+			samples[podKey] = sample
+			podKeys = append(podKeys, podKey)
+		}
+	}
+	if len(samples) == 0 {
+		glog.V(3).Infof("No network stats found for node %s.", summary.Node.NodeName)
+		return
+	}
+	throughput := m.networkUsageCache.update(summary.Node.NodeName, samples)
+	if used, found := throughput[nodeKey]; found {
+		glog.V(4).Infof("Network throughput of node %s is %.3f Kbit/s", nodeKey, used)
+		m.metricSink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(metrics.NodeType, nodeKey, metrics.NetThroughput, metrics.Capacity,
+				float64(nodeNetThroughputCapacityKbps)),
+			metrics.NewEntityResourceMetric(metrics.NodeType, nodeKey, metrics.NetThroughput, metrics.Used, used))
+	}
+	for _, podKey := range podKeys {
+		if used, found := throughput[podKey]; found {
+			glog.V(4).Infof("Network throughput of pod %s is %.3f Kbit/s", podKey, used)
+			m.metricSink.AddNewMetricEntries(
+				metrics.NewEntityResourceMetric(metrics.PodType, podKey, metrics.NetThroughput, metrics.Used, used))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package kubelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+func newNetworkStats(rxBytes, txBytes uint64, timestamp time.Time) *stats.NetworkStats {
+	return &stats.NetworkStats{
+		Time:           metav1.NewTime(timestamp),
+		InterfaceStats: stats.InterfaceStats{Name: "eth0", RxBytes: &rxBytes, TxBytes: &txBytes},
+	}
+}
+
+func newNetworkSummary(nodeBytes, podBytes uint64, timestamp time.Time) *stats.Summary {
+	return &stats.Summary{
+		Node: stats.NodeStats{NodeName: "node-1", Network: newNetworkStats(nodeBytes, nodeBytes, timestamp)},
+		Pods: []stats.PodStats{
+			{PodRef: stats.PodReference{Namespace: "ns", Name: "pod-1"}, Network: newNetworkStats(podBytes, 0, timestamp)},
+			// The network stats of a pod on the host network are not reported
+			{PodRef: stats.PodReference{Namespace: "ns", Name: "pod-2"}},
+		},
+	}
+}
+
+func getNetThroughput(t *testing.T, sink *metrics.EntityMetricSink, etype metrics.DiscoveredEntityType,
+	key string, prop metrics.MetricProp) (float64, bool) {
+	metric, err := sink.GetMetric(metrics.GenerateEntityResourceMetricUID(etype, key, metrics.NetThroughput, prop))
+	if err != nil {
+		return 0, false
+	}
+	value, ok := metric.GetValue().(float64)
+	assert.True(t, ok)
+	return value, true
+}
+
+func TestGenerateNetworkMetrics(t *testing.T) {
+	conf := NewKubeletMonitorConfig(nil, nil)
+	now := time.Now()
+
+	// The throughput is unknown from the first scrape
+	klet, _ := NewKubeletMonitor(conf, true)
+	klet.generateNetworkMetrics(newNetworkSummary(1000, 1000, now), now)
+	_, found := getNetThroughput(t, klet.metricSink, metrics.NodeType, "node-1", metrics.Used)
+	assert.False(t, found)
+
+	// 10 seconds later, the node received and transmitted 250,000 bytes, and the pod received 125,000 bytes
+	later := now.Add(10 * time.Second)
+	klet, _ = NewKubeletMonitor(conf, false)
+	klet.generateNetworkMetrics(newNetworkSummary(126000, 126000, later), later)
+	used, found := getNetThroughput(t, klet.metricSink, metrics.NodeType, "node-1", metrics.Used)
+	assert.True(t, found)
+	assert.InDelta(t, 200, used, 1e-9)
+	capacity, found := getNetThroughput(t, klet.metricSink, metrics.NodeType, "node-1", metrics.Capacity)
+	assert.True(t, found)
+	assert.Equal(t, float64(nodeNetThroughputCapacityKbps), capacity)
+	used, found = getNetThroughput(t, klet.metricSink, metrics.PodType, "ns/pod-1", metrics.Used)
+	assert.True(t, found)
+	assert.InDelta(t, 100, used, 1e-9)
+	_, found = getNetThroughput(t, klet.metricSink, metrics.PodType, "ns/pod-2", metrics.Used)
+	assert.False(t, found)
+
+	// The counters of a restarted pod went backwards
+	latest := later.Add(10 * time.Second)
+	klet, _ = NewKubeletMonitor(conf, false)
+	klet.generateNetworkMetrics(newNetworkSummary(126000, 500, latest), latest)
+	_, found = getNetThroughput(t, klet.metricSink, metrics.PodType, "ns/pod-1", metrics.Used)
+	assert.False(t, found)
+}
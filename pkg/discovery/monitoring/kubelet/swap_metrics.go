@@ -0,0 +1,82 @@
+package kubelet
+
+import (
+	"github.com/golang/glog"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// The cAdvisor container id of the root cgroup, which accounts for the whole node
+const rootCgroupId = "/"
+
+// parseSwapMetrics parses the container_memory_swap metric family reported by cAdvisor into
+// the swap usage in KB of the node and of its containers.
+// Example:
+// in:
+// # HELP container_memory_swap Container swap usage in bytes.
+// # TYPE container_memory_swap gauge
+// container_memory_swap{container="",id="/",image="",name="",namespace="",pod=""} 1.048576e+06 1629775344665
+// container_memory_swap{container="app",id="/kubepods/burstable/pod8266a379/8e1a2ff0",image="app:v1",name="8e1a2ff0",namespace="ns",pod="app-1"} 524288 1629775344665
+//
+// out:
+//
+//	nodeSwapKB: 1024, nodeSwapFound: true
+//	containerSwapKB: map[string]float64{"ns/app-1/app": 512}
+//
+// Nodes which do not report the metric family, e.g. the kernel has no swap accounting, yield no metrics.
+func parseSwapMetrics(metricFamily *dto.MetricFamily) (nodeSwapKB float64, nodeSwapFound bool, containerSwapKB map[string]float64) {
+	containerSwapKB = make(map[string]float64)
+	if metricFamily == nil {
+		return
+	}
+	if metricFamily.GetType() != dto.MetricType_GAUGE {
+		glog.Warningf("Expected metrics type: %v, but received type: %v while parsing swap metrics.",
+			dto.MetricType_GAUGE, metricFamily.GetType())
+		return
+	}
+	for _, metric := range metricFamily.GetMetric() {
+		if metric == nil {
+			continue
+		}
+		var id, name, namespace, podName string
+		for _, l := range metric.GetLabel() {
+			switch l.GetName() {
+			case "id":
+				id = l.GetValue()
+			case "container", "container_name":
+				name = l.GetValue()
+			case "namespace":
+				namespace = l.GetValue()
+			case "pod", "pod_name":
+				podName = l.GetValue()
+			default:
+			}
+		}
+		swapKB := util.Base2BytesToKilobytes(metric.GetGauge().GetValue())
+		if id == rootCgroupId {
+			nodeSwapKB, nodeSwapFound = swapKB, true
+			continue
+		}
+		if name == "" || name == "POD" || namespace == "" || podName == "" {
+			// Skip the metrics of the pod and system cgroups
+			continue
+		}
+		containerSwapKB[util.ContainerMetricId(namespace+"/"+podName, name)] = swapKB
+	}
+	return
+}
+
+func (m *KubeletMonitor) generateSwapMetrics(metricFamily *dto.MetricFamily, nodeKey string) {
+	nodeSwapKB, nodeSwapFound, containerSwapKB := parseSwapMetrics(metricFamily)
+	if !nodeSwapFound {
+		glog.V(3).Infof("No swap metrics found for node %s.", nodeKey)
+		return
+	}
+	glog.V(4).Infof("Swap usage of node %s is %.3f KB", nodeKey, nodeSwapKB)
+	m.metricSink.AddNewMetricEntries(metrics.NewEntityStateMetric(metrics.NodeType, nodeKey, metrics.SwapUsed, nodeSwapKB))
+	for containerMId, swapKB := range containerSwapKB {
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityStateMetric(metrics.ContainerType, containerMId, metrics.SwapUsed, swapKB))
+	}
+}
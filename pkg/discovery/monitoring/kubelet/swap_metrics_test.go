@@ -0,0 +1,61 @@
+package kubelet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+func TestParseSwapMetrics(t *testing.T) {
+	metricSample := []byte(`
+# HELP container_memory_swap Container swap usage in bytes.
+# TYPE container_memory_swap gauge
+container_memory_swap{container="",id="/",image="",name="",namespace="",pod=""} 1.048576e+06 1629775344665
+container_memory_swap{container="",id="/system.slice/kubelet.service",image="",name="",namespace="",pod=""} 4096 1629775344665
+container_memory_swap{container="",id="/kubepods/burstable/pod278c96f7",image="",name="",namespace="lens-metrics",pod="node-exporter-pmngv"} 524288 1629775344665
+container_memory_swap{container="node-exporter",id="/kubepods/burstable/pod278c96f7/6a79a7d4",image="sha256:0e0218",name="6a79a7d4",namespace="lens-metrics",pod="node-exporter-pmngv"} 524288 1629775344665
+# HELP container_cpu_cfs_periods_total Number of elapsed enforcement period intervals.
+# TYPE container_cpu_cfs_periods_total counter
+container_cpu_cfs_periods_total{container="node-exporter",id="/kubepods/burstable/pod278c96f7/6a79a7d4",image="sha256:0e0218",name="6a79a7d4",namespace="lens-metrics",pod="node-exporter-pmngv"} 20 1616975915711
+`)
+	mf, err := kubeclient.TextToSwapMetricFamily(metricSample)
+	assert.Nil(t, err)
+
+	nodeSwapKB, nodeSwapFound, containerSwapKB := parseSwapMetrics(mf)
+	assert.True(t, nodeSwapFound)
+	assert.Equal(t, float64(1024), nodeSwapKB)
+	assert.Equal(t, map[string]float64{"lens-metrics/node-exporter-pmngv/node-exporter": 512}, containerSwapKB)
+
+	klet, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil, nil).WithCollectSwapMetrics(true), true)
+	klet.generateSwapMetrics(mf, "node-1")
+	nodeSwap, err := klet.metricSink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.NodeType, "node-1", metrics.SwapUsed))
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1024), nodeSwap.GetValue())
+	containerSwap, err := klet.metricSink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.ContainerType,
+		"lens-metrics/node-exporter-pmngv/node-exporter", metrics.SwapUsed))
+	assert.Nil(t, err)
+	assert.Equal(t, float64(512), containerSwap.GetValue())
+}
+
+func TestParseSwapMetricsWithoutSwap(t *testing.T) {
+	metricSample := []byte(`
+# HELP container_cpu_cfs_periods_total Number of elapsed enforcement period intervals.
+# TYPE container_cpu_cfs_periods_total counter
+container_cpu_cfs_periods_total{container="node-exporter",id="/kubepods/burstable/pod278c96f7/6a79a7d4",image="sha256:0e0218",name="6a79a7d4",namespace="lens-metrics",pod="node-exporter-pmngv"} 20 1616975915711
+`)
+	mf, err := kubeclient.TextToSwapMetricFamily(metricSample)
+	assert.Nil(t, err)
+	assert.Nil(t, mf)
+
+	_, nodeSwapFound, containerSwapKB := parseSwapMetrics(mf)
+	assert.False(t, nodeSwapFound)
+	assert.Equal(t, 0, len(containerSwapKB))
+
+	klet, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil, nil).WithCollectSwapMetrics(true), true)
+	klet.generateSwapMetrics(mf, "node-1")
+	_, err = klet.metricSink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.NodeType, "node-1", metrics.SwapUsed))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,63 @@
+package kubelet
+
+import (
+	"time"
+
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+// fillWindowsCPUUsage fills the cpu usage in nano cores of the node and the containers of the summary of a Windows
+// node, which the Windows kubelet may not report, from their cumulative cpu usage sampled in the previous scrape.
+// Example:
+// in:
+//
+//	previous scrape at 12:00:00: container ns/app-1/app UsageCoreNanoSeconds 10e9
+//	this scrape at 12:00:10:     container ns/app-1/app UsageCoreNanoSeconds 15e9, no UsageNanoCores
+//
+// out:
+//
+//	container ns/app-1/app UsageNanoCores 0.5e9
+//
+// The usage reported by the kubelet is kept. The usage stays missing on the first scrape of the node, or after the
+// restart of a container.
+func fillWindowsCPUUsage(summary *stats.Summary, cache *cpuUsageCache, now time.Time) {
+	samples := make(map[string]cpuUsageSample)
+	addSample := func(id string, cpu *stats.CPUStats) {
+		if cpu == nil || cpu.UsageCoreNanoSeconds == nil {
+			return
+		}
+		timestamp := now
+		if !cpu.Time.IsZero() {
+			timestamp = cpu.Time.Time
+		}
+		samples[id] = cpuUsageSample{usageSeconds: float64(*cpu.UsageCoreNanoSeconds) / 1e9, timestamp: timestamp}
+	}
+	addSample(rootCgroupId, summary.Node.CPU)
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+		for j := range pod.Containers {
+			addSample(windowsContainerId(pod, &pod.Containers[j]), pod.Containers[j].CPU)
+		}
+	}
+	usage := cache.update(summary.Node.NodeName, samples)
+	fillUsage := func(id string, cpu *stats.CPUStats) {
+		if cpu == nil || cpu.UsageNanoCores != nil {
+			return
+		}
+		if nanoCores, found := usage[id]; found {
+			cpu.UsageNanoCores = &nanoCores
+		}
+	}
+	fillUsage(rootCgroupId, summary.Node.CPU)
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+		for j := range pod.Containers {
+			fillUsage(windowsContainerId(pod, &pod.Containers[j]), pod.Containers[j].CPU)
+		}
+	}
+}
+
+// windowsContainerId identifies a container in the cpu usage cache, as the Windows containers have no cgroup id.
+func windowsContainerId(pod *stats.PodStats, container *stats.ContainerStats) string {
+	return pod.PodRef.Namespace + "/" + pod.PodRef.Name + "/" + pod.PodRef.UID + "/" + container.Name
+}
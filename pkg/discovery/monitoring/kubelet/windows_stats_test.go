@@ -0,0 +1,53 @@
+package kubelet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+)
+
+func newWindowsSummary(nodeUsage, containerUsage uint64, timestamp time.Time) *stats.Summary {
+	reported := uint64(42)
+	return &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName: "win-1",
+			CPU: &stats.CPUStats{Time: metav1.NewTime(timestamp), UsageCoreNanoSeconds: &nodeUsage,
+				UsageNanoCores: &reported},
+		},
+		Pods: []stats.PodStats{{
+			PodRef: stats.PodReference{Name: "app-1", Namespace: "ns", UID: "uid-1"},
+			Containers: []stats.ContainerStats{
+				{Name: "app", CPU: &stats.CPUStats{Time: metav1.NewTime(timestamp),
+					UsageCoreNanoSeconds: &containerUsage}},
+				{Name: "no-cpu"},
+			},
+		}},
+	}
+}
+
+func TestFillWindowsCPUUsage(t *testing.T) {
+	cache := newCPUUsageCache()
+	start := time.Now()
+
+	// No usage on the first scrape
+	summary := newWindowsSummary(100e9, 10e9, start)
+	fillWindowsCPUUsage(summary, cache, start)
+	assert.Nil(t, summary.Pods[0].Containers[0].CPU.UsageNanoCores)
+
+	summary = newWindowsSummary(200e9, 15e9, start.Add(10*time.Second))
+	fillWindowsCPUUsage(summary, cache, start)
+	if assert.NotNil(t, summary.Pods[0].Containers[0].CPU.UsageNanoCores) {
+		assert.Equal(t, uint64(5e8), *summary.Pods[0].Containers[0].CPU.UsageNanoCores)
+	}
+	// The usage reported by the kubelet is kept
+	assert.Equal(t, uint64(42), *summary.Node.CPU.UsageNanoCores)
+	assert.Nil(t, summary.Pods[0].Containers[1].CPU)
+
+	// No usage after the restart of the container
+	summary = newWindowsSummary(300e9, 1e9, start.Add(20*time.Second))
+	fillWindowsCPUUsage(summary, cache, start)
+	assert.Nil(t, summary.Pods[0].Containers[0].CPU.UsageNanoCores)
+}
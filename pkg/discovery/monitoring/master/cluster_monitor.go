@@ -124,8 +124,10 @@ func (m *ClusterMonitor) genNodeResourceMetrics(node *api.Node, key string) {
 	glog.V(3).Infof("Now get resource metrics for node %s", key)
 
 	//1. Capacity of CPU and Memory
-	//1.1 Get the total resource of a node
-	cpuCapacityMillicore, memoryCapacityKiloBytes := util.GetCpuAndMemoryValues(node.Status.Capacity)
+	//1.1 Get the sellable resource of a node, which is its allocatable resource, net of the kube-reserved and
+	// system-reserved resources and of the eviction threshold, so that no consumer is placed into the reserved headroom.
+	// The used resources of the node are the ones of its pods accordingly, see KubeletMonitor.parseNodeStats.
+	cpuCapacityMillicore, memoryCapacityKiloBytes := nodeSellableCapacity(node)
 	glog.V(4).Infof("CPU capacity of node %s is %f Core", node.Name, util.MetricMilliToUnit(cpuCapacityMillicore))
 	glog.V(4).Infof("Memory capacity of node %s is %f Kb", node.Name, memoryCapacityKiloBytes)
 	//1.2 Generate the capacity metric for CPU and Mem
@@ -156,6 +158,20 @@ func (m *ClusterMonitor) genNodeResourceMetrics(node *api.Node, key string) {
 	glog.V(3).Infof("Successfully generated resource metrics for node %s", key)
 }
 
+// nodeSellableCapacity returns the allocatable CPU in millicores and memory in kilobytes of the node, or its total CPU
+// and memory for a resource whose allocatable amount is not reported.
+func nodeSellableCapacity(node *api.Node) (cpuMillicore, memoryKiloBytes float64) {
+	cpuMillicore, memoryKiloBytes = util.GetCpuAndMemoryValues(node.Status.Allocatable)
+	cpuTotalMillicore, memoryTotalKiloBytes := util.GetCpuAndMemoryValues(node.Status.Capacity)
+	if cpuMillicore <= 0 {
+		cpuMillicore = cpuTotalMillicore
+	}
+	if memoryKiloBytes <= 0 {
+		memoryKiloBytes = memoryTotalKiloBytes
+	}
+	return
+}
+
 // Parse the labels of a node and create one EntityStateMetric
 func parseNodeLabels(node *api.Node) metrics.EntityStateMetric {
 	labelsMap := node.ObjectMeta.Labels
@@ -208,7 +224,9 @@ func (m *ClusterMonitor) genNodePodsMetrics(node *api.Node, cpuCapacityMillicore
 // (1) generate Container CPU/Memory capacity, CPURequest/MemoryRequest capacity and CPU/memory limit and request quota used
 // (resource quota used is the same as corresponding resource capacity)
 // (2) generate Pod CPU/Memory capacity and CPURequest/MemoryRequest capacity
-// (3) Pod CPURequest/MemoryRequest usage is the sum of containers CPURequest/MemoryRequest capacity
+// (3) Pod CPURequest/MemoryRequest usage is the effective request of the pod as the scheduler accounts it: the sum of
+// the containers CPURequest/MemoryRequest capacity, or the largest init container request if it is larger, plus the
+// pod overhead
 func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacityMillicore, nodeMemCapacity, nodeCPUAllocatableMillicore,
 	nodeMemAllocatable float64) (float64, float64) {
 	key := util.PodKeyFunc(pod)
@@ -221,8 +239,10 @@ func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacityMillicore, n
 	m.genCapacityMetrics(metrics.PodType, podMId, cpuCapacityMillicore, memCapacity)
 
 	//2. Requests
-	//2.1 Get the totalCPURequest and totalMemRequest from all containers in the pod
-	podCPURequest, podMemRequest := m.genContainerMetrics(pod, cpuCapacityMillicore, memCapacity)
+	//2.1 Generate the container metrics and get the effective requests of the pod
+	m.genContainerMetrics(pod, cpuCapacityMillicore, memCapacity)
+	podRequests, _ := util.GetPodComputeResources(pod)
+	podCPURequest, podMemRequest := util.GetCpuAndMemoryValues(podRequests)
 	//2.2 Generate capacity metric for CPURequest and MemRequest. Pod requests capacity is node Allocatable
 	m.genRequestCapacityMetrics(metrics.PodType, podMId, nodeCPUAllocatableMillicore, nodeMemAllocatable)
 	//2.3 Generate used metric for CPURequest and MemRequest
@@ -239,9 +259,7 @@ func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacityMillicore, n
 
 // Container.Capacity = container.Limit if limit is set, otherwise is Pod.Capacity
 // Application won't sell CPU/Memory, so no need to generate application CPU/Memory Capacity for application
-func (m *ClusterMonitor) genContainerMetrics(pod *api.Pod, podCPUMillicore, podMem float64) (float64, float64) {
-	totalCPURequest := 0.0
-	totalMemRequest := 0.0
+func (m *ClusterMonitor) genContainerMetrics(pod *api.Pod, podCPUMillicore, podMem float64) {
 	podMId := util.PodMetricIdAPI(pod)
 	podKey := util.PodKeyFunc(pod)
 
@@ -277,9 +295,6 @@ func (m *ClusterMonitor) genContainerMetrics(pod *api.Pod, podCPUMillicore, podM
 		// Generate resource request quota metrics with used value as CPU/memory resource request capacity
 		m.genRequestQuotaUsedMetrics(metrics.ContainerType, containerMId, cpuRequest, memRequest)
 
-		totalCPURequest += cpuRequest
-		totalMemRequest += memRequest
-
 		//3. Owner
 		podOwner, exists := m.podOwners[podKey]
 		if exists {
@@ -287,8 +302,6 @@ func (m *ClusterMonitor) genContainerMetrics(pod *api.Pod, podCPUMillicore, podM
 			m.genOwnerMetrics(metrics.ContainerType, containerMId, podOwner.Kind, podOwner.Name, podOwner.Uid)
 		}
 	}
-
-	return totalCPURequest, totalMemRequest
 }
 
 func IsInjectedSidecar(name string, containers sets.String) bool {
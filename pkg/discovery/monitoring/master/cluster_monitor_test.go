@@ -18,16 +18,16 @@ import (
 // All cpu metrics are generated in millicores
 var expectedMetrics = map[string]float64{
 	// Node metrics
-	"Node-mynode-CPU-Capacity":           2000,
-	"Node-mynode-Memory-Capacity":        8.388608e+06,
+	"Node-mynode-CPU-Capacity":           1900,
+	"Node-mynode-Memory-Capacity":        7.340032e+06,
 	"Node-mynode-CPURequest-Capacity":    1900,
 	"Node-mynode-MemoryRequest-Capacity": 7.340032e+06,
 	"Node-mynode-CPURequest-Used":        261,
 	"Node-mynode-MemoryRequest-Used":     262144,
 
 	// Pod metrics
-	"Pod-default/mypod-CPU-Capacity":       2000,
-	"Pod-default/mypod-Memory-Capacity":    8.388608e+06,
+	"Pod-default/mypod-CPU-Capacity":       1900,
+	"Pod-default/mypod-Memory-Capacity":    7.340032e+06,
 	"Pod-default/mypod-CPURequest-Used":    261,
 	"Pod-default/mypod-MemoryRequest-Used": 262144,
 
@@ -50,7 +50,7 @@ var expectedMetrics = map[string]float64{
 	"Container-default/mypod/istio-proxy-MemoryRequestQuota-Used":        0,
 	"Container-default/mypod/filebeat-sidecar-CPULimitQuota-Used":        1,
 	"Container-default/mypod/filebeat-sidecar-CPURequestQuota-Used":      1,
-	"Container-default/mypod/filebeat-sidecar-MemoryLimitQuota-Used":     8.388608e+06,
+	"Container-default/mypod/filebeat-sidecar-MemoryLimitQuota-Used":     7.340032e+06,
 	"Container-default/mypod/filebeat-sidecar-MemoryRequestQuota-Used":   0,
 }
 
@@ -142,7 +142,8 @@ func mockNode(name string, capacity, allocatable api.ResourceList) *api.Node {
 }
 
 func TestGenNodeResourceMetrics(t *testing.T) {
-	// Build a node
+	// Build a node, whose allocatable resources are sold as the capacity of the node and the default capacity of the
+	// pods and containers without limits
 	node := mockNode(
 		"mynode",
 		buildResource(2.0, 8192), // node capacity: 2.0 cores, 8 GiB mem
@@ -170,3 +171,16 @@ func TestGenNodeResourceMetrics(t *testing.T) {
 		assert.EqualValues(t, value, metric.GetValue(), fmt.Sprintf("Metric values are not equal for %s", name))
 	}
 }
+
+func TestNodeSellableCapacity(t *testing.T) {
+	node := mockNode("mynode", buildResource(2.0, 8192), buildResource(1.9, 7168))
+	cpu, memory := nodeSellableCapacity(node)
+	assert.EqualValues(t, 1900, cpu)
+	assert.EqualValues(t, 7168*1024, memory)
+
+	// The total resources without the allocatable resources
+	node.Status.Allocatable = nil
+	cpu, memory = nodeSellableCapacity(node)
+	assert.EqualValues(t, 2000, cpu)
+	assert.EqualValues(t, 8192*1024, memory)
+}
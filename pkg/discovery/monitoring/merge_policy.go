@@ -0,0 +1,123 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+)
+
+// DefaultSourcePriority is the default priority of the monitoring sources, from the highest to the lowest: the usage
+// collected from Prometheus is preferred to the one collected from the kubelets, and the metrics-server only
+// backfills the usage the others could not collect.
+var DefaultSourcePriority = []types.MonitoringSource{
+	types.PrometheusSource,
+	types.KubeletSource,
+	types.MetricsServerSource,
+	types.ClusterSource,
+}
+
+// MonitoringSources returns the monitoring sources which can run together in a discovery.
+func MonitoringSources() []types.MonitoringSource {
+	return []types.MonitoringSource{types.KubeletSource, types.ClusterSource, types.PrometheusSource,
+		types.MetricsServerSource}
+}
+
+// ParseSourcePriority parses the names of the monitoring sources, case-insensitively, from the highest priority to
+// the lowest.
+func ParseSourcePriority(names []string) ([]types.MonitoringSource, error) {
+	var priority []types.MonitoringSource
+	seen := make(map[types.MonitoringSource]bool)
+	for _, name := range names {
+		source, found := findSource(strings.TrimSpace(name))
+		if !found {
+			var known []string
+			for _, source := range MonitoringSources() {
+				known = append(known, string(source))
+			}
+			return nil, fmt.Errorf("unknown monitoring source %q, should be one of %s", name,
+				strings.Join(known, ", "))
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("monitoring source %s is listed more than once", source)
+		}
+		seen[source] = true
+		priority = append(priority, source)
+	}
+	return priority, nil
+}
+
+func findSource(name string) (types.MonitoringSource, bool) {
+	for _, source := range MonitoringSources() {
+		if strings.EqualFold(string(source), name) {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// MergePolicy merges the metrics collected by the monitoring sources running together. Each metric, identified by
+// its entity, resource type and property, is claimed by the source with the highest priority which collected it.
+// The sources with a lower priority backfill the metrics the others did not collect, e.g., for the containers whose
+// series are missing from Prometheus.
+type MergePolicy struct {
+	priority []types.MonitoringSource
+}
+
+// NewMergePolicy creates a merge policy with the given priority of the sources, from the highest to the lowest, or
+// with the default priority if none is given. The sources which are not listed come last.
+func NewMergePolicy(priority []types.MonitoringSource) *MergePolicy {
+	if len(priority) == 0 {
+		priority = DefaultSourcePriority
+	}
+	return &MergePolicy{priority: priority}
+}
+
+// Merge merges the sinks of the sources into the given sink, by priority of their sources.
+func (p *MergePolicy) Merge(sink *metrics.EntityMetricSink,
+	sourceSinks map[types.MonitoringSource][]*metrics.EntityMetricSink) {
+	claimed := make(map[string]bool)
+	for _, source := range p.order(sourceSinks) {
+		merged := make(map[string]bool)
+		for _, sourceSink := range sourceSinks[source] {
+			sink.MergeSink(sourceSink, func(m metrics.Metric) bool {
+				if claimed[m.GetUID()] {
+					return false
+				}
+				merged[m.GetUID()] = true
+				return true
+			})
+		}
+		for uid := range merged {
+			claimed[uid] = true
+		}
+	}
+}
+
+// order returns the sources of the given sinks by priority.
+func (p *MergePolicy) order(sourceSinks map[types.MonitoringSource][]*metrics.EntityMetricSink) []types.MonitoringSource {
+	var ordered, unlisted []types.MonitoringSource
+	for _, source := range p.priority {
+		if _, found := sourceSinks[source]; found {
+			ordered = append(ordered, source)
+		}
+	}
+	for source := range sourceSinks {
+		if !containsSource(p.priority, source) {
+			unlisted = append(unlisted, source)
+		}
+	}
+	sort.Slice(unlisted, func(i, j int) bool { return unlisted[i] < unlisted[j] })
+	return append(ordered, unlisted...)
+}
+
+func containsSource(sources []types.MonitoringSource, source types.MonitoringSource) bool {
+	for _, s := range sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
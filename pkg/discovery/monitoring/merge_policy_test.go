@@ -0,0 +1,60 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+)
+
+func newUsedSink(entries map[string]float64) *metrics.EntityMetricSink {
+	sink := metrics.NewEntityMetricSink().WithMaxMetricPointsSize(10)
+	for key, value := range entries {
+		sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.ContainerType, key, metrics.CPU, metrics.Used,
+			[]metrics.Point{{Value: value}}))
+	}
+	return sink
+}
+
+func usedValue(t *testing.T, sink *metrics.EntityMetricSink, key string) []metrics.Point {
+	metric, err := sink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, key, metrics.CPU,
+		metrics.Used))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	return metric.GetValue().([]metrics.Point)
+}
+
+func TestMergePolicyMerge(t *testing.T) {
+	sourceSinks := map[types.MonitoringSource][]*metrics.EntityMetricSink{
+		types.KubeletSource: {
+			newUsedSink(map[string]float64{"ns/app-1/app": 1, "ns/app-1/sidecar": 2}),
+			// The samples collected since the last full discovery
+			newUsedSink(map[string]float64{"ns/app-1/sidecar": 3}),
+		},
+		types.PrometheusSource: {newUsedSink(map[string]float64{"ns/app-1/app": 10})},
+	}
+
+	sink := metrics.NewEntityMetricSink().WithMaxMetricPointsSize(10)
+	NewMergePolicy(nil).Merge(sink, sourceSinks)
+	// Prometheus claims the usage of the app, and the kubelet backfills the usage of the sidecar
+	assert.Equal(t, []metrics.Point{{Value: 10}}, usedValue(t, sink, "ns/app-1/app"))
+	assert.Equal(t, []metrics.Point{{Value: 2}, {Value: 3}}, usedValue(t, sink, "ns/app-1/sidecar"))
+
+	sink = metrics.NewEntityMetricSink().WithMaxMetricPointsSize(10)
+	NewMergePolicy([]types.MonitoringSource{types.KubeletSource}).Merge(sink, sourceSinks)
+	assert.Equal(t, []metrics.Point{{Value: 1}}, usedValue(t, sink, "ns/app-1/app"))
+}
+
+func TestParseSourcePriority(t *testing.T) {
+	priority, err := ParseSourcePriority([]string{"kubelet", " Prometheus"})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.MonitoringSource{types.KubeletSource, types.PrometheusSource}, priority)
+
+	_, err = ParseSourcePriority([]string{"cadvisor"})
+	assert.Error(t, err)
+	_, err = ParseSourcePriority([]string{"Kubelet", "kubelet"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package metricsserver
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+)
+
+var (
+	// The resources of the Kubernetes metrics API served by the metrics-server
+	nodeMetricsRes = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+	podMetricsRes  = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+)
+
+type MetricsServerMonitorConfig struct {
+	// The client of the metrics API, shared by the monitors of all the discovery workers
+	client dynamic.Interface
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c MetricsServerMonitorConfig) GetMonitorType() types.MonitorType {
+	return types.ResourceMonitor
+}
+func (c MetricsServerMonitorConfig) GetMonitoringSource() types.MonitoringSource {
+	return types.MetricsServerSource
+}
+
+func NewMetricsServerMonitorConfig(client dynamic.Interface) *MetricsServerMonitorConfig {
+	return &MetricsServerMonitorConfig{
+		client: client,
+	}
+}
@@ -0,0 +1,226 @@
+package metricsserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// MetricsServerMonitor collects the cpu and memory usage of a node and of the containers and pods running on it from
+// the Kubernetes metrics API, e.g. on the managed clusters where the kubelet stats are not reachable from the pods.
+// Nothing is collected if the metrics-server is not installed.
+type MetricsServerMonitor struct {
+	config *MetricsServerMonitorConfig
+
+	metricSink *metrics.EntityMetricSink
+
+	node *api.Node
+	pods []*api.Pod
+}
+
+func NewMetricsServerMonitor(config *MetricsServerMonitorConfig) (*MetricsServerMonitor, error) {
+	if config == nil || config.client == nil {
+		return nil, errors.New("no client of the metrics API is configured")
+	}
+	return &MetricsServerMonitor{
+		config:     config,
+		metricSink: metrics.NewEntityMetricSink(),
+	}, nil
+}
+
+func (m *MetricsServerMonitor) reset() {
+	m.metricSink = metrics.NewEntityMetricSink()
+}
+
+func (m *MetricsServerMonitor) GetMonitoringSource() types.MonitoringSource {
+	return types.MetricsServerSource
+}
+
+func (m *MetricsServerMonitor) ReceiveTask(task *task.Task) {
+	m.reset()
+	m.node = task.Node()
+	m.pods = task.RunningPodList()
+}
+
+func (m *MetricsServerMonitor) Do() (*metrics.EntityMetricSink, error) {
+	if m.node == nil {
+		return m.metricSink, errors.New("empty node")
+	}
+	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
+	err := m.RetrieveResourceStat()
+	if err != nil {
+		glog.Errorf("Failed to execute task: %s", err)
+		return m.metricSink, err
+	}
+	glog.V(4).Infof("%s monitor has finished task.", m.GetMonitoringSource())
+	return m.metricSink, nil
+}
+
+// RetrieveResourceStat retrieves the usage of the received node and of the containers of its running pods.
+func (m *MetricsServerMonitor) RetrieveResourceStat() error {
+	nodeMetrics, err := m.config.client.Resource(nodeMetricsRes).Get(context.TODO(), m.node.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		glog.V(3).Infof("No metrics of node %s in the metrics API, the metrics-server may not be installed: %v",
+			m.node.Name, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the metrics of node %s: %v", m.node.Name, err)
+	}
+	// The usage of the pods of the node
+	podsCPUUsed, podsMemUsed := float64(0), float64(0)
+	podsByNamespace := make(map[string][]*api.Pod)
+	for _, pod := range m.pods {
+		podsByNamespace[pod.Namespace] = append(podsByNamespace[pod.Namespace], pod)
+	}
+	for namespace, pods := range podsByNamespace {
+		podMetricsList, err := m.config.client.Resource(podMetricsRes).Namespace(namespace).
+			List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list the pod metrics in namespace %s: %v", namespace, err)
+		}
+		podMetrics := make(map[string]*unstructured.Unstructured)
+		for i := range podMetricsList.Items {
+			podMetrics[podMetricsList.Items[i].GetName()] = &podMetricsList.Items[i]
+		}
+		for _, pod := range pods {
+			cpuUsed, memUsed := m.parsePodUsage(pod, podMetrics[pod.Name])
+			podsCPUUsed += cpuUsed
+			podsMemUsed += memUsed
+		}
+	}
+	m.parseNodeUsage(nodeMetrics, podsCPUUsed, podsMemUsed)
+	glog.V(4).Infof("Finished getting the usage of node %s from the metrics API.", m.node.Name)
+	return nil
+}
+
+// parseNodeUsage generates the same usage metrics of the node as the kubelet monitor, i.e. the usage of its pods, as
+// the node sells its allocatable resources, net of the resources reserved for the system daemons.
+func (m *MetricsServerMonitor) parseNodeUsage(nodeMetrics *unstructured.Unstructured, podsCPUUsed, podsMemUsed float64) {
+	usage, _, _ := unstructured.NestedStringMap(nodeMetrics.Object, "usage")
+	cpuUsed, memUsed, found := parseUsage(usage)
+	if !found {
+		glog.V(3).Infof("No usage of node %s in the metrics API.", m.node.Name)
+		return
+	}
+	glog.V(4).Infof("CPU and memory usage of node %s is %.3f and %.3f, of its pods %.3f and %.3f.", m.node.Name,
+		cpuUsed, memUsed, podsCPUUsed, podsMemUsed)
+	m.genUsedMetrics(metrics.NodeType, util.NodeKeyFunc(m.node), podsCPUUsed, podsMemUsed,
+		metricsTimestamp(nodeMetrics))
+}
+
+// parsePodUsage generates the same usage metrics of the given pod and of its containers and applications as the
+// kubelet monitor, and returns the usage of the pod. The pod metrics are nil if the metrics API has none for the pod,
+// e.g. when it has just started.
+func (m *MetricsServerMonitor) parsePodUsage(pod *api.Pod, podMetrics *unstructured.Unstructured) (float64, float64) {
+	podMId := util.PodMetricIdAPI(pod)
+	containerUsage := make(map[string]map[string]string)
+	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+	if podMetrics != nil {
+		containers, _, _ := unstructured.NestedSlice(podMetrics.Object, "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			usage, _, _ := unstructured.NestedStringMap(container, "usage")
+			containerUsage[name] = usage
+		}
+		timestamp = metricsTimestamp(podMetrics)
+	}
+
+	totalUsedCPU, totalUsedMem := float64(0), float64(0)
+	allMetricsMissing := true
+	for _, container := range pod.Spec.Containers {
+		cpuUsed, memUsed, found := parseUsage(containerUsage[container.Name])
+		if !found {
+			continue
+		}
+		allMetricsMissing = false
+		totalUsedCPU += cpuUsed
+		totalUsedMem += memUsed
+
+		containerMId := util.ContainerMetricId(podMId, container.Name)
+		m.genUsedMetrics(metrics.ContainerType, containerMId, cpuUsed, memUsed, timestamp)
+		m.genRequestUsedMetrics(metrics.ContainerType, containerMId, cpuUsed, memUsed, timestamp)
+		m.genUsedMetrics(metrics.ApplicationType, util.ApplicationMetricId(containerMId), cpuUsed, memUsed, timestamp)
+		glog.V(4).Infof("container[%s-%s] cpu/memory usage:%.3f, %.3f", pod.Name, container.Name, cpuUsed, memUsed)
+	}
+	if allMetricsMissing {
+		// Leave the pod to the other monitoring sources, if any
+		glog.V(4).Infof("No usage of pod %s in the metrics API.", podMId)
+		return 0, 0
+	}
+	m.genUsedMetrics(metrics.PodType, podMId, totalUsedCPU, totalUsedMem, timestamp)
+	m.metricSink.AddNewMetricEntries(
+		metrics.NewEntityStateMetric(metrics.PodType, podMId, metrics.MetricsAvailability, true))
+	return totalUsedCPU, totalUsedMem
+}
+
+// parseUsage parses the cpu usage in millicores and the memory usage in kilobytes from the usage reported by the
+// metrics API, and returns whether both are found.
+func parseUsage(usage map[string]string) (float64, float64, bool) {
+	cpu, err := resource.ParseQuantity(usage[string(api.ResourceCPU)])
+	if err != nil {
+		return 0, 0, false
+	}
+	memory, err := resource.ParseQuantity(usage[string(api.ResourceMemory)])
+	if err != nil {
+		return 0, 0, false
+	}
+	return float64(cpu.MilliValue()), util.Base2BytesToKilobytes(float64(memory.Value())), true
+}
+
+// metricsTimestamp returns the time at which the metrics were collected in milliseconds, or the current time if it is
+// not reported.
+func metricsTimestamp(obj *unstructured.Unstructured) int64 {
+	value, _, _ := unstructured.NestedString(obj.Object, "timestamp")
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		timestamp = time.Now()
+	}
+	return timestamp.UnixNano() / int64(time.Millisecond)
+}
+
+func (m *MetricsServerMonitor) genUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64, timestamp int64) {
+	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used,
+		[]metrics.Point{{
+			Value:     cpu,
+			Timestamp: timestamp,
+		}})
+	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used,
+		[]metrics.Point{{
+			Value:     memory,
+			Timestamp: timestamp,
+		}})
+	m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+}
+
+// genRequestUsedMetrics generates used metrics for VCPURequest and VMemRequest commodity
+func (m *MetricsServerMonitor) genRequestUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64, timestamp int64) {
+	cpuRequestMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPURequest, metrics.Used,
+		[]metrics.Point{{
+			Value:     cpu,
+			Timestamp: timestamp,
+		}})
+	memRequestMetric := metrics.NewEntityResourceMetric(etype, key, metrics.MemoryRequest, metrics.Used,
+		[]metrics.Point{{
+			Value:     memory,
+			Timestamp: timestamp,
+		}})
+	m.metricSink.AddNewMetricEntries(cpuRequestMetric, memRequestMetric)
+}
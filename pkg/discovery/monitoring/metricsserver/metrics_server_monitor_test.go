@@ -0,0 +1,105 @@
+package metricsserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+)
+
+const (
+	nodeMetricsResponse = `{"apiVersion":"metrics.k8s.io/v1beta1","kind":"NodeMetrics",
+"metadata":{"name":"node-1"},"timestamp":"2023-08-24T03:21:24Z","window":"20s",
+"usage":{"cpu":"1500m","memory":"2Gi"}}`
+	podMetricsResponse = `{"apiVersion":"metrics.k8s.io/v1beta1","kind":"PodMetricsList","metadata":{},"items":[
+{"metadata":{"name":"app-1","namespace":"ns"},"timestamp":"2023-08-24T03:21:24Z","window":"20s",
+"containers":[{"name":"app","usage":{"cpu":"200m","memory":"1Mi"}},{"name":"sidecar","usage":{"cpu":"50m","memory":"1Mi"}}]}]}`
+	notFoundResponse = `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`
+)
+
+func newTestMonitor(t *testing.T, handler http.HandlerFunc) *MetricsServerMonitor {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := dynamic.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NoError(t, err)
+	monitor, err := NewMetricsServerMonitor(NewMetricsServerMonitorConfig(client))
+	assert.NoError(t, err)
+	return monitor
+}
+
+func newTestPod(name string, containers ...string) *api.Pod {
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, api.Container{Name: container})
+	}
+	return pod
+}
+
+func usedPoints(t *testing.T, sink *metrics.EntityMetricSink, etype metrics.DiscoveredEntityType, key string,
+	rtype metrics.ResourceType) []metrics.Point {
+	metric, err := sink.GetMetric(metrics.GenerateEntityResourceMetricUID(etype, key, rtype, metrics.Used))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	return metric.GetValue().([]metrics.Point)
+}
+
+func TestMetricsServerMonitor(t *testing.T) {
+	monitor := newTestMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/metrics.k8s.io/v1beta1/nodes/node-1":
+			w.Write([]byte(nodeMetricsResponse))
+		case "/apis/metrics.k8s.io/v1beta1/namespaces/ns/pods":
+			w.Write([]byte(podMetricsResponse))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+	})
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	pod, missing := newTestPod("app-1", "app", "sidecar"), newTestPod("app-2", "app")
+	monitor.ReceiveTask(task.NewTask().WithNode(node).WithRunningPods([]*api.Pod{pod, missing}))
+	sink, err := monitor.Do()
+	assert.NoError(t, err)
+
+	// The usage of the node is the usage of its pods, without the system daemons
+	nodeCPU := usedPoints(t, sink, metrics.NodeType, "node-1", metrics.CPU)
+	assert.Equal(t, 250.0, nodeCPU[0].Value)
+	assert.Equal(t, int64(1692847284000), nodeCPU[0].Timestamp)
+	assert.Equal(t, 2048.0, usedPoints(t, sink, metrics.NodeType, "node-1", metrics.Memory)[0].Value)
+
+	assert.Equal(t, 200.0, usedPoints(t, sink, metrics.ContainerType, "ns/app-1/app", metrics.CPU)[0].Value)
+	assert.Equal(t, 200.0, usedPoints(t, sink, metrics.ContainerType, "ns/app-1/app", metrics.CPURequest)[0].Value)
+	assert.Equal(t, 200.0, usedPoints(t, sink, metrics.ApplicationType, "App-ns/app-1/app", metrics.CPU)[0].Value)
+	assert.Equal(t, 250.0, usedPoints(t, sink, metrics.PodType, "ns/app-1", metrics.CPU)[0].Value)
+	assert.Equal(t, 2048.0, usedPoints(t, sink, metrics.PodType, "ns/app-1", metrics.Memory)[0].Value)
+
+	// The pods without metrics are left to the other monitoring sources
+	_, err = sink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.PodType, "ns/app-2", metrics.CPU,
+		metrics.Used))
+	assert.Error(t, err)
+}
+
+func TestMetricsServerMonitorNotInstalled(t *testing.T) {
+	requests := 0
+	monitor := newTestMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(notFoundResponse))
+	})
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	monitor.ReceiveTask(task.NewTask().WithNode(node).WithRunningPods([]*api.Pod{newTestPod("app-1", "app")}))
+	_, err := monitor.Do()
+	assert.NoError(t, err)
+	// The pod metrics are not listed without the node metrics
+	assert.Equal(t, 1, requests)
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/metricsserver"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/prometheus"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 )
@@ -47,6 +49,18 @@ func BuildMonitorWorker(source types.MonitoringSource, config MonitorWorkerConfi
 			return nil, errors.New("Failed to build a cluster monitoring client as the provided config was not a ClusterMonitorConfig")
 		}
 		return master.NewClusterMonitor(clusterMonitorConfig)
+	case types.PrometheusSource:
+		prometheusConfig, ok := config.(*prometheus.PrometheusMonitorConfig)
+		if !ok {
+			return nil, errors.New("failed to build a Prometheus monitoring client as the provided config was not a PrometheusMonitorConfig")
+		}
+		return prometheus.NewPrometheusMonitor(prometheusConfig)
+	case types.MetricsServerSource:
+		metricsServerConfig, ok := config.(*metricsserver.MetricsServerMonitorConfig)
+		if !ok {
+			return nil, errors.New("failed to build a metrics-server monitoring client as the provided config was not a MetricsServerMonitorConfig")
+		}
+		return metricsserver.NewMetricsServerMonitor(metricsServerConfig)
 	case types.DummySource:
 		dummyMonitorConfig, _ := config.(*DummyMonitorConfig)
 		return NewDummyMonitor(dummyMonitorConfig)
@@ -0,0 +1,61 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+)
+
+const (
+	// DefaultCPUQuery is the default query of the cpu usage of the containers, in cores.
+	DefaultCPUQuery = `sum by (namespace, pod, container) (rate(container_cpu_usage_seconds_total{container!="",container!="POD"}[5m]))`
+	// DefaultMemoryQuery is the default query of the memory usage of the containers, in bytes.
+	DefaultMemoryQuery = `sum by (namespace, pod, container) (container_memory_working_set_bytes{container!="",container!="POD"})`
+	// DefaultQueryStep is the default resolution of the range queries.
+	DefaultQueryStep = time.Minute
+)
+
+type PrometheusMonitorConfig struct {
+	// The client of the Prometheus server, shared by the monitors of all the discovery workers
+	client *PrometheusClient
+	// The queries of the cpu usage in cores and the memory usage in bytes of the containers. The
+	// result series must be labeled with the namespace, pod and container names of the containers.
+	cpuQuery    string
+	memoryQuery string
+	// The resolution of the range queries; the latest sample within the last step is used
+	step time.Duration
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c PrometheusMonitorConfig) GetMonitorType() types.MonitorType {
+	return types.ResourceMonitor
+}
+func (c PrometheusMonitorConfig) GetMonitoringSource() types.MonitoringSource {
+	return types.PrometheusSource
+}
+
+func NewPrometheusMonitorConfig(serverURL string) *PrometheusMonitorConfig {
+	return &PrometheusMonitorConfig{
+		client:      NewPrometheusClient(serverURL),
+		cpuQuery:    DefaultCPUQuery,
+		memoryQuery: DefaultMemoryQuery,
+		step:        DefaultQueryStep,
+	}
+}
+
+func (c *PrometheusMonitorConfig) WithQueries(cpuQuery, memoryQuery string) *PrometheusMonitorConfig {
+	if cpuQuery != "" {
+		c.cpuQuery = cpuQuery
+	}
+	if memoryQuery != "" {
+		c.memoryQuery = memoryQuery
+	}
+	return c
+}
+
+func (c *PrometheusMonitorConfig) WithStep(step time.Duration) *PrometheusMonitorConfig {
+	if step > 0 {
+		c.step = step
+	}
+	return c
+}
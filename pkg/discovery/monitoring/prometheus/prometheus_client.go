@@ -0,0 +1,182 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	queryRangePath = "/api/v1/query_range"
+
+	defaultQueryTimeout = 20 * time.Second
+	// The results of a query are reused by the discovery workers for this long, so that the usage of all the
+	// containers is queried once for all the nodes of a discovery rather than once per node
+	resultCacheTTL = 10 * time.Second
+)
+
+// PrometheusClient runs the range queries of the container usage and of the service mesh metrics against the HTTP
+// API of a Prometheus server. It is safe for concurrent use.
+type PrometheusClient struct {
+	client    *http.Client
+	serverURL string
+
+	cacheLock sync.Mutex
+	cache     map[string]*queryResult
+}
+
+type queryResult struct {
+	// The latest value of each series, by the key of its labels
+	values    map[string]float64
+	timestamp time.Time
+}
+
+// The response of the Prometheus HTTP API, see https://prometheus.io/docs/prometheus/latest/querying/api/
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			// Pairs of a unix timestamp in seconds and a value as a string
+			Values [][2]interface{} `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func NewPrometheusClient(serverURL string) *PrometheusClient {
+	return &PrometheusClient{
+		client:    &http.Client{Timeout: defaultQueryTimeout},
+		serverURL: strings.TrimSuffix(serverURL, "/"),
+		cache:     make(map[string]*queryResult),
+	}
+}
+
+// QueryContainerValues runs the given range query over the last step, and returns the latest value of each
+// series by the metric id of the container it belongs to, i.e., <namespace>/<pod>/<container>. The series
+// which are not labeled with the namespace, pod and container names are ignored.
+func (c *PrometheusClient) QueryContainerValues(query string, step time.Duration) (map[string]float64, error) {
+	return c.queryValues(query, step, containerMetricId)
+}
+
+// QueryServiceValues runs the given range query over the last step, and returns the latest value of each series
+// by the <namespace>/<name> of the Kubernetes service it belongs to, as labeled by the Istio standard metrics. The
+// series which are not labeled with the destination service namespace and name are ignored.
+func (c *PrometheusClient) QueryServiceValues(query string, step time.Duration) (map[string]float64, error) {
+	return c.queryValues(query, step, serviceId)
+}
+
+// queryValues runs the given range query over the last step, and returns the latest value of each series by the
+// key of its labels, skipping the series without a key and the values which are not numbers, e.g. the ratios of
+// the rates of the series without samples in the range.
+func (c *PrometheusClient) queryValues(query string, step time.Duration,
+	keyFunc func(labels map[string]string) (string, bool)) (map[string]float64, error) {
+	// The lock is held during the query, so that the concurrent workers wait for the result and reuse it
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+	if result, found := c.cache[query]; found && time.Since(result.timestamp) < resultCacheTTL {
+		return result.values, nil
+	}
+	end := time.Now()
+	response, err := c.queryRange(query, end.Add(-step), end, step)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64)
+	for _, series := range response.Data.Result {
+		key, ok := keyFunc(series.Metric)
+		if !ok || len(series.Values) == 0 {
+			continue
+		}
+		latest := series.Values[len(series.Values)-1]
+		valueStr, ok := latest[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			glog.V(3).Infof("Invalid value %q of %s returned by query %q: %v", valueStr, key, query, err)
+			continue
+		}
+		values[key] = value
+	}
+	glog.V(3).Infof("Query %q returned %d values.", query, len(values))
+	c.cache[query] = &queryResult{values: values, timestamp: end}
+	return values, nil
+}
+
+func (c *PrometheusClient) queryRange(query string, start, end time.Time, step time.Duration) (*queryResponse, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	requestURL := c.serverURL + queryRangePath + "?" + params.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultQueryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus server %s: %w", c.serverURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body - %v", err)
+	}
+	response := &queryResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("failed to parse the response of Prometheus server %s - %q: %v",
+			c.serverURL, resp.Status, err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("query %q failed - %q: %s: %s", query, resp.Status, response.ErrorType, response.Error)
+	}
+	if response.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("query %q returned unexpected result type %s", query, response.Data.ResultType)
+	}
+	return response, nil
+}
+
+// containerMetricId returns the metric id of the container of the given series labels.
+func containerMetricId(labels map[string]string) (string, bool) {
+	namespace := labels["namespace"]
+	pod := labels["pod"]
+	if pod == "" {
+		pod = labels["pod_name"]
+	}
+	container := labels["container"]
+	if container == "" {
+		container = labels["container_name"]
+	}
+	if namespace == "" || pod == "" || container == "" || container == "POD" {
+		return "", false
+	}
+	return namespace + "/" + pod + "/" + container, true
+}
+
+// serviceId returns the <namespace>/<name> of the destination service of the given series labels.
+func serviceId(labels map[string]string) (string, bool) {
+	namespace := labels["destination_service_namespace"]
+	name := labels["destination_service_name"]
+	if namespace == "" || name == "" || namespace == "unknown" || name == "unknown" {
+		return "", false
+	}
+	return namespace + "/" + name, true
+}
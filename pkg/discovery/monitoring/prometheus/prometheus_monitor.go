@@ -0,0 +1,140 @@
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// PrometheusMonitor collects the cpu and memory usage of the containers and pods running on a node from a
+// Prometheus server, in place of the kubelet, e.g. on the clusters where the kubelet stats are restricted.
+type PrometheusMonitor struct {
+	config *PrometheusMonitorConfig
+
+	metricSink *metrics.EntityMetricSink
+
+	node *api.Node
+	pods []*api.Pod
+}
+
+func NewPrometheusMonitor(config *PrometheusMonitorConfig) (*PrometheusMonitor, error) {
+	if config == nil || config.client == nil {
+		return nil, errors.New("no Prometheus server is configured")
+	}
+	return &PrometheusMonitor{
+		config:     config,
+		metricSink: metrics.NewEntityMetricSink(),
+	}, nil
+}
+
+func (m *PrometheusMonitor) reset() {
+	m.metricSink = metrics.NewEntityMetricSink()
+}
+
+func (m *PrometheusMonitor) GetMonitoringSource() types.MonitoringSource {
+	return types.PrometheusSource
+}
+
+func (m *PrometheusMonitor) ReceiveTask(task *task.Task) {
+	m.reset()
+	m.node = task.Node()
+	m.pods = task.RunningPodList()
+}
+
+func (m *PrometheusMonitor) Do() (*metrics.EntityMetricSink, error) {
+	if m.node == nil {
+		return m.metricSink, errors.New("empty node")
+	}
+	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
+	err := m.RetrieveResourceStat()
+	if err != nil {
+		glog.Errorf("Failed to execute task: %s", err)
+		return m.metricSink, err
+	}
+	glog.V(4).Infof("%s monitor has finished task.", m.GetMonitoringSource())
+	return m.metricSink, nil
+}
+
+// RetrieveResourceStat retrieves the usage of the containers of the running pods of the received node.
+func (m *PrometheusMonitor) RetrieveResourceStat() error {
+	cpuCores, err := m.config.client.QueryContainerValues(m.config.cpuQuery, m.config.step)
+	if err != nil {
+		return fmt.Errorf("failed to query the cpu usage of the containers on node %s: %v", m.node.Name, err)
+	}
+	memoryBytes, err := m.config.client.QueryContainerValues(m.config.memoryQuery, m.config.step)
+	if err != nil {
+		return fmt.Errorf("failed to query the memory usage of the containers on node %s: %v", m.node.Name, err)
+	}
+	timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+	for _, pod := range m.pods {
+		m.parsePodUsage(pod, cpuCores, memoryBytes, timestamp)
+	}
+	glog.V(4).Infof("Finished querying the container usage of node %s.", m.node.Name)
+	return nil
+}
+
+// parsePodUsage generates the same usage metrics of the given pod and of its containers and applications as the
+// kubelet monitor, from the cpu usage in cores and the memory usage in bytes of the containers.
+func (m *PrometheusMonitor) parsePodUsage(pod *api.Pod, cpuCores, memoryBytes map[string]float64, timestamp int64) {
+	podMId := util.PodMetricIdAPI(pod)
+	totalUsedCPU, totalUsedMem := float64(0), float64(0)
+	allMetricsMissing := true
+	for _, container := range pod.Spec.Containers {
+		containerMId := util.ContainerMetricId(podMId, container.Name)
+		cpu, cpuFound := cpuCores[containerMId]
+		memory, memFound := memoryBytes[containerMId]
+		if !cpuFound && !memFound {
+			continue
+		}
+		allMetricsMissing = false
+		cpuUsed := util.MetricUnitToMilli(cpu)
+		memUsed := util.Base2BytesToKilobytes(memory)
+		totalUsedCPU += cpuUsed
+		totalUsedMem += memUsed
+
+		m.genUsedMetrics(metrics.ContainerType, containerMId, cpuUsed, memUsed, timestamp)
+		m.genRequestUsedMetrics(metrics.ContainerType, containerMId, cpuUsed, memUsed, timestamp)
+		m.genUsedMetrics(metrics.ApplicationType, util.ApplicationMetricId(containerMId), cpuUsed, memUsed, timestamp)
+		glog.V(4).Infof("container[%s-%s] cpu/memory usage:%.3f, %.3f", pod.Name, container.Name, cpuUsed, memUsed)
+	}
+	m.genUsedMetrics(metrics.PodType, podMId, totalUsedCPU, totalUsedMem, timestamp)
+	m.metricSink.AddNewMetricEntries(
+		metrics.NewEntityStateMetric(metrics.PodType, podMId, metrics.MetricsAvailability, !allMetricsMissing))
+}
+
+func (m *PrometheusMonitor) genUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64, timestamp int64) {
+	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used,
+		[]metrics.Point{{
+			Value:     cpu,
+			Timestamp: timestamp,
+		}})
+	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used,
+		[]metrics.Point{{
+			Value:     memory,
+			Timestamp: timestamp,
+		}})
+	m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+}
+
+// genRequestUsedMetrics generates used metrics for VCPURequest and VMemRequest commodity
+func (m *PrometheusMonitor) genRequestUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64, timestamp int64) {
+	cpuRequestMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPURequest, metrics.Used,
+		[]metrics.Point{{
+			Value:     cpu,
+			Timestamp: timestamp,
+		}})
+	memRequestMetric := metrics.NewEntityResourceMetric(etype, key, metrics.MemoryRequest, metrics.Used,
+		[]metrics.Point{{
+			Value:     memory,
+			Timestamp: timestamp,
+		}})
+	m.metricSink.AddNewMetricEntries(cpuRequestMetric, memRequestMetric)
+}
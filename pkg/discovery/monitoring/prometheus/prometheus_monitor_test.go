@@ -0,0 +1,141 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+)
+
+const (
+	cpuResponse = `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"namespace":"ns","pod":"app-1","container":"app"},"values":[[1629775284,"0.2"],[1629775344,"0.5"]]},
+{"metric":{"namespace":"ns","pod":"app-1","container":"sidecar"},"values":[[1629775344,"0.1"]]},
+{"metric":{"namespace":"ns","pod":"app-1","container":"POD"},"values":[[1629775344,"0.01"]]},
+{"metric":{"namespace":"ns","pod":"other-1","container":"other"},"values":[[1629775344,"1"]]}]}}`
+	transactionResponse = `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"destination_service_namespace":"ns","destination_service_name":"web"},"values":[[1629775344,"12.5"]]},
+{"metric":{"destination_service_namespace":"unknown","destination_service_name":"unknown"},"values":[[1629775344,"3"]]}]}}`
+	responseTimeResponse = `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"destination_service_namespace":"ns","destination_service_name":"web"},"values":[[1629775344,"42"]]},
+{"metric":{"destination_service_namespace":"ns","destination_service_name":"idle"},"values":[[1629775344,"NaN"]]}]}}`
+	memoryResponse = `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"namespace":"ns","pod":"app-1","container":"app"},"values":[[1629775344,"1048576"]]}]}}`
+)
+
+func newTestServer(t *testing.T, queries *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, queryRangePath, r.URL.Path)
+		assert.Equal(t, "60", r.URL.Query().Get("step"))
+		*queries++
+		switch r.URL.Query().Get("query") {
+		case DefaultCPUQuery:
+			w.Write([]byte(cpuResponse))
+		case DefaultMemoryQuery:
+			w.Write([]byte(memoryResponse))
+		case DefaultTransactionQuery:
+			w.Write([]byte(transactionResponse))
+		case DefaultResponseTimeQuery:
+			w.Write([]byte(responseTimeResponse))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"parse error"}`))
+		}
+	}))
+}
+
+func TestQueryContainerValues(t *testing.T) {
+	queries := 0
+	server := newTestServer(t, &queries)
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL + "/")
+	values, err := client.QueryContainerValues(DefaultCPUQuery, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{
+		"ns/app-1/app":     0.5,
+		"ns/app-1/sidecar": 0.1,
+		"ns/other-1/other": 1,
+	}, values)
+
+	// The result is reused by the following queries
+	_, err = client.QueryContainerValues(DefaultCPUQuery, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, queries)
+
+	_, err = client.QueryContainerValues("invalid{", time.Minute)
+	assert.NotNil(t, err)
+}
+
+func TestServiceMeshCollector(t *testing.T) {
+	queries := 0
+	server := newTestServer(t, &queries)
+	defer server.Close()
+
+	meshMetrics, err := NewServiceMeshCollector(server.URL).Collect()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{"ns/web": 12.5}, meshMetrics.Transactions)
+	// The services without requests have no response time
+	assert.Equal(t, map[string]float64{"ns/web": 42}, meshMetrics.ResponseTimes)
+	assert.Equal(t, 2, queries)
+
+	_, err = NewServiceMeshCollector(server.URL).WithQueries("bad query", "").Collect()
+	assert.NotNil(t, err)
+}
+
+func TestPrometheusMonitor(t *testing.T) {
+	queries := 0
+	server := newTestServer(t, &queries)
+	defer server.Close()
+
+	monitor, err := NewPrometheusMonitor(NewPrometheusMonitorConfig(server.URL).WithStep(time.Minute))
+	assert.Nil(t, err)
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1", Namespace: "ns"},
+		Spec: api.PodSpec{
+			Containers: []api.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+	}
+	missing := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-2", Namespace: "ns"},
+		Spec: api.PodSpec{
+			Containers: []api.Container{{Name: "app"}},
+		},
+	}
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	monitor.ReceiveTask(task.NewTask().WithNode(node).WithRunningPods([]*api.Pod{pod, missing}))
+	sink, err := monitor.Do()
+	assert.Nil(t, err)
+
+	for key, expected := range map[string]float64{
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "ns/app-1/app", metrics.CPU, metrics.Used):              500,
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "ns/app-1/app", metrics.Memory, metrics.Used):           1024,
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "ns/app-1/app", metrics.CPURequest, metrics.Used):       500,
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "ns/app-1/sidecar", metrics.CPU, metrics.Used):          100,
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "ns/app-1/sidecar", metrics.Memory, metrics.Used):       0,
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, "ns/app-1", metrics.CPU, metrics.Used):                        600,
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, "ns/app-1", metrics.Memory, metrics.Used):                     1024,
+		metrics.GenerateEntityResourceMetricUID(metrics.ApplicationType, "App-ns/app-1/app", metrics.CPU, metrics.Used):        500,
+		metrics.GenerateEntityResourceMetricUID(metrics.ApplicationType, "App-ns/app-1/sidecar", metrics.Memory, metrics.Used): 0,
+	} {
+		metric, err := sink.GetMetric(key)
+		if assert.Nil(t, err, key) {
+			assert.InDelta(t, expected, metric.GetValue().([]metrics.Point)[0].Value, 0.001, key)
+		}
+	}
+	available, err := sink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.PodType, "ns/app-1", metrics.MetricsAvailability))
+	assert.Nil(t, err)
+	assert.Equal(t, true, available.GetValue())
+	available, err = sink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.PodType, "ns/app-2", metrics.MetricsAvailability))
+	assert.Nil(t, err)
+	assert.Equal(t, false, available.GetValue())
+	_, err = sink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "ns/other-1/other", metrics.CPU, metrics.Used))
+	assert.NotNil(t, err)
+}
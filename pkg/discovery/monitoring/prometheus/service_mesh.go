@@ -0,0 +1,84 @@
+package prometheus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// DefaultTransactionQuery is the default query of the rate of the requests received by the services of the
+	// Istio service mesh, in requests per second, as reported by the Envoy sidecars of their pods.
+	DefaultTransactionQuery = `sum by (destination_service_namespace, destination_service_name) ` +
+		`(rate(istio_requests_total{reporter="destination"}[5m]))`
+	// DefaultResponseTimeQuery is the default query of the mean latency of the requests received by the services
+	// of the Istio service mesh, in milliseconds, as reported by the Envoy sidecars of their pods.
+	DefaultResponseTimeQuery = `sum by (destination_service_namespace, destination_service_name) ` +
+		`(rate(istio_request_duration_milliseconds_sum{reporter="destination"}[5m])) / ` +
+		`sum by (destination_service_namespace, destination_service_name) ` +
+		`(rate(istio_request_duration_milliseconds_count{reporter="destination"}[5m]))`
+)
+
+// ServiceMeshMetrics is the request rate in requests per second and the response time in milliseconds of the
+// services of the service mesh, by the <namespace>/<name> of the services.
+type ServiceMeshMetrics struct {
+	Transactions  map[string]float64
+	ResponseTimes map[string]float64
+}
+
+// ServiceMeshCollector collects the request rate and the latency of the services of the Istio service mesh from
+// the metrics which the Envoy sidecars export to a Prometheus server.
+type ServiceMeshCollector struct {
+	client *PrometheusClient
+	// The queries of the request rate and of the response time of the services. The result series must be labeled
+	// with the destination_service_namespace and destination_service_name of the services.
+	transactionQuery  string
+	responseTimeQuery string
+	// The resolution of the range queries; the latest sample within the last step is used
+	step time.Duration
+}
+
+func NewServiceMeshCollector(serverURL string) *ServiceMeshCollector {
+	return &ServiceMeshCollector{
+		client:            NewPrometheusClient(serverURL),
+		transactionQuery:  DefaultTransactionQuery,
+		responseTimeQuery: DefaultResponseTimeQuery,
+		step:              DefaultQueryStep,
+	}
+}
+
+func (c *ServiceMeshCollector) WithQueries(transactionQuery, responseTimeQuery string) *ServiceMeshCollector {
+	if transactionQuery != "" {
+		c.transactionQuery = transactionQuery
+	}
+	if responseTimeQuery != "" {
+		c.responseTimeQuery = responseTimeQuery
+	}
+	return c
+}
+
+func (c *ServiceMeshCollector) WithStep(step time.Duration) *ServiceMeshCollector {
+	if step > 0 {
+		c.step = step
+	}
+	return c
+}
+
+// Collect queries the request rate and the response time of the services.
+func (c *ServiceMeshCollector) Collect() (*ServiceMeshMetrics, error) {
+	transactions, err := c.client.QueryServiceValues(c.transactionQuery, c.step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the request rate of the services: %v", err)
+	}
+	responseTimes, err := c.client.QueryServiceValues(c.responseTimeQuery, c.step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the response time of the services: %v", err)
+	}
+	glog.V(2).Infof("Collected the request rate of %d services and the response time of %d services "+
+		"of the service mesh.", len(transactions), len(responseTimes))
+	return &ServiceMeshMetrics{
+		Transactions:  transactions,
+		ResponseTimes: responseTimes,
+	}, nil
+}
@@ -3,11 +3,12 @@ package types
 type MonitoringSource string
 
 const (
-	KubeletSource      MonitoringSource = "Kubelet"
-	K8sConntrackSource MonitoringSource = "K8sConntrack"
-	ClusterSource      MonitoringSource = "Cluster"
-	PrometheusSource   MonitoringSource = "Prometheus"
-	DummySource        MonitoringSource = "Dummy" //Testing only
+	KubeletSource       MonitoringSource = "Kubelet"
+	K8sConntrackSource  MonitoringSource = "K8sConntrack"
+	ClusterSource       MonitoringSource = "Cluster"
+	PrometheusSource    MonitoringSource = "Prometheus"
+	MetricsServerSource MonitoringSource = "MetricsServer"
+	DummySource         MonitoringSource = "Dummy" //Testing only
 )
 
 type MonitorType string
@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podChangeTracker watches the pods of the cluster, and keeps the pods which have started running or have been
+// deleted since the last discovery, to be reported by the incremental discoveries between the full discoveries.
+type podChangeTracker struct {
+	lock sync.Mutex
+	// The pods known by the server, i.e., reported by the last full discovery or by an incremental discovery since
+	known map[types.UID]bool
+	// The running pods which are not known by the server yet
+	started map[types.UID]*api.Pod
+	// The pods known by the server which have been deleted
+	deleted map[types.UID]*api.Pod
+}
+
+func newPodChangeTracker() *podChangeTracker {
+	return &podChangeTracker{
+		known:   make(map[types.UID]bool),
+		started: make(map[types.UID]*api.Pod),
+		deleted: make(map[types.UID]*api.Pod),
+	}
+}
+
+// Start watches the pods of the cluster in the background until the given channel is closed.
+func (t *podChangeTracker) Start(clientset *client.Clientset, stopCh <-chan struct{}) {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	t.Watch(factory.Core().V1().Pods().Informer())
+	factory.Start(stopCh)
+}
+
+// Watch tracks the pods of the given informer, e.g. the pod informer shared with the informer cache of discovery.
+func (t *podChangeTracker) Watch(informer cache.SharedIndexInformer) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    t.onAddOrUpdate,
+		UpdateFunc: func(_, obj interface{}) { t.onAddOrUpdate(obj) },
+		DeleteFunc: t.onDelete,
+	})
+}
+
+// Reset replaces the known pods with the pods reported by a full discovery. The changes of the pods which are not
+// reflected in the full discovery, i.e., the pods started or deleted after the pods were listed, are kept.
+func (t *podChangeTracker) Reset(pods []*api.Pod) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.known = make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		t.known[pod.UID] = true
+		delete(t.started, pod.UID)
+	}
+	for uid := range t.deleted {
+		if !t.known[uid] {
+			delete(t.deleted, uid)
+		}
+	}
+}
+
+// Changes returns the pods which have started running and the pods which have been deleted since the last
+// discovery, and marks them as reported.
+func (t *podChangeTracker) Changes() ([]*api.Pod, []*api.Pod) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var started, deleted []*api.Pod
+	for uid, pod := range t.started {
+		started = append(started, pod)
+		t.known[uid] = true
+	}
+	for uid, pod := range t.deleted {
+		deleted = append(deleted, pod)
+		delete(t.known, uid)
+	}
+	t.started = make(map[types.UID]*api.Pod)
+	t.deleted = make(map[types.UID]*api.Pod)
+	return started, deleted
+}
+
+func (t *podChangeTracker) onAddOrUpdate(obj interface{}) {
+	pod, ok := obj.(*api.Pod)
+	if !ok || pod.Spec.NodeName == "" || pod.Status.Phase != api.PodRunning {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.known[pod.UID] {
+		return
+	}
+	if _, exists := t.started[pod.UID]; !exists {
+		glog.V(3).Infof("Pod %s/%s has started running on node %s.", pod.Namespace, pod.Name, pod.Spec.NodeName)
+	}
+	t.started[pod.UID] = pod
+}
+
+func (t *podChangeTracker) onDelete(obj interface{}) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		// The final state of the pod is unknown if the watch missed the deletion
+		tombstone, isTombstone := obj.(cache.DeletedFinalStateUnknown)
+		if !isTombstone {
+			return
+		}
+		if pod, ok = tombstone.Obj.(*api.Pod); !ok {
+			return
+		}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.started, pod.UID)
+	if t.known[pod.UID] {
+		glog.V(3).Infof("Pod %s/%s has been deleted.", pod.Namespace, pod.Name)
+		t.deleted[pod.UID] = pod
+	}
+}
@@ -0,0 +1,119 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+func newTrackedPod(name, nodeName string, phase api.PodPhase) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "-uid")},
+		Spec:       api.PodSpec{NodeName: nodeName},
+		Status:     api.PodStatus{Phase: phase},
+	}
+}
+
+func TestPodChangeTracker(t *testing.T) {
+	known := newTrackedPod("known", "node-1", api.PodRunning)
+	gone := newTrackedPod("gone", "node-1", api.PodRunning)
+	pending := newTrackedPod("pending", "node-1", api.PodPending)
+	started := newTrackedPod("started", "node-2", api.PodRunning)
+
+	tracker := newPodChangeTracker()
+	// The pods listed by the informer before the full discovery are known
+	tracker.onAddOrUpdate(known)
+	tracker.onAddOrUpdate(gone)
+	tracker.Reset([]*api.Pod{known, gone})
+
+	tracker.onAddOrUpdate(known)
+	tracker.onAddOrUpdate(pending)
+	tracker.onAddOrUpdate(started)
+	tracker.onDelete(cache.DeletedFinalStateUnknown{Key: "ns/gone", Obj: gone})
+	newPods, deletedPods := tracker.Changes()
+	assert.Equal(t, []*api.Pod{started}, newPods)
+	assert.Equal(t, []*api.Pod{gone}, deletedPods)
+
+	// The reported changes are not reported again
+	tracker.onAddOrUpdate(started)
+	newPods, deletedPods = tracker.Changes()
+	assert.Empty(t, newPods)
+	assert.Empty(t, deletedPods)
+
+	// A pod started and deleted between two discoveries is not reported
+	pending.Status.Phase = api.PodRunning
+	tracker.onAddOrUpdate(pending)
+	tracker.onDelete(pending)
+	newPods, deletedPods = tracker.Changes()
+	assert.Empty(t, newPods)
+	assert.Empty(t, deletedPods)
+}
+
+func TestPodChangeTrackerReset(t *testing.T) {
+	listed := newTrackedPod("listed", "node-1", api.PodRunning)
+	later := newTrackedPod("later", "node-1", api.PodRunning)
+
+	tracker := newPodChangeTracker()
+	tracker.Reset([]*api.Pod{listed})
+	tracker.onDelete(listed)
+	// The pod started after the pods are listed by the next full discovery is kept, while the pod deleted before
+	// is not reported as it is not known by the server anymore
+	tracker.onAddOrUpdate(later)
+	tracker.Reset(nil)
+	newPods, deletedPods := tracker.Changes()
+	assert.Equal(t, []*api.Pod{later}, newPods)
+	assert.Empty(t, deletedPods)
+}
+
+func TestGroupPodsByNode(t *testing.T) {
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	clusterSummary := &repository.ClusterSummary{
+		NodeMap: map[string]*repository.KubeNode{"node-1": {Node: node}},
+	}
+	pod1 := newTrackedPod("pod-1", "node-1", api.PodRunning)
+	pod2 := newTrackedPod("pod-2", "node-1", api.PodRunning)
+	unknown := newTrackedPod("pod-3", "node-2", api.PodRunning)
+
+	nodes, nodeRunningPods := groupPodsByNode([]*api.Pod{pod1, unknown, pod2}, clusterSummary)
+	assert.Equal(t, []*api.Node{node}, nodes)
+	assert.Equal(t, map[string][]*api.Pod{"node-1": {pod1, pod2}}, nodeRunningPods)
+}
+
+func TestResetIncrementalDiscovery(t *testing.T) {
+	dc := &K8sDiscoveryClient{
+		podChangeTracker:   newPodChangeTracker(),
+		lastClusterSummary: &repository.ClusterSummary{},
+	}
+	dc.ResetIncrementalDiscovery()
+	assert.Nil(t, dc.lastClusterSummary)
+
+	// No incremental discovery is reported until the next full discovery
+	dc.podChangeTracker.onAddOrUpdate(newTrackedPod("pod-1", "node-1", api.PodRunning))
+	response, err := dc.DiscoverIncremental(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, response.GetEntityDTO())
+}
+
+func TestNewDeletedPodDTOs(t *testing.T) {
+	pod := newTrackedPod("pod-1", "node-1", api.PodRunning)
+	pod.Spec.Containers = []api.Container{{Name: "app"}, {Name: "sidecar"}}
+	entityDTOs := newDeletedPodDTOs(pod)
+	var ids []string
+	for _, entityDTO := range entityDTOs {
+		assert.Equal(t, proto.UpdateType_DELETED, entityDTO.GetUpdateType())
+		ids = append(ids, entityDTO.GetId())
+	}
+	assert.Equal(t, []string{"pod-1-uid", "pod-1-uid-0", "App-pod-1-uid-0", "pod-1-uid-1", "App-pod-1-uid-1"}, ids)
+	assert.Equal(t, proto.EntityDTO_CONTAINER_POD, entityDTOs[0].GetEntityType())
+	assert.Equal(t, "ns/pod-1", entityDTOs[0].GetDisplayName())
+	assert.Equal(t, proto.EntityDTO_CONTAINER, entityDTOs[1].GetEntityType())
+	assert.Equal(t, "ns/pod-1/app", entityDTOs[1].GetDisplayName())
+	assert.Equal(t, proto.EntityDTO_APPLICATION_COMPONENT, entityDTOs[2].GetEntityType())
+}
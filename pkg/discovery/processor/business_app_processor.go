@@ -24,6 +24,9 @@ import (
 type BusinessAppProcessor struct {
 	ClusterScraper cluster.ClusterScraperInterface
 	KubeCluster    *repository.KubeCluster
+	// The label used to group workload controllers, services and pods into business applications,
+	// for example app.kubernetes.io/part-of. Label based grouping is disabled if it is empty.
+	businessAppLabel string
 }
 
 func NewBusinessAppProcessor(clusterScraper cluster.ClusterScraperInterface,
@@ -34,6 +37,11 @@ func NewBusinessAppProcessor(clusterScraper cluster.ClusterScraperInterface,
 	}
 }
 
+func (p *BusinessAppProcessor) WithBusinessAppLabel(businessAppLabel string) *BusinessAppProcessor {
+	p.businessAppLabel = businessAppLabel
+	return p
+}
+
 func (p *BusinessAppProcessor) ProcessBusinessApps() {
 	resources := []schema.GroupVersionResource{
 		{
@@ -61,6 +69,14 @@ func (p *BusinessAppProcessor) ProcessBusinessApps() {
 			}
 		}
 	}
+	if p.businessAppLabel != "" {
+		if p.KubeCluster.K8sAppToComponentMap == nil {
+			p.KubeCluster.K8sAppToComponentMap = make(map[repository.K8sApp][]repository.K8sAppComponent)
+		}
+		for key, val := range p.ProcessLabeledApps() {
+			p.KubeCluster.K8sAppToComponentMap[key] = val
+		}
+	}
 	p.KubeCluster.ComponentToAppMap = inverseAppToComponentMap(p.KubeCluster.K8sAppToComponentMap)
 
 }
@@ -113,6 +129,66 @@ func (p *BusinessAppProcessor) ProcessAppsOfType(res schema.GroupVersionResource
 	return appToComponentMap
 }
 
+// ProcessLabeledApps groups the workload controllers, services and pods of the cluster into business
+// applications by the value of the configured business app label. Resources in different namespaces
+// with the same label value belong to different applications. Resources without the label are not
+// grouped into any application.
+func (p *BusinessAppProcessor) ProcessLabeledApps() map[repository.K8sApp][]repository.K8sAppComponent {
+	appToComponentMap := make(map[repository.K8sApp][]repository.K8sAppComponent)
+	labelKey := p.businessAppLabel
+	if labelKey == "" {
+		return appToComponentMap
+	}
+	ungrouped := 0
+	addComponent := func(labels map[string]string, component repository.K8sAppComponent) {
+		appName, found := labels[labelKey]
+		if !found || appName == "" {
+			ungrouped++
+			return
+		}
+		app := repository.K8sApp{
+			Uid:       labeledAppUid(p.KubeCluster.Name, component.Namespace, labelKey, appName),
+			Namespace: component.Namespace,
+			Name:      appName,
+			Type:      repository.AppTypeLabel,
+		}
+		appToComponentMap[app] = append(appToComponentMap[app], component)
+	}
+
+	for _, controller := range p.KubeCluster.ControllerMap {
+		addComponent(controller.Labels, repository.K8sAppComponent{
+			EntityType: proto.EntityDTO_WORKLOAD_CONTROLLER,
+			Uid:        controller.UID,
+			Namespace:  controller.Namespace,
+			Name:       controller.Name,
+		})
+	}
+	for svc := range p.KubeCluster.Services {
+		addComponent(svc.Labels, repository.K8sAppComponent{
+			EntityType: proto.EntityDTO_SERVICE,
+			Uid:        string(svc.UID),
+			Namespace:  svc.Namespace,
+			Name:       svc.Name,
+		})
+	}
+	for _, pod := range p.KubeCluster.Pods {
+		addComponent(pod.Labels, repository.K8sAppComponent{
+			EntityType: proto.EntityDTO_CONTAINER_POD,
+			Uid:        string(pod.UID),
+			Namespace:  pod.Namespace,
+			Name:       pod.Name,
+		})
+	}
+	glog.V(2).Infof("Discovered %d business apps by label %s, %d resources are not grouped into any app.",
+		len(appToComponentMap), labelKey, ungrouped)
+	return appToComponentMap
+}
+
+// labeledAppUid builds a cluster unique id for a business app discovered by label.
+func labeledAppUid(clusterName, namespace, labelKey, appName string) string {
+	return fmt.Sprintf("%s/%s/%s=%s", clusterName, namespace, labelKey, appName)
+}
+
 func (p *BusinessAppProcessor) getK8sAppEntities(unstructuredApp unstructured.Unstructured) []repository.K8sAppComponent {
 	app := appv1beta1.Application{}
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredApp.Object, &app); err != nil {
@@ -187,7 +263,8 @@ func (p *BusinessAppProcessor) getArgoCDAppEntities(unstructuredApp unstructured
 	return allEntities, nil
 }
 
-func renderTypeInfo(gk metav1.GroupKind) (schema.GroupVersionResource, proto.EntityDTO_EntityType, error) {
+func renderTypeInfo(controllerGVs util.ControllerGroupVersions,
+	gk metav1.GroupKind) (schema.GroupVersionResource, proto.EntityDTO_EntityType, error) {
 	var res schema.GroupVersionResource
 	var entityType proto.EntityDTO_EntityType
 	switch gk.String() {
@@ -200,16 +277,10 @@ func renderTypeInfo(gk metav1.GroupKind) (schema.GroupVersionResource, proto.Ent
 			Resource: "statefulsets"}
 		entityType = proto.EntityDTO_WORKLOAD_CONTROLLER
 	case "Deployment.apps":
-		res = schema.GroupVersionResource{
-			Group:    util.K8sAPIDeploymentGV.Group,
-			Version:  util.K8sAPIDeploymentGV.Version,
-			Resource: util.DeploymentResName}
+		res = controllerGVs.DeploymentResource()
 		entityType = proto.EntityDTO_WORKLOAD_CONTROLLER
 	case "ReplicaSet.apps":
-		res = schema.GroupVersionResource{
-			Group:    util.K8sAPIReplicasetGV.Group,
-			Version:  util.K8sAPIReplicasetGV.Version,
-			Resource: util.ReplicaSetResName}
+		res = controllerGVs.ReplicaSetResource()
 		entityType = proto.EntityDTO_WORKLOAD_CONTROLLER
 	case "DaemonSet.apps":
 		res = schema.GroupVersionResource{
@@ -257,11 +328,12 @@ func renderTypeInfo(gk metav1.GroupKind) (schema.GroupVersionResource, proto.Ent
 }
 
 func (p *BusinessAppProcessor) getEntitiesViaSelector(selector *metav1.LabelSelector, gk metav1.GroupKind, namespace string) ([]repository.K8sAppComponent, error) {
-	res, entityType, err := renderTypeInfo(gk)
+	clusterScraper := p.ClusterScraper.(*cluster.ClusterScraper)
+	res, entityType, err := renderTypeInfo(clusterScraper.ControllerGroupVersions(), gk)
 	if err != nil {
 		return nil, err
 	}
-	dynClient := p.ClusterScraper.(*cluster.ClusterScraper).DynamicClient
+	dynClient := clusterScraper.DynamicClient
 	resourceList, err := dynClient.Resource(res).Namespace(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labels.Set(selector.MatchLabels).String()})
 	if err != nil {
 		return nil, err
@@ -283,11 +355,12 @@ func (p *BusinessAppProcessor) getEntitiesViaSelector(selector *metav1.LabelSele
 }
 
 func (p *BusinessAppProcessor) getEntity(gk metav1.GroupKind, name, namespace string) (*repository.K8sAppComponent, error) {
-	res, entityType, err := renderTypeInfo(gk)
+	clusterScraper := p.ClusterScraper.(*cluster.ClusterScraper)
+	res, entityType, err := renderTypeInfo(clusterScraper.ControllerGroupVersions(), gk)
 	if err != nil {
 		return nil, err
 	}
-	dynClient := p.ClusterScraper.(*cluster.ClusterScraper).DynamicClient
+	dynClient := clusterScraper.DynamicClient
 	object, err := dynClient.Resource(res).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/k8sappcomponents"
+)
+
+const partOfLabel = "app.kubernetes.io/part-of"
+
+func newLabeledPod(name, namespace string, labels map[string]string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(name + "-uid"),
+			Labels:    labels,
+		},
+	}
+}
+
+func newLabeledAppCluster() *repository.KubeCluster {
+	kubeCluster := repository.NewKubeCluster("cluster-1", nil).WithPods([]*api.Pod{
+		newLabeledPod("shop-pod-1", "ns1", map[string]string{partOfLabel: "shop"}),
+		newLabeledPod("shop-pod-2", "ns1", map[string]string{partOfLabel: "shop"}),
+		newLabeledPod("billing-pod", "ns1", map[string]string{partOfLabel: "billing"}),
+		newLabeledPod("shop-pod-other-ns", "ns2", map[string]string{partOfLabel: "shop"}),
+		newLabeledPod("lonely-pod", "ns1", map[string]string{"app": "lonely"}),
+	})
+	kubeCluster.ControllerMap = map[string]*repository.K8sController{
+		"shop-deploy-uid": repository.NewK8sController("Deployment", "shop-deploy", "ns1", "shop-deploy-uid").
+			WithLabels(map[string]string{partOfLabel: "shop"}),
+		"lonely-deploy-uid": repository.NewK8sController("Deployment", "lonely-deploy", "ns1", "lonely-deploy-uid"),
+	}
+	kubeCluster.Services = map[*api.Service][]string{
+		{ObjectMeta: metav1.ObjectMeta{Name: "shop-svc", Namespace: "ns1", UID: "shop-svc-uid",
+			Labels: map[string]string{partOfLabel: "shop"}}}: nil,
+	}
+	return kubeCluster
+}
+
+func TestProcessLabeledApps(t *testing.T) {
+	kubeCluster := newLabeledAppCluster()
+	apps := NewBusinessAppProcessor(nil, kubeCluster).WithBusinessAppLabel(partOfLabel).ProcessLabeledApps()
+	assert.Equal(t, 3, len(apps))
+
+	appMembers := make(map[string][]string)
+	for app, components := range apps {
+		assert.Equal(t, repository.AppTypeLabel, app.Type)
+		for _, component := range components {
+			assert.Equal(t, app.Namespace, component.Namespace)
+			appMembers[app.Namespace+"/"+app.Name] = append(appMembers[app.Namespace+"/"+app.Name], component.Uid)
+		}
+	}
+	assert.ElementsMatch(t, []string{"shop-deploy-uid", "shop-svc-uid", "shop-pod-1-uid", "shop-pod-2-uid"},
+		appMembers["ns1/shop"])
+	assert.ElementsMatch(t, []string{"billing-pod-uid"}, appMembers["ns1/billing"])
+	assert.ElementsMatch(t, []string{"shop-pod-other-ns-uid"}, appMembers["ns2/shop"])
+}
+
+func TestProcessLabeledAppsComponentTypes(t *testing.T) {
+	kubeCluster := newLabeledAppCluster()
+	apps := NewBusinessAppProcessor(nil, kubeCluster).WithBusinessAppLabel(partOfLabel).ProcessLabeledApps()
+	entityTypes := make(map[string]proto.EntityDTO_EntityType)
+	for _, components := range apps {
+		for _, component := range components {
+			entityTypes[component.Uid] = component.EntityType
+		}
+	}
+	assert.Equal(t, proto.EntityDTO_WORKLOAD_CONTROLLER, entityTypes["shop-deploy-uid"])
+	assert.Equal(t, proto.EntityDTO_SERVICE, entityTypes["shop-svc-uid"])
+	assert.Equal(t, proto.EntityDTO_CONTAINER_POD, entityTypes["shop-pod-1-uid"])
+	// Resources without the label are not grouped
+	_, found := entityTypes["lonely-pod-uid"]
+	assert.False(t, found)
+	_, found = entityTypes["lonely-deploy-uid"]
+	assert.False(t, found)
+}
+
+func TestProcessBusinessAppsByLabel(t *testing.T) {
+	kubeCluster := newLabeledAppCluster()
+	NewBusinessAppProcessor(nil, kubeCluster).WithBusinessAppLabel(partOfLabel).ProcessBusinessApps()
+	assert.Equal(t, 3, len(kubeCluster.K8sAppToComponentMap))
+	component := repository.K8sAppComponent{
+		EntityType: proto.EntityDTO_CONTAINER_POD,
+		Uid:        "shop-pod-1-uid",
+		Namespace:  "ns1",
+		Name:       "shop-pod-1",
+	}
+	assert.Equal(t, 1, len(kubeCluster.ComponentToAppMap[component]))
+	assert.Equal(t, "shop", kubeCluster.ComponentToAppMap[component][0].Name)
+}
+
+func TestProcessBusinessAppsWithoutLabel(t *testing.T) {
+	kubeCluster := newLabeledAppCluster()
+	NewBusinessAppProcessor(nil, kubeCluster).ProcessBusinessApps()
+	assert.Equal(t, 0, len(kubeCluster.K8sAppToComponentMap))
+}
+
+func TestLabeledAppDTOs(t *testing.T) {
+	kubeCluster := newLabeledAppCluster()
+	NewBusinessAppProcessor(nil, kubeCluster).WithBusinessAppLabel(partOfLabel).ProcessBusinessApps()
+	podID := "shop-pod-1-uid"
+	podDTO := &proto.EntityDTO{Id: &podID, EntityType: proto.EntityDTO_CONTAINER_POD.Enum()}
+	k8sappcomponents.NewK8sAppComponentsProcessor(kubeCluster.ComponentToAppMap).
+		ProcessAppComponentDTOs([]*proto.EntityDTO{podDTO})
+	if !assert.Len(t, podDTO.GetCommoditiesSold(), 1) {
+		return
+	}
+
+	// The business app buys the application commodity sold by its component with the same key
+	var bought []string
+	for _, appDTO := range dtofactory.NewBusinessAppEntityDTOBuilder(kubeCluster.K8sAppToComponentMap).BuildEntityDTOs() {
+		for _, commBought := range appDTO.GetCommoditiesBought() {
+			if commBought.GetProviderId() == podDTO.GetId() {
+				assert.Equal(t, "ns1/shop", appDTO.GetDisplayName())
+				for _, commodity := range commBought.GetBought() {
+					bought = append(bought, commodity.GetKey())
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{podDTO.GetCommoditiesSold()[0].GetKey()}, bought)
+}
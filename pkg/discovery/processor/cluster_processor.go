@@ -41,6 +41,9 @@ type ClusterProcessor struct {
 	nodeScrapper       kubeclient.KubeHttpClientInterface
 	isValidated        bool
 	itemsPerListQuery  int
+	businessAppLabel   string
+	// Whether to discover the workload controllers resized by the VerticalPodAutoscalers
+	discoverVPAs bool
 }
 
 func NewClusterProcessor(
@@ -64,6 +67,18 @@ func NewClusterProcessor(
 	return clusterProcessor
 }
 
+// WithBusinessAppLabel sets the label used to group resources into business applications.
+func (p *ClusterProcessor) WithBusinessAppLabel(businessAppLabel string) *ClusterProcessor {
+	p.businessAppLabel = businessAppLabel
+	return p
+}
+
+// WithVPADiscovery sets whether to discover the workload controllers resized by the VerticalPodAutoscalers.
+func (p *ClusterProcessor) WithVPADiscovery(discoverVPAs bool) *ClusterProcessor {
+	p.discoverVPAs = discoverVPAs
+	return p
+}
+
 // ConnectCluster connects to the Kubernetes API Server and the nodes in the cluster.
 // ClusterProcessor is updated with the validation result.
 // Return error only if all the nodes in the cluster are unreachable.
@@ -224,11 +239,18 @@ func (p *ClusterProcessor) DiscoverCluster() (*repository.ClusterSummary, error)
 	NewVolumeProcessor(p.clusterInfoScraper, kubeCluster).ProcessVolumes()
 
 	// Discover Business Apps
-	NewBusinessAppProcessor(p.clusterInfoScraper, kubeCluster).ProcessBusinessApps()
+	NewBusinessAppProcessor(p.clusterInfoScraper, kubeCluster).
+		WithBusinessAppLabel(p.businessAppLabel).
+		ProcessBusinessApps()
 
 	// Discover Turbo Policies
 	NewTurboPolicyProcessor(p.clusterInfoScraper, kubeCluster).ProcessTurboPolicies()
 
+	// Discover the workload controllers resized by the VerticalPodAutoscalers
+	if p.discoverVPAs {
+		NewVPAProcessor(p.clusterInfoScraper, kubeCluster).ProcessVPAs()
+	}
+
 	// Update the pod to controller cache
 	if clusterScraper, ok := p.clusterInfoScraper.(*cluster.ClusterScraper); ok {
 		podToControllerMap := clusterScraper.UpdatePodControllerCache(kubeCluster.Pods, kubeCluster.ControllerMap)
@@ -8,6 +8,7 @@ import (
 	cadvisorapi "github.com/google/cadvisor/info/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/util"
 	gitopsv1alpha1 "github.com/turbonomic/turbo-gitops/api/v1alpha1"
 	policyv1alpha1 "github.com/turbonomic/turbo-policy/api/v1alpha1"
 	v1 "k8s.io/api/core/v1"
@@ -277,6 +278,7 @@ type MockClusterScrapper struct {
 	mockGetAllNodes                func() ([]*v1.Node, error)
 	mockGetNamespaces              func() ([]*v1.Namespace, error)
 	mockGetNamespaceQuotas         func() (map[string][]*v1.ResourceQuota, error)
+	mockGetNamespaceLimitRanges    func() (map[string][]*v1.LimitRange, error)
 	mockGetAllPods                 func() ([]*v1.Pod, error)
 	mockGetAllEndpoints            func() ([]*v1.Endpoints, error)
 	mockGetAllServices             func() ([]*v1.Service, error)
@@ -288,6 +290,8 @@ type MockClusterScrapper struct {
 	mockGetAllTurboPolicyBindings  func() ([]policyv1alpha1.PolicyBinding, error)
 	mockGetAllGitOpsConfigurations func() ([]gitopsv1alpha1.GitOps, error)
 	mockUpdateGitOpsConfigCache    func()
+	mockGetOwnerReferences         func(namespace string, owner metav1.OwnerReference) ([]metav1.OwnerReference, error)
+	mockGetResources               func(resource schema.GroupVersionResource) ([]unstructured.Unstructured, error)
 }
 
 func (s *MockClusterScrapper) GetAllTurboSLOScalings() ([]policyv1alpha1.SLOHorizontalScale, error) {
@@ -350,6 +354,12 @@ func (s *MockClusterScrapper) GetNamespaceQuotas() (map[string][]*v1.ResourceQuo
 	}
 	return nil, fmt.Errorf("GetNamespaceQuotas Not implemented")
 }
+func (s *MockClusterScrapper) GetNamespaceLimitRanges() (map[string][]*v1.LimitRange, error) {
+	if s.mockGetNamespaceLimitRanges != nil {
+		return s.mockGetNamespaceLimitRanges()
+	}
+	return nil, fmt.Errorf("GetNamespaceLimitRanges Not implemented")
+}
 func (s *MockClusterScrapper) GetAllPods() ([]*v1.Pod, error) {
 	if s.mockGetAllPods != nil {
 		return s.mockGetAllPods()
@@ -391,7 +401,10 @@ func (s *MockClusterScrapper) GetAllPVCs() ([]*v1.PersistentVolumeClaim, error)
 	return nil, fmt.Errorf("GetAllPVCs Not implemented")
 }
 
-func (s *MockClusterScrapper) GetResources(schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+func (s *MockClusterScrapper) GetResources(resource schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	if s.mockGetResources != nil {
+		return s.mockGetResources(resource)
+	}
 	return []unstructured.Unstructured{}, nil
 }
 
@@ -399,10 +412,22 @@ func (s *MockClusterScrapper) GetResourcesPaginated(schema.GroupVersionResource,
 	return []unstructured.Unstructured{}, nil
 }
 
+func (s *MockClusterScrapper) GetOwnerReferences(namespace string,
+	owner metav1.OwnerReference) ([]metav1.OwnerReference, error) {
+	if s.mockGetOwnerReferences != nil {
+		return s.mockGetOwnerReferences(namespace, owner)
+	}
+	return nil, fmt.Errorf("GetOwnerReferences Not implemented")
+}
+
 func (s *MockClusterScrapper) GetMachineSetToNodesMap(nodes []*v1.Node) map[string][]*v1.Node {
 	return make(map[string][]*v1.Node)
 }
 
+func (s *MockClusterScrapper) ControllerGroupVersions() util.ControllerGroupVersions {
+	return util.DefaultControllerGroupVersions()
+}
+
 // Implements the KubeHttpClientInterface
 // Method implementation will check to see if the test has provided the mockXXX method function
 type MockNodeScrapper struct {
@@ -6,8 +6,11 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/util/feature"
 
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
@@ -17,24 +20,17 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/util"
 )
 
-// Query and cache predefined controllers
-var (
-	supportedControllers = []schema.GroupVersionResource{
+// Query and cache predefined controllers, with the group versions of the deployments and the replicasets of the
+// cluster
+func supportedControllers(controllerGVs util.ControllerGroupVersions) []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
 		{
 			Group:    util.K8sAPIReplicationControllerGV.Group,
 			Version:  util.K8sAPIReplicationControllerGV.Version,
 			Resource: util.ReplicationControllerResName,
 		},
-		{
-			Group:    util.K8sAPIReplicasetGV.Group,
-			Version:  util.K8sAPIReplicasetGV.Version,
-			Resource: util.ReplicaSetResName,
-		},
-		{
-			Group:    util.K8sAPIDeploymentGV.Group,
-			Version:  util.K8sAPIDeploymentGV.Version,
-			Resource: util.DeploymentResName,
-		},
+		controllerGVs.ReplicaSetResource(),
+		controllerGVs.DeploymentResource(),
 		{
 			Group:    util.OpenShiftAPIDeploymentConfigGV.Group,
 			Version:  util.OpenShiftAPIDeploymentConfigGV.Version,
@@ -61,7 +57,10 @@ var (
 			Resource: util.CronJobResName,
 		},
 	}
-)
+}
+
+// The maximum number of the owners walked up from a controller, to stop at unexpectedly long ownership chains
+const maxOwnerChainDepth = 10
 
 type ControllerProcessor struct {
 	ClusterInfoScraper cluster.ClusterScraperInterface
@@ -94,7 +93,7 @@ func (cp *ControllerProcessor) cacheAllControllers() {
 		SortKeys:                true,
 	}
 	controllerMap := make(map[string]*repository.K8sController)
-	for _, controller := range supportedControllers {
+	for _, controller := range supportedControllers(cp.ClusterInfoScraper.ControllerGroupVersions()) {
 		var items []unstructured.Unstructured
 		var err error
 		if feature.DefaultFeatureGate.Enabled(features.GoMemLimit) {
@@ -144,9 +143,61 @@ func (cp *ControllerProcessor) cacheAllControllers() {
 			glog.V(4).Infof("%+v", scs.Sdump(k8sController))
 		}
 	}
+	cp.resolveTopLevelOwners(controllerMap)
 	cp.KubeCluster.ControllerMap = controllerMap
 }
 
+// resolveTopLevelOwners walks the owner references of each controller up to the owner at the top of the chain, e.g.
+// the custom resource of an operator owning a Deployment. The owners which are not cached controllers, of any kind
+// including the custom resources, are looked up once per discovery. The walk stops at the last known owner when an
+// owner cannot be looked up.
+func (cp *ControllerProcessor) resolveTopLevelOwners(controllerMap map[string]*repository.K8sController) {
+	// The owner references of the looked up owners by uid
+	ownerRefsCache := make(map[types.UID][]metav1.OwnerReference)
+	for _, controller := range controllerMap {
+		owner, found := discoveryutil.GetOwnerReference(controller.OwnerReferences)
+		if !found {
+			continue
+		}
+		visited := sets.NewString(controller.UID)
+		for depth := 1; depth < maxOwnerChainDepth && !visited.Has(string(owner.UID)); depth++ {
+			visited.Insert(string(owner.UID))
+			ownerRefs, err := cp.getOwnerReferences(controller.Namespace, owner, controllerMap, ownerRefsCache)
+			if err != nil {
+				glog.Warningf("Failed to resolve the owners of %s %s/%s beyond %s %s: %v", controller.Kind,
+					controller.Namespace, controller.Name, owner.Kind, owner.Name, err)
+				break
+			}
+			next, found := discoveryutil.GetOwnerReference(ownerRefs)
+			if !found {
+				break
+			}
+			owner = next
+		}
+		topLevelOwner := owner
+		controller.WithTopLevelOwner(&topLevelOwner)
+		glog.V(4).Infof("The top-level owner of %s %s/%s is %s %s.", controller.Kind, controller.Namespace,
+			controller.Name, owner.Kind, owner.Name)
+	}
+}
+
+func (cp *ControllerProcessor) getOwnerReferences(namespace string, owner metav1.OwnerReference,
+	controllerMap map[string]*repository.K8sController,
+	ownerRefsCache map[types.UID][]metav1.OwnerReference) ([]metav1.OwnerReference, error) {
+	if controller, found := controllerMap[string(owner.UID)]; found {
+		return controller.OwnerReferences, nil
+	}
+	if ownerRefs, found := ownerRefsCache[owner.UID]; found {
+		return ownerRefs, nil
+	}
+	ownerRefs, err := cp.ClusterInfoScraper.GetOwnerReferences(namespace, owner)
+	if err != nil {
+		return nil, err
+	}
+	ownerRefsCache[owner.UID] = ownerRefs
+	return ownerRefs, nil
+}
+
 func cacheController(obj unstructured.Unstructured) bool {
 	if obj.GetKind() != util.ReplicaSetResName &&
 		obj.GetKind() != util.ReplicationControllerResName {
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+func controllerOwnerRef(apiVersion, kind, name, uid string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{
+		APIVersion: apiVersion, Kind: kind, Name: name, UID: types.UID(uid),
+		Controller: &isController,
+	}}
+}
+
+func TestResolveTopLevelOwners(t *testing.T) {
+	lookups := 0
+	ms := &MockClusterScrapper{
+		mockGetOwnerReferences: func(namespace string, owner metav1.OwnerReference) ([]metav1.OwnerReference, error) {
+			lookups++
+			switch owner.Name {
+			case "app":
+				// The custom resource owned by another custom resource
+				return controllerOwnerRef("example.com/v1", "Platform", "platform", "platform-uid"), nil
+			case "platform":
+				return nil, nil
+			case "loop-a":
+				return controllerOwnerRef("example.com/v1", "Loop", "loop-b", "loop-b-uid"), nil
+			case "loop-b":
+				return controllerOwnerRef("example.com/v1", "Loop", "loop-a", "loop-a-uid"), nil
+			}
+			return nil, fmt.Errorf("%s not found", owner.Name)
+		},
+	}
+	controllerMap := map[string]*repository.K8sController{
+		"rs-uid": repository.NewK8sController("ReplicaSet", "rs", "ns", "rs-uid").
+			WithOwnerReferences(controllerOwnerRef("apps/v1", "Deployment", "deploy", "deploy-uid")),
+		"deploy-uid": repository.NewK8sController("Deployment", "deploy", "ns", "deploy-uid").
+			WithOwnerReferences(controllerOwnerRef("example.com/v1", "App", "app", "app-uid")),
+		"other-deploy-uid": repository.NewK8sController("Deployment", "other-deploy", "ns", "other-deploy-uid").
+			WithOwnerReferences(controllerOwnerRef("example.com/v1", "App", "app", "app-uid")),
+		"unknown-deploy-uid": repository.NewK8sController("Deployment", "unknown-deploy", "ns", "unknown-deploy-uid").
+			WithOwnerReferences(controllerOwnerRef("example.com/v1", "Unknown", "unknown", "unknown-uid")),
+		"loop-deploy-uid": repository.NewK8sController("Deployment", "loop-deploy", "ns", "loop-deploy-uid").
+			WithOwnerReferences(controllerOwnerRef("example.com/v1", "Loop", "loop-a", "loop-a-uid")),
+		"standalone-uid": repository.NewK8sController("Deployment", "standalone", "ns", "standalone-uid"),
+	}
+	cp := NewControllerProcessor(ms, repository.NewKubeCluster("cluster", nil))
+	cp.resolveTopLevelOwners(controllerMap)
+
+	// The owners are walked through the cached controllers and the looked up custom resources
+	assert.Equal(t, "platform", controllerMap["rs-uid"].TopLevelOwner.Name)
+	assert.Equal(t, "Platform", controllerMap["deploy-uid"].TopLevelOwner.Kind)
+	assert.Equal(t, "platform", controllerMap["other-deploy-uid"].TopLevelOwner.Name)
+	// The walk stops at the last known owner
+	assert.Equal(t, "unknown", controllerMap["unknown-deploy-uid"].TopLevelOwner.Name)
+	// The walk stops at a cycle
+	assert.Equal(t, "loop-a", controllerMap["loop-deploy-uid"].TopLevelOwner.Name)
+	assert.Nil(t, controllerMap["standalone-uid"].TopLevelOwner)
+	// Each owner is looked up once: app, platform, unknown, loop-a and loop-b
+	assert.Equal(t, 5, lookups)
+}
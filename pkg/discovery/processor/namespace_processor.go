@@ -41,6 +41,12 @@ func (p *NamespaceProcessor) ProcessNamespaces() map[string]*api.Namespace {
 	}
 	glog.V(2).Infof("There are %d resource quotas.", len(quotaMap))
 
+	// The limit ranges only bound the container resizes, so the namespaces are discovered without them on failure
+	limitRangeMap, err := p.ClusterInfoScraper.GetNamespaceLimitRanges()
+	if err != nil {
+		glog.Warningf("Failed to list all limit ranges in the cluster %s: %v.", clusterName, err)
+	}
+
 	namespaceMap := make(map[string]*repository.KubeNamespace)
 	kubeNamespaceMap := make(map[string]*api.Namespace)
 	for _, item := range namespaceList {
@@ -56,6 +62,7 @@ func (p *NamespaceProcessor) ProcessNamespaces() map[string]*api.Namespace {
 			kubeNamespace.QuotaList = quotaList
 			kubeNamespace.ReconcileQuotas(quotaList)
 		}
+		kubeNamespace.LimitRanges = limitRangeMap[item.Name]
 
 		namespaceMap[item.Name] = kubeNamespace
 		kubeNamespaceMap[item.Name] = item
@@ -74,6 +74,9 @@ func TestProcessNamespaces(t *testing.T) {
 		mockGetNamespaceQuotas: func() (map[string][]*v1.ResourceQuota, error) {
 			return quotaMap, nil
 		},
+		mockGetNamespaceLimitRanges: func() (map[string][]*v1.LimitRange, error) {
+			return map[string][]*v1.LimitRange{"test-ns2": {{ObjectMeta: metav1.ObjectMeta{Name: "limits"}}}}, nil
+		},
 	}
 	ks := repository.NewKubeCluster(testClusterName,
 		createMockNodes(allocatableMap, schedulableNodeMap))
@@ -103,4 +106,6 @@ func TestProcessNamespaces(t *testing.T) {
 		_, exists := mockedNamespaces[ns.Name]
 		assert.True(t, exists, fmt.Sprintf("namespace %s does not exist", ns.Name))
 	}
+	assert.Equal(t, 1, len(nsMap["test-ns2"].LimitRanges))
+	assert.Empty(t, nsMap["test-ns1"].LimitRanges)
 }
@@ -78,6 +78,8 @@ func (p *ServiceProcessor) ProcessServices() {
 }
 
 // For every service, find the pods for this service.
+// The pods which are not ready are included, so that a service remains bound to the pods serving it while they
+// are starting up or failing their readiness probes; only the ready pods are counted in its number of replicas.
 func findPodEndpoints(service *v1.Service, serviceEndpoint *v1.Endpoints) []string {
 	// find the endpoint for the service using the service cluster Id.
 	// Endpoint associated with a service have the same cluster Id
@@ -85,8 +87,12 @@ func findPodEndpoints(service *v1.Service, serviceEndpoint *v1.Endpoints) []stri
 
 	subsets := serviceEndpoint.Subsets
 	podList := []string{}
+	// A pod serving multiple ports of the service may appear in multiple subsets
+	podSet := make(map[string]bool)
 	for _, endpointSubset := range subsets {
-		addresses := endpointSubset.Addresses
+		var addresses []v1.EndpointAddress
+		addresses = append(addresses, endpointSubset.Addresses...)
+		addresses = append(addresses, endpointSubset.NotReadyAddresses...)
 		for _, address := range addresses {
 			target := address.TargetRef
 			if target == nil {
@@ -106,6 +112,10 @@ func findPodEndpoints(service *v1.Service, serviceEndpoint *v1.Endpoints) []stri
 			podNamespace := target.Namespace
 			// Pod Cluster Id
 			podClusterID := util.BuildK8sEntityClusterID(podNamespace, podName)
+			if podSet[podClusterID] {
+				continue
+			}
+			podSet[podClusterID] = true
 			// get the pod name and the service name
 			podList = append(podList, podClusterID)
 		}
@@ -69,3 +69,42 @@ func TestFindPodEndpoints(t *testing.T) {
 		t.Errorf("Failed to find service's pod endpoints: %d Vs. %d", 1, len(result))
 	}
 }
+
+func TestFindPodEndpointsNotReady(t *testing.T) {
+	svc := &api.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc-1",
+			Namespace: "default",
+			UID:       "svc-1-uuid",
+		},
+	}
+
+	podAddress := func(name string) api.EndpointAddress {
+		return api.EndpointAddress{
+			TargetRef: &api.ObjectReference{Kind: "Pod", Name: name, Namespace: "default"},
+		}
+	}
+	// pod-1 serves both ports of the service, pod-2 is not ready
+	endpoint := &api.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc-1",
+			Namespace: "default",
+		},
+		Subsets: []api.EndpointSubset{
+			{
+				Addresses:         []api.EndpointAddress{podAddress("pod-1")},
+				NotReadyAddresses: []api.EndpointAddress{podAddress("pod-2")},
+				Ports:             []api.EndpointPort{{Port: 8080}},
+			},
+			{
+				Addresses: []api.EndpointAddress{podAddress("pod-1")},
+				Ports:     []api.EndpointPort{{Port: 9090}},
+			},
+		},
+	}
+
+	result := findPodEndpoints(svc, endpoint)
+	if len(result) != 2 || result[0] != "default/pod-1" || result[1] != "default/pod-2" {
+		t.Errorf("Failed to find service's pod endpoints: %v", result)
+	}
+}
@@ -61,10 +61,8 @@ func (p *VolumeProcessor) ProcessVolumes() {
 	// are used by multiple pods.
 	volumeToPodsMap := make(map[*v1.PersistentVolume][]repository.PodVolume)
 	for pv, pvc := range volumeToClaimMap {
-		if pvc == nil {
-			// Unused volume.
-			volumeToPodsMap[pv] = nil
-		}
+		// The volumes not bound to a claim, or bound to a claim not used by any pod, are kept without pods.
+		volumeToPodsMap[pv] = nil
 		for _, pod := range p.KubeCluster.Pods {
 			for _, vol := range pod.Spec.Volumes {
 				claim := vol.VolumeSource.PersistentVolumeClaim
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+const (
+	// The update mode of a VerticalPodAutoscaler which only computes the recommendations, without resizing the pods
+	vpaUpdateModeOff = "Off"
+)
+
+// The VerticalPodAutoscaler custom resource of the Kubernetes autoscaler
+var vpaResource = schema.GroupVersionResource{
+	Group:    "autoscaling.k8s.io",
+	Version:  "v1",
+	Resource: "verticalpodautoscalers",
+}
+
+// VPAProcessor discovers the workload controllers whose pods are resized by a VerticalPodAutoscaler, so that the
+// resizes of kubeturbo do not fight with those of the VerticalPodAutoscaler.
+type VPAProcessor struct {
+	ClusterScraper cluster.ClusterScraperInterface
+	KubeCluster    *repository.KubeCluster
+}
+
+func NewVPAProcessor(clusterScraper cluster.ClusterScraperInterface,
+	kubeCluster *repository.KubeCluster) *VPAProcessor {
+	return &VPAProcessor{
+		ClusterScraper: clusterScraper,
+		KubeCluster:    kubeCluster,
+	}
+}
+
+// ProcessVPAs finds the workload controllers targeted by the VerticalPodAutoscalers which resize the pods, i.e.,
+// whose update mode is not Off. The workload controllers are matched by kind, namespace and name, either directly
+// or through the top level owner of the workload controllers, e.g. the custom resource of an operator.
+func (p *VPAProcessor) ProcessVPAs() {
+	vpas, err := p.ClusterScraper.GetResources(vpaResource)
+	if err != nil {
+		// The VerticalPodAutoscaler custom resource definition is not installed in most clusters
+		glog.V(3).Infof("Failed to list VerticalPodAutoscalers: %v.", err)
+		return
+	}
+	// The namespace/name of the VerticalPodAutoscalers by the kind/namespace/name of their targets
+	targets := make(map[string]string)
+	for _, vpa := range vpas {
+		kind, name, ok := vpaTarget(vpa)
+		if !ok {
+			glog.Warningf("VerticalPodAutoscaler %s/%s has no target. Skip.", vpa.GetNamespace(), vpa.GetName())
+			continue
+		}
+		if vpaUpdateMode(vpa) == vpaUpdateModeOff {
+			continue
+		}
+		targets[kind+"/"+vpa.GetNamespace()+"/"+name] = vpa.GetNamespace() + "/" + vpa.GetName()
+	}
+	if len(targets) == 0 {
+		glog.V(3).Info("There is no VerticalPodAutoscaler resizing the pods found in the cluster.")
+		return
+	}
+	vpaControllers := make(map[string]string)
+	for uid, controller := range p.KubeCluster.ControllerMap {
+		vpa, found := targets[controller.Kind+"/"+controller.Namespace+"/"+controller.Name]
+		if !found && controller.TopLevelOwner != nil {
+			vpa, found = targets[controller.TopLevelOwner.Kind+"/"+controller.Namespace+"/"+
+				controller.TopLevelOwner.Name]
+		}
+		if found {
+			vpaControllers[uid] = vpa
+		}
+	}
+	glog.V(2).Infof("Discovered %d workload controllers resized by %d VerticalPodAutoscalers.",
+		len(vpaControllers), len(targets))
+	p.KubeCluster.VPAControllers = vpaControllers
+}
+
+// vpaTarget returns the kind and the name of the target of the VerticalPodAutoscaler.
+func vpaTarget(vpa unstructured.Unstructured) (string, string, bool) {
+	kind, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "kind")
+	name, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "name")
+	return kind, name, kind != "" && name != ""
+}
+
+// vpaUpdateMode returns the update mode of the VerticalPodAutoscaler, which defaults to Auto.
+func vpaUpdateMode(vpa unstructured.Unstructured) string {
+	mode, found, _ := unstructured.NestedString(vpa.Object, "spec", "updatePolicy", "updateMode")
+	if !found || mode == "" {
+		return "Auto"
+	}
+	return mode
+}
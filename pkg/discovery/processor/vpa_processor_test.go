@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+func newVPA(namespace, name, targetKind, targetName, updateMode string) unstructured.Unstructured {
+	vpa := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": targetKind, "name": targetName},
+		},
+	}}
+	if updateMode != "" {
+		unstructured.SetNestedField(vpa.Object, updateMode, "spec", "updatePolicy", "updateMode")
+	}
+	return vpa
+}
+
+func TestProcessVPAs(t *testing.T) {
+	ms := &MockClusterScrapper{
+		mockGetResources: func(resource schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+			assert.Equal(t, vpaResource, resource)
+			return []unstructured.Unstructured{
+				newVPA("ns", "web-vpa", "Deployment", "web", ""),
+				newVPA("ns", "db-vpa", "StatefulSet", "db", "Off"),
+				newVPA("ns", "app-vpa", "App", "app", "Recreate"),
+				newVPA("other", "web-vpa", "Deployment", "other-web", "Auto"),
+			}, nil
+		},
+	}
+	kubeCluster := repository.NewKubeCluster("cluster", nil)
+	kubeCluster.ControllerMap = map[string]*repository.K8sController{
+		"web-uid":    repository.NewK8sController("Deployment", "web", "ns", "web-uid"),
+		"db-uid":     repository.NewK8sController("StatefulSet", "db", "ns", "db-uid"),
+		"api-uid":    repository.NewK8sController("Deployment", "api", "ns", "api-uid"),
+		"web-ns-uid": repository.NewK8sController("Deployment", "web", "ns2", "web-ns-uid"),
+		"operated-uid": repository.NewK8sController("Deployment", "app-server", "ns", "operated-uid").
+			WithTopLevelOwner(&metav1.OwnerReference{Kind: "App", Name: "app"}),
+	}
+	NewVPAProcessor(ms, kubeCluster).ProcessVPAs()
+	// The VPAs in Off mode only recommend, and the targets are matched in the namespace of the VPA
+	assert.Equal(t, map[string]string{
+		"web-uid":      "ns/web-vpa",
+		"operated-uid": "ns/app-vpa",
+	}, kubeCluster.VPAControllers)
+
+	// Without the VPA custom resource definition
+	ms.mockGetResources = func(schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+		return nil, fmt.Errorf("the server could not find the requested resource")
+	}
+	kubeCluster.VPAControllers = nil
+	NewVPAProcessor(ms, kubeCluster).ProcessVPAs()
+	assert.Empty(t, kubeCluster.VPAControllers)
+}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 )
 
@@ -99,6 +102,54 @@ func NewContainerMetrics(capacity []float64, used interface{}) *ContainerMetrics
 	}
 }
 
+// containerMetricsJSON is the JSON encoding of ContainerMetrics, e.g. sent by the discovery shard workers to the
+// coordinator, with the kind of the used values so that they are decoded as such.
+type containerMetricsJSON struct {
+	Capacity []float64
+	// points or throttling, none if the used values are nil
+	Kind       string                           `json:",omitempty"`
+	Points     []metrics.Point                  `json:",omitempty"`
+	Throttling [][]metrics.ThrottlingCumulative `json:",omitempty"`
+}
+
+func (m *ContainerMetrics) MarshalJSON() ([]byte, error) {
+	encoded := containerMetricsJSON{Capacity: m.Capacity}
+	switch used := m.Used.(type) {
+	case nil:
+	case []metrics.Point:
+		encoded.Kind, encoded.Points = "points", used
+	case [][]metrics.ThrottlingCumulative:
+		encoded.Kind, encoded.Throttling = "throttling", used
+	default:
+		return nil, fmt.Errorf("unsupported used values of type %T", m.Used)
+	}
+	return json.Marshal(encoded)
+}
+
+func (m *ContainerMetrics) UnmarshalJSON(data []byte) error {
+	var encoded containerMetricsJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	m.Capacity, m.Used = encoded.Capacity, nil
+	switch encoded.Kind {
+	case "":
+	case "points":
+		m.Used = encoded.Points
+		if encoded.Points == nil {
+			m.Used = []metrics.Point{}
+		}
+	case "throttling":
+		m.Used = encoded.Throttling
+		if encoded.Throttling == nil {
+			m.Used = [][]metrics.ThrottlingCumulative{}
+		}
+	default:
+		return fmt.Errorf("unknown kind %q of the used values", encoded.Kind)
+	}
+	return nil
+}
+
 // ContainerSpecMetrics collects the shared portion of individual container replicas defined by the controller that manages
 // the pods where these containers run, including container replicas and resource metrics with multiple samples of usage data.
 type ContainerSpecMetrics struct {
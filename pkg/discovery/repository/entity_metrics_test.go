@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"encoding/json"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"reflect"
@@ -112,3 +114,21 @@ func TestAggregateUsedNormal(t *testing.T) {
 			expectedAggregateUsed, namespaceMetrics.Used)
 	}
 }
+
+func TestContainerMetricsJSON(t *testing.T) {
+	for _, used := range []interface{}{
+		nil,
+		[]metrics.Point{{Value: 2.4, Timestamp: 100}},
+		[]metrics.Point{},
+		[][]metrics.ThrottlingCumulative{{{Throttled: 1, Total: 10, CPULimit: 500, Timestamp: 100}}},
+	} {
+		data, err := json.Marshal(NewContainerMetrics([]float64{1000}, used))
+		assert.NoError(t, err)
+		decoded := &ContainerMetrics{}
+		assert.NoError(t, json.Unmarshal(data, decoded))
+		assert.Equal(t, NewContainerMetrics([]float64{1000}, used), decoded)
+	}
+
+	_, err := json.Marshal(NewContainerMetrics(nil, 1.0))
+	assert.Error(t, err)
+}
@@ -48,6 +48,10 @@ type KubeCluster struct {
 
 	// Data structures related to Turbo policy
 	TurboPolicyBindings []*TurboPolicyBinding
+
+	// The UIDs of the workload controllers whose pods are resized by a VerticalPodAutoscaler, to the
+	// namespace/name of the VerticalPodAutoscaler
+	VPAControllers map[string]string
 }
 
 func NewKubeCluster(clusterName string, nodes []*v1.Node) *KubeCluster {
@@ -348,6 +352,7 @@ func parseResourceValue(computeResourceType metrics.ResourceType, resourceList v
 const (
 	AppTypeK8s    = "k8s"
 	AppTypeArgoCD = "argocd"
+	AppTypeLabel  = "label"
 )
 
 type K8sApp struct {
@@ -364,6 +369,13 @@ type K8sAppComponent struct {
 	Name       string
 }
 
+// ComponentKey returns the key of the application commodity sold by the given component to the app and bought by the
+// business application entity of the app, which is named after the value of the business app label for the apps
+// discovered by label.
+func (app K8sApp) ComponentKey(component K8sAppComponent) string {
+	return fmt.Sprintf("%s-%s/%s-%s/%s", "App", app.Namespace, app.Name, component.EntityType.String(), component.Name)
+}
+
 type PodVolume struct {
 	// Namespace qualified pod name.
 	QualifiedPodName string
@@ -400,6 +412,9 @@ type K8sController struct {
 	// May not exist in all controllers. For Daemonset, defaults to number of nodes in the cluster.
 	Replicas   *int64
 	Containers sets.String
+	// The owner at the top of the ownership chain, e.g. the custom resource of an operator. Nil if the controller
+	// has no owner.
+	TopLevelOwner *metav1.OwnerReference
 }
 
 func NewK8sController(kind, name, namespace, uid string) *K8sController {
@@ -436,6 +451,11 @@ func (kc *K8sController) WithContainerNames(names sets.String) *K8sController {
 	return kc
 }
 
+func (kc *K8sController) WithTopLevelOwner(owner *metav1.OwnerReference) *K8sController {
+	kc.TopLevelOwner = owner
+	return kc
+}
+
 // KubeController defines K8s controller in the cluster
 type KubeController struct {
 	*KubeEntity
@@ -473,6 +493,9 @@ type KubeNamespace struct {
 	AverageNodeCpuFrequency float64
 	QuotaDefined            map[metrics.ResourceType]bool
 
+	// List of limit ranges defined for a namespace
+	LimitRanges []*v1.LimitRange
+
 	// Stores the labels and annotations on the given namespace
 	Labels      map[string]string
 	Annotations map[string]string
@@ -533,19 +556,12 @@ func (kubeNamespace *KubeNamespace) ReconcileQuotas(quotas []*v1.ResourceQuota)
 	// Quota resources by collecting resources from the list of resource quota objects
 	var quotaNames []string
 	for _, item := range quotas {
-		//  Ignore the resourcequotas with a Terminating scope
-		ignore := false
+		//  Ignore the resourcequotas which only apply to a subset of the workload pods
 		if len(item.Spec.Scopes) > 0 || item.Spec.ScopeSelector != nil {
 			glog.V(2).Infof("Found a resourcequota %v in the namespace %v with the scopes(%v) or scopeSelector(%v)", item.Name, item.Namespace, item.Spec.Scopes, item.Spec.ScopeSelector)
 		}
-		for _, scope := range item.Spec.Scopes {
-			if scope == v1.ResourceQuotaScopeTerminating {
-				ignore = true
-				break
-			}
-		}
-		if ignore {
-			glog.V(2).Infof("Ignore the resourcequota %v in the namespace %v as it has a Terminating scope", item.Name, item.Namespace)
+		if scope, narrowed := getNarrowingQuotaScope(item); narrowed {
+			glog.V(2).Infof("Ignore the resourcequota %v in the namespace %v as it has a %v scope", item.Name, item.Namespace, scope)
 			continue
 		}
 
@@ -592,6 +608,25 @@ func (kubeNamespace *KubeNamespace) ReconcileQuotas(quotas []*v1.ResourceQuota)
 		kubeNamespace.QuotaDefined[metrics.MemoryRequestQuota])
 }
 
+// getNarrowingQuotaScope returns the first scope of the given resource quota, either in its scopes or in its scope
+// selector, that limits the quota to a subset of the long running workload pods of the namespace, e.g., the pods
+// of a priority class. The capacity of the namespace is not capped at the limits of such quotas.
+func getNarrowingQuotaScope(quota *v1.ResourceQuota) (v1.ResourceQuotaScope, bool) {
+	var scopes []v1.ResourceQuotaScope
+	scopes = append(scopes, quota.Spec.Scopes...)
+	if quota.Spec.ScopeSelector != nil {
+		for _, expression := range quota.Spec.ScopeSelector.MatchExpressions {
+			scopes = append(scopes, expression.ScopeName)
+		}
+	}
+	for _, scope := range scopes {
+		if scope != v1.ResourceQuotaScopeNotTerminating && scope != v1.ResourceQuotaScopeNotBestEffort {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
 // Parse the CPU and Memory resource values.
 // CPU is represented in number of millicores, Memory in KBytes
 func parseAllocationResourceValue(resource v1.ResourceName, allocationResourceType metrics.ResourceType, resourceList v1.ResourceList) float64 {
@@ -199,6 +199,45 @@ func TestKubeNamespaceQuotaReconcile(t *testing.T) {
 	assert.Equal(t, resource.Capacity, leastMemKB) // the least of the 3 quotas
 }
 
+func TestKubeNamespaceQuotaReconcileScopes(t *testing.T) {
+	newQuota := func(name, cpuLimit string, scopes []v1.ResourceQuotaScope,
+		scopeSelector *v1.ScopeSelector) *v1.ResourceQuota {
+		return &v1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				UID:       types.UID(name),
+				Namespace: "ns1",
+			},
+			Spec: v1.ResourceQuotaSpec{
+				Scopes:        scopes,
+				ScopeSelector: scopeSelector,
+			},
+			Status: v1.ResourceQuotaStatus{
+				Hard: v1.ResourceList{
+					v1.ResourceLimitsCPU: resource.MustParse(cpuLimit),
+				},
+			},
+		}
+	}
+	quotaList := []*v1.ResourceQuota{
+		newQuota("not-terminating", "4", []v1.ResourceQuotaScope{v1.ResourceQuotaScopeNotTerminating}, nil),
+		newQuota("terminating", "1", []v1.ResourceQuotaScope{v1.ResourceQuotaScopeTerminating}, nil),
+		newQuota("priority-class", "2", nil, &v1.ScopeSelector{
+			MatchExpressions: []v1.ScopedResourceSelectorRequirement{{
+				ScopeName: v1.ResourceQuotaScopePriorityClass,
+				Operator:  v1.ScopeSelectorOpIn,
+				Values:    []string{"high"},
+			}},
+		}),
+	}
+	kubeNamespace := CreateDefaultKubeNamespace("cluster1", "ns1", "namespace-uuid")
+	kubeNamespace.ReconcileQuotas(quotaList)
+
+	// Only the quota applying to all the long running pods caps the capacity
+	resource, _ := kubeNamespace.GetAllocationResource(metrics.CPULimitQuota)
+	assert.Equal(t, 4000.0, resource.Capacity)
+}
+
 func TestNamespaceNames(t *testing.T) {
 	clusterName := "k8s-cluster"
 	namespaceName := "kube-system"
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+// ResourceBounds are the minimum and the maximum amount of a resource of a container, in millicores for the CPU and
+// in kilobytes for the memory. A zero bound is not set.
+type ResourceBounds struct {
+	Min float64
+	Max float64
+}
+
+// ContainerResourceBounds returns the bounds of the CPU and memory limits and requests of the containers in the
+// namespace, set by the minimum and the maximum of the Container limit ranges, and the maximum of the Pod limit ranges
+// which no container can exceed either. The tightest bounds of all the limit ranges apply. The defaults of the limit
+// ranges are set on the containers on admission, so they are already part of the discovered resources.
+func (kubeNamespace *KubeNamespace) ContainerResourceBounds() map[metrics.ResourceType]ResourceBounds {
+	bounds := make(map[metrics.ResourceType]ResourceBounds)
+	for _, limitRange := range kubeNamespace.LimitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != v1.LimitTypeContainer && item.Type != v1.LimitTypePod {
+				continue
+			}
+			for resourceName, resourceTypes := range map[v1.ResourceName][]metrics.ResourceType{
+				v1.ResourceCPU:    {metrics.CPU, metrics.CPURequest},
+				v1.ResourceMemory: {metrics.Memory, metrics.MemoryRequest},
+			} {
+				var minAmount, maxAmount float64
+				if quantity, found := item.Min[resourceName]; found && item.Type == v1.LimitTypeContainer {
+					minAmount = limitRangeAmount(resourceName, quantity)
+				}
+				if quantity, found := item.Max[resourceName]; found {
+					maxAmount = limitRangeAmount(resourceName, quantity)
+				}
+				for _, resourceType := range resourceTypes {
+					bound := bounds[resourceType]
+					bound.Min = math.Max(bound.Min, minAmount)
+					if maxAmount > 0 && (bound.Max == 0 || maxAmount < bound.Max) {
+						bound.Max = maxAmount
+					}
+					if bound.Min > 0 || bound.Max > 0 {
+						bounds[resourceType] = bound
+					}
+				}
+			}
+		}
+	}
+	return bounds
+}
+
+// limitRangeAmount converts the quantity of the given resource to the unit of its commodities.
+func limitRangeAmount(resourceName v1.ResourceName, quantity resource.Quantity) float64 {
+	if resourceName == v1.ResourceCPU {
+		return float64(quantity.MilliValue())
+	}
+	return float64(quantity.Value()) / 1024
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+func TestContainerResourceBounds(t *testing.T) {
+	kubeNamespace := CreateDefaultKubeNamespace("cluster1", "ns1", "ns1-uid")
+	assert.Empty(t, kubeNamespace.ContainerResourceBounds())
+
+	kubeNamespace.LimitRanges = []*v1.LimitRange{
+		{Spec: v1.LimitRangeSpec{Limits: []v1.LimitRangeItem{
+			{
+				Type: v1.LimitTypeContainer,
+				Min:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m")},
+				Max: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("2"),
+					v1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+				Default: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+			},
+			// The minimum of a pod does not bound its containers
+			{
+				Type: v1.LimitTypePod,
+				Min:  v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+				Max:  v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+			},
+			{
+				Type: v1.LimitTypePersistentVolumeClaim,
+				Max:  v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		}}},
+		// The tightest bounds apply
+		{Spec: v1.LimitRangeSpec{Limits: []v1.LimitRangeItem{{
+			Type: v1.LimitTypeContainer,
+			Min:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+			Max:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+		}}}},
+	}
+	cpuBounds := ResourceBounds{Min: 100, Max: 2000}
+	memoryBounds := ResourceBounds{Max: 2 * 1024 * 1024}
+	assert.Equal(t, map[metrics.ResourceType]ResourceBounds{
+		metrics.CPU:           cpuBounds,
+		metrics.CPURequest:    cpuBounds,
+		metrics.Memory:        memoryBounds,
+		metrics.MemoryRequest: memoryBounds,
+	}, kubeNamespace.ContainerResourceBounds())
+}
@@ -0,0 +1,82 @@
+package shard
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/api"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+// Self is the member of the ring of the coordinator, which discovers its own shard of nodes
+const Self = "self"
+
+// Coordinator assigns the nodes of the cluster to the discovery shard workers on the ring, so that the scraping of
+// the kubelets and the building of the DTOs of the nodes and their pods are spread over the workers. The coordinator
+// keeps its own shard of nodes. As the nodes stay with the same worker across the discoveries, the state kept for
+// the nodes, e.g. the usage samples and the utilization history, stays with the worker.
+type Coordinator struct {
+	ring    *Ring
+	workers map[string]*api.Client
+}
+
+// NewCoordinator creates the coordinator of the shard workers serving the internal API at the given base URLs, e.g.
+// https://kubeturbo-shard-0.kubeturbo-shards:9443, called with the given mutual TLS configuration. The discoveries of
+// the shards are bounded by the given timeout.
+func NewCoordinator(workerURLs []string, tlsConfig *tls.Config, timeout time.Duration) (*Coordinator, error) {
+	members := []string{Self}
+	workers := make(map[string]*api.Client, len(workerURLs))
+	for _, workerURL := range workerURLs {
+		client, err := api.NewClient(workerURL, tlsConfig, timeout)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, client.BaseURL())
+		workers[client.BaseURL()] = client
+	}
+	return &Coordinator{
+		ring:    NewRing(members, defaultReplicas),
+		workers: workers,
+	}, nil
+}
+
+// Assign assigns the given nodes to their owners on the ring. It returns the nodes of each shard worker, and the
+// nodes of the coordinator.
+func (c *Coordinator) Assign(nodes []*v1.Node) (map[string][]*v1.Node, []*v1.Node) {
+	shards := make(map[string][]*v1.Node)
+	var selfNodes []*v1.Node
+	for _, node := range nodes {
+		owner := c.ring.Owner(node.Name)
+		if owner == Self {
+			selfNodes = append(selfNodes, node)
+			continue
+		}
+		shards[owner] = append(shards[owner], node)
+	}
+	return shards, selfNodes
+}
+
+// DiscoverShard discovers the nodes of the given names on the given shard worker.
+func (c *Coordinator) DiscoverShard(shardWorker string, nodeNames []string) (*worker.DiscoveryResult, error) {
+	client, found := c.workers[shardWorker]
+	if !found {
+		return nil, fmt.Errorf("unknown shard worker %s", shardWorker)
+	}
+	start := time.Now()
+	response, err := client.DiscoverShard(context.TODO(), &api.DiscoverShardRequest{Nodes: nodeNames})
+	if err != nil {
+		return nil, fmt.Errorf("shard worker %s failed to discover %d nodes: %v", shardWorker, len(nodeNames), err)
+	}
+	result, err := api.DecodeDiscoverShardResponse(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the discovery of shard worker %s: %v", shardWorker, err)
+	}
+	glog.V(3).Infof("Shard worker %s discovered %d nodes with %d entities in %s.", shardWorker, len(nodeNames),
+		len(result.EntityDTOs), time.Since(start))
+	return result, nil
+}
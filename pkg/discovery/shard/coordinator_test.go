@@ -0,0 +1,88 @@
+package shard
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/api"
+	"github.com/turbonomic/kubeturbo/pkg/discovery"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
+)
+
+var _ discovery.ShardDiscoverer = &Coordinator{}
+
+type mockShardWorker struct {
+	shards [][]string
+}
+
+func (w *mockShardWorker) DiscoverShard(nodeNames []string) (*worker.DiscoveryResult, error) {
+	w.shards = append(w.shards, nodeNames)
+	result := &worker.DiscoveryResult{SuccessCount: len(nodeNames)}
+	for _, nodeName := range nodeNames {
+		id := nodeName
+		result.EntityDTOs = append(result.EntityDTOs,
+			&proto.EntityDTO{Id: &id, EntityType: proto.EntityDTO_VIRTUAL_MACHINE.Enum()})
+	}
+	return result, nil
+}
+
+func TestCoordinator(t *testing.T) {
+	shardWorker := &mockShardWorker{}
+	// The worker serves the certificate of httptest, issued for 127.0.0.1
+	certServer := httptest.NewTLSServer(nil)
+	certServer.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(certServer.Certificate())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := api.NewServer().WithShardWorker(shardWorker).
+		NewGRPCServer(&tls.Config{Certificates: certServer.TLS.Certificates})
+	go server.Serve(listener)
+	defer server.Stop()
+	workerURL := "https://" + listener.Addr().String()
+
+	coordinator, err := NewCoordinator([]string{workerURL + "/"}, &tls.Config{RootCAs: roots}, time.Second)
+	assert.NoError(t, err)
+	var nodes []*v1.Node
+	for i := 0; i < 20; i++ {
+		nodes = append(nodes, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+	shards, selfNodes := coordinator.Assign(nodes)
+	workerNodes := shards[workerURL]
+	assert.Len(t, shards, 1)
+	assert.NotEmpty(t, selfNodes)
+	assert.NotEmpty(t, workerNodes)
+	assert.Equal(t, len(nodes), len(selfNodes)+len(workerNodes))
+	for _, node := range selfNodes {
+		assert.Equal(t, Self, coordinator.ring.Owner(node.Name))
+	}
+
+	var nodeNames []string
+	for _, node := range workerNodes {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	result, err := coordinator.DiscoverShard(workerURL, nodeNames)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{nodeNames}, shardWorker.shards)
+	assert.Len(t, result.EntityDTOs, len(nodeNames))
+	assert.Equal(t, len(nodeNames), result.SuccessCount)
+
+	_, err = coordinator.DiscoverShard("https://unknown:9443", nodeNames)
+	assert.Error(t, err)
+
+	// The workers whose certificate is not trusted are refused
+	coordinator, err = NewCoordinator([]string{workerURL}, &tls.Config{}, time.Second)
+	assert.NoError(t, err)
+	_, err = coordinator.DiscoverShard(workerURL, nodeNames)
+	assert.Error(t, err)
+}
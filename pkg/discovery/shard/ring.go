@@ -0,0 +1,71 @@
+package shard
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// The number of the points of each member on the ring, which evens out the number of the nodes of the members
+const defaultReplicas = 100
+
+// Ring assigns the nodes to the members of the discovery shards by consistent hashing on the node names, so that
+// adding or removing a member only moves the nodes of about one member.
+type Ring struct {
+	// The sorted points of the members on the ring
+	points []uint32
+	owners map[uint32]string
+}
+
+// NewRing creates the ring of the given members, with the given number of points per member, a default number if
+// not positive.
+func NewRing(members []string, replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = defaultReplicas
+	}
+	r := &Ring{owners: make(map[uint32]string)}
+	for _, member := range members {
+		for i := 0; i < replicas; i++ {
+			point := hash(member + "#" + strconv.Itoa(i))
+			// Resolve the rare collisions the same way whatever the order of the members
+			if owner, found := r.owners[point]; found {
+				if member < owner {
+					r.owners[point] = member
+				}
+				continue
+			}
+			r.points = append(r.points, point)
+			r.owners[point] = member
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// Owner returns the member owning the given key, i.e., the first member clockwise from the key on the ring, empty if
+// the ring has no member.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	point := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= point })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// hash hashes the key with FNV-1a, whose bits are then mixed with the finalizer of MurmurHash3, as the FNV hashes of
+// the similar keys, e.g. node-1 and node-2, are too close to each other to spread them evenly on the ring.
+func hash(key string) uint32 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return uint32(x >> 32)
+}
@@ -0,0 +1,41 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	assert.Equal(t, "", NewRing(nil, 0).Owner("node-1"))
+
+	members := []string{Self, "http://shard-0", "http://shard-1", "http://shard-2"}
+	ring := NewRing(members, 0)
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		node := fmt.Sprintf("node-%d", i)
+		owners[node] = ring.Owner(node)
+		counts[owners[node]]++
+	}
+	// The nodes are spread over all the members
+	for _, member := range members {
+		assert.InDelta(t, 1000, counts[member], 400, member)
+	}
+	// The order of the members does not matter
+	reversed := NewRing([]string{"http://shard-2", "http://shard-1", "http://shard-0", Self}, 0)
+	for node, owner := range owners {
+		assert.Equal(t, owner, reversed.Owner(node))
+	}
+	// Adding a member only moves the nodes to the new member
+	grown := NewRing(append(members, "http://shard-3"), 0)
+	moved := 0
+	for node, owner := range owners {
+		if newOwner := grown.Owner(node); newOwner != owner {
+			assert.Equal(t, "http://shard-3", newOwner)
+			moved++
+		}
+	}
+	assert.InDelta(t, 800, moved, 400)
+}
@@ -19,6 +19,21 @@ const (
 	awsFormat   = "aws::%v::VM::%v"
 	azureFormat = "azure::VM::%v"
 	gceFormat   = "gcp::%v::VM::%v"
+
+	gceSelfLinkFormat = "https://www.googleapis.com/compute/v1/projects/%v/zones/%v/instances/%v"
+
+	azureSubscriptionsPrefix = "/subscriptions/"
+	azureComputeProvider     = "/providers/microsoft.compute/"
+	azureVirtualMachines     = "virtualmachines"
+)
+
+// The cloud providers accepted by NewNodeUUIDGetter
+const (
+	DefaultProvider = "default"
+	AWSProvider     = "aws"
+	AzureProvider   = "azure"
+	GCPProvider     = "gcp"
+	VsphereProvider = "vsphere"
 )
 
 type NodeUUIDGetter interface {
@@ -46,14 +61,13 @@ func (d *defaultNodeUUIDGetter) Name() string {
 }
 
 func (d *defaultNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
-	suuid := node.Status.NodeInfo.SystemUUID
+	// the uuid is in lower case in vCenter Probe
+	suuid := normalizeSystemUUID(node.Status.NodeInfo.SystemUUID)
 	if len(suuid) < 1 {
 		glog.Errorf("Node uuid is empty: %++v", node)
 		return "", fmt.Errorf("Empty uuid")
 	}
 
-	// the uuid is in lower case in vCenter Probe
-	suuid = strings.ToLower(suuid)
 	reversedSuuid, err := reverseUuid(suuid)
 	if err != nil {
 		glog.Warningf("Failed to reverse endianness of node %s's UUID %s: %v", node.Name, suuid, err)
@@ -87,28 +101,45 @@ func (aws *awsNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
 		return "", fmt.Errorf("Invalid")
 	}
 
-	//2. split the suffix into two parts:
+	//2. split the suffix into the zone and the EC2 instance ID:
 	// aws:///us-west-2a/i-0be85bb9db1707470 -> [us-west-2a, i-0be85bb9db1707470]
+	// The zone is left out by some installers, e.g. aws:////i-0be85bb9db1707470, in which case the
+	// zone and region labels of the node are used.
 	suffix := providerId[len(awsPrefix):]
 	parts := strings.Split(suffix, "/")
-	if len(parts) != 2 {
+	if len(parts) != 2 || parts[1] == "" {
 		glog.Errorf("Failed to split uuid (%d): %v", len(parts), parts)
 		return "", fmt.Errorf("Invalid")
 	}
-
-	//3. get region by remove the zone suffix
-	if len(parts[0]) < 2 {
-		glog.Errorf("Invalid zone Id: %v", providerId)
-		return "", fmt.Errorf("Invalid")
+	zone, instanceId := parts[0], parts[1]
+	if zone == "" {
+		zone = getNodeLabel(node, api.LabelTopologyZone, api.LabelFailureDomainBetaZone)
 	}
 
-	end := len(parts[0]) - 1
-	region := parts[0][0:end]
+	//3. get region from the region label, or by removing the zone suffix
+	region := getNodeLabel(node, api.LabelTopologyRegion, api.LabelFailureDomainBetaRegion)
+	if region == "" {
+		if len(zone) < 2 {
+			glog.Errorf("Invalid zone Id: %v", providerId)
+			return "", fmt.Errorf("Invalid")
+		}
+		region = zone[0 : len(zone)-1]
+	}
 
-	result := fmt.Sprintf(awsFormat, region, parts[1])
+	result := fmt.Sprintf(awsFormat, region, instanceId)
 	return result, nil
 }
 
+// getNodeLabel returns the value of the first of the given labels that is set on the node.
+func getNodeLabel(node *api.Node, labels ...string) string {
+	for _, label := range labels {
+		if value := node.Labels[label]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 /**
   Input Azure.k8s.Node info:
   spec:
@@ -118,7 +149,12 @@ func (aws *awsNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
   NodeInfo:
     systemUUID: D4DD3FE4-7A31-C74F-BBA7-3AE729EABA6E
 
-  Output:  azure::VM::e43fddd4-317a-4fc7-bba7-3ae729eaba6e,azure::VM::D4DD3FE4-7A31-C74F-BBA7-3AE729EABA6E
+  Output:  azure::VM::d4dd3fe4-7a31-c74f-bba7-3ae729eaba6e,azure::VM::e43fddd4-317a-4fc7-bba7-3ae729eaba6e,
+           /subscriptions/758ad253-cbf5-4b18-8863-3eed0825bf07/resourcegroups/spceastus2/providers/microsoft.compute/virtualmachines/spc1w695w0-master-1
+
+  The resource ID of the VM in the providerID is appended in lower case, as the Azure resource IDs are case
+  insensitive; the VMs of the scale sets, e.g., the AKS nodes, have the resource ID
+  /subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Compute/virtualMachineScaleSets/<set>/virtualMachines/<instance>
 
   ref
   https://git.turbonomic.com/turbonomic/opsmgr/blob/develop/com.vmturbo.mediation.azure/src/main/java/com/vmturbo/mediation/azure/AzureUtility.java#L235
@@ -136,25 +172,49 @@ func (azure *azureNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
 	if !strings.HasPrefix(providerId, azurePrefix) {
 		glog.Warningf("Not a Azure node: % ++v", node)
 	}
+	resourceId := getAzureVMResourceId(providerId)
 
-	suuid := node.Status.NodeInfo.SystemUUID
+	suuid := normalizeSystemUUID(node.Status.NodeInfo.SystemUUID)
 	if len(suuid) < 1 {
+		if resourceId != "" {
+			return resourceId, nil
+		}
 		glog.Errorf("Node system uuid is empty: %++v", node)
 		return "", fmt.Errorf("Empty uuid")
 	}
 
 	// we use both the reversed uuid and the actual systemUUID to cater to possibility
 	// of both big and small endian environments.
-	suuid = strings.ToLower(suuid)
 	result := fmt.Sprintf(azureFormat, suuid)
 	reversedSuuid, err := reverseUuid(suuid)
 	if err != nil {
 		glog.Warningf("Failed to reverse endianness of Azure node %s's UUID %s: %v", node.Name, suuid, err)
-		return result, nil
 	} else {
-		reversedResult := fmt.Sprintf(azureFormat, reversedSuuid)
-		return fmt.Sprintf("%s,%s", result, reversedResult), nil
+		result = fmt.Sprintf("%s,%s", result, fmt.Sprintf(azureFormat, reversedSuuid))
+	}
+	if resourceId != "" {
+		result = fmt.Sprintf("%s,%s", result, resourceId)
+	}
+	return result, nil
+}
+
+// getAzureVMResourceId returns the resource ID of the Azure VM in the given provider ID in lower case, or an empty
+// string if the provider ID does not identify an Azure VM.
+func getAzureVMResourceId(providerId string) string {
+	if !strings.HasPrefix(providerId, azurePrefix) {
+		return ""
+	}
+	// Keep the leading slash of the resource ID: azure:///subscriptions/... -> /subscriptions/...
+	resourceId := strings.ToLower(providerId[len(azurePrefix)-1:])
+	if !strings.HasPrefix(resourceId, azureSubscriptionsPrefix) ||
+		!strings.Contains(resourceId, azureComputeProvider) {
+		return ""
 	}
+	parts := strings.Split(resourceId, "/")
+	if len(parts) < 2 || parts[len(parts)-2] != azureVirtualMachines || parts[len(parts)-1] == "" {
+		return ""
+	}
+	return resourceId
 }
 
 /**
@@ -166,7 +226,11 @@ Input GCE.k8s.Node info:
   spec:
     providerID: gce://turbonomic-eng/us-central1-a/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0
 
- Output:  gcp::us-central1-a::VM::8108478110475488564
+ Output:  gcp::us-central1-a::VM::8108478110475488564,
+          https://www.googleapis.com/compute/v1/projects/turbonomic-eng/zones/us-central1-a/instances/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0
+
+ The instance ID annotation is set on the GKE nodes only; the other GCE nodes are stitched by the self-link of
+ the instance alone.
 */
 
 type gceNodeUUIDGetter struct {
@@ -180,26 +244,27 @@ func (gce *gceNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
 	instanceId := node.ObjectMeta.Annotations["container.googleapis.com/instance_id"]
 	providerId := node.Spec.ProviderID
 
-	if len(instanceId) == 0 {
-		glog.Errorf("Not a valid GCE instanceId: %++v", node)
-		return "", fmt.Errorf("Invalid")
-	}
-
 	if !strings.HasPrefix(providerId, gcePrefix) {
 		glog.Errorf("Not a valid GCE node uuid: %++v", node)
 		return "", fmt.Errorf("Invalid")
 	}
 
-	//2. split the suffix into two parts:
-	// gce://turbonomic-eng/us-central1-a/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0 -> [us-central1-a, i-0be85bb9db1707470]
+	//2. split the suffix into the project, zone and instance name:
+	// gce://turbonomic-eng/us-central1-a/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0 -> [turbonomic-eng, us-central1-a, gke-enlin-cluster-1-default-pool-b0f2516c-mrl0]
 	suffix := providerId[len(gcePrefix):]
 	parts := strings.Split(suffix, "/")
-	if len(parts) != 3 {
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
 		glog.Errorf("Failed to split uuid (%d): %v", len(parts), parts)
 		return "", fmt.Errorf("Invalid")
 	}
+	selfLink := fmt.Sprintf(gceSelfLinkFormat, parts[0], parts[1], parts[2])
 
-	result := fmt.Sprintf(gceFormat, parts[1], instanceId)
+	if len(instanceId) == 0 {
+		glog.V(3).Infof("GCE instanceId of node %s is not found, stitching by its self-link %s", node.Name, selfLink)
+		return selfLink, nil
+	}
+
+	result := fmt.Sprintf("%s,%s", fmt.Sprintf(gceFormat, parts[1], instanceId), selfLink)
 	return result, nil
 }
 
@@ -249,7 +314,7 @@ func (v *vsphereNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
 	//   If it is, append that as another set of ids including the reversed id
 	//   29e465c7-74d4-4a63-9ce4-41a7c04ba01d,c765e429-d474-634a-9ce4-41a7c04ba01d,
 	//   4200c244-1e27-8473-ab44-999195de924c,44c20042-271e-7384-ab44-999195de924c
-	suuid := strings.ToLower(node.Status.NodeInfo.SystemUUID)
+	suuid := normalizeSystemUUID(node.Status.NodeInfo.SystemUUID)
 	if suuid != "" && suuid != providerID {
 		stitchingID = fmt.Sprintf("%s,%s", stitchingID, suuid)
 		reversedSuuid, err := reverseUuid(suuid)
@@ -263,6 +328,34 @@ func (v *vsphereNodeUUIDGetter) GetUUID(node *api.Node) (string, error) {
 	return stitchingID, nil
 }
 
+// NewNodeUUIDGetter returns the node UUID getter of the given cloud provider, which is used for all the nodes in
+// place of the getter detected from the provider ID of each node.
+func NewNodeUUIDGetter(provider string) (NodeUUIDGetter, error) {
+	switch strings.ToLower(provider) {
+	case DefaultProvider:
+		return &defaultNodeUUIDGetter{}, nil
+	case AWSProvider:
+		return &awsNodeUUIDGetter{}, nil
+	case AzureProvider:
+		return &azureNodeUUIDGetter{}, nil
+	case GCPProvider:
+		return &gceNodeUUIDGetter{}, nil
+	case VsphereProvider:
+		return &vsphereNodeUUIDGetter{}, nil
+	}
+	return nil, fmt.Errorf("unsupported stitching provider %q, only [%s, %s, %s, %s, %s] are acceptable",
+		provider, DefaultProvider, AWSProvider, AzureProvider, GCPProvider, VsphereProvider)
+}
+
+// normalizeSystemUUID returns the system UUID of a node in lower case, without the braces and the spaces around it
+// that some nodes report, e.g. the Windows nodes: " {4200979A-4EF9-E49B-6BD6-FDBAD2BE7252} " ->
+// 4200979a-4ef9-e49b-6bd6-fdbad2be7252.
+func normalizeSystemUUID(suuid string) string {
+	suuid = strings.TrimSpace(suuid)
+	suuid = strings.TrimSuffix(strings.TrimPrefix(suuid, "{"), "}")
+	return strings.ToLower(strings.TrimSpace(suuid))
+}
+
 func reverseUuid(oid string) (string, error) {
 	parts := strings.Split(oid, uuidSeparator)
 	if len(parts) != 5 {
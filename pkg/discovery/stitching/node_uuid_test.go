@@ -63,6 +63,8 @@ func TestDefaultNodeUUIDGetter_GetUUID(t *testing.T) {
 	tests := [][]string{
 		{"4200979A-4EF9-E49B-6BD6-FDBAD2BE7252", "4200979a-4ef9-e49b-6bd6-fdbad2be7252,9a970042-f94e-9be4-6bd6-fdbad2be7252"},
 		{"DE7D3FE4-7A31-C74F-BBA7-3AE729EABC7E", "de7d3fe4-7a31-c74f-bba7-3ae729eabc7e,e43f7dde-317a-4fc7-bba7-3ae729eabc7e"},
+		// The UUID of a Windows node in braces
+		{" {4200979A-4EF9-E49B-6BD6-FDBAD2BE7252}", "4200979a-4ef9-e49b-6bd6-fdbad2be7252,9a970042-f94e-9be4-6bd6-fdbad2be7252"},
 	}
 
 	vm := &defaultNodeUUIDGetter{}
@@ -82,6 +84,33 @@ func TestDefaultNodeUUIDGetter_GetUUID(t *testing.T) {
 	}
 }
 
+func TestAzureNodeUUIDGetter_GetUUIDWithResourceId(t *testing.T) {
+	vmId := "/subscriptions/758ad253-cbf5-4b18-8863-3eed0825bf07/resourceGroups/spceastus2/providers/Microsoft.Compute/virtualMachines/spc1w695w0-master-1"
+	vmssId := "/subscriptions/758ad253-cbf5-4b18-8863-3eed0825bf07/resourceGroups/MC_aks_aks_eastus/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1-12345678-vmss/virtualMachines/0"
+	tests := [][]string{
+		{azurePrefix[:len(azurePrefix)-1] + vmId, "D4DD3FE4-7A31-C74F-BBA7-3AE729EABA6E",
+			"azure::VM::d4dd3fe4-7a31-c74f-bba7-3ae729eaba6e,azure::VM::e43fddd4-317a-4fc7-bba7-3ae729eaba6e," + strings.ToLower(vmId)},
+		{azurePrefix[:len(azurePrefix)-1] + vmssId, "", strings.ToLower(vmssId)},
+		{azurePrefix + "subscriptions/758ad253/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/lb",
+			"D4DD3FE4-7A31-C74F-BBA7-3AE729EABA6E",
+			"azure::VM::d4dd3fe4-7a31-c74f-bba7-3ae729eaba6e,azure::VM::e43fddd4-317a-4fc7-bba7-3ae729eaba6e"},
+	}
+
+	azure := &azureNodeUUIDGetter{}
+	for _, test := range tests {
+		node := &api.Node{}
+		node.Spec.ProviderID = test[0]
+		node.Status.NodeInfo.SystemUUID = test[1]
+		result, err := azure.GetUUID(node)
+		assert.Nil(t, err, test[0])
+		assert.Equal(t, test[2], result, test[0])
+	}
+
+	// Neither the system UUID nor the resource ID is available
+	_, err := azure.GetUUID(mockAzureNode(""))
+	assert.NotNil(t, err)
+}
+
 func TestAWSNodeUUIDGetter_GetUUID(t *testing.T) {
 	tests := [][]string{
 		{"aws:///us-west-2a/i-0be85bb9db1707470", "aws::us-west-2::VM::i-0be85bb9db1707470"},
@@ -105,6 +134,47 @@ func TestAWSNodeUUIDGetter_GetUUID(t *testing.T) {
 	}
 }
 
+func TestAWSNodeUUIDGetter_GetUUIDWithLabels(t *testing.T) {
+	aws := &awsNodeUUIDGetter{}
+
+	// The zone is taken from the labels if it is left out of the provider ID
+	node := mockAwsNode("aws:////i-0be85bb9db1707470")
+	node.Labels = map[string]string{api.LabelTopologyZone: "us-east-2c"}
+	result, err := aws.GetUUID(node)
+	assert.Nil(t, err)
+	assert.Equal(t, "aws::us-east-2::VM::i-0be85bb9db1707470", result)
+
+	// The region label takes precedence over the zone, e.g., for the local zones
+	node = mockAwsNode("aws:///us-west-2-lax-1a/i-0be85bb9db1707470")
+	node.Labels = map[string]string{api.LabelTopologyRegion: "us-west-2"}
+	result, err = aws.GetUUID(node)
+	assert.Nil(t, err)
+	assert.Equal(t, "aws::us-west-2::VM::i-0be85bb9db1707470", result)
+
+	for _, providerId := range []string{"aws:////i-0be85bb9db1707470", "aws:///us-west-2a/", "gce://project/zone/name"} {
+		_, err = aws.GetUUID(mockAwsNode(providerId))
+		assert.NotNil(t, err, providerId)
+	}
+}
+
+func TestNewNodeUUIDGetter(t *testing.T) {
+	for provider, name := range map[string]string{
+		"default": "Default",
+		"aws":     "AWS",
+		"AWS":     "AWS",
+		"azure":   "Azure",
+		"gcp":     "GCP",
+		"vsphere": "Vsphere",
+	} {
+		getter, err := NewNodeUUIDGetter(provider)
+		if assert.Nil(t, err, provider) {
+			assert.Equal(t, name, getter.Name(), provider)
+		}
+	}
+	_, err := NewNodeUUIDGetter("openstack")
+	assert.NotNil(t, err)
+}
+
 func TestAzureNodeUUIDGetter_GetUUID(t *testing.T) {
 	tests := [][]string{
 		{"D4DD3FE4-7A31-C74F-BBA7-3AE729EABA6E", "azure::VM::d4dd3fe4-7a31-c74f-bba7-3ae729eaba6e,azure::VM::e43fddd4-317a-4fc7-bba7-3ae729eaba6e"},
@@ -164,7 +234,8 @@ func TestVsphereNodeUUIDGetter_GetUUID(t *testing.T) {
 
 func TestGKENodeUUIDGetter_GetUUID(t *testing.T) {
 	tests := [][]string{
-		{"gce://turbonomic-eng/us-central1-a/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0", "gcp::us-central1-a::VM::8108478110475488564"},
+		{"gce://turbonomic-eng/us-central1-a/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0", "gcp::us-central1-a::VM::8108478110475488564," +
+			"https://www.googleapis.com/compute/v1/projects/turbonomic-eng/zones/us-central1-a/instances/gke-enlin-cluster-1-default-pool-b0f2516c-mrl0"},
 	}
 
 	gke := &gceNodeUUIDGetter{}
@@ -184,6 +255,21 @@ func TestGKENodeUUIDGetter_GetUUID(t *testing.T) {
 	}
 }
 
+func TestGCENodeUUIDGetter_GetUUIDWithoutInstanceId(t *testing.T) {
+	gce := &gceNodeUUIDGetter{}
+
+	node := &api.Node{}
+	node.Spec.ProviderID = "gce://turbonomic-eng/us-east1-b/k8s-worker-1"
+	result, err := gce.GetUUID(node)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://www.googleapis.com/compute/v1/projects/turbonomic-eng/zones/us-east1-b/instances/k8s-worker-1", result)
+
+	for _, providerId := range []string{"gce://turbonomic-eng/us-east1-b", "gce:///us-east1-b/k8s-worker-1", "aws:///us-west-2a/i-0be85bb9db1707470"} {
+		_, err = gce.GetUUID(mockGKENode(providerId))
+		assert.NotNil(t, err, providerId)
+	}
+}
+
 func TestReverseUUID(t *testing.T) {
 	uuids := []string{
 		"F4843642-7461-5AF2-5EF5-DA59C298CF44",
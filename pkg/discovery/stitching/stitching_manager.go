@@ -11,11 +11,15 @@ import (
 	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
 
 	"github.com/golang/glog"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 )
 
 const (
 	UUID StitchingPropertyType = "UUID"
 	IP   StitchingPropertyType = "IP"
+	// AUTO selects the UUID or the IP stitching for each node, based on its provider ID
+	AUTO StitchingPropertyType = "AUTO"
 
 	// The property used for node property and replacement entity metadata
 	proxyVMIP       string = "Proxy_VM_IP"
@@ -41,34 +45,64 @@ const (
 // The property type that is used for stitching. For example "UUID", "IP address".
 type StitchingPropertyType string
 
+// ParseStitchingPropertyType parses the stitching property type from its case-insensitive name.
+func ParseStitchingPropertyType(name string) (StitchingPropertyType, error) {
+	pType := StitchingPropertyType(strings.ToUpper(name))
+	switch pType {
+	case UUID, IP, AUTO:
+		return pType, nil
+	}
+	return "", fmt.Errorf("unsupported stitching type %q, only [%v, %v, %v] are acceptable",
+		name, strings.ToLower(string(UUID)), strings.ToLower(string(IP)), strings.ToLower(string(AUTO)))
+}
+
 type StitchingManager struct {
 	// key: node name; value: UID or IP for stitching
 	nodeStitchingIDMap map[string]string
 
+	// key: node name; value: UUID or IP, the stitching type detected for the node with the AUTO stitching type
+	nodeStitchTypeMap map[string]StitchingPropertyType
+
 	// The property used for stitching.
 	stitchType StitchingPropertyType
 
 	// get node reconcile UUID
 	uuidGetter NodeUUIDGetter
+
+	// The node UUID getter of the configured cloud provider, used for all the nodes if set
+	providerUuidGetter NodeUUIDGetter
 }
 
 func NewStitchingManager(pType StitchingPropertyType) *StitchingManager {
-	if pType != UUID && pType != IP {
-		glog.Errorf("Wrong stitching type: %v, only [%v, %v] are acceptable", pType, UUID, IP)
+	if pType != UUID && pType != IP && pType != AUTO {
+		glog.Errorf("Wrong stitching type: %v, only [%v, %v, %v] are acceptable", pType, UUID, IP, AUTO)
 	}
 
 	return &StitchingManager{
 		stitchType:         pType,
 		uuidGetter:         &defaultNodeUUIDGetter{},
 		nodeStitchingIDMap: make(map[string]string),
+		nodeStitchTypeMap:  make(map[string]StitchingPropertyType),
 	}
 }
 
+// WithProviderUuidGetter sets the node UUID getter used for all the nodes, regardless of their provider IDs.
+func (s *StitchingManager) WithProviderUuidGetter(getter NodeUUIDGetter) *StitchingManager {
+	s.providerUuidGetter = getter
+	return s
+}
+
 func (s *StitchingManager) SetNodeUuidGetterByProvider(providerId string) {
 	if s.stitchType == IP {
 		glog.Warningf("Stitching type is IP, no need to set NodeUuidGetter")
 	}
 
+	if s.providerUuidGetter != nil {
+		s.uuidGetter = s.providerUuidGetter
+		glog.V(4).Infof("Node UUID getter is: %v", s.uuidGetter.Name())
+		return
+	}
+
 	var getter NodeUUIDGetter
 
 	getter = &defaultNodeUUIDGetter{}
@@ -99,13 +133,46 @@ func (s *StitchingManager) StoreStitchingValue(node *api.Node) {
 		return
 	}
 
-	if s.stitchType == UUID {
+	stitchType := s.stitchType
+	if stitchType == AUTO {
+		stitchType = detectNodeStitchType(node)
+		s.nodeStitchTypeMap[node.Name] = stitchType
+		glog.V(4).Infof("Detected stitching type %v for node %v with provider ID %q", stitchType, node.Name,
+			node.Spec.ProviderID)
+	}
+
+	if stitchType == UUID {
 		s.storeNodeUUID(node)
 	} else {
 		s.storeNodeIP(node)
 	}
 }
 
+// detectNodeStitchType returns the UUID stitching type for the nodes whose provider ID identifies a cloud
+// instance or a vSphere VM, so that the node can be stitched with the VM discovered by the corresponding probe,
+// and the IP stitching type for the other nodes, e.g., the bare metal nodes.
+func detectNodeStitchType(node *api.Node) StitchingPropertyType {
+	providerId := node.Spec.ProviderID
+	for _, prefix := range []string{vspherePrefix, awsPrefix, azurePrefix, gcePrefix} {
+		if strings.HasPrefix(providerId, prefix) {
+			return UUID
+		}
+	}
+	return IP
+}
+
+// getNodeStitchType returns the stitching type of the given node, which is detected for the node with the
+// AUTO stitching type.
+func (s *StitchingManager) getNodeStitchType(nodeName string) StitchingPropertyType {
+	if s.stitchType != AUTO {
+		return s.stitchType
+	}
+	if stitchType, exist := s.nodeStitchTypeMap[nodeName]; exist {
+		return stitchType
+	}
+	return IP
+}
+
 // Find the IP address of the node and store it in nodeStitchingIPMap.
 func (s *StitchingManager) storeNodeIP(node *api.Node) {
 	nodeStitchingIP := getStitchingIP(node)
@@ -149,7 +216,7 @@ func (s *StitchingManager) GetStitchingValue(nodeName string) (string, error) {
 //	    reconcile: is to merge the proxy-VM to the real-VM;
 func (s *StitchingManager) BuildDTOProperty(nodeName string, isForReconcile bool) (*proto.EntityDTO_EntityProperty, error) {
 	propertyNamespace := DefaultPropertyNamespace
-	propertyName := s.getPropertyName(isForReconcile)
+	propertyName := s.getPropertyName(s.getNodeStitchType(nodeName), isForReconcile)
 	propertyValue, err := s.GetStitchingValue(nodeName)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to build entity stitching property: %s", err)
@@ -164,10 +231,17 @@ func (s *StitchingManager) BuildDTOProperty(nodeName string, isForReconcile bool
 // Stitch one entity with a list of VMs.
 func (s *StitchingManager) BuildDTOLayerOverProperty(nodeNames []string) (*proto.EntityDTO_EntityProperty, error) {
 	propertyNamespace := DefaultPropertyNamespace
-	propertyName := s.getStitchingPropertyName()
-
+	var stitchType StitchingPropertyType
 	values := []string{}
 	for _, nodeName := range nodeNames {
+		nodeStitchType := s.getNodeStitchType(nodeName)
+		if stitchType == "" {
+			stitchType = nodeStitchType
+		} else if nodeStitchType != stitchType {
+			err := fmt.Errorf("nodes %v are stitched with different stitching types", nodeNames)
+			glog.Errorf("Failed to build DTO stitching property: %v", err)
+			return nil, err
+		}
 		value, err := s.GetStitchingValue(nodeName)
 		if err != nil {
 			glog.Errorf("Failed to build DTO stitching property: %v", err)
@@ -176,6 +250,7 @@ func (s *StitchingManager) BuildDTOLayerOverProperty(nodeNames []string) (*proto
 		values = append(values, value)
 	}
 	propertyValue := strings.Join(values, ",")
+	propertyName := s.getStitchingPropertyName(stitchType)
 
 	return &proto.EntityDTO_EntityProperty{
 		Namespace: &propertyNamespace,
@@ -184,18 +259,18 @@ func (s *StitchingManager) BuildDTOLayerOverProperty(nodeNames []string) (*proto
 	}, nil
 }
 
-// Get the property name based on whether it is a stitching or reconciliation.
-func (s *StitchingManager) getPropertyName(isForReconcile bool) string {
+// Get the property name based on the stitching type, and whether it is a stitching or reconciliation.
+func (s *StitchingManager) getPropertyName(stitchType StitchingPropertyType, isForReconcile bool) string {
 	if isForReconcile {
-		return s.getReconciliationPropertyName()
+		return s.getReconciliationPropertyName(stitchType)
 	}
 
-	return s.getStitchingPropertyName()
+	return s.getStitchingPropertyName(stitchType)
 }
 
 // Get the name of property for entities reconciliation.
-func (s *StitchingManager) getReconciliationPropertyName() string {
-	if s.stitchType == UUID {
+func (s *StitchingManager) getReconciliationPropertyName(stitchType StitchingPropertyType) string {
+	if stitchType == UUID {
 		return proxyVMUUID
 	}
 
@@ -203,24 +278,24 @@ func (s *StitchingManager) getReconciliationPropertyName() string {
 }
 
 // Get the name of property for entities stitching.
-func (s *StitchingManager) getStitchingPropertyName() string {
-	if s.stitchType == UUID {
+func (s *StitchingManager) getStitchingPropertyName(stitchType StitchingPropertyType) string {
+	if stitchType == UUID {
 		return supplychain.SUPPLY_CHAIN_CONSTANT_UUID
 	}
 	return supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS
 }
 
-// Create the meta data that will be used during the reconciliation process.
+// Create the meta data that will be used during the reconciliation process of the given node.
 // This seems only applicable for VirtualMachines.
-func (s *StitchingManager) GenerateReconciliationMetaData() (*proto.EntityDTO_ReplacementEntityMetaData, error) {
+func (s *StitchingManager) GenerateReconciliationMetaData(nodeName string) (*proto.EntityDTO_ReplacementEntityMetaData, error) {
 	replacementEntityMetaDataBuilder := builder.NewReplacementEntityMetaDataBuilder()
-	switch s.stitchType {
+	switch stitchType := s.getNodeStitchType(nodeName); stitchType {
 	case UUID:
 		replacementEntityMetaDataBuilder.Matching(proxyVMUUID).MatchingExternal(supplychain.VM_UUID)
 	case IP:
 		replacementEntityMetaDataBuilder.Matching(proxyVMIP).MatchingExternal(supplychain.VM_IP)
 	default:
-		return nil, fmt.Errorf("stitching property type %s is not supported", s.stitchType)
+		return nil, fmt.Errorf("stitching property type %s is not supported", stitchType)
 	}
 	usedAndCapacityPropertyNames := []string{builder.PropertyCapacity, builder.PropertyUsed}
 	vcpuUsedAndCapacityPropertyNames := []string{builder.PropertyCapacity, builder.PropertyUsed, builder.PropertyPeak}
@@ -242,28 +317,18 @@ func (s *StitchingManager) GenerateReconciliationMetaData() (*proto.EntityDTO_Re
 	return meta, nil
 }
 
-// Use external IP if it is available. Otherwise use legacy host IP
+// Use the external IPs if they are available. Otherwise use the legacy host IPs. Both the IPv4 and the IPv6 addresses
+// of a dual-stack node are stitching candidates, e.g. 10.10.0.4,fd00:10::4
 func getStitchingIP(node *api.Node) string {
 	// Node IP Address
-	ip := parseNodeIP(node, api.NodeExternalIP)
-	if ip == "" {
-		ip = parseNodeIP(node, api.NodeInternalIP)
+	ips := util.GetNodeIPs(node, api.NodeExternalIP)
+	if len(ips) == 0 {
+		ips = util.GetNodeIPs(node, api.NodeInternalIP)
 	}
-	if ip == "" {
+	if len(ips) == 0 {
 		glog.Errorf("Failed to find IP for node %s", node.Name)
+		return ""
 	}
-
-	return ip
-}
-
-// Parse the Node instances returned by the kubernetes API to get the IP address
-func parseNodeIP(node *api.Node, addressType api.NodeAddressType) string {
-	nodeAddresses := node.Status.Addresses
-	for _, nodeAddress := range nodeAddresses {
-		if nodeAddress.Type == addressType && nodeAddress.Address != "" {
-			glog.V(4).Infof("%s : %s is %s", node.Name, addressType, nodeAddress.Address)
-			return nodeAddress.Address
-		}
-	}
-	return ""
+	glog.V(4).Infof("Stitching IPs of node %s are %v", node.Name, ips)
+	return strings.Join(ips, ",")
 }
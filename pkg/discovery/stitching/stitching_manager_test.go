@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	api "k8s.io/api/core/v1"
 )
 
 func TestNewStitchingManager(t *testing.T) {
@@ -179,3 +181,87 @@ func TestStitchingManager_BuildDTOLayerOverProperty_Default(t *testing.T) {
 		fmt.Printf("%++v\n", property)
 	}
 }
+
+func TestParseStitchingPropertyType(t *testing.T) {
+	for name, expected := range map[string]StitchingPropertyType{"uuid": UUID, "IP": IP, "auto": AUTO} {
+		pType, err := ParseStitchingPropertyType(name)
+		if err != nil || pType != expected {
+			t.Errorf("Wrong stitching type of %v: %v Vs. %v, %v", name, pType, expected, err)
+		}
+	}
+	if _, err := ParseStitchingPropertyType("vmware"); err == nil {
+		t.Errorf("Expected error for invalid stitching type")
+	}
+}
+
+func TestStitchingManager_StoreStitchingValue_Auto(t *testing.T) {
+	awsNode := mockAwsNode("aws:///us-west-2a/i-0be85bb9db1707470")
+	awsNode.Name = "aws-node"
+	vsphereNode := mockVsphereNode("29e465c7-74d4-4a63-9ce4-41a7c04ba01d", "")
+	vsphereNode.Name = "vsphere-node"
+	metalNode := mockNode("4200979A-4EF9-E49B-6BD6-FDBAD2BE7252")
+	metalNode.Name = "metal-node"
+	metalNode.Status.Addresses = []api.NodeAddress{{Type: api.NodeInternalIP, Address: "10.0.0.1"}}
+	dualStackNode := mockNode("")
+	dualStackNode.Name = "dual-stack-node"
+	dualStackNode.Status.Addresses = []api.NodeAddress{
+		{Type: api.NodeInternalIP, Address: "10.0.0.2"},
+		{Type: api.NodeInternalIP, Address: "fd00::2"},
+	}
+
+	m := NewStitchingManager(AUTO)
+	for _, node := range []*api.Node{awsNode, vsphereNode, metalNode, dualStackNode} {
+		m.SetNodeUuidGetterByProvider(node.Spec.ProviderID)
+		m.StoreStitchingValue(node)
+	}
+
+	tests := [][]string{
+		{"aws-node", proxyVMUUID, "aws::us-west-2::VM::i-0be85bb9db1707470"},
+		{"vsphere-node", proxyVMUUID, "29e465c7-74d4-4a63-9ce4-41a7c04ba01d,c765e429-d474-634a-9ce4-41a7c04ba01d"},
+		{"metal-node", proxyVMIP, "10.0.0.1"},
+		{"dual-stack-node", proxyVMIP, "10.0.0.2,fd00::2"},
+	}
+	for _, test := range tests {
+		property, err := m.BuildDTOProperty(test[0], true)
+		if err != nil {
+			t.Errorf("Failed to build property of node %v: %v", test[0], err)
+			continue
+		}
+		if property.GetName() != test[1] || property.GetValue() != test[2] {
+			t.Errorf("Wrong property of node %v: %v=%v Vs. %v=%v", test[0], property.GetName(),
+				property.GetValue(), test[1], test[2])
+		}
+		metaData, err := m.GenerateReconciliationMetaData(test[0])
+		if err != nil {
+			t.Errorf("Failed to build reconciliation metadata of node %v: %v", test[0], err)
+			continue
+		}
+		if metaData.GetIdentifyingProp()[0] != test[1] {
+			t.Errorf("Wrong reconciliation metadata of node %v: %v Vs. %v", test[0],
+				metaData.GetIdentifyingProp(), test[1])
+		}
+	}
+
+	if _, err := m.BuildDTOLayerOverProperty([]string{"aws-node", "metal-node"}); err == nil {
+		t.Errorf("Expected error for nodes with different stitching types")
+	}
+}
+
+func TestStitchingManager_WithProviderUuidGetter(t *testing.T) {
+	// The configured provider takes precedence over the one detected from the provider ID of the node
+	node := mockAwsNode("aws:///us-west-2a/i-0be85bb9db1707470")
+	node.Name = "node"
+	node.Status.NodeInfo.SystemUUID = "4200979A-4EF9-E49B-6BD6-FDBAD2BE7252"
+
+	getter, _ := NewNodeUUIDGetter(DefaultProvider)
+	m := NewStitchingManager(UUID).WithProviderUuidGetter(getter)
+	m.SetNodeUuidGetterByProvider(node.Spec.ProviderID)
+	m.StoreStitchingValue(node)
+	value, err := m.GetStitchingValue(node.Name)
+	if err != nil {
+		t.Fatalf("Failed to get stitching value: %v", err)
+	}
+	if value != "4200979a-4ef9-e49b-6bd6-fdbad2be7252,9a970042-f94e-9be4-6bd6-fdbad2be7252" {
+		t.Errorf("Wrong stitching value of node with the default provider: %v", value)
+	}
+}
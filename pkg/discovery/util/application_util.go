@@ -15,7 +15,7 @@ import (
 //	If a pod is created by a replication controller, then the name is like name-random
 //	if a pod is created by a deployment, then the name is like name-generated-random
 func GetAppType(pod *api.Pod) string {
-	if isMirrorPod(pod) {
+	if IsMirrorPod(pod) {
 		nodeName := pod.Spec.NodeName
 		na := strings.Split(pod.Name, nodeName)
 		result := na[0]
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// DiscoveryStatus tracks whether a discovery is degraded, i.e., built from data which may be stale or
+// inconsistent, e.g. when the API server drops the watches of the informers during its own rollout.
+// It is shared between the discovery, which reports the problems of the current cycle, and the action
+// execution, which may skip the actions while the last completed discovery is degraded.
+type DiscoveryStatus struct {
+	lock sync.RWMutex
+	// The reasons why the discovery in progress is degraded, with the number of occurrences of each reason
+	reasons map[string]int
+	// The reasons why the last completed discovery is degraded, empty if it is not
+	lastReasons []string
+	// The time the last discovery completed, zero before the first discovery
+	lastCompleted time.Time
+}
+
+func NewDiscoveryStatus() *DiscoveryStatus {
+	return &DiscoveryStatus{
+		reasons: make(map[string]int),
+	}
+}
+
+// Begin starts tracking a new discovery cycle.
+func (s *DiscoveryStatus) Begin() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reasons = make(map[string]int)
+}
+
+// MarkDegraded marks the discovery in progress as degraded for the given reason.
+func (s *DiscoveryStatus) MarkDegraded(reason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reasons[reason]++
+}
+
+// RecordWatchError marks the discovery in progress as degraded because the watch of the given resource failed.
+// It can be used as the watch error handler of an informer. A storm of relists caused by repeated watch errors
+// is reported once, with the number of errors.
+func (s *DiscoveryStatus) RecordWatchError(resource string, err error) {
+	glog.V(2).Infof("Watch of %s failed during discovery: %v", resource, err)
+	s.MarkDegraded(fmt.Sprintf("watch of %s failed", resource))
+}
+
+// Complete completes the discovery in progress, and returns whether it is degraded and why.
+func (s *DiscoveryStatus) Complete() (bool, []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var reasons []string
+	for reason, count := range s.reasons {
+		if count > 1 {
+			reason = fmt.Sprintf("%s (%d times)", reason, count)
+		}
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	s.lastReasons = reasons
+	s.lastCompleted = time.Now()
+	s.reasons = make(map[string]int)
+	return len(reasons) > 0, reasons
+}
+
+// IsDegraded returns whether the last completed discovery is degraded and why.
+func (s *DiscoveryStatus) IsDegraded() (bool, []string) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.lastReasons) > 0, s.lastReasons
+}
+
+// LastCompleted returns the time the last discovery completed, or the zero time if none has completed yet.
+func (s *DiscoveryStatus) LastCompleted() time.Time {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.lastCompleted
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoveryStatus(t *testing.T) {
+	status := NewDiscoveryStatus()
+	degraded, _ := status.IsDegraded()
+	assert.False(t, degraded)
+	assert.True(t, status.LastCompleted().IsZero())
+
+	// A relist storm is reported once with the number of watch errors
+	status.Begin()
+	for i := 0; i < 3; i++ {
+		status.RecordWatchError("namespaces", fmt.Errorf("too old resource version"))
+	}
+	status.MarkDegraded("max entities exceeded")
+	degraded, reasons := status.Complete()
+	assert.True(t, degraded)
+	assert.Equal(t, []string{"max entities exceeded", "watch of namespaces failed (3 times)"}, reasons)
+	degraded, reasons = status.IsDegraded()
+	assert.True(t, degraded)
+	assert.Len(t, reasons, 2)
+
+	// The next consistent discovery clears the degraded status
+	status.Begin()
+	degraded, _ = status.Complete()
+	assert.False(t, degraded)
+	degraded, _ = status.IsDegraded()
+	assert.False(t, degraded)
+	assert.False(t, status.LastCompleted().IsZero())
+}
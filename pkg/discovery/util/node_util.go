@@ -3,6 +3,7 @@ package util
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 
 	set "github.com/deckarep/golang-set"
@@ -48,12 +49,13 @@ const (
 func GetNodeIPForMonitor(node *api.Node, source types.MonitoringSource) (string, error) {
 	switch source {
 	case types.KubeletSource, types.K8sConntrackSource:
+		// The internal IP of the primary address family of the node, which is listed first on a dual-stack node
 		hostname, ip := node.Name, ""
 		for _, addr := range node.Status.Addresses {
 			if addr.Type == api.NodeHostName && addr.Address != "" {
 				hostname = addr.Address
 			}
-			if addr.Type == api.NodeInternalIP && addr.Address != "" {
+			if addr.Type == api.NodeInternalIP && addr.Address != "" && ip == "" {
 				ip = addr.Address
 			}
 		}
@@ -66,6 +68,35 @@ func GetNodeIPForMonitor(node *api.Node, source types.MonitoringSource) (string,
 	}
 }
 
+// GetNodeIPs returns the addresses of the given type of the node, at most one per address family, i.e. the IPv4 and
+// the IPv6 addresses of a dual-stack node, in their canonical form and in the order of the node.
+func GetNodeIPs(node *api.Node, addressType api.NodeAddressType) []string {
+	var ips []string
+	var hasIPv4, hasIPv6 bool
+	for _, addr := range node.Status.Addresses {
+		if addr.Type != addressType {
+			continue
+		}
+		ip := net.ParseIP(addr.Address)
+		if ip == nil {
+			continue
+		}
+		if ip.To4() != nil {
+			if hasIPv4 {
+				continue
+			}
+			hasIPv4 = true
+		} else {
+			if hasIPv6 {
+				continue
+			}
+			hasIPv6 = true
+		}
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
+
 func GetNodeOSArch(node *api.Node) (os string, arch string) {
 	os = "unknown"
 	arch = "unknown"
@@ -96,6 +127,23 @@ func GetNodeOSArch(node *api.Node) (os string, arch string) {
 	return
 }
 
+// GetNodeOS returns the operating system of the node, e.g. linux or windows, from its OS label or, without the label,
+// from its node info. It is empty if unknown.
+func GetNodeOS(node *api.Node) string {
+	if os, found := node.Labels[NodeLabelOS]; found {
+		return os
+	}
+	if os, found := node.Labels[NodeLabelOSBeta]; found {
+		return os
+	}
+	return node.Status.NodeInfo.OperatingSystem
+}
+
+// IsWindowsNode checks if the node runs Windows, whose kubelet reports no cgroup metrics.
+func IsWindowsNode(node *api.Node) bool {
+	return node != nil && GetNodeOS(node) == WindowsOS
+}
+
 // NodeIsReady checks if a node is in Ready status.
 func NodeIsReady(node *api.Node) bool {
 	for _, condition := range node.Status.Conditions {
@@ -7,6 +7,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 )
 
 func TestNodeMatchesLabels(t *testing.T) {
@@ -154,6 +156,37 @@ func TestGetNodeOSArch(t *testing.T) {
 	}
 }
 
+func TestGetNodeOS(t *testing.T) {
+	assert.Equal(t, "windows", GetNodeOS(getNodeWithLabels(map[string]string{"beta.kubernetes.io/os": "windows"})))
+	assert.True(t, IsWindowsNode(getNodeWithLabels(map[string]string{"kubernetes.io/os": "windows"})))
+
+	// The OS of the node info of a node without the OS label
+	node := getNodeWithLabels(nil)
+	assert.Equal(t, "", GetNodeOS(node))
+	node.Status.NodeInfo.OperatingSystem = "linux"
+	assert.Equal(t, "linux", GetNodeOS(node))
+	assert.False(t, IsWindowsNode(node))
+	assert.False(t, IsWindowsNode(nil))
+}
+
+func TestGetNodeIPs(t *testing.T) {
+	node := getNodeWithLabels(nil)
+	node.Status.Addresses = []v1.NodeAddress{
+		{Type: v1.NodeHostName, Address: "node-1"},
+		{Type: v1.NodeInternalIP, Address: "FD00:10:0:0::4"},
+		{Type: v1.NodeInternalIP, Address: "10.10.0.4"},
+		{Type: v1.NodeInternalIP, Address: "10.10.0.5"},
+		{Type: v1.NodeExternalIP, Address: "not-an-ip"},
+	}
+	assert.Equal(t, []string{"fd00:10::4", "10.10.0.4"}, GetNodeIPs(node, v1.NodeInternalIP))
+	assert.Empty(t, GetNodeIPs(node, v1.NodeExternalIP))
+
+	// The kubelet is scraped on the primary address family of the node
+	ip, err := GetNodeIPForMonitor(node, types.KubeletSource)
+	assert.NoError(t, err)
+	assert.Equal(t, "FD00:10:0:0::4", ip)
+}
+
 func TestMapNodePoolToNodeNames(t *testing.T) {
 	// node in gke pool with an additional label
 	node1 := v1.Node{
@@ -20,34 +20,40 @@ type OwnerInfo struct {
 // A valid owner must be a managing controller, and have non-empty Kind, Name and Uid
 // If there are multiple valid owners, pick the first one
 func GetOwnerInfo(owners []metav1.OwnerReference) (OwnerInfo, bool) {
-	var ownerInfo OwnerInfo
+	owner, ownerSet := GetOwnerReference(owners)
+	if !ownerSet {
+		return OwnerInfo{}, false
+	}
+	return OwnerInfo{
+		Kind: owner.Kind,
+		Name: owner.Name,
+		Uid:  string(owner.UID),
+	}, true
+}
+
+// GetOwnerReference returns the owner reference of an object picked the same way as GetOwnerInfo: the managing
+// controller with non-empty Kind, Name and Uid, or the first valid owner if none is the controller.
+func GetOwnerReference(owners []metav1.OwnerReference) (metav1.OwnerReference, bool) {
+	var ownerRef metav1.OwnerReference
 	var ownerSet bool
 	for _, owner := range owners {
 		if len(owner.Kind) > 0 && len(owner.Name) > 0 && len(owner.UID) > 0 {
 			if IsController(owner) {
 				glog.V(3).Infof("Found managing controller %+v.", owner)
 				// This owner is also the controller, so we use this.
-				return OwnerInfo{
-					Kind: owner.Kind,
-					Name: owner.Name,
-					Uid:  string(owner.UID),
-				}, true
+				return owner, true
 			}
 
 			if ownerSet {
 				continue
 			}
 			ownerSet = true
-			ownerInfo = OwnerInfo{
-				Kind: owner.Kind,
-				Name: owner.Name,
-				Uid:  string(owner.UID),
-			}
+			ownerRef = owner
 		}
 	}
 
-	glog.V(3).Infof("No managing controller was found, picked the first owner in list: %+v.", ownerInfo)
-	return ownerInfo, ownerSet
+	glog.V(3).Infof("No managing controller was found, picked the first owner in list: %+v.", ownerRef)
+	return ownerRef, ownerSet
 }
 
 func IsController(owner metav1.OwnerReference) bool {
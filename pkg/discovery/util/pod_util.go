@@ -14,11 +14,13 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	client "k8s.io/client-go/kubernetes"
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/detectors"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/features"
 	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
 )
 
@@ -38,6 +40,11 @@ const (
 	TurboControllableAnnotation string = "kubeturbo.io/controllable"
 	defaultNamespace            string = "default"
 	defaultServiceName          string = "kubernetes"
+
+	// The annotation which the Istio sidecar injector adds to the pods it injects the Envoy sidecar into, and the
+	// name of the sidecar container
+	IstioSidecarStatusAnnotation string = "sidecar.istio.io/status"
+	IstioProxyContainerName      string = "istio-proxy"
 )
 
 type PodEvent struct {
@@ -62,6 +69,12 @@ func IsControllableFromAnnotation(annotations map[string]string) bool {
 	return true
 }
 
+// IsMonitoredFromAnnotation checks whether a Kubernetes object opted out of the analysis with the
+// "kubeturbo.io/monitored" annotation. If no annotation exists, the default value is true.
+func IsMonitoredFromAnnotation(annotations map[string]string) bool {
+	return !strings.EqualFold(annotations[TurboMonitorAnnotation], "false")
+}
+
 // Returns a boolean that indicates whether the given pod is a daemon pod.  A daemon pod
 // is not suspendable, clonable, or movable, and is not considered when counting
 // customers of a supplier when checking whether the supplier can suspend.
@@ -76,7 +89,7 @@ func Daemon(pod *api.Pod) bool {
 // Returns a boolean that indicates whether the given pod should be controllable.
 // Do not monitor mirror pods or pods created by DaemonSets.
 func Controllable(pod *api.Pod, mirrorPodDaemon bool) bool {
-	controllable := (!isMirrorPod(pod) || mirrorPodDaemon) && IsControllableFromAnnotation(pod.GetAnnotations())
+	controllable := (!IsMirrorPod(pod) || mirrorPodDaemon) && IsControllableFromAnnotation(pod.GetAnnotations())
 	if !controllable {
 		glog.V(4).Infof("Pod %s/%s is not controllable", pod.Namespace, pod.Name)
 	}
@@ -93,7 +106,7 @@ func HasController(pod *api.Pod) bool {
 
 // extracts mirror pod prefix. Returns the prefix and extraction result.
 func GetMirrorPodPrefix(pod *api.Pod) (string, bool) {
-	if !isMirrorPod(pod) {
+	if !IsMirrorPod(pod) {
 		return "", false
 	}
 	return strings.Replace(pod.Name, pod.Spec.NodeName, "", 1), true
@@ -103,7 +116,7 @@ func GetMirrorPods(pods []*api.Pod) []*api.Pod {
 	glog.V(3).Info("Getting mirror pods.")
 	mirrorPods := []*api.Pod{}
 	for _, pod := range pods {
-		if isMirrorPod(pod) {
+		if IsMirrorPod(pod) {
 			mirrorPods = append(mirrorPods, pod)
 		}
 	}
@@ -134,8 +147,9 @@ func GetMirrorPodPrefixToNodeNames(pods []*api.Pod) map[string]sets.String {
 	return prefixToNodeNames
 }
 
-// Check if a pod is a mirror pod.
-func isMirrorPod(pod *api.Pod) bool {
+// IsMirrorPod checks if a pod is the mirror pod of a static pod, which is managed by the kubelet of its node
+// instead of the API server.
+func IsMirrorPod(pod *api.Pod) bool {
 	annotations := pod.Annotations
 	if annotations != nil {
 		if _, exist := annotations[api.MirrorPodAnnotationKey]; exist {
@@ -158,6 +172,29 @@ func hasNodeOwner(pod *api.Pod) bool {
 	return false
 }
 
+// IsDaemonSetPod checks if a pod is created by a DaemonSet.
+func IsDaemonSetPod(pod *api.Pod) bool {
+	return isPodCreatedBy(pod, Kind_DaemonSet)
+}
+
+// IsJobPod checks if a pod is created by a Job, including the Jobs created by CronJobs.
+func IsJobPod(pod *api.Pod) bool {
+	return isPodCreatedBy(pod, Kind_Job)
+}
+
+// HasIstioSidecar checks if the Envoy sidecar of the Istio service mesh is injected into a pod.
+func HasIstioSidecar(pod *api.Pod) bool {
+	if _, exist := pod.Annotations[IstioSidecarStatusAnnotation]; exist {
+		return true
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == IstioProxyContainerName {
+			return true
+		}
+	}
+	return false
+}
+
 // Check is a pod is created by the given type of entity.
 func isPodCreatedBy(pod *api.Pod, kind string) bool {
 	ownerInfo, err := GetPodParentInfo(pod)
@@ -202,16 +239,43 @@ func GetReadyPods(pods []*api.Pod) []*api.Pod {
 }
 
 // PodIsReady checks if a pod is in Ready status.
+// When the PodReadinessGates feature is enabled, all the readiness gates of the pod must
+// also be satisfied for the pod to be considered ready.
 func PodIsReady(pod *api.Pod) bool {
 	for _, condition := range pod.Status.Conditions {
 		if condition.Type == api.PodReady {
-			return condition.Status == api.ConditionTrue
+			if condition.Status != api.ConditionTrue {
+				return false
+			}
+			if utilfeature.DefaultFeatureGate.Enabled(features.PodReadinessGates) {
+				return podReadinessGatesMet(pod)
+			}
+			return true
 		}
 	}
 	glog.Errorf("Unable to get status for pod %s", pod.Name)
 	return false
 }
 
+// podReadinessGatesMet checks if the conditions of all the readiness gates of a pod are true.
+// A pod without any readiness gate trivially meets them.
+func podReadinessGatesMet(pod *api.Pod) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		met := false
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == gate.ConditionType {
+				met = condition.Status == api.ConditionTrue
+				break
+			}
+		}
+		if !met {
+			glog.V(4).Infof("Readiness gate %s of pod %s/%s is not met", gate.ConditionType, pod.Namespace, pod.Name)
+			return false
+		}
+	}
+	return true
+}
+
 // PodIsPending checks if a scheduled pod is in Pending status
 func PodIsPending(pod *api.Pod) bool {
 	if pod.Status.Phase != api.PodPending {
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 )
 
 func createPod() *k8sapi.Pod {
@@ -101,6 +102,18 @@ func TestIsMonitoredFromAnnotation_Pod(t *testing.T) {
 	checkObject(pod, t)
 }
 
+func TestIsMonitoredFromAnnotation(t *testing.T) {
+	if !IsMonitoredFromAnnotation(nil) {
+		t.Error("Object without annotation should be monitored.")
+	}
+	if !IsMonitoredFromAnnotation(map[string]string{TurboControllableAnnotation: "false"}) {
+		t.Error("Object which is not controllable should still be monitored.")
+	}
+	if IsMonitoredFromAnnotation(map[string]string{TurboMonitorAnnotation: "False"}) {
+		t.Error("Object annotated with monitored=false should not be monitored.")
+	}
+}
+
 func TestIsMonitoredFromAnnotation_Service(t *testing.T) {
 	svc := createService()
 
@@ -192,6 +205,34 @@ func TestGetReadyPods(t *testing.T) {
 	}
 }
 
+func TestPodIsReadyWithReadinessGates(t *testing.T) {
+	gate := k8sapi.PodConditionType("example.com/load-balancer-ready")
+	pcReadyTrue := k8sapi.PodCondition{Type: k8sapi.PodReady, Status: k8sapi.ConditionTrue}
+	pcGateTrue := k8sapi.PodCondition{Type: gate, Status: k8sapi.ConditionTrue}
+	pcGateFalse := k8sapi.PodCondition{Type: gate, Status: k8sapi.ConditionFalse}
+
+	podNoGates := newPod("pod-1", pcReadyTrue)
+	podGateMet := newPod("pod-2", pcReadyTrue, pcGateTrue)
+	podGateUnmet := newPod("pod-3", pcReadyTrue, pcGateFalse)
+	podGateMissing := newPod("pod-4", pcReadyTrue)
+	for _, pod := range []*k8sapi.Pod{podGateMet, podGateUnmet, podGateMissing} {
+		pod.Spec.ReadinessGates = []k8sapi.PodReadinessGate{{ConditionType: gate}}
+	}
+
+	defer utilfeature.DefaultMutableFeatureGate.Set("PodReadinessGates=false")
+
+	utilfeature.DefaultMutableFeatureGate.Set("PodReadinessGates=false")
+	assert.True(t, PodIsReady(podGateUnmet))
+
+	utilfeature.DefaultMutableFeatureGate.Set("PodReadinessGates=true")
+	assert.True(t, PodIsReady(podNoGates))
+	assert.True(t, PodIsReady(podGateMet))
+	assert.False(t, PodIsReady(podGateUnmet))
+	assert.False(t, PodIsReady(podGateMissing))
+	assert.Equal(t, []*k8sapi.Pod{podNoGates, podGateMet},
+		GetReadyPods([]*k8sapi.Pod{podNoGates, podGateMet, podGateUnmet, podGateMissing}))
+}
+
 func makePodInDaemonSet() *k8sapi.Pod {
 	podWithOwnerRef := newPod("pod-bar")
 	isController := true
@@ -199,6 +240,33 @@ func makePodInDaemonSet() *k8sapi.Pod {
 	return podWithOwnerRef
 }
 
+func TestIsDaemonSetPod(t *testing.T) {
+	pod := makePodInDaemonSet()
+	pod.OwnerReferences[0].UID = "daemon-set-foo-uid"
+	assert.True(t, IsDaemonSetPod(pod))
+	assert.False(t, IsDaemonSetPod(newPod("pod-1")))
+}
+
+func TestIsJobPod(t *testing.T) {
+	isController := true
+	pod := newPod("pod-1")
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", Name: "job-1", UID: "job-uid",
+		Controller: &isController}}
+	assert.True(t, IsJobPod(pod))
+	assert.False(t, IsJobPod(makePodInDaemonSet()))
+	assert.False(t, IsJobPod(newPod("pod-2")))
+}
+
+func TestHasIstioSidecar(t *testing.T) {
+	pod := newPod("pod-1")
+	assert.False(t, HasIstioSidecar(pod))
+	pod.Annotations = map[string]string{IstioSidecarStatusAnnotation: `{"containers":["istio-proxy"]}`}
+	assert.True(t, HasIstioSidecar(pod))
+	pod = newPod("pod-2")
+	pod.Spec.Containers = []k8sapi.Container{{Name: "app"}, {Name: IstioProxyContainerName}}
+	assert.True(t, HasIstioSidecar(pod))
+}
+
 func TestMirroredPod(t *testing.T) {
 	pod := newPod("pod-1")
 	if !Controllable(pod, false) {
@@ -17,6 +17,48 @@ func GetCpuAndMemoryValues(resource api.ResourceList) (ctnCpuCapacityMilliCore,
 	return
 }
 
+// GetPodComputeResources returns the cpu and memory requests and limits of the pod the way the scheduler and the
+// resource quotas account them: the sum of the containers, or the largest init container if it is larger, plus the
+// overhead of the pod runtime class.
+func GetPodComputeResources(pod *api.Pod) (requests, limits api.ResourceList) {
+	requests, limits = api.ResourceList{}, api.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResourceList(requests, container.Resources.Requests)
+		addResourceList(limits, container.Resources.Limits)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		maxResourceList(requests, container.Resources.Requests)
+		maxResourceList(limits, container.Resources.Limits)
+	}
+	if pod.Spec.Overhead != nil {
+		addResourceList(requests, pod.Spec.Overhead)
+		addResourceList(limits, pod.Spec.Overhead)
+	}
+	return
+}
+
+// addResourceList adds the cpu and memory of the new resource list to the given one.
+func addResourceList(list, newList api.ResourceList) {
+	for _, name := range []api.ResourceName{api.ResourceCPU, api.ResourceMemory} {
+		if quantity, found := newList[name]; found {
+			value := list[name]
+			value.Add(quantity)
+			list[name] = value
+		}
+	}
+}
+
+// maxResourceList sets the cpu and memory of the given resource list to those of the new one where they are larger.
+func maxResourceList(list, newList api.ResourceList) {
+	for _, name := range []api.ResourceName{api.ResourceCPU, api.ResourceMemory} {
+		if quantity, found := newList[name]; found {
+			if value, found := list[name]; !found || quantity.Cmp(value) > 0 {
+				list[name] = quantity.DeepCopy()
+			}
+		}
+	}
+}
+
 // Gets the allocatable number of pods from the node resource
 func GetNumPodsAllocatable(node *api.Node) float64 {
 	// Compute both the available IP address range and the maxpods set on the node.
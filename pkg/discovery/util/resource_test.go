@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func resourceList(cpu, memory string) api.ResourceList {
+	return api.ResourceList{
+		api.ResourceCPU:    resource.MustParse(cpu),
+		api.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func TestGetPodComputeResources(t *testing.T) {
+	pod := &api.Pod{
+		Spec: api.PodSpec{
+			Containers: []api.Container{
+				{Resources: api.ResourceRequirements{Requests: resourceList("100m", "64Mi"), Limits: resourceList("200m", "128Mi")}},
+				{Resources: api.ResourceRequirements{Requests: resourceList("200m", "64Mi")}},
+			},
+		},
+	}
+	requests, limits := GetPodComputeResources(pod)
+	cpu, mem := GetCpuAndMemoryValues(requests)
+	assert.Equal(t, 300.0, cpu)
+	assert.Equal(t, 128.0*1024, mem)
+	cpu, mem = GetCpuAndMemoryValues(limits)
+	assert.Equal(t, 200.0, cpu)
+	assert.Equal(t, 128.0*1024, mem)
+
+	// The init container requests more cpu than all the containers, but less memory
+	pod.Spec.InitContainers = []api.Container{
+		{Resources: api.ResourceRequirements{Requests: resourceList("500m", "32Mi"), Limits: resourceList("500m", "32Mi")}},
+	}
+	// The overhead of the runtime class is added to the requests and the limits
+	pod.Spec.Overhead = resourceList("10m", "1Mi")
+	requests, limits = GetPodComputeResources(pod)
+	cpu, mem = GetCpuAndMemoryValues(requests)
+	assert.Equal(t, 510.0, cpu)
+	assert.Equal(t, 129.0*1024, mem)
+	cpu, mem = GetCpuAndMemoryValues(limits)
+	assert.Equal(t, 510.0, cpu)
+	assert.Equal(t, 129.0*1024, mem)
+
+	// The containers of the pod are not modified
+	assert.Equal(t, resourceList("100m", "64Mi"), pod.Spec.Containers[0].Resources.Requests)
+}
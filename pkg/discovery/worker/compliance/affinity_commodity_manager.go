@@ -11,6 +11,8 @@ import (
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
+	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
+
 	"github.com/mitchellh/hashstructure"
 )
 
@@ -132,13 +134,13 @@ func generateKey(termString string) (string, error) {
 
 func getReadableNodeSelectorTermString(term api.NodeSelectorTerm) string {
 	expressionsString, fieldsString := "", ""
-	expressionsSelectors, err := NodeSelectorRequirementsAsSelector(term.MatchExpressions)
+	expressionsSelectors, err := commonutil.NodeSelectorRequirementsAsSelector(term.MatchExpressions)
 	if err != nil {
 		expressionsString = "<error>"
 	} else {
 		expressionsString = expressionsSelectors.String()
 	}
-	fieldsSelectors, err := NodeSelectorRequirementsAsSelector(term.MatchFields)
+	fieldsSelectors, err := commonutil.NodeSelectorRequirementsAsSelector(term.MatchFields)
 	if err != nil {
 		fieldsString = "<error>"
 	} else {
@@ -2,14 +2,13 @@ package compliance
 
 import (
 	"errors"
-	"fmt"
 
 	api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
 
 	"github.com/golang/glog"
 )
@@ -33,7 +32,7 @@ func matchesNodeAffinity(pod *api.Pod, node *api.Node) bool {
 		if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
 			nodeSelectorTerms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
 			glog.V(10).Infof("Match for RequiredDuringSchedulingIgnoredDuringExecution node selector terms %+v", nodeSelectorTerms)
-			nodeAffinityMatches = nodeAffinityMatches && nodeMatchesNodeSelectorTerms(node, nodeSelectorTerms)
+			nodeAffinityMatches = nodeAffinityMatches && commonutil.NodeMatchesNodeSelectorTerms(node, nodeSelectorTerms)
 		}
 	}
 	return nodeAffinityMatches
@@ -42,27 +41,11 @@ func matchesNodeAffinity(pod *api.Pod, node *api.Node) bool {
 func matchesPvNodeAffinity(pvNodeAffinitySelectorTerms []api.NodeSelectorTerm, node *api.Node) bool {
 	nodeAffinityMatches := true
 	if len(pvNodeAffinitySelectorTerms) > 0 {
-		nodeAffinityMatches = nodeMatchesNodeSelectorTerms(node, pvNodeAffinitySelectorTerms)
+		nodeAffinityMatches = commonutil.NodeMatchesNodeSelectorTerms(node, pvNodeAffinitySelectorTerms)
 	}
 	return nodeAffinityMatches
 }
 
-// nodeMatchesNodeSelectorTerms checks if a node's labels satisfy a list of node selector terms,
-// terms are ORed, and an empty list of terms will match nothing.
-func nodeMatchesNodeSelectorTerms(node *api.Node, nodeSelectorTerms []api.NodeSelectorTerm) bool {
-	for _, req := range nodeSelectorTerms {
-		nodeSelector, err := NodeSelectorRequirementsAsSelector(req.MatchExpressions)
-		if err != nil {
-			glog.V(10).Infof("Failed to parse MatchExpressions: %+v, regarding as not match.", req.MatchExpressions)
-			return false
-		}
-		if nodeSelector.Matches(labels.Set(node.Labels)) {
-			return true
-		}
-	}
-	return false
-}
-
 //----------------------------------------- Pod Affinity -------------------------------------------------------
 
 func interPodAffinityMatches(pod *api.Pod, node *api.Node, allPodsNodesMap map[*api.Pod]*api.Node) bool {
@@ -260,40 +243,6 @@ func nodesHaveSameTopologyKey(nodeA, nodeB *api.Node, topologyKey string) bool {
 	return false
 }
 
-// NodeSelectorRequirementsAsSelector converts the []NodeSelectorRequirement api type into a struct that implements
-// labels.Selector.
-func NodeSelectorRequirementsAsSelector(nsm []api.NodeSelectorRequirement) (labels.Selector, error) {
-	if len(nsm) == 0 {
-		return labels.Nothing(), nil
-	}
-	selector := labels.NewSelector()
-	for _, expr := range nsm {
-		var op selection.Operator
-		switch expr.Operator {
-		case api.NodeSelectorOpIn:
-			op = selection.In
-		case api.NodeSelectorOpNotIn:
-			op = selection.NotIn
-		case api.NodeSelectorOpExists:
-			op = selection.Exists
-		case api.NodeSelectorOpDoesNotExist:
-			op = selection.DoesNotExist
-		case api.NodeSelectorOpGt:
-			op = selection.GreaterThan
-		case api.NodeSelectorOpLt:
-			op = selection.LessThan
-		default:
-			return nil, fmt.Errorf("%q is not a valid node selector operator", expr.Operator)
-		}
-		r, err := labels.NewRequirement(expr.Key, op, expr.Values)
-		if err != nil {
-			return nil, err
-		}
-		selector = selector.Add(*r)
-	}
-	return selector, nil
-}
-
 func getPodAffinityTerms(podAffinity *api.PodAffinity) (terms []api.PodAffinityTerm) {
 	if podAffinity != nil {
 		if len(podAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 0 {
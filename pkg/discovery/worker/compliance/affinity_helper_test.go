@@ -86,127 +86,6 @@ func TestMatchesNodeAffinity(t *testing.T) {
 	}
 }
 
-func TestMatchesNodeSelectorTerms(t *testing.T) {
-	table := []struct {
-		node              *api.Node
-		nodeSelectorTerms []api.NodeSelectorTerm
-
-		expectsMatches bool
-	}{
-		{
-			node: &api.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":  "bar",
-						"key1": "value1",
-					},
-				},
-			},
-			nodeSelectorTerms: []api.NodeSelectorTerm{
-				{
-					MatchExpressions: []api.NodeSelectorRequirement{
-						{
-							Key:      "foo",
-							Operator: api.NodeSelectorOpIn,
-							Values:   []string{"bar"},
-						},
-					},
-				},
-			},
-
-			expectsMatches: true,
-		},
-		{
-			node: &api.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":  "bar",
-						"key1": "value1",
-					},
-				},
-			},
-			nodeSelectorTerms: []api.NodeSelectorTerm{
-				{
-					MatchExpressions: []api.NodeSelectorRequirement{
-						{
-							Key:      "foo",
-							Operator: api.NodeSelectorOpIn,
-							Values:   []string{"bar"},
-						},
-						{
-							Key:      "key1",
-							Operator: api.NodeSelectorOpIn,
-							Values:   []string{"value1"},
-						},
-					},
-				},
-			},
-
-			expectsMatches: true,
-		},
-		{
-			// doesn't match.
-			node: &api.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":  "bar",
-						"key1": "value1",
-					},
-				},
-			},
-			nodeSelectorTerms: []api.NodeSelectorTerm{
-				{
-					MatchExpressions: []api.NodeSelectorRequirement{
-						{
-							Key:      "foo",
-							Operator: api.NodeSelectorOpIn,
-							Values:   []string{"bar"},
-						},
-						{
-							Key:      "key1",
-							Operator: api.NodeSelectorOpIn,
-							Values:   []string{"value2"},
-						},
-					},
-				},
-			},
-
-			expectsMatches: false,
-		},
-		{
-			// invalid operator.
-			node: &api.Node{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"foo":  "bar",
-						"key1": "value1",
-					},
-				},
-			},
-			nodeSelectorTerms: []api.NodeSelectorTerm{
-				{
-					MatchExpressions: []api.NodeSelectorRequirement{
-						{
-							Key:      "foo",
-							Operator: api.NodeSelectorOperator("invalid"),
-							Values:   []string{"bar"},
-						},
-					},
-				},
-			},
-
-			expectsMatches: false,
-		},
-	}
-
-	for i, item := range table {
-		matches := nodeMatchesNodeSelectorTerms(item.node, item.nodeSelectorTerms)
-		if matches != item.expectsMatches {
-			t.Errorf("Test case %d failed. Expects %t, got %t", i, item.expectsMatches, matches)
-		}
-	}
-}
-
 func TestAnyPodMatchesPodAffinityTerm(t *testing.T) {
 	podLabel := map[string]string{"service": "securityscan"}
 	podLabel2 := map[string]string{"security": "S1"}
@@ -85,11 +85,20 @@ func (n *NodeInfoListerImpl) HavePodsWithRequiredAntiAffinityList() ([]*NodeInfo
 	return n.nodesWithRequiredAntiAffinity, nil
 }
 
-func NewNamespaceLister(client *client.Clientset, clusterSummary *repository.ClusterSummary) (listersv1.NamespaceLister, error) {
+// NewNamespaceLister creates a namespace lister backed by an informer, which runs until stopCh is closed.
+// The watch errors of the informer, e.g. when the API server drops the watch, are reported to watchErrorHandler,
+// as the lister may be stale until the informer relists the namespaces.
+func NewNamespaceLister(client *client.Clientset, clusterSummary *repository.ClusterSummary,
+	stopCh <-chan struct{}, watchErrorHandler func(resource string, err error)) (listersv1.NamespaceLister, error) {
 	factory := informers.NewSharedInformerFactory(client, 0)
 	nsInformer := factory.Core().V1().Namespaces()
 	informer := nsInformer.Informer()
-	stopCh := make(chan struct{})
+	if watchErrorHandler != nil {
+		informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			cache.DefaultWatchErrorHandler(r, err)
+			watchErrorHandler("namespaces", err)
+		})
+	}
 	factory.Start(stopCh) // runs in background
 	//factory.WaitForCacheSync(stopCh)
 
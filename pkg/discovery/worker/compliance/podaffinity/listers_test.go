@@ -0,0 +1,57 @@
+package podaffinity
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+func TestNamespaceListerWatchError(t *testing.T) {
+	// An API server which lists the namespaces but drops the watches, e.g. during its own rollout
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") == "true" {
+			http.Error(w, "apiserver is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&v1.NamespaceList{
+			TypeMeta: metav1.TypeMeta{Kind: "NamespaceList", APIVersion: "v1"},
+			ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+			Items:    []v1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}}},
+		})
+	}))
+	defer server.Close()
+	kubeClient, err := client.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+
+	status := discoveryutil.NewDiscoveryStatus()
+	status.Begin()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	lister, err := NewNamespaceLister(kubeClient, &repository.ClusterSummary{}, stopCh, status.RecordWatchError)
+	assert.NoError(t, err)
+	nsList, err := lister.List(labels.Everything())
+	assert.NoError(t, err)
+	assert.Len(t, nsList, 1)
+
+	// The watch error is reported asynchronously by the informer
+	assert.Eventually(t, func() bool {
+		degraded, _ := status.Complete()
+		return degraded
+	}, 10*time.Second, 100*time.Millisecond)
+	degraded, reasons := status.IsDegraded()
+	assert.True(t, degraded)
+	assert.Contains(t, reasons[0], "watch of namespaces failed")
+}
@@ -0,0 +1,44 @@
+package compliance
+
+import (
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// SchedulingConstraintViolations returns the scheduling constraints of the pod which the node does not satisfy, i.e.,
+// why the scheduler would not place the pod on the node: the node selector, the required node affinity, the taints
+// not tolerated, and the required pod affinity and anti-affinity and topology spread constraints against the given
+// pods and the nodes they run on.
+// The pod itself is ignored in the given pods.
+func SchedulingConstraintViolations(pod *api.Pod, node *api.Node, allPodsNodesMap map[*api.Pod]*api.Node) []string {
+	var violations []string
+	if len(pod.Spec.NodeSelector) > 0 && !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		violations = append(violations, fmt.Sprintf("node selector %v does not match the node labels",
+			pod.Spec.NodeSelector))
+	}
+	if !matchesNodeAffinity(pod, node) {
+		violations = append(violations, "required node affinity does not match the node labels")
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		// The unschedulable taint is reported by the node readiness and placement, like in the discovery
+		if taint.Effect == api.TaintEffectPreferNoSchedule || isUnschedulableNodeTaint(*taint) {
+			continue
+		}
+		if !tolerationsTolerateTaint(pod.Spec.Tolerations, taint) {
+			violations = append(violations, fmt.Sprintf("taint %s is not tolerated", taint.ToString()))
+		}
+	}
+	if !satisfiesExistingPodsAntiAffinity(pod, node, allPodsNodesMap) {
+		violations = append(violations, "pod anti-affinity of the pods running in the same topology is violated")
+	}
+	affinity := pod.Spec.Affinity
+	if affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil) &&
+		!satisfiesPodsAffinityAntiAffinity(pod, node, affinity, allPodsNodesMap) {
+		violations = append(violations, "required pod affinity or anti-affinity is not satisfied")
+	}
+	violations = append(violations, topologySpreadViolations(pod, node, allPodsNodesMap)...)
+	return violations
+}
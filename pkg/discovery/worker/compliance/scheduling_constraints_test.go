@@ -0,0 +1,152 @@
+package compliance
+
+import (
+	"strings"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newConstraintNode(name, zone string, taints ...api.Taint) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"kubernetes.io/hostname": name, "zone": zone},
+		},
+		Spec: api.NodeSpec{Taints: taints},
+	}
+}
+
+func newConstraintPod(name string, labels map[string]string) *api.Pod {
+	return &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: labels}}
+}
+
+func TestSchedulingConstraintViolations(t *testing.T) {
+	node1 := newConstraintNode("node-1", "zone-a")
+	node2 := newConstraintNode("node-2", "zone-b",
+		api.Taint{Key: "dedicated", Value: "gpu", Effect: api.TaintEffectNoSchedule},
+		api.Taint{Key: "soft", Effect: api.TaintEffectPreferNoSchedule},
+		api.Taint{Key: unschedulableNodeTaintKey, Effect: api.TaintEffectNoSchedule})
+	replica := newConstraintPod("web-2", map[string]string{"app": "web"})
+
+	antiAffinity := &api.Affinity{PodAntiAffinity: &api.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []api.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			TopologyKey:   "zone",
+		}},
+	}}
+	zoneSpread := []api.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       "zone",
+		WhenUnsatisfiable: api.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}}
+	gpuToleration := api.Toleration{Key: "dedicated", Operator: api.TolerationOpEqual, Value: "gpu",
+		Effect: api.TaintEffectNoSchedule}
+
+	table := []struct {
+		name string
+		pod  *api.Pod
+		node *api.Node
+		// The affinity of the replica running on node-1
+		replicaAffinity *api.Affinity
+		violations      []string
+	}{
+		{
+			name: "no constraints",
+			pod:  newConstraintPod("web-1", nil),
+			node: node1,
+		},
+		{
+			name: "node selector",
+			pod: func() *api.Pod {
+				pod := newConstraintPod("web-1", nil)
+				pod.Spec.NodeSelector = map[string]string{"zone": "zone-b"}
+				return pod
+			}(),
+			node:       node1,
+			violations: []string{"node selector"},
+		},
+		{
+			name:       "taint not tolerated",
+			pod:        newConstraintPod("web-1", nil),
+			node:       node2,
+			violations: []string{"taint dedicated=gpu:NoSchedule"},
+		},
+		{
+			name: "taint tolerated",
+			pod: func() *api.Pod {
+				pod := newConstraintPod("web-1", nil)
+				pod.Spec.Tolerations = []api.Toleration{gpuToleration}
+				return pod
+			}(),
+			node: node2,
+		},
+		{
+			name: "pod anti-affinity",
+			pod: func() *api.Pod {
+				pod := newConstraintPod("web-1", map[string]string{"app": "web"})
+				pod.Spec.Affinity = antiAffinity
+				return pod
+			}(),
+			node:       node1,
+			violations: []string{"required pod affinity or anti-affinity"},
+		},
+		{
+			name:            "anti-affinity of the existing pods",
+			pod:             newConstraintPod("web-1", map[string]string{"app": "web"}),
+			node:            node1,
+			replicaAffinity: antiAffinity,
+			violations:      []string{"pod anti-affinity of the pods running in the same topology"},
+		},
+		{
+			name: "topology spread skew",
+			pod: func() *api.Pod {
+				pod := newConstraintPod("web-1", map[string]string{"app": "web"})
+				pod.Spec.TopologySpreadConstraints = zoneSpread
+				return pod
+			}(),
+			// zone-a would have 2 replicas and zone-b none
+			node:       node1,
+			violations: []string{"topology spread constraint on zone"},
+		},
+		{
+			name: "topology spread within the max skew",
+			pod: func() *api.Pod {
+				pod := newConstraintPod("web-1", map[string]string{"app": "web"})
+				pod.Spec.TopologySpreadConstraints = zoneSpread
+				pod.Spec.Tolerations = []api.Toleration{gpuToleration}
+				return pod
+			}(),
+			node: node2,
+		},
+		{
+			name: "topology spread without the topology label",
+			pod: func() *api.Pod {
+				pod := newConstraintPod("web-1", map[string]string{"app": "web"})
+				pod.Spec.TopologySpreadConstraints = zoneSpread
+				return pod
+			}(),
+			node:       &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-3"}},
+			violations: []string{"node has no label zone"},
+		},
+	}
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			existingReplica := replica.DeepCopy()
+			existingReplica.Spec.Affinity = tt.replicaAffinity
+			// The pod itself is ignored
+			allPodsNodesMap := map[*api.Pod]*api.Node{existingReplica: node1, tt.pod: node2}
+			violations := SchedulingConstraintViolations(tt.pod, tt.node, allPodsNodesMap)
+			if len(violations) != len(tt.violations) {
+				t.Fatalf("Expected violations %v, got %v", tt.violations, violations)
+			}
+			for i, violation := range violations {
+				if !strings.HasPrefix(violation, tt.violations[i]) {
+					t.Errorf("Expected violation %q, got %q", tt.violations[i], violation)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package compliance
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+const topologySpreadKeyPrefix = "[k8s topology spread]"
+
+// spreadConstraint is a topology spread constraint of a pod which the scheduler enforces, i.e., which is not only a
+// preference of the pod.
+type spreadConstraint struct {
+	maxSkew     int32
+	topologyKey string
+	selector    labels.Selector
+	// The readable form of the constraint, unique among the constraints of the pods of the namespace
+	key string
+}
+
+// getSpreadConstraints returns the topology spread constraints of the pod which the scheduler enforces.
+func getSpreadConstraints(pod *api.Pod) []spreadConstraint {
+	var constraints []spreadConstraint
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != api.DoNotSchedule {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+		if err != nil {
+			glog.Errorf("Invalid label selector of the topology spread constraint of pod %s/%s: %v",
+				pod.Namespace, pod.Name, err)
+			continue
+		}
+		constraints = append(constraints, spreadConstraint{
+			maxSkew:     constraint.MaxSkew,
+			topologyKey: constraint.TopologyKey,
+			selector:    selector,
+			key: fmt.Sprintf("%s %s|%s|%s|%d", topologySpreadKeyPrefix, pod.Namespace, constraint.TopologyKey,
+				metav1.FormatLabelSelector(constraint.LabelSelector), constraint.MaxSkew),
+		})
+	}
+	return constraints
+}
+
+// selects returns true if the constraint of the given pod selects the other pod.
+func (c *spreadConstraint) selects(pod, other *api.Pod) bool {
+	return other.Namespace == pod.Namespace && c.selector.Matches(labels.Set(other.Labels))
+}
+
+// domainCounts counts the pods selected by the constraint of the pod in each topology domain, and on each node, of
+// the given nodes which the pod can be scheduled on according to its node selector and node affinity, like the
+// scheduler does. The domains without pods are counted as well. The excluded pod, if any, is not counted.
+func (c *spreadConstraint) domainCounts(pod *api.Pod, nodes []*api.Node, podsNodes map[*api.Pod]*api.Node,
+	excluded *api.Pod) (map[string]int, map[string]int) {
+	domains := make(map[string]int)
+	for _, node := range nodes {
+		if domain, found := node.Labels[c.topologyKey]; found && nodeMatchesPodNodeSelector(pod, node) {
+			domains[domain] += 0
+		}
+	}
+	nodeCounts := make(map[string]int)
+	for other, node := range podsNodes {
+		if excluded != nil && other.Name == excluded.Name && other.Namespace == excluded.Namespace {
+			continue
+		}
+		domain, found := node.Labels[c.topologyKey]
+		if _, eligible := domains[domain]; !found || !eligible || !c.selects(pod, other) {
+			continue
+		}
+		domains[domain]++
+		nodeCounts[node.Name]++
+	}
+	return domains, nodeCounts
+}
+
+// nodeMatchesPodNodeSelector checks if the node matches the node selector and the required node affinity of the pod.
+func nodeMatchesPodNodeSelector(pod *api.Pod, node *api.Node) bool {
+	if len(pod.Spec.NodeSelector) > 0 &&
+		!labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	return matchesNodeAffinity(pod, node)
+}
+
+// minDomainCount returns the least number of pods of the given topology domains.
+func minDomainCount(domains map[string]int) int {
+	minCount := -1
+	for _, count := range domains {
+		if minCount < 0 || count < minCount {
+			minCount = count
+		}
+	}
+	if minCount < 0 {
+		return 0
+	}
+	return minCount
+}
+
+// topologySpreadViolations returns the topology spread constraints of the pod which would be violated if the pod ran
+// on the node, given the pods running in the cluster and the nodes they run on. The domains are those of the nodes
+// of the given pods, and of the node, as every schedulable node runs at least the pods of the DaemonSets.
+func topologySpreadViolations(pod *api.Pod, node *api.Node, allPodsNodesMap map[*api.Pod]*api.Node) []string {
+	constraints := getSpreadConstraints(pod)
+	if len(constraints) == 0 {
+		return nil
+	}
+	nodes := []*api.Node{node}
+	seen := map[string]bool{node.Name: true}
+	for _, other := range allPodsNodesMap {
+		if !seen[other.Name] {
+			seen[other.Name] = true
+			nodes = append(nodes, other)
+		}
+	}
+	var violations []string
+	for i := range constraints {
+		constraint := &constraints[i]
+		domain, found := node.Labels[constraint.topologyKey]
+		if !found {
+			violations = append(violations, fmt.Sprintf("node has no label %s of topology spread constraint",
+				constraint.topologyKey))
+			continue
+		}
+		domains, _ := constraint.domainCounts(pod, nodes, allPodsNodesMap, pod)
+		count := domains[domain]
+		if constraint.selects(pod, pod) {
+			count++
+		}
+		if skew := count - minDomainCount(domains); skew > int(constraint.maxSkew) {
+			violations = append(violations, fmt.Sprintf("topology spread constraint on %s is violated with "+
+				"skew %d above max skew %d", constraint.topologyKey, skew, constraint.maxSkew))
+		}
+	}
+	return violations
+}
+
+// TopologySpreadProcessor creates the segmentation commodities which keep the market from moving the pods out of the
+// topology spread constraints they are scheduled with: each node sells a segmentation commodity per constraint,
+// whose capacity is the number of the pods of the constraint on the node plus the number of pods which the topology
+// domain of the node can still accept without exceeding the max skew, and each pod of the constraint buys one from
+// its node. The capacity is conservative as it does not account for the pod leaving its current domain.
+type TopologySpreadProcessor struct {
+	cluster *repository.ClusterSummary
+}
+
+func NewTopologySpreadProcessor(cluster *repository.ClusterSummary) *TopologySpreadProcessor {
+	return &TopologySpreadProcessor{
+		cluster: cluster,
+	}
+}
+
+// Process adds the topology spread segmentation commodities to the given node and pod entity DTOs.
+func (p *TopologySpreadProcessor) Process(entityDTOs []*proto.EntityDTO) {
+	var nodes []*api.Node
+	podsNodes := make(map[*api.Pod]*api.Node)
+	for nodeName, kubeNode := range p.cluster.NodeMap {
+		nodes = append(nodes, kubeNode.Node)
+		for _, pod := range p.cluster.NodeToRunningPods[nodeName] {
+			podsNodes[pod] = kubeNode.Node
+		}
+	}
+
+	// The constraints by key, each with the first pod which has it, and the keys of the constraints of each pod
+	constraints := make(map[string]spreadConstraint)
+	constraintPods := make(map[string]*api.Pod)
+	podKeys := make(map[string][]string)
+	podProviders := make(map[string]string)
+	for pod := range podsNodes {
+		for _, constraint := range getSpreadConstraints(pod) {
+			// The pods not selected by their own constraint do not change the spread when moved
+			if !constraint.selects(pod, pod) {
+				continue
+			}
+			if _, found := constraints[constraint.key]; !found {
+				constraints[constraint.key] = constraint
+				constraintPods[constraint.key] = pod
+			}
+			podKeys[string(pod.UID)] = append(podKeys[string(pod.UID)], constraint.key)
+			podProviders[string(pod.UID)] = string(podsNodes[pod].UID)
+		}
+	}
+	if len(constraints) == 0 {
+		return
+	}
+
+	// The capacity of the commodity of each constraint sold by each node
+	nodeCapacities := make(map[string]map[string]float64)
+	for key := range constraints {
+		constraint := constraints[key]
+		domains, nodeCounts := constraint.domainCounts(constraintPods[key], nodes, podsNodes, nil)
+		maxCount := minDomainCount(domains) + int(constraint.maxSkew)
+		for _, node := range nodes {
+			domain, found := node.Labels[constraint.topologyKey]
+			count, eligible := domains[domain]
+			if !found || !eligible {
+				continue
+			}
+			if nodeCapacities[string(node.UID)] == nil {
+				nodeCapacities[string(node.UID)] = make(map[string]float64)
+			}
+			nodeCapacities[string(node.UID)][key] = float64(nodeCounts[node.Name])
+			if count < maxCount {
+				nodeCapacities[string(node.UID)][key] += float64(maxCount - count)
+			}
+		}
+	}
+
+	nodeDTOs, podDTOs := retrieveNodeAndPodDTOs(entityDTOs)
+	totalSoldComms := 0
+	for _, nodeDTO := range nodeDTOs {
+		for key, capacity := range nodeCapacities[nodeDTO.GetId()] {
+			comm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_SEGMENTATION).
+				Key(key).
+				Capacity(capacity).
+				Create()
+			if err != nil {
+				glog.Errorf("Failed to create topology spread commodity %s for node %s: %v", key,
+					nodeDTO.GetDisplayName(), err)
+				continue
+			}
+			nodeDTO.CommoditiesSold = append(nodeDTO.CommoditiesSold, comm)
+			totalSoldComms++
+		}
+	}
+	for _, podDTO := range podDTOs {
+		keys := podKeys[podDTO.GetId()]
+		if len(keys) == 0 {
+			continue
+		}
+		var comms []*proto.CommodityDTO
+		for _, key := range keys {
+			comm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_SEGMENTATION).
+				Key(key).
+				Used(1).
+				Create()
+			if err != nil {
+				glog.Errorf("Failed to create topology spread commodity %s for pod %s: %v", key,
+					podDTO.GetDisplayName(), err)
+				continue
+			}
+			comms = append(comms, comm)
+		}
+		podBuysCommodities(podDTO, comms, podProviders[podDTO.GetId()])
+	}
+	glog.V(2).Infof("Created %d topology spread commodities sold by the nodes for %d constraints.",
+		totalSoldComms, len(constraints))
+}
@@ -0,0 +1,71 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+)
+
+func newSpreadPod(name, nodeName string) *api.Pod {
+	pod := newConstraintPod(name, map[string]string{"app": "web"})
+	pod.UID = types.UID(name)
+	pod.Spec.NodeName = nodeName
+	pod.Spec.TopologySpreadConstraints = []api.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       "zone",
+		WhenUnsatisfiable: api.DoNotSchedule,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+	}}
+	return pod
+}
+
+func TestTopologySpreadProcessor(t *testing.T) {
+	node1 := newConstraintNode("node-1", "zone-a")
+	node2 := newConstraintNode("node-2", "zone-a")
+	node3 := newConstraintNode("node-3", "zone-b")
+	for _, node := range []*api.Node{node1, node2, node3} {
+		node.UID = types.UID(node.Name)
+	}
+	web1 := newSpreadPod("web-1", "node-1")
+	web2 := newSpreadPod("web-2", "node-3")
+	web3 := newSpreadPod("web-3", "node-3")
+
+	clusterSummary := repository.CreateClusterSummary(
+		repository.NewKubeCluster("cluster", []*api.Node{node1, node2, node3}))
+	clusterSummary.NodeToRunningPods[node1.Name] = []*api.Pod{web1}
+	clusterSummary.NodeToRunningPods[node3.Name] = []*api.Pod{web2, web3}
+
+	nodeDTOs := []*proto.EntityDTO{
+		newEntityDTO("node-1", proto.EntityDTO_VIRTUAL_MACHINE, nil),
+		newEntityDTO("node-2", proto.EntityDTO_VIRTUAL_MACHINE, nil),
+		newEntityDTO("node-3", proto.EntityDTO_VIRTUAL_MACHINE, nil),
+	}
+	podDTO := newEntityDTO("web-1", proto.EntityDTO_CONTAINER_POD, createCommBoughtForPod("node-1"))
+	NewTopologySpreadProcessor(clusterSummary).Process(append(nodeDTOs, podDTO))
+
+	// zone-a has 1 replica and zone-b 2, so zone-a can accept 1 more within the max skew of the least zone, and
+	// zone-b none
+	capacities := make(map[string]float64)
+	for _, nodeDTO := range nodeDTOs {
+		if assert.Len(t, nodeDTO.GetCommoditiesSold(), 1) {
+			comm := nodeDTO.GetCommoditiesSold()[0]
+			assert.Equal(t, proto.CommodityDTO_SEGMENTATION, comm.GetCommodityType())
+			assert.Equal(t, "[k8s topology spread] ns|zone|app=web|1", comm.GetKey())
+			capacities[nodeDTO.GetId()] = comm.GetCapacity()
+		}
+	}
+	assert.Equal(t, map[string]float64{"node-1": 2, "node-2": 1, "node-3": 2}, capacities)
+
+	bought := podDTO.GetCommoditiesBought()[0].GetBought()
+	if assert.Len(t, bought, 2) {
+		assert.Equal(t, proto.CommodityDTO_SEGMENTATION, bought[1].GetCommodityType())
+		assert.Equal(t, 1.0, bought[1].GetUsed())
+	}
+}
@@ -12,6 +12,8 @@ import (
 // Converts the cluster K8s controller objects to entity DTOs
 type K8sControllerDiscoveryWorker struct {
 	cluster *repository.ClusterSummary
+	// Whether the workload controllers of a namespace are modeled as the overhead of the nodes, with no action
+	isOverheadNamespace func(namespace string) bool
 }
 
 func NewK8sControllerDiscoveryWorker(cluster *repository.ClusterSummary) *K8sControllerDiscoveryWorker {
@@ -20,6 +22,13 @@ func NewK8sControllerDiscoveryWorker(cluster *repository.ClusterSummary) *K8sCon
 	}
 }
 
+// WithOverheadNamespaces sets the namespaces whose pods are modeled as the overhead of their nodes, whose workload
+// controllers are not controllable.
+func (worker *K8sControllerDiscoveryWorker) WithOverheadNamespaces(isOverheadNamespace func(namespace string) bool) *K8sControllerDiscoveryWorker {
+	worker.isOverheadNamespace = isOverheadNamespace
+	return worker
+}
+
 // Controller discovery worker collects KubeController entities discovered by different discovery workers.
 // It merges the pods belonging to the same controller but discovered by different discovery workers, and aggregates
 // allocation resources usage of the same KubeController from different workers.
@@ -53,7 +62,8 @@ func (worker *K8sControllerDiscoveryWorker) Do(cluster *repository.ClusterSummar
 		glog.V(4).Infof("Discovered WorkloadController entity: %s", kubeController)
 	}
 	// Create DTOs for each k8s WorkloadController entity
-	workloadControllerDTOBuilder := dtofactory.NewWorkloadControllerDTOBuilder(cluster, kubeControllersMap, worker.cluster.NamespaceUIDMap)
+	workloadControllerDTOBuilder := dtofactory.NewWorkloadControllerDTOBuilder(cluster, kubeControllersMap, worker.cluster.NamespaceUIDMap).
+		WithOverheadNamespaces(worker.isOverheadNamespace)
 	workloadControllerDtos, err := workloadControllerDTOBuilder.BuildDTOs()
 	if err != nil {
 		return nil, fmt.Errorf("error while creating WorkloadController entityDTOs: %v", err)
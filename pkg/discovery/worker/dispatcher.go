@@ -17,6 +17,10 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 )
 
+// The number of nodes per discovery worker above which the discovery of a large cluster is likely to be slowed down
+// by the workers scraping the kubelets of the nodes one after the other
+const maxNodesPerWorker = 50
+
 type DispatcherConfig struct {
 	clusterInfoScraper  *cluster.ClusterScraper
 	probeConfig         *configs.ProbeConfig
@@ -26,6 +30,8 @@ type DispatcherConfig struct {
 	samplingIntervalSec int
 	clusterKeyInjected  string
 	commodityConfig     *dtofactory.CommodityConfig
+	utilizationHistory  *metrics.UtilizationHistory
+	restartTracker      *metrics.ContainerRestartTracker
 }
 
 func NewDispatcherConfig(clusterInfoScraper *cluster.ClusterScraper, probeConfig *configs.ProbeConfig,
@@ -45,6 +51,16 @@ func (config *DispatcherConfig) WithClusterKeyInjected(clusterKeyInjected string
 	return config
 }
 
+func (config *DispatcherConfig) WithUtilizationHistory(history *metrics.UtilizationHistory) *DispatcherConfig {
+	config.utilizationHistory = history
+	return config
+}
+
+func (config *DispatcherConfig) WithContainerRestartTracker(tracker *metrics.ContainerRestartTracker) *DispatcherConfig {
+	config.restartTracker = tracker
+	return config
+}
+
 type Dispatcher struct {
 	config           *DispatcherConfig
 	workerPool       chan chan *task.Task
@@ -90,7 +106,9 @@ func (d *Dispatcher) Init(c *ResultCollector) {
 	for i := 0; i < d.config.workerCount; i++ {
 		// Create the worker instance
 		workerConfig := NewK8sDiscoveryWorkerConfig(d.config.probeConfig, d.config.probeConfig.StitchingPropertyType, d.config.workerTimeoutSec, d.config.samples).
-			WithClusterKeyInjected(d.config.clusterKeyInjected)
+			WithClusterKeyInjected(d.config.clusterKeyInjected).
+			WithUtilizationHistory(d.config.utilizationHistory).
+			WithContainerRestartTracker(d.config.restartTracker)
 		for _, mc := range d.config.probeConfig.MonitoringConfigs {
 			workerConfig.WithMonitoringWorkerConfig(mc)
 		}
@@ -137,6 +155,10 @@ func (d *Dispatcher) RegisterWorker(worker *k8sDiscoveryWorker) {
 // Dispatch the task to the pool, task will be picked by the k8sDiscoveryWorker
 func (d *Dispatcher) Dispatch(nodes []*api.Node, nodesPods map[string][]string, podsWithAffinities,
 	otherSpreadPods sets.String, hostnameSpreadWorkloads map[string]sets.String, cluster *repository.ClusterSummary) int {
+	if len(nodes) > d.config.workerCount*maxNodesPerWorker {
+		glog.Warningf("Discovering %d nodes with %d discovery workers, consider increasing --discovery-workers "+
+			"to shorten the discovery.", len(nodes), d.config.workerCount)
+	}
 	go func() {
 		for _, node := range nodes {
 			runningPods := cluster.GetRunningPodsOnNode(node)
@@ -158,6 +180,24 @@ func (d *Dispatcher) Dispatch(nodes []*api.Node, nodesPods map[string][]string,
 	return len(nodes)
 }
 
+// DispatchPods creates a Task for each of the given nodes with only the given running pods on the node, to discover
+// the pods which have started since the last full discovery. The cluster summary of the last full discovery is used.
+func (d *Dispatcher) DispatchPods(nodes []*api.Node, nodeRunningPods map[string][]*api.Pod,
+	cluster *repository.ClusterSummary) int {
+	go func() {
+		for _, node := range nodes {
+			currTask := task.NewTask().
+				WithNode(node).
+				WithRunningPods(nodeRunningPods[node.Name]).
+				WithCluster(cluster).
+				WithPodsToControllers(cluster.PodToControllerMap)
+			glog.V(2).Infof("Dispatching incremental task %v", currTask)
+			d.assignTask(currTask)
+		}
+	}()
+	return len(nodes)
+}
+
 // FinishSampling stops scheduling dispatcher to assign sampling discovery tasks.
 func (d *SamplingDispatcher) FinishSampling() {
 	if !d.timestamp.IsZero() {
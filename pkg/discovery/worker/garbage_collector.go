@@ -18,29 +18,45 @@ import (
 	commonutil "github.com/turbonomic/kubeturbo/pkg/util"
 )
 
-var supportedGrandParents = []schema.GroupVersionResource{
-	{
-		Group:    commonutil.K8sAPIDeploymentGV.Group,
-		Version:  commonutil.K8sAPIDeploymentGV.Version,
-		Resource: commonutil.DeploymentResName,
-	},
-	{
-		Group:    commonutil.OpenShiftAPIDeploymentConfigGV.Group,
-		Version:  commonutil.OpenShiftAPIDeploymentConfigGV.Version,
-		Resource: commonutil.DeploymentConfigResName,
-	},
+// supportedGrandParents returns the resources of the controllers whose rollouts are paused by the pod moves, with the
+// group version of the deployments of the cluster.
+func supportedGrandParents(controllerGVs commonutil.ControllerGroupVersions) []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
+		controllerGVs.DeploymentResource(),
+		{
+			Group:    commonutil.OpenShiftAPIDeploymentConfigGV.Group,
+			Version:  commonutil.OpenShiftAPIDeploymentConfigGV.Version,
+			Resource: commonutil.DeploymentConfigResName,
+		},
+	}
 }
 
-var supportedParents = []schema.GroupVersionResource{
+// supportedParents returns the resources of the controllers whose schedulers are changed by the pod moves, with the
+// group version of the replicasets of the cluster.
+func supportedParents(controllerGVs commonutil.ControllerGroupVersions) []schema.GroupVersionResource {
+	return []schema.GroupVersionResource{
+		{
+			Group:    commonutil.K8sAPIReplicationControllerGV.Group,
+			Version:  commonutil.K8sAPIReplicationControllerGV.Version,
+			Resource: commonutil.ReplicationControllerResName,
+		},
+		controllerGVs.ReplicaSetResource(),
+	}
+}
+
+// machineDeletionMarks are the annotations marking the machines to be removed first by the node suspend actions,
+// of the machine API of OpenShift and of the upstream Cluster API.
+var machineDeletionMarks = []struct {
+	res        schema.GroupVersionResource
+	annotation string
+}{
 	{
-		Group:    commonutil.K8sAPIReplicationControllerGV.Group,
-		Version:  commonutil.K8sAPIReplicationControllerGV.Version,
-		Resource: commonutil.ReplicationControllerResName,
+		res:        schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machines"},
+		annotation: executor.DeleteNodeAnnotation,
 	},
 	{
-		Group:    commonutil.K8sAPIReplicasetGV.Group,
-		Version:  commonutil.K8sAPIReplicasetGV.Version,
-		Resource: commonutil.ReplicaSetResName,
+		res:        schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machines"},
+		annotation: executor.UpstreamDeleteMachineAnnotation,
 	},
 }
 
@@ -49,6 +65,7 @@ var gcListOpts = metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", executor
 type GarbageCollector struct {
 	client                *kubernetes.Clientset
 	dynClient             dynamic.Interface
+	controllerGVs         commonutil.ControllerGroupVersions
 	finishCollecting      chan bool
 	collectionIntervalSec int
 
@@ -61,11 +78,18 @@ func NewGarbageCollector(client *kubernetes.Clientset, dynClient dynamic.Interfa
 		podAge:                podAge,
 		client:                client,
 		dynClient:             dynClient,
+		controllerGVs:         commonutil.DefaultControllerGroupVersions(),
 		finishCollecting:      finishChan,
 		collectionIntervalSec: collectionIntervalSec,
 	}
 }
 
+// WithControllerGroupVersions sets the group versions of the deployments and the replicasets of the cluster.
+func (g *GarbageCollector) WithControllerGroupVersions(controllerGVs commonutil.ControllerGroupVersions) *GarbageCollector {
+	g.controllerGVs = controllerGVs
+	return g
+}
+
 // The cleanup routine does not error out. It rather retries couple of times on an api error
 // and continues ahead with trying to revert/clean up other items leaving the failure case behind.
 // The opportunity to revert/cleanup would arise only if and when kubeturbo restarts again.
@@ -96,6 +120,7 @@ func (g *GarbageCollector) StartCleanup() {
 		// leaked pods have been cleaned up successfully in this run.
 		g.cleanupLeakedClonePods()
 		g.cleanupLeakedWrongSchedulerPods()
+		g.cleanupStaleMachineDeletionMarks()
 		go func() {
 			collectionInterval := time.Duration(g.collectionIntervalSec) * time.Second
 			ticker := time.NewTicker(collectionInterval)
@@ -108,6 +133,8 @@ func (g *GarbageCollector) StartCleanup() {
 				case <-ticker.C:
 					g.cleanupLeakedClonePods()
 					g.cleanupLeakedWrongSchedulerPods()
+					g.cleanupStaleMachineDeletionMarks()
+					g.cleanupStaleQuotas()
 				}
 			}
 		}()
@@ -174,6 +201,53 @@ func (g *GarbageCollector) isLeakedPod(pod api.Pod) bool {
 	return false
 }
 
+// cleanupStaleMachineDeletionMarks unmarks the machines marked for deletion by node suspend actions which were
+// interrupted before their machine set was scaled down. Otherwise the machines would be removed by the next scale
+// down of their machine set, whoever does it. The marks without the time they were set are left as they are.
+func (g *GarbageCollector) cleanupStaleMachineDeletionMarks() {
+	for _, mark := range machineDeletionMarks {
+		machineList, err := g.dynClient.Resource(mark.res).Namespace("").List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			// The machine API is not available in most clusters
+			glog.V(4).Infof("Not checking the deletion marks of %s: %v", mark.res.String(), err)
+			continue
+		}
+		for _, machine := range machineList.Items {
+			if !g.isStaleMachineDeletionMark(&machine, mark.annotation) {
+				continue
+			}
+			annotations := machine.GetAnnotations()
+			delete(annotations, mark.annotation)
+			machine.SetAnnotations(annotations)
+			_, err := g.dynClient.Resource(mark.res).Namespace(machine.GetNamespace()).
+				Update(context.TODO(), &machine, metav1.UpdateOptions{})
+			if err != nil {
+				glog.Warningf("Encountered error trying to unmark machine %s/%s for deletion: %v",
+					machine.GetNamespace(), machine.GetName(), err)
+				continue
+			}
+			glog.V(2).Infof("Unmarked machine %s/%s left marked for deletion by an interrupted action.",
+				machine.GetNamespace(), machine.GetName())
+		}
+	}
+}
+
+func (g *GarbageCollector) isStaleMachineDeletionMark(machine *unstructured.Unstructured, annotation string) bool {
+	if machine.GetDeletionTimestamp() != nil {
+		return false
+	}
+	value, found := machine.GetAnnotations()[annotation]
+	if !found {
+		return false
+	}
+	markedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	// The actions wait at most 10 minutes for the machine to be removed after scaling down its machine set
+	return markedAt.Add(g.podAge).Before(time.Now())
+}
+
 // The controllers cleanup is supposed to happen only at the startup
 // We fail in case of errors to force kubeturbo to restart and try the cleanup again
 func (g *GarbageCollector) revertControllers() {
@@ -182,7 +256,7 @@ func (g *GarbageCollector) revertControllers() {
 }
 
 func (g *GarbageCollector) revertSchedulers() {
-	for _, parentRes := range supportedParents {
+	for _, parentRes := range supportedParents(g.controllerGVs) {
 		var objList *unstructured.UnstructuredList
 		err := commonutil.RetryDuring(executor.DefaultExecutionRetry, 0,
 			executor.DefaultRetrySleepInterval, func() error {
@@ -213,7 +287,7 @@ func (g *GarbageCollector) revertSchedulers() {
 }
 
 func (g *GarbageCollector) unpauseRollouts() {
-	for _, grandParentRes := range supportedGrandParents {
+	for _, grandParentRes := range supportedGrandParents(g.controllerGVs) {
 		var objList *unstructured.UnstructuredList
 		err := commonutil.RetryDuring(executor.DefaultExecutionRetry, 0,
 			executor.DefaultRetrySleepInterval, func() error {
@@ -263,6 +337,38 @@ func (g *GarbageCollector) cleanupQuotas() {
 	}
 }
 
+// cleanupStaleQuotas reverts the quotas left increased by the actions which failed to revert them, e.g. when the
+// api server was not reachable at the end of the action. Unlike at the startup, an action may be in progress, so
+// only the quotas increased long enough ago are reverted. The quotas without the time they were increased are left
+// as they are.
+func (g *GarbageCollector) cleanupStaleQuotas() {
+	quotaList, err := g.client.CoreV1().ResourceQuotas("").List(context.TODO(), gcListOpts)
+	if err != nil {
+		glog.Warningf("Error getting the quotas increased by actions: %v", err)
+		return
+	}
+	for _, quota := range quotaList.Items {
+		if g.isStaleQuota(&quota) {
+			glog.V(2).Infof("Reverting quota %s/%s left increased by an interrupted action.", quota.Namespace,
+				quota.Name)
+			revertQuota(g.client, &quota)
+		}
+	}
+}
+
+func (g *GarbageCollector) isStaleQuota(quota *api.ResourceQuota) bool {
+	value, found := quota.Annotations[executor.QuotaUpdateTimeAnnotationKey]
+	if !found {
+		return false
+	}
+	updatedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	// The actions which increase the quotas complete well before the clone pods are considered leaked
+	return updatedAt.Add(g.podAge).Before(time.Now())
+}
+
 func revertQuota(client *kubernetes.Clientset, quota *api.ResourceQuota) {
 	var revertedQuota *api.ResourceQuota
 	var err error
@@ -302,8 +408,7 @@ func RemoveTurboAnnotionFromQuota(quota *api.ResourceQuota) {
 		// nothing to do
 		return
 	}
-	if _, exists := annotations[executor.QuotaAnnotationKey]; exists {
-		delete(annotations, executor.QuotaAnnotationKey)
-		quota.SetAnnotations(annotations)
-	}
+	delete(annotations, executor.QuotaAnnotationKey)
+	delete(annotations, executor.QuotaUpdateTimeAnnotationKey)
+	quota.SetAnnotations(annotations)
 }
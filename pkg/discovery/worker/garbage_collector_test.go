@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
+)
+
+func newMarkedMachine(name, markedAt string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "machine.openshift.io/v1beta1",
+		"kind":       "Machine",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   "openshift-machine-api",
+			"annotations": map[string]interface{}{executor.DeleteNodeAnnotation: markedAt},
+		},
+	}
+}
+
+func TestCleanupStaleMachineDeletionMarks(t *testing.T) {
+	now := time.Now().UTC()
+	updated := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/machine.openshift.io/v1beta1/machines":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"apiVersion": "machine.openshift.io/v1beta1",
+				"kind":       "MachineList",
+				"items": []interface{}{
+					newMarkedMachine("stale", now.Add(-time.Hour).Format(time.RFC3339)),
+					newMarkedMachine("recent", now.Add(-time.Minute).Format(time.RFC3339)),
+					newMarkedMachine("untimed", "delete"),
+				},
+			})
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			obj := map[string]interface{}{}
+			json.Unmarshal(body, &obj)
+			updated[r.URL.Path] = obj
+			w.Write(body)
+		default:
+			// The upstream Cluster API is not installed
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1",
+				"status": "Failure", "reason": "NotFound", "code": http.StatusNotFound})
+		}
+	}))
+	defer server.Close()
+	dynClient, err := dynamic.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NoError(t, err)
+
+	NewGarbageCollector(nil, dynClient, nil, 0, 30*time.Minute).cleanupStaleMachineDeletionMarks()
+	assert.Len(t, updated, 1)
+	machine, found := updated["/apis/machine.openshift.io/v1beta1/namespaces/openshift-machine-api/machines/stale"]
+	assert.True(t, found)
+	assert.NotContains(t, machine["metadata"].(map[string]interface{})["annotations"], executor.DeleteNodeAnnotation)
+}
+
+func newIncreasedQuota(name, updatedAt string) map[string]interface{} {
+	annotations := map[string]interface{}{executor.QuotaAnnotationKey: `{"apiVersion":"v1","kind":"ResourceQuota",` +
+		`"metadata":{"name":"` + name + `","namespace":"ns"},"spec":{"hard":{"pods":"10"}}}`}
+	if updatedAt != "" {
+		annotations[executor.QuotaUpdateTimeAnnotationKey] = updatedAt
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   "ns",
+			"labels":      map[string]interface{}{executor.TurboGCLabelKey: executor.TurboGCLabelVal},
+			"annotations": annotations,
+		},
+		"spec": map[string]interface{}{"hard": map[string]interface{}{"pods": "11"}},
+	}
+}
+
+func TestCleanupStaleQuotas(t *testing.T) {
+	now := time.Now().UTC()
+	updated := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ResourceQuotaList",
+				"items": []interface{}{
+					newIncreasedQuota("stale", now.Add(-time.Hour).Format(time.RFC3339)),
+					newIncreasedQuota("recent", now.Add(-time.Minute).Format(time.RFC3339)),
+					newIncreasedQuota("untimed", ""),
+				},
+			})
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			obj := map[string]interface{}{}
+			json.Unmarshal(body, &obj)
+			updated[r.URL.Path] = obj
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	assert.NoError(t, err)
+
+	NewGarbageCollector(client, nil, nil, 0, 30*time.Minute).cleanupStaleQuotas()
+	assert.Len(t, updated, 1)
+	quota, found := updated["/api/v1/namespaces/ns/resourcequotas/stale"]
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"hard": map[string]interface{}{"pods": "10"}}, quota["spec"])
+	assert.NotContains(t, quota["metadata"], "annotations")
+}
@@ -2,18 +2,32 @@ package worker
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/builder/group"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
 
 const (
 	k8sGroupWorkerID = "GroupsDiscoveryWorker"
+
+	// The resize modes of the ContainerSpecs of the injected sidecars, which are not in the templates of the workload
+	// controllers and so cannot be resized through them: recommended only, or not recommended at all
+	SidecarResizeRecommend = "recommend"
+	SidecarResizeDisabled  = "disabled"
+
+	// How the resizes of the workload controllers resized by a VerticalPodAutoscaler coexist with it: not
+	// recommended at all (suppress), recommended only, and never executed (recommend), or as for the other workload
+	// controllers, with the VerticalPodAutoscalers not discovered (ignore)
+	VPACoexistenceSuppress  = "suppress"
+	VPACoexistenceRecommend = "recommend"
+	VPACoexistenceIgnore    = "ignore"
 )
 
 // Converts the cluster group objects to Group DTOs
@@ -21,15 +35,38 @@ type k8sEntityGroupDiscoveryWorker struct {
 	id       string
 	targetId string
 	cluster  *repository.ClusterSummary
+	// The resize mode of the ContainerSpecs of the injected sidecars
+	sidecarResizeMode string
+	// How the resizes of the workload controllers resized by a VerticalPodAutoscaler coexist with it
+	vpaCoexistenceMode string
 }
 
 func Newk8sEntityGroupDiscoveryWorker(cluster *repository.ClusterSummary,
 	targetId string) *k8sEntityGroupDiscoveryWorker {
 	return &k8sEntityGroupDiscoveryWorker{
-		cluster:  cluster,
-		id:       k8sGroupWorkerID,
-		targetId: targetId,
+		cluster:            cluster,
+		id:                 k8sGroupWorkerID,
+		targetId:           targetId,
+		sidecarResizeMode:  SidecarResizeRecommend,
+		vpaCoexistenceMode: VPACoexistenceSuppress,
+	}
+}
+
+// WithSidecarResizeMode sets the resize mode of the ContainerSpecs of the injected sidecars, recommend if empty.
+func (worker *k8sEntityGroupDiscoveryWorker) WithSidecarResizeMode(mode string) *k8sEntityGroupDiscoveryWorker {
+	if mode != "" {
+		worker.sidecarResizeMode = mode
+	}
+	return worker
+}
+
+// WithVPACoexistenceMode sets how the resizes of the workload controllers resized by a VerticalPodAutoscaler
+// coexist with it, suppress if empty.
+func (worker *k8sEntityGroupDiscoveryWorker) WithVPACoexistenceMode(mode string) *k8sEntityGroupDiscoveryWorker {
+	if mode != "" {
+		worker.vpaCoexistenceMode = mode
 	}
+	return worker
 }
 
 // Group discovery worker collects pod and container groups discovered by different discovery workers.
@@ -93,6 +130,8 @@ func (worker *k8sEntityGroupDiscoveryWorker) Do(entityGroupList []*repository.En
 
 	// Create static groups for sidecar containerSpecs
 	groupDTOs = append(groupDTOs, worker.buildSidecarContainerSpecGroup(sidecarContainerSpecs)...)
+	// Create static groups for the containerSpecs resized by VerticalPodAutoscalers
+	groupDTOs = append(groupDTOs, worker.buildVPAContainerSpecGroup()...)
 	// Create static groups for all pods that use volumes
 	groupDTOs = append(groupDTOs, worker.buildPodsWithVolumesGroup(podsWithVolumes)...)
 
@@ -114,12 +153,16 @@ func (worker *k8sEntityGroupDiscoveryWorker) buildSidecarContainerSpecGroup(side
 	id := fmt.Sprintf("Injected Sidecars/All-ContainerSpecs-%s", worker.targetId)
 	displayName := "Injected Sidecars/All ContainerSpecs"
 
+	resizeMode, policyName := "RECOMMEND", " Resize Recommend Only "
+	if worker.sidecarResizeMode == SidecarResizeDisabled {
+		resizeMode, policyName = "DISABLED", " Resize Disabled "
+	}
 	settings := group.NewSettingsBuilder().
-		AddSetting(group.NewResizeAutomationPolicySetting("RECOMMEND")).
+		AddSetting(group.NewResizeAutomationPolicySetting(resizeMode)).
 		Build()
 
 	settingPolicy, err := group.NewSettingPolicyBuilder().
-		WithDisplayName(displayName + " Resize Recommend Only " + "[" + worker.targetId + "]").
+		WithDisplayName(displayName + policyName + "[" + worker.targetId + "]").
 		WithName(id).
 		WithSettings(settings).
 		Build()
@@ -147,6 +190,68 @@ func (worker *k8sEntityGroupDiscoveryWorker) buildSidecarContainerSpecGroup(side
 	return groupsDTOs
 }
 
+// buildVPAContainerSpecGroup builds the group of the ContainerSpecs of the workload controllers resized by a
+// VerticalPodAutoscaler, with the resize mode of the VPA coexistence mode, so that kubeturbo and the
+// VerticalPodAutoscaler do not resize the same containers back and forth.
+func (worker *k8sEntityGroupDiscoveryWorker) buildVPAContainerSpecGroup() []*proto.GroupDTO {
+	var groupsDTOs []*proto.GroupDTO
+	if worker.vpaCoexistenceMode == VPACoexistenceIgnore || worker.cluster == nil ||
+		worker.cluster.KubeCluster == nil || len(worker.cluster.VPAControllers) == 0 {
+		return groupsDTOs
+	}
+	var vpaContainerSpecs []string
+	for controllerUID := range worker.cluster.VPAControllers {
+		controller, found := worker.cluster.ControllerMap[controllerUID]
+		if !found {
+			continue
+		}
+		for containerName := range controller.Containers {
+			vpaContainerSpecs = append(vpaContainerSpecs, util.ContainerSpecIdFunc(controllerUID, containerName))
+		}
+	}
+	if len(vpaContainerSpecs) == 0 {
+		return groupsDTOs
+	}
+	sort.Strings(vpaContainerSpecs)
+	id := fmt.Sprintf("VPA Managed/All-ContainerSpecs-%s", worker.targetId)
+	displayName := "VPA Managed/All ContainerSpecs"
+
+	resizeMode, policyName := "DISABLED", " Resize Disabled "
+	if worker.vpaCoexistenceMode == VPACoexistenceRecommend {
+		resizeMode, policyName = "RECOMMEND", " Resize Recommend Only "
+	}
+	settings := group.NewSettingsBuilder().
+		AddSetting(group.NewResizeAutomationPolicySetting(resizeMode)).
+		Build()
+
+	settingPolicy, err := group.NewSettingPolicyBuilder().
+		WithDisplayName(displayName + policyName + "[" + worker.targetId + "]").
+		WithName(id).
+		WithSettings(settings).
+		Build()
+	if err != nil {
+		glog.Errorf("Error creating setting policy dto  %s: %s", id, err)
+		return groupsDTOs
+	}
+
+	// static group
+	groupBuilder := group.StaticRegularGroup(id).
+		OfType(proto.EntityDTO_CONTAINER_SPEC).
+		WithEntities(vpaContainerSpecs).
+		WithDisplayName(displayName).
+		WithSettingPolicy(settingPolicy)
+
+	// build group
+	groupDTO, err := groupBuilder.Build()
+	if err != nil {
+		glog.Errorf("Error creating group dto  %s::%s", id, err)
+		return groupsDTOs
+	}
+	groupsDTOs = append(groupsDTOs, groupDTO)
+
+	return groupsDTOs
+}
+
 func (worker *k8sEntityGroupDiscoveryWorker) buildPodsWithVolumesGroup(podsWithVolumes []string) []*proto.GroupDTO {
 	var groupsDTOs []*proto.GroupDTO
 	if len(podsWithVolumes) <= 0 {
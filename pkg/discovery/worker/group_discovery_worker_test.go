@@ -4,8 +4,10 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 func TestBuildMirrorPodGroup(t *testing.T) {
@@ -64,3 +66,54 @@ func newGroup(entType proto.EntityDTO_EntityType, name *string, list []string, g
 		},
 	}
 }
+
+func TestBuildSidecarContainerSpecGroup(t *testing.T) {
+	for mode, expected := range map[string]string{
+		"":                     "RECOMMEND",
+		SidecarResizeRecommend: "RECOMMEND",
+		SidecarResizeDisabled:  "DISABLED",
+	} {
+		worker := Newk8sEntityGroupDiscoveryWorker(&repository.ClusterSummary{}, "target_id").
+			WithSidecarResizeMode(mode)
+		assert.Empty(t, worker.buildSidecarContainerSpecGroup(nil))
+		groupDTOs := worker.buildSidecarContainerSpecGroup([]string{"spec-1", "spec-1"})
+		assert.Len(t, groupDTOs, 1)
+		assert.Equal(t, []string{"spec-1"}, groupDTOs[0].GetMemberList().GetMember())
+		settings := groupDTOs[0].GetSettingPolicy().GetSettings()
+		assert.Len(t, settings, 1)
+		assert.Equal(t, expected, settings[0].GetStringSettingValueType().GetValue())
+	}
+}
+
+func TestBuildVPAContainerSpecGroup(t *testing.T) {
+	kubeCluster := repository.NewKubeCluster("cluster", nil)
+	kubeCluster.ControllerMap = map[string]*repository.K8sController{
+		"web-uid": repository.NewK8sController("Deployment", "web", "ns", "web-uid").
+			WithContainerNames(sets.NewString("web", "log")),
+		"api-uid": repository.NewK8sController("Deployment", "api", "ns", "api-uid").
+			WithContainerNames(sets.NewString("api")),
+	}
+	cluster := &repository.ClusterSummary{KubeCluster: kubeCluster}
+	assert.Empty(t, Newk8sEntityGroupDiscoveryWorker(cluster, "target_id").buildVPAContainerSpecGroup())
+
+	kubeCluster.VPAControllers = map[string]string{"web-uid": "ns/web-vpa"}
+	for mode, expected := range map[string]string{
+		"":                      "DISABLED",
+		VPACoexistenceSuppress:  "DISABLED",
+		VPACoexistenceRecommend: "RECOMMEND",
+		VPACoexistenceIgnore:    "",
+	} {
+		groupDTOs := Newk8sEntityGroupDiscoveryWorker(cluster, "target_id").
+			WithVPACoexistenceMode(mode).
+			buildVPAContainerSpecGroup()
+		if expected == "" {
+			assert.Empty(t, groupDTOs)
+			continue
+		}
+		assert.Len(t, groupDTOs, 1)
+		assert.Equal(t, []string{"web-uid/log", "web-uid/web"}, groupDTOs[0].GetMemberList().GetMember())
+		settings := groupDTOs[0].GetSettingPolicy().GetSettings()
+		assert.Len(t, settings, 1)
+		assert.Equal(t, expected, settings[0].GetStringSettingValueType().GetValue())
+	}
+}
@@ -43,6 +43,11 @@ type k8sDiscoveryWorkerConfig struct {
 	clusterKeyInjected string
 	// Config for various commodity settings
 	commodityConfig *dtofactory.CommodityConfig
+	// Usage history of containers across discovery cycles to report the usage percentile, nil if disabled
+	utilizationHistory *metrics.UtilizationHistory
+	// Restarts of containers across discovery cycles to report the restarts since the previous discovery, nil if
+	// disabled
+	restartTracker *metrics.ContainerRestartTracker
 }
 
 func NewK8sDiscoveryWorkerConfig(probeConfig *configs.ProbeConfig, sType stitching.StitchingPropertyType, timeoutSec, metricSamples int) *k8sDiscoveryWorkerConfig {
@@ -73,6 +78,18 @@ func (config *k8sDiscoveryWorkerConfig) WithClusterKeyInjected(clusterKeyInjecte
 	return config
 }
 
+// WithUtilizationHistory sets the utilization history for the k8sDiscoveryWorkerConfig
+func (config *k8sDiscoveryWorkerConfig) WithUtilizationHistory(history *metrics.UtilizationHistory) *k8sDiscoveryWorkerConfig {
+	config.utilizationHistory = history
+	return config
+}
+
+// WithContainerRestartTracker sets the container restart tracker for the k8sDiscoveryWorkerConfig
+func (config *k8sDiscoveryWorkerConfig) WithContainerRestartTracker(tracker *metrics.ContainerRestartTracker) *k8sDiscoveryWorkerConfig {
+	config.restartTracker = tracker
+	return config
+}
+
 // Add new monitoring worker config to the discovery worker config.
 func (c *k8sDiscoveryWorkerConfig) WithMonitoringWorkerConfig(config monitoring.MonitorWorkerConfig) *k8sDiscoveryWorkerConfig {
 	monitorType := config.GetMonitorType()
@@ -101,6 +118,8 @@ type k8sDiscoveryWorker struct {
 	// a collection of all monitoring worker of different types.
 	// key: monitoring types; value: monitoring worker instance.
 	monitoringWorker map[types.MonitorType][]monitoring.MonitoringWorker
+	// Merges the metrics collected by the monitoring workers by priority of their sources
+	mergePolicy *monitoring.MergePolicy
 
 	// sink is a central place to store all the monitored data.
 	sink *metrics.EntityMetricSink
@@ -149,6 +168,14 @@ func NewK8sDiscoveryWorker(config *k8sDiscoveryWorkerConfig, wid string, globalM
 	var stitchingManager *stitching.StitchingManager
 	if isFullDiscoveryWorker && config.stitchingPropertyType != "" {
 		stitchingManager = stitching.NewStitchingManager(config.stitchingPropertyType)
+		if config.probeConfig != nil && config.probeConfig.StitchingProvider != "" {
+			getter, err := stitching.NewNodeUUIDGetter(config.probeConfig.StitchingProvider)
+			if err != nil {
+				glog.Errorf("Failed to set the stitching provider: %v", err)
+			} else {
+				stitchingManager.WithProviderUuidGetter(getter)
+			}
+		}
 	}
 
 	return &k8sDiscoveryWorker{
@@ -156,6 +183,7 @@ func NewK8sDiscoveryWorker(config *k8sDiscoveryWorkerConfig, wid string, globalM
 		isFullDiscoveryWorker: isFullDiscoveryWorker,
 		config:                config,
 		monitoringWorker:      monitoringWorkerMap,
+		mergePolicy:           monitoring.NewMergePolicy(config.probeConfig.MonitoringSourcePriority),
 		sink:                  metricSink,
 		globalMetricSink:      globalMetricSink,
 		stitchingManager:      stitchingManager,
@@ -208,6 +236,9 @@ func (worker *k8sDiscoveryWorker) executeTask(currTask *task.Task) *task.TaskRes
 		// Reset the main sink
 		worker.sink = metrics.NewEntityMetricSink().WithMaxMetricPointsSize(worker.config.metricSamples)
 	}
+	// The sinks of the monitoring workers which completed the task, merged by priority of their sources
+	var sinkLock sync.Mutex
+	sourceSinks := make(map[types.MonitoringSource][]*metrics.EntityMetricSink)
 	// if resourceMonitoringWorkers, exist := worker.monitoringWorker[types.ResourceMonitor]; exist {
 	for _, resourceMonitoringWorkers := range worker.monitoringWorker {
 		for _, rmWorker := range resourceMonitoringWorkers {
@@ -234,13 +265,9 @@ func (worker *k8sDiscoveryWorker) executeTask(currTask *task.Task) *task.TaskRes
 					// glog.Infof("%s has finished", w.GetMonitoringSource())
 					t.Stop()
 					if err == nil {
-						// Don't do any filtering
-						worker.sink.MergeSink(monitoringSink, nil)
-						if worker.isFullDiscoveryWorker {
-							// Merge metrics from global metrics sink into the metrics sink of each full discovery worker
-							worker.sink.MergeSink(worker.globalMetricSink, nil)
-						}
-
+						sinkLock.Lock()
+						sourceSinks[w.GetMonitoringSource()] = append(sourceSinks[w.GetMonitoringSource()], monitoringSink)
+						sinkLock.Unlock()
 					}
 					// glog.Infof("send to finish channel %p", finishCh)
 					close(finishCh)
@@ -264,6 +291,14 @@ func (worker *k8sDiscoveryWorker) executeTask(currTask *task.Task) *task.TaskRes
 	}
 	wg.Wait()
 
+	sinkLock.Lock()
+	if worker.isFullDiscoveryWorker && len(sourceSinks) > 0 {
+		// Merge the usage data samples collected from the kubelets since the last full discovery
+		sourceSinks[types.KubeletSource] = append(sourceSinks[types.KubeletSource], worker.globalMetricSink)
+	}
+	worker.mergePolicy.Merge(worker.sink, sourceSinks)
+	sinkLock.Unlock()
+
 	if timeout {
 		return task.NewTaskResult(worker.id, task.TaskFailed).WithErr(fmt.Errorf("discovery timeout"))
 	}
@@ -292,6 +327,12 @@ func (worker *k8sDiscoveryWorker) executeTask(currTask *task.Task) *task.TaskRes
 	// Add the quota metrics in the sink for the pods and the nodes
 	worker.addPodQuotaMetrics(podMetricsCollection)
 
+	// Add the usage percentile metrics over the utilization history window for the containers
+	worker.addUtilizationPercentileMetrics(currTask.RunningPodList())
+
+	// Add the restarts and the OOMKilled terminations since the previous discovery for the containers
+	worker.addContainerRestartMetrics(currTask.RunningPodList())
+
 	// Collect quota metrics for K8s controllers where usage values are aggregated from pods and capacity values
 	// are from namespaces quota capacity
 	kubeControllers := NewControllerMetricsCollector(worker, currTask).CollectControllerMetrics()
@@ -367,13 +408,74 @@ func (worker *k8sDiscoveryWorker) addPodQuotaMetrics(podMetricsCollection PodMet
 	}
 }
 
+// addUtilizationPercentileMetrics records the CPU and memory usage of the containers of the given pods in the
+// utilization history, and adds the usage percentile over the history window to the sink, which is then
+// reported as the used value of the container commodities.
+func (worker *k8sDiscoveryWorker) addUtilizationPercentileMetrics(pods []*api.Pod) {
+	history := worker.config.utilizationHistory
+	if history == nil {
+		return
+	}
+	for _, pod := range pods {
+		podMId := util.PodMetricIdAPI(pod)
+		for _, container := range pod.Spec.Containers {
+			containerMId := util.ContainerMetricId(podMId, container.Name)
+			for _, resourceType := range []metrics.ResourceType{metrics.CPU, metrics.Memory} {
+				usedUID := metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, containerMId,
+					resourceType, metrics.Used)
+				usedMetric, err := worker.sink.GetMetric(usedUID)
+				if err != nil {
+					continue
+				}
+				points, ok := usedMetric.GetValue().([]metrics.Point)
+				if !ok {
+					continue
+				}
+				percentile, ok := history.AddPoints(usedUID, points)
+				if !ok {
+					continue
+				}
+				worker.sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId,
+					resourceType, metrics.UsedPercentile, percentile))
+				glog.V(4).Infof("Container %s %s used percentile over the utilization history is %f.",
+					containerMId, resourceType, percentile)
+			}
+		}
+	}
+}
+
+// addContainerRestartMetrics adds the number of restarts of the containers of the given pods and whether they were
+// OOMKilled since the previous discovery to the sink, so that the memory resize of the OOMing workloads can be
+// prioritized.
+func (worker *k8sDiscoveryWorker) addContainerRestartMetrics(pods []*api.Pod) {
+	tracker := worker.config.restartTracker
+	if tracker == nil {
+		return
+	}
+	for _, pod := range pods {
+		podMId := util.PodMetricIdAPI(pod)
+		for i := range pod.Status.ContainerStatuses {
+			status := &pod.Status.ContainerStatuses[i]
+			containerMId := util.ContainerMetricId(podMId, status.Name)
+			restarts, oomKilled := tracker.Observe(containerMId, status)
+			worker.sink.AddNewMetricEntries(
+				metrics.NewEntityStateMetric(metrics.ContainerType, containerMId, metrics.Restarts, float64(restarts)),
+				metrics.NewEntityStateMetric(metrics.ContainerType, containerMId, metrics.OOMKilled, oomKilled))
+			if restarts > 0 || oomKilled {
+				glog.V(3).Infof("Container %s restarted %d times since the previous discovery, OOMKilled: %v.",
+					containerMId, restarts, oomKilled)
+			}
+		}
+	}
+}
+
 func (worker *k8sDiscoveryWorker) buildEntityDTOs(currTask *task.Task) ([]*proto.EntityDTO,
 	[]*repository.KubePod, []string, []string, []string, []string) {
 	var entityDTOs []*proto.EntityDTO
 	var notReadyNodes []string
 	// Build entity DTOs for nodes
-	nodeDTOs, notReadyNodes := worker.buildNodeDTOs([]*api.Node{currTask.Node()}, currTask.NodesPods(),
-		currTask.HostnameSpreadWorkloads(), currTask.OtherSpreadPods(), currTask.PodstoControllers())
+	nodeDTOs, notReadyNodes := worker.buildNodeDTOs([]*api.Node{currTask.Node()}, currTask.PodList(),
+		currTask.NodesPods(), currTask.HostnameSpreadWorkloads(), currTask.OtherSpreadPods(), currTask.PodstoControllers())
 
 	glog.V(3).Infof("Worker %s built %d node DTOs.", worker.id, len(nodeDTOs))
 	if len(nodeDTOs) == 0 {
@@ -388,7 +490,7 @@ func (worker *k8sDiscoveryWorker) buildEntityDTOs(currTask *task.Task) ([]*proto
 	}
 	entityDTOs = append(entityDTOs, podDTOs...)
 	// Build entity DTOs for containers from running pods
-	containerDTOs, sidecarContainerSpecs := worker.buildContainerDTOs(runningPods)
+	containerDTOs, sidecarContainerSpecs := worker.buildContainerDTOs(runningPods, currTask.Cluster())
 	glog.V(3).Infof("Worker %s built %d container DTOs.", worker.id, len(containerDTOs))
 	if len(containerDTOs) > 0 {
 		entityDTOs = append(entityDTOs, containerDTOs...)
@@ -403,7 +505,7 @@ func (worker *k8sDiscoveryWorker) buildEntityDTOs(currTask *task.Task) ([]*proto
 	return entityDTOs, podEntities, sidecarContainerSpecs, podWithVolumes, notReadyNodes, mirrorPodUids
 }
 
-func (worker *k8sDiscoveryWorker) buildNodeDTOs(nodes []*api.Node, nodesPods map[string][]string,
+func (worker *k8sDiscoveryWorker) buildNodeDTOs(nodes []*api.Node, pods []*api.Pod, nodesPods map[string][]string,
 	hostnameSpreadWorkloads sets.String, otherSpreadPods sets.String, podsToControllers map[string]string) ([]*proto.EntityDTO, []string) {
 	// SetUp nodeName to nodeId mapping
 	stitchingManager := worker.stitchingManager
@@ -418,6 +520,7 @@ func (worker *k8sDiscoveryWorker) buildNodeDTOs(nodes []*api.Node, nodesPods map
 	// Build entity DTOs for nodes
 	return dtofactory.NewNodeEntityDTOBuilder(worker.sink, stitchingManager).
 		WithClusterKeyInjected(worker.config.clusterKeyInjected).
+		WithPods(pods).
 		BuildEntityDTOs(nodes, nodesPods, hostnameSpreadWorkloads, otherSpreadPods, podsToControllers)
 }
 
@@ -452,6 +555,8 @@ func (worker *k8sDiscoveryWorker) buildPodDTOs(currTask *task.Task) ([]*proto.En
 		WithHostnameSpreadPods(currTask.HostnameSpreadPods()).
 		WithOtherSpreadPods(currTask.OtherSpreadPods()).
 		WithPodsToControllers(currTask.PodstoControllers()).
+		WithBatchWorkloadsIncluded(worker.config.probeConfig.IncludeBatchWorkloads).
+		WithOverheadNamespaces(worker.config.probeConfig.InfrastructurePods.IsOverhead).
 		BuildEntityDTOs()
 
 	var podDTOs []*proto.EntityDTO
@@ -469,9 +574,12 @@ func (worker *k8sDiscoveryWorker) buildPodDTOs(currTask *task.Task) ([]*proto.En
 }
 
 // Build DTOs for containers
-func (worker *k8sDiscoveryWorker) buildContainerDTOs(runningPods []*api.Pod) ([]*proto.EntityDTO, []string) {
+func (worker *k8sDiscoveryWorker) buildContainerDTOs(runningPods []*api.Pod,
+	cluster *repository.ClusterSummary) ([]*proto.EntityDTO, []string) {
 	return dtofactory.
 		NewContainerDTOBuilder(worker.sink).
+		WithClusterSummary(cluster).
+		WithOverheadNamespaces(worker.config.probeConfig.InfrastructurePods.IsOverhead).
 		BuildEntityDTOs(runningPods)
 }
 
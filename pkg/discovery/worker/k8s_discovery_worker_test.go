@@ -9,6 +9,7 @@ import (
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -118,3 +119,73 @@ func newEntityDTO(id string) *proto.EntityDTO {
 		Id: &id,
 	}
 }
+
+func TestAddUtilizationPercentileMetrics(t *testing.T) {
+	probeConfig := &configs.ProbeConfig{}
+	history := metrics.NewUtilizationHistory(100, 0, 0, 0)
+	workerConfig := NewK8sDiscoveryWorkerConfig(probeConfig, "UUID", 1, 1).
+		WithMonitoringWorkerConfig(kubelet.NewKubeletMonitorConfig(nil, nil)).
+		WithUtilizationHistory(history)
+	worker, err := NewK8sDiscoveryWorker(workerConfig, "wid-1", metrics.NewEntityMetricSink(), true)
+	if err != nil {
+		t.Fatalf("Error while creating discovery worker: %v", err)
+	}
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app"}}},
+	}
+	containerMId := util.ContainerMetricId(util.PodMetricIdAPI(pod), "app")
+	usedUID := metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, containerMId, metrics.CPU, metrics.UsedPercentile)
+
+	// The percentile is computed over the samples of both discovery cycles
+	for cycle, value := range []float64{5, 1} {
+		worker.sink = metrics.NewEntityMetricSink()
+		worker.sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId,
+			metrics.CPU, metrics.Used, []metrics.Point{{Value: value, Timestamp: int64(cycle + 1)}}))
+		worker.addUtilizationPercentileMetrics([]*api.Pod{pod})
+		percentileMetric, err := worker.sink.GetMetric(usedUID)
+		if err != nil {
+			t.Fatalf("Missing used percentile metric: %v", err)
+		}
+		if percentileMetric.GetValue().(float64) != 5 {
+			t.Errorf("Used percentile = %v, want 5", percentileMetric.GetValue())
+		}
+	}
+	// No memory used metric, no memory percentile
+	memUID := metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, containerMId, metrics.Memory, metrics.UsedPercentile)
+	if _, err := worker.sink.GetMetric(memUID); err == nil {
+		t.Errorf("Unexpected memory used percentile metric")
+	}
+}
+
+func TestAddContainerRestartMetrics(t *testing.T) {
+	probeConfig := &configs.ProbeConfig{}
+	workerConfig := NewK8sDiscoveryWorkerConfig(probeConfig, "UUID", 1, 1).
+		WithMonitoringWorkerConfig(kubelet.NewKubeletMonitorConfig(nil, nil)).
+		WithContainerRestartTracker(metrics.NewContainerRestartTracker())
+	worker, err := NewK8sDiscoveryWorker(workerConfig, "wid-1", metrics.NewEntityMetricSink(), true)
+	if err != nil {
+		t.Fatalf("Error while creating discovery worker: %v", err)
+	}
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"},
+		Spec:       api.PodSpec{Containers: []api.Container{{Name: "app"}}},
+		Status:     api.PodStatus{ContainerStatuses: []api.ContainerStatus{{Name: "app", RestartCount: 2}}},
+	}
+	containerMId := util.ContainerMetricId(util.PodMetricIdAPI(pod), "app")
+	restartsUID := metrics.GenerateEntityStateMetricUID(metrics.ContainerType, containerMId, metrics.Restarts)
+
+	// Only the restarts since the previous discovery are reported
+	for cycle, restartCount := range []int32{2, 5} {
+		worker.sink = metrics.NewEntityMetricSink()
+		pod.Status.ContainerStatuses[0].RestartCount = restartCount
+		worker.addContainerRestartMetrics([]*api.Pod{pod})
+		restartsMetric, err := worker.sink.GetMetric(restartsUID)
+		if err != nil {
+			t.Fatalf("Missing restarts metric: %v", err)
+		}
+		if want := []float64{2, 3}[cycle]; restartsMetric.GetValue().(float64) != want {
+			t.Errorf("Restarts in cycle %d = %v, want %v", cycle, restartsMetric.GetValue(), want)
+		}
+	}
+}
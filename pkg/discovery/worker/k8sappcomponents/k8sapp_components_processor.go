@@ -1,8 +1,6 @@
 package k8sappcomponents
 
 import (
-	"fmt"
-
 	"github.com/golang/glog"
 
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
@@ -31,13 +29,15 @@ func NewK8sAppComponentsProcessor(appComponents map[repository.K8sAppComponent][
 }
 
 func (k *K8sAppComponentsProcessor) ProcessAppComponentDTOs(entityDTOs []*proto.EntityDTO) {
+	entityDTOsByID := make(map[string]*proto.EntityDTO, len(entityDTOs))
+	for _, entityDTO := range entityDTOs {
+		entityDTOsByID[entityDTO.GetId()] = entityDTO
+	}
 	for component, apps := range k.appComponents {
-		for _, entityDTO := range entityDTOs {
-			// This assumes that the UID of the k8s resource doesn't change within the same discovery cycle.
-			if component.Uid == entityDTO.GetId() {
-				// This does an in place update of the entityDTO
-				k.sellCommodities(entityDTO, component, apps)
-			}
+		// This assumes that the UID of the k8s resource doesn't change within the same discovery cycle.
+		if entityDTO, found := entityDTOsByID[component.Uid]; found {
+			// This does an in place update of the entityDTO
+			k.sellCommodities(entityDTO, component, apps)
 		}
 	}
 }
@@ -50,8 +50,7 @@ func (k *K8sAppComponentsProcessor) sellCommodities(entityDTO *proto.EntityDTO,
 			k.addParentAppProperties(app, entityDTO)
 		}
 
-		key := fmt.Sprintf("%s-%s/%s-%s/%s", "App", app.Namespace, app.Name,
-			component.EntityType.String(), component.Name)
+		key := app.ComponentKey(component)
 		commoditySold, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_APPLICATION).
 			Key(key).
 			Capacity(applicationCommodityDefaultCapacity).
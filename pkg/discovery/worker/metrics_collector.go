@@ -152,7 +152,7 @@ func getResourceQuotaCapacity(namespaceEntity *repository.KubeNamespace, quotaRe
 
 // Create PodMetrics for the given pod.
 // Amount of quota resources bought from the quota provider is equal to the aggregated compute resource limits and
-// requests of all containers of the given pod.
+// requests of the given pod.
 func createPodMetrics(pod *v1.Pod, namespace string, metricsSink *metrics.EntityMetricSink,
 ) *repository.PodMetrics {
 	podKey := util.PodKeyFunc(pod)
@@ -189,22 +189,12 @@ func createPodMetrics(pod *v1.Pod, namespace string, metricsSink *metrics.Entity
 	return podMetrics
 }
 
-// Collect aggregated compute resources limits and requests of all container of the given pod.
+// Collect aggregated compute resources limits and requests of the given pod, including its init containers and
+// overhead as the resource quotas account them.
 func collectContainersComputeResources(pod *v1.Pod) (float64, float64, float64, float64) {
-	totalCPULimits := 0.0
-	totalCPURequests := 0.0
-	totalMemLimits := 0.0
-	totalMemRequests := 0.0
-	for _, container := range pod.Spec.Containers {
-		// Compute resource limits
-		limits := container.Resources.Limits
-		totalCPULimits += float64(limits.Cpu().MilliValue())
-		totalMemLimits += util.Base2BytesToKilobytes(float64(limits.Memory().Value()))
-		// Compute resource requests
-		requests := container.Resources.Requests
-		totalCPURequests += float64(requests.Cpu().MilliValue())
-		totalMemRequests += util.Base2BytesToKilobytes(float64(requests.Memory().Value()))
-	}
+	requests, limits := util.GetPodComputeResources(pod)
+	totalCPULimits, totalMemLimits := util.GetCpuAndMemoryValues(limits)
+	totalCPURequests, totalMemRequests := util.GetCpuAndMemoryValues(requests)
 	return totalCPULimits, totalCPURequests, totalMemLimits, totalMemRequests
 }
 
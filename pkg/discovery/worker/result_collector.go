@@ -30,6 +30,28 @@ type DiscoveryResult struct {
 	ErrorCount            int
 }
 
+// Merge adds the results of the discovery of other nodes, e.g. of the nodes of a discovery shard worker.
+func (result *DiscoveryResult) Merge(other *DiscoveryResult) {
+	result.EntityDTOs = append(result.EntityDTOs, other.EntityDTOs...)
+	result.NamespaceMetrics = append(result.NamespaceMetrics, other.NamespaceMetrics...)
+	result.EntityGroups = append(result.EntityGroups, other.EntityGroups...)
+	result.KubeControllers = append(result.KubeControllers, other.KubeControllers...)
+	result.ContainerSpecMetrics = append(result.ContainerSpecMetrics, other.ContainerSpecMetrics...)
+	result.PodVolumeMetrics = append(result.PodVolumeMetrics, other.PodVolumeMetrics...)
+	if result.PodEntitiesMap == nil {
+		result.PodEntitiesMap = make(map[string]*repository.KubePod, len(other.PodEntitiesMap))
+	}
+	for podClusterID, kubePod := range other.PodEntitiesMap {
+		result.PodEntitiesMap[podClusterID] = kubePod
+	}
+	result.SidecarContainerSpecs = append(result.SidecarContainerSpecs, other.SidecarContainerSpecs...)
+	result.PodsWithVolumes = append(result.PodsWithVolumes, other.PodsWithVolumes...)
+	result.NotReadyNodes = append(result.NotReadyNodes, other.NotReadyNodes...)
+	result.MirrorPodUids = append(result.MirrorPodUids, other.MirrorPodUids...)
+	result.SuccessCount += other.SuccessCount
+	result.ErrorCount += other.ErrorCount
+}
+
 func NewResultCollector(maxWorkerNumber int) *ResultCollector {
 	return &ResultCollector{
 		resultPool: make(chan *task.TaskResult, maxWorkerNumber),
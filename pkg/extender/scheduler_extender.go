@@ -0,0 +1,184 @@
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+)
+
+// The verbs of the scheduler extender, appended to the url prefix of the extender in the scheduler configuration
+const (
+	FilterVerb     = "filter"
+	PrioritizeVerb = "prioritize"
+
+	// The highest score of a node returned to the scheduler, which scales it by the weight of the extender
+	MaxExtenderPriority int64 = 10
+)
+
+// ExtenderArgs is the arguments of the filter and prioritize calls of the scheduler, as defined by the
+// k8s.io/kube-scheduler/extender/v1 api. The nodes are given by name only if the extender is nodeCacheCapable.
+type ExtenderArgs struct {
+	Pod       *api.Pod
+	Nodes     *api.NodeList
+	NodeNames *[]string
+}
+
+// ExtenderFilterResult is the result of a filter call of the scheduler, as defined by the
+// k8s.io/kube-scheduler/extender/v1 api.
+type ExtenderFilterResult struct {
+	Nodes                      *api.NodeList
+	NodeNames                  *[]string
+	FailedNodes                map[string]string
+	FailedAndUnresolvableNodes map[string]string
+	Error                      string
+}
+
+// HostPriority is the score of a node returned by a prioritize call of the scheduler, as defined by the
+// k8s.io/kube-scheduler/extender/v1 api.
+type HostPriority struct {
+	Host  string
+	Score int64
+}
+
+// NodeUtilizationSource provides the utilization of the nodes of the last discovery by node name, nil if the
+// discovery is older than the given max age.
+type NodeUtilizationSource interface {
+	NodeUtilizations(maxAge time.Duration) map[string]float64
+}
+
+// SchedulerExtender biases the initial placement of the pods toward the nodes which the last discovery found
+// under-utilized, so that the pods start in good locations rather than being moved later. It filters out the nodes
+// above the max utilization, unless no node would be left, and scores the other nodes by their free capacity. The
+// nodes unknown to the discovery, e.g. the nodes added since, pass the filter and get the middle score. Without a
+// discovery recent enough, all the nodes pass the filter and get the same score.
+type SchedulerExtender struct {
+	source         NodeUtilizationSource
+	maxUtilization float64
+	maxAge         time.Duration
+}
+
+func NewSchedulerExtender(source NodeUtilizationSource, maxUtilization float64,
+	maxAge time.Duration) *SchedulerExtender {
+	return &SchedulerExtender{
+		source:         source,
+		maxUtilization: maxUtilization,
+		maxAge:         maxAge,
+	}
+}
+
+// Filter filters out the nodes of the arguments above the max utilization.
+func (e *SchedulerExtender) Filter(args *ExtenderArgs) *ExtenderFilterResult {
+	utilizations := e.source.NodeUtilizations(e.maxAge)
+	names := nodeNames(args)
+	failedNodes := make(map[string]string)
+	for _, nodeName := range names {
+		if utilization, found := utilizations[nodeName]; found && utilization > e.maxUtilization {
+			failedNodes[nodeName] = fmt.Sprintf("node utilization %.2f is above %.2f", utilization,
+				e.maxUtilization)
+		}
+	}
+	if len(failedNodes) == len(names) {
+		// Keep all the nodes rather than leaving the pod pending on the utilization of the last discovery
+		failedNodes = make(map[string]string)
+	}
+	result := &ExtenderFilterResult{FailedNodes: failedNodes}
+	if args.Nodes != nil {
+		result.Nodes = &api.NodeList{}
+		for _, node := range args.Nodes.Items {
+			if _, failed := failedNodes[node.Name]; !failed {
+				result.Nodes.Items = append(result.Nodes.Items, node)
+			}
+		}
+	} else {
+		passedNodeNames := []string{}
+		for _, nodeName := range names {
+			if _, failed := failedNodes[nodeName]; !failed {
+				passedNodeNames = append(passedNodeNames, nodeName)
+			}
+		}
+		result.NodeNames = &passedNodeNames
+	}
+	if len(failedNodes) > 0 && args.Pod != nil {
+		glog.V(3).Infof("Filtered out %d nodes above utilization %.2f for pod %s/%s.", len(failedNodes),
+			e.maxUtilization, args.Pod.Namespace, args.Pod.Name)
+	}
+	return result
+}
+
+// Prioritize scores the nodes of the arguments by their free capacity.
+func (e *SchedulerExtender) Prioritize(args *ExtenderArgs) []HostPriority {
+	utilizations := e.source.NodeUtilizations(e.maxAge)
+	priorities := []HostPriority{}
+	for _, nodeName := range nodeNames(args) {
+		score := MaxExtenderPriority / 2
+		if utilizations == nil {
+			score = 0
+		} else if utilization, found := utilizations[nodeName]; found {
+			score = freeCapacityScore(utilization)
+		}
+		priorities = append(priorities, HostPriority{Host: nodeName, Score: score})
+	}
+	return priorities
+}
+
+// freeCapacityScore scores the free capacity of a node of the given utilization from 0 to MaxExtenderPriority.
+func freeCapacityScore(utilization float64) int64 {
+	if utilization >= 1 {
+		return 0
+	}
+	if utilization <= 0 {
+		return MaxExtenderPriority
+	}
+	return int64((1 - utilization) * float64(MaxExtenderPriority))
+}
+
+// nodeNames returns the names of the nodes of the arguments.
+func nodeNames(args *ExtenderArgs) []string {
+	if args.NodeNames != nil {
+		return *args.NodeNames
+	}
+	var names []string
+	if args.Nodes != nil {
+		for _, node := range args.Nodes.Items {
+			names = append(names, node.Name)
+		}
+	}
+	return names
+}
+
+// Handler returns the handler of the calls of the scheduler of the given verb.
+func (e *SchedulerExtender) Handler(verb string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.serve(w, r, verb)
+	})
+}
+
+func (e *SchedulerExtender) serve(w http.ResponseWriter, r *http.Request, verb string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	args := &ExtenderArgs{}
+	if err := json.NewDecoder(r.Body).Decode(args); err != nil {
+		http.Error(w, fmt.Sprintf("invalid extender arguments: %v", err), http.StatusBadRequest)
+		return
+	}
+	var result interface{}
+	switch verb {
+	case FilterVerb:
+		result = e.Filter(args)
+	case PrioritizeVerb:
+		result = e.Prioritize(args)
+	default:
+		http.Error(w, fmt.Sprintf("unknown verb %q", verb), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		glog.Errorf("Failed to write the result of the scheduler extender %s: %v", verb, err)
+	}
+}
@@ -0,0 +1,81 @@
+package extender
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeUtilizationSource map[string]float64
+
+func (f fakeUtilizationSource) NodeUtilizations(_ time.Duration) map[string]float64 {
+	return f
+}
+
+func newNodeList(names ...string) *api.NodeList {
+	nodes := &api.NodeList{}
+	for _, name := range names {
+		nodes.Items = append(nodes.Items, api.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return nodes
+}
+
+func TestFilter(t *testing.T) {
+	e := NewSchedulerExtender(fakeUtilizationSource{"node-1": 0.95, "node-2": 0.5}, 0.9, time.Minute)
+
+	result := e.Filter(&ExtenderArgs{Nodes: newNodeList("node-1", "node-2", "node-3")})
+	assert.Equal(t, newNodeList("node-2", "node-3"), result.Nodes)
+	assert.Contains(t, result.FailedNodes, "node-1")
+
+	nodeNames := []string{"node-1", "node-2"}
+	result = e.Filter(&ExtenderArgs{NodeNames: &nodeNames})
+	assert.Equal(t, []string{"node-2"}, *result.NodeNames)
+
+	// All the nodes are kept if all are above the max utilization
+	result = e.Filter(&ExtenderArgs{Nodes: newNodeList("node-1")})
+	assert.Equal(t, newNodeList("node-1"), result.Nodes)
+	assert.Empty(t, result.FailedNodes)
+
+	// All the nodes pass without a recent discovery
+	e = NewSchedulerExtender(fakeUtilizationSource(nil), 0.9, time.Minute)
+	result = e.Filter(&ExtenderArgs{Nodes: newNodeList("node-1", "node-2")})
+	assert.Equal(t, newNodeList("node-1", "node-2"), result.Nodes)
+}
+
+func TestPrioritize(t *testing.T) {
+	e := NewSchedulerExtender(fakeUtilizationSource{"node-1": 0.95, "node-2": 0.2}, 0.9, time.Minute)
+	assert.Equal(t, []HostPriority{{Host: "node-1", Score: 0}, {Host: "node-2", Score: 8}, {Host: "node-3", Score: 5}},
+		e.Prioritize(&ExtenderArgs{Nodes: newNodeList("node-1", "node-2", "node-3")}))
+
+	e = NewSchedulerExtender(fakeUtilizationSource(nil), 0.9, time.Minute)
+	assert.Equal(t, []HostPriority{{Host: "node-1", Score: 0}, {Host: "node-2", Score: 0}},
+		e.Prioritize(&ExtenderArgs{Nodes: newNodeList("node-1", "node-2")}))
+}
+
+func TestHandler(t *testing.T) {
+	e := NewSchedulerExtender(fakeUtilizationSource{"node-1": 0.5}, 0.9, time.Minute)
+	body, _ := json.Marshal(&ExtenderArgs{Nodes: newNodeList("node-1")})
+	recorder := httptest.NewRecorder()
+	e.Handler(PrioritizeVerb).ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodPost, "/scheduler-extender/prioritize", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var priorities []HostPriority
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &priorities))
+	assert.Equal(t, []HostPriority{{Host: "node-1", Score: 5}}, priorities)
+
+	recorder = httptest.NewRecorder()
+	e.Handler(FilterVerb).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/scheduler-extender/filter", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	e.Handler(FilterVerb).ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodPost, "/scheduler-extender/filter", bytes.NewReader([]byte("{"))))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
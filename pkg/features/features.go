@@ -88,6 +88,23 @@ const (
 	// same. More details in warning note here ->
 	// https://docs.openshift.com/container-platform/3.11/dev_guide/deployments/basic_deployment_operations.html#triggers
 	ForceDeploymentConfigRollout featuregate.Feature = "ForceDeploymentConfigRollout"
+
+	// PodReadinessGates owner: @kevinwang
+	// alpha:
+	//
+	// This gate will evaluate the custom readiness gates (spec.readinessGates) of a pod, in
+	// addition to the built-in Ready condition, when determining if the pod is ready.
+	// A pod with an unmet readiness gate is treated as not ready, so it is excluded from
+	// the features which only consider ready pods.
+	PodReadinessGates featuregate.Feature = "PodReadinessGates"
+
+	// LoadBalancerServices owner: @kevinwang
+	// alpha:
+	//
+	// This gate will enable the discovery of the ingress addresses and the backends of the
+	// services of type LoadBalancer as the properties of the service entities, and model the
+	// external load balancer with an assigned ingress address as a provider of the service.
+	LoadBalancerServices featuregate.Feature = "LoadBalancerServices"
 )
 
 func init() {
@@ -111,4 +128,6 @@ var DefaultKubeturboFeatureGates = map[featuregate.Feature]featuregate.FeatureSp
 	IgnoreAffinities:              {Default: false, PreRelease: featuregate.Alpha},
 	NewAffinityProcessing:         {Default: true, PreRelease: featuregate.Beta},
 	ForceDeploymentConfigRollout:  {Default: false, PreRelease: featuregate.Alpha},
+	PodReadinessGates:             {Default: false, PreRelease: featuregate.Alpha},
+	LoadBalancerServices:          {Default: false, PreRelease: featuregate.Alpha},
 }
@@ -0,0 +1,100 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/discovery"
+
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// healthState holds what the readiness and liveness checks of kubeturbo look at.
+type healthState struct {
+	// Reaches the API server, nil if not checked
+	serverVersion discovery.ServerVersionInterface
+	// Whether the probe has registered with the Turbo server
+	isRegistered func() bool
+	// When the last discovery completed
+	discoveryStatus *discoveryutil.DiscoveryStatus
+	// A discovery is expected to complete at least once per twice the discovery interval
+	discoveryInterval time.Duration
+	// The time kubeturbo started, which stands for the last discovery before the first one completes
+	startTime time.Time
+	now       func() time.Time
+	// Whether kubeturbo is shutting down, no longer accepting actions
+	shuttingDown atomic.Bool
+}
+
+// ReadinessChecks returns the checks of /readyz: kubeturbo is ready when the API server is reachable, the probe has
+// registered with the Turbo server, and the last discovery is recent.
+func (s *K8sTAPService) ReadinessChecks() []healthz.HealthChecker {
+	return []healthz.HealthChecker{
+		healthz.NamedCheck("kube-apiserver", s.health.checkAPIServer),
+		healthz.NamedCheck("turbo-server", s.health.checkRegistered),
+		healthz.NamedCheck("discovery", s.health.checkDiscovery),
+		healthz.NamedCheck("shutdown", s.health.checkShutdown),
+	}
+}
+
+// LivenessChecks returns the checks of /livez: kubeturbo is alive as long as the discoveries keep completing, so that
+// a wedged kubeturbo is restarted. The reachability of the servers is left to the readiness, as a restart does not
+// help when the servers are down, and so is the first discovery, which only starts once a target is added.
+func (s *K8sTAPService) LivenessChecks() []healthz.HealthChecker {
+	return []healthz.HealthChecker{
+		healthz.NamedCheck("discovery", s.health.checkDiscoveryProgress),
+	}
+}
+
+func (h *healthState) checkAPIServer(_ *http.Request) error {
+	if h.serverVersion == nil {
+		return nil
+	}
+	if _, err := h.serverVersion.ServerVersion(); err != nil {
+		return fmt.Errorf("API server is not reachable: %v", err)
+	}
+	return nil
+}
+
+func (h *healthState) checkShutdown(_ *http.Request) error {
+	if h.shuttingDown.Load() {
+		return fmt.Errorf("kubeturbo is shutting down")
+	}
+	return nil
+}
+
+func (h *healthState) checkRegistered(_ *http.Request) error {
+	if !h.isRegistered() {
+		return fmt.Errorf("probe has not registered with the Turbo server")
+	}
+	return nil
+}
+
+// checkDiscovery fails when no discovery has completed within twice the discovery interval. The Turbo server only
+// requests discoveries while it is connected, so a lost connection fails the check as well.
+func (h *healthState) checkDiscovery(_ *http.Request) error {
+	lastCompleted := h.discoveryStatus.LastCompleted()
+	if lastCompleted.IsZero() {
+		lastCompleted = h.startTime
+	}
+	return h.checkDiscoveredSince(lastCompleted)
+}
+
+// checkDiscoveryProgress is like checkDiscovery, but does not fail before the first discovery completes.
+func (h *healthState) checkDiscoveryProgress(_ *http.Request) error {
+	lastCompleted := h.discoveryStatus.LastCompleted()
+	if lastCompleted.IsZero() {
+		return nil
+	}
+	return h.checkDiscoveredSince(lastCompleted)
+}
+
+func (h *healthState) checkDiscoveredSince(lastCompleted time.Time) error {
+	if since := h.now().Sub(lastCompleted); since > 2*h.discoveryInterval {
+		return fmt.Errorf("no discovery has completed in the last %v", since.Round(time.Second))
+	}
+	return nil
+}
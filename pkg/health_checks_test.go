@@ -0,0 +1,55 @@
+package kubeturbo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+func TestHealthStateCheckDiscovery(t *testing.T) {
+	now := time.Now()
+	health := &healthState{
+		discoveryStatus:   discoveryutil.NewDiscoveryStatus(),
+		discoveryInterval: 10 * time.Minute,
+		startTime:         now,
+		now:               func() time.Time { return now },
+	}
+
+	// Before the first discovery, kubeturbo is alive, and ready until twice the discovery interval after it started
+	assert.NoError(t, health.checkDiscovery(nil))
+	assert.NoError(t, health.checkDiscoveryProgress(nil))
+	now = now.Add(21 * time.Minute)
+	assert.Error(t, health.checkDiscovery(nil))
+	assert.NoError(t, health.checkDiscoveryProgress(nil))
+
+	health.discoveryStatus.Begin()
+	health.discoveryStatus.Complete()
+	now = time.Now().Add(15 * time.Minute)
+	assert.NoError(t, health.checkDiscovery(nil))
+	assert.NoError(t, health.checkDiscoveryProgress(nil))
+
+	// The discovery is stale
+	now = now.Add(10 * time.Minute)
+	assert.Error(t, health.checkDiscovery(nil))
+	assert.Error(t, health.checkDiscoveryProgress(nil))
+}
+
+func TestHealthStateCheckRegistered(t *testing.T) {
+	registered := false
+	health := &healthState{isRegistered: func() bool { return registered }}
+	assert.Error(t, health.checkRegistered(nil))
+	registered = true
+	assert.NoError(t, health.checkRegistered(nil))
+	// The API server is not checked without a client
+	assert.NoError(t, health.checkAPIServer(nil))
+}
+
+func TestHealthStateCheckShutdown(t *testing.T) {
+	health := &healthState{}
+	assert.NoError(t, health.checkShutdown(nil))
+	health.shuttingDown.Store(true)
+	assert.Error(t, health.checkShutdown(nil))
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/turbonomic/kubeturbo/pkg/action"
+	"github.com/turbonomic/kubeturbo/pkg/audit"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
@@ -16,10 +18,14 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/metricsserver"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/prometheus"
+	discoveryutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/features"
 	"github.com/turbonomic/kubeturbo/pkg/registration"
 	"github.com/turbonomic/kubeturbo/version"
 	"github.com/turbonomic/turbo-go-sdk/pkg/probe"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	"github.com/turbonomic/turbo-go-sdk/pkg/service"
 )
 
@@ -41,11 +47,19 @@ type K8sTAPServiceSpec struct {
 	*detectors.HANodeConfig           `json:"HANodeConfig,omitempty"`
 	*detectors.AnnotationWhitelist    `json:"annotationWhitelist,omitempty"`
 	FeatureGates                      map[string]bool `json:"featureGates,omitempty"`
+	// The path of the PEM encoded CA bundle against which the certificate of the Turbo server is verified
+	ServerCABundle string `json:"serverCABundle,omitempty"`
+	// The entity types left out of the supply chain and the discovery, e.g. ["services", "volumes"]
+	DisabledEntityTypes []string `json:"disabledEntityTypes,omitempty"`
+	// The API token of the Turbo API, used in place of the username and password to add the target
+	TurboAPIToken string `json:"turboAPIToken,omitempty"`
+	// The infrastructure namespaces and how their pods are modeled, in place of those of the command line
+	InfrastructurePods *configs.InfrastructurePods `json:"infrastructurePods,omitempty"`
 }
 
 func ParseK8sTAPServiceSpec(configFile string, defaultTargetName string) (*K8sTAPServiceSpec, error) {
-	// load the config
-	tapSpec, err := readK8sTAPServiceSpec(configFile)
+	// load the config, overridden by the environment
+	tapSpec, err := readK8sTAPServiceSpecWithEnv(configFile)
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +86,7 @@ func ParseK8sTAPServiceSpec(configFile string, defaultTargetName string) (*K8sTA
 		glog.V(2).Infof("credentials mount path %s does not exist", credentialsDirPath)
 	}
 
+	loadOpsMgrCredentialsFromEnv(tapSpec)
 	if err := loadOpsMgrCredentialsFromSecret(tapSpec); err != nil {
 		return nil, err
 	}
@@ -79,6 +94,10 @@ func ParseK8sTAPServiceSpec(configFile string, defaultTargetName string) (*K8sTA
 	if err := loadClientIdSecretFromSecret(tapSpec); err != nil {
 		return nil, err
 	}
+	loadAPIToken(tapSpec, apiTokenFilePath)
+	if err := loadServerProxy(tapSpec, proxyUsernameFilePath, proxyPasswordFilePath); err != nil {
+		return nil, err
+	}
 	if err := tapSpec.ValidateTurboCommunicationConfig(); err != nil {
 		return nil, err
 	}
@@ -87,6 +106,14 @@ func ParseK8sTAPServiceSpec(configFile string, defaultTargetName string) (*K8sTA
 		return nil, err
 	}
 
+	if _, err := configs.ParseDisabledEntityTypes(strings.Join(tapSpec.DisabledEntityTypes, ",")); err != nil {
+		return nil, fmt.Errorf("invalid disabledEntityTypes: %v", err)
+	}
+
+	if _, err := new(configs.InfrastructurePods).Override(tapSpec.InfrastructurePods); err != nil {
+		return nil, fmt.Errorf("invalid infrastructurePods: %v", err)
+	}
+
 	// This function aborts the program upon fatal error
 	detectors.ValidateAndParseDetectors(tapSpec.MasterNodeDetectors,
 		tapSpec.DaemonPodDetectors, tapSpec.HANodeConfig, tapSpec.AnnotationWhitelist)
@@ -96,6 +123,16 @@ func ParseK8sTAPServiceSpec(configFile string, defaultTargetName string) (*K8sTA
 	return tapSpec, nil
 }
 
+// ReadFeatureGates returns the feature gates of the TAP config. The feature gates are process-wide, so they are read
+// once for all the clusters sharing the TAP config rather than with the TAP config of each cluster.
+func ReadFeatureGates(configFile string) (map[string]bool, error) {
+	tapSpec, err := readK8sTAPServiceSpecWithEnv(configFile)
+	if err != nil {
+		return nil, err
+	}
+	return tapSpec.FeatureGates, nil
+}
+
 func logFeatureGates(tapSpec *K8sTAPServiceSpec) {
 	featureGates := make(map[string]bool)
 	for featureGate, flag := range tapSpec.FeatureGates {
@@ -184,57 +221,198 @@ func readK8sTAPServiceSpec(path string) (*K8sTAPServiceSpec, error) {
 }
 
 func createProbeConfigOrDie(c *Config) *configs.ProbeConfig {
+	discoveryScraper, actionScraper := createClusterScrapers(c)
+
 	// Create Kubelet monitoring
-	kubeletMonitoringConfig := kubelet.NewKubeletMonitorConfig(c.KubeletClient, c.KubeClient)
+	kubeletMonitoringConfig := kubelet.NewKubeletMonitorConfig(c.KubeletClient, discoveryScraper.Clientset).
+		WithCgroupVersion(c.CgroupVersion).
+		WithCollectSwapMetrics(c.CollectSwapMetrics).
+		WithMetricsSource(c.KubeletMetricsSource)
 
 	// Create cluster monitoring
-	clusterScraper := cluster.NewClusterScraper(c.RestConfig, c.KubeClient,
-		c.DynamicClient, c.ControllerRuntimeClient, c.OsClient, c.CAClient, c.CAPINamespace)
-	masterMonitoringConfig := master.NewClusterMonitorConfig(clusterScraper)
+	masterMonitoringConfig := master.NewClusterMonitorConfig(discoveryScraper)
 
-	// TODO for now kubelet is the only monitoring source. As we have more sources, we should choose what to be added into the slice here.
 	monitoringConfigs := []monitoring.MonitorWorkerConfig{
 		kubeletMonitoringConfig,
 		masterMonitoringConfig,
 	}
 
+	// Collect the usage of pods and containers from Prometheus, backfilled from the kubelet by default
+	if c.PrometheusServerURL != "" {
+		glog.Infof("Collecting the usage of pods and containers from Prometheus server %s.", c.PrometheusServerURL)
+		prometheusMonitoringConfig := prometheus.NewPrometheusMonitorConfig(c.PrometheusServerURL).
+			WithQueries(c.PrometheusCPUQuery, c.PrometheusMemoryQuery).
+			WithStep(c.PrometheusQueryStep)
+		monitoringConfigs = append(monitoringConfigs, prometheusMonitoringConfig)
+	}
+
+	// Collect the usage from the Kubernetes metrics API, backfilling the usage the kubelet could not collect by default
+	if c.CollectMetricsServerUsage {
+		monitoringConfigs = append(monitoringConfigs,
+			metricsserver.NewMetricsServerMonitorConfig(discoveryScraper.DynamicClient))
+	}
+
 	probeConfig := &configs.ProbeConfig{
 		StitchingPropertyType: c.StitchingPropType,
+		StitchingProvider:     c.StitchingProvider,
 		MonitoringConfigs:     monitoringConfigs,
-		ClusterScraper:        clusterScraper,
+		ClusterScraper:        discoveryScraper,
+		ActionClusterScraper:  actionScraper,
 		NodeClient:            c.KubeletClient,
+		IncludeBatchWorkloads: c.IncludeBatchWorkloads,
+	}
+	// The infrastructure pods of the command line, overridden by the TAP config, which have both been validated
+	var tapInfrastructurePods *configs.InfrastructurePods
+	if c.tapSpec != nil {
+		tapInfrastructurePods = c.tapSpec.InfrastructurePods
 	}
+	probeConfig.InfrastructurePods, _ = c.InfrastructurePods.Override(tapInfrastructurePods)
+	if probeConfig.InfrastructurePods.Mode != configs.InfrastructurePodsWorkload {
+		glog.Infof("Modeling the pods of the infrastructure namespaces as %s.", probeConfig.InfrastructurePods)
+	}
+	// The priority of the monitoring sources which collect the same metrics
+	probeConfig.MonitoringSourcePriority = c.MonitoringSourcePriority
 
 	return probeConfig
 }
 
+// createClusterScrapers returns the cluster scraper used by discovery and the one used by actions.
+// Discovery lists resources through the discovery clients if they are configured, e.g. against a read
+// replica of the API server, while actions always use the primary clients. Without discovery clients,
+// a single scraper is shared by discovery and actions.
+func createClusterScrapers(c *Config) (*cluster.ClusterScraper, *cluster.ClusterScraper) {
+	actionScraper := cluster.NewClusterScraper(c.RestConfig, c.KubeClient,
+		c.DynamicClient, c.ControllerRuntimeClient, c.OsClient, c.CAClient, c.CAPINamespace).
+		WithControllerGroupVersions(c.ControllerGroupVersions)
+	if c.DiscoveryRestConfig == nil || c.DiscoveryKubeClient == nil || c.DiscoveryDynamicClient == nil {
+		return actionScraper, actionScraper
+	}
+	discoveryScraper := cluster.NewClusterScraper(c.DiscoveryRestConfig, c.DiscoveryKubeClient,
+		c.DiscoveryDynamicClient, c.ControllerRuntimeClient, c.OsClient, c.CAClient, c.CAPINamespace).
+		WithControllerGroupVersions(c.ControllerGroupVersions)
+	// The GitOps configurations are discovered by discovery but used by actions
+	discoveryScraper.MirrorGitOpsConfigCacheTo(actionScraper)
+	return discoveryScraper, actionScraper
+}
+
 type K8sTAPService struct {
 	*service.TAPService
+	// The state reported by the readiness and liveness checks
+	health *healthState
+	// Drained before disconnecting from the Turbo server when kubeturbo shuts down
+	actionHandler   *action.ActionHandler
+	discoveryClient *discovery.K8sDiscoveryClient
+	// Adds the target again through the Turbo API if it is not discovered, nil if not auto-added through the API
+	targetRegistrar *targetRegistrar
+	// Asks the server to rediscover the target through the Turbo API, nil without the Turbo API credentials
+	targetRediscoverer *targetRediscoverer
 }
 
-func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
-	if config == nil || config.tapSpec == nil {
-		return nil, errors.New("invalid K8sTAPServiceConfig")
-	}
-
-	probeConfig := createProbeConfigOrDie(config)
-
+// newDiscoveryClientConfig creates the configuration of the discovery of the cluster, which reports the degraded
+// discoveries to the given status and records its events with the given audit recorder.
+func newDiscoveryClientConfig(config *Config, probeConfig *configs.ProbeConfig,
+	discoveryStatus *discoveryutil.DiscoveryStatus, auditRecorder *audit.Recorder) *discovery.DiscoveryClientConfig {
 	discoveryClientConfig := discovery.NewDiscoveryConfig(probeConfig, config.tapSpec.K8sTargetConfig,
 		config.ValidationWorkers, config.ValidationTimeoutSec, config.containerUtilizationDataAggStrategy,
 		config.containerUsageDataAggStrategy, config.ORMClientManager, config.DiscoveryWorkers, config.DiscoveryTimeoutSec,
 		config.DiscoverySamples, config.DiscoverySampleIntervalSec, config.ItemsPerListQuery)
 
+	if config.BusinessAppLabel != "" {
+		discoveryClientConfig = discoveryClientConfig.WithBusinessAppLabel(config.BusinessAppLabel)
+	}
+
+	if config.UtilizationPercentile > 0 {
+		discoveryClientConfig = discoveryClientConfig.WithUtilizationPercentile(config.UtilizationPercentile,
+			config.UtilizationWindow).WithUtilizationLimits(config.UtilizationMaxSamples, config.UtilizationMaxEntities)
+	}
+
+	discoveryClientConfig = discoveryClientConfig.WithPropertyNormalization(config.PropertyConflictPolicy,
+		config.MaxEntityProperties).WithSchemaVersion(config.EmitSchemaVersion)
+
+	discoveryClientConfig = discoveryClientConfig.WithDiscoveryStatus(discoveryStatus).
+		WithEntityLimits(config.EntityLimits)
+
 	if config.clusterKeyInjected != "" {
 		discoveryClientConfig = discoveryClientConfig.WithClusterKeyInjected(config.clusterKeyInjected)
 	}
 
+	incrementalDiscovery := config.IncrementalDiscoveryIntervalSec > 0
+	discoveryClientConfig = discoveryClientConfig.WithIncrementalDiscovery(incrementalDiscovery).
+		WithDumpDTOsDir(config.DumpDTOsDir).
+		WithInformerCache(config.InformerCache).
+		WithSnapshotReuseWindow(config.DiscoverySnapshotReuseWindow).
+		WithMaxUnchangedDiscoveries(config.MaxUnchangedDiscoveries)
+
+	// The entity types disabled by either the command line or the TAP config
+	tapDisabledEntityTypes, _ := configs.ParseDisabledEntityTypes(strings.Join(config.tapSpec.DisabledEntityTypes, ","))
+	disabledEntityTypes := config.DisabledEntityTypes.Union(tapDisabledEntityTypes)
+	if len(disabledEntityTypes) > 0 {
+		glog.Infof("Entity types left out of the supply chain and the discovery: %v.", disabledEntityTypes)
+	}
+	discoveryClientConfig = discoveryClientConfig.WithDisabledEntityTypes(disabledEntityTypes).
+		WithSidecarResizeMode(config.SidecarResizeMode).
+		WithVPACoexistenceMode(config.VPACoexistenceMode)
+	discoveryClientConfig = discoveryClientConfig.WithAuditRecorder(auditRecorder)
+	if config.ServiceMeshPrometheusURL != "" {
+		glog.Infof("Collecting the metrics of the services of the Istio service mesh from Prometheus server %s.",
+			config.ServiceMeshPrometheusURL)
+		discoveryClientConfig = discoveryClientConfig.WithServiceMeshCollector(
+			prometheus.NewServiceMeshCollector(config.ServiceMeshPrometheusURL).
+				WithQueries(config.ServiceMeshTransactionQuery, config.ServiceMeshResponseTimeQuery).
+				WithStep(config.PrometheusQueryStep))
+	}
+	return discoveryClientConfig
+}
+
+// NewDiscoveryShardWorker creates the discovery client of a discovery shard worker, which discovers the shards of
+// nodes assigned by the coordinator with the discovery configuration of the cluster.
+func NewDiscoveryShardWorker(config *Config) (*discovery.K8sDiscoveryClient, error) {
+	if config == nil || config.tapSpec == nil {
+		return nil, errors.New("invalid K8sTAPServiceConfig")
+	}
+	probeConfig := createProbeConfigOrDie(config)
+	discoveryClientConfig := newDiscoveryClientConfig(config, probeConfig, discoveryutil.NewDiscoveryStatus(),
+		config.AuditLog.Recorder(config.tapSpec.TargetIdentifier))
+	return discovery.NewK8sDiscoveryClient(discoveryClientConfig), nil
+}
+
+func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
+	if config == nil || config.tapSpec == nil {
+		return nil, errors.New("invalid K8sTAPServiceConfig")
+	}
+
+	probeConfig := createProbeConfigOrDie(config)
+
+	// The degraded discoveries are tracked by discovery, and may block the action execution
+	discoveryStatus := discoveryutil.NewDiscoveryStatus()
+	// The events of the audit log are recorded with the target of the pipeline
+	auditRecorder := config.AuditLog.Recorder(config.tapSpec.TargetIdentifier)
+	discoveryClientConfig := newDiscoveryClientConfig(config, probeConfig, discoveryStatus, auditRecorder).
+		WithShardDiscoverer(config.ShardDiscoverer)
+	disabledEntityTypes := discoveryClientConfig.DisabledEntityTypes
+
 	k8sSvcId, err := probeConfig.ClusterScraper.GetKubernetesServiceID()
 	if err != nil {
 		glog.Fatalf("Error retrieving the Kubernetes service id: %v", err)
 	}
 	actionHandlerConfig := action.NewActionHandlerConfig(config.CAPINamespace, config.KubeletClient,
-		probeConfig.ClusterScraper, config.SccSupport, config.ORMClientManager, config.failVolumePodMoves,
-		config.updateQuotaToAllowMoves, config.readinessRetryThreshold, config.gitConfig, k8sSvcId)
+		probeConfig.ActionClusterScraper, config.SccSupport, config.ORMClientManager, config.failVolumePodMoves,
+		config.updateQuotaToAllowMoves, config.readinessRetryThreshold, config.gitConfig, k8sSvcId).
+		WithAnnotateActionResults(config.AnnotateActionResults).
+		WithRefusalReasonPrefix(config.RefusalReasonPrefix).
+		WithEventRecorder(config.ActionEventRecorder).
+		WithSkipActionsOnDegradedDiscovery(discoveryStatus, config.SkipActionsOnDegradedDiscovery).
+		WithResizeRolloutTimeout(config.ResizeRolloutTimeout).
+		WithResizeLimits(config.ResizeLimits).
+		WithActionMode(config.ActionMode).
+		WithNodeSuspendMode(config.NodeSuspendMode, config.MaxConcurrentNodeDrains).
+		WithPodMoveStrategy(config.PodMoveStrategy, config.MoveEndpointsTimeout).
+		WithMovePlacement(config.MovePlacement).
+		WithActionPolicyConfigMap(config.ActionPolicyNamespace, config.ActionPolicyName).
+		WithActionLimits(config.ActionLimits, config.ActionQueueTimeout).
+		WithAuditRecorder(auditRecorder).
+		WithStateDir(config.StateDir, config.tapSpec.TargetIdentifier).
+		WithTarget(config.tapSpec.TargetIdentifier)
 
 	// Kubernetes Probe Discovery Client
 	discoveryClient := discovery.NewK8sDiscoveryClient(discoveryClientConfig)
@@ -248,9 +426,10 @@ func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
 	// TODO: Remove logic that checks ClusterAPI for action policies during probe registration when target level
 	//  action policy is implemented in the server
 	registrationClientConfig := registration.NewRegistrationClientConfig(config.StitchingPropType, config.VMPriority,
-		config.VMIsBase)
+		config.VMIsBase).WithDisabledEntityTypes(disabledEntityTypes)
 	registrationClient := registration.NewK8sRegistrationClient(registrationClientConfig,
-		config.tapSpec.K8sTargetConfig, targetAccountValues.AccountValues(), k8sSvcId)
+		config.tapSpec.K8sTargetConfig, targetAccountValues.AccountValues(), k8sSvcId).
+		WithReRegistrationHandler(discoveryClient.ResetIncrementalDiscovery)
 
 	probeVersion := version.Version
 	probeDisplayName := getProbeDisplayName(config.tapSpec.TargetType, config.tapSpec.TargetIdentifier)
@@ -259,7 +438,8 @@ func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
 		config.tapSpec.ProbeCategory, config.tapSpec.ProbeUICategory).
 		WithVersion(probeVersion).
 		WithDisplayName(probeDisplayName).
-		WithDiscoveryOptions(probe.FullRediscoveryIntervalSecondsOption(int32(config.DiscoveryIntervalSec))).
+		WithDiscoveryOptions(probe.FullRediscoveryIntervalSecondsOption(int32(config.DiscoveryIntervalSec)),
+			probe.IncrementalRediscoveryIntervalSecondsOption(int32(config.IncrementalDiscoveryIntervalSec))).
 		RegisteredBy(registrationClient).
 		WithActionPolicies(registrationClient).
 		WithEntityMetadata(registrationClient).
@@ -298,8 +478,46 @@ func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
 	if err != nil {
 		return nil, err
 	}
+	if discoveryClientConfig.IncrementalDiscovery {
+		// The probe builder does not take an incremental discovery client
+		tapService.TurboProbe.DiscoveryClient.IIncrementalDiscovery = discoveryClient
+	}
 
-	return &K8sTAPService{tapService}, nil
+	var registrar *targetRegistrar
+	var rediscoverer *targetRediscoverer
+	if len(config.tapSpec.TargetIdentifier) > 0 &&
+		(config.tapSpec.TurboAPICredentialsProvided() || config.tapSpec.turboAPITokenProvided()) {
+		// The target is added through the Turbo API again if the addition by the SDK fails
+		registrar, err = newTurboAPITargetRegistrar(tapService, config.tapSpec, k8sSvcId,
+			registrationClient.IsRegistered, discoveryStatus.LastCompleted,
+			time.Duration(config.DiscoveryIntervalSec)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if rediscoverer, err = newTurboAPITargetRediscoverer(tapService, config.tapSpec); err != nil {
+			return nil, err
+		}
+	}
+
+	health := &healthState{
+		isRegistered:      registrationClient.IsRegistered,
+		discoveryStatus:   discoveryStatus,
+		discoveryInterval: time.Duration(config.DiscoveryIntervalSec) * time.Second,
+		startTime:         time.Now(),
+		now:               time.Now,
+	}
+	if config.KubeClient != nil {
+		health.serverVersion = config.KubeClient.Discovery()
+	}
+
+	return &K8sTAPService{
+		TAPService:         tapService,
+		health:             health,
+		actionHandler:      actionHandler,
+		discoveryClient:    discoveryClient,
+		targetRegistrar:    registrar,
+		targetRediscoverer: rediscoverer,
+	}, nil
 }
 
 // getProbeDisplayName constructs a display name for the probe based on the input probe type and target id
@@ -307,6 +525,61 @@ func getProbeDisplayName(probeType, targetId string) string {
 	return strings.Join([]string{probeType, "Probe", targetId}, " ")
 }
 
+// DiscoverySnapshot returns the snapshot of the response of the last full discovery sent to the server.
+func (s *K8sTAPService) DiscoverySnapshot() *discovery.DiscoverySnapshot {
+	return s.discoveryClient.DiscoverySnapshot()
+}
+
+// AccountValues returns the account values of the target of the cluster, as set in the server when the target is
+// added.
+func (s *K8sTAPService) AccountValues() []*proto.AccountValue {
+	return s.discoveryClient.GetAccountValues().AccountValues()
+}
+
+// Rediscover asks the Turbo server to rediscover the target of the cluster at once through the Turbo API, e.g. on the
+// requests of the rediscover endpoint. The server then requests the full discovery from the probe as for the scheduled
+// ones, which discovers the cluster even within the snapshot reuse window. Rediscover waits for at most the timeout
+// for that discovery to complete, and returns the number of its entities by entity type. It returns
+// ErrRediscoveryTimeout along with the UUIDs of the target if the discovery does not complete in time.
+func (s *K8sTAPService) Rediscover(timeout time.Duration) (*Rediscovery, error) {
+	if s.targetRediscoverer == nil {
+		return nil, ErrRediscoveryUnavailable
+	}
+	requested := time.Now()
+	s.discoveryClient.RequireFreshDiscovery()
+	uuids, err := s.targetRediscoverer.rediscover()
+	if err != nil {
+		return nil, err
+	}
+	result := &Rediscovery{UUIDs: uuids}
+	entities, discoveryTime, completed := s.discoveryClient.WaitForFullDiscovery(s.targetRediscoverer.targetID,
+		requested, timeout)
+	if !completed {
+		return result, ErrRediscoveryTimeout
+	}
+	result.DiscoveryTime, result.Entities = discoveryTime, entities
+	return result, nil
+}
+
 func (s *K8sTAPService) Run() {
+	if s.targetRegistrar != nil {
+		go s.targetRegistrar.Run()
+	}
 	s.ConnectToTurbo()
 }
+
+// Shutdown stops accepting new actions, waits for the actions and the discovery in progress to complete for at most
+// the timeout, and disconnects from the Turbo server. The readiness check fails from the start of the shutdown.
+func (s *K8sTAPService) Shutdown(timeout time.Duration) {
+	glog.V(1).Infof("Shutting down kubeturbo, waiting for at most %v for the actions and the discovery in progress.",
+		timeout)
+	s.health.shuttingDown.Store(true)
+	deadline := time.Now().Add(timeout)
+	actionsCompleted := s.actionHandler.Shutdown(timeout)
+	discoveryCompleted := s.discoveryClient.WaitForDiscovery(time.Until(deadline))
+	// Close the mediation container including the endpoints. It avoids the invalid endpoints remaining in the server
+	// side. See OM-28801.
+	s.DisconnectFromTurbo()
+	glog.V(1).Infof("Kubeturbo is shut down: actions in progress completed: %t, discovery in progress completed: %t.",
+		actionsCompleted, discoveryCompleted)
+}
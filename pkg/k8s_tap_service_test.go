@@ -4,6 +4,10 @@ import (
 	"strings"
 	"testing"
 
+	"k8s.io/client-go/dynamic"
+	kubeclient "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 )
@@ -152,6 +156,42 @@ func TestCreateProbeConfigOrDie(t *testing.T) {
 	}
 }
 
+func TestCreateProbeConfigWithDiscoveryClients(t *testing.T) {
+	primaryConfig := &restclient.Config{Host: "https://primary:6443"}
+	readConfig := &restclient.Config{Host: "https://read-replica:6443"}
+	primaryClient := kubeclient.NewForConfigOrDie(primaryConfig)
+	readClient := kubeclient.NewForConfigOrDie(readConfig)
+	primaryDynamicClient := dynamic.NewForConfigOrDie(primaryConfig)
+	readDynamicClient := dynamic.NewForConfigOrDie(readConfig)
+
+	// Discovery and actions share the primary clients when no discovery clients are configured
+	vmtConfig := NewVMTConfig2().WithKubeConfig(primaryConfig).WithKubeClient(primaryClient).
+		WithDynamicClient(primaryDynamicClient)
+	got := createProbeConfigOrDie(vmtConfig)
+	if got.ClusterScraper != got.ActionClusterScraper {
+		t.Errorf("Expected discovery and actions to share the cluster scraper")
+	}
+	if got.ClusterScraper.RestConfig.Host != primaryConfig.Host {
+		t.Errorf("Discovery host = %v, want %v", got.ClusterScraper.RestConfig.Host, primaryConfig.Host)
+	}
+
+	// Discovery uses the read clients and actions use the primary clients
+	vmtConfig.WithDiscoveryClients(readConfig, readClient, readDynamicClient)
+	got = createProbeConfigOrDie(vmtConfig)
+	if got.ClusterScraper.RestConfig.Host != readConfig.Host {
+		t.Errorf("Discovery host = %v, want %v", got.ClusterScraper.RestConfig.Host, readConfig.Host)
+	}
+	if got.ClusterScraper.Clientset != readClient || got.ClusterScraper.DynamicClient != readDynamicClient {
+		t.Errorf("Expected discovery to use the read clients")
+	}
+	if got.ActionClusterScraper.RestConfig.Host != primaryConfig.Host {
+		t.Errorf("Action host = %v, want %v", got.ActionClusterScraper.RestConfig.Host, primaryConfig.Host)
+	}
+	if got.ActionClusterScraper.Clientset != primaryClient || got.ActionClusterScraper.DynamicClient != primaryDynamicClient {
+		t.Errorf("Expected actions to use the primary clients")
+	}
+}
+
 func checkProbeConfig(t *testing.T, pc *configs.ProbeConfig, stitchingPropertyType stitching.StitchingPropertyType) {
 
 	if pc.StitchingPropertyType != stitchingPropertyType {
@@ -6,8 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -35,10 +38,11 @@ const (
 	configPath   string = "/configz"
 	cadvisorPath string = "/metrics/cadvisor"
 
-	DefaultKubeletPort  = 10255
-	DefaultKubeletHttps = false
+	DefaultKubeletPort       = 10255
+	DefaultKubeletHttps      = false
+	DefaultKubeletTimeoutSec = 20
 
-	defaultConnTimeOut         = 20 * time.Second
+	defaultConnTimeOut         = DefaultKubeletTimeoutSec * time.Second
 	defaultTLSHandShakeTimeout = 10 * time.Second
 
 	ContainerCPUThrottledTotal    = "container_cpu_cfs_throttled_periods_total"
@@ -48,6 +52,9 @@ const (
 	ContainerCPUThrottledTotalSec = "container_cpu_cfs_throttled_seconds_total"
 	ContainerCPUTotalUsageSec     = "container_cpu_usage_seconds_total"
 	ContainerThreads              = "container_threads"
+	ContainerMemorySwap           = "container_memory_swap"
+	ContainerMemoryWorkingSet     = "container_memory_working_set_bytes"
+	MachineMemoryBytes            = "machine_memory_bytes"
 )
 
 type KubeHttpClientInterface interface {
@@ -110,6 +117,8 @@ type KubeletClient struct {
 	// Fallback kubernetes API client to fetch data from node's proxy subresource
 	kubeClient         *kubernetes.Clientset
 	forceProxyEndpoint bool
+	// The timeout of a request to the kubelet of a node, directly or through the API server proxy
+	timeout time.Duration
 }
 
 type statusNotFoundError struct {
@@ -136,11 +145,19 @@ func (client *KubeletClient) ExecuteRequest(ip, nodeName, path string) ([]byte,
 		if err != nil && client.kubeClient != nil {
 			glog.V(2).Infof("Failed to query kubelet endpoint %s on node %s/%s: %v. "+
 				"Trying proxy endpoint.", path, nodeName, ip, err)
+			kubeletErr := err
 			body, err = client.callAPIServerProxyEndpoint(nodeName, path)
 			if err != nil {
+				if IsProxyUnreachableError(kubeletErr) {
+					// Report the unreachable metrics proxy so that the node can be skipped
+					return body, kubeletErr
+				}
 				return body, err
 			}
 		}
+		if err != nil {
+			return body, err
+		}
 	}
 
 	return body, nil
@@ -149,7 +166,7 @@ func (client *KubeletClient) ExecuteRequest(ip, nodeName, path string) ([]byte,
 func (client *KubeletClient) callKubeletEndpoint(ip, path string) ([]byte, error) {
 	requestURL := url.URL{
 		Scheme: client.scheme,
-		Host:   fmt.Sprintf("%s:%d", ip, client.port),
+		Host:   net.JoinHostPort(ip, strconv.Itoa(client.port)),
 		Path:   path,
 	}
 
@@ -158,7 +175,7 @@ func (client *KubeletClient) callKubeletEndpoint(ip, path string) ([]byte, error
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultConnTimeOut)
+	ctx, cancel := context.WithTimeout(context.Background(), client.timeout)
 	defer cancel()
 	req = req.WithContext(ctx)
 
@@ -166,7 +183,7 @@ func (client *KubeletClient) callKubeletEndpoint(ip, path string) ([]byte, error
 	response, err := httpClient.Do(req)
 	if err != nil {
 		glog.V(3).Infof("Failed to query kubelet via httpClient for the node %v on the path %v as the error:%v", ip, path, err)
-		return nil, fmt.Errorf("failed to execute the request: %s", err)
+		return nil, fmt.Errorf("failed to execute the request: %w", err)
 	}
 
 	defer response.Body.Close()
@@ -186,7 +203,7 @@ func (client *KubeletClient) callKubeletEndpoint(ip, path string) ([]byte, error
 func (client *KubeletClient) callAPIServerProxyEndpoint(nodeName, path string) ([]byte, error) {
 	var statusCode int
 	fullPath := fmt.Sprintf("%s%s%s%s", "/api/v1/nodes/", nodeName, "/proxy", path)
-	body, err := client.kubeClient.CoreV1().RESTClient().Get().Timeout(defaultConnTimeOut).AbsPath(fullPath).Do(context.TODO()).StatusCode(&statusCode).Raw()
+	body, err := client.kubeClient.CoreV1().RESTClient().Get().Timeout(client.timeout).AbsPath(fullPath).Do(context.TODO()).StatusCode(&statusCode).Raw()
 	if err != nil {
 		glog.V(3).Infof("Failed to query kubelet via RestClient for the node %v on the path %v as the error:%v", nodeName, path, err)
 		return nil, err
@@ -277,13 +294,16 @@ func (client *KubeletClient) GetKubeletThresholds(ip, nodeName string) ([]Thresh
 	return thresholds, nil
 }
 
-func (client *KubeletClient) GetCPUThrottlingMetrics(ip, nodeName string) (map[string]*dto.MetricFamily, error) {
+// GetCadvisorMetricFamilies gets all the metric families reported by cAdvisor on the node, so that the throttling,
+// swap and usage metrics are read from a single scrape of the endpoint.
+func (client *KubeletClient) GetCadvisorMetricFamilies(ip, nodeName string) (map[string]*dto.MetricFamily, error) {
 	data, err := client.ExecuteRequest(ip, nodeName, cadvisorPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return TextToThrottlingMetricFamilies(data)
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(bytes.NewReader(data))
 }
 
 func TextToThrottlingMetricFamilies(data []byte) (map[string]*dto.MetricFamily, error) {
@@ -293,8 +313,14 @@ func TextToThrottlingMetricFamilies(data []byte) (map[string]*dto.MetricFamily,
 		return nil, err
 	}
 
+	return ThrottlingMetricFamilies(parsed), nil
+}
+
+// ThrottlingMetricFamilies returns the cpu throttling metric families of the given cAdvisor metric families, or nil
+// if there is none.
+func ThrottlingMetricFamilies(parsed map[string]*dto.MetricFamily) map[string]*dto.MetricFamily {
 	if len(parsed) < 1 {
-		return nil, nil
+		return nil
 	}
 
 	metricFamilies := make(map[string]*dto.MetricFamily)
@@ -306,7 +332,39 @@ func TextToThrottlingMetricFamilies(data []byte) (map[string]*dto.MetricFamily,
 	metricFamilies[ContainerCPUTotalUsageSec] = parsed[ContainerCPUTotalUsageSec]
 	metricFamilies[ContainerThreads] = parsed[ContainerThreads]
 
-	return metricFamilies, nil
+	return metricFamilies
+}
+
+func TextToSwapMetricFamily(data []byte) (*dto.MetricFamily, error) {
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed[ContainerMemorySwap], nil
+}
+
+func TextToCadvisorMetricFamilies(data []byte) (map[string]*dto.MetricFamily, error) {
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return CadvisorUsageMetricFamilies(parsed), nil
+}
+
+// CadvisorUsageMetricFamilies returns the cpu and memory usage metric families of the given cAdvisor metric families,
+// which are used in place of the summary API when the cAdvisor metrics source is selected.
+func CadvisorUsageMetricFamilies(parsed map[string]*dto.MetricFamily) map[string]*dto.MetricFamily {
+	metricFamilies := make(map[string]*dto.MetricFamily)
+	for _, name := range []string{ContainerCPUTotalUsageSec, ContainerMemoryWorkingSet, MachineMemoryBytes} {
+		if metricFamily, found := parsed[name]; found {
+			metricFamilies[name] = metricFamily
+		}
+	}
+	return metricFamilies
 }
 
 // GetNodeCpuFrequency gets node single-core Frequency, in MHz
@@ -423,14 +481,43 @@ func (client *KubeletClient) HasCacheBeenUsed(ip string) bool {
 	return false
 }
 
+// KubeletAuthMode specifies how the kubelet client authenticates to the kubelet.
+type KubeletAuthMode string
+
+const (
+	// KubeletAuthModeDefault uses all the credentials (bearer token and client certificate)
+	// found in the kube config.
+	KubeletAuthModeDefault KubeletAuthMode = ""
+	// KubeletAuthModeToken uses the bearer token from the kube config only.
+	KubeletAuthModeToken KubeletAuthMode = "token"
+	// KubeletAuthModeClientCert uses the client certificate and key from the kube config only.
+	KubeletAuthModeClientCert KubeletAuthMode = "clientcert"
+	// KubeletAuthModeAnonymous sends requests to the kubelet without any credentials.
+	KubeletAuthModeAnonymous KubeletAuthMode = "anonymous"
+)
+
+// ParseKubeletAuthMode parses and validates the kubelet authentication mode.
+func ParseKubeletAuthMode(mode string) (KubeletAuthMode, error) {
+	switch authMode := KubeletAuthMode(strings.ToLower(strings.TrimSpace(mode))); authMode {
+	case KubeletAuthModeDefault, KubeletAuthModeToken, KubeletAuthModeClientCert, KubeletAuthModeAnonymous:
+		return authMode, nil
+	default:
+		return KubeletAuthModeDefault, fmt.Errorf("unsupported kubelet auth mode %q, must be one of %s, %s or %s",
+			mode, KubeletAuthModeToken, KubeletAuthModeClientCert, KubeletAuthModeAnonymous)
+	}
+}
+
 // ----------------- kubeletConfig -----------------------------------
 type KubeletConfig struct {
 	kubeConfig           *rest.Config
 	enableHttps          bool
 	forceSelfSignedCerts bool
+	authMode             KubeletAuthMode
 	port                 int
 	timeout              time.Duration // timeout when fetching information from kubelet;
 	tlsTimeOut           time.Duration
+	// SOCKS5 proxy used to reach the kubelet endpoints, in the form of socks5://[user:password@]host:port
+	socksProxy string
 }
 
 // Create a new KubeletConfig based on kubeConfig.
@@ -459,15 +546,36 @@ func (kc *KubeletConfig) ForceSelfSignedCerts(forceSelfSignedCerts bool) *Kubele
 	return kc
 }
 
+func (kc *KubeletConfig) WithAuthMode(authMode KubeletAuthMode) *KubeletConfig {
+	kc.authMode = authMode
+	return kc
+}
+
+func (kc *KubeletConfig) WithSOCKSProxy(socksProxy string) *KubeletConfig {
+	kc.socksProxy = socksProxy
+	return kc
+}
+
+// Timeout sets the timeout in seconds of a request to the kubelet, the default timeout is kept if not positive.
 func (kc *KubeletConfig) Timeout(timeout int) *KubeletConfig {
-	kc.timeout = time.Duration(timeout) * time.Second
+	if timeout > 0 {
+		kc.timeout = time.Duration(timeout) * time.Second
+	}
 	return kc
 }
 
 func (kc *KubeletConfig) Create(fallbackClient *kubernetes.Clientset, cpuFreqGetterImage, imagePullSecret string,
 	excludeLabelsMap map[string]set.Set, useProxyEndpoint bool) (*KubeletClient, error) {
 	// 1. http transport
-	transport, err := makeTransport(kc.kubeConfig, kc.enableHttps, kc.tlsTimeOut, kc.forceSelfSignedCerts)
+	var dialer *socks5Dialer
+	if kc.socksProxy != "" {
+		var err error
+		if dialer, err = newSOCKS5Dialer(kc.socksProxy); err != nil {
+			return nil, err
+		}
+		glog.V(2).Infof("Reaching the kubelet endpoints through the SOCKS5 proxy %s.", dialer.proxyAddress)
+	}
+	transport, err := makeTransport(kc.kubeConfig, kc.enableHttps, kc.tlsTimeOut, kc.forceSelfSignedCerts, kc.authMode, dialer)
 	if err != nil {
 		return nil, err
 	}
@@ -493,6 +601,7 @@ func (kc *KubeletConfig) Create(fallbackClient *kubernetes.Clientset, cpuFreqGet
 		defaultCpuFreq:              defaultCpuFreq,
 		kubeClient:                  fallbackClient,
 		forceProxyEndpoint:          useProxyEndpoint,
+		timeout:                     kc.timeout,
 	}, nil
 }
 
@@ -502,9 +611,13 @@ func (kc *KubeletConfig) Create(fallbackClient *kubernetes.Clientset, cpuFreqGet
 // The reason to copy the code from Heapster, instead of using kubernetes/pkg/kubelet/client.MakeTransport(), is that
 // Depending on Kubernetes will make it difficult to maintain the package dependency.
 // So I copied this code, which only depending on "k8s.io/client-go".
-func makeTransport(config *rest.Config, enableHttps bool, timeout time.Duration, forceSelfSignedCerts bool) (http.RoundTripper, error) {
+func makeTransport(config *rest.Config, enableHttps bool, timeout time.Duration, forceSelfSignedCerts bool,
+	authMode KubeletAuthMode, dialer *socks5Dialer) (http.RoundTripper, error) {
 	// 1. get transport.config
-	cfg := transportConfig(config, enableHttps, forceSelfSignedCerts)
+	cfg, err := transportConfig(config, enableHttps, forceSelfSignedCerts, authMode)
+	if err != nil {
+		return nil, err
+	}
 	tlsConfig, err := transport.TLSConfigFor(cfg)
 	if err != nil {
 		glog.Errorf("failed to get TLSConfig: %v", err)
@@ -516,17 +629,23 @@ func makeTransport(config *rest.Config, enableHttps bool, timeout time.Duration,
 
 	// 2. http client
 	rt := http.DefaultTransport
-	if tlsConfig != nil {
-		rt = netutil.SetOldTransportDefaults(&http.Transport{
+	if tlsConfig != nil || dialer != nil {
+		t := &http.Transport{
 			TLSClientConfig:     tlsConfig,
 			TLSHandshakeTimeout: timeout,
-		})
+		}
+		if dialer != nil {
+			// Reach the kubelet endpoints through the SOCKS5 proxy
+			t.DialContext = dialer.DialContext
+		}
+		rt = netutil.SetOldTransportDefaults(t)
 	}
 
 	return transport.HTTPWrappersForConfig(cfg, rt)
 }
 
-func transportConfig(config *rest.Config, enableHttps bool, forceSelfSignedCerts bool) *transport.Config {
+func transportConfig(config *rest.Config, enableHttps bool, forceSelfSignedCerts bool,
+	authMode KubeletAuthMode) (*transport.Config, error) {
 	cfg := &transport.Config{
 		TLS: transport.TLSConfig{
 			CAFile:   config.CAFile,
@@ -549,5 +668,34 @@ func transportConfig(config *rest.Config, enableHttps bool, forceSelfSignedCerts
 		glog.Warning("self-signed certificate use for the TLS transport is enforced.")
 	}
 
-	return cfg
+	if err := applyAuthMode(cfg, config, authMode); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// applyAuthMode keeps only the credentials required by the given auth mode in the transport config,
+// and validates that these credentials are present.
+func applyAuthMode(cfg *transport.Config, config *rest.Config, authMode KubeletAuthMode) error {
+	switch authMode {
+	case KubeletAuthModeToken:
+		cfg.BearerTokenFile = config.BearerTokenFile
+		if !cfg.HasTokenAuth() {
+			return fmt.Errorf("kubelet auth mode %s requires a bearer token in the kube config", authMode)
+		}
+		cfg.TLS.CertFile, cfg.TLS.CertData = "", nil
+		cfg.TLS.KeyFile, cfg.TLS.KeyData = "", nil
+	case KubeletAuthModeClientCert:
+		if !cfg.HasCertAuth() {
+			return fmt.Errorf("kubelet auth mode %s requires a client certificate and key in the kube config", authMode)
+		}
+		cfg.BearerToken = ""
+	case KubeletAuthModeAnonymous:
+		cfg.BearerToken = ""
+		cfg.TLS.CertFile, cfg.TLS.CertData = "", nil
+		cfg.TLS.KeyFile, cfg.TLS.KeyData = "", nil
+	}
+	glog.V(2).Infof("Using kubelet auth mode %q.", authMode)
+	return nil
 }
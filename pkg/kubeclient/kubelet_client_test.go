@@ -1,7 +1,12 @@
 package kubeclient
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	set "github.com/deckarep/golang-set"
 
@@ -80,3 +85,168 @@ func TestKubeletClientCacheNil(t *testing.T) {
 	_, err2 := kc.GetMachineInfo("host_1", "")
 	assert.NotNil(t, err2)
 }
+
+func newAuthModeTestServer(authHeader *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*authHeader = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func newAuthModeTestClient(t *testing.T, serverURL string, config *rest.Config, authMode KubeletAuthMode) *KubeletClient {
+	u, err := url.Parse(serverURL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+	client, err := NewKubeletConfig(config).WithPort(port).WithAuthMode(authMode).
+		Create(nil, "", "", nil, false)
+	assert.NoError(t, err)
+	return client
+}
+
+func TestKubeletClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Respond after the client gives up
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+
+	// The default timeout is kept if the given timeout is not positive
+	config := NewKubeletConfig(&rest.Config{}).WithPort(port).Timeout(0)
+	assert.Equal(t, defaultConnTimeOut, config.timeout)
+
+	client, err := config.Timeout(1).Create(nil, "", "", nil, false)
+	assert.NoError(t, err)
+	start := time.Now()
+	_, err = client.callKubeletEndpoint("127.0.0.1", summaryPath)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), defaultConnTimeOut)
+}
+
+func TestKubeletAuthModeTokenRequest(t *testing.T) {
+	var authHeader string
+	server := newAuthModeTestServer(&authHeader)
+	defer server.Close()
+
+	config := &rest.Config{BearerToken: "test-token"}
+	client := newAuthModeTestClient(t, server.URL, config, KubeletAuthModeToken)
+	_, err := client.callKubeletEndpoint("127.0.0.1", summaryPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer test-token", authHeader)
+}
+
+func TestKubeletAuthModeAnonymousRequest(t *testing.T) {
+	var authHeader string
+	server := newAuthModeTestServer(&authHeader)
+	defer server.Close()
+
+	config := &rest.Config{BearerToken: "test-token"}
+	client := newAuthModeTestClient(t, server.URL, config, KubeletAuthModeAnonymous)
+	_, err := client.callKubeletEndpoint("127.0.0.1", summaryPath)
+	assert.NoError(t, err)
+	assert.Empty(t, authHeader)
+}
+
+func TestKubeletAuthModeDefaultRequest(t *testing.T) {
+	var authHeader string
+	server := newAuthModeTestServer(&authHeader)
+	defer server.Close()
+
+	config := &rest.Config{BearerToken: "test-token"}
+	client := newAuthModeTestClient(t, server.URL, config, KubeletAuthModeDefault)
+	_, err := client.callKubeletEndpoint("127.0.0.1", summaryPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer test-token", authHeader)
+}
+
+func TestKubeletAuthModeTransportConfig(t *testing.T) {
+	config := &rest.Config{
+		BearerToken: "test-token",
+		TLSClientConfig: rest.TLSClientConfig{
+			CertData: []byte("cert"),
+			KeyData:  []byte("key"),
+		},
+	}
+
+	cfg, err := transportConfig(config, true, true, KubeletAuthModeToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-token", cfg.BearerToken)
+	assert.False(t, cfg.HasCertAuth())
+
+	cfg, err = transportConfig(config, true, true, KubeletAuthModeClientCert)
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.BearerToken)
+	assert.True(t, cfg.HasCertAuth())
+
+	cfg, err = transportConfig(config, true, true, KubeletAuthModeAnonymous)
+	assert.NoError(t, err)
+	assert.False(t, cfg.HasTokenAuth())
+	assert.False(t, cfg.HasCertAuth())
+
+	cfg, err = transportConfig(config, true, true, KubeletAuthModeDefault)
+	assert.NoError(t, err)
+	assert.True(t, cfg.HasTokenAuth())
+	assert.True(t, cfg.HasCertAuth())
+}
+
+func TestKubeletAuthModeMissingCredentials(t *testing.T) {
+	_, err := transportConfig(&rest.Config{}, true, true, KubeletAuthModeToken)
+	assert.Error(t, err)
+
+	_, err = transportConfig(&rest.Config{BearerToken: "test-token"}, true, true, KubeletAuthModeClientCert)
+	assert.Error(t, err)
+
+	_, err = transportConfig(&rest.Config{}, true, true, KubeletAuthModeAnonymous)
+	assert.NoError(t, err)
+}
+
+func TestParseKubeletAuthMode(t *testing.T) {
+	for input, expected := range map[string]KubeletAuthMode{
+		"":           KubeletAuthModeDefault,
+		"token":      KubeletAuthModeToken,
+		"ClientCert": KubeletAuthModeClientCert,
+		"anonymous":  KubeletAuthModeAnonymous,
+	} {
+		mode, err := ParseKubeletAuthMode(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, mode)
+	}
+	_, err := ParseKubeletAuthMode("basic")
+	assert.Error(t, err)
+}
+
+func TestKubeletClientGetCadvisorMetricFamilies(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`# TYPE container_cpu_cfs_throttled_periods_total counter
+container_cpu_cfs_throttled_periods_total{container="app",namespace="ns",pod="pod-1"} 5
+# TYPE container_cpu_usage_seconds_total counter
+container_cpu_usage_seconds_total{container="app",namespace="ns",pod="pod-1"} 10
+# TYPE container_memory_swap gauge
+container_memory_swap{container="app",namespace="ns",pod="pod-1"} 1024
+`))
+	}))
+	defer server.Close()
+	client := newAuthModeTestClient(t, server.URL, &rest.Config{}, KubeletAuthModeAnonymous)
+
+	parsed, err := client.GetCadvisorMetricFamilies("127.0.0.1", "node-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// The throttling, usage and swap metrics are all read from the single scrape
+	throttling := ThrottlingMetricFamilies(parsed)
+	assert.NotNil(t, throttling[ContainerCPUThrottledTotal])
+	assert.NotNil(t, throttling[ContainerCPUTotalUsageSec])
+	assert.Nil(t, throttling[ContainerMemorySwap])
+	usage := CadvisorUsageMetricFamilies(parsed)
+	assert.Len(t, usage, 1)
+	assert.NotNil(t, usage[ContainerCPUTotalUsageSec])
+	assert.NotNil(t, parsed[ContainerMemorySwap])
+
+	assert.Nil(t, ThrottlingMetricFamilies(nil))
+}
@@ -0,0 +1,206 @@
+package kubeclient
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SOCKS5 protocol constants, see https://www.rfc-editor.org/rfc/rfc1928 and https://www.rfc-editor.org/rfc/rfc1929
+const (
+	socks5Version         = 0x05
+	socks5AuthNone        = 0x00
+	socks5AuthPassword    = 0x02
+	socks5AuthNoAccept    = 0xff
+	socks5PasswordVersion = 0x01
+	socks5CmdConnect      = 0x01
+	socks5AddrIPv4        = 0x01
+	socks5AddrDomain      = 0x03
+	socks5AddrIPv6        = 0x04
+	socks5ReplySucceeded  = 0x00
+)
+
+// ProxyUnreachableError means that the SOCKS5 proxy used to reach the node metric endpoints cannot be
+// connected to. It only affects the node being scraped, which is skipped in the current discovery.
+type ProxyUnreachableError struct {
+	Proxy string
+	Err   error
+}
+
+func (e *ProxyUnreachableError) Error() string {
+	return fmt.Sprintf("metrics proxy %s is unreachable: %v", e.Proxy, e.Err)
+}
+
+func (e *ProxyUnreachableError) Unwrap() error {
+	return e.Err
+}
+
+// IsProxyUnreachableError returns true if the error, or any error it wraps, is a ProxyUnreachableError.
+func IsProxyUnreachableError(err error) bool {
+	var proxyErr *ProxyUnreachableError
+	return errors.As(err, &proxyErr)
+}
+
+// socks5Dialer dials the node metric endpoints through a SOCKS5 proxy, e.g. an SSH tunnel to a bastion
+// host opened with "ssh -D", for the nodes which are only reachable from a private network.
+type socks5Dialer struct {
+	proxyAddress string
+	username     string
+	password     string
+	forward      *net.Dialer
+}
+
+// newSOCKS5Dialer creates a SOCKS5 dialer from a proxy URL in the form of socks5://[user:password@]host:port.
+func newSOCKS5Dialer(proxyURL string) (*socks5Dialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: %v", proxyURL, err)
+	}
+	if u.Scheme != "socks5" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: unsupported scheme %q, must be socks5", proxyURL, u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q: must be in the form of socks5://host:port", proxyURL)
+	}
+	dialer := &socks5Dialer{
+		proxyAddress: u.Host,
+		forward: &net.Dialer{
+			Timeout:   defaultConnTimeOut,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+	if u.User != nil {
+		dialer.username = u.User.Username()
+		dialer.password, _ = u.User.Password()
+		if len(dialer.username) > 255 || len(dialer.password) > 255 {
+			return nil, fmt.Errorf("invalid SOCKS5 proxy %q: username and password must be at most 255 bytes", proxyURL)
+		}
+	}
+	return dialer, nil
+}
+
+// ValidateSOCKSProxy validates a SOCKS5 proxy URL in the form of socks5://[user:password@]host:port.
+func ValidateSOCKSProxy(proxyURL string) error {
+	_, err := newSOCKS5Dialer(proxyURL)
+	return err
+}
+
+// DialContext connects to the address through the SOCKS5 proxy.
+func (d *socks5Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("network %s is not supported by the SOCKS5 proxy", network)
+	}
+	conn, err := d.forward.DialContext(ctx, "tcp", d.proxyAddress)
+	if err != nil {
+		return nil, &ProxyUnreachableError{Proxy: d.proxyAddress, Err: err}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := d.handshake(conn, address); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Clear the handshake deadline, the http transport manages the deadlines of the requests
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+func (d *socks5Dialer) handshake(conn net.Conn, address string) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port in address %s: %v", address, err)
+	}
+
+	// 1. Negotiate the authentication method
+	method := byte(socks5AuthNone)
+	if d.username != "" {
+		method = socks5AuthPassword
+	}
+	if _, err := conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return &ProxyUnreachableError{Proxy: d.proxyAddress, Err: err}
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return &ProxyUnreachableError{Proxy: d.proxyAddress, Err: err}
+	}
+	if reply[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version %d from proxy %s", reply[0], d.proxyAddress)
+	}
+	if reply[1] == socks5AuthNoAccept || reply[1] != method {
+		return fmt.Errorf("proxy %s does not accept the authentication method %d", d.proxyAddress, method)
+	}
+
+	// 2. Authenticate with username and password
+	if method == socks5AuthPassword {
+		req := []byte{socks5PasswordVersion, byte(len(d.username))}
+		req = append(req, d.username...)
+		req = append(req, byte(len(d.password)))
+		req = append(req, d.password...)
+		if _, err := conn.Write(req); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != socks5ReplySucceeded {
+			return fmt.Errorf("authentication to proxy %s failed", d.proxyAddress)
+		}
+	}
+
+	// 3. Connect to the destination
+	req := []byte{socks5Version, socks5CmdConnect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("host name %s is too long", host)
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socks5AddrIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socks5AddrIPv6)
+		req = append(req, ip.To16()...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[1] != socks5ReplySucceeded {
+		return fmt.Errorf("proxy %s failed to connect to %s: reply code %d", d.proxyAddress, address, header[1])
+	}
+	// Discard the bound address and port
+	var boundAddrLen int
+	switch header[3] {
+	case socks5AddrIPv4:
+		boundAddrLen = net.IPv4len
+	case socks5AddrIPv6:
+		boundAddrLen = net.IPv6len
+	case socks5AddrDomain:
+		if _, err := io.ReadFull(conn, header[:1]); err != nil {
+			return err
+		}
+		boundAddrLen = int(header[0])
+	default:
+		return fmt.Errorf("unexpected address type %d from proxy %s", header[3], d.proxyAddress)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, boundAddrLen+2)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -1,17 +1,29 @@
 package kubeturbo
 
 import (
+	"time"
+
 	osclient "github.com/openshift/client-go/apps/clientset/versioned"
 	"github.com/openshift/machine-api-operator/pkg/generated/clientset/versioned"
 	"k8s.io/client-go/dynamic"
 	kubeclient "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
 	"github.com/turbonomic/kubeturbo/pkg/action/executor/gitops"
+	"github.com/turbonomic/kubeturbo/pkg/audit"
+	"github.com/turbonomic/kubeturbo/pkg/discovery"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	kubeletclient "github.com/turbonomic/kubeturbo/pkg/kubeclient"
 	"github.com/turbonomic/kubeturbo/pkg/resourcemapping"
+	"github.com/turbonomic/kubeturbo/pkg/util"
 )
 
 // Config created using the parameters passed to the kubeturbo service container.
@@ -19,6 +31,8 @@ type Config struct {
 	tapSpec *K8sTAPServiceSpec
 
 	StitchingPropType stitching.StitchingPropertyType
+	// The cloud provider used to build the stitching UUIDs of all the nodes, detected per node if empty
+	StitchingProvider string
 
 	// VMPriority: priority of VM in supplyChain definition from kubeturbo, should be less than 0;
 	VMPriority int32
@@ -28,14 +42,21 @@ type Config struct {
 	KubeClient    *kubeclient.Clientset
 	RestConfig    *restclient.Config
 	DynamicClient dynamic.Interface
-	KubeletClient *kubeletclient.KubeletClient
-	CAClient      *versioned.Clientset
-	OsClient      *osclient.Clientset
+	// The clients of the API server used by discovery to list resources, e.g. a read replica.
+	// They are nil when discovery uses the same API server as actions.
+	DiscoveryRestConfig    *restclient.Config
+	DiscoveryKubeClient    *kubeclient.Clientset
+	DiscoveryDynamicClient dynamic.Interface
+	KubeletClient          *kubeletclient.KubeletClient
+	CAClient               *versioned.Clientset
+	OsClient               *osclient.Clientset
 	// ORMClient builds operator resource mapping templates fetched from OperatorResourceMapping CR in discovery client
 	// and provides the capability to update the corresponding CR for an Operator managed resource in action execution client.
 	ORMClientManager *resourcemapping.ORMClientManager
 	// Controller Runtime Client
 	ControllerRuntimeClient runtimeclient.Client
+	// The group versions of the deployments and the replicasets discovered from the cluster
+	ControllerGroupVersions util.ControllerGroupVersions
 	// Close this to stop all reflectors
 	StopEverything chan struct{}
 
@@ -64,11 +85,108 @@ type Config struct {
 
 	// Number of workload controller items the list api call should request for
 	ItemsPerListQuery int
+
+	// The label used to group resources into business applications
+	BusinessAppLabel string
+
+	// The cgroup version of the nodes
+	CgroupVersion kubelet.CgroupVersion
+
+	// Whether to record the action results on the annotations of the target objects
+	AnnotateActionResults bool
+	// Whether the description of the refused actions starts with the refusal reason
+	RefusalReasonPrefix bool
+	// Emits the Kubernetes events with the action results on the target objects, nil if disabled
+	ActionEventRecorder record.EventRecorder
+
+	// Whether to collect the swap usage of nodes and containers
+	CollectSwapMetrics bool
+	// Whether to collect the usage of nodes, pods and containers from the Kubernetes metrics API
+	CollectMetricsServerUsage bool
+
+	// The percentile of the container usage over the utilization window reported as used, 0 if disabled
+	UtilizationPercentile float64
+	UtilizationWindow     time.Duration
+	// The max number of usage samples per container resource, and of container resources, in the utilization window
+	UtilizationMaxSamples  int
+	UtilizationMaxEntities int
+
+	// How the duplicate entity properties are resolved, and the max number of properties of an entity
+	PropertyConflictPolicy property.ConflictPolicy
+	MaxEntityProperties    int
+	// Whether to tag the discovery response with the schema version of the DTOs
+	EmitSchemaVersion bool
+	// Whether to skip the actions while the last discovery is degraded
+	SkipActionsOnDegradedDiscovery bool
+	// The max number of entities of a discovery, nil if not limited
+	EntityLimits *dtofactory.EntityLimits
+	// The kubelet endpoint from which the cpu and memory usage is collected
+	KubeletMetricsSource kubelet.MetricsSource
+	// The Prometheus server from which the usage of pods and containers is collected, none if empty
+	PrometheusServerURL   string
+	PrometheusCPUQuery    string
+	PrometheusMemoryQuery string
+	PrometheusQueryStep   time.Duration
+	// The Prometheus server from which the metrics of the services of the Istio service mesh are collected, none if
+	// empty, and the queries of the request rate and of the response time of the services
+	ServiceMeshPrometheusURL     string
+	ServiceMeshTransactionQuery  string
+	ServiceMeshResponseTimeQuery string
+	// How long to wait for the rollout of a workload controller resize, no wait if not positive
+	ResizeRolloutTimeout time.Duration
+	// The guardrails of the container resize actions
+	ResizeLimits executor.ResizeLimits
+	// The interval of the incremental discoveries, disabled if not positive
+	IncrementalDiscoveryIntervalSec int
+	// Whether the actions are executed or only recommended
+	ActionMode string
+	// How the node suspend actions are executed, and how many nodes are drained at the same time
+	NodeSuspendMode         string
+	MaxConcurrentNodeDrains int
+	// The ConfigMap holding the policy of the workloads excluded from the actions, none if the name is empty
+	ActionPolicyNamespace string
+	ActionPolicyName      string
+	// The maximum number of the actions of each category executed at once, and how long an action waits for a slot
+	ActionLimits       map[string]int
+	ActionQueueTimeout time.Duration
+	// The priority of the monitoring sources which collect the same metrics, the default if empty
+	MonitoringSourcePriority []types.MonitoringSource
+	// The directory to which the response of each full discovery is written, none if empty
+	DumpDTOsDir string
+	// Whether discovery reads the resources from the local cache of shared informers
+	InformerCache bool
+	// How long the response of a full discovery is sent again for the following full discoveries, disabled if zero
+	DiscoverySnapshotReuseWindow time.Duration
+	// The maximum number of the consecutive full discoveries reported as unchanged, disabled if zero
+	MaxUnchangedDiscoveries int
+	// Spreads the discovery of the nodes over the discovery shard workers, nil if the discovery is not sharded
+	ShardDiscoverer discovery.ShardDiscoverer
+	// The entity types left out of the supply chain and the discovery, along with those of the TAP config
+	DisabledEntityTypes configs.DisabledEntityTypes
+	// The resize mode of the ContainerSpecs of the injected sidecars
+	SidecarResizeMode string
+	// How the resizes of the workload controllers resized by a VerticalPodAutoscaler coexist with it
+	VPACoexistenceMode string
+	// Whether the pods of the Jobs are movable, suspendable and provisionable
+	IncludeBatchWorkloads bool
+	// How the pods of the infrastructure namespaces are modeled, overridden by the TAP config
+	InfrastructurePods *configs.InfrastructurePods
+	// How the pods of the ReplicaSets are moved, and how long a move waits for the new pod to be registered in the
+	// endpoints of the pod
+	PodMoveStrategy      string
+	MoveEndpointsTimeout time.Duration
+	// Who places the cloned pods of the moves: the Turbo server, or the scheduler of the pods
+	MovePlacement string
+	// The audit log of the actions and the discoveries, shared by the pipelines, nil if disabled
+	AuditLog *audit.Log
+	// The directory of the files keeping the state of the actions across the restarts, in memory only if empty
+	StateDir string
 }
 
 func NewVMTConfig2() *Config {
 	cfg := &Config{
-		StopEverything: make(chan struct{}),
+		StopEverything:          make(chan struct{}),
+		ControllerGroupVersions: util.DefaultControllerGroupVersions(),
 	}
 
 	return cfg
@@ -94,6 +212,19 @@ func (c *Config) WithDynamicClient(client dynamic.Interface) *Config {
 	return c
 }
 
+func (c *Config) WithDiscoveryClients(config *restclient.Config, client *kubeclient.Clientset,
+	dynamicClient dynamic.Interface) *Config {
+	c.DiscoveryRestConfig = config
+	c.DiscoveryKubeClient = client
+	c.DiscoveryDynamicClient = dynamicClient
+	return c
+}
+
+func (c *Config) WithControllerGroupVersions(controllerGVs util.ControllerGroupVersions) *Config {
+	c.ControllerGroupVersions = controllerGVs
+	return c
+}
+
 func (c *Config) WithOpenshiftClient(client *osclient.Clientset) *Config {
 	c.OsClient = client
 	return c
@@ -134,6 +265,19 @@ func (c *Config) UsingUUIDStitch(useUUID bool) *Config {
 	return c
 }
 
+// WithStitchingPropertyType overrides the StitchingPropertyType set by UsingUUIDStitch, if the given type is not empty
+func (c *Config) WithStitchingPropertyType(pType stitching.StitchingPropertyType) *Config {
+	if pType != "" {
+		c.StitchingPropType = pType
+	}
+	return c
+}
+
+func (c *Config) WithStitchingProvider(provider string) *Config {
+	c.StitchingProvider = provider
+	return c
+}
+
 func (c *Config) WithVMPriority(p int32) *Config {
 	c.VMPriority = p
 	return c
@@ -223,3 +367,205 @@ func (c *Config) WithItemsPerListQuery(itemsPerListQuery int) *Config {
 	c.ItemsPerListQuery = itemsPerListQuery
 	return c
 }
+
+func (c *Config) WithBusinessAppLabel(businessAppLabel string) *Config {
+	c.BusinessAppLabel = businessAppLabel
+	return c
+}
+
+func (c *Config) WithCgroupVersion(cgroupVersion kubelet.CgroupVersion) *Config {
+	c.CgroupVersion = cgroupVersion
+	return c
+}
+
+func (c *Config) WithCollectSwapMetrics(collectSwapMetrics bool) *Config {
+	c.CollectSwapMetrics = collectSwapMetrics
+	return c
+}
+
+func (c *Config) WithCollectMetricsServerUsage(collectMetricsServerUsage bool) *Config {
+	c.CollectMetricsServerUsage = collectMetricsServerUsage
+	return c
+}
+
+func (c *Config) WithAnnotateActionResults(annotateActionResults bool) *Config {
+	c.AnnotateActionResults = annotateActionResults
+	return c
+}
+
+func (c *Config) WithRefusalReasonPrefix(refusalReasonPrefix bool) *Config {
+	c.RefusalReasonPrefix = refusalReasonPrefix
+	return c
+}
+
+func (c *Config) WithActionEventRecorder(actionEventRecorder record.EventRecorder) *Config {
+	c.ActionEventRecorder = actionEventRecorder
+	return c
+}
+
+func (c *Config) WithUtilizationPercentile(percentile float64, window time.Duration) *Config {
+	c.UtilizationPercentile = percentile
+	c.UtilizationWindow = window
+	return c
+}
+
+func (c *Config) WithUtilizationLimits(maxSamples, maxEntities int) *Config {
+	c.UtilizationMaxSamples = maxSamples
+	c.UtilizationMaxEntities = maxEntities
+	return c
+}
+
+func (c *Config) WithPropertyNormalization(policy property.ConflictPolicy, maxEntityProperties int) *Config {
+	c.PropertyConflictPolicy = policy
+	c.MaxEntityProperties = maxEntityProperties
+	return c
+}
+
+func (c *Config) WithSchemaVersion(emitSchemaVersion bool) *Config {
+	c.EmitSchemaVersion = emitSchemaVersion
+	return c
+}
+
+func (c *Config) WithSkipActionsOnDegradedDiscovery(skipActionsOnDegradedDiscovery bool) *Config {
+	c.SkipActionsOnDegradedDiscovery = skipActionsOnDegradedDiscovery
+	return c
+}
+
+func (c *Config) WithEntityLimits(entityLimits *dtofactory.EntityLimits) *Config {
+	c.EntityLimits = entityLimits
+	return c
+}
+
+func (c *Config) WithKubeletMetricsSource(kubeletMetricsSource kubelet.MetricsSource) *Config {
+	c.KubeletMetricsSource = kubeletMetricsSource
+	return c
+}
+
+func (c *Config) WithPrometheusMetrics(serverURL, cpuQuery, memoryQuery string, step time.Duration) *Config {
+	c.PrometheusServerURL = serverURL
+	c.PrometheusCPUQuery = cpuQuery
+	c.PrometheusMemoryQuery = memoryQuery
+	c.PrometheusQueryStep = step
+	return c
+}
+
+func (c *Config) WithServiceMeshMetrics(serverURL, transactionQuery, responseTimeQuery string) *Config {
+	c.ServiceMeshPrometheusURL = serverURL
+	c.ServiceMeshTransactionQuery = transactionQuery
+	c.ServiceMeshResponseTimeQuery = responseTimeQuery
+	return c
+}
+
+func (c *Config) WithResizeRolloutTimeout(resizeRolloutTimeout time.Duration) *Config {
+	c.ResizeRolloutTimeout = resizeRolloutTimeout
+	return c
+}
+
+func (c *Config) WithResizeLimits(resizeLimits executor.ResizeLimits) *Config {
+	c.ResizeLimits = resizeLimits
+	return c
+}
+
+func (c *Config) WithIncrementalDiscoveryInterval(incrementalDiscoveryIntervalSec int) *Config {
+	c.IncrementalDiscoveryIntervalSec = incrementalDiscoveryIntervalSec
+	return c
+}
+
+func (c *Config) WithActionMode(actionMode string) *Config {
+	c.ActionMode = actionMode
+	return c
+}
+
+func (c *Config) WithNodeSuspendMode(nodeSuspendMode string, maxConcurrentNodeDrains int) *Config {
+	c.NodeSuspendMode = nodeSuspendMode
+	c.MaxConcurrentNodeDrains = maxConcurrentNodeDrains
+	return c
+}
+
+func (c *Config) WithActionPolicyConfigMap(namespace, name string) *Config {
+	c.ActionPolicyNamespace = namespace
+	c.ActionPolicyName = name
+	return c
+}
+
+func (c *Config) WithMonitoringSourcePriority(priority []types.MonitoringSource) *Config {
+	c.MonitoringSourcePriority = priority
+	return c
+}
+
+func (c *Config) WithActionLimits(actionLimits map[string]int, actionQueueTimeout time.Duration) *Config {
+	c.ActionLimits = actionLimits
+	c.ActionQueueTimeout = actionQueueTimeout
+	return c
+}
+
+func (c *Config) WithDumpDTOsDir(dumpDTOsDir string) *Config {
+	c.DumpDTOsDir = dumpDTOsDir
+	return c
+}
+
+func (c *Config) WithInformerCache(informerCache bool) *Config {
+	c.InformerCache = informerCache
+	return c
+}
+
+func (c *Config) WithDiscoverySnapshotReuseWindow(window time.Duration) *Config {
+	c.DiscoverySnapshotReuseWindow = window
+	return c
+}
+
+func (c *Config) WithShardDiscoverer(discoverer discovery.ShardDiscoverer) *Config {
+	c.ShardDiscoverer = discoverer
+	return c
+}
+
+func (c *Config) WithMaxUnchangedDiscoveries(maxUnchangedDiscoveries int) *Config {
+	c.MaxUnchangedDiscoveries = maxUnchangedDiscoveries
+	return c
+}
+
+func (c *Config) WithDisabledEntityTypes(disabledEntityTypes configs.DisabledEntityTypes) *Config {
+	c.DisabledEntityTypes = disabledEntityTypes
+	return c
+}
+
+func (c *Config) WithSidecarResizeMode(mode string) *Config {
+	c.SidecarResizeMode = mode
+	return c
+}
+
+func (c *Config) WithVPACoexistenceMode(mode string) *Config {
+	c.VPACoexistenceMode = mode
+	return c
+}
+
+func (c *Config) WithAuditLog(auditLog *audit.Log) *Config {
+	c.AuditLog = auditLog
+	return c
+}
+
+func (c *Config) WithStateDir(stateDir string) *Config {
+	c.StateDir = stateDir
+	return c
+}
+
+func (c *Config) WithIncludeBatchWorkloads(includeBatchWorkloads bool) *Config {
+	c.IncludeBatchWorkloads = includeBatchWorkloads
+	return c
+}
+
+func (c *Config) WithInfrastructurePods(infrastructurePods *configs.InfrastructurePods) *Config {
+	c.InfrastructurePods = infrastructurePods
+	return c
+}
+
+func (c *Config) WithPodMoveStrategy(podMoveStrategy string, moveEndpointsTimeout time.Duration) *Config {
+	c.PodMoveStrategy = podMoveStrategy
+	c.MoveEndpointsTimeout = moveEndpointsTimeout
+	return c
+}
+
+func (c *Config) WithMovePlacement(movePlacement string) *Config {
+	c.MovePlacement = movePlacement
+	return c
+}
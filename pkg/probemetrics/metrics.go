@@ -0,0 +1,139 @@
+// Package probemetrics defines the Prometheus metrics of the discovery and action pipelines of kubeturbo, which are
+// served on the /metrics endpoint.
+package probemetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "kubeturbo"
+
+// targetLabel is the label of the target of the metrics, so that the clusters of a kubeturbo which discovers several
+// clusters with --k8s-kubeconfig-dir are distinguishable.
+const targetLabel = "target"
+
+const (
+	// The types of discovery
+	FullDiscovery        = "full"
+	IncrementalDiscovery = "incremental"
+
+	// The results of an action execution
+	ActionSucceeded = "succeeded"
+	ActionFailed    = "failed"
+	ActionRefused   = "refused"
+)
+
+var (
+	discoveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "discovery_duration_seconds",
+		Help:      "Duration of the discoveries by target and type of discovery.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{targetLabel, "type"})
+
+	discoveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discovery_failures_total",
+		Help:      "Number of the failed discoveries by target and type of discovery.",
+	}, []string{targetLabel, "type"})
+
+	discoveredEntities = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "discovered_entities",
+		Help:      "Number of the entity DTOs reported by the last full discovery by target and entity type.",
+	}, []string{targetLabel, "entity_type"})
+
+	discoveredEntityChanges = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "discovered_entity_changes",
+		Help: "Number of the entity DTOs of the last full discovery by target and change since the previous " +
+			"full discovery.",
+	}, []string{targetLabel, "change"})
+
+	discoverySnapshotReuses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "discovery_snapshot_reuses_total",
+		Help:      "Number of the full discoveries served the response of a previous full discovery by target.",
+	}, []string{targetLabel})
+
+	kubeletScrapeErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kubelet_scrape_errors_total",
+		Help:      "Number of the failed scrapes of the kubelets.",
+	})
+
+	actions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_total",
+		Help:      "Number of the executed actions by target, action type and result.",
+	}, []string{targetLabel, "action_type", "result"})
+
+	actionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "action_duration_seconds",
+		Help:      "Duration of the action executions by target and action type.",
+		Buckets:   []float64{0.1, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{targetLabel, "action_type"})
+
+	serverReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "turbo_server_reconnects_total",
+		Help:      "Number of the times the probe of the target has registered again with the Turbo server after a reconnect.",
+	}, []string{targetLabel})
+)
+
+func init() {
+	prometheus.MustRegister(discoveryDuration, discoveryFailures, discoveredEntities, discoveredEntityChanges,
+		discoverySnapshotReuses, kubeletScrapeErrors, actions, actionDuration, serverReconnects)
+}
+
+// ObserveDiscovery records a discovery of the given type of the target which took the given duration.
+func ObserveDiscovery(target, discoveryType string, duration time.Duration) {
+	discoveryDuration.WithLabelValues(target, discoveryType).Observe(duration.Seconds())
+}
+
+// RecordDiscoveryFailure records a discovery of the given type of the target which failed.
+func RecordDiscoveryFailure(target, discoveryType string) {
+	discoveryFailures.WithLabelValues(target, discoveryType).Inc()
+}
+
+// SetDiscoveredEntities sets the number of the entity DTOs of each type reported by the last full discovery of the
+// target. The entity types of the target not reported any more are dropped.
+func SetDiscoveredEntities(target string, counts map[string]int) {
+	discoveredEntities.DeletePartialMatch(prometheus.Labels{targetLabel: target})
+	for entityType, count := range counts {
+		discoveredEntities.WithLabelValues(target, entityType).Set(float64(count))
+	}
+}
+
+// SetDiscoveredEntityChanges sets the number of the entity DTOs of the last full discovery of the target which were
+// added, changed, unchanged or removed since the previous full discovery.
+func SetDiscoveredEntityChanges(target string, changes map[string]int) {
+	for change, count := range changes {
+		discoveredEntityChanges.WithLabelValues(target, change).Set(float64(count))
+	}
+}
+
+// RecordDiscoverySnapshotReuse records a full discovery of the target served the response of a previous full
+// discovery.
+func RecordDiscoverySnapshotReuse(target string) {
+	discoverySnapshotReuses.WithLabelValues(target).Inc()
+}
+
+// RecordKubeletScrapeError records a failed scrape of a kubelet.
+func RecordKubeletScrapeError() {
+	kubeletScrapeErrors.Inc()
+}
+
+// ObserveAction records the execution of an action of the given type on the target, with its result and duration.
+func ObserveAction(target, actionType, result string, duration time.Duration) {
+	actions.WithLabelValues(target, actionType, result).Inc()
+	actionDuration.WithLabelValues(target, actionType).Observe(duration.Seconds())
+}
+
+// RecordServerReconnect records that the probe of the target has registered again with the Turbo server.
+func RecordServerReconnect(target string) {
+	serverReconnects.WithLabelValues(target).Inc()
+}
@@ -0,0 +1,73 @@
+package probemetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+// gather returns the metrics of the given family in the default registry.
+func gather(t *testing.T, name string) []*dto.Metric {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()
+		}
+	}
+	return nil
+}
+
+// labelValue returns the value of the given label of the metric.
+func labelValue(metric *dto.Metric, name string) string {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+func TestSetDiscoveredEntities(t *testing.T) {
+	SetDiscoveredEntities("prod", map[string]int{"CONTAINER_POD": 3, "VIRTUAL_MACHINE": 2})
+	SetDiscoveredEntities("dev", map[string]int{"CONTAINER_POD": 1})
+	assert.Len(t, gather(t, "kubeturbo_discovered_entities"), 3)
+
+	// The entity types not discovered any more are dropped, the other targets are kept
+	SetDiscoveredEntities("prod", map[string]int{"CONTAINER_POD": 4})
+	counts := make(map[string]float64)
+	for _, metric := range gather(t, "kubeturbo_discovered_entities") {
+		counts[labelValue(metric, "target")+"/"+labelValue(metric, "entity_type")] = metric.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"prod/CONTAINER_POD": 4, "dev/CONTAINER_POD": 1}, counts)
+}
+
+func TestSetDiscoveredEntityChanges(t *testing.T) {
+	SetDiscoveredEntityChanges("prod", map[string]int{"added": 2, "unchanged": 5})
+	counts := make(map[string]float64)
+	for _, metric := range gather(t, "kubeturbo_discovered_entity_changes") {
+		assert.Equal(t, "prod", labelValue(metric, "target"))
+		counts[labelValue(metric, "change")] = metric.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"added": 2, "unchanged": 5}, counts)
+}
+
+func TestObserveAction(t *testing.T) {
+	ObserveAction("prod", "MOVE", ActionSucceeded, time.Second)
+	ObserveAction("prod", "MOVE", ActionRefused, time.Second)
+	ObserveAction("prod", "MOVE", ActionSucceeded, 2*time.Second)
+
+	counts := make(map[string]float64)
+	for _, metric := range gather(t, "kubeturbo_actions_total") {
+		counts[labelValue(metric, "result")] = metric.GetCounter().GetValue()
+	}
+	assert.Equal(t, map[string]float64{ActionSucceeded: 2, ActionRefused: 1}, counts)
+
+	durations := gather(t, "kubeturbo_action_duration_seconds")
+	assert.Len(t, durations, 1)
+	assert.Equal(t, uint64(3), durations[0].GetHistogram().GetSampleCount())
+	assert.Equal(t, 4.0, durations[0].GetHistogram().GetSampleSum())
+}
@@ -1,11 +1,16 @@
 package registration
 
 import (
+	"sync/atomic"
+
 	"github.com/golang/glog"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/features"
+	"github.com/turbonomic/kubeturbo/pkg/probemetrics"
 	"github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 )
 
 const (
@@ -23,6 +28,8 @@ type RegistrationConfig struct {
 	stitchingPropertyType stitching.StitchingPropertyType
 	vmPriority            int32
 	vmIsBase              bool
+	// The entity types left out of the supply chain
+	disabledEntityTypes configs.DisabledEntityTypes
 }
 
 func NewRegistrationClientConfig(pType stitching.StitchingPropertyType, p int32, isbase bool) *RegistrationConfig {
@@ -33,11 +40,21 @@ func NewRegistrationClientConfig(pType stitching.StitchingPropertyType, p int32,
 	}
 }
 
+// WithDisabledEntityTypes leaves the given entity types out of the supply chain.
+func (config *RegistrationConfig) WithDisabledEntityTypes(disabledEntityTypes configs.DisabledEntityTypes) *RegistrationConfig {
+	config.disabledEntityTypes = disabledEntityTypes
+	return config
+}
+
 type K8sRegistrationClient struct {
 	config                 *RegistrationConfig
 	targetConfig           *configs.K8sTargetConfig
 	accountValues          []*proto.AccountValue
 	communicationChannelId string
+	// The number of the probe registrations, i.e., the initial one and one per reconnection to the server
+	registrations int32
+	// Called when the probe registers again after a reconnection to the server, nil if not set
+	reRegistrationHandler func()
 }
 
 func NewK8sRegistrationClient(config *RegistrationConfig, targetConfig *configs.K8sTargetConfig,
@@ -51,8 +68,29 @@ func NewK8sRegistrationClient(config *RegistrationConfig, targetConfig *configs.
 	}
 }
 
+// WithReRegistrationHandler sets the handler called when the probe registers again with the server, e.g., after the
+// server has restarted, so that the state kept since the last registration can be resynchronized.
+func (rClient *K8sRegistrationClient) WithReRegistrationHandler(handler func()) *K8sRegistrationClient {
+	rClient.reRegistrationHandler = handler
+	return rClient
+}
+
+// IsRegistered returns whether the probe has registered with the server at least once.
+func (rClient *K8sRegistrationClient) IsRegistered() bool {
+	return atomic.LoadInt32(&rClient.registrations) > 0
+}
+
+// GetSupplyChainDefinition is called by the SDK to build the probe info each time the probe registers with the server.
 func (rClient *K8sRegistrationClient) GetSupplyChainDefinition() []*proto.TemplateDTO {
-	supplyChainFactory := NewSupplyChainFactory(rClient.config.stitchingPropertyType, rClient.config.vmPriority, rClient.config.vmIsBase)
+	if atomic.AddInt32(&rClient.registrations, 1) > 1 {
+		glog.V(2).Infof("Probe is registering again with the server.")
+		probemetrics.RecordServerReconnect(rClient.targetConfig.TargetIdentifier)
+		if rClient.reRegistrationHandler != nil {
+			rClient.reRegistrationHandler()
+		}
+	}
+	supplyChainFactory := NewSupplyChainFactory(rClient.config.stitchingPropertyType, rClient.config.vmPriority, rClient.config.vmIsBase).
+		WithDisabledEntityTypes(rClient.config.disabledEntityTypes)
 	supplyChain, err := supplyChainFactory.createSupplyChain()
 	if err != nil {
 		glog.Errorf("Failed to create supply chain: %v", err)
@@ -277,6 +315,9 @@ func (rClient *K8sRegistrationClient) GetEntityMetadata() (result []*proto.Entit
 		proto.EntityDTO_CONTAINER_PLATFORM_CLUSTER,
 		proto.EntityDTO_BUSINESS_APPLICATION,
 	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.LoadBalancerServices) {
+		entities = append(entities, proto.EntityDTO_LOAD_BALANCER)
+	}
 
 	for _, etype := range entities {
 		meta := rClient.newIdMetaData(etype, []string{propertyId})
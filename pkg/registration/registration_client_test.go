@@ -267,3 +267,18 @@ func TestK8sRegistrationClient_GetEntityMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestK8sRegistrationClient_ReRegistrationHandler(t *testing.T) {
+	conf := NewRegistrationClientConfig(stitching.UUID, 0, true)
+	reRegistrations := 0
+	reg := NewK8sRegistrationClient(conf, &configs.K8sTargetConfig{}, nil, "k8s-cluster").
+		WithReRegistrationHandler(func() { reRegistrations++ })
+
+	// The handler is not called on the initial registration
+	assert.NotEmpty(t, reg.GetSupplyChainDefinition())
+	assert.Equal(t, 0, reRegistrations)
+
+	reg.GetSupplyChainDefinition()
+	reg.GetSupplyChainDefinition()
+	assert.Equal(t, 2, reRegistrations)
+}
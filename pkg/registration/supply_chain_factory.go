@@ -9,7 +9,10 @@ import (
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	"github.com/turbonomic/turbo-go-sdk/pkg/supplychain"
 
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 )
 
 var (
@@ -31,6 +34,10 @@ var (
 	taintType              = proto.CommodityDTO_TAINT
 	labelType              = proto.CommodityDTO_LABEL
 	segmentationType       = proto.CommodityDTO_SEGMENTATION
+	gpuType                = proto.CommodityDTO_GPU_SLICE
+	netThroughputType      = proto.CommodityDTO_NET_THROUGHPUT
+	transactionType        = proto.CommodityDTO_TRANSACTION
+	responseTimeType       = proto.CommodityDTO_RESPONSE_TIME
 
 	fakeKey = "fake"
 
@@ -43,6 +50,7 @@ var (
 	vMemRequestTemplateComm        = &proto.TemplateCommodity{CommodityType: &vMemRequestType}
 	numPodNumConsumersTemplateComm = &proto.TemplateCommodity{CommodityType: &numPodNumConsumersType}
 	vStorageTemplateComm           = &proto.TemplateCommodity{CommodityType: &vStorageType}
+	netThroughputTemplateComm      = &proto.TemplateCommodity{CommodityType: &netThroughputType}
 
 	// Optional TemplateCommodity
 	vCpuRequestTemplateCommOpt      = &proto.TemplateCommodity{CommodityType: &vCpuRequestType, Optional: &commIsOptional}
@@ -52,6 +60,8 @@ var (
 	vCpuRequestQuotaTemplateCommOpt = &proto.TemplateCommodity{CommodityType: &vCpuRequestQuotaType, Optional: &commIsOptional}
 	vMemRequestQuotaTemplateCommOpt = &proto.TemplateCommodity{CommodityType: &vMemRequestQuotaType, Optional: &commIsOptional}
 	numberReplicasCommOpt           = &proto.TemplateCommodity{CommodityType: &numberReplicasType, Optional: &commIsOptional}
+	transactionTemplateCommOpt      = &proto.TemplateCommodity{CommodityType: &transactionType, Optional: &commIsOptional}
+	responseTimeTemplateCommOpt     = &proto.TemplateCommodity{CommodityType: &responseTimeType, Optional: &commIsOptional}
 
 	// Resold TemplateCommodity
 	vCpuTemplateCommResold             = &proto.TemplateCommodity{CommodityType: &vCpuType, IsResold: &commIsResold}
@@ -69,6 +79,7 @@ var (
 	vCpuRequestQuotaTemplateCommWithKey = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &vCpuRequestQuotaType}
 	vMemRequestQuotaTemplateCommWithKey = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &vMemRequestQuotaType}
 	storageAmountTemplateCommWithKey    = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &storageAmountType}
+	gpuTemplateCommWithKey              = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &gpuType}
 	// Access commodities
 	vmpmAccessTemplateComm          = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &vmPMAccessType}
 	applicationTemplateCommWithKey  = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &appCommType}
@@ -108,6 +119,8 @@ type SupplyChainFactory struct {
 	stitchingPropertyType stitching.StitchingPropertyType
 	vmPriority            int32
 	vmTemplateType        proto.TemplateDTO_TemplateType
+	// The entity types left out of the supply chain
+	disabledEntityTypes configs.DisabledEntityTypes
 }
 
 func NewSupplyChainFactory(pType stitching.StitchingPropertyType, vmPriority int32, base bool) *SupplyChainFactory {
@@ -122,6 +135,12 @@ func NewSupplyChainFactory(pType stitching.StitchingPropertyType, vmPriority int
 	}
 }
 
+// WithDisabledEntityTypes leaves the given entity types out of the supply chain.
+func (f *SupplyChainFactory) WithDisabledEntityTypes(disabledEntityTypes configs.DisabledEntityTypes) *SupplyChainFactory {
+	f.disabledEntityTypes = disabledEntityTypes
+	return f
+}
+
 func (f *SupplyChainFactory) createSupplyChain() ([]*proto.TemplateDTO, error) {
 	// Node supply chain template
 	nodeSupplyChainNode, err := f.buildNodeSupplyBuilder()
@@ -220,7 +239,21 @@ func (f *SupplyChainFactory) createSupplyChain() ([]*proto.TemplateDTO, error) {
 	supplyChainBuilder.Entity(nodeSupplyChainNode)
 	supplyChainBuilder.Entity(volumeSupplyChainNode)
 
-	return supplyChainBuilder.Create()
+	if utilfeature.DefaultFeatureGate.Enabled(features.LoadBalancerServices) {
+		// Load balancer supply chain template
+		loadBalancerSupplyChainNode, err := f.buildLoadBalancerSupplyBuilder()
+		if err != nil {
+			return nil, err
+		}
+		glog.V(4).Infof("Supply chain node: %+v", loadBalancerSupplyChainNode)
+		supplyChainBuilder.Entity(loadBalancerSupplyChainNode)
+	}
+
+	templates, err := supplyChainBuilder.Create()
+	if err != nil {
+		return nil, err
+	}
+	return f.disabledEntityTypes.RemoveTemplates(templates), nil
 }
 
 // Stitching metadata required for stitching with XL
@@ -252,6 +285,13 @@ func (f *SupplyChainFactory) buildNodeMergedEntityMetadata() (*proto.MergedEntit
 		mergedEntityMetadataBuilder.
 			InternalMatchingPropertyWithDelimiter(proxyVMIP, ",").
 			ExternalMatchingFieldWithDelimiter(VMIPFieldName, VMIPFieldPaths, ",")
+	case stitching.AUTO:
+		// Each node carries either the UUID or the IP property, depending on the stitching type detected for it
+		mergedEntityMetadataBuilder.
+			InternalMatchingPropertyWithDelimiter(proxyVMUUID, ",").
+			ExternalMatchingField(VMUUID, []string{}).
+			InternalMatchingPropertyWithDelimiter(proxyVMIP, ",").
+			ExternalMatchingFieldWithDelimiter(VMIPFieldName, VMIPFieldPaths, ",")
 	default:
 		return nil, fmt.Errorf("stitching property type %s is not supported",
 			f.stitchingPropertyType)
@@ -276,6 +316,8 @@ func (f *SupplyChainFactory) buildNodeMergedEntityMetadata() (*proto.MergedEntit
 		PatchSoldMetadata(proto.CommodityDTO_VMEM_REQUEST_QUOTA, fieldsUsedCapacity).
 		PatchSoldMetadata(proto.CommodityDTO_NUMBER_CONSUMERS, fieldsUsedCapacity).
 		PatchSoldMetadata(proto.CommodityDTO_VSTORAGE, fieldsUsedCapacity).
+		PatchSoldMetadata(proto.CommodityDTO_GPU_SLICE, fieldsUsedCapacity).
+		PatchSoldMetadata(proto.CommodityDTO_NET_THROUGHPUT, fieldsUsedCapacity).
 		PatchSoldMetadata(proto.CommodityDTO_SEGMENTATION, fieldsCapacity).
 		PatchBoughtList(proto.EntityDTO_CONTAINER_PLATFORM_CLUSTER, boughtCommTypes).
 		Build()
@@ -294,6 +336,8 @@ func (f *SupplyChainFactory) buildNodeSupplyBuilder() (*proto.TemplateDTO, error
 		Sells(vmpmAccessTemplateComm).         // sells to Pods
 		Sells(numPodNumConsumersTemplateComm). // sells to Pods
 		Sells(vStorageTemplateComm).           // sells to Pods
+		Sells(netThroughputTemplateComm).      // sells to Pods
+		Sells(gpuTemplateCommWithKey).         // sells to Pods
 		Sells(taintTemplateCommWithKey).
 		Sells(labelTemplateCommWithKey).
 		Sells(segmentationTemplateCommWithKey).
@@ -374,6 +418,8 @@ func (f *SupplyChainFactory) buildPodSupplyBuilder() (*proto.TemplateDTO, error)
 		Buys(vMemRequestTemplateComm).
 		Buys(numPodNumConsumersTemplateComm).
 		Buys(vStorageTemplateComm).
+		Buys(netThroughputTemplateComm).
+		Buys(gpuTemplateCommWithKey).
 		Buys(taintTemplateCommWithKey).
 		Buys(labelTemplateCommWithKey).
 		Buys(segmentationTemplateCommWithKey).
@@ -398,6 +444,8 @@ func (f *SupplyChainFactory) buildPodSupplyBuilder() (*proto.TemplateDTO, error)
 		Commodity(vCpuRequestType, false).
 		Commodity(vMemRequestType, false).
 		Commodity(numPodNumConsumersType, false).
+		Commodity(netThroughputType, false).
+		Commodity(gpuType, true).
 		Commodity(vmPMAccessType, true).
 		Commodity(clusterType, true)
 
@@ -423,6 +471,12 @@ func (f *SupplyChainFactory) addVMStitchingProperty(extLinkBuilder *supplychain.
 		extLinkBuilder.
 			ProbeEntityPropertyDef(supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS, "IP of the Node").
 			ExternalEntityPropertyDef(supplychain.VM_IP)
+	case stitching.AUTO:
+		extLinkBuilder.
+			ProbeEntityPropertyDef(supplychain.SUPPLY_CHAIN_CONSTANT_UUID, "UUID of the Node").
+			ExternalEntityPropertyDef(supplychain.VM_UUID).
+			ProbeEntityPropertyDef(supplychain.SUPPLY_CHAIN_CONSTANT_IP_ADDRESS, "IP of the Node").
+			ExternalEntityPropertyDef(supplychain.VM_IP)
 	default:
 		return fmt.Errorf("stitching property type %s is not supported", f.stitchingPropertyType)
 	}
@@ -487,11 +541,25 @@ func (f *SupplyChainFactory) buildServiceSupplyBuilder() (*proto.TemplateDTO, er
 	serviceSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_SERVICE)
 	serviceSupplyChainNodeBuilder = serviceSupplyChainNodeBuilder.
 		Sells(numberReplicasCommOpt).
+		Sells(transactionTemplateCommOpt).  // sold by the services of the service mesh with metrics
+		Sells(responseTimeTemplateCommOpt). // sold by the services of the service mesh with metrics
 		Provider(proto.EntityDTO_APPLICATION_COMPONENT, proto.Provider_LAYERED_OVER).
 		Buys(applicationTemplateCommWithKey)
+	if utilfeature.DefaultFeatureGate.Enabled(features.LoadBalancerServices) {
+		// The external load balancer of the service of type LoadBalancer
+		serviceSupplyChainNodeBuilder = serviceSupplyChainNodeBuilder.
+			Provider(proto.EntityDTO_LOAD_BALANCER, proto.Provider_LAYERED_OVER).
+			Buys(applicationTemplateCommWithKey)
+	}
 	return serviceSupplyChainNodeBuilder.Create()
 }
 
+func (f *SupplyChainFactory) buildLoadBalancerSupplyBuilder() (*proto.TemplateDTO, error) {
+	loadBalancerSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_LOAD_BALANCER).
+		Sells(applicationTemplateCommWithKey) // sells to the service of type LoadBalancer
+	return loadBalancerSupplyChainNodeBuilder.Create()
+}
+
 func (f *SupplyChainFactory) buildVolumeSupplyBuilder() (*proto.TemplateDTO, error) {
 	volumeSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_VIRTUAL_VOLUME)
 	//	volumeSupplyChainNodeBuilder.SetPriority(f.vmPriority)
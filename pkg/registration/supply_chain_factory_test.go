@@ -2,8 +2,10 @@ package registration
 
 import (
 	"fmt"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"testing"
 )
 
@@ -50,3 +52,85 @@ func TestNewSupplyChainFactory_VM(t *testing.T) {
 	}
 
 }
+
+func TestNewSupplyChainFactory_LoadBalancer(t *testing.T) {
+	defer utilfeature.DefaultMutableFeatureGate.Set("LoadBalancerServices=false")
+
+	for _, enabled := range []bool{false, true} {
+		utilfeature.DefaultMutableFeatureGate.Set(fmt.Sprintf("LoadBalancerServices=%v", enabled))
+		dtos, err := NewSupplyChainFactory(stitching.IP, 0, false).createSupplyChain()
+		if err != nil {
+			t.Fatalf("Failed to create supply chain: %v", err)
+		}
+		foundLoadBalancer, serviceBuysFromLoadBalancer := false, false
+		for _, dto := range dtos {
+			switch dto.GetTemplateClass() {
+			case proto.EntityDTO_LOAD_BALANCER:
+				foundLoadBalancer = true
+			case proto.EntityDTO_SERVICE:
+				for _, bought := range dto.GetCommodityBought() {
+					if bought.GetKey().GetTemplateClass() == proto.EntityDTO_LOAD_BALANCER {
+						serviceBuysFromLoadBalancer = true
+					}
+				}
+			}
+		}
+		if foundLoadBalancer != enabled || serviceBuysFromLoadBalancer != enabled {
+			t.Errorf("LoadBalancerServices=%v: found load balancer template %v, service buys from load balancer %v",
+				enabled, foundLoadBalancer, serviceBuysFromLoadBalancer)
+		}
+	}
+}
+
+func TestNewSupplyChainFactory_AutoStitching(t *testing.T) {
+	dtos, err := NewSupplyChainFactory(stitching.AUTO, -1, false).createSupplyChain()
+	if err != nil {
+		t.Fatalf("Failed to create supply chain: %v", err)
+	}
+	for _, dto := range dtos {
+		switch dto.GetTemplateClass() {
+		case proto.EntityDTO_VIRTUAL_MACHINE:
+			// Both the UUID and the IP are matched for the nodes
+			matching := dto.GetMergedEntityMetaData().GetMatchingMetadata()
+			if len(matching.GetMatchingData()) != 2 || len(matching.GetExternalEntityMatchingProperty()) != 2 {
+				t.Errorf("Wrong node matching metadata: %++v", matching)
+			}
+		case proto.EntityDTO_CONTAINER_POD:
+			for _, link := range dto.GetExternalLink() {
+				if link.GetValue().GetBuyerRef() != proto.EntityDTO_CONTAINER_POD {
+					continue
+				}
+				if len(link.GetValue().GetProbeEntityPropertyDef()) != 2 {
+					t.Errorf("Wrong pod external link: %++v", link.GetValue())
+				}
+			}
+		}
+	}
+}
+
+func TestNewSupplyChainFactory_DisabledEntityTypes(t *testing.T) {
+	disabled, err := configs.ParseDisabledEntityTypes("namespaces,workloadcontrollers")
+	if err != nil {
+		t.Fatalf("Failed to parse the disabled entity types: %v", err)
+	}
+	dtos, err := NewSupplyChainFactory(stitching.IP, 0, false).WithDisabledEntityTypes(disabled).createSupplyChain()
+	if err != nil {
+		t.Fatalf("Failed to create supply chain: %v", err)
+	}
+	foundPod := false
+	for _, dto := range dtos {
+		if disabled[dto.GetTemplateClass()] {
+			t.Errorf("Found template of disabled entity type %v", dto.GetTemplateClass())
+		}
+		for _, bought := range dto.GetCommodityBought() {
+			if disabled[bought.GetKey().GetTemplateClass()] {
+				t.Errorf("Template %v buys from disabled entity type %v", dto.GetTemplateClass(),
+					bought.GetKey().GetTemplateClass())
+			}
+		}
+		foundPod = foundPod || dto.GetTemplateClass() == proto.EntityDTO_CONTAINER_POD
+	}
+	if !foundPod {
+		t.Errorf("Pod template not found")
+	}
+}
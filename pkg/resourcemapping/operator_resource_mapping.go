@@ -73,7 +73,8 @@ func NewORMClient(dynamicClient dynamic.Interface, apiExtClient *apiextclient.Ap
 }
 
 // CacheORMSpecMap clears cached operatorResourceSpecMap data and repopulate the map based on newly discovered Operator
-// managed CRs.
+// managed CRs. The mappings are defined by the OperatorResourceMapping CRs, and by the ConfigMaps labeled with
+// ORMConfigMapLabel in the clusters without the OperatorResourceMapping CRD.
 // The map is from Operator managed CustomResource UID to ORMSpec object. Here's an example of the map:
 // {
 //
@@ -94,11 +95,16 @@ func NewORMClient(dynamicClient dynamic.Interface, apiExtClient *apiextclient.Ap
 // }
 func (ormClient *ORMClient) CacheORMSpecMap() int {
 	ormCRs, err := ormClient.getORMCRList()
-	if err != nil {
+	ormConfigMaps, cmErr := ormClient.getORMConfigMapList()
+	if cmErr != nil {
+		glog.V(3).Infof("No OperatorResourceMapping ConfigMap discovered: %v", cmErr)
+	}
+	if err != nil && len(ormConfigMaps) == 0 {
 		glog.Warningf("No OperatorResourceMapping CR discovered: %v. Create operator-resource-mapping CR to control "+
 			"Operator managed resources.", err)
 		return 0
 	}
+	ormCRs = append(ormCRs, ormConfigMaps...)
 	ormClient.cacheLock.Lock()
 	defer ormClient.cacheLock.Unlock()
 	// Clear existing cached operatorResourceSpecMap data
@@ -53,7 +53,6 @@ func NewORMClientManager(dynamicClient dynamic.Interface, kubeConfig *rest.Confi
 // DiscoverORMs discovers and caches ORMs v1 and v2.
 func (ormClientMgr *ORMClientManager) DiscoverORMs() {
 	// ORM v1 are saved as a map in ORMClient
-	ormClientMgr.CacheORMSpecMap()
 	numV1CRs := ormClientMgr.CacheORMSpecMap()
 	if numV1CRs > 0 {
 		glog.Infof("Discovered %v v1 ORM Resources.", numV1CRs)
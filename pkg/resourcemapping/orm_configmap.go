@@ -0,0 +1,75 @@
+package resourcemapping
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ORMConfigMapLabel labels the ConfigMaps which define the operator resource mappings in the clusters without the
+	// OperatorResourceMapping CRD. Like an ORM CR, such a ConfigMap is named after the Operator managed CRD and
+	// applies to the CRs in its namespace. It holds the spec.resourceMappings of an ORM CR in YAML under the
+	// ormConfigMapKey, e.g.:
+	//
+	//	resourceMappings: |
+	//	  - srcResourceSpec:
+	//	      kind: StatefulSet
+	//	      componentNames:
+	//	        - db
+	//	    resourceMappingTemplates:
+	//	      - srcPath: .spec.template.spec.containers[?(@.name=="{{.componentName}}")].resources
+	//	        destPath: .spec.{{.componentName}}.resources
+	ORMConfigMapLabel = "turbonomic.com/operator-resource-mapping"
+	ormConfigMapKey   = "resourceMappings"
+)
+
+var configMapGVR = v1.SchemeGroupVersion.WithResource("configmaps")
+
+// getORMConfigMapList returns the ORM CRs defined by the labeled ConfigMaps in all namespaces. The ConfigMaps with
+// invalid mappings are skipped.
+func (ormClient *ORMClient) getORMConfigMapList() ([]unstructured.Unstructured, error) {
+	configMaps, err := ormClient.dynClient.Resource(configMapGVR).Namespace(v1.NamespaceAll).
+		List(context.TODO(), metav1.ListOptions{LabelSelector: ORMConfigMapLabel + "=true"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OperatorResourceMapping ConfigMaps: %v", err)
+	}
+	var ormCRs []unstructured.Unstructured
+	for _, configMap := range configMaps.Items {
+		ormCR, err := ormCRFromConfigMap(configMap)
+		if err != nil {
+			glog.Errorf("Skip the OperatorResourceMapping ConfigMap %s/%s: %v", configMap.GetNamespace(),
+				configMap.GetName(), err)
+			continue
+		}
+		ormCRs = append(ormCRs, ormCR)
+	}
+	return ormCRs, nil
+}
+
+// ormCRFromConfigMap converts an ORM ConfigMap to the ORM CR of the same name and namespace, so that its mappings are
+// cached the same way.
+func ormCRFromConfigMap(configMap unstructured.Unstructured) (unstructured.Unstructured, error) {
+	data, found, err := unstructured.NestedString(configMap.Object, "data", ormConfigMapKey)
+	if err != nil || !found {
+		return unstructured.Unstructured{}, fmt.Errorf("no data under '%s'", ormConfigMapKey)
+	}
+	var resourceMappings []interface{}
+	if err := yaml.Unmarshal([]byte(data), &resourceMappings); err != nil {
+		return unstructured.Unstructured{}, fmt.Errorf("invalid resource mappings under '%s': %v", ormConfigMapKey, err)
+	}
+	ormCR := unstructured.Unstructured{Object: map[string]interface{}{}}
+	ormCR.SetAPIVersion(ormGroup + "/" + ormVersion)
+	ormCR.SetKind("OperatorResourceMapping")
+	ormCR.SetName(configMap.GetName())
+	ormCR.SetNamespace(configMap.GetNamespace())
+	if err := unstructured.SetNestedSlice(ormCR.Object, resourceMappings, ormResourceMappingsPath...); err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	return ormCR, nil
+}
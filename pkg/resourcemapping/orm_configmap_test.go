@@ -0,0 +1,64 @@
+package resourcemapping
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestORMCRFromConfigMap(t *testing.T) {
+	testConfigMap := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "xls.charts.helm.k8s.io",
+				"namespace": "turbo",
+			},
+			"data": map[string]interface{}{
+				ormConfigMapKey: `
+- srcResourceSpec:
+    kind: Deployment
+    componentNames:
+      - api
+  resourceMappingTemplates:
+    - srcPath: .spec.template.spec.containers[?(@.name=="{{.componentName}}")].resources
+      destPath: .spec.{{.componentName}}.resources
+`,
+			},
+		},
+	}
+
+	ormCR, err := ormCRFromConfigMap(testConfigMap)
+	if err != nil {
+		t.Fatalf("Test case failed: TestORMCRFromConfigMap: %v", err)
+	}
+	if ormCR.GetName() != "xls.charts.helm.k8s.io" || ormCR.GetNamespace() != "turbo" {
+		t.Errorf("Test case failed: TestORMCRFromConfigMap: unexpected ORM CR %s/%s",
+			ormCR.GetNamespace(), ormCR.GetName())
+	}
+	expectedOrmTemplateMap := map[string]ORMTemplate{
+		"Deployment/api": {
+			componentName: "api",
+			resourceMappingTemplates: []map[string]interface{}{
+				resourceMappingTemplate,
+			},
+		},
+	}
+	ormTemplateMap, _ := NewORMClient(nil, nil).populateORMTemplateMap(ormCR)
+	if !reflect.DeepEqual(expectedOrmTemplateMap, ormTemplateMap) {
+		t.Errorf("Test case failed: TestORMCRFromConfigMap:\nexpected:\n%++v\nactual:\n%++v",
+			expectedOrmTemplateMap, ormTemplateMap)
+	}
+
+	// The ConfigMaps without valid mappings
+	delete(testConfigMap.Object, "data")
+	if _, err := ormCRFromConfigMap(testConfigMap); err == nil {
+		t.Errorf("Test case failed: TestORMCRFromConfigMap: expected an error without data")
+	}
+	testConfigMap.Object["data"] = map[string]interface{}{ormConfigMapKey: "srcResourceSpec: {}"}
+	if _, err := ormCRFromConfigMap(testConfigMap); err == nil {
+		t.Errorf("Test case failed: TestORMCRFromConfigMap: expected an error with invalid mappings")
+	}
+}
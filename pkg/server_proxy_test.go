@@ -0,0 +1,154 @@
+package kubeturbo
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-go-sdk/pkg/mediationcontainer"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+// connectProxy is a proxy tunneling the connections of the HTTP CONNECT method.
+type connectProxy struct {
+	*httptest.Server
+	connects int
+}
+
+// newConnectProxy starts a proxy which requires the given Proxy-Authorization header, if not empty.
+func newConnectProxy(t *testing.T, authorization string) *connectProxy {
+	proxy := &connectProxy{}
+	proxy.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != authorization {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		proxy.connects++
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			io.Copy(target, conn)
+			target.Close()
+		}()
+		io.Copy(conn, target)
+		conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func TestDialThroughProxy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Write([]byte("hello"))
+			conn.Close()
+		}
+	}()
+
+	// user:secret
+	proxy := newConnectProxy(t, "Basic dXNlcjpzZWNyZXQ=")
+	proxyURL, err := parseServerProxy("http://user:secret@" + proxy.Listener.Addr().String())
+	assert.NoError(t, err)
+	conn, err := dialThroughProxy(proxyURL, listener.Addr().String(), 5*time.Second)
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(conn)
+		assert.Equal(t, "hello", string(data))
+		conn.Close()
+	}
+
+	proxyURL.User = nil
+	_, err = dialThroughProxy(proxyURL, listener.Addr().String(), 5*time.Second)
+	assert.ErrorContains(t, err, "407")
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	env := map[string]string{
+		"HTTPS_PROXY": "proxy.example.com:3128",
+		"http_proxy":  "http://plain-proxy.example.com:3128",
+		"NO_PROXY":    "localhost, .svc.cluster.local,10.0.0.0/8,fd00::1",
+	}
+	getenv := func(name string) string { return env[name] }
+	tests := map[string]string{
+		"https://turbo.example.com":                   "http://proxy.example.com:3128",
+		"http://turbo.example.com":                    "http://plain-proxy.example.com:3128",
+		"https://localhost:9443":                      "",
+		"https://turbo.turbonomic.svc.cluster.local":  "",
+		"https://svc.cluster.local":                   "",
+		"https://10.1.2.3":                            "",
+		"https://[fd00::1]":                           "",
+		"https://11.1.2.3":                            "http://proxy.example.com:3128",
+		"https://turbo.turbonomic.svc.cluster.local2": "http://proxy.example.com:3128",
+	}
+	for server, expected := range tests {
+		proxy, err := proxyFromEnvironment(server, getenv)
+		assert.NoError(t, err, server)
+		if expected == "" {
+			assert.Nil(t, proxy, server)
+		} else if assert.NotNil(t, proxy, server) {
+			assert.Equal(t, expected, proxy.String(), server)
+		}
+	}
+	env["NO_PROXY"] = "*"
+	proxy, _ := proxyFromEnvironment("https://turbo.example.com", getenv)
+	assert.Nil(t, proxy)
+	env["NO_PROXY"] = ""
+	env["HTTPS_PROXY"] = "ftp://proxy.example.com"
+	_, err := proxyFromEnvironment("https://turbo.example.com", getenv)
+	assert.Error(t, err)
+}
+
+func TestLoadServerProxy(t *testing.T) {
+	dir := t.TempDir()
+	usernameFile, passwordFile := filepath.Join(dir, "proxyusername"), filepath.Join(dir, "proxypassword")
+	newSpec := func(proxy string) *K8sTAPServiceSpec {
+		return &K8sTAPServiceSpec{TurboCommunicationConfig: &service.TurboCommunicationConfig{
+			ServerMeta: mediationcontainer.ServerMeta{TurboServer: "https://turbo.example.com", Proxy: proxy},
+		}}
+	}
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+
+	// No proxy
+	spec := newSpec("")
+	assert.NoError(t, loadServerProxy(spec, usernameFile, passwordFile))
+	assert.Empty(t, spec.Proxy)
+
+	// The proxy of the environment
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	spec = newSpec("")
+	assert.NoError(t, loadServerProxy(spec, usernameFile, passwordFile))
+	assert.Equal(t, "http://proxy.example.com:3128", spec.Proxy)
+
+	// The credentials of the secret are set in clear in the proxy of the TAP spec
+	assert.NoError(t, os.WriteFile(usernameFile, []byte("proxy-user\n"), 0600))
+	assert.NoError(t, os.WriteFile(passwordFile, []byte("p@ss/word%\n"), 0600))
+	spec = newSpec("http://spec-proxy.example.com:8080")
+	assert.NoError(t, loadServerProxy(spec, usernameFile, passwordFile))
+	assert.Equal(t, "http://proxy-user:p@ss/word%@spec-proxy.example.com:8080", spec.Proxy)
+
+	assert.NoError(t, os.Remove(passwordFile))
+	assert.Error(t, loadServerProxy(newSpec("http://spec-proxy.example.com:8080"), usernameFile, passwordFile))
+	assert.Error(t, loadServerProxy(newSpec("spec-proxy.example.com:8080"), filepath.Join(dir, "missing"), passwordFile))
+}
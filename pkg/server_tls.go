@@ -0,0 +1,75 @@
+package kubeturbo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const serverTLSHandshakeTimeout = 30 * time.Second
+
+// VerifyServerCertificate verifies the certificate chain and the host name of the Turbo server against the CA bundle
+// at the given path, or against the system CAs if the path is empty. It fails if the server cannot be reached, so
+// that kubeturbo does not connect to a server whose identity cannot be verified. The server is reached through the
+// given proxy, if any.
+func VerifyServerCertificate(turboServer, caBundlePath, proxy string) error {
+	serverURL, err := url.Parse(turboServer)
+	if err != nil {
+		return fmt.Errorf("invalid Turbo server URL %s: %v", turboServer, err)
+	}
+	if serverURL.Scheme != "https" {
+		glog.Warningf("Skip verifying the certificate of the Turbo server %s which is not served over https.", turboServer)
+		return nil
+	}
+	tlsConfig := &tls.Config{ServerName: serverURL.Hostname()}
+	if caBundlePath != "" {
+		tlsConfig.RootCAs, err = loadCABundle(caBundlePath)
+		if err != nil {
+			return err
+		}
+	}
+	address := serverURL.Host
+	if serverURL.Port() == "" {
+		address = net.JoinHostPort(serverURL.Hostname(), "443")
+	}
+	var conn net.Conn
+	if proxy == "" {
+		conn, err = net.DialTimeout("tcp", address, serverTLSHandshakeTimeout)
+	} else {
+		var proxyURL *url.URL
+		if proxyURL, err = parseServerProxy(proxy); err != nil {
+			return err
+		}
+		conn, err = dialThroughProxy(proxyURL, address, serverTLSHandshakeTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to verify the certificate of the Turbo server %s: %v", turboServer, err)
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	defer tlsConn.Close()
+	tlsConn.SetDeadline(time.Now().Add(serverTLSHandshakeTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("failed to verify the certificate of the Turbo server %s: %v", turboServer, err)
+	}
+	glog.V(2).Infof("Verified the certificate of the Turbo server %s.", turboServer)
+	return nil
+}
+
+// loadCABundle loads the PEM encoded CA certificates from the given file.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA bundle %s: %v", path, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid PEM encoded certificate found in the CA bundle %s", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,38 @@
+package kubeturbo
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyServerCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caBundle := filepath.Join(dir, "ca.crt")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, os.WriteFile(caBundle, certPEM, 0600))
+	invalidBundle := filepath.Join(dir, "invalid.crt")
+	assert.NoError(t, os.WriteFile(invalidBundle, []byte("not a certificate"), 0600))
+
+	// The self-signed certificate of the test server is trusted by the CA bundle only
+	assert.NoError(t, VerifyServerCertificate(server.URL, caBundle, ""))
+	assert.Error(t, VerifyServerCertificate(server.URL, "", ""))
+	assert.Error(t, VerifyServerCertificate(server.URL, invalidBundle, ""))
+	assert.Error(t, VerifyServerCertificate(server.URL, filepath.Join(dir, "missing.crt"), ""))
+
+	// The server is reached through the proxy
+	proxy := newConnectProxy(t, "")
+	assert.NoError(t, VerifyServerCertificate(server.URL, caBundle, proxy.URL))
+	assert.Equal(t, 1, proxy.connects)
+
+	// The certificate is not verified for a plain http server
+	assert.NoError(t, VerifyServerCertificate("http://127.0.0.1:1", caBundle, ""))
+}
@@ -0,0 +1,411 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/gorilla/websocket"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"github.com/turbonomic/turbo-go-sdk/pkg/version"
+)
+
+// The paths of the remote mediation endpoints of the Turbo server, one of which the SDK connects to
+var mediationPaths = []string{"/vmturbo/remoteMediation", "/remoteMediation"}
+
+// MediationServer is an in-process fake of the remote mediation endpoint of the Turbo server. It accepts the version
+// negotiation and the registration of the probe, and sends the probe the discovery, validation and action requests,
+// so that the whole pipeline of kubeturbo can be exercised without a Turbonomic instance, by the integration tests and
+// with --simulate.
+type MediationServer struct {
+	listener net.Listener
+	server   *http.Server
+	upgrader websocket.Upgrader
+
+	lock sync.Mutex
+	// The connection of the probe, nil until the probe connects
+	conn *probeConnection
+	// The container info of the last registration, nil until the probe registers
+	containerInfo *proto.ContainerInfo
+	// Closed and replaced at each registration of the probe
+	registered    chan struct{}
+	nextMessageID int32
+}
+
+// probeConnection is a websocket connection of the probe, whose responses are dispatched to the pending requests by
+// their message IDs.
+type probeConnection struct {
+	ws        *websocket.Conn
+	writeLock sync.Mutex
+
+	lock    sync.Mutex
+	pending map[int32]*pendingRequest
+	closed  chan struct{}
+}
+
+// pendingRequest receives the responses to a request sent to the probe, until the request is done.
+type pendingRequest struct {
+	messages chan *proto.MediationClientMessage
+	done     chan struct{}
+}
+
+// NewMediationServer starts a mediation server listening on the given address, e.g. "127.0.0.1:0" for a free port.
+func NewMediationServer(address string) (*MediationServer, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", address, err)
+	}
+	s := &MediationServer{
+		listener:   listener,
+		registered: make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	for _, path := range mediationPaths {
+		mux.HandleFunc(path, s.handleConnection)
+	}
+	s.server = &http.Server{Handler: mux}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			glog.Errorf("The simulated mediation server failed: %v", err)
+		}
+	}()
+	glog.V(2).Infof("Started the simulated mediation server on %s.", s.URL())
+	return s, nil
+}
+
+// URL returns the address of the server, to be set as the address of the Turbo server in the TAP config.
+func (s *MediationServer) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Close stops the server and closes the connection of the probe.
+func (s *MediationServer) Close() error {
+	s.lock.Lock()
+	conn := s.conn
+	s.conn = nil
+	s.lock.Unlock()
+	if conn != nil {
+		conn.close()
+	}
+	return s.server.Close()
+}
+
+// WaitForRegistration waits for the probe to register for at most the given timeout, and returns the container info
+// it registered with.
+func (s *MediationServer) WaitForRegistration(timeout time.Duration) (*proto.ContainerInfo, error) {
+	s.lock.Lock()
+	containerInfo, registered := s.containerInfo, s.registered
+	s.lock.Unlock()
+	if containerInfo != nil {
+		return containerInfo, nil
+	}
+	select {
+	case <-registered:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("the probe did not register within %v", timeout)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.containerInfo, nil
+}
+
+// Discover requests a discovery of the given type from the probe, and returns the response once all its chunks are
+// received, or fails after the given timeout.
+func (s *MediationServer) Discover(probeType string, discoveryType proto.DiscoveryType,
+	accountValues []*proto.AccountValue, timeout time.Duration) (*proto.DiscoveryResponse, error) {
+	request := &proto.MediationServerMessage{
+		MediationServerMessage: &proto.MediationServerMessage_DiscoveryRequest{
+			DiscoveryRequest: &proto.DiscoveryRequest{
+				ProbeType:     &probeType,
+				AccountValue:  accountValues,
+				DiscoveryType: discoveryType.Enum(),
+			},
+		},
+	}
+	response := &proto.DiscoveryResponse{}
+	err := s.request(request, timeout, func(message *proto.MediationClientMessage) bool {
+		chunk := message.GetDiscoveryResponse()
+		if chunk == nil {
+			// Keep alive
+			return false
+		}
+		// The SDK sends a chunk for each kind of DTO, and an empty chunk at the end
+		if protobuf.Size(chunk) == 0 {
+			return true
+		}
+		protobuf.Merge(response, chunk)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Validate requests the validation of the target from the probe.
+func (s *MediationServer) Validate(probeType string, accountValues []*proto.AccountValue,
+	timeout time.Duration) (*proto.ValidationResponse, error) {
+	request := &proto.MediationServerMessage{
+		MediationServerMessage: &proto.MediationServerMessage_ValidationRequest{
+			ValidationRequest: &proto.ValidationRequest{
+				ProbeType:    &probeType,
+				AccountValue: accountValues,
+			},
+		},
+	}
+	var response *proto.ValidationResponse
+	err := s.request(request, timeout, func(message *proto.MediationClientMessage) bool {
+		response = message.GetValidationResponse()
+		return response != nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ExecuteAction sends the given action to the probe, and returns its result along with the progress reported until
+// the result, or fails after the given timeout.
+func (s *MediationServer) ExecuteAction(probeType string, actionExecutionDTO *proto.ActionExecutionDTO,
+	accountValues []*proto.AccountValue, timeout time.Duration) (*proto.ActionResult, []*proto.ActionProgress, error) {
+	request := &proto.MediationServerMessage{
+		MediationServerMessage: &proto.MediationServerMessage_ActionRequest{
+			ActionRequest: &proto.ActionRequest{
+				ProbeType:          &probeType,
+				AccountValue:       accountValues,
+				ActionExecutionDTO: actionExecutionDTO,
+			},
+		},
+	}
+	var result *proto.ActionResult
+	var progress []*proto.ActionProgress
+	err := s.request(request, timeout, func(message *proto.MediationClientMessage) bool {
+		if actionProgress := message.GetActionProgress(); actionProgress != nil {
+			progress = append(progress, actionProgress)
+		}
+		result = message.GetActionResponse()
+		return result != nil
+	})
+	if err != nil {
+		return nil, progress, err
+	}
+	return result, progress, nil
+}
+
+// RunDiscoveries requests a full discovery from the probe at each interval until the stop channel is closed, and logs
+// the summary of each discovery, in place of the Turbo server. The account values are those of the target, as they
+// would be set in the server.
+func (s *MediationServer) RunDiscoveries(probeType string, accountValues []*proto.AccountValue,
+	interval time.Duration, stopCh <-chan struct{}) {
+	for {
+		if _, err := s.WaitForRegistration(interval); err == nil {
+			start := time.Now()
+			response, err := s.Discover(probeType, proto.DiscoveryType_FULL, accountValues, interval)
+			if err != nil {
+				glog.Errorf("The simulated discovery of %s failed: %v", probeType, err)
+			} else {
+				glog.Infof("The simulated discovery of %s completed in %v: %s.", probeType,
+					time.Since(start).Round(time.Millisecond), DiscoverySummary(response))
+			}
+		} else {
+			glog.Warningf("Not discovering %s: %v", probeType, err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// DiscoverySummary returns the number of the entities of each type, of the groups and of the errors of the given
+// discovery response.
+func DiscoverySummary(response *proto.DiscoveryResponse) string {
+	counts := make(map[string]int)
+	for _, entityDTO := range response.GetEntityDTO() {
+		counts[entityDTO.GetEntityType().String()]++
+	}
+	var summary []string
+	for entityType, count := range counts {
+		summary = append(summary, fmt.Sprintf("%s=%d", entityType, count))
+	}
+	sort.Strings(summary)
+	return fmt.Sprintf("%d entities [%s], %d groups, %d errors", len(response.GetEntityDTO()),
+		strings.Join(summary, " "), len(response.GetDiscoveredGroup()), len(response.GetErrorDTO()))
+}
+
+// request sends the given request to the probe, and passes its responses to the given handler until the handler is
+// done, or the timeout expires.
+func (s *MediationServer) request(request *proto.MediationServerMessage, timeout time.Duration,
+	handle func(message *proto.MediationClientMessage) bool) error {
+	s.lock.Lock()
+	conn := s.conn
+	s.nextMessageID++
+	messageID := s.nextMessageID
+	s.lock.Unlock()
+	if conn == nil {
+		return errors.New("the probe is not connected")
+	}
+	request.MessageID = &messageID
+	pending := conn.register(messageID)
+	defer conn.unregister(messageID, pending)
+	if err := conn.send(request); err != nil {
+		return err
+	}
+	deadline := time.After(timeout)
+	for {
+		select {
+		case message := <-pending.messages:
+			if handle(message) {
+				return nil
+			}
+		case <-conn.closed:
+			return fmt.Errorf("the probe disconnected before the response to message %d", messageID)
+		case <-deadline:
+			return fmt.Errorf("no response to message %d within %v", messageID, timeout)
+		}
+	}
+}
+
+// handleConnection upgrades the connection of the probe to a websocket, negotiates the protocol version, accepts the
+// registration and dispatches the responses of the probe until the connection is closed.
+func (s *MediationServer) handleConnection(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		glog.Errorf("Failed to accept the connection of the probe: %v", err)
+		return
+	}
+	conn := &probeConnection{
+		ws:      ws,
+		pending: make(map[int32]*pendingRequest),
+		closed:  make(chan struct{}),
+	}
+	defer conn.close()
+	containerInfo, err := conn.handshake()
+	if err != nil {
+		glog.Errorf("Failed to register the probe: %v", err)
+		return
+	}
+	glog.V(2).Infof("The probe registered with the simulated mediation server from %s, with %d probe types.",
+		ws.RemoteAddr(), len(containerInfo.GetProbes()))
+
+	s.lock.Lock()
+	previous := s.conn
+	s.conn, s.containerInfo = conn, containerInfo
+	close(s.registered)
+	s.registered = make(chan struct{})
+	s.lock.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			glog.V(2).Infof("The probe disconnected from the simulated mediation server: %v", err)
+			return
+		}
+		message := &proto.MediationClientMessage{}
+		if err := protobuf.Unmarshal(data, message); err != nil {
+			glog.Errorf("Failed to parse the message of the probe: %v", err)
+			continue
+		}
+		conn.dispatch(message)
+	}
+}
+
+// handshake accepts the protocol version and the registration of the probe, and returns the container info of the
+// registration.
+func (c *probeConnection) handshake() (*proto.ContainerInfo, error) {
+	negotiationRequest := &version.NegotiationRequest{}
+	if err := c.receive(negotiationRequest); err != nil {
+		return nil, fmt.Errorf("failed to receive the version negotiation: %v", err)
+	}
+	description := "Simulated server accepts protocol version " + negotiationRequest.GetProtocolVersion()
+	if err := c.send(&version.NegotiationAnswer{
+		NegotiationResult: version.NegotiationAnswer_ACCEPTED.Enum(),
+		Description:       &description,
+	}); err != nil {
+		return nil, err
+	}
+	containerInfo := &proto.ContainerInfo{}
+	if err := c.receive(containerInfo); err != nil {
+		return nil, fmt.Errorf("failed to receive the registration: %v", err)
+	}
+	if err := c.send(&proto.Ack{}); err != nil {
+		return nil, err
+	}
+	return containerInfo, nil
+}
+
+func (c *probeConnection) receive(message protobuf.Message) error {
+	_, data, err := c.ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return protobuf.Unmarshal(data, message)
+}
+
+func (c *probeConnection) send(message protobuf.Message) error {
+	data, err := protobuf.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the message to the probe: %v", err)
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := c.ws.WriteMessage(websocket.BinaryMessage, data); err != nil {
+		return fmt.Errorf("failed to send the message to the probe: %v", err)
+	}
+	return nil
+}
+
+func (c *probeConnection) register(messageID int32) *pendingRequest {
+	pending := &pendingRequest{
+		messages: make(chan *proto.MediationClientMessage),
+		done:     make(chan struct{}),
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pending[messageID] = pending
+	return pending
+}
+
+func (c *probeConnection) unregister(messageID int32, pending *pendingRequest) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.pending, messageID)
+	close(pending.done)
+}
+
+// dispatch passes the given message to the request of its message ID, unless the request is done.
+func (c *probeConnection) dispatch(message *proto.MediationClientMessage) {
+	c.lock.Lock()
+	pending := c.pending[message.GetMessageID()]
+	c.lock.Unlock()
+	if pending == nil {
+		glog.V(3).Infof("Dropping the message %d of the probe with no pending request.", message.GetMessageID())
+		return
+	}
+	select {
+	case pending.messages <- message:
+	case <-pending.done:
+	}
+}
+
+func (c *probeConnection) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+		c.ws.Close()
+	}
+}
@@ -0,0 +1,127 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/probe"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+const testProbeType = "Kubernetes-simulated"
+
+// testProbe discovers a pod on a node, and executes the actions by reporting their progress.
+type testProbe struct {
+	accountValues []*proto.AccountValue
+}
+
+func (p *testProbe) GetSupplyChainDefinition() []*proto.TemplateDTO {
+	return nil
+}
+
+func (p *testProbe) GetAccountDefinition() []*proto.AccountDefEntry {
+	return nil
+}
+
+func (p *testProbe) GetIdentifyingFields() string {
+	return "targetIdentifier"
+}
+
+func (p *testProbe) GetAccountValues() *probe.TurboTargetInfo {
+	return nil
+}
+
+func (p *testProbe) Validate(accountValues []*proto.AccountValue) (*proto.ValidationResponse, error) {
+	return &proto.ValidationResponse{}, nil
+}
+
+func (p *testProbe) Discover(accountValues []*proto.AccountValue) (*proto.DiscoveryResponse, error) {
+	p.accountValues = accountValues
+	node, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, "node").Create()
+	pod, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, "pod").Create()
+	return &proto.DiscoveryResponse{
+		EntityDTO:       []*proto.EntityDTO{node, pod},
+		DiscoveredGroup: []*proto.GroupDTO{{}},
+	}, nil
+}
+
+func (p *testProbe) ExecuteAction(actionExecutionDTO *proto.ActionExecutionDTO, accountValues []*proto.AccountValue,
+	progressTracker probe.ActionProgressTracker) (*proto.ActionResult, error) {
+	progressTracker.UpdateProgress(proto.ActionResponseState_IN_PROGRESS, "moving", 50)
+	state, progress, description := proto.ActionResponseState_SUCCEEDED, int32(100), "moved"
+	return &proto.ActionResult{Response: &proto.ActionResponse{
+		ActionResponseState: &state,
+		Progress:            &progress,
+		ResponseDescription: &description,
+	}}, nil
+}
+
+func TestMediationServer(t *testing.T) {
+	server, err := NewMediationServer("127.0.0.1:0")
+	assert.NoError(t, err)
+	defer server.Close()
+
+	_, err = server.Discover(testProbeType, proto.DiscoveryType_FULL, nil, time.Second)
+	assert.Error(t, err, "the probe is not connected")
+
+	// The probe connects through the SDK as it would to the Turbo server. The mediation container of the SDK is a
+	// singleton, so the probe connects once per test binary.
+	testProbe := &testProbe{}
+	communicationConfig := &service.TurboCommunicationConfig{}
+	communicationConfig.TurboServer = server.URL()
+	assert.NoError(t, communicationConfig.ValidateTurboCommunicationConfig())
+	tapService, err := service.NewTAPServiceBuilder().
+		WithCommunicationBindingChannel("simulated").
+		WithTurboCommunicator(communicationConfig).
+		WithTurboProbe(probe.NewProbeBuilder(testProbeType, "Cloud Native", "Cloud Native").
+			RegisteredBy(testProbe).
+			WithDiscoveryClient(testProbe).
+			ExecutesActionsBy(testProbe)).
+		Create()
+	assert.NoError(t, err)
+	go tapService.ConnectToTurbo()
+	defer tapService.DisconnectFromTurbo()
+
+	containerInfo, err := server.WaitForRegistration(30 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "simulated", containerInfo.GetCommunicationBindingChannel())
+	if assert.Len(t, containerInfo.GetProbes(), 1) {
+		assert.Equal(t, testProbeType, containerInfo.GetProbes()[0].GetProbeType())
+	}
+
+	// The chunks of the discovery response are merged
+	targetName, targetValue := "targetIdentifier", "cluster"
+	accountValues := []*proto.AccountValue{{Key: &targetName, StringValue: &targetValue}}
+	response, err := server.Discover(testProbeType, proto.DiscoveryType_FULL, accountValues, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, response.GetEntityDTO(), 2)
+	assert.Len(t, response.GetDiscoveredGroup(), 1)
+	assert.Equal(t, "2 entities [CONTAINER_POD=1 VIRTUAL_MACHINE=1], 1 groups, 0 errors", DiscoverySummary(response))
+	if assert.Len(t, testProbe.accountValues, 1) {
+		assert.Equal(t, "cluster", testProbe.accountValues[0].GetStringValue())
+	}
+
+	validation, err := server.Validate(testProbeType, accountValues, 10*time.Second)
+	assert.NoError(t, err)
+	assert.NotNil(t, validation)
+
+	actionType := proto.ActionItemDTO_MOVE
+	uuid := "move-pod"
+	pod, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, "pod").Create()
+	result, progress, err := server.ExecuteAction(testProbeType, &proto.ActionExecutionDTO{
+		ActionType: &actionType,
+		ActionItem: []*proto.ActionItemDTO{{ActionType: &actionType, Uuid: &uuid, TargetSE: pod}},
+	}, accountValues, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, proto.ActionResponseState_SUCCEEDED, result.GetResponse().GetActionResponseState())
+	if assert.Len(t, progress, 1) {
+		assert.Equal(t, "moving", progress[0].GetResponse().GetResponseDescription())
+	}
+
+	// A probe of another type does not respond
+	_, err = server.Discover("unknown", proto.DiscoveryType_FULL, accountValues, 100*time.Millisecond)
+	assert.Error(t, err)
+}
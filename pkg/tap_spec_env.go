@@ -0,0 +1,78 @@
+package kubeturbo
+
+import (
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+// The environment variables which override the TAP config file, e.g. set by a Helm chart or an operator, so that
+// the deployment does not need to template the file. The Turbo server api credentials are read from usernameEnv
+// and passwordEnv.
+const (
+	serverURLEnv     = "TURBO_SERVER_URL"
+	serverVersionEnv = "TURBO_SERVER_VERSION"
+	proxyEnv         = "TURBO_PROXY"
+	targetNameEnv    = "TURBO_TARGET_NAME"
+	targetTypeEnv    = "TURBO_TARGET_TYPE"
+)
+
+// readK8sTAPServiceSpecWithEnv reads the TAP config file and overrides its settings with the environment variables.
+// The file may be missing when the Turbo server address is set in the environment.
+func readK8sTAPServiceSpecWithEnv(configFile string) (*K8sTAPServiceSpec, error) {
+	tapSpec := &K8sTAPServiceSpec{}
+	if _, err := os.Stat(configFile); os.IsNotExist(err) && os.Getenv(serverURLEnv) != "" {
+		glog.V(2).Infof("TAP config %s does not exist, configuring the TAP service from the environment.", configFile)
+	} else {
+		if tapSpec, err = readK8sTAPServiceSpec(configFile); err != nil {
+			return nil, err
+		}
+	}
+	loadTAPSpecFromEnv(tapSpec)
+	return tapSpec, nil
+}
+
+// loadTAPSpecFromEnv overrides the settings of the TAP spec with the ones set in the environment.
+func loadTAPSpecFromEnv(tapSpec *K8sTAPServiceSpec) {
+	if value, found := lookupEnv(serverURLEnv); found {
+		if tapSpec.TurboCommunicationConfig == nil {
+			tapSpec.TurboCommunicationConfig = &service.TurboCommunicationConfig{}
+		}
+		tapSpec.TurboServer = value
+	}
+	if tapSpec.TurboCommunicationConfig != nil {
+		if value, found := lookupEnv(serverVersionEnv); found {
+			tapSpec.Version = value
+		}
+		if value, found := lookupEnv(proxyEnv); found {
+			tapSpec.Proxy = value
+		}
+	}
+	targetName, targetNameFound := lookupEnv(targetNameEnv)
+	targetType, targetTypeFound := lookupEnv(targetTypeEnv)
+	if !targetNameFound && !targetTypeFound {
+		return
+	}
+	if tapSpec.K8sTargetConfig == nil {
+		tapSpec.K8sTargetConfig = &configs.K8sTargetConfig{}
+	}
+	if targetNameFound {
+		tapSpec.TargetIdentifier = targetName
+	}
+	if targetTypeFound {
+		tapSpec.TargetType = targetType
+	}
+}
+
+// lookupEnv returns the trimmed value of the given environment variable, and false if it is not set or empty.
+func lookupEnv(name string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return "", false
+	}
+	glog.V(2).Infof("Setting %s from the environment.", name)
+	return value, true
+}
@@ -0,0 +1,35 @@
+package kubeturbo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseK8sTAPServiceSpecWithEnv(t *testing.T) {
+	t.Setenv(serverURLEnv, "https://turbo.example.com")
+	t.Setenv(serverVersionEnv, "8.9.0")
+	t.Setenv(targetNameEnv, "env-target")
+
+	// The environment overrides the config file
+	config, err := ParseK8sTAPServiceSpec("../test/config/turbo-config", "target-foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://turbo.example.com", config.TurboServer)
+	assert.Equal(t, "8.9.0", config.Version)
+	assert.Equal(t, "Kubernetes-env-target", config.TargetIdentifier)
+	assert.Equal(t, "foo", config.OpsManagerUsername)
+
+	// The config file may be missing when the server address is set in the environment
+	t.Setenv(usernameEnv, "env-user")
+	t.Setenv(passwordEnv, "env-password")
+	config, err = ParseK8sTAPServiceSpec(filepath.Join(t.TempDir(), "turbo.config"), "target-foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://turbo.example.com", config.TurboServer)
+	assert.Equal(t, "Kubernetes-env-target", config.TargetIdentifier)
+	assert.Equal(t, "env-user", config.OpsManagerUsername)
+
+	t.Setenv(serverURLEnv, "")
+	_, err = ParseK8sTAPServiceSpec(filepath.Join(t.TempDir(), "turbo.config"), "target-foo")
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package kubeturbo
+
+import (
+	"path/filepath"
+	"reflect"
+
+	"github.com/golang/glog"
+)
+
+// WatchTAPSpec watches the TAP config file, e.g. mounted from a ConfigMap, and calls onChange once its content
+// differs from the content when the watch started, e.g. when the Turbo server address, version or credentials are
+// edited. The TAP config is only applied when kubeturbo connects to the server, so the caller is expected to restart
+// kubeturbo. The content which cannot be parsed, e.g. while the file is being written, is ignored.
+func WatchTAPSpec(configFile string, onChange func()) error {
+	current, err := readK8sTAPServiceSpec(configFile)
+	if err != nil {
+		return err
+	}
+	return watchDir(filepath.Dir(configFile), func() bool {
+		return tapSpecChanged(configFile, current)
+	}, onChange)
+}
+
+// tapSpecChanged returns whether the TAP config in the given file differs from the current one. A config which
+// cannot be read or parsed is not considered changed.
+func tapSpecChanged(configFile string, current *K8sTAPServiceSpec) bool {
+	spec, err := readK8sTAPServiceSpec(configFile)
+	if err != nil {
+		glog.V(3).Infof("Ignoring the TAP config %s which cannot be read: %v", configFile, err)
+		return false
+	}
+	return !reflect.DeepEqual(spec, current)
+}
@@ -0,0 +1,55 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTAPSpec = `{
+  "communicationConfig": {
+    "serverMeta": {"version": "8.0", "turboServer": "%s"},
+    "restAPIConfig": {"opsManagerUserName": "user", "opsManagerPassword": "password"}
+  }
+}`
+
+func writeTAPSpec(t *testing.T, configFile, turboServer string) {
+	assert.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(testTAPSpec, turboServer)), 0600))
+}
+
+func TestTAPSpecChanged(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "turbo.config")
+	writeTAPSpec(t, configFile, "https://turbo-1")
+	current, err := readK8sTAPServiceSpec(configFile)
+	assert.NoError(t, err)
+	assert.False(t, tapSpecChanged(configFile, current))
+
+	// A partly written config is ignored
+	assert.NoError(t, os.WriteFile(configFile, []byte(`{"communicationConfig": {`), 0600))
+	assert.False(t, tapSpecChanged(configFile, current))
+
+	writeTAPSpec(t, configFile, "https://turbo-2")
+	assert.True(t, tapSpecChanged(configFile, current))
+}
+
+func TestWatchTAPSpec(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "turbo.config")
+	writeTAPSpec(t, configFile, "https://turbo-1")
+	changed := make(chan struct{}, 1)
+	assert.NoError(t, WatchTAPSpec(configFile, func() {
+		changed <- struct{}{}
+	}))
+
+	writeTAPSpec(t, configFile, "https://turbo-2")
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Errorf("The change of the TAP config is not detected")
+	}
+
+	assert.Error(t, WatchTAPSpec(filepath.Join(filepath.Dir(configFile), "missing"), func() {}))
+}
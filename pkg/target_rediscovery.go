@@ -0,0 +1,183 @@
+package kubeturbo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/registration"
+	"github.com/turbonomic/turbo-api/pkg/api"
+	"github.com/turbonomic/turbo-api/pkg/client"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+// ErrRediscoveryUnavailable is returned when the target cannot be rediscovered on demand, as the Turbo API credentials
+// or the target identifier are not configured.
+var ErrRediscoveryUnavailable = errors.New("the rediscovery requires the Turbo API credentials and the target identifier")
+
+// ErrRediscoveryTimeout is returned when the full discovery requested by the server on a rediscovery does not complete
+// in time, e.g. when the discovery fails or the server is busy.
+var ErrRediscoveryTimeout = errors.New("the full discovery did not complete in time")
+
+// Rediscovery is the result of a rediscovery of the target requested on demand.
+type Rediscovery struct {
+	// The UUIDs of the target in the Turbo server
+	UUIDs []string
+	// When the full discovery requested by the server completed
+	DiscoveryTime time.Time
+	// The number of the entities of the full discovery by entity type
+	Entities map[string]int
+}
+
+// targetRediscoverer asks the Turbo server to rediscover the targets through the Turbo API. The server then requests
+// a full discovery from the probe as for the scheduled ones, so that the discovery is sent to the server, and the
+// state kept across the discoveries, e.g. the utilization history, only follows the discoveries of the server.
+type targetRediscoverer struct {
+	// The identifier of the target of the cluster, as in the account values of the discoveries
+	targetID string
+	// The base URL of the Turbo API, e.g. https://turbo/vmturbo/rest/
+	apiURL string
+	// Returns the HTTP client of an authenticated session of the Turbo API
+	newClient func() (*http.Client, error)
+	// Returns the targets of the probe, as added to the server
+	targets func() []*api.Target
+}
+
+// newTurboAPITargetRediscoverer creates the rediscoverer of the targets of the given TAP service, authenticated with the
+// Turbo API credentials of the given TAP spec as the target registrar.
+func newTurboAPITargetRediscoverer(tapService *service.TAPService, tapSpec *K8sTAPServiceSpec) (*targetRediscoverer,
+	error) {
+	serverAddress, err := url.Parse(tapSpec.TurboServer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Turbo server address %s: %v", tapSpec.TurboServer, err)
+	}
+	r := &targetRediscoverer{
+		targetID: tapSpec.TargetIdentifier,
+		apiURL:   strings.TrimSuffix(serverAddress.String(), "/") + client.APIPath,
+		targets: func() []*api.Target {
+			var targets []*api.Target
+			for _, targetInfo := range tapService.GetProbeTargets() {
+				targets = append(targets, targetInfo.GetTargetInstance())
+			}
+			return targets
+		},
+	}
+	if tapSpec.turboAPITokenProvided() {
+		// The tokens are refreshed by the client
+		httpClient, err := newBearerHTTPClient(serverAddress, tapSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the Turbo API client: %v", err)
+		}
+		r.newClient = func() (*http.Client, error) {
+			return httpClient, nil
+		}
+		return r, nil
+	}
+	transport, err := newTurboAPITransport(tapSpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Turbo API client: %v", err)
+	}
+	r.newClient = func() (*http.Client, error) {
+		// A new session is logged in each time, as the session of the previous rediscovery may have expired
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		httpClient := &http.Client{Transport: transport, Jar: jar, Timeout: turboAPITimeout}
+		form := url.Values{"username": {tapSpec.OpsManagerUsername}, "password": {tapSpec.OpsManagerPassword}}
+		response, err := httpClient.PostForm(r.apiURL+"login", form)
+		if err != nil {
+			return nil, fmt.Errorf("failed to log in to the Turbo API: %v", err)
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to log in to the Turbo API: %s", response.Status)
+		}
+		return httpClient, nil
+	}
+	return r, nil
+}
+
+// rediscover asks the server to rediscover the targets of the probe, and returns their UUIDs in the server.
+func (r *targetRediscoverer) rediscover() ([]string, error) {
+	httpClient, err := r.newClient()
+	if err != nil {
+		return nil, err
+	}
+	var existing []api.Target
+	if err := r.do(httpClient, http.MethodGet, "targets", &existing); err != nil {
+		return nil, fmt.Errorf("failed to list the targets: %v", err)
+	}
+	var uuids []string
+	for _, target := range r.targets() {
+		uuid := findTargetUUID(existing, target)
+		if uuid == "" {
+			return uuids, fmt.Errorf("target %s is not added to the server", targetIdentifierOf(target))
+		}
+		if err := r.do(httpClient, http.MethodPost, "targets/"+url.PathEscape(uuid)+"?rediscover=true",
+			nil); err != nil {
+			return uuids, fmt.Errorf("failed to rediscover target %s: %v", targetIdentifierOf(target), err)
+		}
+		glog.V(2).Infof("Requested the rediscovery of target %s (%s).", targetIdentifierOf(target), uuid)
+		uuids = append(uuids, uuid)
+	}
+	return uuids, nil
+}
+
+// do sends the request of the given method to the given path of the Turbo API, and decodes the JSON response into the
+// given value if any.
+func (r *targetRediscoverer) do(httpClient *http.Client, method, path string, value interface{}) error {
+	request, err := http.NewRequest(method, r.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", response.Status, body)
+	}
+	if value == nil {
+		return nil
+	}
+	return json.Unmarshal(body, value)
+}
+
+// findTargetUUID returns the UUID of the existing target of the same category, type and identifier as the given
+// target, empty if none. The type of the existing target may have an extra suffix, as matched by the Turbo API client.
+func findTargetUUID(existing []api.Target, target *api.Target) string {
+	identifier := targetIdentifierOf(target)
+	for _, candidate := range existing {
+		if candidate.Category != target.Category || !strings.HasPrefix(candidate.Type, target.Type) {
+			continue
+		}
+		if targetIdentifierOf(&candidate) == identifier {
+			return candidate.UUID
+		}
+	}
+	return ""
+}
+
+// targetIdentifierOf returns the value of the target identifier field of the target.
+func targetIdentifierOf(target *api.Target) string {
+	for _, inputField := range target.InputFields {
+		if inputField.Name == registration.TargetIdentifierField {
+			return inputField.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package kubeturbo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/turbo-api/pkg/api"
+	"github.com/turbonomic/turbo-api/pkg/client"
+	"github.com/turbonomic/turbo-go-sdk/pkg/mediationcontainer"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+func TestTargetRediscoverer(t *testing.T) {
+	var rediscovered []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == client.APIPath+"login":
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: client.SessionCookie, Value: "session"})
+			return
+		case r.Header.Get("Cookie") != client.SessionCookie+"=session":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodGet && r.URL.Path == client.APIPath+"targets":
+			json.NewEncoder(w).Encode([]api.Target{
+				{UUID: "other", Category: "Cloud Native", Type: "Kubernetes-prod",
+					InputFields: []*api.InputField{{Name: "targetIdentifier", Value: "other"}}},
+				{UUID: "1234", Category: "Cloud Native", Type: "Kubernetes-prod",
+					InputFields: []*api.InputField{{Name: "targetIdentifier", Value: "prod"}}},
+			})
+		case r.Method == http.MethodPost && r.URL.Query().Get("rediscover") == "true":
+			rediscovered = append(rediscovered, r.URL.Path)
+			w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tapSpec := &K8sTAPServiceSpec{
+		TurboCommunicationConfig: &service.TurboCommunicationConfig{
+			ServerMeta: mediationcontainer.ServerMeta{TurboServer: server.URL},
+			RestAPIConfig: service.RestAPIConfig{
+				OpsManagerUsername: "admin",
+				OpsManagerPassword: "secret",
+			},
+		},
+		K8sTargetConfig: &configs.K8sTargetConfig{TargetIdentifier: "prod"},
+	}
+	r, err := newTurboAPITargetRediscoverer(nil, tapSpec)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", r.targetID)
+	target := &api.Target{Category: "Cloud Native", Type: "Kubernetes",
+		InputFields: []*api.InputField{{Name: "targetIdentifier", Value: "prod"}}}
+	r.targets = func() []*api.Target { return []*api.Target{target} }
+
+	uuids, err := r.rediscover()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1234"}, uuids)
+	assert.Equal(t, []string{client.APIPath + "targets/1234"}, rediscovered)
+
+	// The targets not added to the server cannot be rediscovered
+	target.InputFields[0].Value = "dev"
+	_, err = r.rediscover()
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-api/pkg/api"
+	"github.com/turbonomic/turbo-api/pkg/client"
+	"github.com/turbonomic/turbo-go-sdk/pkg/mediationcontainer"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+// How often the registrar checks whether the server has discovered the target
+const targetRegistrationCheckInterval = 5 * time.Minute
+
+// targetRegistrar adds the target again through the Turbo API when the server has not discovered it a while after
+// the probe registered. The SDK adds the target through the API only once, after the first registration of the
+// probe, so a failure, e.g. when the API is not reachable yet when kubeturbo starts, leaves the target to be
+// created in the UI. An existing target is updated instead of added.
+type targetRegistrar struct {
+	addTarget      func() error
+	isRegistered   func() bool
+	lastDiscovery  func() time.Time
+	gracePeriod    time.Duration
+	registeredTime time.Time
+	now            func() time.Time
+}
+
+// newTurboAPITargetRegistrar creates the registrar of the targets of the given TAP service, which adds them with the
+// Turbo API credentials of the given TAP spec: the username and password, or else the API token or the OAuth client
+// credentials.
+func newTurboAPITargetRegistrar(tapService *service.TAPService, tapSpec *K8sTAPServiceSpec,
+	communicationBindingChannel string, isRegistered func() bool, lastDiscovery func() time.Time,
+	gracePeriod time.Duration) (*targetRegistrar, error) {
+	serverAddress, err := url.Parse(tapSpec.TurboServer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Turbo server address %s: %v", tapSpec.TurboServer, err)
+	}
+	newClient := func() (targetAdder, error) {
+		// A new client logs in a new session each time, as the session of the previous addition may have expired
+		config := client.NewConfigBuilder(serverAddress).
+			BasicAuthentication(url.QueryEscape(tapSpec.OpsManagerUsername),
+				url.QueryEscape(tapSpec.OpsManagerPassword)).
+			SetProxy(tapSpec.ServerMeta.Proxy).
+			Create()
+		turboClient, err := client.NewTurboClient(config)
+		if err != nil {
+			return nil, err
+		}
+		return turboAPIClient{turboClient}, nil
+	}
+	if tapSpec.turboAPITokenProvided() {
+		// The tokens are refreshed by the client
+		tokenClient, err := newTokenTurboAPIClient(serverAddress, tapSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the Turbo API client: %v", err)
+		}
+		newClient = func() (targetAdder, error) {
+			return tokenClient, nil
+		}
+	}
+	if _, err := newClient(); err != nil {
+		return nil, fmt.Errorf("failed to create the Turbo API client: %v", err)
+	}
+	addTarget := func() error {
+		turboClient, err := newClient()
+		if err != nil {
+			return err
+		}
+		for _, targetInfo := range tapService.GetProbeTargets() {
+			target := targetInfo.GetTargetInstance()
+			target.InputFields = append(target.InputFields, &api.InputField{
+				Name: api.CommunicationBindingChannel, Value: communicationBindingChannel})
+			if err := turboClient.AddTarget(target); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return &targetRegistrar{
+		addTarget:     addTarget,
+		isRegistered:  isRegistered,
+		lastDiscovery: lastDiscovery,
+		gracePeriod:   gracePeriod,
+		now:           time.Now,
+	}, nil
+}
+
+// Run checks periodically whether the target needs to be added again, until the server has discovered it.
+func (r *targetRegistrar) Run() {
+	ticker := time.NewTicker(targetRegistrationCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if r.check() {
+			return
+		}
+	}
+}
+
+// check adds the target again if the probe registered more than the grace period ago and no discovery has completed
+// since. It returns true once a discovery has completed, i.e., the target exists in the server.
+func (r *targetRegistrar) check() bool {
+	if !r.lastDiscovery().IsZero() {
+		return true
+	}
+	if !r.isRegistered() {
+		r.registeredTime = time.Time{}
+		return false
+	}
+	now := r.now()
+	if r.registeredTime.IsZero() {
+		r.registeredTime = now
+	}
+	if now.Sub(r.registeredTime) < r.gracePeriod {
+		return false
+	}
+	glog.Warningf("The target has not been discovered %v after the probe registered, adding it again through the "+
+		"Turbo API.", r.gracePeriod)
+	if err := r.addTarget(); err != nil {
+		glog.Errorf("Failed to add the target through the Turbo API: %v", err)
+		return false
+	}
+	glog.Infof("Added the target through the Turbo API.")
+	// Wait for another grace period for the discovery of the added target
+	r.registeredTime = now
+	return false
+}
+
+// targetAdder adds a target through the Turbo API.
+type targetAdder interface {
+	AddTarget(target *api.Target) error
+}
+
+// turboAPIClient adds the targets through the API service of the Turbo client of a username and password.
+type turboAPIClient struct {
+	*client.TurboClient
+}
+
+func (c turboAPIClient) AddTarget(target *api.Target) error {
+	return c.TurboClient.AddTarget(target, mediationcontainer.GetMediationService())
+}
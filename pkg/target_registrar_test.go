@@ -0,0 +1,55 @@
+package kubeturbo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetRegistrarCheck(t *testing.T) {
+	now := time.Now()
+	registered := false
+	var lastDiscovery time.Time
+	var addErr error
+	added := 0
+	registrar := &targetRegistrar{
+		addTarget: func() error {
+			added++
+			return addErr
+		},
+		isRegistered:  func() bool { return registered },
+		lastDiscovery: func() time.Time { return lastDiscovery },
+		gracePeriod:   10 * time.Minute,
+		now:           func() time.Time { return now },
+	}
+
+	// The probe has not registered yet
+	assert.False(t, registrar.check())
+	registered = true
+	assert.False(t, registrar.check())
+	now = now.Add(5 * time.Minute)
+	assert.False(t, registrar.check())
+	assert.Equal(t, 0, added)
+
+	// Not discovered for the grace period after the registration, the addition fails and is retried
+	addErr = fmt.Errorf("connection refused")
+	now = now.Add(5 * time.Minute)
+	assert.False(t, registrar.check())
+	assert.Equal(t, 1, added)
+	now = now.Add(5 * time.Minute)
+	addErr = nil
+	assert.False(t, registrar.check())
+	assert.Equal(t, 2, added)
+
+	// Waits for another grace period after a successful addition
+	now = now.Add(5 * time.Minute)
+	assert.False(t, registrar.check())
+	assert.Equal(t, 2, added)
+
+	// Done once the target is discovered
+	lastDiscovery = now
+	assert.True(t, registrar.check())
+	assert.Equal(t, 2, added)
+}
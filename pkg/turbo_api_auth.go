@@ -0,0 +1,215 @@
+package kubeturbo
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-api/pkg/client"
+)
+
+// The Turbo API is authenticated with an API token, or with the access token of the OAuth client credentials of the
+// TAP spec, in place of the username and password which log in a session. The tokens are sent as bearer tokens.
+const (
+	apiTokenEnv      = "TURBO_API_TOKEN"
+	apiTokenFilePath = "/etc/turbonomic-credentials/apitoken"
+
+	// The access tokens are refreshed this long before they expire
+	tokenExpiryMargin = time.Minute
+	// The lifetime of the access tokens issued without an expiry
+	defaultTokenLifetime = 5 * time.Minute
+	turboAPITimeout      = time.Minute
+)
+
+// loadAPIToken sets the API token of the TAP spec from the environment, overridden by the given file of the mounted
+// credentials secret if it exists.
+func loadAPIToken(tapSpec *K8sTAPServiceSpec, tokenFile string) {
+	if token, found := lookupEnv(apiTokenEnv); found {
+		tapSpec.TurboAPIToken = token
+	}
+	if token := readCredentialFile(filepath.Dir(tokenFile), tokenFile); token != "" {
+		tapSpec.TurboAPIToken = token
+	}
+}
+
+// turboAPITokenProvided returns whether the Turbo API is authenticated with a bearer token rather than with the
+// username and password.
+func (tapSpec *K8sTAPServiceSpec) turboAPITokenProvided() bool {
+	return !tapSpec.TurboAPICredentialsProvided() &&
+		(tapSpec.TurboAPIToken != "" || tapSpec.SecureModeCredentialsProvided())
+}
+
+// tokenSource provides the bearer token of the Turbo API requests.
+type tokenSource interface {
+	// token returns the current token, refreshed if it has expired
+	token() (string, error)
+	// invalidate drops the current token after the server has rejected it
+	invalidate()
+}
+
+// staticToken is an API token, which is not refreshed.
+type staticToken string
+
+func (t staticToken) token() (string, error) {
+	return string(t), nil
+}
+
+func (t staticToken) invalidate() {}
+
+// clientCredentialsToken is the access token of the OAuth client credentials, requested again once expired.
+type clientCredentialsToken struct {
+	httpClient   *http.Client
+	tokenURL     string
+	clientId     string
+	clientSecret string
+	now          func() time.Time
+
+	lock        sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+func (t *clientCredentialsToken) token() (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.accessToken != "" && t.now().Before(t.expiry) {
+		return t.accessToken, nil
+	}
+	// The same form as the token request of the SDK for the secure probe connection
+	payload := &bytes.Buffer{}
+	writer := multipart.NewWriter(payload)
+	writer.WriteField("client_id", t.clientId)
+	writer.WriteField("client_secret", t.clientSecret)
+	writer.WriteField("grant_type", "client_credentials")
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	response, err := t.httpClient.Post(t.tokenURL, writer.FormDataContentType(), payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to request the access token: %v", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the access token: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request the access token: %s", response.Status)
+	}
+	var tokenBody client.HydraTokenBody
+	if err := json.Unmarshal(body, &tokenBody); err != nil || tokenBody.AccessToken == "" {
+		return "", fmt.Errorf("invalid access token response: %v", err)
+	}
+	lifetime := defaultTokenLifetime
+	if tokenBody.ExpiresIn > 0 {
+		lifetime = time.Duration(tokenBody.ExpiresIn) * time.Second
+	}
+	if lifetime > 2*tokenExpiryMargin {
+		lifetime -= tokenExpiryMargin
+	}
+	t.accessToken, t.expiry = tokenBody.AccessToken, t.now().Add(lifetime)
+	glog.V(3).Infof("Obtained the access token of the Turbo API, valid for %v.", lifetime)
+	return t.accessToken, nil
+}
+
+func (t *clientCredentialsToken) invalidate() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.accessToken = ""
+}
+
+// bearerTransport authenticates the requests with the bearer token of the token source, in place of the session
+// cookie, and sends a request again with a new token once if the server rejects the token, e.g. revoked early.
+type bearerTransport struct {
+	base   http.RoundTripper
+	source tokenSource
+}
+
+func (t *bearerTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.roundTrip(request)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || (request.Body != nil && request.GetBody == nil) {
+		return response, err
+	}
+	response.Body.Close()
+	glog.V(2).Infof("The Turbo API rejected the token, requesting a new one.")
+	t.source.invalidate()
+	if request.GetBody != nil {
+		if request.Body, err = request.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.roundTrip(request)
+}
+
+func (t *bearerTransport) roundTrip(request *http.Request) (*http.Response, error) {
+	token, err := t.source.token()
+	if err != nil {
+		return nil, err
+	}
+	authenticated := request.Clone(request.Context())
+	authenticated.Header.Del("Cookie")
+	authenticated.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(authenticated)
+}
+
+// newTokenTurboAPIClient creates the client of the Turbo API at the given server address, authenticated with the API
+// token of the TAP spec, or else with its OAuth client credentials.
+func newTokenTurboAPIClient(serverAddress *url.URL, tapSpec *K8sTAPServiceSpec) (client.Client, error) {
+	httpClient, err := newBearerHTTPClient(serverAddress, tapSpec)
+	if err != nil {
+		return nil, err
+	}
+	// The session cookie is set so that the client does not log in with a username and password, and is replaced by
+	// the bearer token
+	return &client.APIClient{
+		RESTClient:    client.NewRESTClient(httpClient, serverAddress, client.APIPath),
+		SessionCookie: &http.Cookie{Name: client.SessionCookie},
+	}, nil
+}
+
+// newBearerHTTPClient creates the HTTP client of the Turbo API at the given server address, which authenticates the
+// requests with the API token of the TAP spec, or else with the access token of its OAuth client credentials.
+func newBearerHTTPClient(serverAddress *url.URL, tapSpec *K8sTAPServiceSpec) (*http.Client, error) {
+	transport, err := newTurboAPITransport(tapSpec)
+	if err != nil {
+		return nil, err
+	}
+	var source tokenSource = staticToken(tapSpec.TurboAPIToken)
+	if tapSpec.TurboAPIToken == "" {
+		source = &clientCredentialsToken{
+			httpClient:   &http.Client{Transport: transport, Timeout: turboAPITimeout},
+			tokenURL:     strings.TrimSuffix(serverAddress.String(), "/") + client.HydraPath + "token",
+			clientId:     tapSpec.ClientId,
+			clientSecret: tapSpec.ClientSecret,
+			now:          time.Now,
+		}
+	}
+	return &http.Client{
+		Transport: &bearerTransport{base: transport, source: source},
+		Timeout:   turboAPITimeout,
+	}, nil
+}
+
+// newTurboAPITransport creates the transport of the Turbo API requests, through the proxy of the TAP spec if any.
+func newTurboAPITransport(tapSpec *K8sTAPServiceSpec) (*http.Transport, error) {
+	// The same transport as the Turbo API client of the SDK, whose server certificate is verified on startup
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	if tapSpec.Proxy != "" {
+		proxy, err := parseServerProxy(tapSpec.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport, nil
+}
@@ -0,0 +1,158 @@
+package kubeturbo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/turbo-api/pkg/api"
+	"github.com/turbonomic/turbo-api/pkg/client"
+	"github.com/turbonomic/turbo-go-sdk/pkg/mediationcontainer"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+)
+
+// tokenServer is a Turbo server which issues the access tokens of the client credentials, and accepts the requests
+// of the API with the last issued token or the API token.
+type tokenServer struct {
+	*httptest.Server
+	lock       sync.Mutex
+	apiToken   string
+	issued     int
+	current    string
+	authorized []string
+}
+
+func newTokenServer(t *testing.T, apiToken string, expiresIn int) *tokenServer {
+	server := &tokenServer{apiToken: apiToken}
+	server.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.lock.Lock()
+		defer server.lock.Unlock()
+		if r.URL.Path == client.HydraPath+"token" {
+			if r.FormValue("client_id") != "id" || r.FormValue("client_secret") != "secret" ||
+				r.FormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			server.issued++
+			server.current = "access-" + string(rune('0'+server.issued))
+			json.NewEncoder(w).Encode(client.HydraTokenBody{AccessToken: server.current, ExpiresIn: expiresIn})
+			return
+		}
+		authorization := r.Header.Get("Authorization")
+		if r.Header.Get("Cookie") != "" ||
+			(authorization != "Bearer "+server.current && authorization != "Bearer "+server.apiToken) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet {
+			// No target exists
+			w.Write([]byte("[]"))
+			return
+		}
+		server.authorized = append(server.authorized, authorization)
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTokenTestSpec(serverURL, apiToken string) *K8sTAPServiceSpec {
+	return &K8sTAPServiceSpec{
+		TurboCommunicationConfig: &service.TurboCommunicationConfig{
+			ServerMeta: mediationcontainer.ServerMeta{TurboServer: serverURL, ClientId: "id", ClientSecret: "secret"},
+		},
+		TurboAPIToken: apiToken,
+	}
+}
+
+func TestTokenTurboAPIClientWithAPIToken(t *testing.T) {
+	server := newTokenServer(t, "api-token", 0)
+	serverURL, _ := url.Parse(server.URL)
+	turboClient, err := newTokenTurboAPIClient(serverURL, newTokenTestSpec(server.URL, "api-token"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, turboClient.AddTarget(&api.Target{Type: "Kubernetes-cluster"}))
+	assert.Equal(t, []string{"Bearer api-token"}, server.authorized)
+	assert.Equal(t, 0, server.issued)
+
+	// A rejected API token is not refreshed
+	server.apiToken = "new-token"
+	assert.Error(t, turboClient.AddTarget(&api.Target{Type: "Kubernetes-cluster"}))
+}
+
+func TestTokenTurboAPIClientWithClientCredentials(t *testing.T) {
+	server := newTokenServer(t, "", 3600)
+	serverURL, _ := url.Parse(server.URL)
+	turboClient, err := newTokenTurboAPIClient(serverURL, newTokenTestSpec(server.URL, ""))
+	assert.NoError(t, err)
+
+	// The access token is requested once and reused
+	assert.NoError(t, turboClient.AddTarget(&api.Target{Type: "Kubernetes-cluster"}))
+	assert.NoError(t, turboClient.AddTarget(&api.Target{Type: "Kubernetes-cluster"}))
+	assert.Equal(t, 1, server.issued)
+
+	// A token revoked by the server is requested again, and the request is sent again
+	server.current = "revoked"
+	assert.NoError(t, turboClient.AddTarget(&api.Target{Type: "Kubernetes-cluster"}))
+	assert.Equal(t, 2, server.issued)
+	assert.Equal(t, []string{"Bearer access-1", "Bearer access-1", "Bearer access-2"}, server.authorized)
+}
+
+func TestClientCredentialsTokenExpiry(t *testing.T) {
+	server := newTokenServer(t, "", 600)
+	now := time.Now()
+	source := &clientCredentialsToken{
+		httpClient:   server.Client(),
+		tokenURL:     server.URL + client.HydraPath + "token",
+		clientId:     "id",
+		clientSecret: "secret",
+		now:          func() time.Time { return now },
+	}
+	token, err := source.token()
+	assert.NoError(t, err)
+	assert.Equal(t, "access-1", token)
+
+	// The token is refreshed ahead of its expiry
+	now = now.Add(600*time.Second - tokenExpiryMargin - time.Second)
+	token, _ = source.token()
+	assert.Equal(t, "access-1", token)
+	now = now.Add(2 * time.Second)
+	token, _ = source.token()
+	assert.Equal(t, "access-2", token)
+
+	// The invalid client credentials are rejected
+	source.clientSecret = "wrong"
+	source.invalidate()
+	_, err = source.token()
+	assert.Error(t, err)
+}
+
+func TestLoadAPIToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "apitoken")
+	tapSpec := newTokenTestSpec("https://turbo.example.com", "")
+	tapSpec.ClientId, tapSpec.ClientSecret = "", ""
+	loadAPIToken(tapSpec, tokenFile)
+	assert.Equal(t, "", tapSpec.TurboAPIToken)
+	assert.False(t, tapSpec.turboAPITokenProvided())
+
+	t.Setenv(apiTokenEnv, "env-token")
+	loadAPIToken(tapSpec, tokenFile)
+	assert.Equal(t, "env-token", tapSpec.TurboAPIToken)
+	assert.True(t, tapSpec.turboAPITokenProvided())
+
+	// The mounted token takes precedence
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+	loadAPIToken(tapSpec, tokenFile)
+	assert.Equal(t, "file-token", tapSpec.TurboAPIToken)
+
+	// The username and password take precedence over the token
+	tapSpec.OpsManagerUsername, tapSpec.OpsManagerPassword = "user", "password"
+	assert.False(t, tapSpec.turboAPITokenProvided())
+}
@@ -0,0 +1,172 @@
+package turbostore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// How long to wait for another process, e.g. a previous kubeturbo pod still terminating, to close the database
+	boltOpenTimeout = 10 * time.Second
+)
+
+// BoltStore is a bbolt database in a file, e.g. on a persistent volume, keeping the caches of the state of kubeturbo
+// across its restarts, one bucket per cache. Each change is written in its own transaction, without rewriting the
+// other items.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens the database in the given file, created if it does not exist.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Close closes the database, after which its caches can no longer be used.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// Cache returns the cache kept in the given bucket, created if it does not exist, after deleting its expired items.
+func (s *BoltStore) Cache(bucket string, defaultExpiration time.Duration) (*BoltCache, error) {
+	c := &BoltCache{
+		db:                s.db,
+		bucket:            []byte(bucket),
+		defaultExpiration: defaultExpiration,
+	}
+	expired := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(c.bucket)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		var keys [][]byte
+		err = b.ForEach(func(k, v []byte) error {
+			if item, err := decodeBoltItem(v); err != nil || item.expired(now) {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		expired = len(keys)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the %s bucket: %v", bucket, err)
+	}
+	glog.V(3).Infof("Loaded the %s bucket of %s, deleted %d expired items.", bucket, s.db.Path(), expired)
+	return c, nil
+}
+
+// BoltCache is an ITurboCache kept in a bucket of a BoltStore. The values must be encodable in JSON, and are loaded
+// back as their JSON types, e.g. string.
+type BoltCache struct {
+	db                *bolt.DB
+	bucket            []byte
+	defaultExpiration time.Duration
+}
+
+type boltItem struct {
+	Value interface{} `json:"value"`
+	// The time the item expires at, never if zero
+	Expiration time.Time `json:"expiration"`
+}
+
+func (item boltItem) expired(now time.Time) bool {
+	return !item.Expiration.IsZero() && now.After(item.Expiration)
+}
+
+func decodeBoltItem(data []byte) (boltItem, error) {
+	var item boltItem
+	err := json.Unmarshal(data, &item)
+	return item, err
+}
+
+// Set adds an item to the cache, replacing any existing item, with the default expiration if the duration is 0,
+// and no expiration if it is negative. The item is lost if it cannot be written.
+func (c *BoltCache) Set(k string, x interface{}, d time.Duration) {
+	if d == 0 {
+		d = c.defaultExpiration
+	}
+	item := boltItem{Value: x}
+	if d > 0 {
+		item.Expiration = time.Now().Add(d)
+	}
+	data, err := json.Marshal(item)
+	if err == nil {
+		err = c.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(c.bucket).Put([]byte(k), data)
+		})
+	}
+	if err != nil {
+		glog.Errorf("Failed to write %s to the %s bucket: %v", k, c.bucket, err)
+	}
+}
+
+// Add adds an item to the cache with the default expiration, like the Add of Cache.
+func (c *BoltCache) Add(k string, x interface{}) {
+	c.Set(k, x, 0)
+}
+
+func (c *BoltCache) Get(k string) (interface{}, bool) {
+	var item boltItem
+	found := false
+	err := c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(c.bucket).Get([]byte(k))
+		if data == nil {
+			return nil
+		}
+		var err error
+		item, err = decodeBoltItem(data)
+		found = err == nil
+		return err
+	})
+	if err != nil {
+		glog.Errorf("Failed to read %s from the %s bucket: %v", k, c.bucket, err)
+		return nil, false
+	}
+	if !found || item.expired(time.Now()) {
+		return nil, false
+	}
+	return item.Value, true
+}
+
+func (c *BoltCache) Delete(k string) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).Delete([]byte(k))
+	})
+}
+
+// Items returns the unexpired items of the cache.
+func (c *BoltCache) Items() map[string]interface{} {
+	items := make(map[string]interface{})
+	now := time.Now()
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(c.bucket).ForEach(func(k, v []byte) error {
+			if item, err := decodeBoltItem(v); err == nil && !item.expired(now) {
+				items[string(k)] = item.Value
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		glog.Errorf("Failed to read the %s bucket: %v", c.bucket, err)
+	}
+	return items
+}
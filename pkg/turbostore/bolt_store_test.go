@@ -0,0 +1,43 @@
+package turbostore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := OpenBoltStore(path)
+	assert.NoError(t, err)
+	cache, err := store.Cache("pod-names", time.Hour)
+	assert.NoError(t, err)
+	cache.Set("pod-foo", "pod-foo-c", 0)
+	cache.Set("pod-bar", "pod-bar-c", time.Nanosecond)
+	cache.Set("action-1", "move pod-foo", -1)
+	cache.Add("action-2", "move pod-bar")
+	assert.NoError(t, cache.Delete("action-2"))
+	time.Sleep(time.Millisecond)
+
+	_, found := cache.Get("pod-bar")
+	assert.False(t, found)
+
+	// The other buckets are separate caches
+	other, err := store.Cache("locks", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, other.Items())
+	assert.NoError(t, store.Close())
+
+	// The unexpired items are loaded back from the file
+	store, err = OpenBoltStore(path)
+	assert.NoError(t, err)
+	defer store.Close()
+	cache, err = store.Cache("pod-names", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"pod-foo": "pod-foo-c", "action-1": "move pod-foo"}, cache.Items())
+	value, found := cache.Get("pod-foo")
+	assert.True(t, found)
+	assert.Equal(t, "pod-foo-c", value)
+}
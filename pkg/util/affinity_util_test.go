@@ -0,0 +1,129 @@
+package util
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeMatchesNodeSelectorTerms(t *testing.T) {
+	table := []struct {
+		node              *corev1.Node
+		nodeSelectorTerms []corev1.NodeSelectorTerm
+
+		expectsMatches bool
+	}{
+		{
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"foo":  "bar",
+						"key1": "value1",
+					},
+				},
+			},
+			nodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      "foo",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"bar"},
+						},
+					},
+				},
+			},
+
+			expectsMatches: true,
+		},
+		{
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"foo":  "bar",
+						"key1": "value1",
+					},
+				},
+			},
+			nodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      "foo",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"bar"},
+						},
+						{
+							Key:      "key1",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"value1"},
+						},
+					},
+				},
+			},
+
+			expectsMatches: true,
+		},
+		{
+			// doesn't match.
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"foo":  "bar",
+						"key1": "value1",
+					},
+				},
+			},
+			nodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      "foo",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"bar"},
+						},
+						{
+							Key:      "key1",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"value2"},
+						},
+					},
+				},
+			},
+
+			expectsMatches: false,
+		},
+		{
+			// invalid operator.
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"foo":  "bar",
+						"key1": "value1",
+					},
+				},
+			},
+			nodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      "foo",
+							Operator: corev1.NodeSelectorOperator("invalid"),
+							Values:   []string{"bar"},
+						},
+					},
+				},
+			},
+
+			expectsMatches: false,
+		},
+	}
+
+	for i, item := range table {
+		matches := NodeMatchesNodeSelectorTerms(item.node, item.nodeSelectorTerms)
+		if matches != item.expectsMatches {
+			t.Errorf("Test case %d failed. Expects %t, got %t", i, item.expectsMatches, matches)
+		}
+	}
+}
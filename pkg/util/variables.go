@@ -58,10 +58,6 @@ const (
 var (
 	// The API group version under which deployments and replicasets are exposed by the k8s cluster as of today
 	K8sAPIDeploymentReplicasetDefaultGV = schema.GroupVersion{Group: K8sAppsGroupName, Version: "v1"}
-	// The API group version under which deployments are exposed by the k8s cluster
-	K8sAPIDeploymentGV = schema.GroupVersion{Group: K8sAppsGroupName, Version: "v1"}
-	// The API group version under which replicasets are exposed by the k8s cluster
-	K8sAPIReplicasetGV = schema.GroupVersion{Group: K8sAppsGroupName, Version: "v1"}
 	// The API group under which replicationcontrollers are exposed by the k8s server
 	// We do not discover the latest GV for this as we know that it has matured under core/v1
 	K8sAPIReplicationControllerGV = schema.GroupVersion{Group: "", Version: "v1"}
@@ -86,3 +82,28 @@ var (
 	// The service account name will be in the format "system:serviceaccount:<ns>:<name>"
 	SCCMapping map[string]string = make(map[string]string)
 )
+
+// ControllerGroupVersions are the API group versions under which the deployments and the replicasets are exposed by
+// the API server of a cluster. They are discovered for each cluster, as they differ between the k8s versions.
+type ControllerGroupVersions struct {
+	Deployment schema.GroupVersion
+	ReplicaSet schema.GroupVersion
+}
+
+// DefaultControllerGroupVersions returns the group versions used when they cannot be discovered from the cluster.
+func DefaultControllerGroupVersions() ControllerGroupVersions {
+	return ControllerGroupVersions{
+		Deployment: K8sAPIDeploymentReplicasetDefaultGV,
+		ReplicaSet: K8sAPIDeploymentReplicasetDefaultGV,
+	}
+}
+
+// DeploymentResource returns the resource of the deployments.
+func (gvs ControllerGroupVersions) DeploymentResource() schema.GroupVersionResource {
+	return gvs.Deployment.WithResource(DeploymentResName)
+}
+
+// ReplicaSetResource returns the resource of the replicasets.
+func (gvs ControllerGroupVersions) ReplicaSetResource() schema.GroupVersionResource {
+	return gvs.ReplicaSet.WithResource(ReplicaSetResName)
+}
@@ -0,0 +1,457 @@
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// The placements of the pods on the nodes
+const (
+	// Each pod is placed on a node picked uniformly at random
+	PlacementUniform = "uniform"
+	// The pods are placed on the nodes following a Zipf distribution, so that a few nodes host most of the pods, as
+	// in the clusters with large node pools of mostly idle nodes
+	PlacementSkewed = "skewed"
+)
+
+const (
+	defaultNodeCPUMillicores = 16000
+	defaultNodeMemoryKB      = 64 * 1024 * 1024
+	defaultNodeMaxPods       = 110
+	// The share of the CPU and memory of the nodes reserved for the system and the kubelet
+	nodeReservedFraction = 0.05
+	nodeCPUFrequencyMHz  = 2600
+	nodeFSCapacityBytes  = 100 * 1024 * 1024 * 1024
+	nodeFSThresholdPct   = 10
+	podFSCapacityBytes   = 10 * 1024 * 1024 * 1024
+	// The exponent of the Zipf distribution of the skewed placement
+	skewedPlacementExponent = 1.2
+)
+
+// Spec is the shape of a synthetic cluster. The zero values of the optional fields get the defaults documented on
+// the fields.
+type Spec struct {
+	Nodes int
+	Pods  int
+	// The number of namespaces the pods are spread over, 1 by default
+	Namespaces int
+	// The number of pods of each ReplicaSet, 0 for bare pods with no controller
+	PodsPerController int
+	// The number of containers of each pod, picked uniformly in [MinContainersPerPod, MaxContainersPerPod], 1 by
+	// default
+	MinContainersPerPod int
+	MaxContainersPerPod int
+	// PlacementUniform by default
+	Placement string
+	// The usage of the containers as a fraction of their limits, picked uniformly in [MinUsage, MaxUsage], from 0.1
+	// to 0.9 by default
+	MinUsage float64
+	MaxUsage float64
+	// The allocatable CPU in millicores and memory in kilobytes of each node, 16 cores and 64GiB by default
+	NodeCPUMillicores float64
+	NodeMemoryKB      float64
+	// The seed of the random generator, so that the same spec always generates the same cluster
+	Seed int64
+}
+
+// Cluster is a synthetic cluster: its nodes, namespaces and pods, and the metric sink populated with the metrics the
+// monitors would have collected for them, ready to be fed into the DTO builders.
+type Cluster struct {
+	Nodes      []*api.Node
+	Namespaces []*api.Namespace
+	Pods       []*api.Pod
+	Sink       *metrics.EntityMetricSink
+	ClusterID  string
+}
+
+// Generate generates the synthetic cluster of the given spec.
+func Generate(spec Spec) (*Cluster, error) {
+	spec, err := spec.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		spec:    spec,
+		random:  rand.New(rand.NewSource(spec.Seed)),
+		cluster: &Cluster{Sink: metrics.NewEntityMetricSink(), ClusterID: fmt.Sprintf("synthetic-%d", spec.Seed)},
+		now:     time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	g.cluster.Sink.AddNewMetricEntries(
+		metrics.NewEntityStateMetric(metrics.ClusterType, "", metrics.Cluster, g.cluster.ClusterID))
+	for i := 0; i < spec.Nodes; i++ {
+		g.cluster.Nodes = append(g.cluster.Nodes, newNode(i, spec))
+	}
+	for i := 0; i < spec.Namespaces; i++ {
+		g.cluster.Namespaces = append(g.cluster.Namespaces, &api.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("namespace-%d", i),
+			UID:  types.UID(fmt.Sprintf("namespace-uid-%d", i)),
+		}})
+	}
+	g.generatePods()
+	g.generateNodeMetrics()
+	return g.cluster, nil
+}
+
+func (spec Spec) withDefaults() (Spec, error) {
+	if spec.Nodes < 1 {
+		return spec, fmt.Errorf("a synthetic cluster needs at least one node, not %d", spec.Nodes)
+	}
+	if spec.Pods < 0 {
+		return spec, fmt.Errorf("invalid number of pods %d", spec.Pods)
+	}
+	if spec.Namespaces < 1 {
+		spec.Namespaces = 1
+	}
+	if spec.MinContainersPerPod < 1 {
+		spec.MinContainersPerPod = 1
+	}
+	if spec.MaxContainersPerPod < spec.MinContainersPerPod {
+		spec.MaxContainersPerPod = spec.MinContainersPerPod
+	}
+	switch spec.Placement {
+	case "":
+		spec.Placement = PlacementUniform
+	case PlacementUniform, PlacementSkewed:
+	default:
+		return spec, fmt.Errorf("unknown placement %q, should be %s or %s", spec.Placement, PlacementUniform,
+			PlacementSkewed)
+	}
+	if spec.MinUsage <= 0 && spec.MaxUsage <= 0 {
+		spec.MinUsage, spec.MaxUsage = 0.1, 0.9
+	}
+	if spec.MinUsage < 0 || spec.MaxUsage > 1 || spec.MaxUsage < spec.MinUsage {
+		return spec, fmt.Errorf("invalid usage range [%v, %v], should be within [0, 1]", spec.MinUsage, spec.MaxUsage)
+	}
+	if spec.NodeCPUMillicores <= 0 {
+		spec.NodeCPUMillicores = defaultNodeCPUMillicores
+	}
+	if spec.NodeMemoryKB <= 0 {
+		spec.NodeMemoryKB = defaultNodeMemoryKB
+	}
+	return spec, nil
+}
+
+// NodeNameUIDMap returns the UIDs of the nodes by their names.
+func (c *Cluster) NodeNameUIDMap() map[string]string {
+	nodeNameUIDMap := make(map[string]string, len(c.Nodes))
+	for _, node := range c.Nodes {
+		nodeNameUIDMap[node.Name] = string(node.UID)
+	}
+	return nodeNameUIDMap
+}
+
+// NamespaceUIDMap returns the UIDs of the namespaces by their names.
+func (c *Cluster) NamespaceUIDMap() map[string]string {
+	namespaceUIDMap := make(map[string]string, len(c.Namespaces))
+	for _, namespace := range c.Namespaces {
+		namespaceUIDMap[namespace.Name] = string(namespace.UID)
+	}
+	return namespaceUIDMap
+}
+
+// NodePods returns the qualified names of the pods of each node by the names of the nodes.
+func (c *Cluster) NodePods() map[string][]string {
+	nodePods := make(map[string][]string, len(c.Nodes))
+	for _, pod := range c.Pods {
+		nodePods[pod.Spec.NodeName] = append(nodePods[pod.Spec.NodeName], util.PodKeyFunc(pod))
+	}
+	return nodePods
+}
+
+// NumContainers returns the number of containers of all the pods.
+func (c *Cluster) NumContainers() int {
+	numContainers := 0
+	for _, pod := range c.Pods {
+		numContainers += len(pod.Spec.Containers)
+	}
+	return numContainers
+}
+
+type generator struct {
+	spec    Spec
+	random  *rand.Rand
+	cluster *Cluster
+	// The timestamp of the used metric points, in milliseconds
+	now int64
+	// The CPU and memory used and requested by the pods of each node
+	nodeUsage map[string]*usage
+}
+
+type usage struct {
+	pods                          int
+	cpuUsed, memoryUsed           float64
+	cpuRequested, memoryRequested float64
+}
+
+func newNode(i int, spec Spec) *api.Node {
+	reserved := 1 / (1 - nodeReservedFraction)
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("node-%d", i),
+			UID:  types.UID(fmt.Sprintf("node-uid-%d", i)),
+			Labels: map[string]string{
+				"kubernetes.io/hostname": fmt.Sprintf("node-%d", i),
+				"kubernetes.io/os":       "linux",
+			},
+		},
+		Status: api.NodeStatus{
+			Capacity: api.ResourceList{
+				api.ResourceCPU:    cpuQuantity(spec.NodeCPUMillicores * reserved),
+				api.ResourceMemory: memoryQuantity(spec.NodeMemoryKB * reserved),
+				api.ResourcePods:   *resource.NewQuantity(defaultNodeMaxPods, resource.DecimalSI),
+			},
+			Allocatable: api.ResourceList{
+				api.ResourceCPU:    cpuQuantity(spec.NodeCPUMillicores),
+				api.ResourceMemory: memoryQuantity(spec.NodeMemoryKB),
+				api.ResourcePods:   *resource.NewQuantity(defaultNodeMaxPods, resource.DecimalSI),
+			},
+			Conditions: []api.NodeCondition{{Type: api.NodeReady, Status: api.ConditionTrue}},
+			Addresses: []api.NodeAddress{{
+				Type:    api.NodeInternalIP,
+				Address: fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff),
+			}},
+			NodeInfo: api.NodeSystemInfo{
+				SystemUUID:      fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+				OperatingSystem: "linux",
+				Architecture:    "amd64",
+				KubeletVersion:  "v1.27.0",
+			},
+		},
+	}
+}
+
+// generatePods generates the pods, placed on the nodes following the placement of the spec, along with the metrics of
+// the pods and their containers.
+func (g *generator) generatePods() {
+	nodes := g.cluster.Nodes
+	var zipf *rand.Zipf
+	if g.spec.Placement == PlacementSkewed && len(nodes) > 1 {
+		zipf = rand.NewZipf(g.random, skewedPlacementExponent, 1, uint64(len(nodes)-1))
+	}
+	g.nodeUsage = make(map[string]*usage, len(nodes))
+	for _, node := range nodes {
+		g.nodeUsage[node.Name] = &usage{}
+	}
+	for i := 0; i < g.spec.Pods; i++ {
+		var node *api.Node
+		if zipf != nil {
+			node = nodes[zipf.Uint64()]
+		} else {
+			node = nodes[g.random.Intn(len(nodes))]
+		}
+		namespace := g.cluster.Namespaces[i%len(g.cluster.Namespaces)]
+		pod := g.newPod(i, namespace.Name, node)
+		g.cluster.Pods = append(g.cluster.Pods, pod)
+		g.generatePodMetrics(pod)
+	}
+}
+
+func (g *generator) newPod(i int, namespace string, node *api.Node) *api.Pod {
+	name := fmt.Sprintf("pod-%d", i)
+	var ownerReferences []metav1.OwnerReference
+	if g.spec.PodsPerController > 0 {
+		controller := fmt.Sprintf("replicaset-%d", i/g.spec.PodsPerController)
+		name = fmt.Sprintf("%s-%d", controller, i%g.spec.PodsPerController)
+		isController := true
+		ownerReferences = []metav1.OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       util.Kind_ReplicaSet,
+			Name:       controller,
+			UID:        types.UID(controller + "-uid"),
+			Controller: &isController,
+		}}
+	}
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			UID:             types.UID(fmt.Sprintf("pod-uid-%d", i)),
+			OwnerReferences: ownerReferences,
+		},
+		Spec: api.PodSpec{NodeName: node.Name},
+		Status: api.PodStatus{
+			Phase:      api.PodRunning,
+			Conditions: []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}},
+			HostIP:     node.Status.Addresses[0].Address,
+			PodIP:      fmt.Sprintf("172.%d.%d.%d", 16+i>>16&0x0f, i>>8&0xff, i&0xff),
+		},
+	}
+	numContainers := g.spec.MinContainersPerPod + g.random.Intn(g.spec.MaxContainersPerPod-g.spec.MinContainersPerPod+1)
+	for j := 0; j < numContainers; j++ {
+		// Requests from 50 millicores and 64MiB, with limits twice the requests
+		cpuRequest := float64(50 * (1 + g.random.Intn(10)))
+		memoryRequest := float64(64 * 1024 * (1 + g.random.Intn(16)))
+		container := api.Container{
+			Name: fmt.Sprintf("container-%d", j),
+			Resources: api.ResourceRequirements{
+				Requests: api.ResourceList{
+					api.ResourceCPU:    cpuQuantity(cpuRequest),
+					api.ResourceMemory: memoryQuantity(memoryRequest),
+				},
+				Limits: api.ResourceList{
+					api.ResourceCPU:    cpuQuantity(2 * cpuRequest),
+					api.ResourceMemory: memoryQuantity(2 * memoryRequest),
+				},
+			},
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, container)
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, api.ContainerStatus{
+			Name:  container.Name,
+			Ready: true,
+		})
+	}
+	return pod
+}
+
+// generatePodMetrics adds the metrics of the pod and its containers to the sink, as the kubelet and cluster monitors
+// and the discovery worker do.
+func (g *generator) generatePodMetrics(pod *api.Pod) {
+	sink := g.cluster.Sink
+	podMId := util.PodMetricIdAPI(pod)
+	nodeUsage := g.nodeUsage[pod.Spec.NodeName]
+	var ownerMetrics func(etype metrics.DiscoveredEntityType, key string)
+	if len(pod.OwnerReferences) > 0 {
+		owner := pod.OwnerReferences[0]
+		ownerMetrics = func(etype metrics.DiscoveredEntityType, key string) {
+			sink.AddNewMetricEntries(
+				metrics.NewEntityStateMetric(etype, key, metrics.Owner, owner.Name),
+				metrics.NewEntityStateMetric(etype, key, metrics.OwnerType, owner.Kind),
+				metrics.NewEntityStateMetric(etype, key, metrics.OwnerUID, string(owner.UID)))
+		}
+	}
+
+	var podCPUUsed, podMemoryUsed float64
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		containerMId := util.ContainerMetricId(podMId, container.Name)
+		cpuLimit, memoryLimit := util.GetCpuAndMemoryValues(container.Resources.Limits)
+		cpuRequest, memoryRequest := util.GetCpuAndMemoryValues(container.Resources.Requests)
+		cpuUsed := cpuLimit * g.usageFraction()
+		memoryUsed := memoryLimit * g.usageFraction()
+		podCPUUsed += cpuUsed
+		podMemoryUsed += memoryUsed
+		sink.AddNewMetricEntries(
+			g.usedMetric(metrics.ContainerType, containerMId, metrics.CPU, cpuUsed),
+			g.usedMetric(metrics.ContainerType, containerMId, metrics.Memory, memoryUsed),
+			g.usedMetric(metrics.ContainerType, containerMId, metrics.CPURequest, cpuUsed),
+			g.usedMetric(metrics.ContainerType, containerMId, metrics.MemoryRequest, memoryUsed),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.CPU, metrics.Capacity, cpuLimit),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.Memory, metrics.Capacity, memoryLimit),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.CPURequest, metrics.Capacity, cpuRequest),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.MemoryRequest, metrics.Capacity, memoryRequest),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.CPULimitQuota, metrics.Used, cpuLimit),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.MemoryLimitQuota, metrics.Used, memoryLimit),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.CPURequestQuota, metrics.Used, cpuRequest),
+			metrics.NewEntityResourceMetric(metrics.ContainerType, containerMId, metrics.MemoryRequestQuota, metrics.Used, memoryRequest))
+		appMId := util.ApplicationMetricId(containerMId)
+		sink.AddNewMetricEntries(
+			g.usedMetric(metrics.ApplicationType, appMId, metrics.CPU, cpuUsed),
+			g.usedMetric(metrics.ApplicationType, appMId, metrics.Memory, memoryUsed))
+		if ownerMetrics != nil {
+			sink.AddNewMetricEntries(
+				metrics.NewEntityStateMetric(metrics.ContainerType, containerMId, metrics.IsInjectedSidecar, false))
+			ownerMetrics(metrics.ContainerType, containerMId)
+		}
+	}
+
+	// The pods can use the whole sellable CPU and memory of their nodes, and request their allocatable CPU and memory
+	requests, limits := util.GetPodComputeResources(pod)
+	cpuRequest, memoryRequest := util.GetCpuAndMemoryValues(requests)
+	cpuLimit, memoryLimit := util.GetCpuAndMemoryValues(limits)
+	sink.AddNewMetricEntries(
+		g.usedMetric(metrics.PodType, podMId, metrics.CPU, podCPUUsed),
+		g.usedMetric(metrics.PodType, podMId, metrics.Memory, podMemoryUsed),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.CPU, metrics.Capacity, g.spec.NodeCPUMillicores),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.Memory, metrics.Capacity, g.spec.NodeMemoryKB),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.CPURequest, metrics.Used, cpuRequest),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.MemoryRequest, metrics.Used, memoryRequest),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.CPURequest, metrics.Capacity, g.spec.NodeCPUMillicores),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.MemoryRequest, metrics.Capacity, g.spec.NodeMemoryKB),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.NumPods, metrics.Used, float64(1)),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.VStorage, metrics.Capacity, float64(podFSCapacityBytes)),
+		metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.VStorage, metrics.Used, podFSCapacityBytes*g.usageFraction()),
+		metrics.NewEntityStateMetric(metrics.PodType, podMId, metrics.MetricsAvailability, true))
+	// The quotas are used by the limits and requests of the pods, and not capped by any resource quota
+	for quotaType, used := range map[metrics.ResourceType]float64{
+		metrics.CPULimitQuota:      cpuLimit,
+		metrics.MemoryLimitQuota:   memoryLimit,
+		metrics.CPURequestQuota:    cpuRequest,
+		metrics.MemoryRequestQuota: memoryRequest,
+	} {
+		sink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(metrics.PodType, podMId, quotaType, metrics.Used, used),
+			metrics.NewEntityResourceMetric(metrics.PodType, podMId, quotaType, metrics.Capacity,
+				repository.DEFAULT_METRIC_CAPACITY_VALUE))
+	}
+	if ownerMetrics != nil {
+		ownerMetrics(metrics.PodType, podMId)
+	}
+
+	nodeUsage.pods++
+	nodeUsage.cpuUsed += podCPUUsed
+	nodeUsage.memoryUsed += podMemoryUsed
+	nodeUsage.cpuRequested += cpuRequest
+	nodeUsage.memoryRequested += memoryRequest
+}
+
+// generateNodeMetrics adds the metrics of the nodes to the sink, their usage being the sum of the usage of their pods.
+func (g *generator) generateNodeMetrics() {
+	sink := g.cluster.Sink
+	for _, node := range g.cluster.Nodes {
+		key := util.NodeKeyFunc(node)
+		nodeUsage := g.nodeUsage[node.Name]
+		cpuCapacity, memoryCapacity := util.GetCpuAndMemoryValues(node.Status.Allocatable)
+		sink.AddNewMetricEntries(
+			metrics.NewEntityStateMetric(metrics.NodeType, key, metrics.CpuFrequency, float64(nodeCPUFrequencyMHz)),
+			g.usedMetric(metrics.NodeType, key, metrics.CPU, nodeUsage.cpuUsed),
+			g.usedMetric(metrics.NodeType, key, metrics.Memory, nodeUsage.memoryUsed),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.CPU, metrics.Capacity, cpuCapacity),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.Memory, metrics.Capacity, memoryCapacity),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.CPURequest, metrics.Used, nodeUsage.cpuRequested),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.MemoryRequest, metrics.Used, nodeUsage.memoryRequested),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.CPURequest, metrics.Capacity, cpuCapacity),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.MemoryRequest, metrics.Capacity, memoryCapacity),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.NumPods, metrics.Used, float64(nodeUsage.pods)),
+			// The skewed placement may put more pods on a node than it can run
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.NumPods, metrics.Capacity,
+				math.Max(defaultNodeMaxPods, float64(nodeUsage.pods))))
+		for _, fsKey := range []string{key, key + "-imagefs"} {
+			sink.AddNewMetricEntries(
+				metrics.NewEntityResourceMetric(metrics.NodeType, fsKey, metrics.VStorage, metrics.Capacity, float64(nodeFSCapacityBytes)),
+				metrics.NewEntityResourceMetric(metrics.NodeType, fsKey, metrics.VStorage, metrics.Available,
+					nodeFSCapacityBytes*(1-g.usageFraction())),
+				metrics.NewEntityResourceMetric(metrics.NodeType, fsKey, metrics.VStorage, metrics.Threshold, float64(nodeFSThresholdPct)))
+		}
+	}
+}
+
+// usageFraction returns a random fraction of usage within the usage range of the spec.
+func (g *generator) usageFraction() float64 {
+	return g.spec.MinUsage + g.random.Float64()*(g.spec.MaxUsage-g.spec.MinUsage)
+}
+
+// usedMetric returns the used metric of a single point, as scraped from the kubelet.
+func (g *generator) usedMetric(etype metrics.DiscoveredEntityType, key string, resourceType metrics.ResourceType,
+	value float64) metrics.EntityResourceMetric {
+	return metrics.NewEntityResourceMetric(etype, key, resourceType, metrics.Used,
+		[]metrics.Point{{Value: value, Timestamp: g.now}})
+}
+
+func cpuQuantity(millicores float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(millicores), resource.DecimalSI)
+}
+
+func memoryQuantity(kiloBytes float64) resource.Quantity {
+	return *resource.NewQuantity(int64(kiloBytes)*1024, resource.BinarySI)
+}
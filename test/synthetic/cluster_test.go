@@ -0,0 +1,89 @@
+package synthetic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+func TestGenerate(t *testing.T) {
+	cluster, err := Generate(Spec{Nodes: 4, Pods: 40, Namespaces: 3, PodsPerController: 5,
+		MinContainersPerPod: 1, MaxContainersPerPod: 3, Seed: 1})
+	assert.NoError(t, err)
+	assert.Len(t, cluster.Nodes, 4)
+	assert.Len(t, cluster.Namespaces, 3)
+	assert.Len(t, cluster.Pods, 40)
+	numPods := 0
+	for _, pods := range cluster.NodePods() {
+		numPods += len(pods)
+	}
+	assert.Equal(t, 40, numPods)
+	for _, pod := range cluster.Pods {
+		assert.True(t, len(pod.Spec.Containers) >= 1 && len(pod.Spec.Containers) <= 3)
+		assert.True(t, util.HasController(pod))
+		assert.Contains(t, cluster.NamespaceUIDMap(), pod.Namespace)
+		assert.Contains(t, cluster.NodeNameUIDMap(), pod.Spec.NodeName)
+		// The usage of the containers is within their limits
+		for _, container := range pod.Spec.Containers {
+			containerMId := util.ContainerMetricId(util.PodMetricIdAPI(pod), container.Name)
+			used, err := cluster.Sink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.ContainerType,
+				containerMId, metrics.CPU, metrics.Used))
+			assert.NoError(t, err)
+			cpuLimit, _ := util.GetCpuAndMemoryValues(container.Resources.Limits)
+			assert.True(t, used.GetValue().([]metrics.Point)[0].Value <= cpuLimit)
+		}
+	}
+
+	// The number of pods of the nodes adds up to the number of pods
+	podsUsed := 0.0
+	for _, node := range cluster.Nodes {
+		used, err := cluster.Sink.GetMetric(metrics.GenerateEntityResourceMetricUID(metrics.NodeType,
+			util.NodeKeyFunc(node), metrics.NumPods, metrics.Used))
+		assert.NoError(t, err)
+		podsUsed += used.GetValue().(float64)
+	}
+	assert.EqualValues(t, 40, podsUsed)
+
+	// The same spec generates the same cluster
+	again, err := Generate(Spec{Nodes: 4, Pods: 40, Namespaces: 3, PodsPerController: 5,
+		MinContainersPerPod: 1, MaxContainersPerPod: 3, Seed: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, cluster.NodePods(), again.NodePods())
+	assert.Equal(t, cluster.NumContainers(), again.NumContainers())
+}
+
+func TestGeneratePlacement(t *testing.T) {
+	busiestNode := func(spec Spec) int {
+		cluster, err := Generate(spec)
+		assert.NoError(t, err)
+		busiest := 0
+		for _, pods := range cluster.NodePods() {
+			if len(pods) > busiest {
+				busiest = len(pods)
+			}
+		}
+		return busiest
+	}
+	// The uniform placement spreads the 1000 pods over the 20 nodes, 50 pods each on average, while the skewed
+	// placement puts most of them on the first nodes
+	assert.Less(t, busiestNode(Spec{Nodes: 20, Pods: 1000, Seed: 1}), 100)
+	assert.Greater(t, busiestNode(Spec{Nodes: 20, Pods: 1000, Placement: PlacementSkewed, Seed: 1}), 200)
+	// A single node hosts all the pods
+	assert.Equal(t, 10, busiestNode(Spec{Nodes: 1, Pods: 10, Placement: PlacementSkewed}))
+}
+
+func TestGenerateInvalidSpec(t *testing.T) {
+	for _, spec := range []Spec{
+		{},
+		{Nodes: 1, Pods: -1},
+		{Nodes: 1, Placement: "random"},
+		{Nodes: 1, MinUsage: 0.5, MaxUsage: 0.2},
+		{Nodes: 1, MaxUsage: 1.5},
+	} {
+		_, err := Generate(spec)
+		assert.Error(t, err, "%+v", spec)
+	}
+}